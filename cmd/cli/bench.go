@@ -4,20 +4,29 @@ import (
 	"fmt"
 	"runtime"
 	"time"
-
-	"github.com/quan-to/chevron/internal/etc/magicbuilder"
 )
 
 // BenchmarkGeneration benchmarks the key generation
 func BenchmarkGeneration(runs, bits int) {
-	pgpMan := magicbuilder.MakePGP(nil, mem)
+	pgpMan := makePGP()
 
 	fmt.Printf("Benchmarking GPG Key Generation with %d bits and %d runs.\n", bits, runs)
-	fmt.Printf("Running on %s-%s\n", runtime.GOOS, runtime.GOARCH)
+	password := ""
+
+	if serverURL != "" {
+		// The server does not generate keys without password
+		password = "benchmark"
+		fmt.Printf("Running on %s\n", serverURL)
+	} else {
+		fmt.Printf("Running on %s-%s\n", runtime.GOOS, runtime.GOARCH)
+	}
 
 	startTime := time.Now()
 	for i := 0; i < runs; i++ {
-		_, _ = pgpMan.GeneratePGPKey(ctx, "", "", bits)
+		_, err := pgpMan.GeneratePGPKey(ctx, "", password, bits)
+		if err != nil {
+			panic(fmt.Sprintf("Error creating key: %s\n", err))
+		}
 	}
 	delta := time.Since(startTime)
 	keyTime := delta.Seconds() / float64(runs)
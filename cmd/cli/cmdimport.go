@@ -8,14 +8,13 @@ import (
 	"os"
 	"strings"
 
-	"github.com/quan-to/chevron/internal/etc/magicbuilder"
 	"github.com/quan-to/chevron/internal/tools"
 )
 
 func ImportKey(filename, keyPassword string, keyPasswordFd int) {
 	var data []byte
 	var err error
-	pgpMan := magicbuilder.MakePGP(nil, mem)
+	pgpMan := makePGP()
 	pgpMan.LoadKeys(ctx)
 
 	if filename == "-" {
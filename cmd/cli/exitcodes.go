@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Exit codes of the sign and verify commands
+//
+// Exit code 2 is skipped since it is used by go when the program panics
+const (
+	// ExitInvalidSignature is returned when the signature does not match the data
+	ExitInvalidSignature = 1
+	// ExitKeyNotFound is returned when the key needed to sign / verify is not in the key backend
+	ExitKeyNotFound = 3
+	// ExitIOError is returned when the input / output files cannot be read / written
+	ExitIOError = 4
+	// ExitFailure is returned on any other error (invalid password, malformed signature, etc)
+	ExitFailure = 5
+)
+
+// cliError is an error with the exit code the CLI should return
+type cliError struct {
+	code int
+	err  error
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func makeCliError(code int, format string, args ...interface{}) *cliError {
+	return &cliError{
+		code: code,
+		err:  fmt.Errorf(format, args...),
+	}
+}
+
+// exitOnError prints the error and exits with its exit code. Does nothing if err is nil
+func exitOnError(err error) {
+	if err == nil {
+		return
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+
+	if e, ok := err.(*cliError); ok {
+		os.Exit(e.code)
+	}
+
+	os.Exit(ExitFailure)
+}
@@ -6,7 +6,7 @@ import (
 	"strings"
 	"syscall"
 
-	"github.com/quan-to/chevron/internal/etc/magicbuilder"
+	"github.com/quan-to/chevron/pkg/interfaces"
 	"github.com/quan-to/chevron/pkg/models"
 
 	"golang.org/x/crypto/ssh/terminal"
@@ -15,21 +15,10 @@ import (
 // ExportKey exports the specified public / secret key
 func ExportKey(name, password string, secret bool) {
 	var err error
-	pgpMan := magicbuilder.MakePGP(nil, mem)
+	pgpMan := makePGP()
 	pgpMan.LoadKeys(ctx)
 
-	// First Search the key
-	keys := pgpMan.GetLoadedKeys()
-
-	var kInfo *models.KeyInfo
-
-	for _, v := range keys {
-		if strings.Contains(v.FingerPrint, strings.ToUpper(name)) || strings.Contains(strings.ToLower(v.Identifier), strings.ToLower(name)) {
-			// Thats our key!
-			kInfo = &v
-			break
-		}
-	}
+	kInfo := findKey(pgpMan, name)
 
 	if kInfo == nil {
 		panic(fmt.Sprintf("Cannot find key with \"%s\"\n", name))
@@ -43,12 +32,10 @@ func ExportKey(name, password string, secret bool) {
 
 	if secret {
 		if password == "" {
-			_, _ = fmt.Fprint(os.Stderr, "Please enter the password: ")
-			bytePassword, err := terminal.ReadPassword(int(syscall.Stdin))
+			password, err = promptPassword()
 			if err != nil {
-				panic(fmt.Sprintf("Error reading password: %s", err))
+				panic(err)
 			}
-			password = string(bytePassword)
 			fmt.Println("")
 		}
 
@@ -68,3 +55,26 @@ func ExportKey(name, password string, secret bool) {
 
 	fmt.Println(strings.Trim(k, "\n"))
 }
+
+// findKey searches a loaded key by its fingerprint or identifier
+func findKey(pgpMan interfaces.PGPManager, name string) *models.KeyInfo {
+	for _, v := range pgpMan.GetLoadedKeys() {
+		if strings.Contains(v.FingerPrint, strings.ToUpper(name)) || strings.Contains(strings.ToLower(v.Identifier), strings.ToLower(name)) {
+			// Thats our key!
+			return &v
+		}
+	}
+
+	return nil
+}
+
+// promptPassword asks the key password in the terminal
+func promptPassword() (string, error) {
+	_, _ = fmt.Fprint(os.Stderr, "Please enter the password: ")
+	bytePassword, err := terminal.ReadPassword(int(syscall.Stdin))
+	if err != nil {
+		return "", fmt.Errorf("Error reading password: %s", err)
+	}
+
+	return string(bytePassword), nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/quan-to/chevron/internal/keymagic"
+	"github.com/quan-to/chevron/pkg/QuantoError"
+	"github.com/quan-to/chevron/pkg/fieldcipher"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// FieldCipherFile encrypts the fields of the JSON object in input for the recipients and writes the packet to output
+func FieldCipherFile(input, output string, recipients []string, options models.FieldCipherInput, signPassword string) error {
+	pgpMan := makePGP()
+	pgpMan.LoadKeys(ctx)
+
+	for _, v := range recipients {
+		// Keys that are not listed are looked up by fingerprint in the key backend
+		if kInfo := findKey(pgpMan, v); kInfo != nil {
+			v = kInfo.FingerPrint
+		}
+		options.Keys = append(options.Keys, v)
+	}
+
+	if options.SignWith != "" {
+		fingerPrint, err := findPrivateKey(pgpMan, options.SignWith, signPassword)
+		if err != nil {
+			return err
+		}
+		options.SignWith = fingerPrint
+	}
+
+	data, err := readInput(input)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, &options.JSON); err != nil {
+		return makeCliError(ExitFailure, "the input is not a JSON object: %s", err)
+	}
+
+	var packet *fieldcipher.CipherPacket
+
+	if rm, ok := pgpMan.(*remotePGPManager); ok {
+		packet, err = rm.FieldCipher(ctx, options)
+	} else {
+		packet, err = keymagic.FieldCipherPacket(ctx, pgpMan, options, func() (*fieldcipher.BlindIndexer, error) {
+			return nil, fmt.Errorf("blind indexes are only available when using a remote server")
+		})
+	}
+
+	if err != nil {
+		return fieldCipherError("encrypting", err)
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Encrypted to %s\n", strings.Join(options.Keys, ", "))
+
+	return writeJSONOutput(output, packet)
+}
+
+// FieldDecipherFile decrypts the fieldcipher packet in input with the key and writes the result to output
+func FieldDecipherFile(input, output, key, password string, partial bool) error {
+	pgpMan := makePGP()
+	pgpMan.LoadKeys(ctx)
+
+	fingerPrint, err := findPrivateKey(pgpMan, key, password)
+	if err != nil {
+		return err
+	}
+
+	data, err := readInput(input)
+	if err != nil {
+		return err
+	}
+
+	options := models.FieldDecipherInput{
+		KeyFingerprint: fingerPrint,
+		Partial:        partial,
+	}
+
+	if err := json.Unmarshal(data, &options); err != nil {
+		return makeCliError(ExitFailure, "the input is not a fieldcipher packet: %s", err)
+	}
+
+	var packet *fieldcipher.DecipherPacket
+
+	if rm, ok := pgpMan.(*remotePGPManager); ok {
+		packet, err = rm.FieldDecipher(ctx, options)
+	} else {
+		packet, err = keymagic.FieldDecipherPacket(ctx, pgpMan, options)
+	}
+
+	if err != nil {
+		return fieldCipherError("decrypting", err)
+	}
+
+	if packet.Signed && !packet.SignatureValid {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: the packet signature is not valid (%s)\n", packet.SignatureError)
+	}
+
+	return writeJSONOutput(output, packet)
+}
+
+// findPrivateKey returns the fingerprint of the private key identified by name, unlocking it if needed
+func findPrivateKey(pgpMan interfaces.PGPManager, name, password string) (string, error) {
+	kInfo := findKey(pgpMan, name)
+
+	if kInfo == nil {
+		return "", makeCliError(ExitKeyNotFound, "cannot find key with \"%s\"", name)
+	}
+
+	if !kInfo.ContainsPrivateKey {
+		return "", makeCliError(ExitKeyNotFound, "the key identified with \"%s\" does not have a private key (found fingerPrint: %s)", name, kInfo.FingerPrint)
+	}
+
+	if err := unlockKey(pgpMan, kInfo.FingerPrint, password); err != nil {
+		return "", err
+	}
+
+	return kInfo.FingerPrint, nil
+}
+
+// fieldCipherError converts a field cipher error to a cliError with the matching exit code
+func fieldCipherError(action string, err error) error {
+	if qe, ok := err.(*QuantoError.ErrorObject); ok && qe.ErrorCode == QuantoError.NotFound {
+		return makeCliError(ExitKeyNotFound, "error %s: %s", action, err)
+	}
+
+	return makeCliError(ExitFailure, "error %s: %s", action, err)
+}
+
+// writeJSONOutput writes the indented JSON of v to the file or stdout if filename is -
+func writeJSONOutput(filename string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return makeCliError(ExitFailure, "error encoding output: %s", err)
+	}
+
+	return writeOutput(filename, append(data, '\n'))
+}
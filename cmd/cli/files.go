@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// readInput reads the whole file or stdin if filename is -
+func readInput(filename string) ([]byte, error) {
+	if filename == "-" {
+		_, _ = fmt.Fprintf(os.Stderr, "Reading from stdin:\n")
+		data, err := ioutil.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, makeCliError(ExitIOError, "error reading stdin: %s", err)
+		}
+		return data, nil
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, makeCliError(ExitIOError, "error reading file %s: %s", filename, err)
+	}
+
+	return data, nil
+}
+
+// writeOutput writes data to the file or stdout if filename is -
+func writeOutput(filename string, data []byte) error {
+	if filename == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return makeCliError(ExitIOError, "error writing to stdout: %s", err)
+		}
+		return nil
+	}
+
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		return makeCliError(ExitIOError, "error writing file %s: %s", filename, err)
+	}
+
+	return nil
+}
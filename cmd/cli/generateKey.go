@@ -6,7 +6,6 @@ import (
 	"os"
 	"syscall"
 
-	"github.com/quan-to/chevron/internal/etc/magicbuilder"
 	"github.com/quan-to/chevron/internal/tools"
 
 	"golang.org/x/crypto/ssh/terminal"
@@ -14,7 +13,7 @@ import (
 
 // GenerateFlow generates a GPG Key with specified parameters
 func GenerateFlow(password, output, identifier string, bits int) {
-	pgpMan := magicbuilder.MakePGP(nil, mem)
+	pgpMan := makePGP()
 	if password == "" {
 		_, _ = fmt.Fprint(os.Stderr, "Please enter the password: ")
 		bytePassword, err := terminal.ReadPassword(int(syscall.Stdin))
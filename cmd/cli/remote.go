@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quan-to/chevron/internal/etc/magicbuilder"
+	"github.com/quan-to/chevron/pkg/QuantoError"
+	"github.com/quan-to/chevron/pkg/fieldcipher"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	pgperrors "github.com/quan-to/chevron/pkg/openpgp/errors"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+	"github.com/quan-to/slog"
+)
+
+// TokenHeader is the header used to send the agent token to the remote server
+const TokenHeader = "proxyToken"
+
+// errRemotePrivateKey is returned by operations that need the private key material, which never leaves the remote server
+var errRemotePrivateKey = errors.New("private keys are not available when using a remote server")
+
+// remotePGPManager is a PGPManager that calls the /gpg, /keyRing and /sks APIs of a running Chevron server
+//
+// Keys loaded with LoadKey are kept in a local memory only PGPManager, so they can be inspected before being sent with SaveKey
+type remotePGPManager struct {
+	interfaces.PGPManager
+	sync.Mutex
+	log         slog.Instance
+	server      string
+	token       string
+	client      *http.Client
+	privateKeys []models.KeyInfo
+	cachedKeys  []models.KeyInfo
+}
+
+// MakeRemotePGPManager creates a PGPManager that runs every operation in the Chevron server at serverURL
+//
+// The token is sent in the proxyToken header of every request if not empty. Requests taking more than timeout are canceled
+func MakeRemotePGPManager(log slog.Instance, serverURL, token string, timeout time.Duration, dbHandler magicbuilder.DatabaseHandler) interfaces.PGPManager {
+	if log == nil {
+		log = slog.Scope("RemotePGP")
+	} else {
+		log = log.SubScope("RemotePGP")
+	}
+
+	return &remotePGPManager{
+		PGPManager:  magicbuilder.MakeVoidPGP(log, dbHandler),
+		log:         log,
+		server:      strings.TrimRight(serverURL, "/"),
+		token:       token,
+		client:      &http.Client{Timeout: timeout},
+		privateKeys: make([]models.KeyInfo, 0),
+		cachedKeys:  make([]models.KeyInfo, 0),
+	}
+}
+
+// do runs a request against the remote server and returns the response body. Non 200 responses are returned as *QuantoError.ErrorObject
+func (rm *remotePGPManager) do(method, path string, query url.Values, body interface{}) ([]byte, error) {
+	u := rm.server + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(data)
+	} else {
+		reqBody = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, u, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", models.MimeJSON)
+		req.Header.Set("Accept", models.MimeJSON)
+	}
+
+	if rm.token != "" {
+		req.Header.Set(TokenHeader, rm.token)
+	}
+
+	res, err := rm.client.Do(req)
+	if err != nil {
+		return nil, makeCliError(ExitIOError, "error calling %s: %s", u, err)
+	}
+
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, makeCliError(ExitIOError, "error reading response of %s: %s", u, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		var errObj QuantoError.ErrorObject
+		if json.Unmarshal(data, &errObj) != nil || errObj.Message == "" {
+			return nil, fmt.Errorf("remote server returned %s", res.Status)
+		}
+		return nil, &errObj
+	}
+
+	return data, nil
+}
+
+func (rm *remotePGPManager) getKeyInfos(path string) ([]models.KeyInfo, error) {
+	data, err := rm.do("GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]models.KeyInfo, 0)
+	err = json.Unmarshal(data, &keys)
+
+	return keys, err
+}
+
+// LoadKeys fetches the private and cached keys of the remote server
+func (rm *remotePGPManager) LoadKeys(ctx context.Context) {
+	privateKeys, err := rm.getKeyInfos("/keyRing/privateKeys")
+	if err != nil {
+		rm.log.Error("Error fetching private keys from %s: %s", rm.server, err)
+		return
+	}
+
+	cachedKeys, err := rm.getKeyInfos("/keyRing/cachedKeys")
+	if err != nil {
+		rm.log.Error("Error fetching cached keys from %s: %s", rm.server, err)
+		return
+	}
+
+	rm.Lock()
+	rm.privateKeys = privateKeys
+	rm.cachedKeys = cachedKeys
+	rm.Unlock()
+}
+
+// IsKeyLocked returns if the key is locked in the remote server, as seen in the last LoadKeys
+func (rm *remotePGPManager) IsKeyLocked(fingerPrint string) bool {
+	rm.Lock()
+	defer rm.Unlock()
+
+	for _, v := range rm.privateKeys {
+		if strings.HasSuffix(v.FingerPrint, fingerPrint) || strings.HasSuffix(fingerPrint, v.FingerPrint) {
+			return !v.PrivateKeyIsDecrypted
+		}
+	}
+
+	return false
+}
+
+// UnlockKey unlocks the key in the remote server
+func (rm *remotePGPManager) UnlockKey(ctx context.Context, fingerPrint, password string) error {
+	_, err := rm.do("POST", "/gpg/unlockKey", nil, models.GPGUnlockKeyData{
+		FingerPrint: fingerPrint,
+		Password:    password,
+	})
+
+	return err
+}
+
+// GetLoadedPrivateKeys returns the private keys of the remote server
+func (rm *remotePGPManager) GetLoadedPrivateKeys(ctx context.Context) []models.KeyInfo {
+	rm.Lock()
+	defer rm.Unlock()
+
+	return append([]models.KeyInfo{}, rm.privateKeys...)
+}
+
+// GetCachedKeys returns the cached public keys of the remote server
+func (rm *remotePGPManager) GetCachedKeys(ctx context.Context) []models.KeyInfo {
+	rm.Lock()
+	defer rm.Unlock()
+
+	return append([]models.KeyInfo{}, rm.cachedKeys...)
+}
+
+// GetLoadedKeys returns the private keys and cached public keys of the remote server
+func (rm *remotePGPManager) GetLoadedKeys() []models.KeyInfo {
+	rm.Lock()
+	defer rm.Unlock()
+
+	keys := append([]models.KeyInfo{}, rm.privateKeys...)
+	for _, v := range rm.cachedKeys {
+		found := false
+		for _, k := range rm.privateKeys {
+			if k.FingerPrint == v.FingerPrint {
+				found = true
+				break
+			}
+		}
+		if !found {
+			keys = append(keys, v)
+		}
+	}
+
+	return keys
+}
+
+// SaveKey sends the key to the remote server. Private keys are added to the key ring and saved in the server key backend
+func (rm *remotePGPManager) SaveKey(fingerPrint, armoredData string, password interface{}) error {
+	if strings.Contains(armoredData, "PRIVATE KEY") {
+		_, err := rm.do("POST", "/keyRing/addPrivateKey", nil, models.KeyRingAddPrivateKeyData{
+			EncryptedPrivateKey: armoredData,
+			SaveToDisk:          true,
+			Password:            password,
+		})
+		return err
+	}
+
+	_, err := rm.do("POST", "/sks/addKey", nil, models.SKSAddKey{
+		PublicKey: armoredData,
+	})
+
+	return err
+}
+
+// DeleteKey removes the private key from the remote server
+func (rm *remotePGPManager) DeleteKey(ctx context.Context, fingerPrint string) error {
+	_, err := rm.do("POST", "/keyRing/deletePrivateKey", nil, models.KeyRingDeletePrivateKeyData{
+		FingerPrint: fingerPrint,
+	})
+
+	return err
+}
+
+// SignData signs the data in the remote server. The remote server always uses SHA512
+func (rm *remotePGPManager) SignData(ctx context.Context, fingerPrint string, data []byte, hashAlgorithm crypto.Hash) (string, error) {
+	return rm.SignDataWithOptions(ctx, fingerPrint, data, hashAlgorithm, models.SignatureOptions{})
+}
+
+// SignDataWithOptions signs the data in the remote server with the signature options. The remote server always uses SHA512
+func (rm *remotePGPManager) SignDataWithOptions(ctx context.Context, fingerPrint string, data []byte, hashAlgorithm crypto.Hash, options models.SignatureOptions) (string, error) {
+	if hashAlgorithm != crypto.SHA512 {
+		return "", fmt.Errorf("the remote server only signs with SHA512")
+	}
+
+	signature, err := rm.do("POST", "/gpg/sign", nil, models.GPGSignData{
+		FingerPrint:      fingerPrint,
+		Base64Data:       base64.StdEncoding.EncodeToString(data),
+		SignatureOptions: options,
+	})
+
+	return string(signature), err
+}
+
+// VerifySignature verifies the signature in the remote server. Signatures rejected by the server are returned as errors.SignatureError
+func (rm *remotePGPManager) VerifySignature(ctx context.Context, data []byte, signature string) (*models.GPGVerifySignatureResult, error) {
+	body, err := rm.do("POST", "/gpg/verifySignature", nil, models.GPGVerifySignatureData{
+		Base64Data: base64.StdEncoding.EncodeToString(data),
+		Signature:  signature,
+	})
+
+	if err != nil {
+		if qe, ok := err.(*QuantoError.ErrorObject); ok && qe.ErrorCode == QuantoError.InvalidFieldData && qe.ErrorField == "Signature" {
+			return nil, pgperrors.SignatureError(qe.Message)
+		}
+		return nil, err
+	}
+
+	result := &models.GPGVerifySignatureResult{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, fmt.Errorf("invalid verification result from %s: %s", rm.server, err)
+	}
+
+	return result, nil
+}
+
+// VerifySignatureStringData verifies the signature of data in string format in the remote server
+func (rm *remotePGPManager) VerifySignatureStringData(ctx context.Context, data string, signature string) (*models.GPGVerifySignatureResult, error) {
+	return rm.VerifySignature(ctx, []byte(data), signature)
+}
+
+// GetPublicKeyASCII returns the public key of a locally loaded key or fetches it from the remote server
+func (rm *remotePGPManager) GetPublicKeyASCII(ctx context.Context, fingerPrint string) (string, error) {
+	if key, err := rm.PGPManager.GetPublicKeyASCII(ctx, fingerPrint); err == nil && key != "" {
+		return key, nil
+	}
+
+	key, err := rm.do("GET", "/sks/getKey", url.Values{"fingerPrint": {fingerPrint}}, nil)
+
+	return string(key), err
+}
+
+// GetPublicKeyEntity returns the public key entity fetched with GetPublicKeyASCII
+func (rm *remotePGPManager) GetPublicKeyEntity(ctx context.Context, fingerPrint string) *openpgp.Entity {
+	key, err := rm.GetPublicKeyASCII(ctx, fingerPrint)
+	if err != nil {
+		return nil
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	if err != nil || len(entities) == 0 {
+		return nil
+	}
+
+	return entities[0]
+}
+
+// GetPublicKey returns the primary public key fetched with GetPublicKeyASCII
+func (rm *remotePGPManager) GetPublicKey(ctx context.Context, fingerPrint string) *packet.PublicKey {
+	ent := rm.GetPublicKeyEntity(ctx, fingerPrint)
+	if ent == nil {
+		return nil
+	}
+
+	return ent.PrimaryKey
+}
+
+// GetPrivateKeyASCII returns a locally loaded private key. Private keys of the remote server cannot be exported
+func (rm *remotePGPManager) GetPrivateKeyASCII(ctx context.Context, fingerPrint, password string) (string, error) {
+	if rm.PGPManager.GetPrivateKeyInfo(ctx, fingerPrint) == nil {
+		return "", errRemotePrivateKey
+	}
+
+	return rm.PGPManager.GetPrivateKeyASCII(ctx, fingerPrint, password)
+}
+
+// GeneratePGPKey generates a key in the remote server
+func (rm *remotePGPManager) GeneratePGPKey(ctx context.Context, identifier, password string, numBits int) (string, error) {
+	key, err := rm.do("POST", "/gpg/generateKey", nil, models.GPGGenerateKeyData{
+		Identifier: identifier,
+		Password:   password,
+		Bits:       numBits,
+	})
+
+	return string(key), err
+}
+
+// Encrypt encrypts the data in the remote server
+func (rm *remotePGPManager) Encrypt(ctx context.Context, filename, fingerPrint string, data []byte, dataOnly bool) (string, error) {
+	encrypted, err := rm.do("POST", "/gpg/encrypt", nil, models.GPGEncryptData{
+		FingerPrint: fingerPrint,
+		Base64Data:  base64.StdEncoding.EncodeToString(data),
+		Filename:    filename,
+		DataOnly:    dataOnly,
+	})
+
+	return string(encrypted), err
+}
+
+// Decrypt decrypts the data in the remote server
+func (rm *remotePGPManager) Decrypt(ctx context.Context, data string, dataOnly bool) (*models.GPGDecryptedData, error) {
+	res, err := rm.do("POST", "/gpg/decrypt", nil, models.GPGDecryptData{
+		AsciiArmoredData: data,
+		DataOnly:         dataOnly,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var decrypted models.GPGDecryptedData
+	if err := json.Unmarshal(res, &decrypted); err != nil {
+		return nil, err
+	}
+
+	return &decrypted, nil
+}
+
+// FieldCipher encrypts the JSON fields of input in the remote server
+func (rm *remotePGPManager) FieldCipher(ctx context.Context, input models.FieldCipherInput) (*fieldcipher.CipherPacket, error) {
+	res, err := rm.do("POST", "/fieldCipher/cipher", nil, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var packet fieldcipher.CipherPacket
+	if err := json.Unmarshal(res, &packet); err != nil {
+		return nil, err
+	}
+
+	return &packet, nil
+}
+
+// FieldDecipher decrypts a fieldcipher packet in the remote server
+func (rm *remotePGPManager) FieldDecipher(ctx context.Context, input models.FieldDecipherInput) (*fieldcipher.DecipherPacket, error) {
+	res, err := rm.do("POST", "/fieldCipher/decipher", nil, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var packet fieldcipher.DecipherPacket
+	if err := json.Unmarshal(res, &packet); err != nil {
+		return nil, err
+	}
+
+	return &packet, nil
+}
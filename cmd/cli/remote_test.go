@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/etc/magicbuilder"
+	"github.com/quan-to/chevron/internal/keymagic"
+	"github.com/quan-to/chevron/internal/server"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/database/memory"
+	"github.com/quan-to/chevron/pkg/fieldcipher"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/test"
+	"github.com/quan-to/slog"
+)
+
+// startRemoteServer starts a Chevron server with the test key unlocked and points the CLI to it
+func startRemoteServer(t *testing.T, dir string) (*httptest.Server, *string) {
+	t.Helper()
+
+	config.PrivateKeyFolder = dir
+	config.MasterGPGKeyBase64Encoded = false
+	config.MasterGPGKeyPath = "../../test/data/testkey_privateTestKey.gpg"
+	config.MasterGPGKeyPasswordPath = "../../test/data/testprivatekeyPassword.txt"
+
+	dbh := memory.MakeMemoryDBDriver(nil)
+	serverCtx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, dbh)
+
+	sm := magicbuilder.MakeSM(nil, dbh)
+	gpg := magicbuilder.MakePGP(nil, dbh)
+
+	key, err := ioutil.ReadFile("../../test/data/testkey_privateTestKey.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := gpg.LoadKey(serverCtx, string(key)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gpg.UnlockKey(serverCtx, test.TestKeyFingerprint, test.TestKeyPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	pubKey, _ := gpg.GetPublicKeyASCII(serverCtx, test.TestKeyFingerprint)
+	keymagic.PKSAdd(serverCtx, pubKey)
+
+	router := server.GenRemoteSignerServerMux(slog.Scope("TestServer"), sm, gpg, dbh)
+	token := new(string)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*token = r.Header.Get(TokenHeader)
+		router.ServeHTTP(w, r)
+	}))
+
+	// The CLI side starts without any local key
+	mem = memory.MakeMemoryDBDriver(nil)
+	ctx = context.WithValue(context.Background(), tools.CtxDatabaseHandler, mem)
+	serverURL = ts.URL
+	serverToken = "huebr"
+
+	return ts, token
+}
+
+func TestRemoteSignVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chevron-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts, token := startRemoteServer(t, dir)
+	defer ts.Close()
+	defer func() { serverURL = "" }()
+
+	input := writeTempFile(t, dir, "data.txt", test.TestSignatureData)
+	tampered := writeTempFile(t, dir, "tampered.txt", test.TestSignatureData+"huebr")
+
+	for _, format := range []string{SignFormatArmor, SignFormatBinary, SignFormatQuanto} {
+		signature := path.Join(dir, "signature."+format)
+
+		if err := SignFile(input, signature, test.TestKeyFingerprint, "", format); err != nil {
+			t.Fatalf("%s: expected no error signing, got %s", format, err)
+		}
+
+		if err := VerifyFile(input, signature, ""); err != nil {
+			t.Errorf("%s: expected signature to be valid, got %s", format, err)
+		}
+
+		if code := exitCode(VerifyFile(tampered, signature, "")); code != ExitInvalidSignature {
+			t.Errorf("%s: expected exit code %d for tampered data, got %d", format, ExitInvalidSignature, code)
+		}
+	}
+
+	if *token != "huebr" {
+		t.Errorf("Expected token huebr to be sent to the server, got %q", *token)
+	}
+
+	if code := exitCode(SignFile(input, path.Join(dir, "data.asc"), test.TestKeyFingerprint, "", SignFormatClearSign)); code != ExitFailure {
+		t.Errorf("Expected exit code %d for clear signing in a remote server, got %d", ExitFailure, code)
+	}
+
+	if code := exitCode(SignFile(input, path.Join(dir, "data.sig"), "huebrhuebrhuebr", "", SignFormatArmor)); code != ExitKeyNotFound {
+		t.Errorf("Expected exit code %d for unknown signer, got %d", ExitKeyNotFound, code)
+	}
+}
+
+func TestRemoteEncryptDecrypt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chevron-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts, _ := startRemoteServer(t, dir)
+	defer ts.Close()
+	defer func() { serverURL = "" }()
+
+	input := writeTempFile(t, dir, "data.txt", test.TestSignatureData)
+	encrypted := path.Join(dir, "data.txt.gpg")
+	decrypted := path.Join(dir, "decrypted.txt")
+
+	EncryptFile(input, encrypted, test.TestKeyFingerprint)
+	Decrypt(encrypted, decrypted)
+
+	data, err := ioutil.ReadFile(decrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != test.TestSignatureData {
+		t.Errorf("Expected decrypted data %q got %q", test.TestSignatureData, string(data))
+	}
+
+	pgpMan := makePGP()
+	pgpMan.LoadKeys(ctx)
+
+	if findKey(pgpMan, test.TestKeyFingerprint) == nil {
+		t.Errorf("Expected key %s to be listed by the remote server", test.TestKeyFingerprint)
+	}
+}
+
+func TestRemoteVerifyFailsClosed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer ts.Close()
+
+	pgpMan := MakeRemotePGPManager(nil, ts.URL, "", time.Second, memory.MakeMemoryDBDriver(nil))
+
+	result, err := pgpMan.VerifySignature(context.Background(), []byte(test.TestSignatureData), test.TestSignatureSignature)
+	if err == nil {
+		t.Fatalf("Expected an error for a response without the verification result, got %+v", result)
+	}
+}
+
+func TestRemoteFieldCipher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chevron-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ts, _ := startRemoteServer(t, dir)
+	defer ts.Close()
+	defer func() { serverURL = "" }()
+
+	input := writeTempFile(t, dir, "data.json", `{"name":"huebr","age":42}`)
+	encrypted := path.Join(dir, "data.packet.json")
+	decrypted := path.Join(dir, "decrypted.json")
+
+	options := models.FieldCipherInput{SignWith: test.TestKeyFingerprint}
+
+	if err := FieldCipherFile(input, encrypted, []string{test.TestKeyFingerprint}, options, ""); err != nil {
+		t.Fatalf("Expected no error encrypting, got %s", err)
+	}
+
+	if err := FieldDecipherFile(encrypted, decrypted, test.TestKeyFingerprint, "", false); err != nil {
+		t.Fatalf("Expected no error decrypting, got %s", err)
+	}
+
+	data, err := ioutil.ReadFile(decrypted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dec fieldcipher.DecipherPacket
+	if err := json.Unmarshal(data, &dec); err != nil {
+		t.Fatal(err)
+	}
+
+	if dec.DecryptedData["name"] != "huebr" {
+		t.Errorf("Expected name huebr got %v", dec.DecryptedData["name"])
+	}
+
+	if !dec.SignatureValid {
+		t.Errorf("Expected packet signature to be valid: %s", dec.SignatureError)
+	}
+
+	if code := exitCode(FieldCipherFile(input, encrypted, []string{"huebrhuebrhuebr"}, models.FieldCipherInput{}, "")); code != ExitKeyNotFound {
+		t.Errorf("Expected exit code %d for unknown recipient, got %d", ExitKeyNotFound, code)
+	}
+}
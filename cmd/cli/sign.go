@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/openpgp/armor"
+	"github.com/quan-to/chevron/pkg/openpgp/clearsign"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+// Signature formats of the sign command
+const (
+	SignFormatArmor     = "armor"
+	SignFormatBinary    = "binary"
+	SignFormatClearSign = "clearsign"
+	SignFormatQuanto    = "quanto"
+)
+
+// SignFormats is the list of formats accepted by the sign command
+var SignFormats = []string{SignFormatArmor, SignFormatBinary, SignFormatClearSign, SignFormatQuanto}
+
+// unlockKey unlocks the private key if it is locked, prompting the password if not provided
+func unlockKey(pgpMan interfaces.PGPManager, fingerPrint, password string) error {
+	if !pgpMan.IsKeyLocked(fingerPrint) {
+		return nil
+	}
+
+	if password == "" {
+		var err error
+		password, err = promptPassword()
+		if err != nil {
+			return makeCliError(ExitIOError, "%s", err)
+		}
+		_, _ = fmt.Fprintln(os.Stderr, "")
+	}
+
+	if err := pgpMan.UnlockKey(ctx, fingerPrint, password); err != nil {
+		return makeCliError(ExitFailure, "cannot unlock key %s: %s", fingerPrint, err)
+	}
+
+	return nil
+}
+
+// SignFile signs a file / data from input with the signer key and writes the signature in the specified format to output
+func SignFile(input, output, signer, password, format string) error {
+	pgpMan := makePGP()
+	pgpMan.LoadKeys(ctx)
+
+	kInfo := findKey(pgpMan, signer)
+
+	if kInfo == nil {
+		return makeCliError(ExitKeyNotFound, "cannot find key with \"%s\"", signer)
+	}
+
+	if !kInfo.ContainsPrivateKey {
+		return makeCliError(ExitKeyNotFound, "the key identified with \"%s\" does not have a private key (found fingerPrint: %s)", signer, kInfo.FingerPrint)
+	}
+
+	fingerPrint := kInfo.FingerPrint
+
+	if err := unlockKey(pgpMan, fingerPrint, password); err != nil {
+		return err
+	}
+
+	data, err := readInput(input)
+	if err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Signing with %s\n", fingerPrint)
+
+	var signature []byte
+
+	if format == SignFormatClearSign {
+		signature, err = clearSign(pgpMan, fingerPrint, data)
+	} else {
+		signature, err = detachSign(pgpMan, fingerPrint, data, format)
+	}
+
+	if err != nil {
+		if _, ok := err.(*cliError); ok {
+			return err
+		}
+		return makeCliError(ExitFailure, "error signing data: %s", err)
+	}
+
+	return writeOutput(output, signature)
+}
+
+// detachSign creates a detached signature of data in the armor, binary or quanto formats
+func detachSign(pgpMan interfaces.PGPManager, fingerPrint string, data []byte, format string) ([]byte, error) {
+	signature, err := pgpMan.SignData(ctx, fingerPrint, data, crypto.SHA512)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case SignFormatQuanto:
+		return []byte(tools.GPG2Quanto(signature, fingerPrint, "SHA512")), nil
+	case SignFormatBinary:
+		block, err := armor.Decode(strings.NewReader(signature))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(block.Body)
+	}
+
+	return []byte(signature), nil
+}
+
+// clearSign creates a clear signed message of data
+func clearSign(pgpMan interfaces.PGPManager, fingerPrint string, data []byte) ([]byte, error) {
+	keys := pgpMan.GetPrivate(ctx, fingerPrint)
+
+	if len(keys) == 0 {
+		if serverURL != "" {
+			// Clear signing needs the private key, which never leaves the server
+			return nil, errRemotePrivateKey
+		}
+		return nil, fmt.Errorf("key %s is not decrypt or not loaded", fingerPrint)
+	}
+
+	// The master key is always the last one
+	privateKey := keys[len(keys)-1].PrivateKey
+
+	var b bytes.Buffer
+
+	w, err := clearsign.Encode(&b, privateKey, &packet.Config{DefaultHash: crypto.SHA512})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+
+	b.WriteByte('\n')
+
+	return b.Bytes(), nil
+}
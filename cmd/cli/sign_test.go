@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/database/memory"
+	"github.com/quan-to/chevron/test"
+)
+
+// setupKeyFolder creates a key folder with the test private key imported
+func setupKeyFolder(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "chevron-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.PrivateKeyFolder = dir
+	mem = memory.MakeMemoryDBDriver(nil)
+	ctx = context.WithValue(context.Background(), tools.CtxDatabaseHandler, mem)
+
+	ImportKey("../../test/data/testkey_privateTestKey.gpg", test.TestKeyPassword, -1)
+
+	return dir
+}
+
+func writeTempFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+
+	filename := path.Join(dir, name)
+	if err := ioutil.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return filename
+}
+
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if e, ok := err.(*cliError); ok {
+		return e.code
+	}
+
+	return -1
+}
+
+func TestSignVerify(t *testing.T) {
+	dir := setupKeyFolder(t)
+	defer os.RemoveAll(dir)
+
+	input := writeTempFile(t, dir, "data.txt", test.TestSignatureData)
+	tampered := writeTempFile(t, dir, "tampered.txt", test.TestSignatureData+"huebr")
+
+	for _, format := range []string{SignFormatArmor, SignFormatBinary, SignFormatQuanto} {
+		signature := path.Join(dir, "signature."+format)
+
+		if err := SignFile(input, signature, test.TestKeyFingerprint, "", format); err != nil {
+			t.Fatalf("%s: expected no error signing, got %s", format, err)
+		}
+
+		if err := VerifyFile(input, signature, ""); err != nil {
+			t.Errorf("%s: expected signature to be valid, got %s", format, err)
+		}
+
+		if code := exitCode(VerifyFile(tampered, signature, "")); code != ExitInvalidSignature {
+			t.Errorf("%s: expected exit code %d for tampered data, got %d", format, ExitInvalidSignature, code)
+		}
+	}
+}
+
+func TestSignVerifyClearSign(t *testing.T) {
+	dir := setupKeyFolder(t)
+	defer os.RemoveAll(dir)
+
+	data := "-----huebr\nline with trailing spaces   \n" + test.TestSignatureData
+	input := writeTempFile(t, dir, "data.txt", data)
+	signed := path.Join(dir, "data.txt.asc")
+	output := path.Join(dir, "output.txt")
+
+	if err := SignFile(input, signed, test.TestKeyName, "", SignFormatClearSign); err != nil {
+		t.Fatalf("Expected no error signing, got %s", err)
+	}
+
+	if err := VerifyFile(signed, "", output); err != nil {
+		t.Fatalf("Expected clear signed message to be valid, got %s", err)
+	}
+
+	content, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(content) == 0 {
+		t.Error("Expected the clear signed content to be written to output")
+	}
+
+	if code := exitCode(VerifyFile(input, "", "")); code != ExitFailure {
+		t.Errorf("Expected exit code %d for a message that is not clear signed, got %d", ExitFailure, code)
+	}
+}
+
+func TestSignVerifyErrors(t *testing.T) {
+	dir := setupKeyFolder(t)
+	defer os.RemoveAll(dir)
+
+	input := writeTempFile(t, dir, "data.txt", test.TestSignatureData)
+	signature := path.Join(dir, "data.txt.sig")
+
+	if code := exitCode(SignFile(input, signature, "huebrhuebrhuebr", "", SignFormatArmor)); code != ExitKeyNotFound {
+		t.Errorf("Expected exit code %d for unknown signer, got %d", ExitKeyNotFound, code)
+	}
+
+	if code := exitCode(SignFile(path.Join(dir, "missing.txt"), signature, test.TestKeyFingerprint, "", SignFormatArmor)); code != ExitIOError {
+		t.Errorf("Expected exit code %d for missing input, got %d", ExitIOError, code)
+	}
+
+	if err := SignFile(input, signature, test.TestKeyFingerprint, "", SignFormatArmor); err != nil {
+		t.Fatalf("Expected no error signing, got %s", err)
+	}
+
+	// A key folder without the signer key
+	emptyDir, err := ioutil.TempDir("", "chevron-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(emptyDir)
+
+	config.PrivateKeyFolder = emptyDir
+	mem = memory.MakeMemoryDBDriver(nil)
+	ctx = context.WithValue(context.Background(), tools.CtxDatabaseHandler, mem)
+
+	if code := exitCode(VerifyFile(input, signature, "")); code != ExitKeyNotFound {
+		t.Errorf("Expected exit code %d for unknown signer key, got %d", ExitKeyNotFound, code)
+	}
+
+	if code := exitCode(VerifyFile("-", "-", "")); code == 0 {
+		t.Error("Expected error when input and signature are both stdin")
+	}
+}
@@ -3,9 +3,13 @@ package main
 import (
 	"context"
 	"os"
+	"time"
 
+	"github.com/quan-to/chevron/internal/etc/magicbuilder"
 	"github.com/quan-to/chevron/internal/tools"
 	"github.com/quan-to/chevron/pkg/database/memory"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
 
 	"github.com/quan-to/slog"
 	"gopkg.in/alecthomas/kingpin.v2"
@@ -14,8 +18,29 @@ import (
 var ctx = context.Background()
 var mem *memory.DbDriver
 
+// serverURL is the Chevron server used by all commands instead of the local key backend, if not empty
+var serverURL string
+
+// serverToken is the agent token sent to serverURL
+var serverToken string
+
+// serverTimeout is the timeout of each request to serverURL
+var serverTimeout = time.Minute
+
+// makePGP creates the PGPManager used by the commands. It uses the remote server if serverURL is set
+func makePGP() interfaces.PGPManager {
+	if serverURL != "" {
+		return MakeRemotePGPManager(nil, serverURL, serverToken, serverTimeout, mem)
+	}
+
+	return magicbuilder.MakePGP(nil, mem)
+}
+
 func main() {
 	debugMode := kingpin.Flag("debug", "Enable debug mode").Bool()
+	kingpin.Flag("server", "URL of a Chevron server to run the commands instead of using the local key folder").Envar("CHEVRON_SERVER").StringVar(&serverURL)
+	kingpin.Flag("token", "Agent token for the Chevron server").Envar("CHEVRON_TOKEN").StringVar(&serverToken)
+	kingpin.Flag("timeout", "Timeout of each request to the Chevron server").Envar("CHEVRON_TIMEOUT").Default("1m").DurationVar(&serverTimeout)
 
 	// region Generate
 	gen := kingpin.Command("gen", "Generate GPG Key")
@@ -64,6 +89,45 @@ func main() {
 	decryptOutput := decrypt.Flag("output", "Filename of the output (use - to stdout)").Default("-").String()
 	// endregion
 
+	// region Sign
+	sign := kingpin.Command("sign", "Sign Data (exit codes: 3 key not found, 4 I/O error, 5 other errors)")
+	signSigner := sign.Arg("signer", "Finger Print or email of the key to sign with").Required().String()
+	signInput := sign.Flag("input", "Filename of the input (use - to stdin)").Default("-").String()
+	signOutput := sign.Flag("output", "Filename of the output (use - to stdout)").Default("-").String()
+	signPassword := sign.Flag("password", "Key Password (if not provided and the key is locked, it will be prompted)").Default("").String()
+	signFormat := sign.Flag("format", "Signature format: armor (detached), binary (detached), clearsign or quanto (detached)").Default(SignFormatArmor).Enum(SignFormats...)
+	// endregion
+
+	// region Verify
+	verify := kingpin.Command("verify", "Verify Signature (exit codes: 1 invalid signature, 3 key not found, 4 I/O error, 5 other errors)")
+	verifyInput := verify.Flag("input", "Filename of the signed data (use - to stdin)").Default("-").String()
+	verifySignature := verify.Flag("signature", "Filename of the detached signature in armor, binary or quanto format (use - to stdin). If not provided, the input should be a clear signed message").Default("").String()
+	verifyOutput := verify.Flag("output", "Filename to write the content of a valid clear signed message (use - to stdout)").Default("").String()
+	// endregion
+
+	// region Field Cipher
+	fieldCipher := kingpin.Command("fieldcipher", "Encrypt the fields of a JSON object (exit codes: 3 key not found, 4 I/O error, 5 other errors)")
+	fieldCipherRecipients := fieldCipher.Arg("recipients", "Finger Prints or emails of who to encrypt for").Required().Strings()
+	fieldCipherInput := fieldCipher.Flag("input", "Filename of the JSON input (use - to stdin)").Default("-").String()
+	fieldCipherOutput := fieldCipher.Flag("output", "Filename of the output packet (use - to stdout)").Default("-").String()
+	fieldCipherSkip := fieldCipher.Flag("skip", "Field to keep in plain text (repeatable)").Strings()
+	fieldCipherInclude := fieldCipher.Flag("include", "JSONPath pattern of the fields to encrypt (repeatable). Every field is encrypted if not provided").Strings()
+	fieldCipherExclude := fieldCipher.Flag("exclude", "JSONPath pattern of the fields to keep in plain text (repeatable)").Strings()
+	fieldCipherBlindIndex := fieldCipher.Flag("blind-index", "JSONPath pattern of the fields to blind index (repeatable). Only available when using a remote server").Strings()
+	fieldCipherSignWith := fieldCipher.Flag("sign-with", "Finger Print or email of the key to sign the packet").Default("").String()
+	fieldCipherPassword := fieldCipher.Flag("password", "Password of the signing key (if not provided and the key is locked, it will be prompted)").Default("").String()
+	fieldCipherVersion := fieldCipher.Flag("format-version", "Field format version (1 or 2)").Default("0").Int()
+	// endregion
+
+	// region Field Decipher
+	fieldDecipher := kingpin.Command("fielddecipher", "Decrypt the fields of a fieldcipher packet (exit codes: 3 key not found, 4 I/O error, 5 other errors)")
+	fieldDecipherKey := fieldDecipher.Arg("key", "Finger Print or email of the key to decrypt with").Required().String()
+	fieldDecipherInput := fieldDecipher.Flag("input", "Filename of the packet (use - to stdin)").Default("-").String()
+	fieldDecipherOutput := fieldDecipher.Flag("output", "Filename of the output (use - to stdout)").Default("-").String()
+	fieldDecipherPassword := fieldDecipher.Flag("password", "Key Password (if not provided and the key is locked, it will be prompted)").Default("").String()
+	fieldDecipherPartial := fieldDecipher.Flag("partial", "Return the fields that could be decrypted and the status of each field instead of failing").Bool()
+	// endregion
+
 	selectedCmd := kingpin.Parse()
 
 	slog.SetDefaultOutput(os.Stderr)
@@ -96,5 +160,20 @@ func main() {
 		ImportKey(*importInput, *keyPassword, *keyPasswordFd)
 	case "decrypt":
 		Decrypt(*decryptInput, *decryptOutput)
+	case "sign":
+		exitOnError(SignFile(*signInput, *signOutput, *signSigner, *signPassword, *signFormat))
+	case "verify":
+		exitOnError(VerifyFile(*verifyInput, *verifySignature, *verifyOutput))
+	case "fieldcipher":
+		exitOnError(FieldCipherFile(*fieldCipherInput, *fieldCipherOutput, *fieldCipherRecipients, models.FieldCipherInput{
+			SkipFields: *fieldCipherSkip,
+			Include:    *fieldCipherInclude,
+			Exclude:    *fieldCipherExclude,
+			BlindIndex: *fieldCipherBlindIndex,
+			SignWith:   *fieldCipherSignWith,
+			Version:    *fieldCipherVersion,
+		}, *fieldCipherPassword))
+	case "fielddecipher":
+		exitOnError(FieldDecipherFile(*fieldDecipherInput, *fieldDecipherOutput, *fieldDecipherKey, *fieldDecipherPassword, *fieldDecipherPartial))
 	}
 }
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/armor"
+	"github.com/quan-to/chevron/pkg/openpgp/clearsign"
+	pgperrors "github.com/quan-to/chevron/pkg/openpgp/errors"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+// VerifyFile verifies the signature of a file / data from input
+//
+// The signature can be ASCII Armored, binary or in Quanto format. If signature is empty, input should be a clear signed message
+// and its content is written to output when the signature is valid (nothing is written if output is empty)
+func VerifyFile(input, signature, output string) error {
+	if input == "-" && signature == "-" {
+		return makeCliError(ExitFailure, "input and signature cannot both be read from stdin")
+	}
+
+	pgpMan := makePGP()
+	pgpMan.LoadKeys(ctx)
+
+	data, err := readInput(input)
+	if err != nil {
+		return err
+	}
+
+	var armoredSignature string
+	var content []byte
+
+	if signature == "" {
+		block, _ := clearsign.Decode(data)
+		if block == nil {
+			return makeCliError(ExitFailure, "input is not a clear signed message and no signature was given")
+		}
+
+		sigData, err := ioutil.ReadAll(block.ArmoredSignature.Body)
+		if err != nil {
+			return makeCliError(ExitFailure, "invalid clear signed message: %s", err)
+		}
+
+		armoredSignature, err = armorSignature(sigData)
+		if err != nil {
+			return makeCliError(ExitFailure, "invalid clear signed message: %s", err)
+		}
+
+		data = block.Bytes
+		content = block.Plaintext
+	} else {
+		sigData, err := readInput(signature)
+		if err != nil {
+			return err
+		}
+
+		armoredSignature, err = toArmoredSignature(sigData)
+		if err != nil {
+			return makeCliError(ExitFailure, "invalid signature file: %s", err)
+		}
+	}
+
+	result, err := pgpMan.VerifySignature(ctx, data, armoredSignature)
+
+	if err != nil {
+		return verifyError(err)
+	}
+
+	if !result.Valid {
+		if result.KeyRevokedAtSigning {
+			return makeCliError(ExitInvalidSignature, "invalid signature: the key was revoked (%s)", result.KeyRevocationReason)
+		}
+		if result.KeyExpiredAtSigning {
+			return makeCliError(ExitInvalidSignature, "invalid signature: the key was expired when the signature was made")
+		}
+		return makeCliError(ExitInvalidSignature, "invalid signature")
+	}
+
+	if !result.CreationTime.IsZero() {
+		_, _ = fmt.Fprintf(os.Stderr, "Signature made %s using %s\n", result.CreationTime.Format(time.RFC1123), result.HashAlgorithm)
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "Good signature from %s\n", signatureIssuer(pgpMan, armoredSignature))
+
+	if result.KeyRevoked {
+		_, _ = fmt.Fprintf(os.Stderr, "Warning: the key was revoked after the signature was made (%s)\n", result.KeyRevocationReason)
+	}
+
+	if content != nil && output != "" {
+		return writeOutput(output, content)
+	}
+
+	return nil
+}
+
+// verifyError converts a VerifySignature error to a cliError with the matching exit code
+func verifyError(err error) error {
+	if _, ok := err.(*cliError); ok {
+		return err
+	}
+
+	if strings.Contains(err.Error(), "cannot find public key") || err == pgperrors.ErrUnknownIssuer {
+		return makeCliError(ExitKeyNotFound, "%s", err)
+	}
+
+	if _, ok := err.(pgperrors.SignatureError); ok {
+		return makeCliError(ExitInvalidSignature, "invalid signature: %s", err)
+	}
+
+	return makeCliError(ExitFailure, "cannot verify signature: %s", err)
+}
+
+// toArmoredSignature converts an ASCII Armored, binary or Quanto signature to ASCII Armored
+func toArmoredSignature(sig []byte) (string, error) {
+	s := strings.TrimSpace(string(sig))
+
+	if strings.Contains(s, "-----BEGIN PGP SIGNATURE-----") {
+		return s, nil
+	}
+
+	if isQuantoSignature(s) {
+		return tools.Quanto2GPG(s), nil
+	}
+
+	return armorSignature(sig)
+}
+
+// isQuantoSignature checks if s is in the FINGERPRINT_HASH_SIGNATURE format
+func isQuantoSignature(s string) bool {
+	for _, c := range s {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+
+	parts := strings.Split(s, "$")
+	if len(parts) != 3 {
+		parts = strings.Split(s, "_")
+	}
+
+	return len(parts) == 3 && len(parts[2]) > 5
+}
+
+// armorSignature encodes a binary signature in ASCII Armored format
+func armorSignature(sig []byte) (string, error) {
+	var b bytes.Buffer
+
+	w, err := armor.Encode(&b, openpgp.SignatureType, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = w.Write(sig); err != nil {
+		return "", err
+	}
+
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// signatureIssuer returns the fingerprint and identifier of the key that made the signature
+func signatureIssuer(pgpMan interfaces.PGPManager, armoredSignature string) string {
+	block, err := armor.Decode(strings.NewReader(tools.SignatureFix(armoredSignature)))
+	if err != nil {
+		return "unknown key"
+	}
+
+	pkt, err := packet.NewReader(block.Body).Next()
+	if err != nil {
+		return "unknown key"
+	}
+
+	fingerPrint := ""
+
+	switch sig := pkt.(type) {
+	case *packet.Signature:
+		if sig.IssuerKeyId != nil {
+			fingerPrint = tools.IssuerKeyIdToFP16(*sig.IssuerKeyId)
+		}
+	case *packet.SignatureV3:
+		fingerPrint = tools.IssuerKeyIdToFP16(sig.IssuerKeyId)
+	}
+
+	if kInfo := findKey(pgpMan, fingerPrint); fingerPrint != "" && kInfo != nil {
+		return fmt.Sprintf("%s (%s)", kInfo.Identifier, fingerPrint)
+	}
+
+	return fingerPrint
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+const (
+	phaseKeys = iota
+	phaseUsers
+	phaseTokens
+	phaseVerify
+	phaseDone
+)
+
+// checkpoint stores the progress of a migration so an interrupted run can be resumed.
+// The counters are the number of records migrated in each phase. The last IDs are the source ID of the last
+// migrated record of each phase, and a resumed run starts after them
+type checkpoint struct {
+	Source         string    `json:"source"`
+	Destination    string    `json:"destination"`
+	Phase          int       `json:"phase"`
+	MigratedKeys   int       `json:"migratedKeys"`
+	MigratedUsers  int       `json:"migratedUsers"`
+	MigratedTokens int       `json:"migratedTokens"`
+	LastKeyID      string    `json:"lastKeyId,omitempty"`
+	LastUserID     string    `json:"lastUserId,omitempty"`
+	LastTokenID    string    `json:"lastTokenId,omitempty"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// loadCheckpoint reads the checkpoint from filename. Returns a empty checkpoint if the file does not exist
+func loadCheckpoint(filename string) (*checkpoint, error) {
+	cp := &checkpoint{}
+
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, cp)
+	if err != nil {
+		return nil, err
+	}
+
+	return cp, nil
+}
+
+// save atomically writes the checkpoint to filename
+func (cp *checkpoint) save(filename string) error {
+	cp.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile := filename + ".tmp"
+
+	err = ioutil.WriteFile(tmpFile, data, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, filename)
+}
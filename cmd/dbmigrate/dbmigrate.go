@@ -1,8 +1,6 @@
 package main
 
 import (
-	"strings"
-
 	"github.com/alecthomas/kong"
 	"github.com/mewkiz/pkg/osutil"
 	"github.com/quan-to/chevron/pkg/models"
@@ -13,13 +11,19 @@ var cli struct {
 	FromConfigFile  string `arg:"" name:"path" help:"JSON Config file for the source database" type:"path"`
 	ToConfigFile    string `arg:"" name:"path" help:"JSON Config file for the destination database" type:"path"`
 	NumParallelKeys int    `arg:"" name:"parallelkeys" help:"Number of parallel keys to fetch at once" type:"int" default:"500"`
+	Checkpoint      string `name:"checkpoint" help:"File to store the migration progress. An interrupted migration is resumed from it" type:"path" default:"dbmigrate.checkpoint.json"`
+	DryRun          bool   `name:"dry-run" help:"Only read the source database and report what would be migrated"`
+	Retries         int    `name:"retries" help:"Number of times a failed write is retried before stopping" default:"3"`
+	SkipVerify      bool   `name:"skip-verify" help:"Do not compare source and destination after the migration"`
 }
 
+// dbSource is the database read by the migration. The cursors return the records ordered by ID
 type dbSource interface {
 	InitCursor() error
 	FinishCursor() error
 	NextGPGKey(key *models.GPGKey) bool
 	NextUser(user *models.User) bool
+	NextUserToken(token *models.UserToken) bool
 	NumGPGKeys() (int, error)
 }
 
@@ -29,6 +33,8 @@ type dbDestination interface {
 	AddUser(um models.User) (string, error)
 	GetUser(username string) (um *models.User, err error)
 	UpdateUser(um models.User) error
+	AddUserToken(ut models.UserToken) (string, error)
+	GetUserToken(token string) (ut *models.UserToken, err error)
 }
 
 type migrateHandler interface {
@@ -61,82 +67,67 @@ func main() {
 	if err != nil {
 		logger.Fatal("Error initializing destination handler: %s", err)
 	}
-	logger.Info("Couting keys...")
-	err = src.InitCursor()
+	cp, err := loadCheckpoint(cli.Checkpoint)
 	if err != nil {
-		logger.Fatal("Error initializing cursor: %s", err)
+		logger.Fatal("Error loading checkpoint %s: %s", cli.Checkpoint, err)
 	}
-	totalKeys, err := src.NumGPGKeys()
-	if err != nil {
-		logger.Fatal("Error getting number of keys: %s", err)
+
+	if cp.Source == "" {
+		cp.Source = cli.FromConfigFile
+		cp.Destination = cli.ToConfigFile
+	} else if cp.Source != cli.FromConfigFile || cp.Destination != cli.ToConfigFile {
+		logger.Fatal("Checkpoint %s belongs to the migration from %s to %s. Remove it to start a new migration", cli.Checkpoint, cp.Source, cp.Destination)
+	} else if cp.Phase == phaseDone {
+		logger.Info("Checkpoint %s says the migration is already done. Remove it to migrate again", cli.Checkpoint)
+		return
+	} else {
+		logger.Info("Resuming migration from checkpoint %s (last update at %s)", cli.Checkpoint, cp.UpdatedAt)
 	}
-	if cli.NumParallelKeys <= 0 {
-		cli.NumParallelKeys = 500
+
+	if cli.DryRun {
+		logger.Warn("Dry run. Nothing will be written to the destination")
 	}
-	logger.Info("Starting migration of %d keys", totalKeys)
-	migratedKeys := 0
-
-	gpgKey := models.GPGKey{}
-	var keys []models.GPGKey
-
-	logger.Info("Fetching <= %d keys from source", cli.NumParallelKeys)
-	for src.NextGPGKey(&gpgKey) {
-		if len(keys) >= cli.NumParallelKeys {
-			logger.Info("Saving %d keys to destination", len(keys))
-			_, _, err = dst.AddGPGKeys(keys)
-			if err != nil {
-				logger.Fatal("error migrating keys: %s", err)
-			}
-			migratedKeys += len(keys)
-			logger.Info("Migrated %6d from %6d keys... [%d]", migratedKeys, totalKeys, len(keys))
-			keys = nil
-			logger.Info("Fetching <= %d keys from source", cli.NumParallelKeys)
-		}
-		gpgKey.ID = "" // Re-generate the ID
-		keys = append(keys, gpgKey)
+
+	m := &migrator{
+		src:            src,
+		dst:            dst,
+		log:            logger,
+		batchSize:      cli.NumParallelKeys,
+		retries:        cli.Retries,
+		dryRun:         cli.DryRun,
+		checkpointFile: cli.Checkpoint,
+		cp:             cp,
 	}
 
-	if len(keys) > 0 {
-		logger.Info("Saving %d keys to destination", len(keys))
-		_, _, err = dst.AddGPGKeys(keys)
-		if err != nil {
-			logger.Fatal("error migrating keys: %s", err)
-		}
-		migratedKeys += len(keys)
+	err = m.run()
+	if err != nil {
+		logger.Fatal("Migration stopped: %s. Run it again to resume from checkpoint %s", err, cli.Checkpoint)
+	}
+
+	if cli.DryRun {
+		logger.Info("Dry run finished: %d keys, %d users and %d user tokens would be migrated", cp.MigratedKeys, cp.MigratedUsers, cp.MigratedTokens)
+		return
 	}
 
-	logger.Info("Migrated %d keys...", migratedKeys)
-	logger.Info("Migrating users...")
-
-	user := models.User{}
-
-	migratedUsers := 0
-
-	for src.NextUser(&user) {
-		user.ID = "" // Re-generate the ID
-		_, err := dst.AddUser(user)
-		if err != nil && strings.EqualFold("already exists", err.Error()) {
-			logger.Info("User %s already exists. Updating it...", user.Username)
-			oldUser, _ := dst.GetUser(user.Username)
-			user.ID = oldUser.ID
-			err := dst.UpdateUser(user)
-			if err != nil {
-				logger.Fatal("error migrating user %s: %s", user.Username, err)
-			}
-			// Update user TODO
-		} else if err != nil {
-			logger.Fatal("error migrating user %s: %s", user.Username, err)
+	if !cli.SkipVerify {
+		logger.Info("Verifying migration...")
+		report, err := verify(src, dst, logger)
+		if err != nil {
+			logger.Fatal("Error verifying migration: %s", err)
 		}
 
-		migratedUsers++
-		if migratedUsers%10 == 0 {
-			logger.Info("Migrated %d users", migratedUsers)
+		report.log(logger)
+
+		if !report.OK() {
+			logger.Fatal("Verification failed. Remove %s to migrate everything again", cli.Checkpoint)
 		}
 	}
-	logger.Info("Migrated %d users...", migratedUsers)
 
-	err = src.FinishCursor()
+	cp.Phase = phaseDone
+	err = m.saveCheckpoint()
 	if err != nil {
-		logger.Fatal("error closing cursor")
+		logger.Error("Error saving checkpoint: %s", err)
 	}
+
+	logger.Info("Migration finished")
 }
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/slog"
+)
+
+type migrator struct {
+	src            migrateHandler
+	dst            migrateHandler
+	log            slog.Instance
+	batchSize      int
+	retries        int
+	dryRun         bool
+	checkpointFile string
+	cp             *checkpoint
+	skipTokens     bool
+}
+
+// resumeCursor skips the source records migrated by a previous run. Since the source cursors are ordered by ID,
+// these are the records up to the last migrated ID, even if the source changed between the runs
+type resumeCursor struct {
+	lastID string
+	prevID string
+}
+
+// skip returns true if the record was already migrated. Returns an error if the cursor is not ordered by ID
+func (rc *resumeCursor) skip(id string) (bool, error) {
+	if id < rc.prevID {
+		return false, fmt.Errorf("source records are not ordered by ID (%q after %q)", id, rc.prevID)
+	}
+
+	rc.prevID = id
+
+	return id <= rc.lastID, nil
+}
+
+// supportsUserTokens returns true if the database handler stores user tokens
+func supportsUserTokens(h interface{}) bool {
+	ts, ok := h.(interfaces.UserTokenSupport)
+	return !ok || ts.SupportsUserTokens()
+}
+
+func (m *migrator) saveCheckpoint() error {
+	if m.dryRun || m.checkpointFile == "" {
+		return nil
+	}
+
+	return m.cp.save(m.checkpointFile)
+}
+
+// retry calls f until it succeeds or the number of retries is exceeded
+func (m *migrator) retry(what string, f func() error) error {
+	var err error
+
+	for attempt := 0; attempt <= m.retries; attempt++ {
+		if attempt > 0 {
+			m.log.Warn("Error %s: %s. Retrying (%d/%d)", what, err, attempt, m.retries)
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+
+		err = f()
+		if err == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("error %s: %s", what, err)
+}
+
+// run migrates keys, users and tokens from source to destination starting from the checkpoint
+func (m *migrator) run() error {
+	if m.batchSize <= 0 {
+		m.batchSize = 500
+	}
+
+	if !supportsUserTokens(m.dst) {
+		m.log.Warn("The destination does not store user tokens (set REDIS_ENABLE to store them). User tokens will not be migrated")
+		m.skipTokens = true
+	}
+
+	err := m.src.InitCursor()
+	if err != nil {
+		return fmt.Errorf("error initializing cursor: %s", err)
+	}
+
+	defer func() {
+		if err := m.src.FinishCursor(); err != nil {
+			m.log.Error("Error closing cursor: %s", err)
+		}
+	}()
+
+	if err := m.migrateKeys(); err != nil {
+		return err
+	}
+
+	if err := m.migrateUsers(); err != nil {
+		return err
+	}
+
+	return m.migrateTokens()
+}
+
+func (m *migrator) migrateKeys() error {
+	if m.cp.Phase > phaseKeys {
+		m.log.Info("Keys already migrated. Skipping...")
+		return nil
+	}
+
+	m.log.Info("Couting keys...")
+	totalKeys, err := m.src.NumGPGKeys()
+	if err != nil {
+		return fmt.Errorf("error getting number of keys: %s", err)
+	}
+
+	gpgKey := models.GPGKey{}
+	cursor := &resumeCursor{lastID: m.cp.LastKeyID}
+
+	if m.cp.LastKeyID != "" {
+		m.log.Info("Resuming from checkpoint. Skipping keys up to ID %s (%d already migrated)", m.cp.LastKeyID, m.cp.MigratedKeys)
+	}
+
+	m.log.Info("Starting migration of %d keys", totalKeys)
+
+	var keys []models.GPGKey
+	var lastID string
+
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+
+		if !m.dryRun {
+			m.log.Info("Saving %d keys to destination", len(keys))
+			err := m.retry("migrating keys", func() error {
+				_, _, err := m.dst.AddGPGKeys(keys)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		m.cp.MigratedKeys += len(keys)
+		m.cp.LastKeyID = lastID
+		m.log.Info("Migrated %6d from %6d keys... [%d]", m.cp.MigratedKeys, totalKeys, len(keys))
+		keys = nil
+
+		return m.saveCheckpoint()
+	}
+
+	for m.src.NextGPGKey(&gpgKey) {
+		skip, err := cursor.skip(gpgKey.ID)
+		if err != nil {
+			return err
+		}
+
+		if skip {
+			gpgKey = models.GPGKey{}
+			continue
+		}
+
+		lastID = gpgKey.ID
+		gpgKey.ID = "" // Re-generate the ID
+		keys = append(keys, gpgKey)
+		gpgKey = models.GPGKey{}
+
+		if len(keys) >= m.batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return err
+	}
+
+	m.log.Info("Migrated %d keys...", m.cp.MigratedKeys)
+	m.cp.Phase = phaseUsers
+
+	return m.saveCheckpoint()
+}
+
+func (m *migrator) saveUser(user models.User) error {
+	user.ID = "" // Re-generate the ID
+
+	oldUser, err := m.dst.GetUser(user.Username)
+	if err == nil && oldUser != nil {
+		m.log.Info("User %s already exists. Updating it...", user.Username)
+		user.ID = oldUser.ID
+		return m.dst.UpdateUser(user)
+	}
+
+	_, err = m.dst.AddUser(user)
+
+	return err
+}
+
+func (m *migrator) migrateUsers() error {
+	if m.cp.Phase > phaseUsers {
+		m.log.Info("Users already migrated. Skipping...")
+		return nil
+	}
+
+	m.log.Info("Migrating users...")
+
+	user := models.User{}
+	cursor := &resumeCursor{lastID: m.cp.LastUserID}
+
+	if m.cp.LastUserID != "" {
+		m.log.Info("Resuming from checkpoint. Skipping users up to ID %s (%d already migrated)", m.cp.LastUserID, m.cp.MigratedUsers)
+	}
+
+	for m.src.NextUser(&user) {
+		skip, err := cursor.skip(user.ID)
+		if err != nil {
+			return err
+		}
+
+		if skip {
+			user = models.User{}
+			continue
+		}
+
+		if !m.dryRun {
+			u := user
+			err := m.retry(fmt.Sprintf("migrating user %s", user.Username), func() error {
+				return m.saveUser(u)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		m.cp.MigratedUsers++
+		m.cp.LastUserID = user.ID
+		if m.cp.MigratedUsers%m.batchSize == 0 {
+			if err := m.saveCheckpoint(); err != nil {
+				return err
+			}
+		}
+		if m.cp.MigratedUsers%10 == 0 {
+			m.log.Info("Migrated %d users", m.cp.MigratedUsers)
+		}
+		user = models.User{}
+	}
+
+	m.log.Info("Migrated %d users...", m.cp.MigratedUsers)
+	m.cp.Phase = phaseTokens
+
+	return m.saveCheckpoint()
+}
+
+func (m *migrator) saveToken(token models.UserToken) error {
+	if _, err := m.dst.GetUserToken(token.Token); err == nil {
+		return nil // Already migrated
+	}
+
+	token.ID = "" // Re-generate the ID
+	_, err := m.dst.AddUserToken(token)
+
+	return err
+}
+
+func (m *migrator) migrateTokens() error {
+	if m.cp.Phase > phaseTokens {
+		m.log.Info("User tokens already migrated. Skipping...")
+		return nil
+	}
+
+	if m.skipTokens {
+		m.log.Warn("Skipping user tokens since the destination does not store them")
+		m.cp.Phase = phaseVerify
+		return m.saveCheckpoint()
+	}
+
+	m.log.Info("Migrating user tokens...")
+
+	token := models.UserToken{}
+	cursor := &resumeCursor{lastID: m.cp.LastTokenID}
+
+	if m.cp.LastTokenID != "" {
+		m.log.Info("Resuming from checkpoint. Skipping user tokens up to ID %s (%d already migrated)", m.cp.LastTokenID, m.cp.MigratedTokens)
+	}
+
+	expiredTokens := 0
+
+	for m.src.NextUserToken(&token) {
+		skip, err := cursor.skip(token.ID)
+		if err != nil {
+			return err
+		}
+
+		if skip {
+			token = models.UserToken{}
+			continue
+		}
+
+		if token.Expiration.Before(time.Now()) {
+			expiredTokens++
+		} else if !m.dryRun {
+			t := token
+			err := m.retry(fmt.Sprintf("migrating token of user %s", token.Username), func() error {
+				return m.saveToken(t)
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		m.cp.MigratedTokens++
+		m.cp.LastTokenID = token.ID
+		if m.cp.MigratedTokens%m.batchSize == 0 {
+			if err := m.saveCheckpoint(); err != nil {
+				return err
+			}
+		}
+		token = models.UserToken{}
+	}
+
+	m.log.Info("Migrated %d user tokens (%d expired tokens skipped)...", m.cp.MigratedTokens-expiredTokens, expiredTokens)
+	m.cp.Phase = phaseVerify
+
+	return m.saveCheckpoint()
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/pkg/database/memory"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/models/testmodels"
+	"github.com/quan-to/slog"
+)
+
+func makeTestSource(t *testing.T, numKeys int) *memory.DbDriver {
+	src := memory.MakeMemoryDBDriver(nil)
+
+	for i := 0; i < numKeys; i++ {
+		key := testmodels.GpgKey
+		key.FullFingerprint = fmt.Sprintf("DEADBEEFDEADBEEFDEADBEEF%08X", i)
+		if _, _, err := src.AddGPGKey(key); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	_, _ = src.AddUser(testmodels.User)
+
+	_, _ = src.AddUserToken(models.UserToken{
+		Username:   testmodels.User.Username,
+		Token:      "VALID",
+		Expiration: time.Now().Add(time.Hour),
+	})
+
+	_, _ = src.AddUserToken(models.UserToken{
+		Username:   testmodels.User.Username,
+		Token:      "EXPIRED",
+		Expiration: time.Now().Add(-time.Hour),
+	})
+
+	return src
+}
+
+func makeTestMigrator(src, dst migrateHandler, checkpointFile string) *migrator {
+	return &migrator{
+		src:            src,
+		dst:            dst,
+		log:            slog.Scope("Test"),
+		batchSize:      3,
+		checkpointFile: checkpointFile,
+		cp:             &checkpoint{},
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dbmigrate")
+	defer os.RemoveAll(dir)
+
+	src := makeTestSource(t, 10)
+	dst := memory.MakeMemoryDBDriver(nil)
+
+	m := makeTestMigrator(src, dst, path.Join(dir, "checkpoint.json"))
+
+	if err := m.run(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if n, _ := dst.NumGPGKeys(); n != 10 {
+		t.Errorf("expected 10 keys in destination got %d", n)
+	}
+
+	if _, err := dst.GetUser(testmodels.User.Username); err != nil {
+		t.Errorf("expected user to be migrated: %s", err)
+	}
+
+	if _, err := dst.GetUserToken("VALID"); err != nil {
+		t.Errorf("expected valid token to be migrated: %s", err)
+	}
+
+	if _, err := dst.GetUserToken("EXPIRED"); err == nil {
+		t.Errorf("expected expired token to not be migrated")
+	}
+
+	cp, err := loadCheckpoint(m.checkpointFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if cp.Phase != phaseVerify || cp.MigratedKeys != 10 || cp.MigratedUsers != 1 {
+		t.Errorf("unexpected checkpoint %+v", cp)
+	}
+
+	report, err := verify(src, dst, m.log)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !report.OK() {
+		t.Errorf("expected verification to pass: %+v", report)
+	}
+}
+
+// sourceKeyIDs returns the IDs of the source keys in cursor order
+func sourceKeyIDs(t *testing.T, src *memory.DbDriver) []string {
+	if err := src.InitCursor(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	defer func() {
+		_ = src.FinishCursor()
+	}()
+
+	var ids []string
+	key := models.GPGKey{}
+	for src.NextGPGKey(&key) {
+		ids = append(ids, key.ID)
+	}
+
+	return ids
+}
+
+func TestMigrateResume(t *testing.T) {
+	src := makeTestSource(t, 10)
+	dst := memory.MakeMemoryDBDriver(nil)
+	ids := sourceKeyIDs(t, src)
+
+	m := makeTestMigrator(src, dst, "")
+	m.cp.MigratedKeys = 6
+	m.cp.LastKeyID = ids[5]
+
+	// Keys removed from the source after the checkpoint do not change what is resumed
+	if err := src.DeleteGPGKey(models.GPGKey{ID: ids[2]}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := m.run(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if n, _ := dst.NumGPGKeys(); n != 4 {
+		t.Errorf("expected 4 keys in destination got %d", n)
+	}
+
+	if m.cp.MigratedKeys != 10 || m.cp.LastKeyID != ids[9] {
+		t.Errorf("unexpected checkpoint %+v", m.cp)
+	}
+
+	report, err := verify(src, dst, m.log)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if report.OK() || len(report.MissingKeys) != 5 {
+		t.Errorf("expected verification to report 5 missing keys: %+v", report)
+	}
+}
+
+// tokenlessDestination is a destination that does not store user tokens, like postgres without REDIS
+type tokenlessDestination struct {
+	*memory.DbDriver
+}
+
+func (d tokenlessDestination) SupportsUserTokens() bool {
+	return false
+}
+
+func (d tokenlessDestination) AddUserToken(models.UserToken) (string, error) {
+	return "", fmt.Errorf("token is not supported")
+}
+
+func (d tokenlessDestination) GetUserToken(string) (*models.UserToken, error) {
+	return nil, fmt.Errorf("token is not supported")
+}
+
+func TestMigrateWithoutTokenSupport(t *testing.T) {
+	src := makeTestSource(t, 4)
+	dst := tokenlessDestination{memory.MakeMemoryDBDriver(nil)}
+
+	m := makeTestMigrator(src, dst, "")
+
+	if err := m.run(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if n, _ := dst.NumGPGKeys(); n != 4 || m.cp.MigratedTokens != 0 || m.cp.Phase != phaseVerify {
+		t.Errorf("expected keys to be migrated without tokens: %d keys, %+v", n, m.cp)
+	}
+
+	report, err := verify(src, dst, m.log)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !report.OK() || !report.TokensSkipped {
+		t.Errorf("expected verification to pass skipping tokens: %+v", report)
+	}
+}
+
+func TestMigrateDryRun(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dbmigrate")
+	defer os.RemoveAll(dir)
+
+	src := makeTestSource(t, 5)
+	dst := memory.MakeMemoryDBDriver(nil)
+
+	m := makeTestMigrator(src, dst, path.Join(dir, "checkpoint.json"))
+	m.dryRun = true
+
+	if err := m.run(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if n, _ := dst.NumGPGKeys(); n != 0 {
+		t.Errorf("expected no keys in destination got %d", n)
+	}
+
+	if m.cp.MigratedKeys != 5 || m.cp.MigratedUsers != 1 {
+		t.Errorf("unexpected dry run counters %+v", m.cp)
+	}
+
+	if _, err := os.Stat(m.checkpointFile); !os.IsNotExist(err) {
+		t.Errorf("expected no checkpoint to be written on dry run")
+	}
+}
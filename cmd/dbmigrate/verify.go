@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/slog"
+)
+
+const maxReportedMissing = 20
+
+// verificationReport is the result of comparing source and destination after a migration
+type verificationReport struct {
+	SourceKeys      int
+	DestinationKeys int
+	MissingKeys     []string
+	MissingUsers    []string
+	MissingTokens   int
+	// TokensSkipped is true if the tokens were not verified since the destination does not store them
+	TokensSkipped bool
+}
+
+// OK returns true if everything in source was found in destination
+func (vr *verificationReport) OK() bool {
+	return len(vr.MissingKeys) == 0 && len(vr.MissingUsers) == 0 && vr.MissingTokens == 0
+}
+
+func (vr *verificationReport) log(log slog.Instance) {
+	log.Info("Source has %d keys, destination has %d keys", vr.SourceKeys, vr.DestinationKeys)
+
+	if vr.TokensSkipped {
+		log.Warn("User tokens were not verified since the destination does not store them")
+	}
+
+	if vr.OK() {
+		log.Info("Verification passed")
+		return
+	}
+
+	if len(vr.MissingKeys) > 0 {
+		shown := vr.MissingKeys
+		if len(shown) > maxReportedMissing {
+			shown = shown[:maxReportedMissing]
+		}
+		log.Error("%d keys are missing in destination: %s", len(vr.MissingKeys), strings.Join(shown, ", "))
+	}
+
+	if len(vr.MissingUsers) > 0 {
+		log.Error("%d users are missing in destination: %s", len(vr.MissingUsers), strings.Join(vr.MissingUsers, ", "))
+	}
+
+	if vr.MissingTokens > 0 {
+		log.Error("%d user tokens are missing in destination", vr.MissingTokens)
+	}
+}
+
+// verify compares the key counts and fingerprints of source and destination and checks that all source users and valid tokens exist in destination
+func verify(src, dst migrateHandler, log slog.Instance) (*verificationReport, error) {
+	report := &verificationReport{}
+	var err error
+
+	report.SourceKeys, err = src.NumGPGKeys()
+	if err != nil {
+		return nil, fmt.Errorf("error counting source keys: %s", err)
+	}
+
+	report.DestinationKeys, err = dst.NumGPGKeys()
+	if err != nil {
+		return nil, fmt.Errorf("error counting destination keys: %s", err)
+	}
+
+	log.Info("Reading destination fingerprints...")
+	destinationFingerprints, err := fingerprints(dst)
+	if err != nil {
+		return nil, fmt.Errorf("error reading destination keys: %s", err)
+	}
+
+	log.Info("Comparing source keys, users and tokens...")
+	err = src.InitCursor()
+	if err != nil {
+		return nil, fmt.Errorf("error initializing source cursor: %s", err)
+	}
+
+	defer func() {
+		_ = src.FinishCursor()
+	}()
+
+	key := models.GPGKey{}
+	for src.NextGPGKey(&key) {
+		if _, ok := destinationFingerprints[strings.ToUpper(key.FullFingerprint)]; !ok {
+			report.MissingKeys = append(report.MissingKeys, key.FullFingerprint)
+		}
+		key = models.GPGKey{}
+	}
+
+	user := models.User{}
+	for src.NextUser(&user) {
+		dstUser, err := dst.GetUser(user.Username)
+		if err != nil || dstUser == nil || !strings.EqualFold(dstUser.Fingerprint, user.Fingerprint) {
+			report.MissingUsers = append(report.MissingUsers, user.Username)
+		}
+		user = models.User{}
+	}
+
+	if !supportsUserTokens(dst) {
+		report.TokensSkipped = true
+		return report, nil
+	}
+
+	token := models.UserToken{}
+	for src.NextUserToken(&token) {
+		if token.Expiration.After(time.Now()) {
+			if _, err := dst.GetUserToken(token.Token); err != nil {
+				report.MissingTokens++
+			}
+		}
+		token = models.UserToken{}
+	}
+
+	return report, nil
+}
+
+func fingerprints(h migrateHandler) (map[string]struct{}, error) {
+	err := h.InitCursor()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = h.FinishCursor()
+	}()
+
+	fps := map[string]struct{}{}
+	key := models.GPGKey{}
+
+	for h.NextGPGKey(&key) {
+		fps[strings.ToUpper(key.FullFingerprint)] = struct{}{}
+		key = models.GPGKey{}
+	}
+
+	return fps, nil
+}
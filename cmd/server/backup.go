@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"syscall"
+
+	"github.com/quan-to/chevron/internal/agent"
+	"github.com/quan-to/chevron/internal/backup"
+	"github.com/quan-to/chevron/internal/etc/kbBuilder"
+	"github.com/quan-to/chevron/internal/etc/magicbuilder"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/database/memory"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// loadRecoveryKey loads the armored recovery key file in a PGPManager that does not store anything. Returns its fingerprint
+func loadRecoveryKey(ctx context.Context, filename string) (interfaces.PGPManager, string, error) {
+	keyData, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, "", err
+	}
+
+	fp, err := tools.GetFingerPrintFromKey(string(keyData))
+	if err != nil {
+		return nil, "", err
+	}
+
+	gpg := magicbuilder.MakeVoidPGP(log, memory.MakeMemoryDBDriver(log))
+
+	_, err = gpg.LoadKey(ctx, string(keyData))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return gpg, fp, nil
+}
+
+// Backup writes the signed and encrypted archive of the current configured backends to output
+func Backup(output, recoveryKeyFile string) {
+	ctx := context.Background()
+
+	dbh, err := agent.MakeDatabaseHandler(log)
+	if err != nil {
+		log.Fatal("Error initializing selected database: %s", err)
+	}
+
+	sm := magicbuilder.MakeSM(log, dbh)
+	kb := kbBuilder.BuildKeyBackend(log)
+
+	recoveryGPG, recoveryFp, err := loadRecoveryKey(ctx, recoveryKeyFile)
+	if err != nil {
+		log.Fatal("Error loading recovery key %s: %s", recoveryKeyFile, err)
+	}
+
+	data, err := backup.Collect(log, dbh, kb)
+	if err != nil {
+		log.Fatal("Error reading data to backup: %s", err)
+	}
+
+	archive, err := backup.Seal(ctx, sm, recoveryGPG, recoveryFp, *data)
+	if err != nil {
+		log.Fatal("Error creating backup: %s", err)
+	}
+
+	archiveData, _ := json.Marshal(archive)
+
+	err = ioutil.WriteFile(output, archiveData, 0600)
+	if err != nil {
+		log.Fatal("Error saving backup to %s: %s", output, err)
+	}
+
+	log.Info("Backup saved to %s (recovery key %s)", output, recoveryFp)
+}
+
+// Restore verifies and decrypts the archive at input and writes its content to the current configured backends
+func Restore(input, recoveryKeyFile, recoveryKeyPassword string) {
+	ctx := context.Background()
+
+	archiveData, err := ioutil.ReadFile(input)
+	if err != nil {
+		log.Fatal("Error reading backup %s: %s", input, err)
+	}
+
+	var archive models.BackupArchive
+
+	err = json.Unmarshal(archiveData, &archive)
+	if err != nil {
+		log.Fatal("Error parsing backup %s: %s", input, err)
+	}
+
+	dbh, err := agent.MakeDatabaseHandler(log)
+	if err != nil {
+		log.Fatal("Error initializing selected database: %s", err)
+	}
+
+	sm := magicbuilder.MakeSM(log, dbh)
+	kb := kbBuilder.BuildKeyBackend(log)
+
+	recoveryGPG, recoveryFp, err := loadRecoveryKey(ctx, recoveryKeyFile)
+	if err != nil {
+		log.Fatal("Error loading recovery key %s: %s", recoveryKeyFile, err)
+	}
+
+	if recoveryKeyPassword == "" {
+		_, _ = fmt.Fprint(os.Stderr, "Please enter the recovery key password: ")
+		bytePassword, err := terminal.ReadPassword(int(syscall.Stdin))
+		if err != nil {
+			log.Fatal("Error reading password: %s", err)
+		}
+		recoveryKeyPassword = string(bytePassword)
+		_, _ = fmt.Fprintln(os.Stderr, "")
+	}
+
+	err = recoveryGPG.UnlockKey(ctx, recoveryFp, recoveryKeyPassword)
+	if err != nil {
+		log.Fatal("Error unlocking recovery key: %s", err)
+	}
+
+	data, err := backup.Open(ctx, sm, recoveryGPG, archive)
+	if err != nil {
+		log.Fatal("Error opening backup: %s", err)
+	}
+
+	err = backup.Restore(log, dbh, kb, *data)
+	if err != nil {
+		log.Fatal("Error restoring backup: %s", err)
+	}
+
+	log.Info("Backup from %s restored", archive.CreatedAt)
+}
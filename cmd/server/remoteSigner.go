@@ -8,12 +8,14 @@ import (
 
 	_ "github.com/quan-to/chevron/cmd/server/init"
 	"github.com/quan-to/chevron/internal/agent"
+	"github.com/quan-to/chevron/internal/cluster"
 	"github.com/quan-to/chevron/internal/config"
 	"github.com/quan-to/chevron/internal/etc/magicbuilder"
-	"github.com/quan-to/chevron/internal/kubernetes"
+	"github.com/quan-to/chevron/internal/keymagic"
 	"github.com/quan-to/chevron/internal/server"
 	"github.com/quan-to/chevron/internal/tools"
 	"github.com/quan-to/slog"
+	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 var log = slog.Scope("QRS").Tag(tools.DefaultTag)
@@ -22,10 +24,36 @@ func main() {
 	var stop chan bool
 	var err error
 
+	_ = kingpin.Command("serve", "Run the remote signer server").Default()
+
+	// region Backup
+	cmdBackup := kingpin.Command("backup", "Save the keys, users and tokens of the configured backends to a signed archive encrypted to a recovery key")
+	backupOutput := cmdBackup.Flag("output", "Filename of the archive").Default("chevron-backup.json").String()
+	backupRecoveryKey := cmdBackup.Flag("recovery-key", "Filename of the ASCII Armored recovery public key").Required().String()
+	// endregion
+
+	// region Restore
+	cmdRestore := kingpin.Command("restore", "Restore a backup archive to the configured backends")
+	restoreInput := cmdRestore.Flag("input", "Filename of the archive").Default("chevron-backup.json").String()
+	restoreRecoveryKey := cmdRestore.Flag("recovery-key", "Filename of the ASCII Armored recovery private key").Required().String()
+	restoreRecoveryKeyPassword := cmdRestore.Flag("recovery-key-password", "Recovery key password (if not provided, it will be prompted)").Default("").String()
+	// endregion
+
+	selectedCmd := kingpin.Parse()
+
 	if os.Getenv("SHOW_LINES") == "true" {
 		slog.SetShowLines(true)
 	}
 
+	switch selectedCmd {
+	case "backup":
+		Backup(*backupOutput, *backupRecoveryKey)
+		return
+	case "restore":
+		Restore(*restoreInput, *restoreRecoveryKey, *restoreRecoveryKeyPassword)
+		return
+	}
+
 	ctx := context.Background()
 
 	dbh, err := agent.MakeDatabaseHandler(log)
@@ -49,23 +77,35 @@ func main() {
 	}
 
 	localStop := make(chan bool)
-	kubeStop := make(chan bool)
+	clusterStop := make(chan bool)
+
+	discovery := cluster.MakePeerDiscovery(log, dbh)
+	bus := cluster.MakeClusterBus(log, dbh, discovery)
+	clusterEnabled := discovery != nil || bus != nil
 
-	if kubernetes.InKubernetes() {
-		go kubernetes.KubeRoutine(kubeStop)
+	if clusterEnabled {
+		go cluster.Routine(sm, gpg, discovery, bus, clusterStop)
 	}
 
-	c := make(chan os.Signal)
+	keyRingStop := make(chan bool)
+	go keymagic.KeyRingRefreshRoutine(keyRingStop)
+
+	pksUpdateStop := make(chan bool)
+	go keymagic.PKSUpdateRoutine(dbh, pksUpdateStop)
+
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		<-c // Wait for SIGTERM (Ctrl + C)
-		if kubernetes.InKubernetes() {
-			kubeStop <- true // Send Stop signal to Kubernetes Routine
+		if clusterEnabled {
+			clusterStop <- true // Send Stop signal to Cluster Routine
 		}
-		stop <- true      // Send stop signal to HTTP
-		<-stop            // Wait HTTP to Cleanup
-		localStop <- true // Send Local stop
+		keyRingStop <- true   // Send Stop signal to Key Ring Refresh Routine
+		pksUpdateStop <- true // Send Stop signal to Public Key Store Update Routine
+		stop <- true          // Send stop signal to HTTP
+		<-stop                // Wait HTTP to Cleanup
+		localStop <- true     // Send Local stop
 	}()
 
 	<-localStop
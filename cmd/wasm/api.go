@@ -0,0 +1,412 @@
+// +build js,wasm
+
+package main
+
+import (
+	"encoding/base64"
+	"strings"
+	"syscall/js"
+
+	"github.com/quan-to/chevron/pkg/chevronlib"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// DefaultIndexedDBDatabase is the IndexedDB database used by useIndexedDB when no name is specified
+const DefaultIndexedDBDatabase = "chevron"
+
+// indexedDBKeyPrefix is the prefix of the keys stored in IndexedDB
+const indexedDBKeyPrefix = "key_"
+
+// makeAPI returns the chevron JS object
+func makeAPI() js.Value {
+	api := js.Global().Get("Object").New()
+
+	methods := map[string]apiCall{
+		"useIndexedDB":          useIndexedDB,
+		"loadKey":               loadKey,
+		"saveKey":               saveKey,
+		"deleteKey":             deleteKey,
+		"listKeys":              listKeys,
+		"unlockKey":             unlockKey,
+		"generateKey":           generateKey,
+		"changeKeyPassword":     changeKeyPassword,
+		"getKeyFingerprints":    getKeyFingerprints,
+		"getFingerprintFromKey": getFingerprintFromKey,
+		"getPublicKey":          getPublicKey,
+		"signData":              signData,
+		"quantoSignData":        quantoSignData,
+		"verifySignature":       verifySignature,
+		"quantoVerifySignature": quantoVerifySignature,
+		"encrypt":               encrypt,
+		"decrypt":               decrypt,
+		"gpg2quanto":            gpg2quanto,
+		"quanto2gpg":            quanto2gpg,
+		"fieldCipher":           fieldCipher,
+		"fieldDecipher":         fieldDecipher,
+		"setBlindIndexKey":      setBlindIndexKey,
+		"computeBlindIndex":     computeBlindIndex,
+	}
+
+	for name, call := range methods {
+		api.Set(name, promise(call))
+	}
+
+	return api
+}
+
+// useIndexedDB(database?: string): Promise<number>
+// Stores the keys saved with saveKey in the IndexedDB database and loads the ones already stored. Returns the number of loaded keys
+func useIndexedDB(args []js.Value) (interface{}, error) {
+	database, err := optionalStringArg(args, 0, "database", DefaultIndexedDBDatabase)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := chevronlib.MakeIndexedDBBackend(nil, database, indexedDBKeyPrefix)
+
+	// Fail early if IndexedDB cannot be used, since LoadKeys only logs errors
+	if _, err := storage.List(); err != nil {
+		return nil, err
+	}
+
+	chevronlib.SetStorageBackend(storage)
+
+	return len(chevronlib.GetLoadedKeys()), nil
+}
+
+// loadKey(keyData: string): Promise<string>
+// Loads a public or private key in memory. Returns the key fingerprint
+func loadKey(args []js.Value) (interface{}, error) {
+	keyData, err := stringArg(args, 0, "keyData")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := chevronlib.LoadKey(keyData); err != nil {
+		return nil, err
+	}
+
+	return chevronlib.GetFingerprintFromKey(keyData)
+}
+
+// saveKey(keyData: string): Promise<string>
+// Loads a public or private key and stores it in IndexedDB. Returns the key fingerprint
+func saveKey(args []js.Value) (interface{}, error) {
+	keyData, err := stringArg(args, 0, "keyData")
+	if err != nil {
+		return nil, err
+	}
+
+	return chevronlib.SaveKey(keyData)
+}
+
+// deleteKey(fingerprint: string): Promise<void>
+func deleteKey(args []js.Value) (interface{}, error) {
+	fingerprint, err := stringArg(args, 0, "fingerprint")
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, chevronlib.DeleteKey(fingerprint)
+}
+
+// listKeys(): Promise<KeyInfo[]>
+func listKeys(args []js.Value) (interface{}, error) {
+	return toJS(chevronlib.GetLoadedKeys())
+}
+
+// unlockKey(fingerprint: string, password: string): Promise<void>
+func unlockKey(args []js.Value) (interface{}, error) {
+	fingerprint, err := stringArg(args, 0, "fingerprint")
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := stringArg(args, 1, "password")
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, chevronlib.UnlockKey(fingerprint, password)
+}
+
+// generateKey(password: string, identifier: string, bits: number): Promise<string>
+func generateKey(args []js.Value) (interface{}, error) {
+	password, err := stringArg(args, 0, "password")
+	if err != nil {
+		return nil, err
+	}
+
+	identifier, err := stringArg(args, 1, "identifier")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(args) < 3 || args[2].Type() != js.TypeNumber {
+		return nil, argumentError{`expected argument "bits" to be number`}
+	}
+
+	return chevronlib.GenerateKey(password, identifier, args[2].Int())
+}
+
+// changeKeyPassword(keyData: string, currentPassword: string, newPassword: string): Promise<string>
+func changeKeyPassword(args []js.Value) (interface{}, error) {
+	keyData, err := stringArg(args, 0, "keyData")
+	if err != nil {
+		return nil, err
+	}
+
+	currentPassword, err := stringArg(args, 1, "currentPassword")
+	if err != nil {
+		return nil, err
+	}
+
+	newPassword, err := stringArg(args, 2, "newPassword")
+	if err != nil {
+		return nil, err
+	}
+
+	return chevronlib.ChangeKeyPassword(keyData, currentPassword, newPassword)
+}
+
+// getKeyFingerprints(keyData: string): Promise<string[]>
+func getKeyFingerprints(args []js.Value) (interface{}, error) {
+	keyData, err := stringArg(args, 0, "keyData")
+	if err != nil {
+		return nil, err
+	}
+
+	fps, err := chevronlib.GetKeyFingerprints(keyData)
+	if err != nil {
+		return nil, err
+	}
+
+	return toJS(fps)
+}
+
+// getFingerprintFromKey(keyData: string): Promise<string>
+func getFingerprintFromKey(args []js.Value) (interface{}, error) {
+	keyData, err := stringArg(args, 0, "keyData")
+	if err != nil {
+		return nil, err
+	}
+
+	return chevronlib.GetFingerprintFromKey(keyData)
+}
+
+// getPublicKey(fingerprint: string): Promise<string>
+func getPublicKey(args []js.Value) (interface{}, error) {
+	fingerprint, err := stringArg(args, 0, "fingerprint")
+	if err != nil {
+		return nil, err
+	}
+
+	return chevronlib.GetPublicKey(fingerprint)
+}
+
+func sign(args []js.Value, fn func([]byte, string) (string, error)) (interface{}, error) {
+	data, err := base64Arg(args, 0, "data")
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := stringArg(args, 1, "fingerprint")
+	if err != nil {
+		return nil, err
+	}
+
+	return fn(data, fingerprint)
+}
+
+// signData(data: string, fingerprint: string): Promise<string>
+// data is base64 encoded
+func signData(args []js.Value) (interface{}, error) {
+	return sign(args, chevronlib.SignData)
+}
+
+// quantoSignData(data: string, fingerprint: string): Promise<string>
+// data is base64 encoded
+func quantoSignData(args []js.Value) (interface{}, error) {
+	return sign(args, chevronlib.QuantoSignData)
+}
+
+func verify(args []js.Value, fn func([]byte, string) (bool, error)) (interface{}, error) {
+	data, err := base64Arg(args, 0, "data")
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := stringArg(args, 1, "signature")
+	if err != nil {
+		return nil, err
+	}
+
+	valid, err := fn(data, signature)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid signature") {
+			return false, nil
+		}
+		return nil, err
+	}
+
+	return valid, nil
+}
+
+// verifySignature(data: string, signature: string): Promise<boolean>
+// data is base64 encoded
+func verifySignature(args []js.Value) (interface{}, error) {
+	return verify(args, chevronlib.VerifySignature)
+}
+
+// quantoVerifySignature(data: string, signature: string): Promise<boolean>
+// data is base64 encoded
+func quantoVerifySignature(args []js.Value) (interface{}, error) {
+	return verify(args, chevronlib.QuantoVerifySignature)
+}
+
+// encrypt(data: string, fingerprint: string, filename?: string): Promise<string>
+// data is base64 encoded
+func encrypt(args []js.Value) (interface{}, error) {
+	data, err := base64Arg(args, 0, "data")
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := stringArg(args, 1, "fingerprint")
+	if err != nil {
+		return nil, err
+	}
+
+	filename, err := optionalStringArg(args, 2, "filename", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return chevronlib.Encrypt(data, filename, fingerprint)
+}
+
+// decrypt(encryptedData: string): Promise<{data: string, filename: string}>
+// data is base64 encoded
+func decrypt(args []js.Value) (interface{}, error) {
+	encryptedData, err := stringArg(args, 0, "encryptedData")
+	if err != nil {
+		return nil, err
+	}
+
+	data, filename, err := chevronlib.DecryptData(encryptedData)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"data":     base64.StdEncoding.EncodeToString(data),
+		"filename": filename,
+	}, nil
+}
+
+// gpg2quanto(signature: string, fingerprint: string, hash?: string): Promise<string>
+func gpg2quanto(args []js.Value) (interface{}, error) {
+	signature, err := stringArg(args, 0, "signature")
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := stringArg(args, 1, "fingerprint")
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := optionalStringArg(args, 2, "hash", "SHA512")
+	if err != nil {
+		return nil, err
+	}
+
+	return chevronlib.GPG2Quanto(signature, fingerprint, hash), nil
+}
+
+// quanto2gpg(signature: string): Promise<string>
+func quanto2gpg(args []js.Value) (interface{}, error) {
+	signature, err := stringArg(args, 0, "signature")
+	if err != nil {
+		return nil, err
+	}
+
+	return chevronlib.Quanto2GPG(signature), nil
+}
+
+// fieldCipher(data: object, keys: string[], options?: FieldCipherOptions): Promise<CipherPacket>
+// options has the same fields as the /fieldCipher endpoint input, except JSON and Keys
+func fieldCipher(args []js.Value) (interface{}, error) {
+	var input models.FieldCipherInput
+
+	if len(args) > 2 && !args[2].IsUndefined() && !args[2].IsNull() {
+		if err := jsonArg(args, 2, "options", &input); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := jsonArg(args, 0, "data", &input.JSON); err != nil {
+		return nil, err
+	}
+
+	if err := jsonArg(args, 1, "keys", &input.Keys); err != nil {
+		return nil, err
+	}
+
+	packet, err := chevronlib.FieldCipher(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toJS(packet)
+}
+
+// fieldDecipher(packet: CipherPacket, fingerprint: string, partial?: boolean): Promise<DecipherPacket>
+func fieldDecipher(args []js.Value) (interface{}, error) {
+	var input models.FieldDecipherInput
+
+	if err := jsonArg(args, 0, "packet", &input); err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := stringArg(args, 1, "fingerprint")
+	if err != nil {
+		return nil, err
+	}
+
+	input.KeyFingerprint = fingerprint
+	input.Partial = len(args) > 2 && args[2].Truthy()
+
+	packet, err := chevronlib.FieldDecipher(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return toJS(packet)
+}
+
+// setBlindIndexKey(key: string): Promise<void>
+// key is base64 encoded
+func setBlindIndexKey(args []js.Value) (interface{}, error) {
+	key, err := base64Arg(args, 0, "key")
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, chevronlib.SetBlindIndexKey(key)
+}
+
+// computeBlindIndex(field: string, value: any): Promise<string>
+func computeBlindIndex(args []js.Value) (interface{}, error) {
+	field, err := stringArg(args, 0, "field")
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := jsonArg(args, 1, "value", &value); err != nil {
+		return nil, err
+	}
+
+	return chevronlib.ComputeBlindIndex(field, value)
+}
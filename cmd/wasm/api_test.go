@@ -0,0 +1,262 @@
+// +build js,wasm
+
+package main
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/quan-to/chevron/test"
+)
+
+// await waits for a Promise and returns its resolved value or rejection reason
+func await(p js.Value) (value js.Value, rejection js.Value) {
+	done := make(chan struct{})
+
+	onResolve := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		value = js.Undefined()
+		if len(args) > 0 {
+			value = args[0]
+		}
+		close(done)
+		return nil
+	})
+	defer onResolve.Release()
+
+	onReject := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		rejection = args[0]
+		close(done)
+		return nil
+	})
+	defer onReject.Release()
+
+	p.Call("then", onResolve, onReject)
+	<-done
+
+	return
+}
+
+func mustCall(t *testing.T, api js.Value, method string, args ...interface{}) js.Value {
+	t.Helper()
+
+	v, rejection := await(api.Call(method, args...))
+	if rejection.Truthy() {
+		t.Fatalf("Expected %s to resolve, got %s", method, rejection.Get("message").String())
+	}
+
+	return v
+}
+
+func loadTestKey(t *testing.T, api js.Value) {
+	t.Helper()
+
+	key, err := ioutil.ReadFile("../../test/data/testkey_privateTestKey.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := mustCall(t, api, "loadKey", string(key)).String()
+	if fp != test.TestKeyFingerprint {
+		t.Fatalf("Expected fingerprint %s got %s", test.TestKeyFingerprint, fp)
+	}
+
+	mustCall(t, api, "unlockKey", test.TestKeyFingerprint, test.TestKeyPassword)
+}
+
+func TestSignVerify(t *testing.T) {
+	api := makeAPI()
+	loadTestKey(t, api)
+
+	data := base64.StdEncoding.EncodeToString([]byte(test.TestSignatureData))
+
+	signature := mustCall(t, api, "signData", data, test.TestKeyFingerprint).String()
+	if !strings.Contains(signature, "PGP SIGNATURE") {
+		t.Errorf("Expected armored signature got %s", signature)
+	}
+
+	if !mustCall(t, api, "verifySignature", data, signature).Bool() {
+		t.Error("Expected signature to be valid")
+	}
+
+	tampered := base64.StdEncoding.EncodeToString([]byte(test.TestSignatureData + "huebr"))
+	if mustCall(t, api, "verifySignature", tampered, signature).Bool() {
+		t.Error("Expected signature of tampered data to be invalid")
+	}
+
+	quanto := mustCall(t, api, "quantoSignData", data, test.TestKeyFingerprint).String()
+	if !mustCall(t, api, "quantoVerifySignature", data, quanto).Bool() {
+		t.Error("Expected quanto signature to be valid")
+	}
+
+	gpg := mustCall(t, api, "quanto2gpg", quanto).String()
+	if mustCall(t, api, "gpg2quanto", gpg, test.TestKeyFingerprint).String() != quanto {
+		t.Error("Expected signature converters to be symmetric")
+	}
+
+	_, rejection := await(api.Call("signData", "huebr!", test.TestKeyFingerprint))
+	if !rejection.Truthy() || rejection.Get("name").String() != "TypeError" {
+		t.Error("Expected invalid base64 data to be rejected with TypeError")
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	api := makeAPI()
+	loadTestKey(t, api)
+
+	data := base64.StdEncoding.EncodeToString([]byte(test.TestSignatureData))
+
+	encrypted := mustCall(t, api, "encrypt", data, test.TestKeyFingerprint, "test.txt").String()
+	decrypted := mustCall(t, api, "decrypt", encrypted)
+
+	if decrypted.Get("data").String() != data {
+		t.Errorf("Expected decrypted data %s got %s", data, decrypted.Get("data").String())
+	}
+
+	if decrypted.Get("filename").String() != "test.txt" {
+		t.Errorf("Expected filename test.txt got %s", decrypted.Get("filename").String())
+	}
+
+	_, rejection := await(api.Call("encrypt", data, "0000000000000000"))
+	if !rejection.Truthy() || rejection.Get("name").String() != "Error" {
+		t.Error("Expected encrypt to an unknown key to be rejected")
+	}
+}
+
+func TestFieldCipher(t *testing.T) {
+	api := makeAPI()
+	loadTestKey(t, api)
+
+	JSON := js.Global().Get("JSON")
+	data := JSON.Call("parse", `{"name": "huebr", "taxId": "12345678900"}`)
+	keys := JSON.Call("parse", `["`+test.TestKeyFingerprint+`"]`)
+	options := JSON.Call("parse", `{"Exclude": ["$.name"], "SignWith": "`+test.TestKeyFingerprint+`", "BlindIndex": ["$.taxId"]}`)
+
+	mustCall(t, api, "setBlindIndexKey", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+
+	packet := mustCall(t, api, "fieldCipher", data, keys, options)
+	encrypted := packet.Get("EncryptedJSON")
+
+	if encrypted.Get("name").String() != "huebr" {
+		t.Error("Expected excluded field to stay in plain text")
+	}
+
+	if encrypted.Get("taxId").String() == "12345678900" {
+		t.Error("Expected taxId to be encrypted")
+	}
+
+	index := mustCall(t, api, "computeBlindIndex", "$.taxId", "12345678900").String()
+	if encrypted.Get("taxId_bidx").String() != index {
+		t.Errorf("Expected blind index %s got %s", index, encrypted.Get("taxId_bidx").String())
+	}
+
+	decrypted := mustCall(t, api, "fieldDecipher", packet, test.TestKeyFingerprint)
+
+	if decrypted.Get("DecryptedData").Get("taxId").String() != "12345678900" {
+		t.Error("Expected taxId to be decrypted")
+	}
+
+	if !decrypted.Get("SignatureValid").Bool() {
+		t.Error("Expected packet signature to be valid")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	api := makeAPI()
+	loadTestKey(t, api)
+
+	keys := mustCall(t, api, "listKeys")
+	found := false
+	for i := 0; i < keys.Length(); i++ {
+		if keys.Index(i).Get("FingerPrint").String() == test.TestKeyFingerprint {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("Expected %s in listKeys", test.TestKeyFingerprint)
+	}
+
+	publicKey := mustCall(t, api, "getPublicKey", test.TestKeyFingerprint).String()
+	if mustCall(t, api, "getFingerprintFromKey", publicKey).String() != test.TestKeyFingerprint {
+		t.Error("Expected public key fingerprint to match")
+	}
+
+	if mustCall(t, api, "getKeyFingerprints", publicKey).Length() == 0 {
+		t.Error("Expected public key to have fingerprints")
+	}
+}
+
+// fakeIndexedDB is a in memory implementation of the IndexedDB calls used by the IndexedDB StorageBackend
+const fakeIndexedDB = `(function() {
+	const stores = {};
+	const request = (fn) => {
+		const req = {};
+		setTimeout(() => {
+			req.result = fn(req);
+			if (req.onsuccess) req.onsuccess({ target: req });
+		}, 0);
+		return req;
+	};
+	const objectStore = (data) => ({
+		put: (value, key) => request(() => { data[key] = value; return key; }),
+		get: (key) => request(() => data[key]),
+		delete: (key) => request(() => { delete data[key]; }),
+		getAllKeys: () => request(() => Object.keys(data)),
+	});
+	return {
+		open: (name) => request((req) => {
+			const db = {
+				objectStoreNames: { contains: (s) => s in stores },
+				createObjectStore: (s) => { stores[s] = {}; },
+				transaction: (s) => ({ objectStore: () => objectStore(stores[s]) }),
+			};
+			req.result = db;
+			if (Object.keys(stores).length === 0 && req.onupgradeneeded) req.onupgradeneeded({ target: req });
+			return db;
+		}),
+	};
+})()`
+
+func TestUseIndexedDB(t *testing.T) {
+	js.Global().Set("indexedDB", js.Global().Call("eval", fakeIndexedDB))
+	defer js.Global().Delete("indexedDB")
+
+	api := makeAPI()
+
+	if n := mustCall(t, api, "useIndexedDB", "test").Int(); n != 0 {
+		t.Errorf("Expected an empty database, got %d keys", n)
+	}
+
+	key, err := ioutil.ReadFile("../../test/data/testkey_privateTestKey.gpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fp := mustCall(t, api, "saveKey", string(key)).String(); fp != test.TestKeyFingerprint {
+		t.Errorf("Expected fingerprint %s got %s", test.TestKeyFingerprint, fp)
+	}
+
+	// Switching to the same database again should load the saved key
+	if n := mustCall(t, api, "useIndexedDB", "test").Int(); n == 0 {
+		t.Error("Expected the saved key to be loaded from IndexedDB")
+	}
+
+	mustCall(t, api, "deleteKey", test.TestKeyFingerprint)
+
+	if n := mustCall(t, api, "useIndexedDB", "test").Int(); n != 0 {
+		t.Errorf("Expected the deleted key to be removed from IndexedDB, got %d keys", n)
+	}
+}
+
+func TestUseIndexedDBUnavailable(t *testing.T) {
+	api := makeAPI()
+
+	// Node does not have IndexedDB
+	_, rejection := await(api.Call("useIndexedDB"))
+	if !rejection.Truthy() {
+		t.Error("Expected useIndexedDB to be rejected without IndexedDB")
+	}
+}
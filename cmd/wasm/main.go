@@ -0,0 +1,22 @@
+// +build js,wasm
+
+package main
+
+import (
+	"syscall/js"
+)
+
+// GlobalName is the name of the global JS object that holds the chevron API
+const GlobalName = "chevron"
+
+func main() {
+	js.Global().Set(GlobalName, makeAPI())
+
+	// Signals that the API is ready for who is waiting the WASM module to start
+	if onReady := js.Global().Get("onChevronReady"); onReady.Type() == js.TypeFunction {
+		onReady.Invoke(js.Global().Get(GlobalName))
+	}
+
+	// Keep the go runtime alive to answer the API calls
+	select {}
+}
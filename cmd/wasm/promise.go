@@ -0,0 +1,119 @@
+// +build js,wasm
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"syscall/js"
+)
+
+// apiCall is a chevron JS API method. Returns the value that resolves the Promise
+type apiCall func(args []js.Value) (interface{}, error)
+
+// argumentError is returned when a JS API method is called with invalid arguments
+type argumentError struct {
+	msg string
+}
+
+func (e argumentError) Error() string {
+	return e.msg
+}
+
+// jsError converts a go error to a JS Error. Argument errors are converted to TypeError
+func jsError(err error) js.Value {
+	if _, ok := err.(argumentError); ok {
+		return js.Global().Get("TypeError").New(err.Error())
+	}
+
+	return js.Global().Get("Error").New(err.Error())
+}
+
+// promise wraps call in a js.Func that returns a Promise
+//
+// The call runs in its own goroutine, so it can block waiting for the browser (for example in IndexedDB)
+func promise(call apiCall) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		executor := js.FuncOf(func(this js.Value, pArgs []js.Value) interface{} {
+			resolve, reject := pArgs[0], pArgs[1]
+			go func() {
+				defer func() {
+					if r := recover(); r != nil {
+						reject.Invoke(jsError(fmt.Errorf("%v", r)))
+					}
+				}()
+
+				result, err := call(args)
+				if err != nil {
+					reject.Invoke(jsError(err))
+					return
+				}
+
+				resolve.Invoke(result)
+			}()
+			return nil
+		})
+		defer executor.Release()
+
+		return js.Global().Get("Promise").New(executor)
+	})
+}
+
+// stringArg returns the argument i as string
+func stringArg(args []js.Value, i int, name string) (string, error) {
+	if i >= len(args) || args[i].Type() != js.TypeString {
+		return "", argumentError{fmt.Sprintf("expected argument %q to be string", name)}
+	}
+
+	return args[i].String(), nil
+}
+
+// optionalStringArg returns the argument i as string or def if it is undefined
+func optionalStringArg(args []js.Value, i int, name, def string) (string, error) {
+	if i >= len(args) || args[i].IsUndefined() || args[i].IsNull() {
+		return def, nil
+	}
+
+	return stringArg(args, i, name)
+}
+
+// base64Arg returns the base64 decoded argument i
+func base64Arg(args []js.Value, i int, name string) ([]byte, error) {
+	s, err := stringArg(args, i, name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, argumentError{"Expected a base64 encoded data"}
+	}
+
+	return data, nil
+}
+
+// jsonArg decodes the argument i into v using JSON.stringify
+func jsonArg(args []js.Value, i int, name string, v interface{}) error {
+	if i >= len(args) || args[i].IsUndefined() {
+		return argumentError{fmt.Sprintf("expected argument %q", name)}
+	}
+
+	s := js.Global().Get("JSON").Call("stringify", args[i]).String()
+
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		return argumentError{fmt.Sprintf("invalid argument %q: %s", name, err)}
+	}
+
+	return nil
+}
+
+// toJS converts v to a JS value using JSON.parse
+func toJS(v interface{}) (js.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return js.Undefined(), err
+	}
+
+	return js.Global().Get("JSON").Call("parse", string(data)), nil
+}
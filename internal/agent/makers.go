@@ -19,6 +19,7 @@ type MigrationHandler interface {
 	FinishCursor() error
 	NextGPGKey(key *models.GPGKey) bool
 	NextUser(user *models.User) bool
+	NextUserToken(token *models.UserToken) bool
 	NumGPGKeys() (int, error)
 }
 
@@ -31,10 +32,13 @@ type GPGRepository interface {
 	FindGPGKeyByFingerPrint(fingerPrint string, pageStart, pageEnd int) ([]models.GPGKey, error)
 	FindGPGKeyByValue(value string, pageStart, pageEnd int) ([]models.GPGKey, error)
 	FindGPGKeyByName(name string, pageStart, pageEnd int) ([]models.GPGKey, error)
+	SearchGPGKeys(query models.GPGKeySearchQuery) (*models.GPGKeySearchResult, error)
 	FetchGPGKeyByFingerprint(fingerprint string) (*models.GPGKey, error)
 	FetchGPGKeysWithoutSubKeys() (res []models.GPGKey, err error)
 	DeleteGPGKey(key models.GPGKey) error
 	UpdateGPGKey(key models.GPGKey) (err error)
+	AddGPGKeyTombstone(tombstone models.GPGKeyTombstone) error
+	FetchGPGKeyTombstone(fingerPrint string) (*models.GPGKeyTombstone, error)
 }
 
 type UserRepository interface {
@@ -55,6 +59,7 @@ type DatabaseHandler interface {
 	MigrationHandler
 	GPGRepository
 	UserRepository
+	interfaces.ClusterRepository
 	HealthChecker
 }
 
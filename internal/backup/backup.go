@@ -0,0 +1,259 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/slog"
+)
+
+// Version is the current backup archive format version
+const Version = 1
+
+const signedDataPrefix = "CHEVRON_BACKUP"
+const payloadFilename = "chevron-backup.json.gz"
+
+// Source is the database access needed to create a backup
+type Source interface {
+	InitCursor() error
+	FinishCursor() error
+	NextGPGKey(key *models.GPGKey) bool
+	NextUser(user *models.User) bool
+	NextUserToken(token *models.UserToken) bool
+}
+
+// Destination is the database access needed to restore a backup
+type Destination interface {
+	AddGPGKeys(keys []models.GPGKey) ([]string, []bool, error)
+	GetUser(username string) (um *models.User, err error)
+	AddUser(um models.User) (string, error)
+	UpdateUser(um models.User) error
+	GetUserToken(token string) (ut *models.UserToken, err error)
+	AddUserToken(ut models.UserToken) (string, error)
+}
+
+func signedData(archive models.BackupArchive) []byte {
+	return []byte(fmt.Sprintf("%s|%d|%d|%s|%s|%s",
+		signedDataPrefix,
+		archive.Version,
+		archive.CreatedAt.Unix(),
+		archive.MasterKeyFingerprint,
+		archive.RecoveryKeyFingerprint,
+		archive.Payload,
+	))
+}
+
+// Collect reads the public keys, users and tokens from the database and the encrypted private keys from the key backend
+func Collect(log slog.Instance, dbh Source, kb interfaces.StorageBackend) (*models.BackupData, error) {
+	data := &models.BackupData{}
+
+	log.Info("Reading database")
+	err := dbh.InitCursor()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = dbh.FinishCursor()
+	}()
+
+	key := models.GPGKey{}
+	for dbh.NextGPGKey(&key) {
+		data.PublicKeys = append(data.PublicKeys, key)
+		key = models.GPGKey{}
+	}
+
+	user := models.User{}
+	for dbh.NextUser(&user) {
+		data.Users = append(data.Users, user)
+		user = models.User{}
+	}
+
+	token := models.UserToken{}
+	for dbh.NextUserToken(&token) {
+		data.UserTokens = append(data.UserTokens, token)
+		token = models.UserToken{}
+	}
+
+	log.Info("Reading private keys from %s", kb.Name())
+	names, err := kb.List()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		keyData, metadata, err := kb.Read(name)
+		if err != nil {
+			return nil, fmt.Errorf("error reading private key %s: %s", name, err)
+		}
+
+		data.PrivateKeys = append(data.PrivateKeys, models.BackupPrivateKey{
+			Name:     name,
+			Data:     keyData,
+			Metadata: metadata,
+		})
+	}
+
+	log.Info("Collected %d public keys, %d private keys, %d users and %d user tokens", len(data.PublicKeys), len(data.PrivateKeys), len(data.Users), len(data.UserTokens))
+
+	return data, nil
+}
+
+// Seal encrypts data to the recovery key and signs the resulting archive with the master key.
+// The recovery public key should be loaded in gpg
+func Seal(ctx context.Context, sm interfaces.SecretsManager, gpg interfaces.PGPManager, recoveryFingerprint string, data models.BackupData) (*models.BackupArchive, error) {
+	var buff bytes.Buffer
+
+	zw := gzip.NewWriter(&buff)
+	err := json.NewEncoder(zw).Encode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := gpg.Encrypt(ctx, payloadFilename, recoveryFingerprint, buff.Bytes(), false)
+	if err != nil {
+		return nil, fmt.Errorf("error encrypting backup to recovery key: %s", err)
+	}
+
+	archive := models.BackupArchive{
+		Version:                Version,
+		CreatedAt:              time.Now().UTC().Truncate(time.Second),
+		MasterKeyFingerprint:   sm.GetMasterKeyFingerPrint(ctx),
+		RecoveryKeyFingerprint: recoveryFingerprint,
+		Payload:                payload,
+	}
+
+	archive.Signature, err = sm.SignWithMasterKey(ctx, signedData(archive))
+	if err != nil {
+		return nil, fmt.Errorf("error signing backup with master key: %s", err)
+	}
+
+	return &archive, nil
+}
+
+// Open verifies the master key signature of the archive and decrypts its content.
+// The recovery private key should be loaded and unlocked in gpg
+func Open(ctx context.Context, sm interfaces.SecretsManager, gpg interfaces.PGPManager, archive models.BackupArchive) (*models.BackupData, error) {
+	if archive.Version != Version {
+		return nil, fmt.Errorf("unsupported backup version %d", archive.Version)
+	}
+
+	err := sm.VerifyMasterKeySignature(ctx, signedData(archive), archive.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup signature: %s", err)
+	}
+
+	dec, err := gpg.Decrypt(ctx, archive.Payload, false)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting backup with recovery key: %s", err)
+	}
+
+	compressed, err := base64.StdEncoding.DecodeString(dec.Base64Data)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &models.BackupData{}
+
+	err = json.Unmarshal(raw, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Restore writes the backup content to the database and key backend. A failed restore can be run again, since existing private
+// keys are overwritten, public keys are updated by fingerprint, existing users are updated and existing tokens are kept.
+// The tokens are not restored if the database does not store them
+func Restore(log slog.Instance, dbh Destination, kb interfaces.StorageBackend, data models.BackupData) error {
+	tokens := data.UserTokens
+
+	if ts, ok := dbh.(interfaces.UserTokenSupport); ok && !ts.SupportsUserTokens() && len(tokens) > 0 {
+		log.Warn("The database does not store user tokens (set REDIS_ENABLE to store them). %d user tokens will not be restored", len(tokens))
+		tokens = nil
+	}
+
+	log.Info("Restoring %d private keys to %s", len(data.PrivateKeys), kb.Name())
+	for _, pk := range data.PrivateKeys {
+		err := kb.SaveWithMetadata(pk.Name, pk.Data, pk.Metadata)
+		if err != nil {
+			return fmt.Errorf("error restoring private key %s: %s", pk.Name, err)
+		}
+	}
+
+	log.Info("Restoring %d public keys", len(data.PublicKeys))
+	if len(data.PublicKeys) > 0 {
+		keys := make([]models.GPGKey, len(data.PublicKeys))
+		for i, key := range data.PublicKeys {
+			key.ID = "" // Re-generate the ID
+			keys[i] = key
+		}
+
+		_, _, err := dbh.AddGPGKeys(keys)
+		if err != nil {
+			return fmt.Errorf("error restoring public keys: %s", err)
+		}
+	}
+
+	log.Info("Restoring %d users", len(data.Users))
+	for _, user := range data.Users {
+		user.ID = "" // Re-generate the ID
+		oldUser, err := dbh.GetUser(user.Username)
+		if err == nil && oldUser != nil {
+			user.ID = oldUser.ID
+			err = dbh.UpdateUser(user)
+		} else {
+			_, err = dbh.AddUser(user)
+		}
+
+		if err != nil {
+			return fmt.Errorf("error restoring user %s: %s", user.Username, err)
+		}
+	}
+
+	restoredTokens := 0
+	for _, token := range tokens {
+		if token.Expiration.Before(time.Now()) {
+			continue
+		}
+
+		if _, err := dbh.GetUserToken(token.Token); err == nil {
+			continue
+		}
+
+		token.ID = "" // Re-generate the ID
+		_, err := dbh.AddUserToken(token)
+		if err != nil {
+			return fmt.Errorf("error restoring token of user %s: %s", token.Username, err)
+		}
+		restoredTokens++
+	}
+
+	log.Info("Restored %d user tokens", restoredTokens)
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/etc/magicbuilder"
+	"github.com/quan-to/chevron/internal/keybackend"
+	"github.com/quan-to/chevron/internal/keymagic"
+	"github.com/quan-to/chevron/pkg/database/memory"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/models/testmodels"
+	"github.com/quan-to/chevron/test"
+	"github.com/quan-to/slog"
+)
+
+var sm interfaces.SecretsManager
+var recoveryGPG interfaces.PGPManager
+
+func TestMain(m *testing.M) {
+	slog.SetTestMode()
+	config.PrivateKeyFolder = "../../test/data/"
+	config.KeyPrefix = "testkey_"
+	config.KeysBase64Encoded = false
+	config.MasterGPGKeyBase64Encoded = false
+	config.MasterGPGKeyPath = "../../test/data/testkey_privateTestKey.gpg"
+	config.MasterGPGKeyPasswordPath = "../../test/data/testprivatekeyPassword.txt"
+
+	sm = keymagic.MakeSecretsManager(nil, memory.MakeMemoryDBDriver(nil))
+
+	// The test key is used as both master and recovery key
+	keyData, err := ioutil.ReadFile(config.MasterGPGKeyPath)
+	if err != nil {
+		panic(err)
+	}
+
+	recoveryGPG = magicbuilder.MakeVoidPGP(nil, memory.MakeMemoryDBDriver(nil))
+	_, err = recoveryGPG.LoadKey(context.Background(), string(keyData))
+	if err != nil {
+		panic(err)
+	}
+
+	err = recoveryGPG.UnlockKey(context.Background(), test.TestKeyFingerprint, test.TestKeyPassword)
+	if err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	slog.UnsetTestMode()
+	os.Exit(code)
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Scope("Test")
+
+	srcDir, _ := ioutil.TempDir("", "backup-src")
+	defer os.RemoveAll(srcDir)
+	dstDir, _ := ioutil.TempDir("", "backup-dst")
+	defer os.RemoveAll(dstDir)
+
+	srcDB := memory.MakeMemoryDBDriver(nil)
+	_, _, _ = srcDB.AddGPGKey(testmodels.GpgKey)
+	_, _ = srcDB.AddUser(testmodels.User)
+	_, _ = srcDB.AddUserToken(models.UserToken{
+		Username:   testmodels.User.Username,
+		Token:      "VALID",
+		Expiration: time.Now().Add(time.Hour),
+	})
+
+	srcKB := keybackend.MakeSaveToDiskBackend(log, srcDir, "key_")
+	err := srcKB.SaveWithMetadata("ABCD", "private key data", `{"meta": true}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	data, err := Collect(log, srcDB, srcKB)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	archive, err := Seal(ctx, sm, recoveryGPG, test.TestKeyFingerprint, *data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if archive.MasterKeyFingerprint != sm.GetMasterKeyFingerPrint(ctx) {
+		t.Errorf("expected master key fingerprint %s got %s", sm.GetMasterKeyFingerPrint(ctx), archive.MasterKeyFingerprint)
+	}
+
+	opened, err := Open(ctx, sm, recoveryGPG, *archive)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dstDB := memory.MakeMemoryDBDriver(nil)
+	dstKB := keybackend.MakeSaveToDiskBackend(log, dstDir, "other_")
+
+	err = Restore(log, dstDB, dstKB, *opened)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	keyData, metadata, err := dstKB.Read("ABCD")
+	if err != nil {
+		t.Fatalf("expected private key to be restored: %s", err)
+	}
+
+	if keyData != "private key data" || metadata != `{"meta": true}` {
+		t.Errorf("restored private key does not match: %q %q", keyData, metadata)
+	}
+
+	if _, err := dstDB.FetchGPGKeyByFingerprint(testmodels.GpgKey.FullFingerprint); err != nil {
+		t.Errorf("expected public key to be restored: %s", err)
+	}
+
+	if _, err := dstDB.GetUser(testmodels.User.Username); err != nil {
+		t.Errorf("expected user to be restored: %s", err)
+	}
+
+	if _, err := dstDB.GetUserToken("VALID"); err != nil {
+		t.Errorf("expected token to be restored: %s", err)
+	}
+
+	// Restoring again does not duplicate anything
+	err = Restore(log, dstDB, dstKB, *opened)
+	if err != nil {
+		t.Fatalf("unexpected error restoring again: %s", err)
+	}
+
+	if n, _ := dstDB.NumGPGKeys(); n != 1 {
+		t.Errorf("expected 1 public key after restoring again, got %d", n)
+	}
+
+	// Tokens are skipped when the database does not store them
+	tokenlessDB := tokenlessDestination{memory.MakeMemoryDBDriver(nil)}
+
+	err = Restore(log, tokenlessDB, dstKB, *opened)
+	if err != nil {
+		t.Fatalf("unexpected error restoring without token support: %s", err)
+	}
+
+	if _, err := tokenlessDB.GetUser(testmodels.User.Username); err != nil {
+		t.Errorf("expected user to be restored without token support: %s", err)
+	}
+}
+
+// tokenlessDestination is a database that does not store user tokens, like postgres without REDIS
+type tokenlessDestination struct {
+	*memory.DbDriver
+}
+
+func (d tokenlessDestination) SupportsUserTokens() bool {
+	return false
+}
+
+func (d tokenlessDestination) AddUserToken(models.UserToken) (string, error) {
+	return "", fmt.Errorf("token is not supported")
+}
+
+func TestOpenTampered(t *testing.T) {
+	ctx := context.Background()
+
+	archive, err := Seal(ctx, sm, recoveryGPG, test.TestKeyFingerprint, models.BackupData{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tampered := *archive
+	tampered.RecoveryKeyFingerprint = "0000000000000000"
+
+	if _, err := Open(ctx, sm, recoveryGPG, tampered); err == nil {
+		t.Errorf("expected tampered archive to be rejected")
+	}
+
+	tampered = *archive
+	tampered.Version = 99
+
+	if _, err := Open(ctx, sm, recoveryGPG, tampered); err == nil {
+		t.Errorf("expected unknown version to be rejected")
+	}
+}
@@ -0,0 +1,121 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/slog"
+)
+
+const busChannel = "chevron_cluster_passwords"
+
+// PubSub is the publish / subscribe access needed by the redis cluster bus
+type PubSub interface {
+	Publish(ctx context.Context, channel, message string) error
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+}
+
+// Notifier is the NOTIFY / LISTEN access needed by the postgres cluster bus
+type Notifier interface {
+	Notify(ctx context.Context, channel, message string) error
+	Listen(ctx context.Context, channel string) (<-chan string, error)
+}
+
+// MakeClusterBus creates the ClusterBus selected by config.ClusterBus.
+// Returns nil if the bus is disabled, in which case passwords are only shared by the periodic reconciliation
+func MakeClusterBus(log slog.Instance, dbh interface{}, discovery interfaces.PeerDiscovery) interfaces.ClusterBus {
+	if log == nil {
+		log = slog.Scope("ClusterBus")
+	} else {
+		log = log.SubScope("ClusterBus")
+	}
+
+	switch config.ClusterBus {
+	case "", "none":
+	case "redis":
+		ps, ok := dbh.(PubSub)
+		if !config.EnableRedis || !ok {
+			log.Error("Redis cluster bus selected, but redis is not enabled. Cluster bus disabled")
+			return nil
+		}
+		return MakeBrokerBus("redis", ps.Publish, ps.Subscribe)
+	case "postgres":
+		n, ok := dbh.(Notifier)
+		if config.DatabaseDialect != "postgres" || !ok {
+			log.Error("PostgreSQL cluster bus selected, but the database is not PostgreSQL. Cluster bus disabled")
+			return nil
+		}
+		return MakeBrokerBus("postgres", n.Notify, n.Listen)
+	case "http":
+		if discovery == nil {
+			log.Error("HTTP cluster bus selected, but cluster discovery is disabled. Cluster bus disabled")
+			return nil
+		}
+		return MakeHTTPBus(discovery)
+	default:
+		log.Error("Unknown cluster bus %q. Cluster bus disabled", config.ClusterBus)
+	}
+
+	return nil
+}
+
+type brokerBus struct {
+	name      string
+	publish   func(ctx context.Context, channel, message string) error
+	subscribe func(ctx context.Context, channel string) (<-chan string, error)
+}
+
+// MakeBrokerBus creates a ClusterBus over a message broker channel like REDIS pub/sub or PostgreSQL LISTEN / NOTIFY
+func MakeBrokerBus(name string, publish func(ctx context.Context, channel, message string) error, subscribe func(ctx context.Context, channel string) (<-chan string, error)) interfaces.ClusterBus {
+	return &brokerBus{
+		name:      name,
+		publish:   publish,
+		subscribe: subscribe,
+	}
+}
+
+// Name returns the name of the bus
+func (b *brokerBus) Name() string {
+	return b.name
+}
+
+// Publish sends the event to the other cluster nodes
+func (b *brokerBus) Publish(ctx context.Context, event models.ClusterPasswordEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return b.publish(ctx, busChannel, string(data))
+}
+
+// Subscribe returns a channel that receives the events published in the bus until ctx is done
+func (b *brokerBus) Subscribe(ctx context.Context) (<-chan models.ClusterPasswordEvent, error) {
+	messages, err := b.subscribe(ctx, busChannel)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan models.ClusterPasswordEvent)
+
+	go func() {
+		defer close(events)
+		for msg := range messages {
+			var event models.ClusterPasswordEvent
+			if err := json.Unmarshal([]byte(msg), &event); err != nil {
+				clusterLog.Error("Invalid message received from %s bus: %s", b.name, err)
+				continue
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
@@ -0,0 +1,100 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+type fakeBroker struct {
+	sync.Mutex
+	subscribers map[string][]chan string
+}
+
+func (fb *fakeBroker) Publish(ctx context.Context, channel, message string) error {
+	fb.Lock()
+	defer fb.Unlock()
+	for _, s := range fb.subscribers[channel] {
+		s <- message
+	}
+	return nil
+}
+
+func (fb *fakeBroker) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	fb.Lock()
+	defer fb.Unlock()
+	s := make(chan string, 10)
+	fb.subscribers[channel] = append(fb.subscribers[channel], s)
+	return s, nil
+}
+
+func TestBrokerBus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	broker := &fakeBroker{subscribers: map[string][]chan string{}}
+	bus := MakeBrokerBus("fake", broker.Publish, broker.Subscribe)
+
+	if bus.Name() != "fake" {
+		t.Errorf("Expected bus name to be %q got %q", "fake", bus.Name())
+	}
+
+	events, err := bus.Subscribe(ctx)
+	if err != nil {
+		t.Fatalf("Error subscribing: %s", err)
+	}
+
+	event, _ := MakePasswordEvent(ctx, sm, "0000000000000003", "encrypted")
+
+	if err := bus.Publish(ctx, *event); err != nil {
+		t.Fatalf("Error publishing: %s", err)
+	}
+
+	select {
+	case received := <-events:
+		if received != *event {
+			t.Errorf("Expected received event to be the published one")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Event not received")
+	}
+}
+
+func TestHTTPBus(t *testing.T) {
+	ctx := context.Background()
+	received := make(chan models.ClusterPasswordEvent, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/remoteSigner/__internal/__passwordEvent" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var event models.ClusterPasswordEvent
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer ts.Close()
+
+	bus := MakeHTTPBus(MakeStaticDiscovery([]string{ts.URL}, ""))
+
+	event, _ := MakePasswordEvent(ctx, sm, "0000000000000004", "encrypted")
+
+	if err := bus.Publish(ctx, *event); err != nil {
+		t.Fatalf("Error publishing: %s", err)
+	}
+
+	select {
+	case r := <-received:
+		if r != *event {
+			t.Errorf("Expected received event to be the published one")
+		}
+	default:
+		t.Fatalf("Event not received")
+	}
+}
@@ -0,0 +1,54 @@
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+type databaseDiscovery struct {
+	dbh     interfaces.ClusterRepository
+	self    models.ClusterNode
+	timeout time.Duration
+}
+
+// MakeDatabaseDiscovery creates a PeerDiscovery backed by a database registry.
+// Every call to Peers refreshes this node in the registry, and nodes not seen for the timeout are ignored
+func MakeDatabaseDiscovery(dbh interfaces.ClusterRepository, nodeID, advertiseURL string, timeout time.Duration) *databaseDiscovery {
+	return &databaseDiscovery{
+		dbh: dbh,
+		self: models.ClusterNode{
+			ID:      nodeID,
+			Address: advertiseURL,
+		},
+		timeout: timeout,
+	}
+}
+
+func (dd *databaseDiscovery) Name() string {
+	return "database"
+}
+
+func (dd *databaseDiscovery) Peers(ctx context.Context) ([]models.ClusterNode, error) {
+	err := dd.dbh.RegisterClusterNode(dd.self)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := dd.dbh.FetchClusterNodes(time.Now().Add(-dd.timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make([]models.ClusterNode, 0)
+	for _, v := range nodes {
+		if v.ID == dd.self.ID {
+			continue
+		}
+		peers = append(peers, v)
+	}
+
+	return peers, nil
+}
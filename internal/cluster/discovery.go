@@ -0,0 +1,52 @@
+package cluster
+
+import (
+	"net"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/kubernetes"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/slog"
+)
+
+// MakePeerDiscovery creates the PeerDiscovery selected by config.ClusterDiscovery.
+// Returns nil if cluster password sharing is disabled
+func MakePeerDiscovery(log slog.Instance, dbh interfaces.ClusterRepository) interfaces.PeerDiscovery {
+	if log == nil {
+		log = slog.Scope("Discovery")
+	} else {
+		log = log.SubScope("Discovery")
+	}
+
+	switch config.ClusterDiscovery {
+	case "":
+		if kubernetes.InKubernetes() {
+			return MakeKubernetesDiscovery()
+		}
+	case "kubernetes":
+		if !kubernetes.InKubernetes() {
+			log.Error("Kubernetes discovery selected, but not in Kubernetes! Cluster mode disabled")
+			return nil
+		}
+		return MakeKubernetesDiscovery()
+	case "static":
+		return MakeStaticDiscovery(config.ClusterPeers, config.ClusterAdvertiseURL)
+	case "dns":
+		if config.ClusterDNSName == "" {
+			log.Error("DNS discovery selected, but CLUSTER_DNS_NAME is empty. Cluster mode disabled")
+			return nil
+		}
+		return MakeDNSDiscovery(net.DefaultResolver, config.ClusterDNSName, config.HttpPort)
+	case "database":
+		if dbh == nil {
+			log.Error("Database discovery selected, but there is no database handler. Cluster mode disabled")
+			return nil
+		}
+		return MakeDatabaseDiscovery(dbh, config.ClusterNodeID, config.ClusterAdvertiseURL, 3*config.ClusterSyncInterval)
+	case "none":
+	default:
+		log.Error("Unknown cluster discovery %q. Cluster mode disabled", config.ClusterDiscovery)
+	}
+
+	return nil
+}
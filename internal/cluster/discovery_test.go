@@ -0,0 +1,142 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/pkg/database/memory"
+)
+
+type fakeResolver struct {
+	srv   []*net.SRV
+	hosts map[string][]string
+}
+
+func (fr *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if fr.srv == nil {
+		return "", nil, fmt.Errorf("no such host")
+	}
+	return name, fr.srv, nil
+}
+
+func (fr *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if h, ok := fr.hosts[host]; ok {
+		return h, nil
+	}
+	return nil, fmt.Errorf("no such host")
+}
+
+func TestStaticDiscovery(t *testing.T) {
+	sd := MakeStaticDiscovery([]string{"http://10.0.0.1:5100", "http://10.0.0.2:5100", "http://10.0.0.3:5100"}, "http://10.0.0.2:5100")
+
+	peers, err := sd.Peers(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(peers) != 2 {
+		t.Fatalf("Expected 2 peers got %d", len(peers))
+	}
+
+	for _, v := range peers {
+		if v.Address == "http://10.0.0.2:5100" {
+			t.Errorf("Expected self to not be in the peer list")
+		}
+	}
+}
+
+func TestDNSDiscovery(t *testing.T) {
+	resolver := &fakeResolver{
+		hosts: map[string][]string{
+			"chevron.local": {"10.0.0.1", "10.0.0.2"},
+		},
+	}
+
+	dd := MakeDNSDiscovery(resolver, "chevron.local", 5100)
+	dd.localIPs = func() map[string]bool {
+		return map[string]bool{"10.0.0.1": true}
+	}
+
+	peers, err := dd.Peers(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(peers) != 1 {
+		t.Fatalf("Expected 1 peer got %d", len(peers))
+	}
+
+	if peers[0].Address != "http://10.0.0.2:5100" {
+		t.Errorf("Expected http://10.0.0.2:5100 got %s", peers[0].Address)
+	}
+}
+
+func TestDNSDiscoverySRV(t *testing.T) {
+	resolver := &fakeResolver{
+		srv: []*net.SRV{
+			{Target: "node1.chevron.local.", Port: 5100},
+			{Target: "node2.chevron.local.", Port: 5200},
+		},
+		hosts: map[string][]string{
+			"node1.chevron.local": {"10.0.0.1"},
+			"node2.chevron.local": {"10.0.0.2"},
+		},
+	}
+
+	dd := MakeDNSDiscovery(resolver, "_chevron._tcp.chevron.local", 5100)
+	dd.localIPs = func() map[string]bool {
+		return map[string]bool{"10.0.0.1": true}
+	}
+
+	peers, err := dd.Peers(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(peers) != 1 {
+		t.Fatalf("Expected 1 peer got %d", len(peers))
+	}
+
+	if peers[0].Address != "http://node2.chevron.local:5200" {
+		t.Errorf("Expected http://node2.chevron.local:5200 got %s", peers[0].Address)
+	}
+}
+
+func TestDatabaseDiscovery(t *testing.T) {
+	ctx := context.Background()
+	mem := memory.MakeMemoryDBDriver(nil)
+
+	nodeA := MakeDatabaseDiscovery(mem, "node-a", "http://10.0.0.1:5100", time.Minute)
+	nodeB := MakeDatabaseDiscovery(mem, "node-b", "http://10.0.0.2:5100", time.Minute)
+
+	peers, err := nodeA.Peers(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(peers) != 0 {
+		t.Fatalf("Expected no peers got %d", len(peers))
+	}
+
+	peers, err = nodeB.Peers(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(peers) != 1 || peers[0].ID != "node-a" {
+		t.Fatalf("Expected node-a as peer got %+v", peers)
+	}
+
+	expired := MakeDatabaseDiscovery(mem, "node-c", "http://10.0.0.3:5100", -time.Minute)
+	peers, err = expired.Peers(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(peers) != 0 {
+		t.Errorf("Expected nodes not seen within the timeout to be ignored. Got %+v", peers)
+	}
+}
@@ -0,0 +1,120 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// Resolver is the subset of net.Resolver used by the DNS peer discovery
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type dnsDiscovery struct {
+	resolver Resolver
+	name     string
+	port     int
+	localIPs func() map[string]bool
+}
+
+// MakeDNSDiscovery creates a PeerDiscovery that resolves name to find the peers.
+// If name starts with _ it is resolved as a SRV record, otherwise as A / AAAA records using the specified port
+func MakeDNSDiscovery(resolver Resolver, name string, port int) *dnsDiscovery {
+	return &dnsDiscovery{
+		resolver: resolver,
+		name:     name,
+		port:     port,
+		localIPs: localIPs,
+	}
+}
+
+func (dd *dnsDiscovery) Name() string {
+	return "dns"
+}
+
+func (dd *dnsDiscovery) Peers(ctx context.Context) ([]models.ClusterNode, error) {
+	if strings.HasPrefix(dd.name, "_") {
+		return dd.srvPeers(ctx)
+	}
+
+	hosts, err := dd.resolver.LookupHost(ctx, dd.name)
+	if err != nil {
+		return nil, err
+	}
+
+	local := dd.localIPs()
+	nodes := make([]models.ClusterNode, 0)
+
+	for _, host := range hosts {
+		if local[host] {
+			continue
+		}
+		address := net.JoinHostPort(host, strconv.Itoa(dd.port))
+		nodes = append(nodes, models.ClusterNode{
+			ID:      address,
+			Address: fmt.Sprintf("http://%s", address),
+		})
+	}
+
+	return nodes, nil
+}
+
+func (dd *dnsDiscovery) srvPeers(ctx context.Context) ([]models.ClusterNode, error) {
+	_, records, err := dd.resolver.LookupSRV(ctx, "", "", dd.name)
+	if err != nil {
+		return nil, err
+	}
+
+	local := dd.localIPs()
+	nodes := make([]models.ClusterNode, 0)
+
+	for _, srv := range records {
+		target := strings.TrimSuffix(srv.Target, ".")
+		hosts, err := dd.resolver.LookupHost(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+
+		isLocal := false
+		for _, host := range hosts {
+			if local[host] {
+				isLocal = true
+				break
+			}
+		}
+
+		if isLocal {
+			continue
+		}
+
+		address := net.JoinHostPort(target, strconv.Itoa(int(srv.Port)))
+		nodes = append(nodes, models.ClusterNode{
+			ID:      address,
+			Address: fmt.Sprintf("http://%s", address),
+		})
+	}
+
+	return nodes, nil
+}
+
+func localIPs() map[string]bool {
+	ips := map[string]bool{}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ips
+	}
+
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok {
+			ips[ipNet.IP.String()] = true
+		}
+	}
+
+	return ips
+}
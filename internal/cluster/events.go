@@ -0,0 +1,98 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// MakePasswordEvent creates a master key signed event announcing the encrypted password of the specified key
+func MakePasswordEvent(ctx context.Context, sm interfaces.SecretsManager, fingerPrint, encryptedPassword string) (*models.ClusterPasswordEvent, error) {
+	return makeSignedPasswords(ctx, sm, map[string]string{fingerPrint: encryptedPassword}, config.ClusterNodeID)
+}
+
+// MakePasswordPost creates a master key signed list of encrypted passwords to be stored by a peer
+func MakePasswordPost(ctx context.Context, sm interfaces.SecretsManager, passwords map[string]string) (*models.ClusterPasswordEvent, error) {
+	return makeSignedPasswords(ctx, sm, passwords, "")
+}
+
+func makeSignedPasswords(ctx context.Context, sm interfaces.SecretsManager, passwords map[string]string, origin string) (*models.ClusterPasswordEvent, error) {
+	nonce, err := generateNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := json.Marshal(models.ClusterPasswordPayload{
+		Nonce:     nonce,
+		Timestamp: time.Now().Unix(),
+		Passwords: passwords,
+		Origin:    origin,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := sm.SignWithMasterKey(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ClusterPasswordEvent{
+		Payload:   string(payload),
+		Signature: signature,
+	}, nil
+}
+
+// VerifyPasswordEvent checks if the event was signed by the master key, is recent and was not seen before. Returns the event payload
+func VerifyPasswordEvent(ctx context.Context, sm interfaces.SecretsManager, nonces *NonceCache, event models.ClusterPasswordEvent) (*models.ClusterPasswordPayload, error) {
+	if err := sm.VerifyMasterKeySignature(ctx, []byte(event.Payload), event.Signature); err != nil {
+		return nil, fmt.Errorf("invalid event signature: %s", err)
+	}
+
+	var payload models.ClusterPasswordPayload
+
+	if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+		return nil, err
+	}
+
+	if len(payload.Nonce) == 0 {
+		return nil, fmt.Errorf("empty nonce")
+	}
+
+	if err := checkTimestamp(payload.Timestamp); err != nil {
+		return nil, err
+	}
+
+	if !nonces.Use(payload.Nonce) {
+		return nil, fmt.Errorf("nonce %s was already used", payload.Nonce)
+	}
+
+	return &payload, nil
+}
+
+// ApplyPasswordEvent verifies the event, stores its passwords and unlocks the local keys with them.
+// Events published by this node are ignored.
+func ApplyPasswordEvent(ctx context.Context, sm interfaces.SecretsManager, gpg interfaces.PGPManager, nonces *NonceCache, event models.ClusterPasswordEvent) error {
+	payload, err := VerifyPasswordEvent(ctx, sm, nonces, event)
+	if err != nil {
+		return err
+	}
+
+	if payload.Origin == config.ClusterNodeID {
+		return nil
+	}
+
+	for fp, encryptedPassword := range payload.Passwords {
+		sm.PutEncryptedPassword(ctx, fp, encryptedPassword)
+	}
+
+	sm.UnlockLocalKeys(ctx, gpg)
+
+	return nil
+}
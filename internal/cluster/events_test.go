@@ -0,0 +1,77 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+func TestPasswordEvent(t *testing.T) {
+	ctx := context.Background()
+	nonces := MakeNonceCache(time.Minute)
+
+	event, err := MakePasswordEvent(ctx, sm, "0000000000000001", "encrypted")
+	if err != nil {
+		t.Fatalf("Error creating event: %s", err)
+	}
+
+	payload, err := VerifyPasswordEvent(ctx, sm, nonces, *event)
+	if err != nil {
+		t.Fatalf("Expected event to be valid. Got %s", err)
+	}
+
+	if payload.Passwords["0000000000000001"] != "encrypted" {
+		t.Errorf("Expected event password to be %q got %q", "encrypted", payload.Passwords["0000000000000001"])
+	}
+
+	if payload.Origin != config.ClusterNodeID {
+		t.Errorf("Expected event origin to be %q got %q", config.ClusterNodeID, payload.Origin)
+	}
+
+	if _, err := VerifyPasswordEvent(ctx, sm, nonces, *event); err == nil {
+		t.Errorf("Expected replayed event to be rejected")
+	}
+
+	tampered, _ := MakePasswordEvent(ctx, sm, "0000000000000001", "encrypted")
+	tampered.Payload = tampered.Payload[:len(tampered.Payload)-1] + " }"
+	if _, err := VerifyPasswordEvent(ctx, sm, nonces, *tampered); err == nil {
+		t.Errorf("Expected tampered event to be rejected")
+	}
+}
+
+func TestApplyPasswordEvent(t *testing.T) {
+	ctx := context.Background()
+	nonces := MakeNonceCache(time.Minute)
+	localNode := config.ClusterNodeID
+	defer func() { config.ClusterNodeID = localNode }()
+
+	// Own events are ignored
+	config.ClusterNodeID = "node-a"
+	event, _ := MakePasswordEvent(ctx, sm, "0000000000000002", "own")
+	if err := ApplyPasswordEvent(ctx, sm, gpg, nonces, *event); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, ok := sm.GetPasswords(ctx)["0000000000000002"]; ok {
+		t.Errorf("Expected own event to be ignored")
+	}
+
+	// Events from other nodes are stored
+	config.ClusterNodeID = "node-b"
+	event, _ = MakePasswordEvent(ctx, sm, "0000000000000002", "remote")
+	config.ClusterNodeID = "node-a"
+	if err := ApplyPasswordEvent(ctx, sm, gpg, nonces, *event); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if sm.GetPasswords(ctx)["0000000000000002"] != "remote" {
+		t.Errorf("Expected remote event password to be stored")
+	}
+
+	if err := ApplyPasswordEvent(ctx, sm, gpg, nonces, models.ClusterPasswordEvent{Payload: "{}"}); err == nil {
+		t.Errorf("Expected unsigned event to be rejected")
+	}
+}
@@ -0,0 +1,191 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+const passwordRequestPrefix = "CHEVRON_CLUSTER_PASSWORD_REQUEST"
+const unlockRequestPrefix = "CHEVRON_CLUSTER_UNLOCK_REQUEST"
+const nonceSize = 16
+const exchangeTimeout = 10 * time.Second
+
+func generateNonce() (string, error) {
+	n := make([]byte, nonceSize)
+	_, err := rand.Read(n)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(n), nil
+}
+
+// signedRequestData returns the data signed by a request. The prefix binds the signature to a single request type
+func signedRequestData(prefix, nonce string, timestamp int64) []byte {
+	return []byte(fmt.Sprintf("%s|%s|%d", prefix, nonce, timestamp))
+}
+
+func checkTimestamp(timestamp int64) error {
+	diff := time.Since(time.Unix(timestamp, 0))
+	if diff < 0 {
+		diff = -diff
+	}
+
+	if diff > config.ClusterMaxClockSkew {
+		return fmt.Errorf("timestamp is %s away from local clock (max %s)", diff, config.ClusterMaxClockSkew)
+	}
+
+	return nil
+}
+
+// MakePasswordRequest creates a new password request with a random nonce signed by the master key
+func MakePasswordRequest(ctx context.Context, sm interfaces.SecretsManager) (*models.ClusterPasswordRequest, error) {
+	return makeSignedRequest(ctx, sm, passwordRequestPrefix)
+}
+
+// VerifyPasswordRequest checks if the request was signed by the master key, is recent and was not seen before
+func VerifyPasswordRequest(ctx context.Context, sm interfaces.SecretsManager, nonces *NonceCache, req models.ClusterPasswordRequest) error {
+	return verifySignedRequest(ctx, sm, nonces, passwordRequestPrefix, req)
+}
+
+// MakeUnlockRequest creates a new request to unlock the local keys of a node, with a random nonce signed by the master key
+func MakeUnlockRequest(ctx context.Context, sm interfaces.SecretsManager) (*models.ClusterPasswordRequest, error) {
+	return makeSignedRequest(ctx, sm, unlockRequestPrefix)
+}
+
+// VerifyUnlockRequest checks if the unlock request was signed by the master key, is recent and was not seen before
+func VerifyUnlockRequest(ctx context.Context, sm interfaces.SecretsManager, nonces *NonceCache, req models.ClusterPasswordRequest) error {
+	return verifySignedRequest(ctx, sm, nonces, unlockRequestPrefix, req)
+}
+
+func makeSignedRequest(ctx context.Context, sm interfaces.SecretsManager, prefix string) (*models.ClusterPasswordRequest, error) {
+	nonce, err := generateNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp := time.Now().Unix()
+
+	signature, err := sm.SignWithMasterKey(ctx, signedRequestData(prefix, nonce, timestamp))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ClusterPasswordRequest{
+		Nonce:     nonce,
+		Timestamp: timestamp,
+		Signature: signature,
+	}, nil
+}
+
+func verifySignedRequest(ctx context.Context, sm interfaces.SecretsManager, nonces *NonceCache, prefix string, req models.ClusterPasswordRequest) error {
+	if len(req.Nonce) == 0 {
+		return fmt.Errorf("empty nonce")
+	}
+
+	if err := checkTimestamp(req.Timestamp); err != nil {
+		return err
+	}
+
+	if err := sm.VerifyMasterKeySignature(ctx, signedRequestData(prefix, req.Nonce, req.Timestamp), req.Signature); err != nil {
+		return fmt.Errorf("invalid request signature: %s", err)
+	}
+
+	if !nonces.Use(req.Nonce) {
+		return fmt.Errorf("nonce %s was already used", req.Nonce)
+	}
+
+	return nil
+}
+
+// MakePasswordResponse creates a master key signed response containing the stored encrypted passwords bound to the request nonce
+func MakePasswordResponse(ctx context.Context, sm interfaces.SecretsManager, req models.ClusterPasswordRequest) (*models.ClusterPasswordResponse, error) {
+	payload, err := json.Marshal(models.ClusterPasswordPayload{
+		Nonce:     req.Nonce,
+		Timestamp: time.Now().Unix(),
+		Passwords: sm.GetPasswords(ctx),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := sm.SignWithMasterKey(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ClusterPasswordResponse{
+		Payload:   string(payload),
+		Signature: signature,
+	}, nil
+}
+
+// VerifyPasswordResponse checks if the response was signed by the master key and matches the request. Returns the received passwords
+func VerifyPasswordResponse(ctx context.Context, sm interfaces.SecretsManager, req models.ClusterPasswordRequest, res models.ClusterPasswordResponse) (map[string]string, error) {
+	if err := sm.VerifyMasterKeySignature(ctx, []byte(res.Payload), res.Signature); err != nil {
+		return nil, fmt.Errorf("invalid response signature: %s", err)
+	}
+
+	var payload models.ClusterPasswordPayload
+
+	if err := json.Unmarshal([]byte(res.Payload), &payload); err != nil {
+		return nil, err
+	}
+
+	if payload.Nonce != req.Nonce {
+		return nil, fmt.Errorf("response nonce %q does not match request nonce %q", payload.Nonce, req.Nonce)
+	}
+
+	if err := checkTimestamp(payload.Timestamp); err != nil {
+		return nil, err
+	}
+
+	return payload.Passwords, nil
+}
+
+// FetchPeerPasswords asks the node at baseURL for its encrypted passwords and verifies the answer
+func FetchPeerPasswords(ctx context.Context, sm interfaces.SecretsManager, baseURL string) (map[string]string, error) {
+	req, err := MakePasswordRequest(ctx, sm)
+	if err != nil {
+		return nil, err
+	}
+
+	body, _ := json.Marshal(req)
+
+	client := &http.Client{Timeout: exchangeTimeout}
+	res, err := client.Post(fmt.Sprintf("%s/remoteSigner/__internal/__getUnlockPasswords", baseURL), models.MimeJSON, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer returned status %d: %s", res.StatusCode, string(data))
+	}
+
+	var response models.ClusterPasswordResponse
+
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, err
+	}
+
+	return VerifyPasswordResponse(ctx, sm, *req, response)
+}
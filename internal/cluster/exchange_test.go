@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/etc/magicbuilder"
+	"github.com/quan-to/chevron/internal/keymagic"
+	"github.com/quan-to/chevron/pkg/database/memory"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/slog"
+)
+
+var sm interfaces.SecretsManager
+var gpg interfaces.PGPManager
+
+func TestMain(m *testing.M) {
+	slog.SetTestMode()
+	config.PrivateKeyFolder = "../../test/data/"
+	config.KeyPrefix = "testkey_"
+	config.KeysBase64Encoded = false
+	config.MasterGPGKeyBase64Encoded = false
+	config.MasterGPGKeyPath = "../../test/data/testkey_privateTestKey.gpg"
+	config.MasterGPGKeyPasswordPath = "../../test/data/testprivatekeyPassword.txt"
+
+	dbh := memory.MakeMemoryDBDriver(nil)
+	sm = keymagic.MakeSecretsManager(nil, dbh)
+	gpg = magicbuilder.MakePGP(nil, dbh)
+
+	code := m.Run()
+	slog.UnsetTestMode()
+	os.Exit(code)
+}
+
+func TestPasswordExchange(t *testing.T) {
+	ctx := context.Background()
+	sm.PutKeyPassword(ctx, "0000000000000000", "huebr")
+	nonces := MakeNonceCache(time.Minute)
+
+	req, err := MakePasswordRequest(ctx, sm)
+	if err != nil {
+		t.Fatalf("Error creating request: %s", err)
+	}
+
+	if err := VerifyPasswordRequest(ctx, sm, nonces, *req); err != nil {
+		t.Fatalf("Expected request to be valid. Got %s", err)
+	}
+
+	if err := VerifyPasswordRequest(ctx, sm, nonces, *req); err == nil {
+		t.Fatalf("Expected replayed request to be rejected")
+	}
+
+	res, err := MakePasswordResponse(ctx, sm, *req)
+	if err != nil {
+		t.Fatalf("Error creating response: %s", err)
+	}
+
+	passwords, err := VerifyPasswordResponse(ctx, sm, *req, *res)
+	if err != nil {
+		t.Fatalf("Expected response to be valid. Got %s", err)
+	}
+
+	if passwords["0000000000000000"] != sm.GetPasswords(ctx)["0000000000000000"] {
+		t.Errorf("Expected received password to match the stored one")
+	}
+
+	otherReq, _ := MakePasswordRequest(ctx, sm)
+	if _, err := VerifyPasswordResponse(ctx, sm, *otherReq, *res); err == nil {
+		t.Errorf("Expected response for another nonce to be rejected")
+	}
+
+	tampered := *res
+	tampered.Payload = tampered.Payload[:len(tampered.Payload)-1] + " }"
+	if _, err := VerifyPasswordResponse(ctx, sm, *req, tampered); err == nil {
+		t.Errorf("Expected tampered response to be rejected")
+	}
+}
+
+func TestPasswordRequestTimestamp(t *testing.T) {
+	ctx := context.Background()
+	nonces := MakeNonceCache(time.Minute)
+
+	req := models.ClusterPasswordRequest{
+		Nonce:     "abcd",
+		Timestamp: time.Now().Add(-2 * config.ClusterMaxClockSkew).Unix(),
+	}
+
+	req.Signature, _ = sm.SignWithMasterKey(ctx, signedRequestData(passwordRequestPrefix, req.Nonce, req.Timestamp))
+
+	if err := VerifyPasswordRequest(ctx, sm, nonces, req); err == nil {
+		t.Errorf("Expected expired request to be rejected")
+	}
+}
+
+func TestFetchPeerPasswords(t *testing.T) {
+	ctx := context.Background()
+	nonces := MakeNonceCache(time.Minute)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.ClusterPasswordRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		if err := VerifyPasswordRequest(ctx, sm, nonces, req); err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		res, _ := MakePasswordResponse(ctx, sm, req)
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	defer ts.Close()
+
+	passwords, err := FetchPeerPasswords(ctx, sm, ts.URL)
+	if err != nil {
+		t.Fatalf("Error fetching passwords: %s", err)
+	}
+
+	if len(passwords) != len(sm.GetPasswords(ctx)) {
+		t.Errorf("Expected %d passwords got %d", len(sm.GetPasswords(ctx)), len(passwords))
+	}
+}
@@ -0,0 +1,90 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+type httpBus struct {
+	discovery interfaces.PeerDiscovery
+	client    *http.Client
+}
+
+// MakeHTTPBus creates a ClusterBus that posts the events directly to each node found by discovery.
+// The events are received by the internal endpoint, so Subscribe never delivers anything.
+func MakeHTTPBus(discovery interfaces.PeerDiscovery) interfaces.ClusterBus {
+	return &httpBus{
+		discovery: discovery,
+		client:    &http.Client{Timeout: exchangeTimeout},
+	}
+}
+
+// Name returns the name of the bus
+func (b *httpBus) Name() string {
+	return "http"
+}
+
+// Publish sends the event to the other cluster nodes. Returns the last error if any node failed
+func (b *httpBus) Publish(ctx context.Context, event models.ClusterPasswordEvent) error {
+	peers, err := b.discovery.Peers(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(event)
+
+	var lastErr error
+
+	for _, peer := range peers {
+		if err := b.post(ctx, peer.Address, body); err != nil {
+			clusterLog.Error("Error sending password event to %s: %s", peer.Address, err)
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+func (b *httpBus) post(ctx context.Context, baseURL string, body []byte) error {
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/remoteSigner/__internal/__passwordEvent", baseURL), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req = req.WithContext(ctx)
+
+	req.Header.Set("Content-Type", models.MimeJSON)
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	data, _ := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("peer returned status %d: %s", res.StatusCode, string(data))
+	}
+
+	return nil
+}
+
+// Subscribe returns a channel that is closed when ctx is done
+func (b *httpBus) Subscribe(ctx context.Context) (<-chan models.ClusterPasswordEvent, error) {
+	events := make(chan models.ClusterPasswordEvent)
+
+	go func() {
+		<-ctx.Done()
+		close(events)
+	}()
+
+	return events, nil
+}
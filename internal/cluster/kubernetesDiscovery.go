@@ -0,0 +1,42 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/kubernetes"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+type kubernetesDiscovery struct{}
+
+// MakeKubernetesDiscovery creates a PeerDiscovery that lists the running pods of the current namespace
+func MakeKubernetesDiscovery() *kubernetesDiscovery {
+	return &kubernetesDiscovery{}
+}
+
+func (kd *kubernetesDiscovery) Name() string {
+	return "kubernetes"
+}
+
+func (kd *kubernetesDiscovery) Peers(ctx context.Context) ([]models.ClusterNode, error) {
+	myId := kubernetes.Me().Metadata.UID
+	nodes := make([]models.ClusterNode, 0)
+
+	for _, pod := range kubernetes.Pods() {
+		if pod.Metadata.UID == myId {
+			continue
+		}
+		if pod.Status.Phase != kubernetes.Running {
+			continue
+		}
+
+		nodes = append(nodes, models.ClusterNode{
+			ID:      pod.Metadata.UID,
+			Address: fmt.Sprintf("http://%s:%d", pod.Status.PodIP, config.HttpPort),
+		})
+	}
+
+	return nodes, nil
+}
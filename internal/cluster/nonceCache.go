@@ -0,0 +1,43 @@
+package cluster
+
+import (
+	"sync"
+	"time"
+)
+
+// NonceCache keeps track of recently seen nonces to reject replayed password exchanges
+type NonceCache struct {
+	sync.Mutex
+	ttl    time.Duration
+	nonces map[string]time.Time
+}
+
+// MakeNonceCache creates a NonceCache that remembers nonces for the specified time
+func MakeNonceCache(ttl time.Duration) *NonceCache {
+	return &NonceCache{
+		ttl:    ttl,
+		nonces: make(map[string]time.Time),
+	}
+}
+
+// Use marks the nonce as seen. Returns false if the nonce was already used before
+func (nc *NonceCache) Use(nonce string) bool {
+	nc.Lock()
+	defer nc.Unlock()
+
+	now := time.Now()
+
+	for k, expiration := range nc.nonces {
+		if now.After(expiration) {
+			delete(nc.nonces, k)
+		}
+	}
+
+	if _, ok := nc.nonces[nonce]; ok {
+		return false
+	}
+
+	nc.nonces[nonce] = now.Add(nc.ttl)
+
+	return true
+}
@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/slog"
+)
+
+var clusterLog = slog.Scope("Cluster").Tag(tools.DefaultTag)
+
+// Routine shares the key passwords with the other cluster nodes.
+// New passwords are pushed through bus as soon as they're stored, and the master key encrypted passwords of the peers found
+// by discovery are fetched every config.ClusterSyncInterval as a fallback. Both bus and discovery can be nil
+func Routine(sm interfaces.SecretsManager, gpg interfaces.PGPManager, discovery interfaces.PeerDiscovery, bus interfaces.ClusterBus, stopSig chan bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var events <-chan models.ClusterPasswordEvent
+
+	if bus != nil {
+		clusterLog.Info("Pushing key passwords using %s bus", bus.Name())
+		sm.OnPasswordStored(func(ctx context.Context, fingerPrint, encryptedPassword string) {
+			publishPassword(ctx, sm, bus, fingerPrint, encryptedPassword)
+		})
+
+		var err error
+		events, err = bus.Subscribe(ctx)
+		if err != nil {
+			clusterLog.Error("Error subscribing to %s bus: %s. Relying on periodic sync only", bus.Name(), err)
+		}
+	}
+
+	if discovery != nil {
+		clusterLog.Info("Starting Cluster Routine using %s discovery", discovery.Name())
+
+		randomWaitTime := rand.Int31n(5)*1000 + 1000 // Milisseconds
+
+		clusterLog.Info("To avoid concurrency on cluster starting we're waiting 1 second plus some random time")
+		clusterLog.Info("The exact time is %d ms", randomWaitTime)
+
+		time.Sleep(time.Millisecond * time.Duration(randomWaitTime))
+
+		clusterLog.Info("Checking for other remote-signer nodes...")
+		syncPasswords(ctx, sm, gpg, discovery)
+	}
+
+	ticker := time.NewTicker(config.ClusterSyncInterval)
+	defer ticker.Stop()
+
+	nonces := MakeNonceCache(2 * config.ClusterMaxClockSkew)
+
+	for {
+		select {
+		case <-stopSig:
+			clusterLog.Info("Cluster Routine Stopped")
+			return
+		case event, ok := <-events:
+			if !ok {
+				clusterLog.Warn("Cluster bus closed. Relying on periodic sync only")
+				events = nil
+				continue
+			}
+			if err := ApplyPasswordEvent(ctx, sm, gpg, nonces, event); err != nil {
+				clusterLog.Error("Rejected password event: %s", err)
+			}
+		case <-ticker.C:
+			if discovery != nil {
+				clusterLog.Info("Checking for other remote-signer nodes...")
+				syncPasswords(ctx, sm, gpg, discovery)
+			}
+		}
+	}
+}
+
+func publishPassword(ctx context.Context, sm interfaces.SecretsManager, bus interfaces.ClusterBus, fingerPrint, encryptedPassword string) {
+	event, err := MakePasswordEvent(ctx, sm, fingerPrint, encryptedPassword)
+	if err != nil {
+		clusterLog.Error("Error creating password event for key %s: %s", fingerPrint, err)
+		return
+	}
+
+	if err := bus.Publish(ctx, *event); err != nil {
+		clusterLog.Error("Error publishing password of key %s to %s bus: %s", fingerPrint, bus.Name(), err)
+		return
+	}
+
+	clusterLog.Info("Published password of key %s to %s bus", fingerPrint, bus.Name())
+}
+
+func syncPasswords(ctx context.Context, sm interfaces.SecretsManager, gpg interfaces.PGPManager, discovery interfaces.PeerDiscovery) {
+	peers, err := discovery.Peers(ctx)
+	if err != nil {
+		clusterLog.Error("Error discovering peers: %s", err)
+		return
+	}
+
+	clusterLog.Info("There are %d peers. Fetching encrypted passwords...", len(peers))
+	passwordCount := 0
+	for _, peer := range peers {
+		passwords, err := FetchPeerPasswords(ctx, sm, peer.Address)
+
+		if err != nil {
+			clusterLog.Error("Error fetching unlock passwords from %s: %s", peer.Address, err)
+			continue
+		}
+
+		passwordCount += len(passwords)
+		clusterLog.Info("Received %d passwords from %s", len(passwords), peer.Address)
+
+		for fp, encryptedPassword := range passwords {
+			sm.PutEncryptedPassword(ctx, fp, encryptedPassword)
+		}
+	}
+
+	if passwordCount == 0 {
+		clusterLog.Info("No passwords received")
+		return
+	}
+
+	clusterLog.Info("Received %d passwords from %d peers. Triggering Local Unlock", passwordCount, len(peers))
+	sm.UnlockLocalKeys(ctx, gpg)
+}
@@ -0,0 +1,39 @@
+package cluster
+
+import (
+	"context"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+type staticDiscovery struct {
+	peers []models.ClusterNode
+}
+
+// MakeStaticDiscovery creates a PeerDiscovery that returns a fixed list of peers.
+// The entry equal to selfURL is skipped so the same list can be used on every node
+func MakeStaticDiscovery(peerURLs []string, selfURL string) *staticDiscovery {
+	sd := &staticDiscovery{
+		peers: make([]models.ClusterNode, 0),
+	}
+
+	for _, v := range peerURLs {
+		if v == selfURL {
+			continue
+		}
+		sd.peers = append(sd.peers, models.ClusterNode{
+			ID:      v,
+			Address: v,
+		})
+	}
+
+	return sd
+}
+
+func (sd *staticDiscovery) Name() string {
+	return "static"
+}
+
+func (sd *staticDiscovery) Peers(ctx context.Context) ([]models.ClusterNode, error) {
+	return sd.peers, nil
+}
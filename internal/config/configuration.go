@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"strings"
@@ -17,8 +18,37 @@ var SyslogFacility string
 var PrivateKeyFolder string
 var KeyPrefix string
 var SKSServer string
+
+// KeyServers is the ordered list of upstream keyservers used to find keys that are not in the Public Key Store
+var KeyServers []string
 var HttpPort int
 var MaxKeyRingCache int
+
+// KeyRingRefreshInterval is the maximum age of a public key cached from the Public Key Store before it is fetched again
+var KeyRingRefreshInterval time.Duration
+
+// PKSEmailVerification keeps the user ids of keys uploaded to the Public Key Store unpublished until their email is verified
+var PKSEmailVerification bool
+
+// PKSVerificationSecret is the secret used to sign the email verification tokens. A random one is used if empty
+var PKSVerificationSecret string
+
+// PKSVerificationURL is the base URL of this server used in the email verification links
+var PKSVerificationURL string
+
+// PKSVerificationTokenTTL is how long a email verification token is valid
+var PKSVerificationTokenTTL time.Duration
+
+// Mailer is the method used to send emails (smtp, file or log)
+var Mailer string
+
+// MailerFolder is the folder where the file mailer writes the emails
+var MailerFolder string
+var SMTPHost string
+var SMTPPort int
+var SMTPUsername string
+var SMTPPassword string
+var SMTPFrom string
 var EnableDatabase bool
 var RethinkDBHost string
 var RethinkDBPort int
@@ -74,12 +104,73 @@ var RedisTLSEnabled bool
 var RedisMaxLocalObjects int
 var RedisLocalObjectTTL time.Duration
 
+// ClusterDiscovery is the method used to find the other cluster nodes (kubernetes, static, dns, database or none)
+var ClusterDiscovery string
+
+// ClusterPeers is the list of peer base URLs used by the static cluster discovery
+var ClusterPeers []string
+
+// ClusterDNSName is the DNS name used by the dns cluster discovery. Names starting with _ are resolved as SRV records
+var ClusterDNSName string
+
+// ClusterNodeID is the identifier of this node in the cluster registry
+var ClusterNodeID string
+
+// ClusterAdvertiseURL is the base URL that the other cluster nodes should use to reach this node
+var ClusterAdvertiseURL string
+
+// ClusterMaxClockSkew is the maximum accepted difference between the timestamp of a cluster password exchange and the local clock
+var ClusterMaxClockSkew time.Duration
+
+// ClusterBus is the message bus used to push new key passwords to the other cluster nodes (redis, postgres, http or none)
+var ClusterBus string
+
+// ClusterSyncInterval is the interval between the full password reconciliation with the other cluster nodes
+var ClusterSyncInterval time.Duration
+
+// BlindIndexEnabled enables the fieldcipher blind indexes. Their key is derived from the master key
+var BlindIndexEnabled bool
+
+// TrustedKeys is the list of fingerprints of the keys that are trusted to sign data
+var TrustedKeys []string
+
+// TrustCAKeys is the list of fingerprints of the keys whose certifications make the certified keys trusted
+var TrustCAKeys []string
+
+// TrustRequiredEndpoints is the list of verify endpoints that reject signatures made by untrusted keys
+var TrustRequiredEndpoints []string
+
+// SignatureKnownNotations is the list of critical notation names accepted by the verify endpoints
+var SignatureKnownNotations []string
+
+// TimestampAuthorityURL is the URL of the RFC 3161 timestamp authority used to timestamp signatures
+var TimestampAuthorityURL string
+
+// TimestampAuthorityKey is the fingerprint of the key used by the built-in timestamp authority
+var TimestampAuthorityKey string
+
+// TimestampTrustedCerts is the PEM file with the root certificates of the trusted timestamp authorities
+var TimestampTrustedCerts string
+
 var SetExposedServices bool
 var ExposedServices []string
 
 // LogFormat allows to configure the output log format
 var LogFormat slog.Format
 
+// IsTrustRequired returns if the verify endpoint only accepts signatures made by trusted keys
+func IsTrustRequired(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, v := range TrustRequiredEndpoints {
+		if v == name {
+			return true
+		}
+	}
+
+	return false
+}
+
 func IsServiceExposed(name string) bool {
 	if !SetExposedServices {
 		return true
@@ -104,6 +195,25 @@ func configDeprecationMessage(userConfig, newConfig string) {
 	}
 }
 
+// parseFingerPrintList parses a comma separated list of fingerprints with at least 16 characters
+func parseFingerPrintList(envName string) []string {
+	var fingerPrints []string
+
+	for _, v := range strings.Split(os.Getenv(envName), ",") {
+		v = strings.ToUpper(strings.Replace(strings.TrimSpace(v), " ", "", -1))
+		if v == "" {
+			continue
+		}
+		if len(v) < 16 {
+			slog.Error("Invalid fingerprint %q in %s - It should have at least 16 characters", v, envName)
+			continue
+		}
+		fingerPrints = append(fingerPrints, v)
+	}
+
+	return fingerPrints
+}
+
 func Setup() {
 	var err error
 	// Pre init
@@ -123,6 +233,12 @@ func Setup() {
 	SyslogFacility = os.Getenv("SYSLOG_FACILITY")
 	PrivateKeyFolder = os.Getenv("PRIVATE_KEY_FOLDER")
 	SKSServer = os.Getenv("SKS_SERVER")
+	KeyServers = nil
+	for _, v := range strings.Split(os.Getenv("KEYSERVERS"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			KeyServers = append(KeyServers, v)
+		}
+	}
 	KeyPrefix = os.Getenv("KEY_PREFIX")
 	ShowLines = os.Getenv("SHOW_LINES") == "true"
 	EnableSwagger = os.Getenv("ENABLE_SWAGGER") == "" || os.Getenv("ENABLE_SWAGGER") == "true"
@@ -139,6 +255,41 @@ func Setup() {
 		MaxKeyRingCache = int(i)
 	}
 
+	keyRingRefreshInterval := os.Getenv("KEYRING_REFRESH_INTERVAL")
+	if keyRingRefreshInterval != "" {
+		if KeyRingRefreshInterval, err = time.ParseDuration(keyRingRefreshInterval); err != nil {
+			slog.Error("Invalid field KEYRING_REFRESH_INTERVAL = %q - Invalid Duration", keyRingRefreshInterval)
+		}
+	}
+
+	PKSEmailVerification = os.Getenv("PKS_EMAIL_VERIFICATION") == "true"
+	PKSVerificationSecret = os.Getenv("PKS_VERIFICATION_SECRET")
+	PKSVerificationURL = strings.TrimRight(os.Getenv("PKS_VERIFICATION_URL"), "/")
+
+	pksVerificationTokenTTL := os.Getenv("PKS_VERIFICATION_TOKEN_TTL")
+	if pksVerificationTokenTTL != "" {
+		if PKSVerificationTokenTTL, err = time.ParseDuration(pksVerificationTokenTTL); err != nil {
+			slog.Error("Invalid field PKS_VERIFICATION_TOKEN_TTL = %q - Invalid Duration", pksVerificationTokenTTL)
+		}
+	}
+
+	Mailer = strings.ToLower(os.Getenv("MAILER"))
+	MailerFolder = os.Getenv("MAILER_FOLDER")
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPUsername = os.Getenv("SMTP_USERNAME")
+	SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	SMTPFrom = os.Getenv("SMTP_FROM")
+	SMTPPort = 0
+
+	var smtpPort = os.Getenv("SMTP_PORT")
+	if smtpPort != "" {
+		i, err := strconv.ParseInt(smtpPort, 10, 32)
+		if err != nil {
+			slog.Error("Invalid field SMTP_PORT = %q - Invalid number", smtpPort)
+		}
+		SMTPPort = int(i)
+	}
+
 	var hp = os.Getenv("HTTP_PORT")
 	if hp != "" {
 		i, err := strconv.ParseInt(hp, 10, 32)
@@ -258,6 +409,54 @@ func Setup() {
 		RedisMaxLocalObjects = int(v)
 	}
 
+	ClusterDiscovery = strings.ToLower(os.Getenv("CLUSTER_DISCOVERY"))
+	ClusterPeers = nil
+	for _, v := range strings.Split(os.Getenv("CLUSTER_PEERS"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			ClusterPeers = append(ClusterPeers, strings.TrimRight(v, "/"))
+		}
+	}
+	ClusterDNSName = os.Getenv("CLUSTER_DNS_NAME")
+	ClusterNodeID = os.Getenv("CLUSTER_NODE_ID")
+	ClusterAdvertiseURL = strings.TrimRight(os.Getenv("CLUSTER_ADVERTISE_URL"), "/")
+
+	clusterMaxClockSkew := os.Getenv("CLUSTER_MAX_CLOCK_SKEW")
+	if clusterMaxClockSkew != "" {
+		if ClusterMaxClockSkew, err = time.ParseDuration(clusterMaxClockSkew); err != nil {
+			slog.Error("Invalid field CLUSTER_MAX_CLOCK_SKEW = %q - Invalid Duration", clusterMaxClockSkew)
+		}
+	}
+
+	ClusterBus = strings.ToLower(os.Getenv("CLUSTER_BUS"))
+
+	clusterSyncInterval := os.Getenv("CLUSTER_SYNC_INTERVAL")
+	if clusterSyncInterval != "" {
+		if ClusterSyncInterval, err = time.ParseDuration(clusterSyncInterval); err != nil {
+			slog.Error("Invalid field CLUSTER_SYNC_INTERVAL = %q - Invalid Duration", clusterSyncInterval)
+		}
+	}
+
+	BlindIndexEnabled = os.Getenv("BLIND_INDEX_ENABLED") == "true"
+
+	TrustedKeys = parseFingerPrintList("TRUSTED_KEYS")
+	TrustCAKeys = parseFingerPrintList("TRUST_CA_KEYS")
+	TrustRequiredEndpoints = nil
+	for _, v := range strings.Split(os.Getenv("TRUST_REQUIRED_ENDPOINTS"), ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			TrustRequiredEndpoints = append(TrustRequiredEndpoints, v)
+		}
+	}
+	SignatureKnownNotations = nil
+	for _, v := range strings.Split(os.Getenv("SIGNATURE_KNOWN_NOTATIONS"), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			SignatureKnownNotations = append(SignatureKnownNotations, v)
+		}
+	}
+
+	TimestampAuthorityURL = os.Getenv("TIMESTAMP_AUTHORITY_URL")
+	TimestampAuthorityKey = strings.ToUpper(strings.TrimSpace(os.Getenv("TIMESTAMP_AUTHORITY_KEY")))
+	TimestampTrustedCerts = os.Getenv("TIMESTAMP_TRUSTED_CERTS")
+
 	SetExposedServices = os.Getenv("SET_EXPOSED_SERVICES") == "true"
 	ExposedServices = strings.Split(os.Getenv("EXPOSED_SERVICES"), ",")
 
@@ -350,6 +549,47 @@ func Setup() {
 		RedisHost = "localhost:6379"
 	}
 
+	if ClusterNodeID == "" {
+		ClusterNodeID, _ = os.Hostname()
+	}
+
+	if ClusterAdvertiseURL == "" {
+		hostname, _ := os.Hostname()
+		ClusterAdvertiseURL = fmt.Sprintf("http://%s:%d", hostname, HttpPort)
+	}
+
+	if ClusterMaxClockSkew <= 0 {
+		ClusterMaxClockSkew = time.Second * 30
+	}
+
+	if ClusterSyncInterval <= 0 {
+		ClusterSyncInterval = time.Minute
+	}
+
+	if KeyRingRefreshInterval <= 0 {
+		KeyRingRefreshInterval = time.Minute * 15
+	}
+
+	if PKSVerificationURL == "" {
+		PKSVerificationURL = ClusterAdvertiseURL
+	}
+
+	if PKSVerificationTokenTTL <= 0 {
+		PKSVerificationTokenTTL = time.Hour * 24
+	}
+
+	if Mailer == "" {
+		Mailer = "log"
+	}
+
+	if MailerFolder == "" {
+		MailerFolder = "./mails"
+	}
+
+	if SMTPPort == 0 {
+		SMTPPort = 25
+	}
+
 	// Other stuff
 	_ = os.Mkdir(PrivateKeyFolder, 0750)
 
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 var varStack []map[string]interface{}
 
 func PushVariables() {
@@ -15,7 +17,17 @@ func PushVariables() {
 		"SKSServer":                 SKSServer,
 		"HttpPort":                  HttpPort,
 		"MaxKeyRingCache":           MaxKeyRingCache,
+		"KeyRingRefreshInterval":    KeyRingRefreshInterval,
+		"PKSEmailVerification":      PKSEmailVerification,
+		"BlindIndexEnabled":         BlindIndexEnabled,
+		"PKSVerificationSecret":     PKSVerificationSecret,
+		"PKSVerificationURL":        PKSVerificationURL,
+		"PKSVerificationTokenTTL":   PKSVerificationTokenTTL,
+		"Mailer":                    Mailer,
+		"MailerFolder":              MailerFolder,
+		"SMTPHost":                  SMTPHost,
 		"EnableDatabase":            EnableDatabase,
+		"EnableRedis":               EnableRedis,
 		"RethinkDBHost":             RethinkDBHost,
 		"RethinkDBPort":             RethinkDBPort,
 		"RethinkDBUsername":         RethinkDBUsername,
@@ -48,6 +60,13 @@ func PushVariables() {
 		"AgentExternalURL":          AgentExternalURL,
 		"AgentAdminExternalURL":     AgentAdminExternalURL,
 		"OnDemandKeyLoad":           OnDemandKeyLoad,
+		"TrustedKeys":               TrustedKeys,
+		"TrustCAKeys":               TrustCAKeys,
+		"TrustRequiredEndpoints":    TrustRequiredEndpoints,
+		"SignatureKnownNotations":   SignatureKnownNotations,
+		"TimestampAuthorityURL":     TimestampAuthorityURL,
+		"TimestampAuthorityKey":     TimestampAuthorityKey,
+		"TimestampTrustedCerts":     TimestampTrustedCerts,
 	}
 
 	varStack = append(varStack, insMap)
@@ -68,7 +87,17 @@ func PopVariables() {
 	SKSServer = insMap["SKSServer"].(string)
 	HttpPort = insMap["HttpPort"].(int)
 	MaxKeyRingCache = insMap["MaxKeyRingCache"].(int)
+	KeyRingRefreshInterval = insMap["KeyRingRefreshInterval"].(time.Duration)
+	PKSEmailVerification = insMap["PKSEmailVerification"].(bool)
+	BlindIndexEnabled = insMap["BlindIndexEnabled"].(bool)
+	PKSVerificationSecret = insMap["PKSVerificationSecret"].(string)
+	PKSVerificationURL = insMap["PKSVerificationURL"].(string)
+	PKSVerificationTokenTTL = insMap["PKSVerificationTokenTTL"].(time.Duration)
+	Mailer = insMap["Mailer"].(string)
+	MailerFolder = insMap["MailerFolder"].(string)
+	SMTPHost = insMap["SMTPHost"].(string)
 	EnableDatabase = insMap["EnableDatabase"].(bool)
+	EnableRedis = insMap["EnableRedis"].(bool)
 	RethinkDBHost = insMap["RethinkDBHost"].(string)
 	RethinkDBPort = insMap["RethinkDBPort"].(int)
 	RethinkDBUsername = insMap["RethinkDBUsername"].(string)
@@ -101,4 +130,11 @@ func PopVariables() {
 	AgentExternalURL = insMap["AgentExternalURL"].(string)
 	AgentAdminExternalURL = insMap["AgentAdminExternalURL"].(string)
 	OnDemandKeyLoad = insMap["OnDemandKeyLoad"].(bool)
+	TrustedKeys = insMap["TrustedKeys"].([]string)
+	TrustCAKeys = insMap["TrustCAKeys"].([]string)
+	TrustRequiredEndpoints = insMap["TrustRequiredEndpoints"].([]string)
+	SignatureKnownNotations = insMap["SignatureKnownNotations"].([]string)
+	TimestampAuthorityURL = insMap["TimestampAuthorityURL"].(string)
+	TimestampAuthorityKey = insMap["TimestampAuthorityKey"].(string)
+	TimestampTrustedCerts = insMap["TimestampTrustedCerts"].(string)
 }
@@ -8,14 +8,16 @@ import (
 	"github.com/quan-to/slog"
 )
 
+type DatabaseHandler keymagic.DatabaseHandler
+
 // MakePGP creates a new PGPManager using environment variables KeyPrefix, PrivateKeyFolder
-func MakePGP(log slog.Instance) interfaces.PGPManager {
+func MakePGP(log slog.Instance, dbHandler DatabaseHandler) interfaces.PGPManager {
 	kb := keybackend.MakeSaveToDiskBackend(log, config.PrivateKeyFolder, config.KeyPrefix)
 
-	return keymagic.MakePGPManager(log, kb, keymagic.MakeKeyRingManager())
+	return keymagic.MakePGPManager(log, kb, keymagic.MakeKeyRingManager(log, dbHandler))
 }
 
 // MakeVoidPGP creates a PGPManager that does not store anything anywhere
-func MakeVoidPGP(log slog.Instance) interfaces.PGPManager {
-	return keymagic.MakePGPManager(log, keybackend.MakeVoidBackend(), keymagic.MakeKeyRingManager())
+func MakeVoidPGP(log slog.Instance, dbHandler DatabaseHandler) interfaces.PGPManager {
+	return keymagic.MakePGPManager(log, keybackend.MakeVoidBackend(), keymagic.MakeKeyRingManager(log, dbHandler))
 }
@@ -0,0 +1,232 @@
+// +build js,wasm
+
+package keybackend
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"syscall/js"
+
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/slog"
+)
+
+const indexedDBVersion = 1
+const indexedDBStore = "keys"
+
+// indexedDBBackend is a Key StorageBackend that stores keys in the browser IndexedDB
+//
+// IndexedDB is asynchronous, so every call blocks the calling goroutine until the browser answers.
+// It should never be called from the goroutine of a js.Func callback, since the browser event loop would be blocked
+type indexedDBBackend struct {
+	sync.Mutex
+	database string
+	prefix   string
+	db       js.Value
+	log      slog.Instance
+}
+
+// MakeIndexedDBBackend creates an instance of indexedDBBackend that stores keys in the specified IndexedDB database with the specified prefix
+func MakeIndexedDBBackend(log slog.Instance, database, prefix string) interfaces.StorageBackend {
+	if log == nil {
+		log = slog.Scope("indexedDBBackend")
+	} else {
+		log = log.SubScope("indexedDBBackend")
+	}
+
+	log.Info("Initialized indexedDBBackend on database %s with prefix %s", database, prefix)
+
+	return &indexedDBBackend{
+		database: database,
+		prefix:   prefix,
+		db:       js.Null(),
+		log:      log,
+	}
+}
+
+// awaitRequest waits for a IDBRequest to finish and returns its result
+func awaitRequest(req js.Value) (js.Value, error) {
+	type requestResult struct {
+		value js.Value
+		err   error
+	}
+
+	done := make(chan requestResult, 1)
+
+	onSuccess := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- requestResult{value: req.Get("result")}
+		return nil
+	})
+	defer onSuccess.Release()
+
+	onError := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		msg := "unknown error"
+		if e := req.Get("error"); e.Truthy() {
+			msg = e.Get("message").String()
+		}
+		done <- requestResult{err: fmt.Errorf("indexeddb: %s", msg)}
+		return nil
+	})
+	defer onError.Release()
+
+	req.Set("onsuccess", onSuccess)
+	req.Set("onerror", onError)
+
+	r := <-done
+
+	return r.value, r.err
+}
+
+// open returns the opened database, creating the key store on the first use
+func (d *indexedDBBackend) open() (js.Value, error) {
+	d.Lock()
+	defer d.Unlock()
+
+	if d.db.Truthy() {
+		return d.db, nil
+	}
+
+	factory := js.Global().Get("indexedDB")
+	if !factory.Truthy() {
+		return js.Null(), fmt.Errorf("indexeddb is not available")
+	}
+
+	req := factory.Call("open", d.database, indexedDBVersion)
+
+	onUpgrade := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		db := req.Get("result")
+		if !db.Get("objectStoreNames").Call("contains", indexedDBStore).Bool() {
+			db.Call("createObjectStore", indexedDBStore)
+		}
+		return nil
+	})
+	defer onUpgrade.Release()
+
+	req.Set("onupgradeneeded", onUpgrade)
+
+	db, err := awaitRequest(req)
+	if err != nil {
+		d.log.Error("Error opening database %s: %s", d.database, err)
+		return js.Null(), err
+	}
+
+	d.db = db
+
+	return db, nil
+}
+
+// store returns the key object store in a new transaction with the specified mode
+func (d *indexedDBBackend) store(mode string) (js.Value, error) {
+	db, err := d.open()
+	if err != nil {
+		return js.Null(), err
+	}
+
+	return db.Call("transaction", indexedDBStore, mode).Call("objectStore", indexedDBStore), nil
+}
+
+// Name returns the name of the KeyBackend
+func (d *indexedDBBackend) Name() string {
+	return "indexedDBBackend StorageBackend"
+}
+
+// Path returns the path of the current KeyBackend
+func (d *indexedDBBackend) Path() string {
+	return d.database + "/" + indexedDBStore + "/" + d.prefix + "*"
+}
+
+// Save saves a key to the backend
+func (d *indexedDBBackend) Save(key, data string) error {
+	return d.SaveWithMetadata(key, data, "")
+}
+
+// SaveWithMetadata saves a key to backend storing some metadata with it
+func (d *indexedDBBackend) SaveWithMetadata(key, data, metadata string) error {
+	d.log.DebugAwait("Saving to %s", d.prefix+key)
+
+	store, err := d.store("readwrite")
+	if err != nil {
+		return err
+	}
+
+	value := map[string]interface{}{
+		"data":     data,
+		"metadata": metadata,
+	}
+
+	_, err = awaitRequest(store.Call("put", value, d.prefix+key))
+	if err != nil {
+		d.log.ErrorDone("Error saving to %s: %s", d.prefix+key, err)
+	}
+
+	return err
+}
+
+// Delete deletes a key and its metadata from the backend
+func (d *indexedDBBackend) Delete(key string) error {
+	d.log.DebugAwait("Deleting %s", d.prefix+key)
+
+	_, _, err := d.Read(key)
+	if err != nil {
+		return err
+	}
+
+	store, err := d.store("readwrite")
+	if err != nil {
+		return err
+	}
+
+	_, err = awaitRequest(store.Call("delete", d.prefix+key))
+	if err != nil {
+		d.log.ErrorDone("Error deleting from %s: %s", d.prefix+key, err)
+	}
+
+	return err
+}
+
+// Read reads a key from the backend
+func (d *indexedDBBackend) Read(key string) (data string, metadata string, err error) {
+	d.log.DebugAwait("Reading from %s", d.prefix+key)
+
+	store, err := d.store("readonly")
+	if err != nil {
+		return "", "", err
+	}
+
+	value, err := awaitRequest(store.Call("get", d.prefix+key))
+	if err != nil {
+		d.log.ErrorDone("Error reading from %s: %s", d.prefix+key, err)
+		return "", "", err
+	}
+
+	if !value.Truthy() {
+		return "", "", fmt.Errorf("key %s not found", key)
+	}
+
+	return value.Get("data").String(), value.Get("metadata").String(), nil
+}
+
+// List lists the stored keys
+func (d *indexedDBBackend) List() ([]string, error) {
+	store, err := d.store("readonly")
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := awaitRequest(store.Call("getAllKeys"))
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0)
+
+	for i := 0; i < value.Length(); i++ {
+		name := value.Index(i).String()
+		if strings.HasPrefix(name, d.prefix) {
+			keys = append(keys, name[len(d.prefix):])
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,438 @@
+package keymagic
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/mailer"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+const verificationMailSubject = "Verify your email in the Chevron key server"
+
+const verificationMailBody = `Hi,
+
+The key %s with the user id %q was uploaded to the Chevron key server.
+To publish this user id, open the link below:
+
+%s
+
+The link is valid until %s. If you did not upload this key, ignore this message.
+`
+
+var verificationLock sync.Mutex
+var verificationMailer interfaces.Mailer
+var randomVerificationSecret []byte
+
+// verificationSent has the expiration of the last verification link sent for each key fingerprint and email
+var verificationSent = map[string]time.Time{}
+
+// verificationToken is the signed content of a email verification link
+type verificationToken struct {
+	FingerPrint string `json:"fp"`
+	Email       string `json:"email"`
+	Expiration  int64  `json:"exp"`
+}
+
+// SetVerificationMailer replaces the mailer configured in MAILER. If m is nil the configuration is used again
+func SetVerificationMailer(m interfaces.Mailer) {
+	verificationLock.Lock()
+	defer verificationLock.Unlock()
+
+	verificationMailer = m
+}
+
+func getVerificationMailer() interfaces.Mailer {
+	verificationLock.Lock()
+	defer verificationLock.Unlock()
+
+	if verificationMailer == nil {
+		m, err := mailer.MakeMailerFromConfig(pksLog)
+		if err != nil {
+			pksLog.Error("Error creating mailer: %s. Using the log mailer", err)
+			m = mailer.MakeLogMailer(pksLog)
+		}
+		verificationMailer = m
+	}
+
+	return verificationMailer
+}
+
+// reserveVerification records a verification link of fingerPrint sent to email that expires at expiration.
+// Returns false if the previous link is not expired yet, so uploading the same key again does not flood the email
+func reserveVerification(fingerPrint, email string, expiration time.Time) bool {
+	verificationLock.Lock()
+	defer verificationLock.Unlock()
+
+	now := time.Now()
+	for k, v := range verificationSent {
+		if now.After(v) {
+			delete(verificationSent, k)
+		}
+	}
+
+	id := fingerPrint + "/" + email
+	if _, ok := verificationSent[id]; ok {
+		return false
+	}
+
+	verificationSent[id] = expiration
+
+	return true
+}
+
+// releaseVerification removes the record of reserveVerification, so the link can be sent again
+func releaseVerification(fingerPrint, email string) {
+	verificationLock.Lock()
+	defer verificationLock.Unlock()
+
+	delete(verificationSent, fingerPrint+"/"+email)
+}
+
+func verificationSecret() []byte {
+	if config.PKSVerificationSecret != "" {
+		return []byte(config.PKSVerificationSecret)
+	}
+
+	verificationLock.Lock()
+	defer verificationLock.Unlock()
+
+	if randomVerificationSecret == nil {
+		pksLog.Warn("PKS_VERIFICATION_SECRET is not set. Using a random secret, so the verification links will not work in other nodes or after a restart")
+		randomVerificationSecret = make([]byte, 32)
+		_, _ = rand.Read(randomVerificationSecret)
+	}
+
+	return randomVerificationSecret
+}
+
+func signVerificationPayload(payload string) string {
+	mac := hmac.New(sha256.New, verificationSecret())
+	_, _ = mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// makeVerificationToken creates a signed token that verifies email in the key with the specified fingerprint
+func makeVerificationToken(fingerPrint, email string, expiration time.Time) (string, error) {
+	data, err := json.Marshal(verificationToken{
+		FingerPrint: fingerPrint,
+		Email:       email,
+		Expiration:  expiration.Unix(),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+
+	return payload + "." + signVerificationPayload(payload), nil
+}
+
+// parseVerificationToken checks the signature and expiration of a token created by makeVerificationToken
+func parseVerificationToken(token string) (*verificationToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(parts[1]), []byte(signVerificationPayload(parts[0]))) {
+		return nil, fmt.Errorf("invalid verification token")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid verification token")
+	}
+
+	var t verificationToken
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("invalid verification token")
+	}
+
+	if time.Now().Unix() > t.Expiration {
+		return nil, fmt.Errorf("the verification token has expired")
+	}
+
+	return &t, nil
+}
+
+// verifiedEmails returns the lower case emails of the verified user ids
+func verifiedEmails(uids []models.GPGKeyUid) map[string]bool {
+	emails := make(map[string]bool)
+
+	for _, v := range uids {
+		if v.Verified && v.Email != "" {
+			emails[strings.ToLower(v.Email)] = true
+		}
+	}
+
+	return emails
+}
+
+// markVerified marks the user ids of key with a email in emails as verified. Returns true if any user id changed
+func markVerified(key *models.GPGKey, emails map[string]bool) bool {
+	changed := false
+
+	for i, v := range key.KeyUids {
+		if !v.Verified && v.Email != "" && emails[strings.ToLower(v.Email)] {
+			key.KeyUids[i].Verified = true
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// filterUserIds keeps the user ids of armoredKey accepted by keep. User attributes are passed to keep as nil
+func filterUserIds(armoredKey string, keep func(uid *packet.UserId) bool) (string, error) {
+	key, err := readKeyPackets(armoredKey)
+	if err != nil {
+		return "", err
+	}
+
+	userIds := make([]*keyComponent, 0)
+
+	for _, uid := range key.userIds {
+		if uid.packet.Tag != tagUserId {
+			if keep(nil) {
+				userIds = append(userIds, uid)
+			}
+			continue
+		}
+
+		parsed, err := uid.packet.Parse()
+		if err != nil {
+			continue
+		}
+
+		if u, ok := parsed.(*packet.UserId); ok && keep(u) {
+			userIds = append(userIds, uid)
+		}
+	}
+
+	key.userIds = userIds
+
+	return key.serialize()
+}
+
+// stripUserIds removes the user ids and user attributes of armoredKey that do not have a email in emails
+func stripUserIds(armoredKey string, emails map[string]bool) (string, error) {
+	return filterUserIds(armoredKey, func(uid *packet.UserId) bool {
+		return uid != nil && emails[strings.ToLower(uid.Email)]
+	})
+}
+
+// publishedKey returns key with only its verified user ids. Returns nil if key has no verified user id
+func publishedKey(key models.GPGKey) *models.GPGKey {
+	emails := verifiedEmails(key.KeyUids)
+	if len(emails) == 0 {
+		return nil
+	}
+
+	armored, err := stripUserIds(key.AsciiArmoredPublicKey, emails)
+	if err != nil {
+		pksLog.Error("Error stripping unverified user ids of %s: %s", key.GetShortFingerPrint(), err)
+		return nil
+	}
+
+	published := key
+	published.AsciiArmoredPublicKey = armored
+	published.KeyUids = make([]models.GPGKeyUid, 0)
+	published.Names = make([]string, 0)
+	published.Emails = make([]string, 0)
+
+	for _, v := range key.KeyUids {
+		if !v.Verified || v.Email == "" {
+			continue
+		}
+
+		published.KeyUids = append(published.KeyUids, v)
+		published.Emails = append(published.Emails, v.Email)
+
+		if v.Name != "" {
+			published.Names = append(published.Names, v.Name)
+		}
+	}
+
+	return &published
+}
+
+// publishedKeys applies publishedKey to keys when email verification is enabled, keeping the keys that still match the search
+func publishedKeys(keys []models.GPGKey, matches func(key models.GPGKey) bool) []models.GPGKey {
+	if !config.PKSEmailVerification || keys == nil {
+		return keys
+	}
+
+	published := make([]models.GPGKey, 0)
+
+	for _, v := range keys {
+		p := publishedKey(v)
+		if p != nil && (matches == nil || matches(*p)) {
+			published = append(published, *p)
+		}
+	}
+
+	return published
+}
+
+// publicArmoredKey returns the ASCII Armored public key of key as it is published by the Public Key Store
+func publicArmoredKey(key models.GPGKey) (string, error) {
+	if !config.PKSEmailVerification {
+		return key.AsciiArmoredPublicKey, nil
+	}
+
+	p := publishedKey(key)
+	if p == nil {
+		return "", fmt.Errorf("not found")
+	}
+
+	return p.AsciiArmoredPublicKey, nil
+}
+
+// PKSPublishedKey returns armoredKey as it is published by the Public Key Store.
+// With email verification enabled only the verified user ids of the stored key are kept. Keys that are not stored are returned unchanged
+func PKSPublishedKey(ctx context.Context, armoredKey string) (string, error) {
+	dbh := dbHandlerFromContext(ctx)
+	if !config.PKSEmailVerification || dbh == nil {
+		return armoredKey, nil
+	}
+
+	fingerPrint, err := tools.GetFingerPrintFromKey(armoredKey)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := dbh.FetchGPGKeyByFingerprint(fingerPrint)
+	if err != nil && !strings.EqualFold(err.Error(), "not found") {
+		return "", err
+	}
+
+	if v == nil {
+		return armoredKey, nil
+	}
+
+	return publicArmoredKey(*v)
+}
+
+func containsFold(values []string, search string) bool {
+	search = strings.ToLower(search)
+
+	for _, v := range values {
+		if strings.Contains(strings.ToLower(v), search) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestVerification sends a verification link to each unverified email of key that is also in uploadedEmails,
+// unless a link sent before is still valid. Returns the emails that received the link
+func requestVerification(ctx context.Context, key models.GPGKey, uploadedEmails []string) []string {
+	if !config.PKSEmailVerification {
+		return nil
+	}
+
+	log := pksLog.Tag(tools.GetRequestIDFromContext(ctx))
+
+	uploaded := make(map[string]bool)
+	for _, v := range uploadedEmails {
+		uploaded[strings.ToLower(v)] = true
+	}
+
+	verified := verifiedEmails(key.KeyUids)
+	sent := make([]string, 0)
+	expiration := time.Now().Add(config.PKSVerificationTokenTTL)
+
+	for _, v := range key.KeyUids {
+		email := strings.ToLower(v.Email)
+
+		if v.Verified || !uploaded[email] || verified[email] {
+			continue
+		}
+
+		// Only one link per email
+		verified[email] = true
+
+		if _, err := mail.ParseAddress(v.Email); err != nil {
+			log.Warn("Not sending verification of key %s to invalid email %q", key.GetShortFingerPrint(), v.Email)
+			continue
+		}
+
+		if !reserveVerification(key.FullFingerprint, email, expiration) {
+			log.Info("Verification of key %s was already sent to %s", key.GetShortFingerPrint(), v.Email)
+			continue
+		}
+
+		token, err := makeVerificationToken(key.FullFingerprint, email, expiration)
+		if err != nil {
+			log.Error("Error creating verification token for %s: %s", v.Email, err)
+			releaseVerification(key.FullFingerprint, email)
+			continue
+		}
+
+		link := fmt.Sprintf("%s/sks/verifyEmail?token=%s", config.PKSVerificationURL, url.QueryEscape(token))
+		userId := packet.NewUserId(v.Name, v.Description, v.Email)
+		body := fmt.Sprintf(verificationMailBody, key.GetShortFingerPrint(), userId.Id, link, expiration.Format(time.RFC1123))
+
+		if err := getVerificationMailer().SendMail(v.Email, verificationMailSubject, body); err != nil {
+			log.Error("Error sending verification of key %s to %s: %s", key.GetShortFingerPrint(), v.Email, err)
+			releaseVerification(key.FullFingerprint, email)
+			continue
+		}
+
+		log.Info("Verification of key %s sent to %s", key.GetShortFingerPrint(), v.Email)
+		sent = append(sent, v.Email)
+	}
+
+	return sent
+}
+
+// PKSVerifyEmail checks a email verification token and publishes the user ids of the token email in the Public Key Store.
+// Returns the fingerprint of the key and the verified email
+func PKSVerifyEmail(ctx context.Context, token string) (string, string, error) {
+	log := pksLog.Tag(tools.GetRequestIDFromContext(ctx))
+	dbh := dbHandlerFromContext(ctx)
+
+	if dbh == nil {
+		return "", "", fmt.Errorf("the server does not have database enabled so it cannot verify emails")
+	}
+
+	t, err := parseVerificationToken(token)
+	if err != nil {
+		return "", "", err
+	}
+
+	key, err := dbh.FetchGPGKeyByFingerprint(t.FingerPrint)
+	if err != nil {
+		return "", "", err
+	}
+
+	if !markVerified(key, map[string]bool{t.Email: true}) {
+		if verifiedEmails(key.KeyUids)[t.Email] {
+			// Link clicked twice
+			return key.GetShortFingerPrint(), t.Email, nil
+		}
+		return "", "", fmt.Errorf("the key %s does not have the email %s", key.GetShortFingerPrint(), t.Email)
+	}
+
+	if err := dbh.UpdateGPGKey(*key); err != nil {
+		return "", "", err
+	}
+
+	log.Info("Email %s verified for key %s", t.Email, key.GetShortFingerPrint())
+	notifyPKSKeyUpdated(ctx, key.GetShortFingerPrint())
+
+	return key.GetShortFingerPrint(), t.Email, nil
+}
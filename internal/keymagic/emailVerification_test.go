@@ -0,0 +1,252 @@
+package keymagic
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/database/memory"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
+)
+
+type testMail struct {
+	to   string
+	body string
+}
+
+type testMailer struct {
+	mails []testMail
+}
+
+func (tm *testMailer) SendMail(to, subject, body string) error {
+	tm.mails = append(tm.mails, testMail{to: to, body: body})
+	return nil
+}
+
+var tokenRegex = regexp.MustCompile(`token=([^\s]+)`)
+
+func (tm *testMailer) token(t *testing.T, to string) string {
+	t.Helper()
+
+	for _, v := range tm.mails {
+		if v.to == to {
+			m := tokenRegex.FindStringSubmatch(v.body)
+			if len(m) != 2 {
+				t.Fatalf("Expected verification link in mail to %s", to)
+			}
+			token, err := url.QueryUnescape(m[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}
+	}
+
+	t.Fatalf("Expected a mail to %s", to)
+	return ""
+}
+
+func TestVerificationToken(t *testing.T) {
+	config.PushVariables()
+	defer config.PopVariables()
+
+	config.PKSVerificationSecret = "huebr"
+
+	token, err := makeVerificationToken("0551F452ABE463A4", "john@huebr.com", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := parseVerificationToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.FingerPrint != "0551F452ABE463A4" || v.Email != "john@huebr.com" {
+		t.Errorf("Expected token of john@huebr.com in 0551F452ABE463A4, got %s in %s", v.Email, v.FingerPrint)
+	}
+
+	if _, err := parseVerificationToken(token[:len(token)-2]); err == nil {
+		t.Errorf("Expected error with tampered token")
+	}
+
+	config.PKSVerificationSecret = "brhue"
+	if _, err := parseVerificationToken(token); err == nil {
+		t.Errorf("Expected error with token signed by other secret")
+	}
+
+	expired, err := makeVerificationToken("0551F452ABE463A4", "john@huebr.com", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parseVerificationToken(expired); err == nil {
+		t.Errorf("Expected error with expired token")
+	}
+}
+
+func TestPKSEmailVerification(t *testing.T) {
+	config.PushVariables()
+	defer config.PopVariables()
+
+	config.PKSEmailVerification = true
+	config.PKSVerificationSecret = "huebr"
+
+	mailer := &testMailer{}
+	SetVerificationMailer(mailer)
+	defer SetVerificationMailer(nil)
+
+	dbh := memory.MakeMemoryDBDriver(nil)
+	ctx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, dbh)
+
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)
+
+	result, err := PKSMerge(ctx, armoredPublicKey(t, e))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.VerificationSent) != 1 || result.VerificationSent[0] != "john@huebr.com" {
+		t.Errorf("Expected verification sent to john@huebr.com, got %v", result.VerificationSent)
+	}
+
+	// Uploading the same key again does not send another link while the first one is valid
+	result, err = PKSMerge(ctx, armoredPublicKey(t, e))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.VerificationSent) != 0 || len(mailer.mails) != 1 {
+		t.Errorf("Expected a single verification mail, got %d", len(mailer.mails))
+	}
+
+	keys, err := PKSSearchByEmail(ctx, "john@huebr.com", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 0 {
+		t.Errorf("Expected unverified key to not be found, got %d keys", len(keys))
+	}
+
+	if _, err := PKSGetKey(ctx, fp); err == nil {
+		t.Errorf("Expected unverified key to not be published")
+	}
+
+	// The key ring verifies signatures with all the user ids
+	if key, source, err := pksGetKey(ctx, fp); err != nil || key == "" || source != models.KeySourcePKS {
+		t.Errorf("Expected unverified key to be available to the key ring, got %v", err)
+	}
+
+	verifiedFp, email, err := PKSVerifyEmail(ctx, mailer.token(t, "john@huebr.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if verifiedFp != fp || email != "john@huebr.com" {
+		t.Errorf("Expected john@huebr.com verified in %s, got %s in %s", fp, email, verifiedFp)
+	}
+
+	// A new user id stays unpublished
+	addIdentity(t, e, "John HUEBR", "john@work.huebr.com")
+
+	result, err = PKSMerge(ctx, armoredPublicKey(t, e))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.NewUserIds) != 1 || len(result.VerificationSent) != 1 || result.VerificationSent[0] != "john@work.huebr.com" {
+		t.Errorf("Expected a new user id with verification sent to john@work.huebr.com, got %s", result.String())
+	}
+
+	keys, err = PKSSearchByEmail(ctx, "john@huebr.com", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 1 || len(keys[0].KeyUids) != 1 || keys[0].Emails[0] != "john@huebr.com" {
+		t.Fatalf("Expected the key with only the verified user id, got %v", keys)
+	}
+
+	keys, err = PKSSearch(ctx, "work.huebr.com", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 0 {
+		t.Errorf("Expected no keys searching the unverified email, got %d", len(keys))
+	}
+
+	armored, err := PKSGetKey(ctx, fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entities[0].Identities) != 1 {
+		t.Errorf("Expected published key to have 1 identity, got %d", len(entities[0].Identities))
+	}
+
+	if _, _, err := PKSVerifyEmail(ctx, mailer.token(t, "john@work.huebr.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err = PKSSearchByEmail(ctx, "john@work.huebr.com", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 1 || len(keys[0].KeyUids) != 2 {
+		t.Errorf("Expected the key with both user ids after verification, got %v", keys)
+	}
+
+	if _, _, err := PKSVerifyEmail(ctx, "huebr.huebr"); err == nil {
+		t.Errorf("Expected error with invalid token")
+	}
+}
+
+func TestPKSMergeVerified(t *testing.T) {
+	config.PushVariables()
+	defer config.PopVariables()
+
+	config.PKSEmailVerification = true
+
+	mailer := &testMailer{}
+	SetVerificationMailer(mailer)
+	defer SetVerificationMailer(nil)
+
+	dbh := memory.MakeMemoryDBDriver(nil)
+	ctx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, dbh)
+
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := PKSMergeVerified(ctx, armoredPublicKey(t, e)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mailer.mails) != 0 {
+		t.Errorf("Expected no verification mails, got %d", len(mailer.mails))
+	}
+
+	if _, err := PKSGetKey(ctx, tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)); err != nil {
+		t.Errorf("Expected verified key to be published, got %s", err)
+	}
+}
@@ -0,0 +1,174 @@
+package keymagic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/quan-to/chevron/pkg/QuantoError"
+	"github.com/quan-to/chevron/pkg/fieldcipher"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
+)
+
+// FieldCipherPacket encrypts the JSON fields of input to public keys loaded in gpg, signing the packet if requested.
+// getIndexer is only called if blind indexes are requested. Errors of the input are *QuantoError.ErrorObject
+func FieldCipherPacket(ctx context.Context, gpg interfaces.PGPManager, input models.FieldCipherInput, getIndexer func() (*fieldcipher.BlindIndexer, error)) (*fieldcipher.CipherPacket, error) {
+	keys, err := fieldCipherKeys(ctx, gpg, "data.Keys", input.Keys)
+	if err != nil {
+		return nil, err
+	}
+
+	cipher := fieldcipher.MakeCipher(keys)
+
+	version := input.Version
+	if version == 0 && len(input.Groups) > 0 { // Recipient groups are only supported by the authenticated format
+		version = fieldcipher.FormatV2
+	}
+
+	if version != 0 {
+		if err := cipher.SetFormatVersion(version); err != nil {
+			return nil, QuantoError.New(QuantoError.InvalidFieldData, "data.Version", err.Error(), nil)
+		}
+	}
+
+	if input.SignWith != "" {
+		var signer *openpgp.Entity
+
+		// The master key is the last one in the list
+		privateKeys := gpg.GetPrivate(ctx, input.SignWith)
+		for i := len(privateKeys) - 1; i >= 0; i-- {
+			if privateKeys[i].PrivateKey != nil && privateKeys[i].PrivateKey.CanSign() {
+				signer = privateKeys[i]
+				break
+			}
+		}
+
+		if signer == nil {
+			return nil, QuantoError.New(QuantoError.NotFound, "data.SignWith", fmt.Sprintf("There is no such key %s or its not decrypted.", input.SignWith), nil)
+		}
+
+		if err := cipher.SetSigner(signer); err != nil {
+			return nil, QuantoError.New(QuantoError.InvalidFieldData, "data.SignWith", err.Error(), nil)
+		}
+	}
+
+	if len(input.Include) == 0 && len(input.Exclude) == 0 && len(input.Groups) == 0 && len(input.BlindIndex) == 0 {
+		return cipher.GenerateEncryptedPacket(input.JSON, input.SkipFields)
+	}
+
+	if len(input.SkipFields) > 0 {
+		return nil, QuantoError.New(QuantoError.InvalidFieldData, "data.SkipFields", "SkipFields cannot be used with Include, Exclude, Groups or BlindIndex", nil)
+	}
+
+	policy := fieldcipher.FieldPolicy{
+		Include:    input.Include,
+		Exclude:    input.Exclude,
+		BlindIndex: input.BlindIndex,
+	}
+
+	if len(input.BlindIndex) > 0 {
+		indexer, err := getIndexer()
+		if err != nil {
+			return nil, QuantoError.New(QuantoError.InvalidFieldData, "data.BlindIndex", err.Error(), nil)
+		}
+		cipher.SetBlindIndexer(indexer)
+	}
+
+	for i, g := range input.Groups {
+		groupKeys, err := fieldCipherKeys(ctx, gpg, fmt.Sprintf("data.Groups[%d].Keys", i), g.Keys)
+		if err != nil {
+			return nil, err
+		}
+
+		policy.Groups = append(policy.Groups, fieldcipher.RecipientGroup{
+			Name:       g.Name,
+			Fields:     g.Fields,
+			PublicKeys: groupKeys,
+		})
+	}
+
+	if err := policy.Validate(); err != nil {
+		return nil, QuantoError.New(QuantoError.InvalidFieldData, "data", err.Error(), nil)
+	}
+
+	return cipher.GenerateEncryptedPacketWithPolicy(input.JSON, policy)
+}
+
+// fieldCipherKeys returns the public keys of the fingerprints in field
+func fieldCipherKeys(ctx context.Context, gpg interfaces.PGPManager, field string, fingerprints []string) ([]*openpgp.Entity, error) {
+	keys := make([]*openpgp.Entity, 0, len(fingerprints))
+
+	for i, v := range fingerprints {
+		k := gpg.GetPublicKeyEntity(ctx, v)
+		if k == nil {
+			return nil, QuantoError.New(QuantoError.NotFound, fmt.Sprintf("%s[%d]", field, i), fmt.Sprintf("publickey for fingerPrint %s was not found", v), nil)
+		}
+		keys = append(keys, k)
+	}
+
+	if len(keys) == 0 {
+		return nil, QuantoError.New(QuantoError.InvalidFieldData, field, "no keys specified", nil)
+	}
+
+	return keys, nil
+}
+
+// FieldDecipherPacket decrypts a fieldcipher packet with a private key unlocked in gpg, verifying its signature if the signer key is known.
+// Errors of the input are *QuantoError.ErrorObject
+func FieldDecipherPacket(ctx context.Context, gpg interfaces.PGPManager, input models.FieldDecipherInput) (*fieldcipher.DecipherPacket, error) {
+	keys := gpg.GetPrivate(ctx, input.KeyFingerprint)
+	if len(keys) == 0 {
+		return nil, QuantoError.New(QuantoError.NotFound, "keyFingerprint", fmt.Sprintf("There is no such key %s or its not decrypted.", input.KeyFingerprint), nil)
+	}
+
+	decipher, err := fieldcipher.MakeDecipher(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups map[string]fieldcipher.CipherGroup
+
+	for name, g := range input.Groups {
+		if groups == nil {
+			groups = map[string]fieldcipher.CipherGroup{}
+		}
+		groups[name] = fieldcipher.CipherGroup{
+			EncryptedKey: g.EncryptedKey,
+			Fields:       g.Fields,
+		}
+	}
+
+	packet := fieldcipher.CipherPacket{
+		Version:       input.Version,
+		EncryptedKey:  input.EncryptedKey,
+		Groups:        groups,
+		EncryptedJSON: input.EncryptedJSON,
+		Signature:     input.Signature,
+	}
+
+	if packet.Signature != "" {
+		signer, err := fieldcipher.GetPacketSigner(packet)
+		if err != nil {
+			return nil, QuantoError.New(QuantoError.InvalidFieldData, "data.Signature", err.Error(), nil)
+		}
+
+		if ent := gpg.GetPublicKeyEntity(ctx, signer); ent != nil {
+			decipher.SetVerificationKeys(openpgp.EntityList{ent})
+		}
+	}
+
+	var dec *fieldcipher.DecipherPacket
+
+	if input.Partial {
+		dec, err = decipher.DecipherPacketPartial(packet)
+	} else {
+		dec, err = decipher.DecipherPacket(packet)
+	}
+
+	if err != nil {
+		return nil, QuantoError.New(QuantoError.InvalidFieldData, "payload", err.Error(), nil)
+	}
+
+	return dec, nil
+}
@@ -0,0 +1,255 @@
+package keymagic
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+// keyDeletionRequestMaxAge is how long a signed deletion request is accepted after its timestamp
+const keyDeletionRequestMaxAge = time.Hour
+
+// keyDeletionRequestMaxSkew is how far in the future the timestamp of a signed deletion request can be
+const keyDeletionRequestMaxSkew = 5 * time.Minute
+
+// KeyDeletionOwner is the RemovedBy of the tombstones created by a deletion request signed by the key owner
+const KeyDeletionOwner = "owner"
+
+// fetchTombstone returns the tombstone of the key or nil if it was not removed
+func fetchTombstone(ctx context.Context, dbh DatabaseHandler, fingerPrint string) (*models.GPGKeyTombstone, error) {
+	tombstone, err := dbh.FetchGPGKeyTombstone(fingerPrint)
+	if err != nil {
+		if strings.EqualFold(err.Error(), "not found") {
+			return nil, nil
+		}
+		pksLog.Tag(tools.GetRequestIDFromContext(ctx)).Error("Error fetching tombstone of key %s: %s", fingerPrint, err)
+		return nil, err
+	}
+
+	return tombstone, nil
+}
+
+// removedEmails returns the lower case emails of a tombstone
+func removedEmails(tombstone *models.GPGKeyTombstone) map[string]bool {
+	emails := make(map[string]bool)
+
+	if tombstone != nil {
+		for _, v := range tombstone.Emails {
+			emails[strings.ToLower(v)] = true
+		}
+	}
+
+	return emails
+}
+
+// removeUserIds removes the user ids of armoredKey that have a email in emails
+func removeUserIds(armoredKey string, emails map[string]bool) (string, error) {
+	return filterUserIds(armoredKey, func(uid *packet.UserId) bool {
+		return uid == nil || !emails[strings.ToLower(uid.Email)]
+	})
+}
+
+// ownerRequestID identifies a signed deletion request by its timestamp and the SHA256 of its signed data
+func ownerRequestID(req models.KeyDeletionRequest) string {
+	return fmt.Sprintf("%d:%x", req.Timestamp, sha256.Sum256([]byte(req.SignedData())))
+}
+
+// ownerRequests returns the owner requests of the tombstone that did not expire yet with ownerRequest added.
+// Expired requests are rejected by their timestamp, so they do not need to be kept
+func ownerRequests(tombstone *models.GPGKeyTombstone, ownerRequest string) []string {
+	requests := make([]string, 0)
+
+	if tombstone != nil {
+		for _, v := range tombstone.OwnerRequests {
+			var timestamp int64
+			if _, err := fmt.Sscanf(v, "%d:", &timestamp); err == nil && time.Since(time.Unix(timestamp, 0)) <= keyDeletionRequestMaxAge {
+				requests = append(requests, v)
+			}
+		}
+	}
+
+	if ownerRequest != "" {
+		requests = append(requests, ownerRequest)
+	}
+
+	return requests
+}
+
+// checkOwnerSignature checks that signature is a valid signature of data made by key
+func checkOwnerSignature(key models.GPGKey, data, signature string) error {
+	if !strings.HasPrefix(signature, "-----") {
+		signature = tools.Quanto2GPG(signature)
+	}
+
+	keyRing, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key.AsciiArmoredPublicKey))
+	if err != nil {
+		return err
+	}
+
+	_, err = openpgp.CheckArmoredDetachedSignature(keyRing, strings.NewReader(data), strings.NewReader(tools.SignatureFix(signature)))
+
+	return err
+}
+
+// PKSDeleteKey removes a public key, or only some of its user ids, from the Public Key Store by a request signed by the key itself.
+// A tombstone is recorded so the removed key or user ids are not added again
+func PKSDeleteKey(ctx context.Context, req models.KeyDeletionRequest) (*models.KeyDeletionResult, error) {
+	pksLog.DebugNote("PKSDeleteKey(%s, %v)", req.FingerPrint, req.Emails)
+	dbh := dbHandlerFromContext(ctx)
+	if dbh == nil {
+		return nil, fmt.Errorf("the server does not have database enabled so it cannot delete keys")
+	}
+
+	timestamp := time.Unix(req.Timestamp, 0)
+	if time.Since(timestamp) > keyDeletionRequestMaxAge || time.Until(timestamp) > keyDeletionRequestMaxSkew {
+		return nil, fmt.Errorf("the deletion request timestamp should be in the last %s", keyDeletionRequestMaxAge)
+	}
+
+	key, err := dbh.FetchGPGKeyByFingerprint(req.FingerPrint)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkOwnerSignature(*key, req.SignedData(), req.Signature); err != nil {
+		return nil, fmt.Errorf("the deletion request should be signed by the key %s: %s", key.GetShortFingerPrint(), err)
+	}
+
+	return pksDelete(ctx, dbh, key, req.FingerPrint, req.Emails, req.Reason, KeyDeletionOwner, ownerRequestID(req))
+}
+
+// PKSAdminDeleteKey removes a public key, or only some of its user ids, from the Public Key Store.
+// The key does not need to be stored, so it can be blocked before a upstream keyserver adds it
+func PKSAdminDeleteKey(ctx context.Context, fingerPrint string, emails []string, reason, removedBy string) (*models.KeyDeletionResult, error) {
+	pksLog.DebugNote("PKSAdminDeleteKey(%s, %v, %s)", fingerPrint, emails, removedBy)
+	dbh := dbHandlerFromContext(ctx)
+	if dbh == nil {
+		return nil, fmt.Errorf("the server does not have database enabled so it cannot delete keys")
+	}
+
+	key, err := dbh.FetchGPGKeyByFingerprint(fingerPrint)
+	if err != nil && (!strings.EqualFold(err.Error(), "not found") || len(emails) > 0) {
+		return nil, err
+	}
+
+	return pksDelete(ctx, dbh, key, fingerPrint, emails, reason, removedBy, "")
+}
+
+// pksDelete records the tombstone and removes key, or its user ids with a email in emails, from the database.
+// key can be nil when the whole key is removed. ownerRequest is the ownerRequestID of a request signed by the key owner, which is only accepted once
+func pksDelete(ctx context.Context, dbh DatabaseHandler, key *models.GPGKey, fingerPrint string, emails []string, reason, removedBy, ownerRequest string) (*models.KeyDeletionResult, error) {
+	log := pksLog.Tag(tools.GetRequestIDFromContext(ctx))
+
+	if key != nil {
+		fingerPrint = key.GetShortFingerPrint()
+	} else {
+		fingerPrint = tools.FPto16(fingerPrint)
+	}
+
+	if len(fingerPrint) < 16 {
+		return nil, fmt.Errorf("the fingerprint should have at least 16 characters")
+	}
+
+	if key != nil && key.AsciiArmoredPrivateKey != "" {
+		return nil, fmt.Errorf("the key %s is managed by this server and cannot be deleted from the key store", fingerPrint)
+	}
+
+	existing, err := fetchTombstone(ctx, dbh, fingerPrint)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing != nil && ownerRequest != "" && containsFold(existing.OwnerRequests, ownerRequest) {
+		return nil, fmt.Errorf("the deletion request was already used")
+	}
+
+	result := &models.KeyDeletionResult{
+		FingerPrint:    fingerPrint,
+		RemovedUserIds: make([]string, 0),
+	}
+
+	remove := make(map[string]bool)
+	for _, v := range emails {
+		remove[strings.ToLower(v)] = true
+	}
+
+	keptUids := 0
+
+	if key != nil {
+		for _, v := range key.KeyUids {
+			if len(remove) == 0 || remove[strings.ToLower(v.Email)] {
+				result.RemovedUserIds = append(result.RemovedUserIds, packet.NewUserId(v.Name, v.Description, v.Email).Id)
+			} else {
+				keptUids++
+			}
+		}
+
+		if len(remove) > 0 && len(result.RemovedUserIds) == 0 {
+			return nil, fmt.Errorf("the key %s does not have user ids with the emails %s", fingerPrint, strings.Join(emails, ", "))
+		}
+	}
+
+	tombstone := models.GPGKeyTombstone{
+		FingerPrint:   fingerPrint,
+		Emails:        make([]string, 0),
+		Reason:        reason,
+		RemovedBy:     removedBy,
+		CreatedAt:     time.Now(),
+		OwnerRequests: ownerRequests(existing, ownerRequest),
+	}
+
+	// A removed key stays removed, and removed emails are accumulated
+	if len(remove) > 0 && (existing == nil || !existing.IsWholeKey()) {
+		for email := range removedEmails(existing) {
+			remove[email] = true
+		}
+		for email := range remove {
+			tombstone.Emails = append(tombstone.Emails, email)
+		}
+	}
+
+	// The tombstone is recorded first so a concurrent upstream lookup cannot add the key again
+	if err := dbh.AddGPGKeyTombstone(tombstone); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case key == nil:
+		log.Info("Key %s blocked in PKS by %s: %s", fingerPrint, removedBy, reason)
+	case keptUids == 0:
+		if err := dbh.DeleteGPGKey(*key); err != nil {
+			return nil, err
+		}
+		result.KeyDeleted = true
+		log.Info("Key %s deleted from PKS by %s: %s", fingerPrint, removedBy, reason)
+	default:
+		armored, err := removeUserIds(key.AsciiArmoredPublicKey, remove)
+		if err != nil {
+			return nil, err
+		}
+
+		updated, err := models.AsciiArmored2GPGKey(armored)
+		if err != nil {
+			return nil, err
+		}
+
+		updated.ID = key.ID
+		updated.ParentKey = key.ParentKey
+		markVerified(&updated, verifiedEmails(key.KeyUids))
+
+		if err := dbh.UpdateGPGKey(updated); err != nil {
+			return nil, err
+		}
+		log.Info("User ids %s of key %s removed from PKS by %s: %s", strings.Join(result.RemovedUserIds, ", "), fingerPrint, removedBy, reason)
+	}
+
+	notifyPKSKeyUpdated(ctx, fingerPrint)
+
+	return result, nil
+}
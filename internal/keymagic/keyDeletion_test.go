@@ -0,0 +1,168 @@
+package keymagic
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/database/memory"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
+)
+
+func signDeletionRequest(t *testing.T, e *openpgp.Entity, req models.KeyDeletionRequest) models.KeyDeletionRequest {
+	t.Helper()
+
+	var b bytes.Buffer
+
+	if err := openpgp.ArmoredDetachSign(&b, e, strings.NewReader(req.SignedData()), mergeTestConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	req.Signature = b.String()
+
+	return req
+}
+
+func TestPKSDeleteKey(t *testing.T) {
+	dbh := memory.MakeMemoryDBDriver(nil)
+	ctx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, dbh)
+
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)
+	addIdentity(t, e, "John HUEBR", "john@work.huebr.com")
+	armored := armoredPublicKey(t, e)
+
+	if _, err := PKSMerge(ctx, armored); err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := openpgp.NewEntity("Maria HUEBR", "", "maria@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := models.KeyDeletionRequest{
+		FingerPrint: fp,
+		Emails:      []string{"john@work.huebr.com"},
+		Reason:      "Left the company",
+		Timestamp:   time.Now().Unix(),
+	}
+
+	if _, err := PKSDeleteKey(ctx, signDeletionRequest(t, other, req)); err == nil {
+		t.Errorf("Expected error with a request signed by other key")
+	}
+
+	expired := req
+	expired.Timestamp = time.Now().Add(-2 * keyDeletionRequestMaxAge).Unix()
+	if _, err := PKSDeleteKey(ctx, signDeletionRequest(t, e, expired)); err == nil {
+		t.Errorf("Expected error with an expired request")
+	}
+
+	signed := signDeletionRequest(t, e, req)
+	signed.Reason = "Changed after signing"
+	if _, err := PKSDeleteKey(ctx, signed); err == nil {
+		t.Errorf("Expected error with a request changed after signing")
+	}
+
+	signed = signDeletionRequest(t, e, req)
+	result, err := PKSDeleteKey(ctx, signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.KeyDeleted || len(result.RemovedUserIds) != 1 {
+		t.Errorf("Expected 1 removed user id, got %s", result.String())
+	}
+
+	if _, err := PKSDeleteKey(ctx, signed); err == nil || !strings.Contains(err.Error(), "already used") {
+		t.Errorf("Expected error replaying the deletion request, got %v", err)
+	}
+
+	key, err := dbh.FetchGPGKeyByFingerprint(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(key.KeyUids) != 1 || key.KeyUids[0].Email != "john@huebr.com" {
+		t.Errorf("Expected stored key to have only john@huebr.com, got %v", key.Emails)
+	}
+
+	// The removed user id is not added again
+	mergeResult, err := PKSMerge(ctx, armored)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mergeResult.Changed() {
+		t.Errorf("Expected no changes uploading the removed user id again, got %s", mergeResult.String())
+	}
+
+	req.Emails = nil
+	result, err = PKSDeleteKey(ctx, signDeletionRequest(t, e, req))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.KeyDeleted {
+		t.Errorf("Expected key to be deleted, got %s", result.String())
+	}
+
+	if _, err := dbh.FetchGPGKeyByFingerprint(fp); err == nil {
+		t.Errorf("Expected key to be removed from the database")
+	}
+
+	if _, err := PKSMerge(ctx, armored); err == nil {
+		t.Errorf("Expected error uploading a removed key")
+	}
+
+	tombstone, err := dbh.FetchGPGKeyTombstone(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tombstone.IsWholeKey() || tombstone.RemovedBy != KeyDeletionOwner {
+		t.Errorf("Expected whole key tombstone by the owner, got %+v", tombstone)
+	}
+}
+
+func TestPKSAdminDeleteKey(t *testing.T) {
+	dbh := memory.MakeMemoryDBDriver(nil)
+	ctx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, dbh)
+
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)
+
+	if _, err := PKSAdminDeleteKey(ctx, fp, []string{"john@huebr.com"}, "GDPR", "admin"); err == nil {
+		t.Errorf("Expected error removing user ids of a key that is not stored")
+	}
+
+	// Keys that are not stored can be blocked
+	result, err := PKSAdminDeleteKey(ctx, fp, nil, "GDPR", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.KeyDeleted || result.FingerPrint != fp {
+		t.Errorf("Expected key %s to be blocked, got %s", fp, result.String())
+	}
+
+	if _, err := PKSMerge(ctx, armoredPublicKey(t, e)); err == nil {
+		t.Errorf("Expected error uploading a blocked key")
+	}
+
+	if _, err := PKSAdminDeleteKey(context.Background(), fp, nil, "GDPR", "admin"); err == nil {
+		t.Errorf("Expected error without database")
+	}
+}
@@ -0,0 +1,420 @@
+package keymagic
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/armor"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+// OpenPGP packet tags used in key merging (RFC 4880 4.3)
+const (
+	tagSignature     = 2
+	tagPrivateKey    = 5
+	tagPublicKey     = 6
+	tagPrivateSubkey = 7
+	tagUserId        = 13
+	tagPublicSubkey  = 14
+	tagUserAttribute = 17
+)
+
+// sigTypeCertificationRevocation is the signature type of a user id revocation (RFC 4880 5.2.1)
+const sigTypeCertificationRevocation = 0x30
+
+// keyComponent is a packet of a transferable public key (primary key, user id or subkey) with the signatures that follow it
+type keyComponent struct {
+	packet     *packet.OpaquePacket
+	signatures []*packet.OpaquePacket
+}
+
+// hasSignature checks if the component already has a signature with the same contents
+func (c *keyComponent) hasSignature(sig *packet.OpaquePacket) bool {
+	for _, v := range c.signatures {
+		if bytes.Equal(v.Contents, sig.Contents) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// keyPackets is a transferable public key split in its components
+type keyPackets struct {
+	primary *keyComponent
+	userIds []*keyComponent
+	subkeys []*keyComponent
+}
+
+func findComponent(components []*keyComponent, p *packet.OpaquePacket) *keyComponent {
+	for _, v := range components {
+		if v.packet.Tag == p.Tag && bytes.Equal(v.packet.Contents, p.Contents) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// publicOpaquePacket converts a private key packet to its public key packet
+func publicOpaquePacket(p *packet.OpaquePacket) (*packet.OpaquePacket, error) {
+	parsed, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	privateKey, ok := parsed.(*packet.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("invalid private key packet")
+	}
+
+	var b bytes.Buffer
+	if err := privateKey.PublicKey.Serialize(&b); err != nil {
+		return nil, err
+	}
+
+	return packet.NewOpaqueReader(&b).Next()
+}
+
+// readKeyPackets splits the first key of an ASCII Armored key block in its components. Private key material is discarded
+func readKeyPackets(armoredKey string) (*keyPackets, error) {
+	block, err := armor.Decode(strings.NewReader(armoredKey))
+	if err != nil {
+		return nil, err
+	}
+
+	reader := packet.NewOpaqueReader(block.Body)
+	key := &keyPackets{}
+
+	var current *keyComponent
+
+	for {
+		p, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if p.Tag == tagPrivateKey || p.Tag == tagPrivateSubkey {
+			if p, err = publicOpaquePacket(p); err != nil {
+				return nil, err
+			}
+		}
+
+		switch p.Tag {
+		case tagPublicKey:
+			if key.primary != nil {
+				// Only the first key is read
+				return key, nil
+			}
+			key.primary = &keyComponent{packet: p}
+			current = key.primary
+		case tagUserId, tagUserAttribute:
+			current = &keyComponent{packet: p}
+			key.userIds = append(key.userIds, current)
+		case tagPublicSubkey:
+			current = &keyComponent{packet: p}
+			key.subkeys = append(key.subkeys, current)
+		case tagSignature:
+			if current == nil {
+				return nil, fmt.Errorf("signature packet found before the primary key")
+			}
+			if !current.hasSignature(p) {
+				current.signatures = append(current.signatures, p)
+			}
+		default:
+			// Trust and unknown packets are not transferable
+		}
+	}
+
+	if key.primary == nil {
+		return nil, fmt.Errorf("no public key found")
+	}
+
+	return key, nil
+}
+
+// serialize writes the key as an ASCII Armored public key block
+func (key *keyPackets) serialize() (string, error) {
+	var b bytes.Buffer
+
+	w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", err
+	}
+
+	components := append([]*keyComponent{key.primary}, key.userIds...)
+	components = append(components, key.subkeys...)
+
+	for _, c := range components {
+		if err := c.packet.Serialize(w); err != nil {
+			return "", err
+		}
+
+		signatures := c.signatures
+		if c.packet.Tag == tagPublicSubkey {
+			// The openpgp package only reads the first signature of a subkey, so revocations go first
+			signatures = make([]*packet.OpaquePacket, 0, len(c.signatures))
+			for _, sig := range c.signatures {
+				if isRevocation(sig) {
+					signatures = append(signatures, sig)
+				}
+			}
+			for _, sig := range c.signatures {
+				if !isRevocation(sig) {
+					signatures = append(signatures, sig)
+				}
+			}
+		}
+
+		for _, sig := range signatures {
+			if err := sig.Serialize(w); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// isRevocation checks the signature type of a raw signature packet
+func isRevocation(sig *packet.OpaquePacket) bool {
+	var sigType byte
+
+	switch {
+	case len(sig.Contents) > 2 && (sig.Contents[0] == 2 || sig.Contents[0] == 3):
+		// v3 signatures have a hashed material length before the type
+		sigType = sig.Contents[2]
+	case len(sig.Contents) > 1:
+		sigType = sig.Contents[1]
+	}
+
+	return sigType == byte(packet.SigTypeKeyRevocation) || sigType == byte(packet.SigTypeSubkeyRevocation) || sigType == sigTypeCertificationRevocation
+}
+
+// issuerPublicKey returns the key or subkey of e with keyId
+func issuerPublicKey(e *openpgp.Entity, keyId uint64) *packet.PublicKey {
+	if e.PrimaryKey.KeyId == keyId {
+		return e.PrimaryKey
+	}
+
+	for _, v := range e.Subkeys {
+		if v.PublicKey.KeyId == keyId {
+			return v.PublicKey
+		}
+	}
+
+	return nil
+}
+
+// verifyComponentSignature checks if sig is a valid signature of the component c of the key with primary key.
+// Signatures by other keys are verified with the keys returned by getIssuer, and are rejected if getIssuer is nil or
+// does not find the issuer. Only v4 signatures of the types that are read from each component are accepted
+func verifyComponentSignature(primary *packet.PublicKey, c *keyComponent, sig *packet.OpaquePacket, getIssuer func(keyId uint64) *openpgp.Entity) bool {
+	parsed, err := sig.Parse()
+	if err != nil {
+		return false
+	}
+
+	s, ok := parsed.(*packet.Signature)
+	if !ok || s.IssuerKeyId == nil {
+		return false
+	}
+
+	issuer := primary
+	if *s.IssuerKeyId != primary.KeyId {
+		if getIssuer == nil {
+			return false
+		}
+		e := getIssuer(*s.IssuerKeyId)
+		if e == nil {
+			return false
+		}
+		if issuer = issuerPublicKey(e, *s.IssuerKeyId); issuer == nil {
+			return false
+		}
+	}
+
+	switch c.packet.Tag {
+	case tagPublicKey:
+		// Only revocations and direct key signatures by the key itself
+		if issuer != primary || (s.SigType != packet.SigTypeKeyRevocation && s.SigType != packet.SigTypeDirectSignature) {
+			return false
+		}
+		return primary.VerifyRevocationSignature(s) == nil
+	case tagUserId:
+		if (s.SigType < packet.SigTypeGenericCert || s.SigType > packet.SigTypePositiveCert) && s.SigType != sigTypeCertificationRevocation {
+			return false
+		}
+		return issuer.VerifyUserIdSignature(string(c.packet.Contents), primary, s) == nil
+	case tagPublicSubkey:
+		if issuer != primary || (s.SigType != packet.SigTypeSubkeyBinding && s.SigType != packet.SigTypeSubkeyRevocation) {
+			return false
+		}
+		subkey, err := c.packet.Parse()
+		if err != nil {
+			return false
+		}
+		pub, ok := subkey.(*packet.PublicKey)
+		return ok && primary.VerifyKeySignature(pub, s) == nil
+	}
+
+	// Signatures of user attributes cannot be verified by the openpgp package
+	return false
+}
+
+// validComponents returns the user ids and subkeys of armoredKey that have a valid self signature / binding signature
+func validComponents(armoredKey string) (userIds map[string]bool, subkeys map[string]bool, err error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredKey))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(entities) == 0 {
+		return nil, nil, fmt.Errorf("no public key found")
+	}
+
+	userIds = make(map[string]bool)
+	subkeys = make(map[string]bool)
+
+	for name := range entities[0].Identities {
+		userIds[name] = true
+	}
+
+	for _, v := range entities[0].Subkeys {
+		var b bytes.Buffer
+		if err := v.PublicKey.Serialize(&b); err != nil {
+			return nil, nil, err
+		}
+		p, err := packet.NewOpaqueReader(&b).Next()
+		if err != nil {
+			return nil, nil, err
+		}
+		subkeys[string(p.Contents)] = true
+	}
+
+	return userIds, subkeys, nil
+}
+
+// MergeKeys merges the packets of incomingKey into existingKey like a OpenPGP keyserver does
+//
+// The result has the union of user ids, subkeys and signatures of both keys, without duplicated signatures.
+// New user ids and subkeys from incomingKey are only accepted with a valid self signature. Both keys should have the same fingerprint.
+// New signatures are only merged if they are valid, and certifications made by other keys need their issuer returned by getIssuer.
+// Returns the merged key in ASCII Armored format and what changed in existingKey
+func MergeKeys(existingKey, incomingKey string, getIssuer func(keyId uint64) *openpgp.Entity) (string, models.KeyMergeResult, error) {
+	var result models.KeyMergeResult
+
+	existing, err := readKeyPackets(existingKey)
+	if err != nil {
+		return "", result, fmt.Errorf("error reading existing key: %s", err)
+	}
+
+	incoming, err := readKeyPackets(incomingKey)
+	if err != nil {
+		return "", result, fmt.Errorf("error reading incoming key: %s", err)
+	}
+
+	if !bytes.Equal(existing.primary.packet.Contents, incoming.primary.packet.Contents) {
+		return "", result, fmt.Errorf("the keys have different primary keys")
+	}
+
+	validUserIds, validSubkeys, err := validComponents(incomingKey)
+	if err != nil {
+		return "", result, fmt.Errorf("error reading incoming key: %s", err)
+	}
+
+	fp, err := tools.GetFingerPrintFromKey(existingKey)
+	if err != nil {
+		return "", result, err
+	}
+
+	parsedPrimary, err := existing.primary.packet.Parse()
+	if err != nil {
+		return "", result, fmt.Errorf("error reading existing key: %s", err)
+	}
+
+	primary, ok := parsedPrimary.(*packet.PublicKey)
+	if !ok {
+		return "", result, fmt.Errorf("error reading existing key: invalid primary key packet")
+	}
+
+	result.FingerPrint = fp
+	result.NewUserIds = make([]string, 0)
+	result.NewSubkeys = make([]string, 0)
+
+	mergeSignatures := func(target, source *keyComponent) {
+		for _, sig := range source.signatures {
+			if target.hasSignature(sig) || !verifyComponentSignature(primary, target, sig, getIssuer) {
+				continue
+			}
+
+			target.signatures = append(target.signatures, sig)
+			if isRevocation(sig) {
+				result.NewRevocations++
+			} else {
+				result.NewSignatures++
+			}
+		}
+	}
+
+	mergeSignatures(existing.primary, incoming.primary)
+
+	for _, uid := range incoming.userIds {
+		if target := findComponent(existing.userIds, uid.packet); target != nil {
+			mergeSignatures(target, uid)
+			continue
+		}
+
+		// User attributes are not validated by the openpgp package, so only known ones are kept
+		if uid.packet.Tag != tagUserId || !validUserIds[string(uid.packet.Contents)] {
+			continue
+		}
+
+		target := &keyComponent{packet: uid.packet}
+		existing.userIds = append(existing.userIds, target)
+		result.NewUserIds = append(result.NewUserIds, string(uid.packet.Contents))
+		mergeSignatures(target, uid)
+	}
+
+	for _, subkey := range incoming.subkeys {
+		if target := findComponent(existing.subkeys, subkey.packet); target != nil {
+			mergeSignatures(target, subkey)
+			continue
+		}
+
+		if !validSubkeys[string(subkey.packet.Contents)] {
+			continue
+		}
+
+		parsed, err := subkey.packet.Parse()
+		if err != nil {
+			continue
+		}
+
+		target := &keyComponent{packet: subkey.packet}
+		existing.subkeys = append(existing.subkeys, target)
+		if pub, ok := parsed.(*packet.PublicKey); ok {
+			result.NewSubkeys = append(result.NewSubkeys, tools.IssuerKeyIdToFP16(pub.KeyId))
+		}
+		mergeSignatures(target, subkey)
+	}
+
+	merged, err := existing.serialize()
+	if err != nil {
+		return "", result, err
+	}
+
+	return merged, result, nil
+}
@@ -0,0 +1,454 @@
+package keymagic
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/database/memory"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/armor"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+var mergeTestConfig = &packet.Config{RSABits: 1024}
+
+func armoredPublicKey(t *testing.T, e *openpgp.Entity) string {
+	t.Helper()
+
+	var b bytes.Buffer
+
+	w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.String()
+}
+
+// armoredRevokedKey returns the public key of e with a key revocation signature
+func armoredRevokedKey(t *testing.T, e *openpgp.Entity) string {
+	t.Helper()
+
+	var pk bytes.Buffer
+	if err := e.PrimaryKey.Serialize(&pk); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := packet.NewOpaqueReader(&pk).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// RFC 4880 5.2.4: the primary key is hashed as a old format packet
+	h := mergeTestConfig.Hash().New()
+	_, _ = h.Write([]byte{0x99, byte(len(p.Contents) >> 8), byte(len(p.Contents))})
+	_, _ = h.Write(p.Contents)
+
+	sig := &packet.Signature{
+		CreationTime: mergeTestConfig.Now(),
+		SigType:      packet.SigTypeKeyRevocation,
+		PubKeyAlgo:   e.PrimaryKey.PubKeyAlgo,
+		Hash:         mergeTestConfig.Hash(),
+		IssuerKeyId:  &e.PrimaryKey.KeyId,
+	}
+
+	if err := sig.Sign(h, e.PrivateKey, mergeTestConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+
+	w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.PrimaryKey.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sig.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ident := range e.Identities {
+		if err := ident.UserId.Serialize(w); err != nil {
+			t.Fatal(err)
+		}
+		if err := ident.SelfSignature.Serialize(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, subkey := range e.Subkeys {
+		if err := subkey.PublicKey.Serialize(w); err != nil {
+			t.Fatal(err)
+		}
+		if err := subkey.Sig.Serialize(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return b.String()
+}
+
+func addIdentity(t *testing.T, e *openpgp.Entity, name, email string) string {
+	t.Helper()
+
+	uid := packet.NewUserId(name, "", email)
+	sig := &packet.Signature{
+		CreationTime: mergeTestConfig.Now(),
+		SigType:      packet.SigTypePositiveCert,
+		PubKeyAlgo:   packet.PubKeyAlgoRSA,
+		Hash:         mergeTestConfig.Hash(),
+		IssuerKeyId:  &e.PrimaryKey.KeyId,
+	}
+
+	if err := sig.SignUserId(uid.Id, e.PrimaryKey, e.PrivateKey, mergeTestConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	e.Identities[uid.Id] = &openpgp.Identity{
+		Name:          uid.Id,
+		UserId:        uid,
+		SelfSignature: sig,
+	}
+
+	return uid.Id
+}
+
+func TestMergeKeys(t *testing.T) {
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := armoredPublicKey(t, e)
+
+	// Unchanged upload
+	merged, result, err := MergeKeys(original, original, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Changed() {
+		t.Errorf("Expected no changes merging the same key, got %s", result.String())
+	}
+
+	if result.FingerPrint != tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId) {
+		t.Errorf("Expected fingerprint %s got %s", tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId), result.FingerPrint)
+	}
+
+	// New user id with a signature from a third party
+	newId := addIdentity(t, e, "John HUEBR", "john@work.huebr.com")
+
+	signer, err := openpgp.NewEntity("Maria HUEBR", "", "maria@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.SignIdentity(newId, signer, mergeTestConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := armoredPublicKey(t, e)
+
+	// Third party signatures are dropped if the issuer is not known
+	_, result, err = MergeKeys(original, updated, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.NewSignatures != 1 {
+		t.Errorf("Expected only the self signature to be merged, got %d new signatures", result.NewSignatures)
+	}
+
+	issuers := func(keyId uint64) *openpgp.Entity {
+		if keyId == signer.PrimaryKey.KeyId {
+			return signer
+		}
+		return nil
+	}
+
+	merged, result, err = MergeKeys(original, updated, issuers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.NewUserIds) != 1 || result.NewUserIds[0] != newId {
+		t.Errorf("Expected new user id %q got %v", newId, result.NewUserIds)
+	}
+
+	if result.NewSignatures != 2 {
+		t.Errorf("Expected 2 new signatures got %d", result.NewSignatures)
+	}
+
+	if len(result.NewSubkeys) != 0 || result.NewRevocations != 0 {
+		t.Errorf("Expected no new subkeys or revocations, got %s", result.String())
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(merged))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entities[0].Identities) != 2 {
+		t.Errorf("Expected merged key to have 2 identities got %d", len(entities[0].Identities))
+	}
+
+	if len(entities[0].Identities[newId].Signatures) != 1 {
+		t.Errorf("Expected the third party signature to be kept in the merged key")
+	}
+
+	// Merging again should not duplicate anything
+	_, result, err = MergeKeys(merged, updated, issuers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Changed() {
+		t.Errorf("Expected no changes merging an already merged key, got %s", result.String())
+	}
+
+	// A older upload should not remove packets
+	_, result, err = MergeKeys(merged, original, issuers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Changed() {
+		t.Errorf("Expected no changes merging an older key, got %s", result.String())
+	}
+
+	// Different keys cannot be merged
+	_, _, err = MergeKeys(original, armoredPublicKey(t, signer), issuers)
+	if err == nil {
+		t.Errorf("Expected error merging different keys")
+	}
+}
+
+func TestMergeKeysSubkey(t *testing.T) {
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subkey := e.Subkeys[0]
+	e.Subkeys = nil
+	original := armoredPublicKey(t, e)
+
+	e.Subkeys = []openpgp.Subkey{subkey}
+	updated := armoredPublicKey(t, e)
+
+	_, result, err := MergeKeys(original, updated, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.NewSubkeys) != 1 || result.NewSubkeys[0] != tools.IssuerKeyIdToFP16(subkey.PublicKey.KeyId) {
+		t.Errorf("Expected new subkey %s got %v", tools.IssuerKeyIdToFP16(subkey.PublicKey.KeyId), result.NewSubkeys)
+	}
+
+	if result.NewSignatures != 1 {
+		t.Errorf("Expected 1 new signature got %d", result.NewSignatures)
+	}
+
+	// Keys with invalid binding signatures are rejected
+	other, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.Subkeys = []openpgp.Subkey{other.Subkeys[0]}
+
+	_, _, err = MergeKeys(original, armoredPublicKey(t, e), nil)
+	if err == nil {
+		t.Errorf("Expected error merging a subkey bound to another key")
+	}
+}
+
+func TestMergeKeysRevocation(t *testing.T) {
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged, result, err := MergeKeys(armoredPublicKey(t, e), armoredRevokedKey(t, e), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.NewRevocations != 1 || result.NewSignatures != 0 {
+		t.Errorf("Expected 1 new revocation, got %s", result.String())
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(merged))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entities[0].Revocations) != 1 {
+		t.Errorf("Expected merged key to be revoked")
+	}
+}
+
+func TestMergeKeysForgedSignatures(t *testing.T) {
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := armoredPublicKey(t, e)
+
+	forger, err := openpgp.NewEntity("Maria HUEBR", "", "maria@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A user id revocation claiming to be issued by the key itself
+	for id, identity := range e.Identities {
+		sig := &packet.Signature{
+			CreationTime: mergeTestConfig.Now(),
+			SigType:      sigTypeCertificationRevocation,
+			PubKeyAlgo:   forger.PrivateKey.PubKeyAlgo,
+			Hash:         mergeTestConfig.Hash(),
+			IssuerKeyId:  &e.PrimaryKey.KeyId,
+		}
+
+		if err := sig.SignUserId(id, e.PrimaryKey, forger.PrivateKey, mergeTestConfig); err != nil {
+			t.Fatal(err)
+		}
+
+		identity.Signatures = append(identity.Signatures, sig)
+	}
+
+	_, result, err := MergeKeys(original, armoredPublicKey(t, e), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Changed() {
+		t.Errorf("Expected forged signatures to be dropped, got %s", result.String())
+	}
+}
+
+func TestMergeKeysSubkeyRevocation(t *testing.T) {
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original := armoredPublicKey(t, e)
+
+	revocation := &packet.Signature{
+		CreationTime: mergeTestConfig.Now(),
+		SigType:      packet.SigTypeSubkeyRevocation,
+		PubKeyAlgo:   e.PrimaryKey.PubKeyAlgo,
+		Hash:         mergeTestConfig.Hash(),
+		IssuerKeyId:  &e.PrimaryKey.KeyId,
+	}
+
+	if err := revocation.SignKey(e.Subkeys[0].PublicKey, e.PrivateKey, mergeTestConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	e.Subkeys[0].Sig = revocation
+
+	merged, result, err := MergeKeys(original, armoredPublicKey(t, e), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.NewRevocations != 1 {
+		t.Errorf("Expected 1 new revocation, got %s", result.String())
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(merged))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entities[0].Subkeys) != 1 || entities[0].Subkeys[0].Sig.SigType != packet.SigTypeSubkeyRevocation {
+		t.Errorf("Expected the subkey of the merged key to be revoked")
+	}
+}
+
+func TestPKSMerge(t *testing.T) {
+	dbh := memory.MakeMemoryDBDriver(nil)
+	ctx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, dbh)
+
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)
+
+	result, err := PKSMerge(ctx, armoredPublicKey(t, e))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Added {
+		t.Errorf("Expected key to be added, got %s", result.String())
+	}
+
+	newId := addIdentity(t, e, "John HUEBR", "john@work.huebr.com")
+
+	result, err = PKSMerge(ctx, armoredPublicKey(t, e))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Added || len(result.NewUserIds) != 1 {
+		t.Errorf("Expected key to be updated with a new user id, got %s", result.String())
+	}
+
+	key, err := dbh.FetchGPGKeyByFingerprint(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, v := range key.KeyUids {
+		if v.Email == "john@work.huebr.com" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("Expected stored key uids to have %q", newId)
+	}
+
+	if !strings.Contains(key.AsciiArmoredPublicKey, "PGP PUBLIC KEY BLOCK") {
+		t.Errorf("Expected stored key to have the merged public key")
+	}
+
+	result, err = PKSMerge(ctx, armoredPublicKey(t, e))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Changed() {
+		t.Errorf("Expected no changes, got %s", result.String())
+	}
+
+	if PKSAdd(ctx, "huebrbrbrbrbr") != "NOK" {
+		t.Errorf("Expected NOK adding invalid key")
+	}
+}
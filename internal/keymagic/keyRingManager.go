@@ -3,7 +3,9 @@ package keymagic
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/quan-to/chevron/internal/config"
 	"github.com/quan-to/chevron/internal/tools"
@@ -13,11 +15,18 @@ import (
 	"github.com/quan-to/slog"
 )
 
+var keyRingsLock sync.Mutex
+var keyRings []*KeyRingManager
+
 type KeyRingManager struct {
 	sync.Mutex
 	fingerPrints []string
 	entities     map[string]*openpgp.Entity
 	keyInfo      map[string]models.KeyInfo
+	subKeyToKey  map[string]string
+	fetchedAt    map[string]time.Time
+	lastUsed     map[string]time.Time
+	sources      map[string]models.KeySource
 	log          slog.Instance
 	dbh          DatabaseHandler
 }
@@ -30,13 +39,53 @@ func MakeKeyRingManager(log slog.Instance, dbHandler DatabaseHandler) *KeyRingMa
 		log = log.SubScope("KRM")
 	}
 
-	return &KeyRingManager{
+	krm := &KeyRingManager{
 		fingerPrints: make([]string, 0),
 		entities:     make(map[string]*openpgp.Entity),
 		keyInfo:      make(map[string]models.KeyInfo),
+		subKeyToKey:  make(map[string]string),
+		fetchedAt:    make(map[string]time.Time),
+		lastUsed:     make(map[string]time.Time),
+		sources:      make(map[string]models.KeySource),
 		log:          log,
 		dbh:          dbHandler,
 	}
+
+	keyRingsLock.Lock()
+	keyRings = append(keyRings, krm)
+	keyRingsLock.Unlock()
+
+	return krm
+}
+
+// allKeyRings returns every KeyRingManager created in this process that was not closed
+func allKeyRings() []*KeyRingManager {
+	keyRingsLock.Lock()
+	defer keyRingsLock.Unlock()
+
+	return append([]*KeyRingManager{}, keyRings...)
+}
+
+// Close stops the Public Key Store invalidations and the periodic refresh of the Key Ring, so it can be garbage collected
+func (krm *KeyRingManager) Close() {
+	keyRingsLock.Lock()
+	defer keyRingsLock.Unlock()
+
+	for i, v := range keyRings {
+		if v == krm {
+			keyRings = append(keyRings[:i], keyRings[i+1:]...)
+			return
+		}
+	}
+}
+
+func init() {
+	// Keys changed in the Public Key Store are fetched again by every Key Ring in the next use
+	OnPKSKeyUpdated(func(ctx context.Context, fingerPrint string) {
+		for _, krm := range allKeyRings() {
+			krm.InvalidateKey(ctx, fingerPrint)
+		}
+	})
 }
 
 func (krm *KeyRingManager) containsFp(fp string) bool {
@@ -55,6 +104,10 @@ func (krm *KeyRingManager) removeFp(fp string) {
 			krm.fingerPrints = append(krm.fingerPrints[:i], krm.fingerPrints[i+1:]...)
 			delete(krm.entities, fp)
 			delete(krm.keyInfo, fp)
+			delete(krm.subKeyToKey, fp)
+			delete(krm.fetchedAt, fp)
+			delete(krm.lastUsed, fp)
+			delete(krm.sources, fp)
 			return
 		}
 	}
@@ -64,7 +117,37 @@ func (krm *KeyRingManager) addFp(fp string) {
 	krm.fingerPrints = append(krm.fingerPrints, fp)
 }
 
+// removeKey removes a erasable key and its subkeys from the cache
+func (krm *KeyRingManager) removeKey(fp string) {
+	for sub, master := range krm.subKeyToKey {
+		if master == fp {
+			krm.removeFp(sub)
+		}
+	}
+
+	krm.removeFp(fp)
+}
+
+// masterFp returns the fingerprint of the master key of a cached subkey, or fp itself
+func (krm *KeyRingManager) masterFp(fp string) string {
+	if master, ok := krm.subKeyToKey[fp]; ok {
+		return master
+	}
+
+	return fp
+}
+
 func (krm *KeyRingManager) AddKey(ctx context.Context, key *openpgp.Entity, nonErasable bool) {
+	source := models.KeySourcePKS
+	if nonErasable {
+		source = models.KeySourceLocal
+	}
+
+	krm.addKey(ctx, key, nonErasable, source)
+}
+
+// addKey adds the key to the cache recording where it was loaded from
+func (krm *KeyRingManager) addKey(ctx context.Context, key *openpgp.Entity, nonErasable bool, source models.KeySource) {
 	requestID := tools.GetRequestIDFromContext(ctx)
 	log := krm.log.Tag(requestID)
 	log.DebugNote("AddKey(---, %v)", nonErasable)
@@ -82,11 +165,13 @@ func (krm *KeyRingManager) AddKey(ctx context.Context, key *openpgp.Entity, nonE
 			krm.removeFp(lastFp)
 		}
 		krm.addFp(fp)
+		krm.fetchedAt[fp] = time.Now()
 	}
 
 	log.Info("Adding Public Key %s to the cache", fp)
 
 	krm.entities[fp] = key
+	krm.sources[fp] = source
 
 	keyBits, _ := key.PrimaryKey.BitLength()
 
@@ -103,8 +188,15 @@ func (krm *KeyRingManager) AddKey(ctx context.Context, key *openpgp.Entity, nonE
 	for _, sub := range key.Subkeys {
 		subfp := tools.ByteFingerPrint2FP16(sub.PublicKey.Fingerprint[:])
 		subE := tools.CreateEntityForSubKey(fp, sub.PublicKey, sub.PrivateKey)
+		// A revoked master key also revokes its subkeys
+		subE.Revocations = key.Revocations
 		log.Debug("	Adding also subkey %s", subfp)
-		krm.AddKey(ctx, subE, nonErasable)
+		krm.addKey(ctx, subE, nonErasable, source)
+		if !nonErasable {
+			krm.Lock()
+			krm.subKeyToKey[subfp] = fp
+			krm.Unlock()
+		}
 	}
 }
 
@@ -117,6 +209,7 @@ func (krm *KeyRingManager) DeleteKey(ctx context.Context, fp string) error {
 	if _, ok := krm.entities[fp]; ok {
 		log.Info("Deleting key %s from memory", fp)
 		delete(krm.entities, fp)
+		delete(krm.sources, fp)
 		keyFound = true
 	}
 	krm.Unlock()
@@ -152,15 +245,40 @@ func (krm *KeyRingManager) ContainsKey(ctx context.Context, fp string) bool {
 	return krm.entities[fp] != nil
 }
 
+// GetKey returns the cached key with the specified fingerprint, fetching it from the Public Key Store if it is not cached
+// or if it was fetched more than config.KeyRingRefreshInterval ago
 func (krm *KeyRingManager) GetKey(ctx context.Context, fp string) *openpgp.Entity {
 	requestID := tools.GetRequestIDFromContext(ctx)
 	log := krm.log.Tag(requestID)
 	log.DebugNote("GetKey(%s)", fp)
 	krm.Lock()
 	ent := krm.entities[fp]
+	masterFp := krm.masterFp(fp)
+	fetchedAt, erasable := krm.fetchedAt[masterFp]
+	if !erasable {
+		// Master key evicted from the cache before its subkey
+		masterFp = fp
+		fetchedAt, erasable = krm.fetchedAt[fp]
+	}
+	if ent != nil && erasable {
+		krm.lastUsed[masterFp] = time.Now()
+	}
 	krm.Unlock()
 
+	if ent != nil && (!erasable || !krm.isStale(fetchedAt)) {
+		return ent
+	}
+
 	if ent != nil {
+		log.Info("Key %s was fetched at %s. Refreshing from KeyStore", fp, fetchedAt.Format(time.RFC3339))
+		if !krm.refreshKey(ctx, masterFp) {
+			return ent
+		}
+
+		krm.Lock()
+		ent = krm.entities[fp]
+		krm.Unlock()
+
 		return ent
 	}
 
@@ -168,7 +286,7 @@ func (krm *KeyRingManager) GetKey(ctx context.Context, fp string) *openpgp.Entit
 	log.Await("Key %s not found in local cache. Trying fetch KeyStore", fp)
 
 	ctx = context.WithValue(ctx, tools.CtxDatabaseHandler, krm.dbh)
-	asciiArmored, err := PKSGetKey(ctx, fp)
+	asciiArmored, source, err := pksGetKey(ctx, fp)
 
 	if err != nil {
 		log.Error("Error fetching from KeyStore: %s", err)
@@ -183,12 +301,158 @@ func (krm *KeyRingManager) GetKey(ctx context.Context, fp string) *openpgp.Entit
 		}
 		log.Info("Key %s found in PKS. Adding to local cache", fp)
 		ent = k
-		krm.AddKey(ctx, k, false)
+		krm.addKey(ctx, k, false, source)
 	}
 
 	return ent
 }
 
+func (krm *KeyRingManager) isStale(fetchedAt time.Time) bool {
+	return config.KeyRingRefreshInterval > 0 && time.Since(fetchedAt) > config.KeyRingRefreshInterval
+}
+
+// refreshKey fetches again the erasable key fp from the Public Key Store and replaces the cached one.
+// Returns true if the cache was changed. If the PKS cannot be reached the cached key is kept until the next refresh interval
+func (krm *KeyRingManager) refreshKey(ctx context.Context, fp string) bool {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := krm.log.Tag(requestID)
+
+	ctx = context.WithValue(ctx, tools.CtxDatabaseHandler, krm.dbh)
+	asciiArmored, source, err := pksGetKey(ctx, fp)
+
+	if err != nil && !strings.EqualFold(err.Error(), "not found") {
+		log.Error("Error refreshing key %s from KeyStore: %s. Keeping cached key", fp, err)
+		krm.Lock()
+		if _, ok := krm.fetchedAt[fp]; ok {
+			krm.fetchedAt[fp] = time.Now()
+		}
+		krm.Unlock()
+		return false
+	}
+
+	var k *openpgp.Entity
+
+	if len(asciiArmored) > 0 {
+		k, err = tools.ReadKeyToEntity(asciiArmored)
+		if err != nil {
+			log.Error("Invalid key received from PKS! Error: %s", err)
+			k = nil
+		}
+	}
+
+	krm.Lock()
+	if _, ok := krm.fetchedAt[fp]; !ok {
+		// Removed or turned non erasable while fetching
+		krm.Unlock()
+		return false
+	}
+	krm.removeKey(fp)
+	krm.Unlock()
+
+	if k == nil {
+		log.Warn("Key %s is not in the KeyStore anymore. Removed from cache", fp)
+		return true
+	}
+
+	log.Info("Key %s refreshed from KeyStore", fp)
+	krm.addKey(ctx, k, false, source)
+
+	return true
+}
+
+// GetMasterKey returns the cached key of the specified key or subkey fingerprint. Returns nil if it is not cached
+func (krm *KeyRingManager) GetMasterKey(ctx context.Context, fp string) *openpgp.Entity {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := krm.log.Tag(requestID)
+	log.DebugNote("GetMasterKey(%s)", fp)
+	krm.Lock()
+	defer krm.Unlock()
+
+	return krm.entities[krm.masterFp(fp)]
+}
+
+// GetKeySource returns where the cached key was loaded from. Returns empty if it is not cached
+func (krm *KeyRingManager) GetKeySource(ctx context.Context, fp string) models.KeySource {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := krm.log.Tag(requestID)
+	log.DebugNote("GetKeySource(%s)", fp)
+	krm.Lock()
+	defer krm.Unlock()
+
+	return krm.sources[fp]
+}
+
+// InvalidateKey removes a key cached from the Public Key Store, so it is fetched again in the next use.
+// Non erasable keys are not changed
+func (krm *KeyRingManager) InvalidateKey(ctx context.Context, fp string) {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := krm.log.Tag(requestID)
+	log.DebugNote("InvalidateKey(%s)", fp)
+	krm.Lock()
+	defer krm.Unlock()
+
+	fp = krm.masterFp(tools.FPto16(fp))
+
+	if _, ok := krm.fetchedAt[fp]; ok {
+		log.Info("Key %s changed in KeyStore. Removing from cache", fp)
+		krm.removeKey(fp)
+	}
+}
+
+// RefreshHotKeys refreshes the cached keys that were used since they were fetched and are older than half of config.KeyRingRefreshInterval,
+// so keys in use are refreshed before they get stale
+func (krm *KeyRingManager) RefreshHotKeys(ctx context.Context) {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := krm.log.Tag(requestID)
+	log.DebugNote("RefreshHotKeys()")
+
+	hotKeys := make([]string, 0)
+
+	krm.Lock()
+	for fp, fetchedAt := range krm.fetchedAt {
+		if _, isSubKey := krm.subKeyToKey[fp]; isSubKey {
+			continue
+		}
+		if krm.lastUsed[fp].After(fetchedAt) && time.Since(fetchedAt) > config.KeyRingRefreshInterval/2 {
+			hotKeys = append(hotKeys, fp)
+		}
+	}
+	krm.Unlock()
+
+	for _, fp := range hotKeys {
+		krm.refreshKey(ctx, fp)
+	}
+
+	if len(hotKeys) > 0 {
+		log.Info("Refreshed %d keys in use", len(hotKeys))
+	}
+}
+
+// KeyRingRefreshRoutine refreshes the keys in use of every KeyRingManager each half of config.KeyRingRefreshInterval until stopSig receives a value
+func KeyRingRefreshRoutine(stopSig chan bool) {
+	if config.KeyRingRefreshInterval <= 0 {
+		<-stopSig
+		return
+	}
+
+	ticker := time.NewTicker(config.KeyRingRefreshInterval / 2)
+	defer ticker.Stop()
+
+	ctx := context.WithValue(context.Background(), tools.CtxRequestID, tools.DefaultTag)
+
+	for {
+		select {
+		case <-stopSig:
+			slog.Scope("KRM").Info("Key Ring Refresh Routine Stopped")
+			return
+		case <-ticker.C:
+			for _, krm := range allKeyRings() {
+				krm.RefreshHotKeys(ctx)
+			}
+		}
+	}
+}
+
 func (krm *KeyRingManager) GetFingerPrints(ctx context.Context) []string {
 	requestID := tools.GetRequestIDFromContext(ctx)
 	log := krm.log.Tag(requestID)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/quan-to/chevron/internal/agent"
 	remote_signer "github.com/quan-to/chevron/internal/config"
@@ -13,6 +14,7 @@ import (
 	"github.com/quan-to/chevron/pkg/database/memory"
 	"github.com/quan-to/chevron/pkg/interfaces"
 	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
 	"github.com/quan-to/chevron/test"
 	"github.com/quan-to/slog"
 )
@@ -297,3 +299,215 @@ func TestGetKeyExternal(t *testing.T) {
 //        t.Error(err)
 //    }
 //}
+
+func TestKeyRingRefresh(t *testing.T) {
+	remote_signer.PushVariables()
+	defer remote_signer.PopVariables()
+	remote_signer.KeyRingRefreshInterval = time.Hour
+
+	mem := memory.MakeMemoryDBDriver(nil)
+	ctx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, mem)
+	krm := MakeKeyRingManager(nil, mem)
+
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)
+	subFp := tools.IssuerKeyIdToFP16(e.Subkeys[0].PublicKey.KeyId)
+
+	if _, err := PKSMerge(ctx, armoredPublicKey(t, e)); err != nil {
+		t.Fatal(err)
+	}
+
+	if k := krm.GetKey(ctx, fp); k == nil || len(k.Identities) != 1 {
+		t.Fatalf("Expected key %s with 1 identity to be fetched from PKS", fp)
+	}
+
+	// Updates in the PKS invalidate the cached key
+	addIdentity(t, e, "John HUEBR", "john@work.huebr.com")
+
+	if _, err := PKSMerge(ctx, armoredPublicKey(t, e)); err != nil {
+		t.Fatal(err)
+	}
+
+	if k := krm.GetKey(ctx, fp); k == nil || len(k.Identities) != 2 {
+		t.Errorf("Expected key %s to be refreshed with the new identity", fp)
+	}
+
+	// Changes made by other nodes are only seen after the refresh interval
+	gpgKey, err := models.AsciiArmored2GPGKey(armoredRevokedKey(t, e))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mem.UpdateGPGKey(gpgKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if k := krm.GetKey(ctx, fp); k == nil || len(k.Revocations) != 0 {
+		t.Errorf("Expected cached key %s to be used before the refresh interval", fp)
+	}
+
+	krm.Lock()
+	krm.fetchedAt[fp] = time.Now().Add(-2 * time.Hour)
+	krm.Unlock()
+
+	if k := krm.GetKey(ctx, subFp); k == nil || len(k.Revocations) != 1 {
+		t.Errorf("Expected subkey %s to be refreshed with the master key revocation", subFp)
+	}
+
+	if k := krm.GetKey(ctx, fp); k == nil || len(k.Revocations) != 1 {
+		t.Errorf("Expected key %s to be refreshed with the revocation", fp)
+	}
+}
+
+func TestKeyRingRefreshHotKeys(t *testing.T) {
+	remote_signer.PushVariables()
+	defer remote_signer.PopVariables()
+	remote_signer.KeyRingRefreshInterval = time.Hour
+
+	mem := memory.MakeMemoryDBDriver(nil)
+	ctx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, mem)
+	krm := MakeKeyRingManager(nil, mem)
+
+	hot, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cold, err := openpgp.NewEntity("Maria HUEBR", "", "maria@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hotFp := tools.IssuerKeyIdToFP16(hot.PrimaryKey.KeyId)
+	coldFp := tools.IssuerKeyIdToFP16(cold.PrimaryKey.KeyId)
+
+	for _, e := range []*openpgp.Entity{hot, cold} {
+		if _, err := PKSMerge(ctx, armoredPublicKey(t, e)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	past := time.Now().Add(-45 * time.Minute)
+
+	// Both keys are fetched, but only the hot one is used after that
+	krm.GetKey(ctx, hotFp)
+	krm.GetKey(ctx, coldFp)
+
+	krm.Lock()
+	krm.fetchedAt[hotFp] = past
+	krm.fetchedAt[coldFp] = past
+	krm.Unlock()
+
+	krm.GetKey(ctx, hotFp)
+
+	krm.RefreshHotKeys(ctx)
+
+	krm.Lock()
+	defer krm.Unlock()
+
+	if !krm.fetchedAt[hotFp].After(past) {
+		t.Errorf("Expected key in use %s to be refreshed", hotFp)
+	}
+
+	if krm.fetchedAt[coldFp] != past {
+		t.Errorf("Expected key not used %s to not be refreshed", coldFp)
+	}
+}
+
+// memoryPubSub is a memory database with a single channel message broker
+type memoryPubSub struct {
+	*memory.DbDriver
+	messages chan string
+}
+
+func (ps *memoryPubSub) Publish(ctx context.Context, channel, message string) error {
+	ps.messages <- message
+	return nil
+}
+
+func (ps *memoryPubSub) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	return ps.messages, nil
+}
+
+func TestPKSUpdateRoutine(t *testing.T) {
+	remote_signer.PushVariables()
+	defer remote_signer.PopVariables()
+	remote_signer.KeyRingRefreshInterval = time.Hour
+	remote_signer.EnableRedis = true
+
+	mem := memory.MakeMemoryDBDriver(nil)
+	broker := &memoryPubSub{DbDriver: mem, messages: make(chan string, 1)}
+	ctx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, broker)
+	krm := MakeKeyRingManager(nil, mem)
+
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)
+
+	if _, err := PKSMerge(ctx, armoredPublicKey(t, e)); err != nil {
+		t.Fatal(err)
+	}
+
+	addIdentity(t, e, "John HUEBR", "john@work.huebr.com")
+
+	if _, err := PKSMerge(ctx, armoredPublicKey(t, e)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-broker.messages:
+		if msg != fp {
+			t.Errorf("Expected update of key %s to be published, got %s", fp, msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Expected update of key %s to be published", fp)
+	}
+
+	if k := krm.GetKey(ctx, fp); k == nil || len(k.Revocations) != 0 {
+		t.Fatalf("Expected key %s to be fetched from PKS", fp)
+	}
+
+	// Another node revokes the key
+	gpgKey, err := models.AsciiArmored2GPGKey(armoredRevokedKey(t, e))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := mem.UpdateGPGKey(gpgKey); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan bool)
+	go PKSUpdateRoutine(broker, stop)
+	broker.messages <- fp
+
+	revoked := false
+	for i := 0; i < 100 && !revoked; i++ {
+		if k := krm.GetKey(ctx, fp); k != nil && len(k.Revocations) == 1 {
+			revoked = true
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	stop <- true
+
+	if !revoked {
+		t.Errorf("Expected key %s to be refreshed after the update of the other node", fp)
+	}
+
+	krm.Close()
+
+	for _, v := range allKeyRings() {
+		if v == krm {
+			t.Errorf("Expected closed Key Ring to be removed from the Key Rings")
+		}
+	}
+}
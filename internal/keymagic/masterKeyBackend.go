@@ -0,0 +1,22 @@
+// +build !js,!wasm
+
+package keymagic
+
+import (
+	"path"
+
+	config "github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/keybackend"
+	"github.com/quan-to/chevron/internal/vaultManager"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/slog"
+)
+
+// makeMasterKeyBackend returns the StorageBackend of the master key defined by environment variables VaultStorage, MasterGPGKeyPath
+func makeMasterKeyBackend(log slog.Instance) interfaces.StorageBackend {
+	if config.VaultStorage {
+		return vaultManager.MakeVaultManager(log, "__master__")
+	}
+
+	return keybackend.MakeSaveToDiskBackend(log, path.Dir(config.MasterGPGKeyPath), "__master__")
+}
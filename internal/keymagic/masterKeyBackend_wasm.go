@@ -0,0 +1,15 @@
+package keymagic
+
+import (
+	"path"
+
+	config "github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/keybackend"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/slog"
+)
+
+// makeMasterKeyBackend returns the SaveToDisk StorageBackend of the master key defined by environment variable MasterGPGKeyPath
+func makeMasterKeyBackend(log slog.Instance) interfaces.StorageBackend {
+	return keybackend.MakeSaveToDiskBackend(log, path.Dir(config.MasterGPGKeyPath), "__master__")
+}
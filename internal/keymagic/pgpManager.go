@@ -20,6 +20,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/quan-to/chevron/internal/config"
 	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/QuantoError"
 	"github.com/quan-to/chevron/pkg/interfaces"
 	"github.com/quan-to/chevron/pkg/models"
 	"github.com/quan-to/chevron/pkg/openpgp"
@@ -271,13 +272,13 @@ func (pm *pgpManager) unlockKey(ctx context.Context, fp, password string) error
 
 	if ent == nil {
 		pm.log.Error("No such key with fingerprint %s", fp)
-		return fmt.Errorf("no such key %s", fp)
+		return keyNotFound("no such key %s", fp)
 	}
 
 	pk := ent.PrivateKey
 
 	if pk == nil {
-		return errors.New(fmt.Sprintf("private key %s not found", fp))
+		return keyNotFound("private key %s not found", fp)
 	}
 
 	vpk := *pk // Copy data, for safety (aka: not unlock key at encrypted keys list)
@@ -499,9 +500,50 @@ func (pm *pgpManager) DeleteKey(ctx context.Context, fingerPrint string) error {
 
 // SignData signs the specified data with a unlocked private key
 func (pm *pgpManager) SignData(ctx context.Context, fingerPrint string, data []byte, hashAlgorithm crypto.Hash) (string, error) {
+	return pm.SignDataWithOptions(ctx, fingerPrint, data, hashAlgorithm, models.SignatureOptions{})
+}
+
+// SignDataWithOptions signs the specified data with a unlocked private key adding the notations, signer user id, policy URI and expiration of the options
+func (pm *pgpManager) SignDataWithOptions(ctx context.Context, fingerPrint string, data []byte, hashAlgorithm crypto.Hash, options models.SignatureOptions) (string, error) {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := pm.log.Tag(requestID)
+	log.DebugNote("SignDataWithOptions(%s, ---, %v, %d notations)", fingerPrint, hashAlgorithm, len(options.Notations))
+
+	ent, err := pm.unlockedEntity(ctx, fingerPrint)
+	if err != nil {
+		return "", err
+	}
+
+	c, err := signatureConfig(ent, hashAlgorithm, options)
+	if err != nil {
+		return "", err
+	}
+
+	d := bytes.NewReader(data)
+
+	var b bytes.Buffer
+	bw := bufio.NewWriter(&b)
+
+	if options.Timestamp {
+		err = pm.timestampedDetachSign(ctx, bw, ent, d, c)
+	} else {
+		err = openpgp.ArmoredDetachSign(bw, ent, d, c)
+	}
+	if err != nil {
+		return "", err
+	}
+	err = bw.Flush()
+	if err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// unlockedEntity returns a copy of the entity with the specified fingerprint with its decrypted private key
+func (pm *pgpManager) unlockedEntity(ctx context.Context, fingerPrint string) (*openpgp.Entity, error) {
 	requestID := tools.GetRequestIDFromContext(ctx)
 	log := pm.log.Tag(requestID)
-	log.DebugNote("SignData(%s, ---, %v)", fingerPrint, hashAlgorithm)
 	fingerPrint = pm.sanitizeFingerprint(fingerPrint)
 	pm.Lock()
 	pk := pm.decryptedPrivateKeys[fingerPrint]
@@ -511,7 +553,7 @@ func (pm *pgpManager) SignData(ctx context.Context, fingerPrint string, data []b
 		log.Warn("Private key %s not loaded or decrypted. Trying to load from keybackend", fingerPrint)
 		err := pm.LoadKeyFromKB(ctx, fingerPrint)
 		if err != nil {
-			return "", errors.New(fmt.Sprintf("key %s is not decrypt or not loaded", fingerPrint))
+			return nil, errors.New(fmt.Sprintf("key %s is not decrypt or not loaded", fingerPrint))
 		}
 		pm.Lock()
 		pk = pm.decryptedPrivateKeys[fingerPrint]
@@ -519,7 +561,7 @@ func (pm *pgpManager) SignData(ctx context.Context, fingerPrint string, data []b
 
 	if pk == nil {
 		pm.Unlock()
-		return "", errors.New(fmt.Sprintf("key %s is not decrypt or not loaded", fingerPrint))
+		return nil, errors.New(fmt.Sprintf("key %s is not decrypt or not loaded", fingerPrint))
 	}
 
 	vpk := *pk
@@ -527,25 +569,7 @@ func (pm *pgpManager) SignData(ctx context.Context, fingerPrint string, data []b
 	ent.PrivateKey = &vpk
 	pm.Unlock()
 
-	d := bytes.NewReader(data)
-
-	var b bytes.Buffer
-	bw := bufio.NewWriter(&b)
-
-	c := &packet.Config{
-		DefaultHash: hashAlgorithm,
-	}
-
-	err := openpgp.ArmoredDetachSign(bw, &ent, d, c)
-	if err != nil {
-		return "", err
-	}
-	err = bw.Flush()
-	if err != nil {
-		return "", err
-	}
-
-	return b.String(), nil
+	return &ent, nil
 }
 
 // GetPublicKeyEntity returns the public key entity
@@ -565,8 +589,8 @@ func (pm *pgpManager) GetPublicKeyEntity(ctx context.Context, fingerPrint string
 		if len(subMaster) > 0 {
 			ent = pm.entities[subMaster]
 		} else {
-			// Try PKS
-			ent = pm.krm.GetKey(ctx, fingerPrint)
+			// Try PKS. Not stored in entities, so the Key Ring Manager can refresh it
+			return pm.krm.GetKey(ctx, fingerPrint)
 		}
 	}
 
@@ -605,9 +629,11 @@ func (pm *pgpManager) GetPublicKey(ctx context.Context, fingerPrint string) *pac
 			ent = pm.krm.GetKey(ctx, fingerPrint)
 			if ent == nil {
 				log.WarnDone("Not found in KeyRingManager")
-			} else {
-				log.Success("Found in Key Ring Manager")
+				return nil
 			}
+			// Not stored in entities, so the Key Ring Manager can refresh it
+			log.Success("Found in Key Ring Manager")
+			return ent.PrimaryKey
 		}
 	}
 
@@ -693,7 +719,7 @@ func (pm *pgpManager) GetPublicKeyASCII(ctx context.Context, fingerPrint string)
 	pubKey := pm.GetPublicKey(ctx, fingerPrint)
 
 	if pubKey == nil {
-		return "", fmt.Errorf("not found")
+		return "", keyNotFound("not found")
 	}
 
 	ent := pm.GetPublicKeyEntity(ctx, fingerPrint)
@@ -806,7 +832,7 @@ func (pm *pgpManager) GetPrivateKeyASCIIReencrypt(ctx context.Context, fingerPri
 
 		key = buf.String()
 	} else {
-		return "", fmt.Errorf("cannot find private key for %s", fingerPrint)
+		return "", keyNotFound("cannot find private key for %s", fingerPrint)
 	}
 
 	return key, nil
@@ -818,35 +844,35 @@ func (pm *pgpManager) GetPrivateKeyASCII(ctx context.Context, fingerPrint, passw
 }
 
 // VerifySignatureStringData verifies signature of specified data in string format
-func (pm *pgpManager) VerifySignatureStringData(ctx context.Context, data string, signature string) (bool, error) {
+func (pm *pgpManager) VerifySignatureStringData(ctx context.Context, data string, signature string) (*models.GPGVerifySignatureResult, error) {
 	requestID := tools.GetRequestIDFromContext(ctx)
 	log := pm.log.Tag(requestID)
 	log.DebugNote("VerifySignatureStringData(---, %s)", tools.TruncateFieldForDisplay(signature))
 	return pm.VerifySignature(ctx, []byte(data), signature)
 }
 
-// VerifySignatureStringData verifies signature of specified data
-func (pm *pgpManager) VerifySignature(ctx context.Context, data []byte, signature string) (bool, error) {
+// VerifySignature verifies signature of specified data and returns the signature details with the state and trust of the signer key
+func (pm *pgpManager) VerifySignature(ctx context.Context, data []byte, signature string) (*models.GPGVerifySignatureResult, error) {
 	requestID := tools.GetRequestIDFromContext(ctx)
 	log := pm.log.Tag(requestID)
 	log.DebugNote("VerifySignature(---, %s)", tools.TruncateFieldForDisplay(signature))
 	var issuerKeyId uint64
-	var publicKey *packet.PublicKey
+	var signer *openpgp.Entity
+	var source models.KeySource
 	var fingerprint string
 
 	signature = tools.SignatureFix(signature)
 	b := bytes.NewReader([]byte(signature))
 	block, err := armor.Decode(b)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	if block.Type != openpgp.SignatureType {
-		return false, errors.New("openpgp packet is not signature")
+		return nil, errors.New("openpgp packet is not signature")
 	}
 
 	reader := packet.NewReader(block.Body)
-	publicKey = nil
 	foundSignatureFingerprints := make([]string, 0)
 
 	for {
@@ -856,14 +882,14 @@ func (pm *pgpManager) VerifySignature(ctx context.Context, data []byte, signatur
 			if len(foundSignatureFingerprints) > 0 {
 				break // We found signatures just not public keys
 			} else {
-				return false, err
+				return nil, err
 			}
 		}
 
 		switch sig := pkt.(type) {
 		case *packet.Signature:
 			if sig.IssuerKeyId == nil {
-				return false, errors.New("signature doesn't have an issuer")
+				return nil, errors.New("signature doesn't have an issuer")
 			}
 			issuerKeyId = *sig.IssuerKeyId
 			fingerprint = tools.IssuerKeyIdToFP16(issuerKeyId)
@@ -875,30 +901,83 @@ func (pm *pgpManager) VerifySignature(ctx context.Context, data []byte, signatur
 		}
 
 		if len(fingerprint) == 16 {
-			publicKey = pm.GetPublicKey(ctx, fingerprint)
-			if publicKey != nil {
+			signer, source = pm.getSignerKey(ctx, fingerprint)
+			if signer != nil {
 				break
 			}
 		}
 	}
 
-	if publicKey == nil {
-		return false, fmt.Errorf("cannot find public key for any of these signatures: %s", strings.Join(foundSignatureFingerprints, ", "))
+	if signer == nil {
+		return nil, keyNotFound("cannot find public key for any of these signatures: %s", strings.Join(foundSignatureFingerprints, ", "))
 	}
 
-	keyRing := make(openpgp.EntityList, 1)
-	keyRing[0] = pm.entities[fingerprint]
-
 	dr := bytes.NewReader(data)
 	sr := strings.NewReader(signature)
 
-	_, err = openpgp.CheckArmoredDetachedSignature(keyRing, dr, sr)
-
+	key, sig, err := openpgp.VerifyArmoredDetachedSignature(openpgp.EntityList{signer}, dr, sr, verifyConfig())
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return true, nil
+	var timestampedAt *time.Time
+	var timestampAuthority, timestampError string
+
+	if s, ok := sig.(*packet.Signature); ok {
+		token, authority, err := pm.verifyTimestamp(ctx, s)
+		if err != nil {
+			log.Warn("Invalid timestamp of the signature by %s: %s", fingerprint, err)
+			timestampError = err.Error()
+		} else if token != nil {
+			timestampedAt = &token.GenTime
+			timestampAuthority = authority
+		}
+	}
+
+	result := signatureResult(key, sig, timestampedAt)
+	result.TimestampAuthority = timestampAuthority
+	result.TimestampError = timestampError
+	result.KeySource = source
+
+	te := makeTrustEvaluator(config.TrustedKeys, config.TrustCAKeys, func(fingerPrint string) *openpgp.Entity {
+		return pm.GetPublicKeyEntity(ctx, fingerPrint)
+	})
+
+	trust := te.keyTrust(signer)
+	result.Trusted = trust.Trusted
+	result.TrustedVia = trust.Via
+	log.Debug("%s", trust.String())
+
+	return result, nil
+}
+
+// getSignerKey returns the key with the specified key or subkey fingerprint and where it was loaded from
+func (pm *pgpManager) getSignerKey(ctx context.Context, fingerPrint string) (*openpgp.Entity, models.KeySource) {
+	pm.Lock()
+	fingerPrint = pm.sanitizeFingerprint(fingerPrint)
+	ent := pm.entities[fingerPrint]
+	if ent == nil {
+		if subMaster := pm.subKeyToKey[fingerPrint]; len(subMaster) > 0 {
+			ent = pm.entities[subMaster]
+		}
+	}
+	pm.Unlock()
+
+	if ent != nil {
+		return ent, models.KeySourceLocal
+	}
+
+	if pm.krm.GetKey(ctx, fingerPrint) == nil {
+		return nil, ""
+	}
+
+	// Subkeys are cached as separated keys, but the signer is the master key
+	ent = pm.krm.GetMasterKey(ctx, fingerPrint)
+	if ent == nil {
+		return nil, ""
+	}
+
+	return ent, pm.krm.GetKeySource(ctx, fingerPrint)
 }
 
 // GenerateTestKey generates a private key for testing
@@ -1032,14 +1111,10 @@ func (pm *pgpManager) Encrypt(ctx context.Context, filename, fingerPrint string,
 	var pubKey = pm.GetPublicKey(ctx, fingerPrint)
 
 	if pubKey == nil {
-		return "", fmt.Errorf("no public key for %s", fingerPrint)
+		return "", keyNotFound("no public key for %s", fingerPrint)
 	}
 	fingerPrint = tools.ByteFingerPrint2FP16(pubKey.Fingerprint[:])
-	var entity *openpgp.Entity
-
-	pm.Lock()
-	entity = pm.entities[fingerPrint]
-	pm.Unlock()
+	entity := pm.GetPublicKeyEntity(ctx, fingerPrint)
 
 	buf := bytes.NewBuffer(nil)
 
@@ -1217,3 +1292,8 @@ func (pm *pgpManager) GetCachedKeys(ctx context.Context) []models.KeyInfo {
 	log.DebugNote("GetCachedKeys()")
 	return pm.krm.GetCachedKeys(ctx)
 }
+
+// keyNotFound returns a NotFound error for a key that is not loaded
+func keyNotFound(format string, args ...interface{}) error {
+	return QuantoError.New(QuantoError.NotFound, "fingerPrint", fmt.Sprintf(format, args...), nil)
+}
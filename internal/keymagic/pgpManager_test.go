@@ -7,7 +7,9 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/quan-to/chevron/internal/config"
 	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/models"
 
 	"github.com/quan-to/chevron/test"
 )
@@ -21,21 +23,25 @@ func TestNilBackend(t *testing.T) {
 
 func TestVerifySign(t *testing.T) {
 	ctx := context.Background()
-	valid, err := pgpMan.VerifySignature(ctx, testData, test.TestSignatureSignature)
-	if err != nil || !valid {
-		t.Errorf("Signature not valid or error found: %s", err)
+	result, err := pgpMan.VerifySignature(ctx, testData, test.TestSignatureSignature)
+	if err != nil || !result.Valid {
+		t.Fatalf("Signature not valid or error found: %s", err)
+	}
+
+	if result.FingerPrint != test.TestKeyFingerprint || result.KeySource != models.KeySourceLocal || result.HashAlgorithm == "" || result.SignatureType != "binary" {
+		t.Errorf("Expected signature details of the local key %s, got %+v", test.TestKeyFingerprint, result)
 	}
 
-	valid, err = pgpMan.VerifySignatureStringData(ctx, test.TestSignatureData, test.TestSignatureSignature)
-	if err != nil || !valid {
+	result, err = pgpMan.VerifySignatureStringData(ctx, test.TestSignatureData, test.TestSignatureSignature)
+	if err != nil || !result.Valid {
 		t.Errorf("Signature not valid or error found: %s", err)
 	}
 
 	invalidTestData := []byte("huebr for the win!" + "makemeinvalid")
 
-	valid, err = pgpMan.VerifySignature(ctx, invalidTestData, test.TestSignatureSignature)
+	result, err = pgpMan.VerifySignature(ctx, invalidTestData, test.TestSignatureSignature)
 
-	if valid || err == nil {
+	if result != nil || err == nil {
 		t.Error("A invalid test data passed to verify has been validated!")
 	}
 }
@@ -48,6 +54,48 @@ func TestSign(t *testing.T) {
 	}
 }
 
+func TestSignTimestamp(t *testing.T) {
+	config.PushVariables()
+	defer config.PopVariables()
+
+	ctx := context.Background()
+
+	config.TimestampAuthorityKey = ""
+	config.TimestampAuthorityURL = ""
+
+	if _, err := pgpMan.SignDataWithOptions(ctx, test.TestKeyFingerprint, testData, crypto.SHA512, models.SignatureOptions{Timestamp: true}); err == nil {
+		t.Fatalf("Expected error timestamping without a timestamp authority")
+	}
+
+	config.TimestampAuthorityKey = test.TestKeyFingerprint
+
+	signature, err := pgpMan.SignDataWithOptions(ctx, test.TestKeyFingerprint, testData, crypto.SHA512, models.SignatureOptions{Timestamp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := pgpMan.VerifySignature(ctx, testData, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Valid || result.Timestamp == nil || result.TimestampAuthority != "Chevron TSA "+test.TestKeyFingerprint || result.TimestampError != "" {
+		t.Errorf("Expected valid signature with a timestamp of the built-in authority, got %+v", result)
+	}
+
+	// Without the built-in authority the self signed certificate is not trusted
+	config.TimestampAuthorityKey = ""
+
+	result, err = pgpMan.VerifySignature(ctx, testData, signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Valid || result.Timestamp != nil || result.TimestampError == "" {
+		t.Errorf("Expected valid signature with a untrusted timestamp, got %+v", result)
+	}
+}
+
 func TestDecrypt(t *testing.T) {
 	ctx := context.Background()
 	g, err := pgpMan.Decrypt(ctx, test.TestDecryptDataAscii, false)
@@ -170,11 +218,11 @@ func TestGenerateKey(t *testing.T) {
 		t.Error(err)
 	}
 	// Try verify
-	valid, err := pgpMan.VerifySignature(ctx, testData, signature)
+	result, err := pgpMan.VerifySignature(ctx, testData, signature)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-	if !valid {
+	if !result.Valid {
 		t.Error("Generated signature is not valid!")
 	}
 }
@@ -0,0 +1,82 @@
+package keymagic
+
+import (
+	"context"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/tools"
+)
+
+// pksUpdateChannel is the message broker channel that receives the fingerprints of the keys changed in the Public Key Store
+const pksUpdateChannel = "chevron_pks_key_updated"
+
+type pksPubSub interface {
+	Publish(ctx context.Context, channel, message string) error
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+}
+
+type pksNotifier interface {
+	Notify(ctx context.Context, channel, message string) error
+	Listen(ctx context.Context, channel string) (<-chan string, error)
+}
+
+// pksUpdateBroker returns the REDIS pub/sub of dbh if redis is enabled, or its PostgreSQL LISTEN / NOTIFY if the database is PostgreSQL.
+// Returns nil functions if dbh has no message broker
+func pksUpdateBroker(dbh interface{}) (publish func(ctx context.Context, channel, message string) error, subscribe func(ctx context.Context, channel string) (<-chan string, error)) {
+	if ps, ok := dbh.(pksPubSub); ok && config.EnableRedis {
+		return ps.Publish, ps.Subscribe
+	}
+
+	if n, ok := dbh.(pksNotifier); ok && config.DatabaseDialect == "postgres" {
+		return n.Notify, n.Listen
+	}
+
+	return nil, nil
+}
+
+// publishPKSKeyUpdated sends the fingerprint of a changed key to the other nodes of the cluster
+func publishPKSKeyUpdated(ctx context.Context, fingerPrint string) {
+	publish, _ := pksUpdateBroker(ctx.Value(tools.CtxDatabaseHandler))
+	if publish == nil {
+		return
+	}
+
+	if err := publish(ctx, pksUpdateChannel, fingerPrint); err != nil {
+		pksLog.Tag(tools.GetRequestIDFromContext(ctx)).Error("Error publishing the update of key %s: %s", fingerPrint, err)
+	}
+}
+
+// PKSUpdateRoutine runs the OnPKSKeyUpdated callbacks for the keys changed in the Public Key Store by other nodes until stopSig receives a value.
+// The changes are received from the REDIS pub/sub or PostgreSQL LISTEN / NOTIFY of dbh
+func PKSUpdateRoutine(dbh interface{}, stopSig chan bool) {
+	_, subscribe := pksUpdateBroker(dbh)
+	if subscribe == nil {
+		<-stopSig
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), tools.CtxRequestID, tools.DefaultTag))
+	defer cancel()
+
+	messages, err := subscribe(ctx, pksUpdateChannel)
+	if err != nil {
+		pksLog.Error("Error subscribing to the Public Key Store updates. Keys changed in other nodes are only refreshed periodically: %s", err)
+		<-stopSig
+		return
+	}
+
+	for {
+		select {
+		case <-stopSig:
+			pksLog.Info("Public Key Store Update Routine Stopped")
+			return
+		case fingerPrint, ok := <-messages:
+			if !ok {
+				pksLog.Error("The Public Key Store updates subscription was closed. Keys changed in other nodes are only refreshed periodically")
+				<-stopSig
+				return
+			}
+			runPKSKeyUpdated(ctx, fingerPrint)
+		}
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/keyserver"
 	"github.com/quan-to/chevron/internal/tools"
 	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
 
 	"github.com/quan-to/slog"
 )
@@ -17,11 +21,43 @@ type DatabaseHandler interface {
 	FindGPGKeyByFingerPrint(fingerPrint string, pageStart, pageEnd int) ([]models.GPGKey, error)
 	FindGPGKeyByValue(value string, pageStart, pageEnd int) ([]models.GPGKey, error)
 	FindGPGKeyByName(name string, pageStart, pageEnd int) ([]models.GPGKey, error)
+	SearchGPGKeys(query models.GPGKeySearchQuery) (*models.GPGKeySearchResult, error)
 	FetchGPGKeyByFingerprint(fingerprint string) (*models.GPGKey, error)
+	UpdateGPGKey(key models.GPGKey) error
+	DeleteGPGKey(key models.GPGKey) error
+	AddGPGKeyTombstone(tombstone models.GPGKeyTombstone) error
+	FetchGPGKeyTombstone(fingerPrint string) (*models.GPGKeyTombstone, error)
 }
 
 var pksLog = slog.Scope("PKS")
 
+var pksUpdateLock sync.Mutex
+var pksUpdateCallbacks []func(ctx context.Context, fingerPrint string)
+
+// OnPKSKeyUpdated registers a callback that is called with the fingerprint of every key added or changed in the Public Key Store
+func OnPKSKeyUpdated(cb func(ctx context.Context, fingerPrint string)) {
+	pksUpdateLock.Lock()
+	defer pksUpdateLock.Unlock()
+
+	pksUpdateCallbacks = append(pksUpdateCallbacks, cb)
+}
+
+// notifyPKSKeyUpdated runs the OnPKSKeyUpdated callbacks in this node and publishes the change to the other nodes
+func notifyPKSKeyUpdated(ctx context.Context, fingerPrint string) {
+	runPKSKeyUpdated(ctx, fingerPrint)
+	publishPKSKeyUpdated(ctx, fingerPrint)
+}
+
+func runPKSKeyUpdated(ctx context.Context, fingerPrint string) {
+	pksUpdateLock.Lock()
+	callbacks := pksUpdateCallbacks
+	pksUpdateLock.Unlock()
+
+	for _, cb := range callbacks {
+		cb(ctx, fingerPrint)
+	}
+}
+
 func dbHandlerFromContext(ctx context.Context) DatabaseHandler {
 	dbhI := ctx.Value(tools.CtxDatabaseHandler)
 	if dbhI != nil {
@@ -34,29 +70,100 @@ func dbHandlerFromContext(ctx context.Context) DatabaseHandler {
 	return nil
 }
 
+// PKSGetKey returns the key with the specified fingerprint as it is published by the Public Key Store
 func PKSGetKey(ctx context.Context, fingerPrint string) (string, error) {
+	key, source, err := pksGetKey(ctx, fingerPrint)
+	if err != nil || !config.PKSEmailVerification || dbHandlerFromContext(ctx) == nil {
+		return key, err
+	}
+
+	if source != models.KeySourcePKS {
+		// Upstream user ids are not verified by this server
+		return "", fmt.Errorf("not found")
+	}
+
+	return PKSPublishedKey(ctx, key)
+}
+
+// pksIssuer returns a function that finds signature issuers in the Public Key Store by their key id
+func pksIssuer(dbh DatabaseHandler) func(keyId uint64) *openpgp.Entity {
+	return func(keyId uint64) *openpgp.Entity {
+		key, err := dbh.FetchGPGKeyByFingerprint(tools.IssuerKeyIdToFP16(keyId))
+		if err != nil || key == nil {
+			return nil
+		}
+
+		entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key.AsciiArmoredPublicKey))
+		if err != nil || len(entities) == 0 {
+			return nil
+		}
+
+		return entities[0]
+	}
+}
+
+// pksGetKey returns the key with all its user ids and if it was found in the Public Key Store or in the upstream keyservers
+func pksGetKey(ctx context.Context, fingerPrint string) (string, models.KeySource, error) {
 	requestID := tools.GetRequestIDFromContext(ctx)
 	log := pksLog.Tag(requestID)
 	log.DebugNote("PKSGetKey(%q)", fingerPrint)
 	dbh := dbHandlerFromContext(ctx)
 	if dbh == nil {
-		return GetSKSKey(fingerPrint)
+		key, err := GetSKSKey(ctx, fingerPrint)
+		return key, models.KeySourceSKS, err
 	}
 
 	v, err := dbh.FetchGPGKeyByFingerprint(fingerPrint)
 
 	if v != nil {
-		return v.AsciiArmoredPublicKey, nil
+		return v.AsciiArmoredPublicKey, models.KeySourcePKS, nil
+	}
+
+	ks := upstream()
+
+	if ks == nil || (err != nil && !strings.EqualFold(err.Error(), "not found")) {
+		return "", "", err
+	}
+
+	tombstone, terr := fetchTombstone(ctx, dbh, fingerPrint)
+	if terr != nil {
+		return "", "", terr
+	}
+
+	if tombstone != nil && tombstone.IsWholeKey() {
+		return "", "", err
 	}
 
-	return "", err
+	key, uerr := ks.GetKeyByFingerPrint(ctx, fingerPrint)
+	if uerr != nil {
+		log.Debug("Key %s not found in upstream keyservers: %s", fingerPrint, uerr)
+		return "", "", err
+	}
+
+	if tombstone != nil {
+		if key, uerr = removeUserIds(key, removedEmails(tombstone)); uerr != nil {
+			return "", "", uerr
+		}
+	}
+
+	// Only keys verified to match a full fingerprint are stored, since key ids can collide
+	if keyserver.IsFullFingerPrint(fingerPrint) {
+		if _, err := pksMerge(ctx, key, false, nil); err != nil {
+			log.Error("Error storing upstream key %s in PKS: %s", fingerPrint, err)
+		}
+	}
+
+	return key, models.KeySourceSKS, nil
 }
 
 func PKSSearchByName(ctx context.Context, name string, pageStart, pageEnd int) ([]models.GPGKey, error) {
 	pksLog.DebugNote("PKSSearchByName(%s, %d, %d)", name, pageStart, pageEnd)
 	dbh := dbHandlerFromContext(ctx)
 	if dbh != nil {
-		return dbh.FindGPGKeyByName(name, pageStart, pageEnd)
+		keys, err := dbh.FindGPGKeyByName(name, pageStart, pageEnd)
+		return publishedKeys(keys, func(key models.GPGKey) bool {
+			return containsFold(key.Names, name)
+		}), err
 	}
 
 	return nil, fmt.Errorf("the server does not have database enabled so it cannot serve search")
@@ -66,7 +173,8 @@ func PKSSearchByFingerPrint(ctx context.Context, fingerPrint string, pageStart,
 	pksLog.DebugNote("PKSSearchByFingerPrint(%s, %d, %d)", fingerPrint, pageStart, pageEnd)
 	dbh := dbHandlerFromContext(ctx)
 	if dbh != nil {
-		return dbh.FindGPGKeyByFingerPrint(fingerPrint, pageStart, pageEnd)
+		keys, err := dbh.FindGPGKeyByFingerPrint(fingerPrint, pageStart, pageEnd)
+		return publishedKeys(keys, nil), err
 	}
 	return nil, fmt.Errorf("the server does not have database enabled so it cannot serve search")
 }
@@ -74,66 +182,278 @@ func PKSSearchByFingerPrint(ctx context.Context, fingerPrint string, pageStart,
 func PKSSearchByEmail(ctx context.Context, email string, pageStart, pageEnd int) ([]models.GPGKey, error) {
 	pksLog.DebugNote("PKSSearchByEmail(%s, %d, %d)", email, pageStart, pageEnd)
 	dbh := dbHandlerFromContext(ctx)
+	ks := upstream()
+
+	if dbh == nil && ks == nil {
+		return nil, fmt.Errorf("the server does not have database enabled so it cannot serve search")
+	}
+
 	if dbh != nil {
-		return dbh.FindGPGKeyByEmail(email, pageStart, pageEnd)
+		keys, err := dbh.FindGPGKeyByEmail(email, pageStart, pageEnd)
+		keys = publishedKeys(keys, func(key models.GPGKey) bool {
+			return containsFold(key.Emails, email)
+		})
+		if err != nil || len(keys) > 0 || ks == nil || pageStart > 0 {
+			return keys, err
+		}
 	}
-	return nil, fmt.Errorf("the server does not have database enabled so it cannot serve search")
+
+	key, err := ks.GetKeyByEmail(ctx, email)
+	if err != nil {
+		pksLog.Debug("Email %s not found in upstream keyservers: %s", email, err)
+		return []models.GPGKey{}, nil
+	}
+
+	gpgKey, err := models.AsciiArmored2GPGKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if dbh == nil {
+		return []models.GPGKey{gpgKey}, nil
+	}
+
+	tombstone, err := fetchTombstone(ctx, dbh, gpgKey.FullFingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	if tombstone != nil {
+		removed := removedEmails(tombstone)
+		if tombstone.IsWholeKey() || removed[strings.ToLower(email)] {
+			return []models.GPGKey{}, nil
+		}
+
+		if key, err = removeUserIds(key, removed); err != nil {
+			return nil, err
+		}
+
+		if gpgKey, err = models.AsciiArmored2GPGKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only trusted keyservers answer email lookups, so the email is verified
+	if _, err := pksMerge(ctx, key, false, []string{email}); err != nil {
+		pksLog.Error("Error storing upstream key %s in PKS: %s", gpgKey.GetShortFingerPrint(), err)
+	}
+
+	for i := range gpgKey.KeyUids {
+		gpgKey.KeyUids[i].Verified = strings.EqualFold(gpgKey.KeyUids[i].Email, email)
+	}
+
+	if p := publishedKeys([]models.GPGKey{gpgKey}, nil); len(p) > 0 {
+		return p, nil
+	}
+
+	return []models.GPGKey{}, nil
 }
 
 func PKSSearch(ctx context.Context, value string, pageStart, pageEnd int) ([]models.GPGKey, error) {
 	pksLog.DebugNote("PKSSearch(%s, %d, %d)", value, pageStart, pageEnd)
 	dbh := dbHandlerFromContext(ctx)
 	if dbh != nil {
-		return dbh.FindGPGKeyByValue(value, pageStart, pageEnd)
+		keys, err := dbh.FindGPGKeyByValue(value, pageStart, pageEnd)
+		return publishedKeys(keys, func(key models.GPGKey) bool {
+			return containsFold(key.Names, value) || containsFold(key.Emails, value) || containsFold([]string{key.FullFingerprint}, value)
+		}), err
 	}
 
 	return nil, fmt.Errorf("the server does not have database enabled so it cannot serve search")
 }
 
+// PKSSearchRanked searches the keys by name, email or fingerprint ranked by relevance.
+// With email verification enabled only the verified user ids are matched and returned
+func PKSSearchRanked(ctx context.Context, query models.GPGKeySearchQuery) (*models.GPGKeySearchResult, error) {
+	pksLog.DebugNote("PKSSearchRanked(%s, %s, %d)", query.Value, query.Cursor, query.Limit)
+	dbh := dbHandlerFromContext(ctx)
+	if dbh == nil {
+		return nil, fmt.Errorf("the server does not have database enabled so it cannot serve search")
+	}
+
+	query.VerifiedOnly = query.VerifiedOnly || config.PKSEmailVerification
+
+	result, err := dbh.SearchGPGKeys(query)
+	if err != nil || !config.PKSEmailVerification {
+		return result, err
+	}
+
+	hits := make([]models.GPGKeySearchHit, 0)
+
+	for _, v := range result.Hits {
+		if p := publishedKey(v.Key); p != nil {
+			hits = append(hits, models.GPGKeySearchHit{Key: *p, Score: v.Score})
+		}
+	}
+
+	result.Hits = hits
+
+	return result, nil
+}
+
+// PKSAdd adds a public key to the Public Key Store. Returns "OK" on success and "NOK" otherwise
 func PKSAdd(ctx context.Context, pubKey string) string {
+	if _, err := PKSMerge(ctx, pubKey); err != nil {
+		return "NOK"
+	}
+
+	return "OK"
+}
+
+// PKSMerge adds a public key to the Public Key Store or merges it with the stored key of the same fingerprint.
+// With email verification enabled the new user ids are only published after the owner of the email clicks the link sent to it.
+// Returns what changed in the store
+func PKSMerge(ctx context.Context, pubKey string) (*models.KeyMergeResult, error) {
+	return pksMerge(ctx, pubKey, true, nil)
+}
+
+// PKSMergeVerified works like PKSMerge but publishes all user ids of pubKey without email verification.
+// Should only be used with keys owned by this server
+func PKSMergeVerified(ctx context.Context, pubKey string) (*models.KeyMergeResult, error) {
+	key, err := models.AsciiArmored2GPGKey(pubKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pksMerge(ctx, pubKey, false, key.Emails)
+}
+
+// pksMerge adds or merges pubKey in the Public Key Store. The user ids with a email in verified are marked as verified.
+// If sendVerification is true the unverified emails of pubKey receive a verification link
+func pksMerge(ctx context.Context, pubKey string, sendVerification bool, verified []string) (*models.KeyMergeResult, error) {
 	requestID := tools.GetRequestIDFromContext(ctx)
 	log := pksLog.Tag(requestID)
-	log.DebugNote("PKSAdd(---)")
+	log.DebugNote("PKSMerge(---)")
 	dbh := dbHandlerFromContext(ctx)
 	if dbh != nil {
 		key, err := models.AsciiArmored2GPGKey(pubKey)
 		if err != nil {
-			log.Debug("PKSAdd Error: %s", err)
-			return "NOK"
+			log.Debug("PKSMerge Error: %s", err)
+			return nil, err
+		}
+
+		// Removed keys and user ids are not added again
+		tombstone, err := fetchTombstone(ctx, dbh, key.FullFingerprint)
+		if err != nil {
+			return nil, err
+		}
+
+		if tombstone != nil {
+			fingerPrint := key.GetShortFingerPrint()
+
+			if tombstone.IsWholeKey() {
+				return nil, fmt.Errorf("the key %s was removed from the key store", fingerPrint)
+			}
+
+			if pubKey, err = removeUserIds(pubKey, removedEmails(tombstone)); err != nil {
+				return nil, err
+			}
+
+			if key, err = models.AsciiArmored2GPGKey(pubKey); err != nil {
+				return nil, fmt.Errorf("the user ids of key %s were removed from the key store", fingerPrint)
+			}
+		}
+
+		uploadedEmails := key.Emails
+		if !sendVerification {
+			uploadedEmails = nil
+		}
+
+		newVerified := make(map[string]bool)
+		for _, v := range verified {
+			newVerified[strings.ToLower(v)] = true
 		}
 
 		existingKey, err := dbh.FetchGPGKeyByFingerprint(key.FullFingerprint)
 
 		if err != nil && !strings.EqualFold(err.Error(), "not found") {
-			log.Debug("PKSAdd Error: %s", err)
-			return "NOK"
+			log.Debug("PKSMerge Error: %s", err)
+			return nil, err
+		}
+
+		if existingKey == nil {
+			log.Info("Adding public key %s to PKS", key.GetShortFingerPrint())
+			markVerified(&key, newVerified)
+			_, _, err = dbh.AddGPGKey(key)
+
+			if err != nil {
+				log.Debug("PKSMerge Error: %s", err)
+				return nil, err
+			}
+
+			return &models.KeyMergeResult{
+				FingerPrint:      key.GetShortFingerPrint(),
+				Added:            true,
+				NewUserIds:       make([]string, 0),
+				NewSubkeys:       make([]string, 0),
+				VerificationSent: requestVerification(ctx, key, uploadedEmails),
+			}, nil
+		}
+
+		merged, result, err := MergeKeys(existingKey.AsciiArmoredPublicKey, pubKey, pksIssuer(dbh))
+		if err != nil {
+			log.Debug("PKSMerge Error: %s", err)
+			return nil, err
 		}
 
-		if existingKey != nil {
-			log.Info("Tried to add key %s to PKS but already exists.", key.GetShortFingerPrint())
-			return "OK"
+		var storedKey models.GPGKey
+
+		if result.Changed() {
+			mergedKey, err := models.AsciiArmored2GPGKey(merged)
+			if err != nil {
+				log.Debug("PKSMerge Error: %s", err)
+				return nil, err
+			}
+
+			mergedKey.ID = existingKey.ID
+			mergedKey.ParentKey = existingKey.ParentKey
+			mergedKey.AsciiArmoredPrivateKey = existingKey.AsciiArmoredPrivateKey
+
+			// Verification is per email, so it is kept for new user ids with a already verified email
+			markVerified(&mergedKey, verifiedEmails(existingKey.KeyUids))
+			markVerified(&mergedKey, newVerified)
+			storedKey = mergedKey
+
+			log.Info("Merging public key %s in PKS: %s", key.GetShortFingerPrint(), result.String())
+		} else if markVerified(existingKey, newVerified) {
+			storedKey = *existingKey
+			log.Info("Verifying emails of public key %s in PKS", key.GetShortFingerPrint())
+		} else {
+			log.Info("Tried to add key %s to PKS but already exists without changes.", key.GetShortFingerPrint())
+			result.VerificationSent = requestVerification(ctx, *existingKey, uploadedEmails)
+			return &result, nil
 		}
 
-		log.Info("Adding public key %s to PKS", key.GetShortFingerPrint())
-		_, _, err = dbh.AddGPGKey(key)
+		err = dbh.UpdateGPGKey(storedKey)
 
 		if err != nil {
-			log.Debug("PKSAdd Error: %s", err)
-			return "NOK"
+			log.Debug("PKSMerge Error: %s", err)
+			return nil, err
 		}
 
-		return "OK"
+		notifyPKSKeyUpdated(ctx, result.FingerPrint)
+		result.VerificationSent = requestVerification(ctx, storedKey, uploadedEmails)
+
+		return &result, nil
 	}
 
 	res, err := PutSKSKey(pubKey)
 
 	if err != nil {
-		log.Debug("PKSAdd Error: %s", err)
+		log.Debug("PKSMerge Error: %s", err)
+		return nil, err
 	}
 
-	if res {
-		return "OK"
+	if !res {
+		return nil, fmt.Errorf("the key was not accepted by the keyserver")
 	}
 
-	return "NOK"
+	fingerPrint, _ := tools.GetFingerPrintFromKey(pubKey)
+	notifyPKSKeyUpdated(ctx, fingerPrint)
+
+	return &models.KeyMergeResult{
+		FingerPrint: fingerPrint,
+		NewUserIds:  make([]string, 0),
+		NewSubkeys:  make([]string, 0),
+	}, nil
 }
@@ -4,12 +4,18 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/quan-to/chevron/internal/agent"
 	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/keyserver"
 	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/database/memory"
 	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
 	"github.com/quan-to/chevron/test"
 	"github.com/quan-to/slog"
 )
@@ -119,6 +125,61 @@ func TestPKSSearch(t *testing.T) {
 	}
 }
 
+func TestPKSSearchRanked(t *testing.T) {
+	config.PushVariables()
+	defer config.PopVariables()
+
+	_, err := PKSSearchRanked(context.Background(), models.GPGKeySearchQuery{Value: "john"})
+	if err == nil {
+		t.Fatalf("Search should fail without database")
+	}
+
+	dbh := memory.MakeMemoryDBDriver(nil)
+	ctx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, dbh)
+
+	john, err := openpgp.NewEntity("John", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	johnny, err := openpgp.NewEntity("Johnny Bravo", "", "johnny@bravo.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := PKSMergeVerified(ctx, armoredPublicKey(t, john)); err != nil {
+		t.Fatal(err)
+	}
+
+	config.PKSEmailVerification = true
+	SetVerificationMailer(&testMailer{})
+	defer SetVerificationMailer(nil)
+
+	if _, err := PKSMerge(ctx, armoredPublicKey(t, johnny)); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := PKSSearchRanked(ctx, models.GPGKeySearchQuery{Value: "john"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Total != 1 || len(result.Hits) != 1 || result.Hits[0].Key.Emails[0] != "john@huebr.com" {
+		t.Fatalf("Expected only the verified key, got %d hits", result.Total)
+	}
+
+	config.PKSEmailVerification = false
+
+	result, err = PKSSearchRanked(ctx, models.GPGKeySearchQuery{Value: "john"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Total != 2 || result.Hits[0].Key.Emails[0] != "john@huebr.com" {
+		t.Fatalf("Expected john@huebr.com to be ranked first of 2 hits, got %d hits", result.Total)
+	}
+}
+
 func TestPKSAdd(t *testing.T) {
 	config.PushVariables()
 	defer config.PopVariables()
@@ -175,3 +236,78 @@ func TestPKSAdd(t *testing.T) {
 	// Test External
 	// TODO: How to be a good test without stuffying SKS?
 }
+
+func TestPKSGetKeyUpstream(t *testing.T) {
+	dbh := memory.MakeMemoryDBDriver(nil)
+	ctx := context.WithValue(context.Background(), tools.CtxDatabaseHandler, dbh)
+
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fp := tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)
+	fullFP := fmt.Sprintf("%X", e.PrimaryKey.Fingerprint)
+	key := armoredPublicKey(t, e)
+
+	vks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/vks/v1/by-fingerprint/"+fullFP && r.URL.Path != "/vks/v1/by-keyid/"+fp && r.URL.Path != "/vks/v1/by-email/john@huebr.com" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(key))
+	}))
+	defer vks.Close()
+
+	source, err := keyserver.ParseSource("vks=" + vks.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetUpstreamKeyServer(keyserver.MakeFederation(nil, []*keyserver.Source{source}))
+	defer SetUpstreamKeyServer(nil)
+
+	// Key ids can collide so the keys found by them are not stored
+	p, err := PKSGetKey(ctx, fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fp2, _ := tools.GetFingerPrintFromKey(p); fp2 != fp {
+		t.Errorf("Expected key %s from upstream got %s", fp, fp2)
+	}
+
+	if stored, _ := dbh.FetchGPGKeyByFingerprint(fp); stored != nil {
+		t.Errorf("Expected upstream key %s found by key id to not be stored in PKS", fp)
+	}
+
+	p, err = PKSGetKey(ctx, fullFP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fp2, _ := tools.GetFingerPrintFromKey(p); fp2 != fp {
+		t.Errorf("Expected key %s from upstream got %s", fp, fp2)
+	}
+
+	if stored, _ := dbh.FetchGPGKeyByFingerprint(fp); stored == nil {
+		t.Errorf("Expected upstream key %s to be stored in PKS", fp)
+	}
+
+	if _, err := PKSGetKey(ctx, strings.Repeat("0", 40)); err == nil {
+		t.Errorf("Expected error for key not found in any keyserver")
+	}
+
+	// Email search falls back to the upstream keyservers
+	dbh = memory.MakeMemoryDBDriver(nil)
+	ctx = context.WithValue(context.Background(), tools.CtxDatabaseHandler, dbh)
+
+	keys, err := PKSSearchByEmail(ctx, "john@huebr.com", 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 1 || keys[0].GetShortFingerPrint() != fp {
+		t.Errorf("Expected key %s to be found by email in upstream", fp)
+	}
+}
@@ -1,21 +1,21 @@
-// +build !js,!wasm
-
 package keymagic
 
 import (
+	"bytes"
 	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
 	"io/ioutil"
-	"path"
 	"strings"
 	"sync"
 
 	config "github.com/quan-to/chevron/internal/config"
-	"github.com/quan-to/chevron/internal/keybackend"
 	"github.com/quan-to/chevron/internal/tools"
-	"github.com/quan-to/chevron/internal/vaultManager"
 	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/openpgp"
 
 	"github.com/quan-to/slog"
 )
@@ -27,7 +27,10 @@ type secretsManager struct {
 	masterKeyFingerPrint string
 	amIUseless           bool
 	log                  slog.Instance
+	passwordCallbacks    []func(ctx context.Context, fingerPrint, encryptedPassword string)
 	dbh                  DatabaseHandler
+	// secretSeed is the key of the secrets derived from the master key
+	secretSeed []byte
 }
 
 // MakeSecretsManager creates an instance of the backend secrets manager
@@ -40,13 +43,7 @@ func MakeSecretsManager(log slog.Instance, dbHandler DatabaseHandler) interfaces
 
 	ctx := context.Background()
 
-	var kb interfaces.StorageBackend
-
-	if config.VaultStorage {
-		kb = vaultManager.MakeVaultManager(log, "__master__")
-	} else {
-		kb = keybackend.MakeSaveToDiskBackend(log, path.Dir(config.MasterGPGKeyPath), "__master__")
-	}
+	kb := makeMasterKeyBackend(log)
 
 	var sm = &secretsManager{
 		amIUseless:         false,
@@ -120,6 +117,12 @@ func MakeSecretsManager(log slog.Instance, dbHandler DatabaseHandler) interfaces
 		sm.log.Fatal("Error unlocking master key: %s", err)
 	}
 
+	sm.secretSeed, err = masterKeySecretSeed(string(masterKeyBytes), strings.Trim(string(masterKeyPassBytes), "\n\r"))
+
+	if err != nil {
+		sm.log.Fatal("Error deriving master key secrets: %s", err)
+	}
+
 	err = sm.gpg.SaveKey(masterKeyFp, string(masterKeyBytes), string(masterKeyPassBytes))
 
 	if err != nil {
@@ -154,6 +157,12 @@ func (sm *secretsManager) PutKeyPassword(ctx context.Context, fingerprint, passw
 	}
 
 	sm.encryptedPasswords[fingerprint] = encPass
+
+	// Do not hold the caller (and the lock) while the callbacks talk to the other nodes
+	cbCtx := context.WithValue(context.Background(), tools.CtxRequestID, tools.GetRequestIDFromContext(ctx))
+	for _, cb := range sm.passwordCallbacks {
+		go cb(cbCtx, fingerprint, encPass)
+	}
 }
 
 // PutEncryptedPassword stores in memory a master key encrypted password for the specified fingerprint
@@ -232,3 +241,86 @@ func (sm *secretsManager) GetMasterKeyFingerPrint(ctx context.Context) string {
 	log.DebugNote("GetMasterKeyFingerPrint()")
 	return sm.masterKeyFingerPrint
 }
+
+// SignWithMasterKey creates an armored detached signature of data using the master key
+func (sm *secretsManager) SignWithMasterKey(ctx context.Context, data []byte) (string, error) {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := pksLog.Tag(requestID)
+	log.DebugNote("SignWithMasterKey(---)")
+	if sm.amIUseless {
+		return "", fmt.Errorf("master key not loaded")
+	}
+
+	return sm.gpg.SignData(ctx, sm.masterKeyFingerPrint, data, crypto.SHA512)
+}
+
+// VerifyMasterKeySignature checks if signature is a valid detached signature of data made by the master key
+func (sm *secretsManager) VerifyMasterKeySignature(ctx context.Context, data []byte, signature string) error {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := pksLog.Tag(requestID)
+	log.DebugNote("VerifyMasterKeySignature(---, %s)", tools.TruncateFieldForDisplay(signature))
+	if sm.amIUseless {
+		return fmt.Errorf("master key not loaded")
+	}
+
+	ent := sm.gpg.GetPublicKeyEntity(ctx, sm.masterKeyFingerPrint)
+	if ent == nil {
+		return fmt.Errorf("master key %s not found", sm.masterKeyFingerPrint)
+	}
+
+	// Only the master key is in the keyring, so any valid signature was made by it
+	_, err := openpgp.CheckArmoredDetachedSignature(openpgp.EntityList{ent}, bytes.NewReader(data), strings.NewReader(signature))
+
+	return err
+}
+
+// DeriveMasterKeySecret returns a secret for purpose derived from the master key. Every node with the same master key derives the same secret
+func (sm *secretsManager) DeriveMasterKeySecret(ctx context.Context, purpose string) ([]byte, error) {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := pksLog.Tag(requestID)
+	log.DebugNote("DeriveMasterKeySecret(%s)", purpose)
+	if sm.amIUseless {
+		return nil, fmt.Errorf("master key not loaded")
+	}
+
+	h := hmac.New(sha256.New, sm.secretSeed)
+	_, _ = h.Write([]byte(purpose))
+
+	return h.Sum(nil), nil
+}
+
+// OnPasswordStored registers a callback that is called with the master key encrypted password every time PutKeyPassword stores a password
+func (sm *secretsManager) OnPasswordStored(cb func(ctx context.Context, fingerPrint, encryptedPassword string)) {
+	sm.Lock()
+	defer sm.Unlock()
+
+	sm.passwordCallbacks = append(sm.passwordCallbacks, cb)
+}
+
+// masterKeySecretSeed returns the hash of the master key private material, used as key of the secrets derived from it
+func masterKeySecretSeed(armoredKey, password string) ([]byte, error) {
+	keys, err := tools.ReadKey(armoredKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) == 0 || keys[0].PrivateKey == nil {
+		return nil, fmt.Errorf("the master key does not have a private key")
+	}
+
+	pk := keys[0].PrivateKey
+	if pk.Encrypted {
+		if err := pk.Decrypt([]byte(password)); err != nil {
+			return nil, err
+		}
+	}
+
+	h := sha256.New()
+	_, _ = h.Write([]byte("chevron-master-key-secret"))
+
+	if err := pk.SerializePrivateMPI(h); err != nil {
+		return nil, err
+	}
+
+	return h.Sum(nil), nil
+}
@@ -1,10 +1,13 @@
 package keymagic
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
+	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/quan-to/chevron/internal/config"
 	"github.com/quan-to/chevron/test"
@@ -90,3 +93,58 @@ func TestUnlockLocalKeys(t *testing.T) {
 
 	sm.UnlockLocalKeys(ctx, pgpMan)
 }
+
+func TestOnPasswordStored(t *testing.T) {
+	ctx := context.Background()
+	stored := make(chan string, 1)
+
+	sm.OnPasswordStored(func(ctx context.Context, fingerPrint, encryptedPassword string) {
+		if encryptedPassword != sm.GetPasswords(ctx)[fingerPrint] {
+			t.Errorf("Expected callback to receive the stored encrypted password")
+		}
+		stored <- fingerPrint
+	})
+
+	sm.PutKeyPassword(ctx, test.TestKeyFingerprint, test.TestKeyFingerprint)
+
+	select {
+	case fp := <-stored:
+		if fp != test.TestKeyFingerprint {
+			t.Errorf("Expected fingerprint %s got %s", test.TestKeyFingerprint, fp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Callback was not called")
+	}
+}
+
+func TestDeriveMasterKeySecret(t *testing.T) {
+	ctx := context.Background()
+
+	secret, err := sm.DeriveMasterKeySecret(ctx, "test")
+	if err != nil {
+		t.Fatalf("Error deriving secret: %s", err)
+	}
+
+	// Another node with the same master key derives the same secret
+	masterKey, err := ioutil.ReadFile(config.MasterGPGKeyPath)
+	if err != nil {
+		t.Fatalf("Error reading master key: %s", err)
+	}
+
+	seed, err := masterKeySecretSeed(string(masterKey), test.TestKeyPassword)
+	if err != nil {
+		t.Fatalf("Error reading master key: %s", err)
+	}
+
+	other := &secretsManager{secretSeed: seed}
+
+	again, _ := other.DeriveMasterKeySecret(ctx, "test")
+	if len(secret) != 32 || !bytes.Equal(secret, again) {
+		t.Errorf("Expected the same 32 bytes secret for the same purpose")
+	}
+
+	different, _ := sm.DeriveMasterKeySecret(ctx, "other")
+	if bytes.Equal(secret, different) {
+		t.Errorf("Expected different secrets for different purposes")
+	}
+}
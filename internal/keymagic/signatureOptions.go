@@ -0,0 +1,87 @@
+package keymagic
+
+import (
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+// signatureNotation returns the notation to be added to a signature. Notation names without a @ are reserved to the IETF (RFC 4880 5.2.3.16)
+func signatureNotation(notation models.SignatureNotation) (packet.Notation, error) {
+	at := strings.Index(notation.Name, "@")
+	if at <= 0 || at == len(notation.Name)-1 {
+		return packet.Notation{}, fmt.Errorf("notation name %q should be in the name@domain format", notation.Name)
+	}
+
+	value := []byte(notation.Value)
+	if !notation.HumanReadable {
+		var err error
+		value, err = base64.StdEncoding.DecodeString(notation.Value)
+		if err != nil {
+			return packet.Notation{}, fmt.Errorf("notation %s value is not base64 encoded: %s", notation.Name, err)
+		}
+	}
+
+	if len(notation.Name) > math.MaxUint16 || len(value) > math.MaxUint16 {
+		return packet.Notation{}, fmt.Errorf("notation %s is too long", notation.Name)
+	}
+
+	return packet.Notation{
+		Name:          notation.Name,
+		Value:         value,
+		HumanReadable: notation.HumanReadable,
+		IsCritical:    notation.Critical,
+	}, nil
+}
+
+// signerUserId returns the user id of e that matches the whole user id or the email
+func signerUserId(e *openpgp.Entity, userId string) (string, error) {
+	for name, identity := range e.Identities {
+		if name == userId || (identity.UserId != nil && identity.UserId.Email != "" && strings.EqualFold(identity.UserId.Email, userId)) {
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("the key does not have the user id %s", userId)
+}
+
+// signatureConfig returns the config to sign data with e using the signature options
+func signatureConfig(e *openpgp.Entity, hashAlgorithm crypto.Hash, options models.SignatureOptions) (*packet.Config, error) {
+	c := &packet.Config{
+		DefaultHash:     hashAlgorithm,
+		SigLifetimeSecs: options.ExpirationSeconds,
+	}
+
+	for _, v := range options.Notations {
+		notation, err := signatureNotation(v)
+		if err != nil {
+			return nil, err
+		}
+		c.SigNotations = append(c.SigNotations, notation)
+	}
+
+	if options.SignerUserId != "" {
+		userId, err := signerUserId(e, options.SignerUserId)
+		if err != nil {
+			return nil, err
+		}
+		c.SignerUserId = userId
+	}
+
+	if options.PolicyURI != "" {
+		u, err := url.Parse(options.PolicyURI)
+		if err != nil || u.Scheme == "" {
+			return nil, fmt.Errorf("invalid policy URI %q", options.PolicyURI)
+		}
+		c.SigPolicyURI = options.PolicyURI
+	}
+
+	return c, nil
+}
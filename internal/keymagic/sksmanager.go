@@ -1,32 +1,74 @@
 package keymagic
 
 import (
-	"fmt"
-	"io/ioutil"
+	"context"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 
 	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/keyserver"
+	"github.com/quan-to/chevron/pkg/interfaces"
 )
 
-func GetSKSKey(fingerPrint string) (string, error) {
-	response, err := http.Get(fmt.Sprintf("%s/pks/lookup?op=get&options=mr&search=0x%s", config.SKSServer, fingerPrint))
+var upstreamLock sync.Mutex
+var upstreamKeyServer interfaces.KeyServer
+var upstreamFromConfig bool
+var sksKeyServer interfaces.KeyServer
+var sksKeyServerURL string
 
-	if err != nil {
-		return "", err
+// SetUpstreamKeyServer replaces the upstream keyservers configured in KEYSERVERS. If ks is nil the configuration is used again
+func SetUpstreamKeyServer(ks interfaces.KeyServer) {
+	upstreamLock.Lock()
+	defer upstreamLock.Unlock()
+
+	upstreamKeyServer = ks
+	upstreamFromConfig = false
+}
+
+// upstream returns the keyservers used to find keys that are not in the database. Returns nil if there is none
+func upstream() interfaces.KeyServer {
+	upstreamLock.Lock()
+	defer upstreamLock.Unlock()
+
+	if upstreamKeyServer == nil && !upstreamFromConfig {
+		if f := keyserver.MakeFederationFromConfig(pksLog); f != nil {
+			upstreamKeyServer = f
+		}
+		upstreamFromConfig = true
 	}
 
-	contents, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return "", err
+	return upstreamKeyServer
+}
+
+// sksUpstream returns the keyservers used when there is no database. Defaults to the SKS_SERVER
+func sksUpstream() interfaces.KeyServer {
+	if ks := upstream(); ks != nil {
+		return ks
 	}
-	_ = response.Body.Close()
 
-	return string(contents), nil
+	upstreamLock.Lock()
+	defer upstreamLock.Unlock()
+
+	if sksKeyServer == nil || sksKeyServerURL != config.SKSServer {
+		sksKeyServerURL = config.SKSServer
+		sksKeyServer = keyserver.MakeFederation(pksLog, []*keyserver.Source{
+			keyserver.MakeSource(keyserver.MakeHKPServer(strings.TrimRight(config.SKSServer, "/"), keyserver.DefaultTimeout), false, keyserver.DefaultMaxFailures, keyserver.DefaultCooldown),
+		})
+	}
+
+	return sksKeyServer
+}
+
+// GetSKSKey fetches the key with the specified fingerprint from the upstream keyservers
+func GetSKSKey(ctx context.Context, fingerPrint string) (string, error) {
+	return sksUpstream().GetKeyByFingerPrint(ctx, fingerPrint)
 }
 
 func PutSKSKey(publicKey string) (bool, error) {
-	response, err := http.PostForm(config.SKSServer, url.Values{"keytext": {publicKey}})
+	client := &http.Client{Timeout: keyserver.DefaultTimeout}
+	response, err := client.PostForm(config.SKSServer, url.Values{"keytext": {publicKey}})
 
 	if err != nil {
 		return false, err
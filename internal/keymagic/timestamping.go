@@ -0,0 +1,208 @@
+package keymagic
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/armor"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+	"github.com/quan-to/chevron/pkg/timestamp"
+)
+
+// timestampNotationName is the name of the unhashed signature notation with the RFC 3161 timestamp token of the signature value
+const timestampNotationName = "rfc3161-timestamp@quan.to"
+
+// timestampHash is the hash of the signature value sent to the timestamp authority
+const timestampHash = crypto.SHA512
+
+// timestampAuthority returns the built-in timestamp authority backed by the TimestampAuthorityKey
+func (pm *pgpManager) timestampAuthority(ctx context.Context) (*timestamp.Authority, error) {
+	if config.TimestampAuthorityKey == "" {
+		return nil, fmt.Errorf("the built-in timestamp authority is not enabled")
+	}
+
+	ent, err := pm.unlockedEntity(ctx, config.TimestampAuthorityKey)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := ent.PrivateKey.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("the key %s cannot be used by the timestamp authority", config.TimestampAuthorityKey)
+	}
+
+	fp := tools.IssuerKeyIdToFP16(ent.PrimaryKey.KeyId)
+
+	certificate, err := timestamp.MakeAuthorityCertificate(signer, "Chevron TSA "+fp, new(big.Int).SetUint64(ent.PrimaryKey.KeyId), ent.PrimaryKey.CreationTime)
+	if err != nil {
+		return nil, err
+	}
+
+	return timestamp.MakeAuthority(signer, certificate), nil
+}
+
+// TimestampResponse returns the RFC 3161 response of the built-in timestamp authority to a DER encoded timestamp request
+func (pm *pgpManager) TimestampResponse(ctx context.Context, request []byte) ([]byte, error) {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := pm.log.Tag(requestID)
+	log.DebugNote("TimestampResponse(---)")
+
+	authority, err := pm.timestampAuthority(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return authority.Respond(request)
+}
+
+// timestampSignature adds to sig a timestamp of its signature value from the configured timestamp authority, or the built-in one
+func (pm *pgpManager) timestampSignature(ctx context.Context, sig *packet.Signature) error {
+	h := timestampHash.New()
+	_, _ = h.Write(sig.SignatureValue())
+	digest := h.Sum(nil)
+
+	var token []byte
+
+	if config.TimestampAuthorityURL != "" {
+		t, err := timestamp.Request(config.TimestampAuthorityURL, timestampHash, digest)
+		if err != nil {
+			return err
+		}
+		token = t.Raw
+	} else {
+		authority, err := pm.timestampAuthority(ctx)
+		if err != nil {
+			return fmt.Errorf("no timestamp authority available: %s", err)
+		}
+
+		if token, err = authority.Timestamp(timestampHash, digest, nil); err != nil {
+			return err
+		}
+	}
+
+	sig.AddUnhashedNotation(packet.Notation{Name: timestampNotationName, Value: token})
+
+	return nil
+}
+
+// timestampedDetachSign writes the armored detached signature of the message with a timestamp of the signature
+func (pm *pgpManager) timestampedDetachSign(ctx context.Context, w io.Writer, signer *openpgp.Entity, message io.Reader, c *packet.Config) error {
+	var b bytes.Buffer
+
+	if err := openpgp.DetachSign(&b, signer, message, c); err != nil {
+		return err
+	}
+
+	p, err := packet.Read(&b)
+	if err != nil {
+		return err
+	}
+
+	sig, ok := p.(*packet.Signature)
+	if !ok {
+		return fmt.Errorf("unexpected signature packet %T", p)
+	}
+
+	if err := pm.timestampSignature(ctx, sig); err != nil {
+		return err
+	}
+
+	out, err := armor.Encode(w, openpgp.SignatureType, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := sig.Serialize(out); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// verifyTimestamp returns the timestamp token of the signature if it is valid and trusted, and the name of its authority.
+// Returns nil without error if the signature does not have a timestamp
+func (pm *pgpManager) verifyTimestamp(ctx context.Context, sig *packet.Signature) (*timestamp.Token, string, error) {
+	var der []byte
+	for _, v := range sig.UnhashedNotations {
+		if v.Name == timestampNotationName {
+			der = v.Value
+			break
+		}
+	}
+
+	if der == nil {
+		return nil, "", nil
+	}
+
+	token, err := timestamp.ParseToken(der)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := token.VerifyData(sig.SignatureValue()); err != nil {
+		return nil, "", err
+	}
+
+	// Tokens of the built-in timestamp authority are trusted by its key
+	if config.TimestampAuthorityKey != "" {
+		if e := pm.GetPublicKeyEntity(ctx, config.TimestampAuthorityKey); e != nil && samePublicKey(e.PrimaryKey.PublicKey, token.Signer.PublicKey) {
+			return token, token.Signer.Subject.CommonName, nil
+		}
+	}
+
+	roots, err := timestampRoots()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := token.VerifyCertificate(roots); err != nil {
+		return nil, "", err
+	}
+
+	return token, token.Signer.Subject.CommonName, nil
+}
+
+// timestampRoots returns the root certificates of the trusted timestamp authorities. The system roots are used if
+// TimestampTrustedCerts is not set
+func timestampRoots() (*x509.CertPool, error) {
+	if config.TimestampTrustedCerts == "" {
+		return x509.SystemCertPool()
+	}
+
+	data, err := ioutil.ReadFile(config.TimestampTrustedCerts)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the trusted timestamp authority certificates: %s", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", config.TimestampTrustedCerts)
+	}
+
+	return roots, nil
+}
+
+// samePublicKey returns if a and b are the same RSA or ECDSA public key
+func samePublicKey(a, b interface{}) bool {
+	switch ka := a.(type) {
+	case *rsa.PublicKey:
+		kb, ok := b.(*rsa.PublicKey)
+		return ok && ka.N.Cmp(kb.N) == 0 && ka.E == kb.E
+	case *ecdsa.PublicKey:
+		kb, ok := b.(*ecdsa.PublicKey)
+		return ok && ka.Curve == kb.Curve && ka.X.Cmp(kb.X) == 0 && ka.Y.Cmp(kb.Y) == 0
+	}
+
+	return false
+}
@@ -0,0 +1,208 @@
+package keymagic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+// trustMaxDepth is the maximum trust signature level followed from a CA key
+const trustMaxDepth = 8
+
+// trustMaxLookups is the maximum number of certifier keys fetched to evaluate the trust of a single key
+const trustMaxLookups = 32
+
+// trustFullAmount is the minimum trust amount of a trust signature to delegate trust. GnuPG uses 60 for partial and 120 for full trust
+const trustFullAmount = 120
+
+// trustEvaluator decides if a key is trusted from the explicitly trusted keys and the certifications of the CA keys
+type trustEvaluator struct {
+	trustedKeys []string
+	caKeys      []string
+	getKey      func(fingerPrint string) *openpgp.Entity
+	now         time.Time
+	keys        map[string]*openpgp.Entity
+	lookups     int
+}
+
+// certification is a valid certification of a key made by issuer
+type certification struct {
+	issuer *openpgp.Entity
+	sig    *packet.Signature
+}
+
+func makeTrustEvaluator(trustedKeys, caKeys []string, getKey func(fingerPrint string) *openpgp.Entity) *trustEvaluator {
+	return &trustEvaluator{
+		trustedKeys: trustedKeys,
+		caKeys:      caKeys,
+		getKey:      getKey,
+		now:         time.Now(),
+		keys:        make(map[string]*openpgp.Entity),
+	}
+}
+
+// matchFingerPrint returns the short fingerprint of the key if it is in fingerPrints
+func matchFingerPrint(e *openpgp.Entity, fingerPrints []string) string {
+	fp := strings.ToUpper(fmt.Sprintf("%x", e.PrimaryKey.Fingerprint))
+
+	for _, v := range fingerPrints {
+		if strings.HasSuffix(fp, v) {
+			return tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)
+		}
+	}
+
+	return ""
+}
+
+// isRevoked returns if the key has a valid revocation
+func isRevoked(e *openpgp.Entity) bool {
+	for _, v := range e.Revocations {
+		if e.PrimaryKey.VerifyRevocationSignature(v) == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isExpired returns if the signature expired at now
+func isExpired(sig *packet.Signature, now time.Time) bool {
+	if sig.SigLifetimeSecs == nil || *sig.SigLifetimeSecs == 0 {
+		return false
+	}
+
+	return now.After(sig.CreationTime.Add(time.Duration(*sig.SigLifetimeSecs) * time.Second))
+}
+
+// fetchKey returns the key with the issuer key id, limited to trustMaxLookups fetches
+func (te *trustEvaluator) fetchKey(keyId uint64) *openpgp.Entity {
+	fp := tools.IssuerKeyIdToFP16(keyId)
+
+	if e, ok := te.keys[fp]; ok {
+		return e
+	}
+
+	if te.lookups >= trustMaxLookups {
+		return nil
+	}
+
+	te.lookups++
+	e := te.getKey(fp)
+	if e != nil && (e.PrimaryKey.KeyId != keyId || isRevoked(e)) {
+		e = nil
+	}
+	te.keys[fp] = e
+
+	return e
+}
+
+// certifications returns the valid certifications made by other keys on the user ids of e
+func (te *trustEvaluator) certifications(e *openpgp.Entity) []certification {
+	certs := make([]certification, 0)
+
+	for name, identity := range e.Identities {
+		revoked := make(map[uint64]time.Time)
+		for _, sig := range identity.Signatures {
+			if sig.SigType == sigTypeCertificationRevocation && sig.IssuerKeyId != nil {
+				if issuer := te.fetchKey(*sig.IssuerKeyId); issuer != nil && issuer.PrimaryKey.VerifyUserIdSignature(name, e.PrimaryKey, sig) == nil {
+					revoked[*sig.IssuerKeyId] = sig.CreationTime
+				}
+			}
+		}
+
+		for _, sig := range identity.Signatures {
+			if sig.SigType < packet.SigTypeGenericCert || sig.SigType > packet.SigTypePositiveCert {
+				continue
+			}
+
+			if sig.IssuerKeyId == nil || *sig.IssuerKeyId == e.PrimaryKey.KeyId || isExpired(sig, te.now) {
+				continue
+			}
+
+			if revokedAt, ok := revoked[*sig.IssuerKeyId]; ok && !revokedAt.Before(sig.CreationTime) {
+				continue
+			}
+
+			issuer := te.fetchKey(*sig.IssuerKeyId)
+			if issuer == nil || issuer.PrimaryKey.VerifyUserIdSignature(name, e.PrimaryKey, sig) != nil {
+				continue
+			}
+
+			certs = append(certs, certification{issuer: issuer, sig: sig})
+		}
+	}
+
+	return certs
+}
+
+// introducerPath returns the path from a CA key to e if the certifications of e are trusted at the level.
+// Level 0 certifications make the certified key trusted, and a key certified by a trust signature of level n
+// by a introducer of level n is a introducer of level n - 1
+func (te *trustEvaluator) introducerPath(e *openpgp.Entity, level int, visited map[uint64]bool) []string {
+	if fp := matchFingerPrint(e, te.caKeys); fp != "" {
+		return []string{fp}
+	}
+
+	if level >= trustMaxDepth || visited[e.PrimaryKey.KeyId] {
+		return nil
+	}
+
+	visited[e.PrimaryKey.KeyId] = true
+	defer delete(visited, e.PrimaryKey.KeyId)
+
+	for _, cert := range te.certifications(e) {
+		if int(cert.sig.TrustLevel) <= level || cert.sig.TrustAmount < trustFullAmount {
+			continue
+		}
+
+		if path := te.introducerPath(cert.issuer, level+1, visited); path != nil {
+			return append(path, tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId))
+		}
+	}
+
+	return nil
+}
+
+// keyTrust returns the trust of the key e
+func (te *trustEvaluator) keyTrust(e *openpgp.Entity) models.KeyTrust {
+	trust := models.KeyTrust{
+		FingerPrint: tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId),
+	}
+
+	if isRevoked(e) {
+		return trust
+	}
+
+	if fp := matchFingerPrint(e, te.trustedKeys); fp != "" {
+		trust.Trusted = true
+		trust.Via = fmt.Sprintf("trusted key %s", fp)
+		return trust
+	}
+
+	if fp := matchFingerPrint(e, te.caKeys); fp != "" {
+		trust.Trusted = true
+		trust.Via = fmt.Sprintf("CA key %s", fp)
+		return trust
+	}
+
+	if len(te.caKeys) == 0 {
+		return trust
+	}
+
+	visited := map[uint64]bool{e.PrimaryKey.KeyId: true}
+
+	for _, cert := range te.certifications(e) {
+		if path := te.introducerPath(cert.issuer, 0, visited); path != nil {
+			trust.Trusted = true
+			trust.Via = fmt.Sprintf("CA %s", strings.Join(path, " > "))
+			return trust
+		}
+	}
+
+	return trust
+}
@@ -0,0 +1,106 @@
+package keymagic
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+// certifyKey adds a certification made by signer to all user ids of e
+func certifyKey(t *testing.T, e, signer *openpgp.Entity, trustLevel, trustAmount uint8) {
+	t.Helper()
+
+	for name, identity := range e.Identities {
+		sig := &packet.Signature{
+			CreationTime: mergeTestConfig.Now(),
+			SigType:      packet.SigTypeGenericCert,
+			PubKeyAlgo:   signer.PrivateKey.PubKeyAlgo,
+			Hash:         mergeTestConfig.Hash(),
+			IssuerKeyId:  &signer.PrimaryKey.KeyId,
+			TrustLevel:   trustLevel,
+			TrustAmount:  trustAmount,
+		}
+
+		if err := sig.SignUserId(name, e.PrimaryKey, signer.PrivateKey, mergeTestConfig); err != nil {
+			t.Fatal(err)
+		}
+
+		identity.Signatures = append(identity.Signatures, sig)
+	}
+}
+
+// publicEntity returns e as read from its armored public key
+func publicEntity(t *testing.T, e *openpgp.Entity) *openpgp.Entity {
+	t.Helper()
+
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armoredPublicKey(t, e)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return entities[0]
+}
+
+func TestTrustEvaluator(t *testing.T) {
+	entities := make(map[string]*openpgp.Entity)
+	newEntity := func(name string) *openpgp.Entity {
+		e, err := openpgp.NewEntity(name, "", strings.ToLower(name)+"@huebr.com", mergeTestConfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+
+	ca := newEntity("CA")
+	intermediate := newEntity("Intermediate")
+	leaf := newEntity("Leaf")
+	other := newEntity("Other")
+	stranger := newEntity("Stranger")
+
+	certifyKey(t, intermediate, ca, 1, trustFullAmount)
+	certifyKey(t, leaf, intermediate, 0, 0)
+	certifyKey(t, other, ca, 0, 0)
+	certifyKey(t, stranger, leaf, 0, 0)
+
+	for _, e := range []*openpgp.Entity{ca, intermediate, leaf, other, stranger} {
+		entities[tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)] = publicEntity(t, e)
+	}
+
+	getKey := func(fingerPrint string) *openpgp.Entity {
+		return entities[fingerPrint]
+	}
+
+	fp := func(e *openpgp.Entity) string {
+		return tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId)
+	}
+
+	caFP := strings.ToUpper(fmt.Sprintf("%x", ca.PrimaryKey.Fingerprint))
+
+	tests := []struct {
+		name        string
+		trustedKeys []string
+		caKeys      []string
+		key         *openpgp.Entity
+		via         string
+	}{
+		{"no trust configured", nil, nil, leaf, ""},
+		{"trusted key", []string{fp(stranger)}, nil, stranger, "trusted key " + fp(stranger)},
+		{"CA key", nil, []string{caFP}, ca, "CA key " + fp(ca)},
+		{"certified by CA", nil, []string{caFP}, other, "CA " + fp(ca)},
+		{"trust signature delegation", nil, []string{caFP}, leaf, "CA " + fp(ca) + " > " + fp(intermediate)},
+		{"certified without trust signature", nil, []string{caFP}, stranger, ""},
+		{"not certified", nil, []string{fp(other)}, intermediate, ""},
+	}
+
+	for _, tt := range tests {
+		trust := makeTrustEvaluator(tt.trustedKeys, tt.caKeys, getKey).keyTrust(entities[fp(tt.key)])
+
+		if trust.Trusted != (tt.via != "") || trust.Via != tt.via {
+			t.Errorf("%s: expected trust via %q, got %s", tt.name, tt.via, trust.String())
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package keymagic
+
+import (
+	"crypto"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+// Revocation reasons of RFC 4880 5.2.3.23
+const (
+	revocationNoReason    = 0
+	revocationSuperseded  = 1
+	revocationCompromised = 2
+	revocationRetired     = 3
+)
+
+// verifyConfig returns the openpgp configuration used to verify signatures
+func verifyConfig() *packet.Config {
+	knownNotations := make(map[string]bool, len(config.SignatureKnownNotations))
+	for _, name := range config.SignatureKnownNotations {
+		knownNotations[name] = true
+	}
+
+	return &packet.Config{KnownNotations: knownNotations}
+}
+
+var hashNames = map[crypto.Hash]string{
+	crypto.MD5:       "MD5",
+	crypto.SHA1:      "SHA1",
+	crypto.RIPEMD160: "RIPEMD160",
+	crypto.SHA224:    "SHA224",
+	crypto.SHA256:    "SHA256",
+	crypto.SHA384:    "SHA384",
+	crypto.SHA512:    "SHA512",
+}
+
+var revocationReasons = map[uint8]string{
+	revocationNoReason:    "no reason",
+	revocationSuperseded:  "key superseded",
+	revocationCompromised: "key compromised",
+	revocationRetired:     "key retired",
+}
+
+// hashName returns the name of the hash algorithm in the Quanto signature format
+func hashName(h crypto.Hash) string {
+	if name, ok := hashNames[h]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("HASH%d", h)
+}
+
+// signatureTypeName returns the name of a data signature type
+func signatureTypeName(sigType packet.SignatureType) string {
+	switch sigType {
+	case packet.SigTypeBinary:
+		return "binary"
+	case packet.SigTypeText:
+		return "text"
+	}
+
+	return fmt.Sprintf("0x%02x", uint8(sigType))
+}
+
+// revocationReason returns a human readable revocation reason
+func revocationReason(sig *packet.Signature) string {
+	if sig.RevocationReason == nil {
+		return revocationReasons[revocationNoReason]
+	}
+
+	reason, ok := revocationReasons[*sig.RevocationReason]
+	if !ok {
+		reason = fmt.Sprintf("reason %d", *sig.RevocationReason)
+	}
+
+	if sig.RevocationReasonText != "" {
+		reason = fmt.Sprintf("%s: %s", reason, sig.RevocationReasonText)
+	}
+
+	return reason
+}
+
+// keyRevocation returns the earliest valid revocation of the key or of its primary key. Returns nil if it is not revoked
+func keyRevocation(key *openpgp.Key) *packet.Signature {
+	var revocation *packet.Signature
+
+	for _, v := range key.Entity.Revocations {
+		if key.Entity.PrimaryKey.VerifyRevocationSignature(v) == nil && (revocation == nil || v.CreationTime.Before(revocation.CreationTime)) {
+			revocation = v
+		}
+	}
+
+	// Subkey revocations are verified when the key is read
+	sig := key.SelfSignature
+	if key.PublicKey != key.Entity.PrimaryKey && sig != nil && sig.SigType == packet.SigTypeSubkeyRevocation &&
+		(revocation == nil || sig.CreationTime.Before(revocation.CreationTime)) {
+		revocation = sig
+	}
+
+	return revocation
+}
+
+// signatureResult returns the details of a valid signature made by key. The result is not valid if the signature expired or
+// the key was revoked when the signature was made. Signatures of compromised keys, or revoked without a reason, are only valid
+// with a trusted timestamp before the revocation, since the signature creation time is chosen by the signer
+func signatureResult(key *openpgp.Key, sig packet.Packet, timestamp *time.Time) *models.GPGVerifySignatureResult {
+	result := &models.GPGVerifySignatureResult{
+		Valid:                 true,
+		FingerPrint:           tools.IssuerKeyIdToFP16(key.Entity.PrimaryKey.KeyId),
+		PrimaryKeyFingerPrint: strings.ToUpper(fmt.Sprintf("%x", key.Entity.PrimaryKey.Fingerprint)),
+		Notations:             make([]models.SignatureNotation, 0),
+		Timestamp:             timestamp,
+	}
+
+	if key.PublicKey != key.Entity.PrimaryKey {
+		result.SubKeyFingerPrint = strings.ToUpper(fmt.Sprintf("%x", key.PublicKey.Fingerprint))
+	}
+
+	switch s := sig.(type) {
+	case *packet.Signature:
+		result.CreationTime = s.CreationTime
+		result.HashAlgorithm = hashName(s.Hash)
+		result.SignatureType = signatureTypeName(s.SigType)
+		for _, v := range s.Notations {
+			notation := models.SignatureNotation{
+				Name:          v.Name,
+				Value:         string(v.Value),
+				HumanReadable: v.HumanReadable,
+				Critical:      v.IsCritical,
+			}
+			if !v.HumanReadable {
+				notation.Value = base64.StdEncoding.EncodeToString(v.Value)
+			}
+			result.Notations = append(result.Notations, notation)
+		}
+		result.PolicyURI = s.PolicyURI
+		if s.SignerUserId != nil {
+			result.SignerUserId = *s.SignerUserId
+		}
+		if s.SigLifetimeSecs != nil && *s.SigLifetimeSecs != 0 {
+			expiration := s.CreationTime.Add(time.Duration(*s.SigLifetimeSecs) * time.Second)
+			result.SignatureExpiration = &expiration
+			result.SignatureExpired = time.Now().After(expiration)
+			result.Valid = !result.SignatureExpired
+		}
+	case *packet.SignatureV3:
+		result.CreationTime = s.CreationTime
+		result.HashAlgorithm = hashName(s.Hash)
+		result.SignatureType = signatureTypeName(s.SigType)
+	}
+
+	if selfSig := key.SelfSignature; selfSig != nil && selfSig.KeyLifetimeSecs != nil && *selfSig.KeyLifetimeSecs != 0 {
+		expiration := key.PublicKey.CreationTime.Add(time.Duration(*selfSig.KeyLifetimeSecs) * time.Second)
+		result.KeyExpiration = &expiration
+		result.KeyExpiredAtSigning = result.CreationTime.After(expiration)
+		result.Valid = result.Valid && !result.KeyExpiredAtSigning
+	}
+
+	if revocation := keyRevocation(key); revocation != nil {
+		revokedAt := revocation.CreationTime
+		result.KeyRevoked = true
+		result.KeyRevocationTime = &revokedAt
+		result.KeyRevocationReason = revocationReason(revocation)
+
+		if timestamp != nil {
+			result.KeyRevokedAtSigning = !timestamp.Before(revokedAt)
+		} else {
+			soft := revocation.RevocationReason != nil && (*revocation.RevocationReason == revocationSuperseded || *revocation.RevocationReason == revocationRetired)
+			result.KeyRevokedAtSigning = !soft || !result.CreationTime.Before(revokedAt)
+		}
+		result.Valid = result.Valid && !result.KeyRevokedAtSigning
+	}
+
+	return result
+}
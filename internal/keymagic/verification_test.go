@@ -0,0 +1,238 @@
+package keymagic
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+// revokeKey adds to e a revocation made at the specified time
+func revokeKey(t *testing.T, e *openpgp.Entity, reason uint8, at time.Time) {
+	t.Helper()
+
+	var pk bytes.Buffer
+	if err := e.PrimaryKey.Serialize(&pk); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := packet.NewOpaqueReader(&pk).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := mergeTestConfig.Hash().New()
+	_, _ = h.Write([]byte{0x99, byte(len(p.Contents) >> 8), byte(len(p.Contents))})
+	_, _ = h.Write(p.Contents)
+
+	sig := &packet.Signature{
+		CreationTime:         at,
+		SigType:              packet.SigTypeKeyRevocation,
+		PubKeyAlgo:           e.PrimaryKey.PubKeyAlgo,
+		Hash:                 mergeTestConfig.Hash(),
+		IssuerKeyId:          &e.PrimaryKey.KeyId,
+		RevocationReason:     &reason,
+		RevocationReasonText: "test",
+	}
+
+	if err := sig.Sign(h, e.PrivateKey, mergeTestConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	e.Revocations = []*packet.Signature{sig}
+}
+
+func TestSignatureResult(t *testing.T) {
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, e, bytes.NewReader(testData), mergeTestConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	verify := func(timestamp *time.Time) *models.GPGVerifySignatureResult {
+		key, sig, err := openpgp.VerifyArmoredDetachedSignature(openpgp.EntityList{e}, bytes.NewReader(testData), strings.NewReader(signature.String()), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signatureResult(key, sig, timestamp)
+	}
+
+	result := verify(nil)
+
+	if !result.Valid || result.FingerPrint != tools.IssuerKeyIdToFP16(e.PrimaryKey.KeyId) || result.SubKeyFingerPrint != "" {
+		t.Errorf("Expected valid signature of the primary key, got %+v", result)
+	}
+
+	if result.HashAlgorithm != hashName(mergeTestConfig.Hash()) || result.SignatureType != "binary" || result.CreationTime.IsZero() {
+		t.Errorf("Expected binary signature details, got %+v", result)
+	}
+
+	if result.KeyRevoked || result.KeyExpiration != nil {
+		t.Errorf("Expected not revoked key without expiration, got %+v", result)
+	}
+
+	tests := []struct {
+		name            string
+		reason          uint8
+		at              time.Time
+		revokedAtSigned bool
+	}{
+		{"superseded after signing", revocationSuperseded, time.Now().Add(time.Hour), false},
+		{"superseded before signing", revocationSuperseded, time.Now().Add(-time.Hour), true},
+		{"retired after signing", revocationRetired, time.Now().Add(time.Hour), false},
+		{"compromised after signing", revocationCompromised, time.Now().Add(time.Hour), true},
+		{"no reason after signing", revocationNoReason, time.Now().Add(time.Hour), true},
+	}
+
+	for _, tt := range tests {
+		revokeKey(t, e, tt.reason, tt.at)
+		result := verify(nil)
+
+		if !result.KeyRevoked || result.KeyRevokedAtSigning != tt.revokedAtSigned || result.Valid == tt.revokedAtSigned {
+			t.Errorf("%s: expected revoked at signing %v, got %+v", tt.name, tt.revokedAtSigned, result)
+		}
+
+		if !strings.HasSuffix(result.KeyRevocationReason, ": test") {
+			t.Errorf("%s: expected revocation reason text, got %q", tt.name, result.KeyRevocationReason)
+		}
+	}
+
+	// A trusted timestamp before the revocation keeps the signature valid for any revocation reason
+	revokeKey(t, e, revocationCompromised, time.Now().Add(time.Hour))
+
+	before := time.Now()
+	if result := verify(&before); !result.Valid || result.KeyRevokedAtSigning || result.Timestamp == nil {
+		t.Errorf("Expected valid signature timestamped before the revocation, got %+v", result)
+	}
+
+	after := time.Now().Add(2 * time.Hour)
+	if result := verify(&after); result.Valid || !result.KeyRevokedAtSigning {
+		t.Errorf("Expected invalid signature timestamped after the revocation, got %+v", result)
+	}
+}
+
+func TestSignatureResultKeyExpired(t *testing.T) {
+	now := time.Now()
+	c := &packet.Config{RSABits: 1024, Time: func() time.Time {
+		return now.Add(-2 * time.Hour)
+	}}
+
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lifetime := uint32(time.Hour / time.Second)
+	for _, id := range e.Identities {
+		id.SelfSignature.KeyLifetimeSecs = &lifetime
+	}
+
+	verify := func(signedAt time.Time) *models.GPGVerifySignatureResult {
+		c.Time = func() time.Time {
+			return signedAt
+		}
+
+		var signature bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&signature, e, bytes.NewReader(testData), c); err != nil {
+			t.Fatal(err)
+		}
+
+		key, sig, err := openpgp.VerifyArmoredDetachedSignature(openpgp.EntityList{e}, bytes.NewReader(testData), strings.NewReader(signature.String()), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return signatureResult(key, sig, nil)
+	}
+
+	if result := verify(now.Add(-90 * time.Minute)); !result.Valid || result.KeyExpiredAtSigning || result.KeyExpiration == nil {
+		t.Errorf("Expected valid signature made before the key expired, got %+v", result)
+	}
+
+	if result := verify(now); result.Valid || !result.KeyExpiredAtSigning {
+		t.Errorf("Expected invalid signature made after the key expired, got %+v", result)
+	}
+}
+
+func TestVerifyCriticalNotations(t *testing.T) {
+	config.PushVariables()
+	defer config.PopVariables()
+
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := signatureConfig(e, mergeTestConfig.Hash(), models.SignatureOptions{
+		Notations: []models.SignatureNotation{{Name: "invoice@huebr.com", Value: "1234", HumanReadable: true, Critical: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, e, bytes.NewReader(testData), c); err != nil {
+		t.Fatal(err)
+	}
+
+	verify := func() error {
+		_, _, err := openpgp.VerifyArmoredDetachedSignature(openpgp.EntityList{e}, bytes.NewReader(testData), strings.NewReader(signature.String()), verifyConfig())
+		return err
+	}
+
+	config.SignatureKnownNotations = nil
+	if err := verify(); err == nil {
+		t.Error("Expected signature with unknown critical notation to be rejected")
+	}
+
+	config.SignatureKnownNotations = []string{"invoice@huebr.com"}
+	if err := verify(); err != nil {
+		t.Errorf("Expected signature with known critical notation to be valid, got %v", err)
+	}
+}
+
+func TestSignatureResultExpired(t *testing.T) {
+	e, err := openpgp.NewEntity("John HUEBR", "", "john@huebr.com", mergeTestConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := signatureConfig(e, mergeTestConfig.Hash(), models.SignatureOptions{SignerUserId: "JOHN@huebr.com", ExpirationSeconds: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Time = func() time.Time {
+		return time.Now().Add(-time.Hour)
+	}
+
+	var signature bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&signature, e, bytes.NewReader(testData), c); err != nil {
+		t.Fatal(err)
+	}
+
+	key, sig, err := openpgp.VerifyArmoredDetachedSignature(openpgp.EntityList{e}, bytes.NewReader(testData), strings.NewReader(signature.String()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := signatureResult(key, sig, nil)
+
+	if result.Valid || !result.SignatureExpired || result.SignatureExpiration == nil {
+		t.Errorf("Expected expired signature, got %+v", result)
+	}
+
+	if result.SignerUserId != "John HUEBR <john@huebr.com>" {
+		t.Errorf("Expected signer user id John HUEBR <john@huebr.com>, got %q", result.SignerUserId)
+	}
+}
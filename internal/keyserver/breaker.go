@@ -0,0 +1,48 @@
+package keyserver
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitBreaker stops using a keyserver for cooldown after maxFailures consecutive failures.
+// After the cooldown the keyserver is tried again, and a new failure opens the circuit again
+type circuitBreaker struct {
+	sync.Mutex
+	maxFailures int
+	cooldown    time.Duration
+	failures    int
+	openUntil   time.Time
+}
+
+func makeCircuitBreaker(maxFailures int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		maxFailures: maxFailures,
+		cooldown:    cooldown,
+	}
+}
+
+// allow returns false while the circuit is open
+func (cb *circuitBreaker) allow() bool {
+	cb.Lock()
+	defer cb.Unlock()
+
+	return cb.maxFailures <= 0 || cb.failures < cb.maxFailures || time.Now().After(cb.openUntil)
+}
+
+func (cb *circuitBreaker) success() {
+	cb.Lock()
+	defer cb.Unlock()
+
+	cb.failures = 0
+}
+
+func (cb *circuitBreaker) failure() {
+	cb.Lock()
+	defer cb.Unlock()
+
+	cb.failures++
+	if cb.maxFailures > 0 && cb.failures >= cb.maxFailures {
+		cb.openUntil = time.Now().Add(cb.cooldown)
+	}
+}
@@ -0,0 +1,128 @@
+package keyserver
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/slog"
+)
+
+// Keyserver types of a KEYSERVERS entry
+const (
+	TypeHKP = "hkp"
+	TypeVKS = "vks"
+	TypeWKD = "wkd"
+)
+
+// Circuit breaker defaults of a KEYSERVERS entry
+const (
+	DefaultMaxFailures = 3
+	DefaultCooldown    = time.Minute
+)
+
+// MakeFederationFromConfig creates a Federation with the sources of config.KeyServers. Invalid entries are skipped.
+// Returns nil if config.KeyServers is empty
+func MakeFederationFromConfig(log slog.Instance) *Federation {
+	if len(config.KeyServers) == 0 {
+		return nil
+	}
+
+	f := MakeFederation(log, nil)
+
+	for _, v := range config.KeyServers {
+		source, err := ParseSource(v)
+		if err != nil {
+			f.log.Error("Invalid KEYSERVERS entry %q: %s", v, err)
+			continue
+		}
+		f.sources = append(f.sources, source)
+	}
+
+	return f
+}
+
+// ParseSource parses a KEYSERVERS entry in the format type=url?option=value&option=value, or just wkd.
+// The options are timeout (golang duration), trusted (true / false), failures (consecutive failures to open the circuit) and cooldown (golang duration)
+func ParseSource(entry string) (*Source, error) {
+	parts := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+	kind := strings.ToLower(parts[0])
+	rawURL := ""
+
+	if len(parts) == 2 {
+		rawURL = parts[1]
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	query := u.Query()
+
+	timeout := DefaultTimeout
+	maxFailures := DefaultMaxFailures
+	cooldown := DefaultCooldown
+	trusted := kind != TypeHKP // HKP keyservers do not verify the emails
+
+	if v := query.Get("timeout"); v != "" {
+		if timeout, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid timeout %q", v)
+		}
+	}
+
+	if v := query.Get("trusted"); v != "" {
+		if trusted, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid trusted %q", v)
+		}
+	}
+
+	if v := query.Get("failures"); v != "" {
+		if maxFailures, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid failures %q", v)
+		}
+	}
+
+	if v := query.Get("cooldown"); v != "" {
+		if cooldown, err = time.ParseDuration(v); err != nil {
+			return nil, fmt.Errorf("invalid cooldown %q", v)
+		}
+	}
+
+	u.RawQuery = ""
+
+	switch u.Scheme {
+	case "hkp":
+		u.Scheme = "http"
+		if u.Port() == "" {
+			u.Host += ":11371"
+		}
+	case "hkps":
+		u.Scheme = "https"
+	}
+
+	baseURL := strings.TrimRight(u.String(), "/")
+
+	var server interfaces.KeyServer
+
+	switch kind {
+	case TypeHKP:
+		server = MakeHKPServer(baseURL, timeout)
+	case TypeVKS:
+		server = MakeVKSServer(baseURL, timeout)
+	case TypeWKD:
+		server = MakeWKDServer(baseURL, timeout)
+	default:
+		return nil, fmt.Errorf("unknown keyserver type %q", kind)
+	}
+
+	if kind != TypeWKD && baseURL == "" {
+		return nil, fmt.Errorf("the %s keyserver needs a url", kind)
+	}
+
+	return MakeSource(server, trusted, maxFailures, cooldown), nil
+}
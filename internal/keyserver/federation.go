@@ -0,0 +1,237 @@
+package keyserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/armor"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+	"github.com/quan-to/slog"
+)
+
+// Source is a upstream keyserver of a Federation
+type Source struct {
+	Server interfaces.KeyServer
+	// Trusted sources verify the ownership of the emails (like VKS and WKD), so their email lookups are accepted
+	Trusted bool
+	breaker *circuitBreaker
+}
+
+// MakeSource creates a Source that is skipped for cooldown after maxFailures consecutive failures
+func MakeSource(server interfaces.KeyServer, trusted bool, maxFailures int, cooldown time.Duration) *Source {
+	return &Source{
+		Server:  server,
+		Trusted: trusted,
+		breaker: makeCircuitBreaker(maxFailures, cooldown),
+	}
+}
+
+// Federation is a KeyServer that looks up a ordered list of upstream keyservers.
+// Returned keys are verified to match the requested fingerprint / email
+type Federation struct {
+	log     slog.Instance
+	sources []*Source
+}
+
+// MakeFederation creates a Federation of the specified sources
+func MakeFederation(log slog.Instance, sources []*Source) *Federation {
+	if log == nil {
+		log = slog.Scope("KeyServer")
+	} else {
+		log = log.SubScope("KeyServer")
+	}
+
+	return &Federation{
+		log:     log,
+		sources: sources,
+	}
+}
+
+func (f *Federation) Name() string {
+	names := make([]string, len(f.sources))
+	for i, v := range f.sources {
+		names[i] = v.Server.Name()
+	}
+
+	return fmt.Sprintf("federation (%s)", strings.Join(names, ", "))
+}
+
+// GetKeyByFingerPrint returns the first key found for fingerPrint that has it as primary key or subkey.
+// Key ids (the last 16 characters of the fingerprint) are accepted, but they can collide so only
+// a full fingerprint verifies the key found (see IsFullFingerPrint)
+func (f *Federation) GetKeyByFingerPrint(ctx context.Context, fingerPrint string) (string, error) {
+	fingerPrint = normalizeFingerPrint(fingerPrint)
+
+	if len(fingerPrint) < keyIdLength || len(fingerPrint) > fullFingerPrintLength {
+		return "", fmt.Errorf("fingerprint %q is not a key id or fingerprint", fingerPrint)
+	}
+
+	return f.lookup(ctx, "fingerprint "+fingerPrint, false, func(ks interfaces.KeyServer) (string, error) {
+		return ks.GetKeyByFingerPrint(ctx, fingerPrint)
+	}, func(e *openpgp.Entity) bool {
+		return matchesFingerPrint(e, fingerPrint)
+	})
+}
+
+// GetKeyByEmail returns the first key found for email in a trusted source that has a identity with it
+func (f *Federation) GetKeyByEmail(ctx context.Context, email string) (string, error) {
+	return f.lookup(ctx, "email "+email, true, func(ks interfaces.KeyServer) (string, error) {
+		return ks.GetKeyByEmail(ctx, email)
+	}, func(e *openpgp.Entity) bool {
+		return matchesEmail(e, email)
+	})
+}
+
+func (f *Federation) lookup(ctx context.Context, what string, trustedOnly bool, fetch func(ks interfaces.KeyServer) (string, error), match func(e *openpgp.Entity) bool) (string, error) {
+	requestID := tools.GetRequestIDFromContext(ctx)
+	log := f.log.Tag(requestID)
+
+	for _, source := range f.sources {
+		name := source.Server.Name()
+
+		if trustedOnly && !source.Trusted {
+			continue
+		}
+
+		if !source.breaker.allow() {
+			log.Debug("Skipping %s: too many failures", name)
+			continue
+		}
+
+		keys, err := fetch(source.Server)
+
+		if err == ErrNotFound || err == ErrNotSupported {
+			source.breaker.success()
+			continue
+		}
+
+		if err != nil {
+			source.breaker.failure()
+			log.Warn("Error looking up %s in %s: %s", what, name, err)
+			continue
+		}
+
+		key, err := findKey(keys, match)
+		if err != nil {
+			source.breaker.failure()
+			log.Warn("Invalid response for %s from %s: %s", what, name, err)
+			continue
+		}
+
+		if key == "" {
+			// A keyserver answering with keys that were not asked for is misbehaving
+			source.breaker.failure()
+			log.Warn("%s returned keys that do not match %s. Ignoring", name, what)
+			continue
+		}
+
+		source.breaker.success()
+
+		log.Info("Found %s in %s", what, name)
+		return key, nil
+	}
+
+	return "", ErrNotFound
+}
+
+// findKey returns the first key of the ASCII Armored keyring keys accepted by match.
+// Returns a empty string if no key matches
+func findKey(keys string, match func(e *openpgp.Entity) bool) (string, error) {
+	block, err := armor.Decode(strings.NewReader(keys))
+	if err != nil {
+		return "", err
+	}
+
+	reader := packet.NewOpaqueReader(block.Body)
+
+	var current *bytes.Buffer
+	groups := make([]*bytes.Buffer, 0)
+
+	for {
+		p, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if p.Tag == tagPublicKey {
+			current = &bytes.Buffer{}
+			groups = append(groups, current)
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if err := p.Serialize(current); err != nil {
+			return "", err
+		}
+	}
+
+	for _, v := range groups {
+		el, err := openpgp.ReadKeyRing(bytes.NewReader(v.Bytes()))
+		if err != nil || len(el) == 0 || !match(el[0]) {
+			// Keys with invalid signatures are skipped
+			continue
+		}
+
+		return toArmored(v.Bytes())
+	}
+
+	return "", nil
+}
+
+// tagPublicKey is the OpenPGP packet tag of a primary public key (RFC 4880 4.3)
+const tagPublicKey = 6
+
+// fullFingerPrintLength is the length of a hex encoded V4 key fingerprint
+const fullFingerPrintLength = 40
+
+// keyIdLength is the length of a hex encoded key id
+const keyIdLength = 16
+
+// IsFullFingerPrint returns if fingerPrint is a full V4 key fingerprint, that verifies the key found for it
+func IsFullFingerPrint(fingerPrint string) bool {
+	return len(normalizeFingerPrint(fingerPrint)) == fullFingerPrintLength
+}
+
+// matchesFingerPrint returns if the primary key or a subkey of e has the key id of fingerPrint
+// and a fingerprint ending with it
+func matchesFingerPrint(e *openpgp.Entity, fingerPrint string) bool {
+	keyId := fingerPrint[len(fingerPrint)-keyIdLength:]
+
+	matches := func(pk *packet.PublicKey) bool {
+		return fmt.Sprintf("%016X", pk.KeyId) == keyId && strings.HasSuffix(fmt.Sprintf("%X", pk.Fingerprint), fingerPrint)
+	}
+
+	if matches(e.PrimaryKey) {
+		return true
+	}
+
+	for _, v := range e.Subkeys {
+		if matches(v.PublicKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchesEmail(e *openpgp.Entity, email string) bool {
+	for _, v := range e.Identities {
+		if v.UserId != nil && strings.EqualFold(v.UserId.Email, email) {
+			return true
+		}
+	}
+
+	return false
+}
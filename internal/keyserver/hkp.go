@@ -0,0 +1,49 @@
+package keyserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type hkpServer struct {
+	baseURL string
+	client  *http.Client
+}
+
+// MakeHKPServer creates a KeyServer that uses the HKP lookup of a SKS / Hockeypuck compatible keyserver
+func MakeHKPServer(baseURL string, timeout time.Duration) *hkpServer {
+	return &hkpServer{
+		baseURL: baseURL,
+		client:  makeClient(timeout),
+	}
+}
+
+func (hs *hkpServer) Name() string {
+	return "hkp " + hs.baseURL
+}
+
+func (hs *hkpServer) lookup(ctx context.Context, search string) (string, error) {
+	data, err := httpGet(ctx, hs.client, fmt.Sprintf("%s/pks/lookup?op=get&options=mr&search=%s", hs.baseURL, url.QueryEscape(search)))
+	if err != nil {
+		return "", err
+	}
+
+	// Some keyservers answer 200 with a error page when the key is not found
+	if !bytes.Contains(data, []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----")) {
+		return "", ErrNotFound
+	}
+
+	return string(data), nil
+}
+
+func (hs *hkpServer) GetKeyByFingerPrint(ctx context.Context, fingerPrint string) (string, error) {
+	return hs.lookup(ctx, "0x"+normalizeFingerPrint(fingerPrint))
+}
+
+func (hs *hkpServer) GetKeyByEmail(ctx context.Context, email string) (string, error) {
+	return hs.lookup(ctx, email)
+}
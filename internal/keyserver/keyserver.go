@@ -0,0 +1,99 @@
+package keyserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/armor"
+)
+
+// ErrNotFound is returned when a keyserver does not have the requested key
+var ErrNotFound = errors.New("not found")
+
+// ErrNotSupported is returned when a keyserver does not support the requested lookup
+var ErrNotSupported = errors.New("lookup not supported by the keyserver")
+
+// DefaultTimeout is the timeout of a keyserver request when none is specified
+const DefaultTimeout = 10 * time.Second
+
+// maxKeySize is the maximum size of a keyserver response
+const maxKeySize = 4 * 1024 * 1024
+
+func makeClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+	}
+}
+
+// httpGet fetches url returning ErrNotFound for 404 responses
+func httpGet(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+
+	return ioutil.ReadAll(io.LimitReader(res.Body, maxKeySize))
+}
+
+// toArmored returns data in ASCII Armored format. Binary keys are armored
+func toArmored(data []byte) (string, error) {
+	if bytes.Contains(data, []byte("-----BEGIN PGP PUBLIC KEY BLOCK-----")) {
+		return string(data), nil
+	}
+
+	if len(data) == 0 {
+		return "", ErrNotFound
+	}
+
+	var b bytes.Buffer
+
+	w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = w.Write(data); err != nil {
+		return "", err
+	}
+
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// normalizeFingerPrint returns fp in upper case without the 0x prefix
+func normalizeFingerPrint(fp string) string {
+	fp = strings.ToUpper(strings.TrimSpace(fp))
+	return strings.TrimPrefix(fp, "0X")
+}
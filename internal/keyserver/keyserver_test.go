@@ -0,0 +1,303 @@
+package keyserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/armor"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+func generateKey(t *testing.T, name, email string) (*openpgp.Entity, string) {
+	t.Helper()
+
+	e, err := openpgp.NewEntity(name, "", email, &packet.Config{RSABits: 1024})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+
+	w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return e, b.String()
+}
+
+func fullFingerPrint(e *openpgp.Entity) string {
+	return fmt.Sprintf("%X", e.PrimaryKey.Fingerprint)
+}
+
+func TestZBase32Encode(t *testing.T) {
+	// Example from draft-koch-openpgp-webkey-service
+	hash := "a83ee94be89c48a11ed25ab44cfdc848833c8b6e"
+	var data []byte
+	for i := 0; i < len(hash); i += 2 {
+		var b byte
+		_, _ = fmt.Sscanf(hash[i:i+2], "%02x", &b)
+		data = append(data, b)
+	}
+
+	if v := zBase32Encode(data); v != "iy9q119eutrkn8s1mk4r39qejnbu3n5q" {
+		t.Errorf("Expected iy9q119eutrkn8s1mk4r39qejnbu3n5q got %s", v)
+	}
+}
+
+func TestHKPServer(t *testing.T) {
+	e, key := generateKey(t, "John HUEBR", "john@huebr.com")
+	fp := fullFingerPrint(e)[24:]
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		search := r.URL.Query().Get("search")
+		if r.URL.Path != "/pks/lookup" || (search != "0x"+fp && search != "john@huebr.com") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(key))
+	}))
+	defer ts.Close()
+
+	hkp := MakeHKPServer(ts.URL, time.Second)
+
+	if v, err := hkp.GetKeyByFingerPrint(context.Background(), fp); err != nil || v != key {
+		t.Errorf("Expected key for fingerprint %s, got error %v", fp, err)
+	}
+
+	if v, err := hkp.GetKeyByEmail(context.Background(), "john@huebr.com"); err != nil || v != key {
+		t.Errorf("Expected key for email, got error %v", err)
+	}
+
+	if _, err := hkp.GetKeyByFingerPrint(context.Background(), "0000000000000000"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound got %v", err)
+	}
+}
+
+func TestVKSServer(t *testing.T) {
+	e, key := generateKey(t, "John HUEBR", "john@huebr.com")
+	fp := fullFingerPrint(e)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/vks/v1/by-fingerprint/" + fp, "/vks/v1/by-keyid/" + fp[24:], "/vks/v1/by-email/john@huebr.com":
+			_, _ = w.Write([]byte(key))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	vks := MakeVKSServer(ts.URL, time.Second)
+	ctx := context.Background()
+
+	for _, v := range []string{fp, fp[24:], "0x" + strings.ToLower(fp)} {
+		if k, err := vks.GetKeyByFingerPrint(ctx, v); err != nil || k != key {
+			t.Errorf("Expected key for fingerprint %s, got error %v", v, err)
+		}
+	}
+
+	if k, err := vks.GetKeyByEmail(ctx, "john@huebr.com"); err != nil || k != key {
+		t.Errorf("Expected key for email, got error %v", err)
+	}
+
+	if _, err := vks.GetKeyByEmail(ctx, "maria@huebr.com"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound got %v", err)
+	}
+}
+
+func TestWKDServer(t *testing.T) {
+	e, _ := generateKey(t, "John HUEBR", "Joe.Doe@Example.ORG")
+
+	var binaryKey bytes.Buffer
+	if err := e.Serialize(&binaryKey); err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openpgpkey/hu/iy9q119eutrkn8s1mk4r39qejnbu3n5q" || r.URL.Query().Get("l") != "Joe.Doe" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(binaryKey.Bytes())
+	}))
+	defer ts.Close()
+
+	wkd := MakeWKDServer(ts.URL, time.Second)
+	ctx := context.Background()
+
+	key, err := wkd.GetKeyByEmail(ctx, "Joe.Doe@Example.ORG")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	el, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	if err != nil || len(el) != 1 || el[0].PrimaryKey.KeyId != e.PrimaryKey.KeyId {
+		t.Errorf("Expected WKD key to be returned in ASCII Armored format, got error %v", err)
+	}
+
+	if _, err := wkd.GetKeyByFingerPrint(ctx, fullFingerPrint(e)); err != ErrNotSupported {
+		t.Errorf("Expected ErrNotSupported got %v", err)
+	}
+
+	urls, _ := MakeWKDServer("", time.Second).lookupURLs("Joe.Doe@Example.ORG")
+	expected := "https://openpgpkey.example.org/.well-known/openpgpkey/example.org/hu/iy9q119eutrkn8s1mk4r39qejnbu3n5q?l=Joe.Doe"
+	if len(urls) != 2 || urls[0] != expected {
+		t.Errorf("Expected advanced method url %s got %v", expected, urls)
+	}
+}
+
+func TestFederation(t *testing.T) {
+	e, key := generateKey(t, "John HUEBR", "john@huebr.com")
+	_, otherKey := generateKey(t, "Maria HUEBR", "maria@huebr.com")
+	fp := fullFingerPrint(e)
+	ctx := context.Background()
+
+	var badHits, goodHits int32
+
+	// Answers every lookup with a key that was not asked for
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&badHits, 1)
+		_, _ = w.Write([]byte(otherKey))
+	}))
+	defer bad.Close()
+
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&goodHits, 1)
+		if r.URL.Path != "/vks/v1/by-fingerprint/"+fp && r.URL.Path != "/vks/v1/by-keyid/"+fp[24:] && r.URL.Path != "/vks/v1/by-email/john@huebr.com" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(key))
+	}))
+	defer good.Close()
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	down.Close()
+
+	f := MakeFederation(nil, []*Source{
+		MakeSource(MakeHKPServer(down.URL, time.Second), false, 1, time.Hour),
+		MakeSource(MakeHKPServer(bad.URL, time.Second), false, 2, time.Hour),
+		MakeSource(MakeVKSServer(good.URL, time.Second), true, 2, time.Hour),
+	})
+
+	for i := 0; i < 3; i++ {
+		k, err := f.GetKeyByFingerPrint(ctx, fp)
+		if err != nil {
+			t.Fatalf("Expected key to be found, got %s", err)
+		}
+		if k != key {
+			t.Errorf("Expected the key of the requested fingerprint")
+		}
+	}
+
+	// The bad server has a open circuit after 2 mismatched keys
+	if badHits != 2 {
+		t.Errorf("Expected 2 requests to the keyserver returning wrong keys got %d", badHits)
+	}
+
+	if goodHits != 3 {
+		t.Errorf("Expected 3 requests to the working keyserver got %d", goodHits)
+	}
+
+	// Email lookups only use trusted sources
+	if _, err := f.GetKeyByEmail(ctx, "john@huebr.com"); err != nil {
+		t.Errorf("Expected key to be found by email, got %s", err)
+	}
+
+	if badHits != 2 {
+		t.Errorf("Expected untrusted keyserver to not be used for email lookups")
+	}
+
+	if _, err := f.GetKeyByFingerPrint(ctx, strings.Repeat("0", 40)); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound got %v", err)
+	}
+
+	if k, err := f.GetKeyByFingerPrint(ctx, "0x"+fp[24:]); err != nil || k != key {
+		t.Errorf("Expected key to be found by key id, got %v", err)
+	}
+
+	for _, v := range []string{"1234", fp[26:], fp + "00"} {
+		if _, err := f.GetKeyByFingerPrint(ctx, v); err == nil {
+			t.Errorf("Expected error for fingerprint %s", v)
+		}
+	}
+}
+
+func TestMatchesFingerPrint(t *testing.T) {
+	e, _ := generateKey(t, "John HUEBR", "john@huebr.com")
+	fp := fullFingerPrint(e)
+	subKeyFP := fmt.Sprintf("%X", e.Subkeys[0].PublicKey.Fingerprint)
+
+	for _, v := range []string{fp, fp[24:], fp[10:], subKeyFP, subKeyFP[24:]} {
+		if !matchesFingerPrint(e, v) {
+			t.Errorf("Expected %s to match the key", v)
+		}
+	}
+
+	// flip changes the first character of fingerPrint
+	flip := func(fingerPrint string) string {
+		if fingerPrint[0] == '0' {
+			return "1" + fingerPrint[1:]
+		}
+		return "0" + fingerPrint[1:]
+	}
+
+	// The key id must match exactly, and the rest of the fingerprint too
+	for _, v := range []string{flip(fp[24:]), flip(fp), flip(fp[10:])} {
+		if matchesFingerPrint(e, v) {
+			t.Errorf("Expected %s to not match the key", v)
+		}
+	}
+}
+
+func TestParseSource(t *testing.T) {
+	s, err := ParseSource("hkp=hkps://keyserver.ubuntu.com/?timeout=5s&failures=5&cooldown=10m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Server.Name() != "hkp https://keyserver.ubuntu.com" || s.Trusted {
+		t.Errorf("Unexpected hkp source %s trusted %v", s.Server.Name(), s.Trusted)
+	}
+
+	if s.breaker.maxFailures != 5 || s.breaker.cooldown != 10*time.Minute {
+		t.Errorf("Expected circuit breaker options to be parsed")
+	}
+
+	if s, err = ParseSource("hkp=hkp://pgp.mit.edu"); err != nil || s.Server.Name() != "hkp http://pgp.mit.edu:11371" {
+		t.Errorf("Expected hkp scheme to be converted, got %v", err)
+	}
+
+	if s, err = ParseSource("vks=https://keys.openpgp.org?trusted=false"); err != nil || s.Trusted || s.Server.Name() != "vks https://keys.openpgp.org" {
+		t.Errorf("Expected untrusted vks source, got %v", err)
+	}
+
+	if s, err = ParseSource("wkd"); err != nil || !s.Trusted || s.Server.Name() != "wkd" {
+		t.Errorf("Expected trusted wkd source, got %v", err)
+	}
+
+	for _, v := range []string{"ldap=ldap://huebr", "vks", "hkp=http://huebr?timeout=huebr"} {
+		if _, err := ParseSource(v); err == nil {
+			t.Errorf("Expected error parsing %q", v)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package keyserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type vksServer struct {
+	baseURL string
+	client  *http.Client
+}
+
+// MakeVKSServer creates a KeyServer that uses the Verifying Keyserver API of Hagrid (like keys.openpgp.org)
+func MakeVKSServer(baseURL string, timeout time.Duration) *vksServer {
+	return &vksServer{
+		baseURL: baseURL,
+		client:  makeClient(timeout),
+	}
+}
+
+func (vs *vksServer) Name() string {
+	return "vks " + vs.baseURL
+}
+
+func (vs *vksServer) get(ctx context.Context, path string) (string, error) {
+	data, err := httpGet(ctx, vs.client, vs.baseURL+path)
+	if err != nil {
+		return "", err
+	}
+
+	return toArmored(data)
+}
+
+func (vs *vksServer) GetKeyByFingerPrint(ctx context.Context, fingerPrint string) (string, error) {
+	fingerPrint = normalizeFingerPrint(fingerPrint)
+
+	switch len(fingerPrint) {
+	case 16:
+		return vs.get(ctx, "/vks/v1/by-keyid/"+fingerPrint)
+	case 40:
+		return vs.get(ctx, "/vks/v1/by-fingerprint/"+fingerPrint)
+	}
+
+	return "", fmt.Errorf("the vks api needs a 16 or 40 characters fingerprint, got %q", fingerPrint)
+}
+
+func (vs *vksServer) GetKeyByEmail(ctx context.Context, email string) (string, error) {
+	return vs.get(ctx, "/vks/v1/by-email/"+url.PathEscape(email))
+}
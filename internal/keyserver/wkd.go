@@ -0,0 +1,105 @@
+package keyserver
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type wkdServer struct {
+	baseURL string
+	client  *http.Client
+}
+
+// MakeWKDServer creates a KeyServer that uses the Web Key Directory of the email domain.
+// If baseURL is not empty, every lookup uses the direct method in baseURL instead of the email domain
+func MakeWKDServer(baseURL string, timeout time.Duration) *wkdServer {
+	return &wkdServer{
+		baseURL: baseURL,
+		client:  makeClient(timeout),
+	}
+}
+
+func (ws *wkdServer) Name() string {
+	if ws.baseURL != "" {
+		return "wkd " + ws.baseURL
+	}
+
+	return "wkd"
+}
+
+func (ws *wkdServer) GetKeyByFingerPrint(ctx context.Context, fingerPrint string) (string, error) {
+	return "", ErrNotSupported
+}
+
+func (ws *wkdServer) GetKeyByEmail(ctx context.Context, email string) (string, error) {
+	urls, err := ws.lookupURLs(email)
+	if err != nil {
+		return "", err
+	}
+
+	var lastErr error = ErrNotFound
+
+	for _, u := range urls {
+		data, err := httpGet(ctx, ws.client, u)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return toArmored(data)
+	}
+
+	return "", lastErr
+}
+
+// lookupURLs returns the advanced and direct method URLs of email (draft-koch-openpgp-webkey-service 3.1)
+func (ws *wkdServer) lookupURLs(email string) ([]string, error) {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return nil, fmt.Errorf("invalid email %q", email)
+	}
+
+	local := email[:at]
+	domain := strings.ToLower(email[at+1:])
+	hash := sha1.Sum([]byte(strings.ToLower(local)))
+	path := fmt.Sprintf("hu/%s?l=%s", zBase32Encode(hash[:]), url.QueryEscape(local))
+
+	if ws.baseURL != "" {
+		return []string{fmt.Sprintf("%s/.well-known/openpgpkey/%s", ws.baseURL, path)}, nil
+	}
+
+	return []string{
+		fmt.Sprintf("https://openpgpkey.%s/.well-known/openpgpkey/%s/%s", domain, domain, path),
+		fmt.Sprintf("https://%s/.well-known/openpgpkey/%s", domain, path),
+	}, nil
+}
+
+const zBase32Alphabet = "ybndrfg8ejkmcpqxot1uwisza345h769"
+
+// zBase32Encode encodes data in z-base-32 as used by the Web Key Directory
+func zBase32Encode(data []byte) string {
+	var sb strings.Builder
+
+	buffer := 0
+	bits := 0
+
+	for _, b := range data {
+		buffer = buffer<<8 | int(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			sb.WriteByte(zBase32Alphabet[(buffer>>uint(bits))&0x1F])
+		}
+	}
+
+	if bits > 0 {
+		sb.WriteByte(zBase32Alphabet[(buffer<<uint(5-bits))&0x1F])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"time"
+
+	"github.com/quan-to/slog"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+type fileMailer struct {
+	log    slog.Instance
+	folder string
+}
+
+// MakeFileMailer creates a Mailer that writes every email as a file in folder
+func MakeFileMailer(log slog.Instance, folder string) *fileMailer {
+	if log == nil {
+		log = slog.Scope("FileMailer")
+	} else {
+		log = log.SubScope("FileMailer")
+	}
+
+	return &fileMailer{
+		log:    log,
+		folder: folder,
+	}
+}
+
+func (fm *fileMailer) SendMail(to, subject, body string) error {
+	if err := checkAddress(to); err != nil {
+		return err
+	}
+
+	err := os.MkdirAll(fm.folder, 0750)
+	if err != nil {
+		return err
+	}
+
+	filename := path.Join(fm.folder, fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(to, "_")))
+	fm.log.Debug("Writing mail to %s in %s", to, filename)
+
+	return ioutil.WriteFile(filename, []byte(formatMessage("", to, subject, body)), 0640)
+}
@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"github.com/quan-to/slog"
+)
+
+type logMailer struct {
+	log slog.Instance
+}
+
+// MakeLogMailer creates a Mailer that only logs the emails. Useful for development and tests
+func MakeLogMailer(log slog.Instance) *logMailer {
+	if log == nil {
+		log = slog.Scope("LogMailer")
+	} else {
+		log = log.SubScope("LogMailer")
+	}
+
+	return &logMailer{
+		log: log,
+	}
+}
+
+func (lm *logMailer) SendMail(to, subject, body string) error {
+	if err := checkAddress(to); err != nil {
+		return err
+	}
+
+	lm.log.Info("Mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
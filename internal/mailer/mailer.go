@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/slog"
+)
+
+// Mailer types of config.Mailer
+const (
+	TypeSMTP = "smtp"
+	TypeFile = "file"
+	TypeLog  = "log"
+)
+
+// MakeMailerFromConfig creates the Mailer specified by config.Mailer
+func MakeMailerFromConfig(log slog.Instance) (interfaces.Mailer, error) {
+	switch config.Mailer {
+	case TypeSMTP:
+		if config.SMTPHost == "" {
+			return nil, fmt.Errorf("the smtp mailer requires SMTP_HOST")
+		}
+		return MakeSMTPMailer(log, config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.SMTPFrom), nil
+	case TypeFile:
+		return MakeFileMailer(log, config.MailerFolder), nil
+	case TypeLog:
+		return MakeLogMailer(log), nil
+	}
+
+	return nil, fmt.Errorf("unknown mailer %q", config.Mailer)
+}
+
+// checkAddress rejects addresses that could inject headers in the message
+func checkAddress(address string) error {
+	if address == "" || strings.ContainsAny(address, "\r\n") {
+		return fmt.Errorf("invalid email address %q", address)
+	}
+
+	return nil
+}
+
+// formatMessage formats a plain text RFC 5322 message
+func formatMessage(from, to, subject, body string) string {
+	headers := make([]string, 0)
+
+	if from != "" {
+		headers = append(headers, "From: "+from)
+	}
+
+	headers = append(headers,
+		"To: "+to,
+		"Subject: "+mime.QEncoding.Encode("utf-8", subject),
+		"Date: "+time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	)
+
+	body = strings.Replace(body, "\r\n", "\n", -1)
+	body = strings.Replace(body, "\n", "\r\n", -1)
+
+	return strings.Join(headers, "\r\n") + "\r\n\r\n" + body
+}
@@ -0,0 +1,74 @@
+package mailer
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/quan-to/chevron/internal/config"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chevron-mailer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := MakeFileMailer(nil, path.Join(dir, "mails"))
+
+	if err := m.SendMail("john@huebr.com", "Hue", "Line 1\nLine 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := ioutil.ReadDir(path.Join(dir, "mails"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 mail file, got %d", len(files))
+	}
+
+	data, err := ioutil.ReadFile(path.Join(dir, "mails", files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mail := string(data)
+
+	if !strings.Contains(mail, "To: john@huebr.com\r\n") || !strings.Contains(mail, "Subject: Hue\r\n") {
+		t.Errorf("Expected mail headers, got %q", mail)
+	}
+
+	if !strings.HasSuffix(mail, "\r\n\r\nLine 1\r\nLine 2") {
+		t.Errorf("Expected mail body with CRLF line endings, got %q", mail)
+	}
+
+	if err := m.SendMail("john@huebr.com\r\nBcc: maria@huebr.com", "Hue", "br"); err == nil {
+		t.Errorf("Expected error with header injection in address")
+	}
+}
+
+func TestMakeMailerFromConfig(t *testing.T) {
+	config.PushVariables()
+	defer config.PopVariables()
+
+	config.Mailer = TypeLog
+	if _, err := MakeMailerFromConfig(nil); err != nil {
+		t.Errorf("Expected log mailer, got %s", err)
+	}
+
+	config.Mailer = TypeSMTP
+	config.SMTPHost = ""
+	if _, err := MakeMailerFromConfig(nil); err == nil {
+		t.Errorf("Expected error with smtp mailer without host")
+	}
+
+	config.Mailer = "huebr"
+	if _, err := MakeMailerFromConfig(nil); err == nil {
+		t.Errorf("Expected error with unknown mailer")
+	}
+}
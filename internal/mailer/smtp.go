@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+
+	"github.com/quan-to/slog"
+)
+
+type smtpMailer struct {
+	log  slog.Instance
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// MakeSMTPMailer creates a Mailer that sends the emails through a SMTP server.
+// Authentication is only used if username is not empty
+func MakeSMTPMailer(log slog.Instance, host string, port int, username, password, from string) *smtpMailer {
+	if log == nil {
+		log = slog.Scope("SMTPMailer")
+	} else {
+		log = log.SubScope("SMTPMailer")
+	}
+
+	var auth smtp.Auth
+
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpMailer{
+		log:  log,
+		addr: fmt.Sprintf("%s:%d", host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (sm *smtpMailer) SendMail(to, subject, body string) error {
+	if err := checkAddress(to); err != nil {
+		return err
+	}
+
+	sm.log.Debug("Sending mail to %s through %s", to, sm.addr)
+	return smtp.SendMail(sm.addr, sm.auth, sm.from, []string{to}, []byte(formatMessage(sm.from, to, subject, body)))
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/graphql-go/graphql"
 	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/keymagic"
 	"github.com/quan-to/chevron/internal/tools"
 	"github.com/quan-to/chevron/pkg/QuantoError"
 	"github.com/quan-to/chevron/pkg/interfaces"
@@ -112,6 +113,24 @@ var RootManagementMutation = graphql.NewObject(graphql.ObjectConfig{
 			},
 			Resolve: resolveInvalidateToken,
 		},
+		"DeletePublicKey": &graphql.Field{
+			Type: graphql.String,
+			Args: graphql.FieldConfigArgument{
+				"fingerPrint": &graphql.ArgumentConfig{
+					Type:        graphql.NewNonNull(graphql.String),
+					Description: "Fingerprint of the public key to be removed from the key store. It does not need to be stored, so it can be blocked",
+				},
+				"emails": &graphql.ArgumentConfig{
+					Type:        graphql.NewList(graphql.String),
+					Description: "Remove only the user ids with these emails. If empty the whole key is removed",
+				},
+				"reason": &graphql.ArgumentConfig{
+					Type:        graphql.String,
+					Description: "Reason of the removal, like a GDPR erasure request",
+				},
+			},
+			Resolve: resolveDeletePublicKey,
+		},
 	},
 })
 
@@ -312,3 +331,42 @@ func resolveInvalidateToken(p graphql.ResolveParams) (i interface{}, e error) {
 
 	return "OK", nil
 }
+
+func resolveDeletePublicKey(p graphql.ResolveParams) (i interface{}, e error) {
+	lu := p.Context.Value(LoggedUserKey).(interfaces.UserData)
+	if lu == nil {
+		e := QuantoError.New(QuantoError.PermissionDenied, "proxyToken", "You need to be logged in to use this query", nil)
+		return nil, e.ToFormattedError()
+	}
+
+	if lu.GetUsername() != "admin" {
+		e := QuantoError.New(QuantoError.PermissionDenied, "username", "Only the administrator can delete public keys", nil)
+		return nil, e.ToFormattedError()
+	}
+
+	fingerPrint := p.Args["fingerPrint"].(string)
+	emails := make([]string, 0)
+	reason := ""
+
+	if p.Args["emails"] != nil {
+		for _, v := range p.Args["emails"].([]interface{}) {
+			if email, ok := v.(string); ok {
+				emails = append(emails, email)
+			}
+		}
+	}
+
+	if p.Args["reason"] != nil {
+		reason = p.Args["reason"].(string)
+	}
+
+	result, err := keymagic.PKSAdminDeleteKey(p.Context, fingerPrint, emails, reason, lu.GetUsername())
+	if err != nil {
+		e := QuantoError.New(QuantoError.InvalidFieldData, "fingerPrint", err.Error(), nil)
+		return nil, e.ToFormattedError()
+	}
+
+	amGqlLog.Info("%s (%s): %s", lu.GetFullName(), lu.GetUsername(), result)
+
+	return result.String(), nil
+}
@@ -23,7 +23,7 @@ type AgentAdmin struct {
 }
 
 // MakeAgentAdmin creates an instance of Agent Administration endpoint
-func MakeAgentAdmin(log slog.Instance, tm interfaces.TokenManager, am interfaces.AuthManager) *AgentAdmin {
+func MakeAgentAdmin(log slog.Instance, tm interfaces.TokenManager, am interfaces.AuthManager, dbh DatabaseHandler) *AgentAdmin {
 	if log == nil {
 		log = slog.Scope("AgentAdmin")
 	} else {
@@ -64,10 +64,10 @@ func MakeAgentAdmin(log slog.Instance, tm interfaces.TokenManager, am interfaces
 	return &AgentAdmin{
 		handler: h,
 		tm:      tm,
-		ctx: tools.ContextWithValues(context.Background(), map[string]interface{}{
+		ctx: wrapContextWithDatabaseHandler(dbh, tools.ContextWithValues(context.Background(), map[string]interface{}{
 			agent.TokenManagerKey: tm,
 			agent.AuthManagerKey:  am,
-		}),
+		})),
 		log: log,
 	}
 }
@@ -5,12 +5,17 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/quan-to/chevron/internal/config"
 	"github.com/quan-to/chevron/internal/tools"
 	"github.com/quan-to/chevron/pkg/interfaces"
 	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/timestamp"
 
 	"github.com/gorilla/mux"
 	"github.com/quan-to/slog"
@@ -46,6 +51,7 @@ func (ge *GPGEndpoint) AttachHandlers(r *mux.Router) {
 	r.HandleFunc("/verifySignatureQuanto", ge.verifySignatureQuanto).Methods("POST")
 	r.HandleFunc("/encrypt", ge.encrypt).Methods("POST")
 	r.HandleFunc("/decrypt", ge.decrypt).Methods("POST")
+	r.HandleFunc("/timestamp", ge.timestamp).Methods("POST")
 }
 
 // Decrypt godoc
@@ -138,7 +144,7 @@ func (ge *GPGEndpoint) encrypt(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param message body models.GPGVerifySignatureDataNonQuanto true "Information to verify a signature in GPG format"
-// @Success 200 {string} Returns OK
+// @Success 200 {object} models.GPGVerifySignatureResult "Returns OK, or the verification details with Accept: application/json"
 // @Failure default {object} QuantoError.ErrorObject
 // @Router /gpg/verifySignature [post]
 func (ge *GPGEndpoint) verifySignature(w http.ResponseWriter, r *http.Request) {
@@ -163,21 +169,14 @@ func (ge *GPGEndpoint) verifySignature(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	valid, err := ge.gpg.VerifySignature(ctx, bytes, data.Signature)
+	result, err := ge.gpg.VerifySignature(ctx, bytes, data.Signature)
 
 	if err != nil {
 		InvalidFieldData("Signature", err.Error(), w, r, log)
 		return
 	}
 
-	if !valid {
-		InvalidFieldData("Signature", "The provided signature is invalid", w, r, log)
-		return
-	}
-
-	w.Header().Set("Content-Type", models.MimeText)
-	w.WriteHeader(200)
-	_, _ = w.Write([]byte("OK"))
+	ge.writeVerifyResult("verifySignature", result, w, r, log)
 }
 
 // VerifySignatureQuanto godoc
@@ -187,7 +186,7 @@ func (ge *GPGEndpoint) verifySignature(w http.ResponseWriter, r *http.Request) {
 // @Accept json
 // @Produce json
 // @Param message body models.GPGVerifySignatureData true "Information to verify a signature in quanto format"
-// @Success 200 {string} Returns OK
+// @Success 200 {object} models.GPGVerifySignatureResult "Returns OK, or the verification details with Accept: application/json"
 // @Failure default {object} QuantoError.ErrorObject
 // @Router /gpg/verifySignatureQuanto [post]
 func (ge *GPGEndpoint) verifySignatureQuanto(w http.ResponseWriter, r *http.Request) {
@@ -213,7 +212,7 @@ func (ge *GPGEndpoint) verifySignatureQuanto(w http.ResponseWriter, r *http.Requ
 	}
 
 	signature := tools.Quanto2GPG(data.Signature)
-	valid, err := ge.gpg.VerifySignature(ctx, bytes, signature)
+	result, err := ge.gpg.VerifySignature(ctx, bytes, signature)
 
 	if err != nil {
 		if strings.Contains(err.Error(), "cannot find public key to verify signature") {
@@ -224,8 +223,38 @@ func (ge *GPGEndpoint) verifySignatureQuanto(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if !valid {
-		InvalidFieldData("Signature", "The provided signature is invalid", w, r, log)
+	ge.writeVerifyResult("verifySignatureQuanto", result, w, r, log)
+}
+
+// writeVerifyResult writes the result of a signature verification. Endpoints that require trust reject untrusted signer keys.
+// The verification details are returned in a JSON body when requested by the Accept header, otherwise the body is OK
+func (ge *GPGEndpoint) writeVerifyResult(endpoint string, result *models.GPGVerifySignatureResult, w http.ResponseWriter, r *http.Request, log slog.Instance) {
+	if result.SignatureExpired {
+		InvalidFieldData("Signature", fmt.Sprintf("The provided signature is invalid. It expired at %s", result.SignatureExpiration.Format(time.RFC3339)), w, r, log)
+		return
+	}
+
+	if result.KeyExpiredAtSigning {
+		InvalidFieldData("Signature", fmt.Sprintf("The provided signature is invalid. The key %s was expired at %s when the signature was made", result.FingerPrint, result.KeyExpiration.Format(time.RFC3339)), w, r, log)
+		return
+	}
+
+	if !result.Valid {
+		InvalidFieldData("Signature", fmt.Sprintf("The provided signature is invalid. The key %s was revoked (%s)", result.FingerPrint, result.KeyRevocationReason), w, r, log)
+		return
+	}
+
+	if !result.Trusted && config.IsTrustRequired(endpoint) {
+		PermissionDenied("Signature", fmt.Sprintf("The signature is valid but the key %s is not trusted", result.FingerPrint), w, r, log)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), models.MimeJSON) {
+		d, _ := json.Marshal(result)
+
+		w.Header().Set("Content-Type", models.MimeJSON)
+		w.WriteHeader(200)
+		_, _ = w.Write(d)
 		return
 	}
 
@@ -238,7 +267,7 @@ func (ge *GPGEndpoint) verifySignatureQuanto(w http.ResponseWriter, r *http.Requ
 // @id gpg-data-sign
 // @tags GPG Operations
 // @Summary Signs a payload with a standard GPG signature format
-// @Description Signs a payload using the specified GPG key and returns the signature in GPG Format
+// @Description Signs a payload using the specified GPG key and returns the signature in GPG Format. The optional notations, signer user id, policy URI and expiration are added to the signature
 // @Accept json
 // @Produce plain
 // @Param message body models.GPGSignData true "Data to sign"
@@ -267,7 +296,7 @@ func (ge *GPGEndpoint) sign(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	signature, err := ge.gpg.SignData(ctx, data.FingerPrint, bytes, crypto.SHA512)
+	signature, err := ge.gpg.SignDataWithOptions(ctx, data.FingerPrint, bytes, crypto.SHA512, data.SignatureOptions)
 
 	if err != nil {
 		InvalidFieldData("Key", fmt.Sprintf("There was an error signing your data: %s", err.Error()), w, r, log)
@@ -283,7 +312,7 @@ func (ge *GPGEndpoint) sign(w http.ResponseWriter, r *http.Request) {
 // @id gpg-data-sign-quanto
 // @tags GPG Operations
 // @Summary Signs a payload with a Quanto's signature format
-// @Description Signs a payload using the specified GPG key and returns the signature in Quanto Format
+// @Description Signs a payload using the specified GPG key and returns the signature in Quanto Format. The optional notations, signer user id, policy URI and expiration are added to the signature
 // @Accept json
 // @Produce plain
 // @Param message body models.GPGSignData true "Data to sign"
@@ -312,7 +341,7 @@ func (ge *GPGEndpoint) signQuanto(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	signature, err := ge.gpg.SignData(ctx, data.FingerPrint, bytes, crypto.SHA512)
+	signature, err := ge.gpg.SignDataWithOptions(ctx, data.FingerPrint, bytes, crypto.SHA512, data.SignatureOptions)
 
 	if err != nil {
 		InvalidFieldData("Key", fmt.Sprintf("There was an error signing your data: %s", err.Error()), w, r, log)
@@ -326,6 +355,64 @@ func (ge *GPGEndpoint) signQuanto(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte(quantoSig))
 }
 
+// maxTimestampRequestSize is the maximum size of a timestamp request body
+const maxTimestampRequestSize = 64 * 1024
+
+// Timestamp godoc
+// @id gpg-timestamp
+// @tags GPG Operations
+// @Summary RFC 3161 timestamp authority
+// @Description Returns the RFC 3161 response of the built-in timestamp authority to a DER encoded timestamp request. Only available if TIMESTAMP_AUTHORITY_KEY is set
+// @Accept application/timestamp-query
+// @Produce application/timestamp-reply
+// @Param message body string true "DER encoded timestamp request"
+// @Success 200 {string} Response "DER encoded timestamp response"
+// @Failure default {object} QuantoError.ErrorObject
+// @Router /gpg/timestamp [post]
+func (ge *GPGEndpoint) timestamp(w http.ResponseWriter, r *http.Request) {
+	ctx := wrapContextWithRequestID(r)
+	log := wrapLogWithRequestID(ge.log, r)
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			CatchAllError(rec, w, r, log)
+		}
+	}()
+
+	if config.TimestampAuthorityKey == "" {
+		NotFound("timestampAuthority", "the built-in timestamp authority is not enabled", w, r, log)
+		return
+	}
+
+	request, err := ioutil.ReadAll(io.LimitReader(r.Body, maxTimestampRequestSize+1))
+
+	if err != nil {
+		InvalidFieldData("body", err.Error(), w, r, log)
+		return
+	}
+
+	if len(request) > maxTimestampRequestSize {
+		InvalidFieldData("body", fmt.Sprintf("the timestamp request is larger than %d bytes", maxTimestampRequestSize), w, r, log)
+		return
+	}
+
+	if err := timestamp.CheckRequest(request); err != nil {
+		InvalidFieldData("body", fmt.Sprintf("invalid timestamp request: %s", err), w, r, log)
+		return
+	}
+
+	response, err := ge.gpg.TimestampResponse(ctx, request)
+
+	if err != nil {
+		InternalServerError("There was an error creating the timestamp response", err.Error(), w, r, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", timestamp.MimeResponse)
+	w.WriteHeader(200)
+	_, _ = w.Write(response)
+}
+
 // UnlockKey godoc
 // @id gpg-key-unlock
 // @tags GPG Operations
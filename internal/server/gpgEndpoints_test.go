@@ -3,17 +3,24 @@ package server
 import (
 	"bytes"
 	"context"
+	"crypto"
+	"crypto/sha512"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/quan-to/chevron/internal/config"
 	"github.com/quan-to/chevron/internal/tools"
 	"github.com/quan-to/chevron/pkg/QuantoError"
 	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/timestamp"
 	"github.com/quan-to/chevron/test"
+	"github.com/quan-to/slog"
 )
 
 // region GPG Endpoint Tests
@@ -374,6 +381,86 @@ func TestVerifySignatureQuanto(t *testing.T) {
 		t.Errorf("Expected OK got %s", string(d))
 	}
 }
+func TestVerifySignatureTrust(t *testing.T) {
+	config.PushVariables()
+	defer config.PopVariables()
+
+	config.TrustedKeys = nil
+	config.TrustCAKeys = nil
+	config.TrustRequiredEndpoints = []string{"verifysignature"}
+
+	body, err := json.Marshal(models.GPGVerifySignatureData{
+		Base64Data: base64.StdEncoding.EncodeToString([]byte(test.TestSignatureData)),
+		Signature:  test.TestSignatureSignature,
+	})
+
+	errorDie(err, t)
+
+	req, err := http.NewRequest("POST", "/gpg/verifySignature", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	res := executeRequest(req)
+
+	if res.Code == 200 {
+		t.Errorf("Expected a valid signature of a untrusted key to be rejected")
+	}
+
+	config.TrustedKeys = []string{test.TestKeyFingerprint}
+
+	req, err = http.NewRequest("POST", "/gpg/verifySignature", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	req.Header.Set("Accept", models.MimeJSON)
+	res = executeRequest(req)
+
+	d, err := ioutil.ReadAll(res.Body)
+
+	errorDie(err, t)
+
+	if res.Code != 200 {
+		t.Fatalf("Expected 200 got %d: %s", res.Code, string(d))
+	}
+
+	var result models.GPGVerifySignatureResult
+
+	errorDie(json.Unmarshal(d, &result), t)
+
+	if !result.Valid || !result.Trusted || result.TrustedVia != "trusted key "+test.TestKeyFingerprint {
+		t.Errorf("Expected signature trusted via the trusted key %s, got %+v", test.TestKeyFingerprint, result)
+	}
+}
+func TestWriteVerifyResult(t *testing.T) {
+	ge := &GPGEndpoint{}
+	at := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		result models.GPGVerifySignatureResult
+	}{
+		{"expired key", models.GPGVerifySignatureResult{Valid: true, FingerPrint: test.TestKeyFingerprint, KeyExpiration: &at, KeyExpiredAtSigning: true}},
+		{"revoked key", models.GPGVerifySignatureResult{FingerPrint: test.TestKeyFingerprint, KeyRevoked: true, KeyRevokedAtSigning: true, KeyRevocationReason: "key compromised"}},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest("POST", "/gpg/verifySignature", nil)
+
+		errorDie(err, t)
+
+		res := httptest.NewRecorder()
+		ge.writeVerifyResult("verifySignature", &tt.result, res, req, slog.Scope("Test"))
+
+		errObj, err := ReadErrorObject(res.Body)
+
+		errorDie(err, t)
+
+		if res.Code == http.StatusOK || errObj.ErrorCode != QuantoError.InvalidFieldData {
+			t.Errorf("%s: expected error code %s, got %d %s", tt.name, QuantoError.InvalidFieldData, res.Code, errObj.ErrorCode)
+		}
+	}
+}
+
 func TestSign(t *testing.T) {
 	InvalidPayloadTest("/gpg/sign", t)
 	// region Generate Signature
@@ -487,6 +574,230 @@ func TestSign(t *testing.T) {
 	}
 	// endregion
 }
+func TestSignWithOptions(t *testing.T) {
+	signBody := models.GPGSignData{
+		FingerPrint: test.TestKeyFingerprint,
+		Base64Data:  base64.StdEncoding.EncodeToString([]byte(test.TestSignatureData)),
+		SignatureOptions: models.SignatureOptions{
+			Notations: []models.SignatureNotation{
+				{Name: "transaction@quanto.app", Value: "TX-1234", HumanReadable: true},
+				{Name: "environment@quanto.app", Value: base64.StdEncoding.EncodeToString([]byte("sandbox"))},
+			},
+			SignerUserId:      "jon@huebr.com",
+			PolicyURI:         "https://quanto.app/signature-policy",
+			ExpirationSeconds: 3600,
+		},
+	}
+
+	body, err := json.Marshal(signBody)
+
+	errorDie(err, t)
+
+	req, err := http.NewRequest("POST", "/gpg/sign", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	res := executeRequest(req)
+
+	d, err := ioutil.ReadAll(res.Body)
+
+	errorDie(err, t)
+
+	if res.Code != 200 {
+		t.Fatalf("Expected 200 got %d: %s", res.Code, string(d))
+	}
+
+	body, err = json.Marshal(models.GPGVerifySignatureData{
+		Base64Data: signBody.Base64Data,
+		Signature:  string(d),
+	})
+
+	errorDie(err, t)
+
+	req, err = http.NewRequest("POST", "/gpg/verifySignature", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	req.Header.Set("Accept", models.MimeJSON)
+	res = executeRequest(req)
+
+	d, err = ioutil.ReadAll(res.Body)
+
+	errorDie(err, t)
+
+	if res.Code != 200 {
+		t.Fatalf("Expected 200 got %d: %s", res.Code, string(d))
+	}
+
+	var result models.GPGVerifySignatureResult
+
+	errorDie(json.Unmarshal(d, &result), t)
+
+	if len(result.Notations) != 2 || result.Notations[0] != signBody.Notations[0] || result.Notations[1] != signBody.Notations[1] {
+		t.Errorf("Expected notations %+v got %+v", signBody.Notations, result.Notations)
+	}
+
+	if result.SignerUserId != "Jon HUEBR <jon@huebr.com>" || result.PolicyURI != signBody.PolicyURI {
+		t.Errorf("Expected signer user id and policy URI, got %q and %q", result.SignerUserId, result.PolicyURI)
+	}
+
+	if result.SignatureExpiration == nil || !result.SignatureExpiration.Equal(result.CreationTime.Add(time.Hour)) || result.SignatureExpired {
+		t.Errorf("Expected signature to expire in a hour, got %v", result.SignatureExpiration)
+	}
+
+	// region Test Invalid Options
+	invalidOptions := []models.SignatureOptions{
+		{Notations: []models.SignatureNotation{{Name: "transaction", Value: "TX-1234", HumanReadable: true}}},
+		{Notations: []models.SignatureNotation{{Name: "transaction@quanto.app", Value: "not base64!"}}},
+		{SignerUserId: "someone@huebr.com"},
+		{PolicyURI: "signature-policy"},
+	}
+
+	for _, options := range invalidOptions {
+		signBody.SignatureOptions = options
+		body, _ = json.Marshal(signBody)
+
+		req, err = http.NewRequest("POST", "/gpg/sign", bytes.NewReader(body))
+
+		errorDie(err, t)
+
+		res = executeRequest(req)
+
+		errObj, err := ReadErrorObject(res.Body)
+
+		errorDie(err, t)
+
+		if errObj.ErrorCode != QuantoError.InvalidFieldData {
+			t.Errorf("Expected error code %s with options %+v, got %s", QuantoError.InvalidFieldData, options, errObj.ErrorCode)
+		}
+	}
+	// endregion
+}
+
+func TestTimestamp(t *testing.T) {
+	config.PushVariables()
+	defer config.PopVariables()
+
+	config.TimestampAuthorityURL = ""
+	config.TimestampAuthorityKey = ""
+
+	digest := sha512.Sum512([]byte(test.TestSignatureData))
+	tsReq, err := timestamp.MakeRequest(crypto.SHA512, digest[:], nil)
+
+	errorDie(err, t)
+
+	// region Test Disabled Authority
+	req, err := http.NewRequest("POST", "/gpg/timestamp", bytes.NewReader(tsReq))
+
+	errorDie(err, t)
+
+	req.Header.Set("Content-Type", timestamp.MimeRequest)
+	res := executeRequest(req)
+
+	errObj, err := ReadErrorObject(res.Body)
+
+	errorDie(err, t)
+
+	if errObj.ErrorCode != QuantoError.NotFound {
+		t.Errorf("Expected error code %s without timestamp authority, got %s", QuantoError.NotFound, errObj.ErrorCode)
+	}
+	// endregion
+
+	config.TimestampAuthorityKey = test.TestKeyFingerprint
+
+	// region Test Invalid Timestamp Request
+	req, err = http.NewRequest("POST", "/gpg/timestamp", bytes.NewReader([]byte("invalid request")))
+
+	errorDie(err, t)
+
+	req.Header.Set("Content-Type", timestamp.MimeRequest)
+	res = executeRequest(req)
+
+	errObj, err = ReadErrorObject(res.Body)
+
+	errorDie(err, t)
+
+	if errObj.ErrorCode != QuantoError.InvalidFieldData {
+		t.Errorf("Expected error code %s for a invalid timestamp request, got %s", QuantoError.InvalidFieldData, errObj.ErrorCode)
+	}
+	// endregion
+
+	// region Test Timestamp Request
+	req, err = http.NewRequest("POST", "/gpg/timestamp", bytes.NewReader(tsReq))
+
+	errorDie(err, t)
+
+	req.Header.Set("Content-Type", timestamp.MimeRequest)
+	res = executeRequest(req)
+
+	d, err := ioutil.ReadAll(res.Body)
+
+	errorDie(err, t)
+
+	if res.Code != 200 || res.Header().Get("Content-Type") != timestamp.MimeResponse {
+		t.Fatalf("Expected 200 with a timestamp response, got %d: %s", res.Code, string(d))
+	}
+
+	token, err := timestamp.ParseResponse(d)
+
+	errorDie(err, t)
+
+	if err := token.VerifyData([]byte(test.TestSignatureData)); err != nil {
+		t.Error(err)
+	}
+	// endregion
+
+	// region Test Timestamped Signature
+	body, err := json.Marshal(models.GPGSignData{
+		FingerPrint:      test.TestKeyFingerprint,
+		Base64Data:       base64.StdEncoding.EncodeToString([]byte(test.TestSignatureData)),
+		SignatureOptions: models.SignatureOptions{Timestamp: true},
+	})
+
+	errorDie(err, t)
+
+	req, err = http.NewRequest("POST", "/gpg/sign", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	res = executeRequest(req)
+
+	d, err = ioutil.ReadAll(res.Body)
+
+	errorDie(err, t)
+
+	if res.Code != 200 {
+		t.Fatalf("Expected 200 got %d: %s", res.Code, string(d))
+	}
+
+	body, err = json.Marshal(models.GPGVerifySignatureData{
+		Base64Data: base64.StdEncoding.EncodeToString([]byte(test.TestSignatureData)),
+		Signature:  string(d),
+	})
+
+	errorDie(err, t)
+
+	req, err = http.NewRequest("POST", "/gpg/verifySignature", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	req.Header.Set("Accept", models.MimeJSON)
+	res = executeRequest(req)
+
+	d, err = ioutil.ReadAll(res.Body)
+
+	errorDie(err, t)
+
+	var result models.GPGVerifySignatureResult
+
+	errorDie(json.Unmarshal(d, &result), t)
+
+	if !result.Valid || result.Timestamp == nil || result.TimestampAuthority == "" {
+		t.Errorf("Expected valid signature with a timestamp, got %+v", result)
+	}
+	// endregion
+}
+
 func TestSignQuanto(t *testing.T) {
 	InvalidPayloadTest("/gpg/signQuanto", t)
 	// region Generate Signature
@@ -137,7 +137,7 @@ func hkpLookup(log slog.Instance, w http.ResponseWriter, r *http.Request) {
 // @Accept plain
 // @Produce plain
 // @param publickey body string true "GPG Public Key"
-// @Success 200 {string} result "OK"
+// @Success 200 {object} models.KeyMergeResult "Returns OK, or what changed in the stored key with Accept: application/json"
 // @Failure default {object} QuantoError.ErrorObject
 // @Router /pks/add [post]
 func hkpAdd(log slog.Instance, w http.ResponseWriter, r *http.Request) {
@@ -154,10 +154,15 @@ func hkpAdd(log slog.Instance, w http.ResponseWriter, r *http.Request) {
 
 	key := r.Form.Get("keytext")
 	log.Await("Adding key")
-	result := keymagic.PKSAdd(ctx, key)
+	result, err := keymagic.PKSMerge(ctx, key)
+	if err != nil {
+		log.Done("Key add error: %s", err)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("NOK"))
+		return
+	}
 	log.Done("Key add result: %s", result)
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte(result))
+	writeKeyMergeResult(result, w, r)
 }
 
 // AddHKPEndpoints attach the HKP /lookup and /add endpoints to the specified router with the specified log wrapped into the calls
@@ -1,9 +1,10 @@
 package server
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"github.com/quan-to/chevron/internal/cluster"
+	"github.com/quan-to/chevron/internal/config"
 	"github.com/quan-to/chevron/pkg/interfaces"
 	"github.com/quan-to/chevron/pkg/models"
 
@@ -12,9 +13,10 @@ import (
 )
 
 type InternalEndpoint struct {
-	sm  interfaces.SecretsManager
-	gpg interfaces.PGPManager
-	log slog.Instance
+	sm     interfaces.SecretsManager
+	gpg    interfaces.PGPManager
+	log    slog.Instance
+	nonces *cluster.NonceCache
 }
 
 // MakeInternalEndpoint creates an instance to handle internal control endpoints such as key password data
@@ -29,25 +31,42 @@ func MakeInternalEndpoint(log slog.Instance, sm interfaces.SecretsManager, gpg i
 		sm:  sm,
 		gpg: gpg,
 		log: log,
+		// A nonce only needs to be remembered while its timestamp is accepted
+		nonces: cluster.MakeNonceCache(2 * config.ClusterMaxClockSkew),
 	}
 }
 
 func (ie *InternalEndpoint) AttachHandlers(r *mux.Router) {
-	r.HandleFunc("/__triggerKeyUnlock", ie.triggerKeyUnlock)
-	r.HandleFunc("/__getUnlockPasswords", ie.getUnlockPasswords).Methods("GET")
+	r.HandleFunc("/__triggerKeyUnlock", ie.triggerKeyUnlock).Methods("POST")
+	r.HandleFunc("/__getUnlockPasswords", ie.getUnlockPasswords).Methods("POST")
 	r.HandleFunc("/__postEncryptedPasswords", ie.postUnlockPasswords).Methods("POST")
+	r.HandleFunc("/__passwordEvent", ie.passwordEvent).Methods("POST")
 }
 
 func (ie *InternalEndpoint) triggerKeyUnlock(w http.ResponseWriter, r *http.Request) {
 	ctx := wrapContextWithRequestID(r)
 	log := wrapLogWithRequestID(ie.log, r)
 
+	var req models.ClusterPasswordRequest
+
+	if !UnmarshalBodyOrDie(&req, w, r, log) {
+		return
+	}
+
 	defer func() {
 		if rec := recover(); rec != nil {
 			CatchAllError(rec, w, r, log)
 		}
 	}()
 
+	err := cluster.VerifyUnlockRequest(ctx, ie.sm, ie.nonces, req)
+
+	if err != nil {
+		log.Warn("Rejected unlock request: %s", err)
+		PermissionDenied("signature", err.Error(), w, r, log)
+		return
+	}
+
 	ie.sm.UnlockLocalKeys(ctx, ie.gpg)
 
 	w.Header().Set("Content-Type", models.MimeText)
@@ -59,28 +78,43 @@ func (ie *InternalEndpoint) getUnlockPasswords(w http.ResponseWriter, r *http.Re
 	ctx := wrapContextWithRequestID(r)
 	log := wrapLogWithRequestID(ie.log, r)
 
+	var req models.ClusterPasswordRequest
+
+	if !UnmarshalBodyOrDie(&req, w, r, log) {
+		return
+	}
+
 	defer func() {
 		if rec := recover(); rec != nil {
 			CatchAllError(rec, w, r, log)
 		}
 	}()
 
-	passwords := ie.sm.GetPasswords(ctx)
+	err := cluster.VerifyPasswordRequest(ctx, ie.sm, ie.nonces, req)
 
-	bodyData, _ := json.Marshal(passwords)
+	if err != nil {
+		log.Warn("Rejected password request: %s", err)
+		PermissionDenied("signature", err.Error(), w, r, log)
+		return
+	}
 
-	w.Header().Set("Content-Type", models.MimeJSON)
-	w.WriteHeader(200)
-	_, _ = w.Write(bodyData)
+	res, err := cluster.MakePasswordResponse(ctx, ie.sm, req)
+
+	if err != nil {
+		InternalServerError("Error signing passwords", err.Error(), w, r, log)
+		return
+	}
+
+	WriteJSON(res, 200, w, r, log)
 }
 
 func (ie *InternalEndpoint) postUnlockPasswords(w http.ResponseWriter, r *http.Request) {
 	ctx := wrapContextWithRequestID(r)
 	log := wrapLogWithRequestID(ie.log, r)
 
-	var passwords map[string]string
+	var post models.ClusterPasswordEvent
 
-	if !UnmarshalBodyOrDie(&passwords, w, r, log) {
+	if !UnmarshalBodyOrDie(&post, w, r, log) {
 		return
 	}
 
@@ -90,7 +124,15 @@ func (ie *InternalEndpoint) postUnlockPasswords(w http.ResponseWriter, r *http.R
 		}
 	}()
 
-	for k, v := range passwords {
+	payload, err := cluster.VerifyPasswordEvent(ctx, ie.sm, ie.nonces, post)
+
+	if err != nil {
+		log.Warn("Rejected encrypted passwords: %s", err)
+		PermissionDenied("signature", err.Error(), w, r, log)
+		return
+	}
+
+	for k, v := range payload.Passwords {
 		ie.sm.PutEncryptedPassword(ctx, k, v)
 	}
 
@@ -98,3 +140,32 @@ func (ie *InternalEndpoint) postUnlockPasswords(w http.ResponseWriter, r *http.R
 	w.WriteHeader(200)
 	_, _ = w.Write([]byte("OK"))
 }
+
+func (ie *InternalEndpoint) passwordEvent(w http.ResponseWriter, r *http.Request) {
+	ctx := wrapContextWithRequestID(r)
+	log := wrapLogWithRequestID(ie.log, r)
+
+	var event models.ClusterPasswordEvent
+
+	if !UnmarshalBodyOrDie(&event, w, r, log) {
+		return
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			CatchAllError(rec, w, r, log)
+		}
+	}()
+
+	err := cluster.ApplyPasswordEvent(ctx, ie.sm, ie.gpg, ie.nonces, event)
+
+	if err != nil {
+		log.Warn("Rejected password event: %s", err)
+		PermissionDenied("signature", err.Error(), w, r, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", models.MimeText)
+	w.WriteHeader(200)
+	_, _ = w.Write([]byte("OK"))
+}
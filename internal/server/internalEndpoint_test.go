@@ -9,8 +9,10 @@ import (
 	"net/http"
 	"testing"
 
+	"github.com/quan-to/chevron/internal/cluster"
 	remote_signer "github.com/quan-to/chevron/internal/config"
 	"github.com/quan-to/chevron/pkg/QuantoError"
+	"github.com/quan-to/chevron/pkg/models"
 	"github.com/quan-to/chevron/test"
 )
 
@@ -58,7 +60,13 @@ func TestGetUnlockPasswords(t *testing.T) {
 
 	sm.PutEncryptedPassword(ctx, test.TestKeyFingerprint, encPass)
 
-	req, err := http.NewRequest("GET", "/__internal/__getUnlockPasswords", nil)
+	passwordRequest, err := cluster.MakePasswordRequest(ctx, sm)
+
+	errorDie(err, t)
+
+	body, _ := json.Marshal(passwordRequest)
+
+	req, err := http.NewRequest("POST", "/__internal/__getUnlockPasswords", bytes.NewReader(body))
 
 	errorDie(err, t)
 
@@ -75,9 +83,13 @@ func TestGetUnlockPasswords(t *testing.T) {
 
 	errorDie(err, t)
 
-	var data map[string]string
+	var passwordResponse models.ClusterPasswordResponse
+
+	err = json.Unmarshal(d, &passwordResponse)
+
+	errorDie(err, t)
 
-	err = json.Unmarshal(d, &data)
+	data, err := cluster.VerifyPasswordResponse(ctx, sm, *passwordRequest, passwordResponse)
 
 	errorDie(err, t)
 
@@ -96,6 +108,48 @@ func TestGetUnlockPasswords(t *testing.T) {
 	if !found {
 		t.Errorf("The added password was not found at the password list")
 	}
+
+	// Replaying the same request should be rejected
+	req, err = http.NewRequest("POST", "/__internal/__getUnlockPasswords", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	res = executeRequest(req)
+
+	if res.Code == 200 {
+		t.Errorf("Expected replayed request to be rejected")
+	}
+}
+
+func TestGetUnlockPasswordsInvalidSignature(t *testing.T) {
+	ctx := context.Background()
+
+	passwordRequest, err := cluster.MakePasswordRequest(ctx, sm)
+
+	errorDie(err, t)
+
+	// Signature doesn't match the nonce anymore
+	passwordRequest.Nonce = "00000000000000000000000000000000"
+
+	body, _ := json.Marshal(passwordRequest)
+
+	req, err := http.NewRequest("POST", "/__internal/__getUnlockPasswords", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	res := executeRequest(req)
+
+	if res.Code == 200 {
+		t.Fatalf("Expected request with invalid signature to be rejected")
+	}
+
+	errObj, err := ReadErrorObject(res.Body)
+
+	errorDie(err, t)
+
+	if errObj.ErrorCode != QuantoError.PermissionDenied {
+		t.Errorf("Expected %s got %s", QuantoError.PermissionDenied, errObj.ErrorCode)
+	}
 }
 
 func TestPostUnlockPassword(t *testing.T) {
@@ -109,15 +163,28 @@ func TestPostUnlockPassword(t *testing.T) {
 		t.FailNow()
 	}
 
-	payload := map[string]string{
-		test.TestKeyFingerprint: encPass,
+	// Unsigned passwords are rejected
+	d, _ := json.Marshal(map[string]string{test.TestKeyFingerprint: encPass})
+
+	req, err := http.NewRequest("POST", "/__internal/__postEncryptedPasswords", bytes.NewReader(d))
+
+	errorDie(err, t)
+
+	errObj, err := ReadErrorObject(executeRequest(req).Body)
+
+	errorDie(err, t)
+
+	if errObj.ErrorCode != QuantoError.PermissionDenied {
+		t.Errorf("Expected %s got %s", QuantoError.PermissionDenied, errObj.ErrorCode)
 	}
 
-	d, _ := json.Marshal(payload)
+	post, err := cluster.MakePasswordPost(ctx, sm, map[string]string{test.TestKeyFingerprint: encPass})
 
-	r := bytes.NewReader(d)
+	errorDie(err, t)
+
+	d, _ = json.Marshal(post)
 
-	req, err := http.NewRequest("POST", "/__internal/__postEncryptedPasswords", r)
+	req, err = http.NewRequest("POST", "/__internal/__postEncryptedPasswords", bytes.NewReader(d))
 
 	errorDie(err, t)
 
@@ -145,7 +212,15 @@ func TestPostUnlockPassword(t *testing.T) {
 }
 
 func TestTriggerKeyUnlock(t *testing.T) {
-	req, err := http.NewRequest("POST", "/__internal/__triggerKeyUnlock", nil)
+	ctx := context.Background()
+
+	unlockReq, err := cluster.MakeUnlockRequest(ctx, sm)
+
+	errorDie(err, t)
+
+	body, _ := json.Marshal(unlockReq)
+
+	req, err := http.NewRequest("POST", "/__internal/__triggerKeyUnlock", bytes.NewReader(body))
 
 	errorDie(err, t)
 
@@ -167,4 +242,76 @@ func TestTriggerKeyUnlock(t *testing.T) {
 	}
 
 	// TODO: Check if the key was really unlocked
+
+	// Replayed requests, and password requests, are rejected
+	passwordReq, err := cluster.MakePasswordRequest(ctx, sm)
+
+	errorDie(err, t)
+
+	passwordBody, _ := json.Marshal(passwordReq)
+
+	for _, b := range [][]byte{body, passwordBody} {
+		req, err = http.NewRequest("POST", "/__internal/__triggerKeyUnlock", bytes.NewReader(b))
+
+		errorDie(err, t)
+
+		errObj, err := ReadErrorObject(executeRequest(req).Body)
+
+		errorDie(err, t)
+
+		if errObj.ErrorCode != QuantoError.PermissionDenied {
+			t.Errorf("Expected %s got %s", QuantoError.PermissionDenied, errObj.ErrorCode)
+		}
+	}
+}
+
+func TestPasswordEvent(t *testing.T) {
+	ctx := context.Background()
+	filename := fmt.Sprintf("key-password-utf8-%s.txt", test.TestKeyFingerprint)
+
+	encPass, err := gpg.Encrypt(ctx, filename, sm.GetMasterKeyFingerPrint(ctx), []byte(test.TestKeyPassword), remote_signer.SMEncryptedDataOnly)
+
+	errorDie(err, t)
+
+	// Events are only applied when they come from another node
+	localNode := remote_signer.ClusterNodeID
+	remote_signer.ClusterNodeID = "other-node"
+	event, err := cluster.MakePasswordEvent(ctx, sm, "0000000000000005", encPass)
+	remote_signer.ClusterNodeID = localNode
+
+	errorDie(err, t)
+
+	body, _ := json.Marshal(event)
+
+	req, err := http.NewRequest("POST", "/__internal/__passwordEvent", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	res := executeRequest(req)
+
+	d, err := ioutil.ReadAll(res.Body)
+
+	if res.Code != 200 {
+		var errObj QuantoError.ErrorObject
+		err := json.Unmarshal(d, &errObj)
+		errorDie(err, t)
+		errorDie(fmt.Errorf(errObj.Message), t)
+	}
+
+	errorDie(err, t)
+
+	if sm.GetPasswords(ctx)["0000000000000005"] != encPass {
+		t.Errorf("Expected event password to be stored")
+	}
+
+	// Replaying the same event should be rejected
+	req, err = http.NewRequest("POST", "/__internal/__passwordEvent", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	res = executeRequest(req)
+
+	if res.Code == 200 {
+		t.Errorf("Expected replayed event to be rejected")
+	}
 }
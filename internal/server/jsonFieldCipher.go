@@ -1,23 +1,30 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/internal/keymagic"
+	"github.com/quan-to/chevron/pkg/QuantoError"
 
 	"github.com/quan-to/chevron/pkg/fieldcipher"
 	"github.com/quan-to/chevron/pkg/interfaces"
 	"github.com/quan-to/chevron/pkg/models"
 
 	"github.com/gorilla/mux"
-	"github.com/quan-to/chevron/pkg/openpgp"
 	"github.com/quan-to/slog"
 )
 
 type JFCEndpoint struct {
-	sm  interfaces.SecretsManager
-	gpg interfaces.PGPManager
-	log slog.Instance
+	sync.Mutex
+	sm      interfaces.SecretsManager
+	gpg     interfaces.PGPManager
+	log     slog.Instance
+	indexer *fieldcipher.BlindIndexer
 }
 
 // MakeJFCEndpoint creates a handler for Json Field Cipher Endpoints
@@ -38,12 +45,39 @@ func MakeJFCEndpoint(log slog.Instance, sm interfaces.SecretsManager, gpg interf
 func (jfc *JFCEndpoint) AttachHandlers(r *mux.Router) {
 	r.HandleFunc("/cipher", jfc.cipher).Methods("POST")
 	r.HandleFunc("/decipher", jfc.decipher).Methods("POST")
+	r.HandleFunc("/blindIndex", jfc.blindIndex).Methods("POST")
+}
+
+// blindIndexKeyPurpose is the purpose of the master key derived blind index key
+const blindIndexKeyPurpose = "fieldcipher-blind-index"
+
+// getBlindIndexer returns the blind indexer with the key derived from the master key
+func (jfc *JFCEndpoint) getBlindIndexer(ctx context.Context) (*fieldcipher.BlindIndexer, error) {
+	jfc.Lock()
+	defer jfc.Unlock()
+
+	if jfc.indexer != nil {
+		return jfc.indexer, nil
+	}
+
+	if !config.BlindIndexEnabled {
+		return nil, fmt.Errorf("blind index is disabled. Set BLIND_INDEX_ENABLED to enable it")
+	}
+
+	key, err := jfc.sm.DeriveMasterKeySecret(ctx, blindIndexKeyPurpose)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving blind index key: %s", err)
+	}
+
+	jfc.indexer, err = fieldcipher.MakeBlindIndexer(key)
+
+	return jfc.indexer, err
 }
 
 // Field Cipher godoc
 // @id field-cipher-cipher
 // @tags Field Cipher
-// @Summary Encrypts JSON fields to specified GPG keys
+// @Summary Encrypts JSON fields to specified GPG keys, optionally signing the packet
 // @Accept json
 // @Produce json
 // @param message body models.FieldCipherInput true "The encryption parameters"
@@ -66,26 +100,15 @@ func (jfc *JFCEndpoint) cipher(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	keys := make([]*openpgp.Entity, 0)
-
-	for i, v := range data.Keys {
-		k := jfc.gpg.GetPublicKeyEntity(ctx, v)
-		if k == nil {
-			NotFound(fmt.Sprintf("data.Keys[%d]", i), fmt.Sprintf("publickey for fingerPrint %s was not found", v), w, r, log)
-			return
-		}
-		keys = append(keys, k)
-	}
+	packet, err := keymagic.FieldCipherPacket(ctx, jfc.gpg, data, func() (*fieldcipher.BlindIndexer, error) {
+		return jfc.getBlindIndexer(ctx)
+	})
 
-	if len(keys) == 0 {
-		InvalidFieldData("data.Keys", "no keys specified", w, r, log)
+	if qe, ok := err.(*QuantoError.ErrorObject); ok {
+		WriteJSON(qe, 400, w, r, log)
 		return
 	}
 
-	cipher := fieldcipher.MakeCipher(keys)
-
-	packet, err := cipher.GenerateEncryptedPacket(data.JSON, data.SkipFields)
-
 	if err != nil {
 		InternalServerError(err.Error(), err, w, r, log)
 		return
@@ -101,7 +124,7 @@ func (jfc *JFCEndpoint) cipher(w http.ResponseWriter, r *http.Request) {
 // Field Decipher godoc
 // @id field-cipher-decipher
 // @tags Field Cipher
-// @Summary Decrypts JSON fields from specified GPG keys.
+// @Summary Decrypts JSON fields from specified GPG keys. In partial mode returns the readable fields and the status of each field. Signed packets are verified and the signer is reported.
 // @Accept json
 // @Produce json
 // @param message body models.FieldDecipherInput true "The decryption parameters"
@@ -124,28 +147,17 @@ func (jfc *JFCEndpoint) decipher(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	keys := jfc.gpg.GetPrivate(ctx, data.KeyFingerprint)
-	if len(keys) == 0 {
-		NotFound("keyFingerprint", fmt.Sprintf("There is no such key %s or its not decrypted.", data.KeyFingerprint), w, r, log)
-		return
-	}
-
-	decipher, err := fieldcipher.MakeDecipher(keys)
+	dec, err := keymagic.FieldDecipherPacket(ctx, jfc.gpg, data)
 
-	if err != nil {
+	if qe, ok := err.(*QuantoError.ErrorObject); ok {
 		log.Error(err)
-		InternalServerError("Error processing your request. Please try again.", err, w, r, log)
+		WriteJSON(qe, 400, w, r, log)
 		return
 	}
 
-	dec, err := decipher.DecipherPacket(fieldcipher.CipherPacket{
-		EncryptedKey:  data.EncryptedKey,
-		EncryptedJSON: data.EncryptedJSON,
-	})
-
 	if err != nil {
 		log.Error(err)
-		InvalidFieldData("payload", err.Error(), w, r, log)
+		InternalServerError("Error processing your request. Please try again.", err, w, r, log)
 		return
 	}
 
@@ -155,3 +167,47 @@ func (jfc *JFCEndpoint) decipher(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(200)
 	_, _ = w.Write([]byte(d))
 }
+
+// Field Blind Index godoc
+// @id field-cipher-blind-index
+// @tags Field Cipher
+// @Summary Computes the blind index of a value, to search fields encrypted with BlindIndex by equality
+// @Accept json
+// @Produce json
+// @param message body models.FieldBlindIndexInput true "The field and the value to index"
+// @Success 200 {object} models.FieldBlindIndexOutput
+// @Failure default {object} QuantoError.ErrorObject
+// @Router /fieldCipher/blindIndex [post]
+func (jfc *JFCEndpoint) blindIndex(w http.ResponseWriter, r *http.Request) {
+	ctx := wrapContextWithRequestID(r)
+	log := wrapLogWithRequestID(jfc.log, r)
+
+	var data models.FieldBlindIndexInput
+
+	if !UnmarshalBodyOrDie(&data, w, r, log) {
+		return
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			CatchAllError(rec, w, r, log)
+		}
+	}()
+
+	indexer, err := jfc.getBlindIndexer(ctx)
+	if err != nil {
+		InvalidFieldData("BlindIndex", err.Error(), w, r, log)
+		return
+	}
+
+	idx, err := indexer.Compute(data.Field, data.Value)
+	if err != nil {
+		InvalidFieldData("data", err.Error(), w, r, log)
+		return
+	}
+
+	WriteJSON(models.FieldBlindIndexOutput{
+		Field:      data.Field,
+		BlindIndex: idx,
+	}, 200, w, r, log)
+}
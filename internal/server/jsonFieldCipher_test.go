@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/quan-to/chevron/internal/config"
+	"github.com/quan-to/chevron/pkg/QuantoError"
+	"github.com/quan-to/chevron/pkg/fieldcipher"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/test"
+)
+
+func postFieldCipher(endpoint string, payload, out interface{}, t *testing.T) {
+	body, _ := json.Marshal(payload)
+
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	errorDie(err, t)
+
+	res := executeRequest(req)
+
+	d, err := ioutil.ReadAll(res.Body)
+	errorDie(err, t)
+
+	if res.Code != 200 {
+		var errObj QuantoError.ErrorObject
+		err := json.Unmarshal(d, &errObj)
+		errorDie(err, t)
+		errorDie(fmt.Errorf(errObj.Message), t)
+	}
+
+	errorDie(json.Unmarshal(d, out), t)
+}
+
+func TestFieldCipherSigned(t *testing.T) {
+	var packet fieldcipher.CipherPacket
+
+	postFieldCipher("/fieldCipher/cipher", models.FieldCipherInput{
+		JSON:     map[string]interface{}{"a": "b", "c": 1.5},
+		Keys:     []string{test.TestKeyFingerprint},
+		SignWith: test.TestKeyFingerprint,
+	}, &packet, t)
+
+	if packet.Signature == "" {
+		errorDie(fmt.Errorf("expected packet to be signed"), t)
+	}
+
+	input := models.FieldDecipherInput{
+		Version:        packet.Version,
+		KeyFingerprint: test.TestKeyFingerprint,
+		EncryptedKey:   packet.EncryptedKey,
+		EncryptedJSON:  packet.EncryptedJSON,
+		Signature:      packet.Signature,
+	}
+
+	var dec fieldcipher.DecipherPacket
+
+	postFieldCipher("/fieldCipher/decipher", input, &dec, t)
+
+	if !dec.SignatureValid || dec.SignerFingerprint != test.TestKeyFingerprint {
+		errorDie(fmt.Errorf("expected valid signature from %s got %+v", test.TestKeyFingerprint, dec), t)
+	}
+
+	if dec.DecryptedData["a"] != "b" {
+		errorDie(fmt.Errorf("expected /a/ to be b got %v", dec.DecryptedData["a"]), t)
+	}
+
+	input.EncryptedJSON["d"] = "forged"
+
+	postFieldCipher("/fieldCipher/decipher", input, &dec, t)
+
+	if !dec.Signed || dec.SignatureValid {
+		errorDie(fmt.Errorf("expected forged packet signature to be invalid"), t)
+	}
+}
+
+func TestFieldCipherBlindIndex(t *testing.T) {
+	ctx := context.Background()
+
+	config.PushVariables()
+	defer config.PopVariables()
+
+	config.BlindIndexEnabled = true
+
+	var packet fieldcipher.CipherPacket
+
+	postFieldCipher("/fieldCipher/cipher", models.FieldCipherInput{
+		JSON:       map[string]interface{}{"customer": map[string]interface{}{"taxId": "12345"}},
+		Keys:       []string{test.TestKeyFingerprint},
+		BlindIndex: []string{"$.customer.taxId"},
+	}, &packet, t)
+
+	var idx models.FieldBlindIndexOutput
+
+	postFieldCipher("/fieldCipher/blindIndex", models.FieldBlindIndexInput{
+		Field: "$.customer.taxId",
+		Value: "12345",
+	}, &idx, t)
+
+	customer := packet.EncryptedJSON["customer"].(map[string]interface{})
+	if idx.BlindIndex == "" || customer["taxId_bidx"] != idx.BlindIndex {
+		errorDie(fmt.Errorf("expected blind index %s got %v", idx.BlindIndex, customer["taxId_bidx"]), t)
+	}
+
+	// A new endpoint, like the one of another node, should derive the same key
+	indexer, err := MakeJFCEndpoint(nil, sm, gpg).getBlindIndexer(ctx)
+	errorDie(err, t)
+
+	reloaded, _ := indexer.Compute("$.customer.taxId", "12345")
+	if reloaded != idx.BlindIndex {
+		errorDie(fmt.Errorf("expected stored key to generate the same blind index"), t)
+	}
+}
@@ -247,8 +247,12 @@ func (kre *KeyRingEndpoint) addPrivateKey(w http.ResponseWriter, r *http.Request
 	pubKey, _ := kre.gpg.GetPublicKeyASCII(ctx, fp)
 
 	log.Info("Adding public key for %s on PKS", fp)
-	res := keymagic.PKSAdd(ctx, pubKey)
-	log.Info("PKS Add Key: %s", res)
+	res, pksErr := keymagic.PKSMergeVerified(ctx, pubKey)
+	if pksErr != nil {
+		log.Error("PKS Add Key: %s", pksErr)
+	} else {
+		log.Info("PKS Add Key: %s", res)
+	}
 
 	if data.SaveToDisk {
 		err = kre.gpg.SaveKey(fingerPrint, data.EncryptedPrivateKey, data.Password)
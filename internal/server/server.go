@@ -71,7 +71,7 @@ func GenRemoteSignerServerMux(slog slog.Instance, sm interfaces.SecretsManager,
 	am := agent.MakeAuthManager(log, dbh)
 	ap := MakeAgentProxy(log, gpg, tm)
 	sGql := MakeStaticGraphiQL(log)
-	agentAdmin := MakeAgentAdmin(log, tm, am)
+	agentAdmin := MakeAgentAdmin(log, tm, am, dbh)
 	jfc := MakeJFCEndpoint(log, sm, gpg)
 
 	if ge == nil || ie == nil || te == nil || kre == nil || sks == nil || tm == nil || am == nil || ap == nil || agentAdmin == nil {
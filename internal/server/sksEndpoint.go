@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/quan-to/chevron/internal/agent"
 
 	"github.com/quan-to/chevron/internal/keymagic"
+	"github.com/quan-to/chevron/pkg/database/search"
 	"github.com/quan-to/chevron/pkg/interfaces"
 	"github.com/quan-to/chevron/pkg/models"
 
@@ -46,7 +48,10 @@ func (sks *SKSEndpoint) AttachHandlers(r *mux.Router) {
 	r.HandleFunc("/searchByFingerPrint", sks.searchByFingerPrint).Methods("GET")
 	r.HandleFunc("/searchByEmail", sks.searchByEmail).Methods("GET")
 	r.HandleFunc("/search", sks.search).Methods("GET")
+	r.HandleFunc("/searchRanked", sks.searchRanked).Methods("GET")
 	r.HandleFunc("/addKey", sks.addKey).Methods("POST")
+	r.HandleFunc("/deleteKey", sks.deleteKey).Methods("POST")
+	r.HandleFunc("/verifyEmail", sks.verifyEmail).Methods("GET")
 }
 
 // Get GPG Key godoc
@@ -74,6 +79,10 @@ func (sks *SKSEndpoint) getKey(w http.ResponseWriter, r *http.Request) {
 	fingerPrint := q.Get("fingerPrint")
 	key, _ := sks.gpg.GetPublicKeyASCII(ctx, fingerPrint)
 
+	if key != "" {
+		key, _ = keymagic.PKSPublishedKey(ctx, key)
+	}
+
 	if key == "" {
 		NotFound("fingerPrint", fmt.Sprintf("Key with fingerPrint %s was not found", fingerPrint), w, r, log)
 		return
@@ -328,14 +337,82 @@ func (sks *SKSEndpoint) search(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(bodyData)
 }
 
+// Ranked Search GPG Key godoc
+// @id pks-search-ranked
+// @tags Public Key Server, Key Store
+// @Summary Searches for GPG Keys by name, email or fingerprint ranked by relevance
+// @Produce json
+// @param q query string true "Words, word prefixes or fingerprint to search"
+// @param cursor query string false "Cursor of the next page returned by the previous search"
+// @param limit query int false "Maximum number of keys in the page (default: 20, max: 100)"
+// @Success 200 {object} models.GPGKeySearchResult
+// @Failure default {object} QuantoError.ErrorObject
+// @Router /sks/searchRanked [get]
+func (sks *SKSEndpoint) searchRanked(w http.ResponseWriter, r *http.Request) {
+	log := wrapLogWithRequestID(sks.log, r)
+	ctx := wrapContextWithRequestID(r)
+	ctx = wrapContextWithDatabaseHandler(sks.dbh, ctx)
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			CatchAllError(rec, w, r, log)
+		}
+	}()
+
+	q := r.URL.Query()
+	query := models.GPGKeySearchQuery{
+		Value:  q.Get("q"),
+		Cursor: q.Get("cursor"),
+	}
+
+	if query.Value == "" {
+		InvalidFieldData("q", "you should provide a q", w, r, log)
+		return
+	}
+
+	if limitS := q.Get("limit"); limitS != "" {
+		limit, err := strconv.ParseInt(limitS, 10, 32)
+		if err != nil || limit <= 0 {
+			InvalidFieldData("limit", "limit should be a positive number", w, r, log)
+			return
+		}
+		query.Limit = int(limit)
+	}
+
+	if query.Cursor != "" {
+		if _, _, err := search.DecodeCursor(query.Cursor); err != nil {
+			InvalidFieldData("cursor", err.Error(), w, r, log)
+			return
+		}
+	}
+
+	result, err := keymagic.PKSSearchRanked(ctx, query)
+
+	if err != nil {
+		NotFound("q", err.Error(), w, r, log)
+		return
+	}
+
+	bodyData, err := json.Marshal(result)
+
+	if err != nil {
+		InternalServerError("There was an internal server error. Please try again", nil, w, r, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", models.MimeJSON)
+	w.WriteHeader(200)
+	_, _ = w.Write(bodyData)
+}
+
 // Add Public Key godoc
 // @id pks-add-public-key
 // @tags Public Key Server, Key Store
-// @Summary Adds a GPG Public Key
+// @Summary Adds a GPG Public Key or merges it with the stored key
 // @Accept json
 // @Produce plain
 // @Param message body models.SKSAddKey true "GPG Public Key in an Armored format"
-// @Success 200 {string} result "OK"
+// @Success 200 {object} models.KeyMergeResult "Returns OK, or what changed in the stored key with Accept: application/json"
 // @Failure default {object} QuantoError.ErrorObject
 // @Router /sks/addKey [post]
 func (sks *SKSEndpoint) addKey(w http.ResponseWriter, r *http.Request) {
@@ -355,14 +432,109 @@ func (sks *SKSEndpoint) addKey(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	status := keymagic.PKSAdd(ctx, data.PublicKey)
+	result, err := keymagic.PKSMerge(ctx, data.PublicKey)
 
-	if status != "OK" {
+	if err != nil {
 		InvalidFieldData("PublicKey", "Invalid Public Key specified. Check if its in ASCII Armored Format", w, r, log)
 		return
 	}
 
+	log.Info("PKS Add Key: %s", result)
+
+	writeKeyMergeResult(result, w, r)
+}
+
+// writeKeyMergeResult writes what changed in the stored key when requested by the Accept header, otherwise the body is OK
+func writeKeyMergeResult(result *models.KeyMergeResult, w http.ResponseWriter, r *http.Request) {
+	if strings.Contains(r.Header.Get("Accept"), models.MimeJSON) {
+		d, _ := json.Marshal(result)
+
+		w.Header().Set("Content-Type", models.MimeJSON)
+		w.WriteHeader(200)
+		_, _ = w.Write(d)
+		return
+	}
+
 	w.Header().Set("Content-Type", models.MimeText)
 	w.WriteHeader(200)
 	_, _ = w.Write([]byte("OK"))
 }
+
+// Delete Public Key godoc
+// @id pks-delete-public-key
+// @tags Public Key Server, Key Store
+// @Summary Removes a GPG Public Key, or only some of its user ids, by a request signed by the key
+// @Accept json
+// @Produce json
+// @Param message body models.KeyDeletionRequest true "Deletion request with a detached signature of its SignedData"
+// @Success 200 {object} models.KeyDeletionResult
+// @Failure default {object} QuantoError.ErrorObject
+// @Router /sks/deleteKey [post]
+func (sks *SKSEndpoint) deleteKey(w http.ResponseWriter, r *http.Request) {
+	ctx := wrapContextWithRequestID(r)
+	log := wrapLogWithRequestID(sks.log, r)
+	ctx = wrapContextWithDatabaseHandler(sks.dbh, ctx)
+
+	var data models.KeyDeletionRequest
+
+	if !UnmarshalBodyOrDie(&data, w, r, log) {
+		return
+	}
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			CatchAllError(rec, w, r, log)
+		}
+	}()
+
+	result, err := keymagic.PKSDeleteKey(ctx, data)
+
+	if err != nil {
+		if strings.EqualFold(err.Error(), "not found") {
+			NotFound("FingerPrint", fmt.Sprintf("Key %s not found", data.FingerPrint), w, r, log)
+			return
+		}
+		InvalidFieldData("KeyDeletionRequest", err.Error(), w, r, log)
+		return
+	}
+
+	log.Info("PKS Delete Key: %s", result)
+
+	d, _ := json.Marshal(result)
+
+	w.Header().Set("Content-Type", models.MimeJSON)
+	w.WriteHeader(200)
+	_, _ = w.Write(d)
+}
+
+// Verify Email godoc
+// @id pks-verify-email
+// @tags Public Key Server, Key Store
+// @Summary Publishes the user ids of a email using the token sent to it
+// @Produce plain
+// @param token query string true "Verification token received by email"
+// @Success 200 {string} result "Email john@huebr.com verified for key 0551F452ABE463A4"
+// @Failure default {object} QuantoError.ErrorObject
+// @Router /sks/verifyEmail [get]
+func (sks *SKSEndpoint) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := wrapContextWithRequestID(r)
+	log := wrapLogWithRequestID(sks.log, r)
+	ctx = wrapContextWithDatabaseHandler(sks.dbh, ctx)
+
+	defer func() {
+		if rec := recover(); rec != nil {
+			CatchAllError(rec, w, r, log)
+		}
+	}()
+
+	fingerPrint, email, err := keymagic.PKSVerifyEmail(ctx, r.URL.Query().Get("token"))
+
+	if err != nil {
+		InvalidFieldData("token", err.Error(), w, r, log)
+		return
+	}
+
+	w.Header().Set("Content-Type", models.MimeText)
+	w.WriteHeader(200)
+	_, _ = w.Write([]byte(fmt.Sprintf("Email %s verified for key %s", email, fingerPrint)))
+}
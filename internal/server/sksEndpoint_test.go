@@ -200,6 +200,27 @@ func TestAddKey(t *testing.T) {
 		errorDie(fmt.Errorf("expected OK got %s", string(d)), t)
 	}
 	// endregion
+	// region Test Add Key with merge result
+	req, err = http.NewRequest("POST", "/sks/addKey", bytes.NewReader(body))
+
+	errorDie(err, t)
+
+	req.Header.Set("Accept", models.MimeJSON)
+
+	res = executeRequest(req)
+
+	d, err = ioutil.ReadAll(res.Body)
+
+	errorDie(err, t)
+
+	var result models.KeyMergeResult
+
+	errorDie(json.Unmarshal(d, &result), t)
+
+	if result.FingerPrint != test.TestKeyFingerprint {
+		errorDie(fmt.Errorf("expected fingerprint %s got %s", test.TestKeyFingerprint, result.FingerPrint), t)
+	}
+	// endregion
 	// region Test Add Invalid Key
 	payload.PublicKey = "huebrbrbrbrbr"
 	body, _ = json.Marshal(payload)
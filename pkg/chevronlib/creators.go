@@ -7,6 +7,7 @@ import (
 
 	"github.com/quan-to/chevron/internal/keybackend"
 	"github.com/quan-to/chevron/internal/keymagic"
+	"github.com/quan-to/chevron/pkg/QuantoError"
 	"github.com/quan-to/chevron/pkg/interfaces"
 	"github.com/quan-to/chevron/pkg/openpgp"
 	"github.com/quan-to/chevron/pkg/openpgp/packet"
@@ -46,7 +47,7 @@ func GetStreamEncrypter(signerFingerprint string, encryptToFingerprints []string
 			}
 		}
 		if signer == nil {
-			return nil, fmt.Errorf("cannot find private key with fingerprint %s", signerFingerprint)
+			return nil, QuantoError.New(QuantoError.NotFound, "signerFingerprint", fmt.Sprintf("cannot find private key with fingerprint %s", signerFingerprint), nil)
 		}
 		if signer.PrivateKey.Encrypted {
 			return nil, fmt.Errorf("found private key %s but it's encrypted", signerFingerprint)
@@ -56,7 +57,7 @@ func GetStreamEncrypter(signerFingerprint string, encryptToFingerprints []string
 	for _, pubKeyFp := range encryptToFingerprints {
 		pub := GetPublicKeyEntity(pubKeyFp)
 		if pub == nil {
-			return nil, fmt.Errorf("cannot find public key with fingerprint %s", pubKeyFp)
+			return nil, QuantoError.New(QuantoError.NotFound, "encryptToFingerprints", fmt.Sprintf("cannot find public key with fingerprint %s", pubKeyFp), nil)
 		}
 		pubkeys = append(pubkeys, pub)
 	}
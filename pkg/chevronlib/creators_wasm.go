@@ -0,0 +1,16 @@
+// +build js,wasm
+
+package chevronlib
+
+import (
+	"github.com/quan-to/chevron/internal/keybackend"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/slog"
+)
+
+// MakeIndexedDBBackend creates an instance of a StorageBackend that
+// saves the keys in the specified browser IndexedDB database with the specified prefix
+// log instance can be nil
+func MakeIndexedDBBackend(log slog.Instance, database, prefix string) interfaces.StorageBackend {
+	return keybackend.MakeIndexedDBBackend(log, database, prefix)
+}
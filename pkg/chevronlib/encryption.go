@@ -0,0 +1,50 @@
+package chevronlib
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// Encrypt encrypts data to a already loaded public key and returns it ASCII Armored
+// export Encrypt
+func Encrypt(data []byte, filename, fingerprint string) (result string, err error) {
+	return pgpBackend.Encrypt(ctx, filename, fingerprint, data, false)
+}
+
+// EncryptBase64Data encrypts data to a already loaded public key and returns it ASCII Armored.
+// The b64data is a raw binary data encoded in base64 string
+// export EncryptBase64Data
+func EncryptBase64Data(b64data, filename, fingerprint string) (result string, err error) {
+	var data []byte
+	data, err = base64.StdEncoding.DecodeString(b64data)
+	if err != nil {
+		return
+	}
+
+	return Encrypt(data, filename, fingerprint)
+}
+
+// Decrypt decrypts a ASCII Armored message using any already loaded and unlocked private key
+// export Decrypt
+func Decrypt(encryptedData string) (result *models.GPGDecryptedData, err error) {
+	return pgpBackend.Decrypt(ctx, encryptedData, false)
+}
+
+// DecryptData decrypts a ASCII Armored message and returns the raw decrypted data and its filename
+func DecryptData(encryptedData string) (data []byte, filename string, err error) {
+	var dec *models.GPGDecryptedData
+	dec, err = Decrypt(encryptedData)
+	if err != nil {
+		return
+	}
+
+	data, err = base64.StdEncoding.DecodeString(dec.Base64Data)
+	if err != nil {
+		err = fmt.Errorf("error decoding decrypted data: %s", err)
+		return
+	}
+
+	return data, dec.Filename, nil
+}
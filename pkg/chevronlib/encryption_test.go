@@ -0,0 +1,63 @@
+package chevronlib
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	_, _ = LoadKey(testKey)
+	_ = UnlockKey(testKeyFingerprint, testKeyPassword)
+
+	encrypted, err := Encrypt([]byte(payloadToSign), "test.txt", testKeyFingerprint)
+	if err != nil {
+		t.Fatalf("Expected encryption to work but got %q", err)
+	}
+
+	data, filename, err := DecryptData(encrypted)
+	if err != nil {
+		t.Fatalf("Expected decryption to work but got %q", err)
+	}
+
+	if string(data) != payloadToSign {
+		t.Errorf("Expected decrypted data to be %q but got %q", payloadToSign, string(data))
+	}
+
+	if filename != "test.txt" {
+		t.Errorf("Expected filename to be test.txt but got %q", filename)
+	}
+
+	_, err = Encrypt([]byte(payloadToSign), "test.txt", "0000000000000000")
+	if err == nil {
+		t.Error("Expected encryption to an unknown key to fail")
+	}
+
+	_, err = Decrypt("huebr")
+	if err == nil {
+		t.Error("Expected decryption of \"huebr\" to fail")
+	}
+}
+
+func TestEncryptBase64Data(t *testing.T) {
+	_, _ = LoadKey(testKey)
+	_ = UnlockKey(testKeyFingerprint, testKeyPassword)
+
+	encrypted, err := EncryptBase64Data(base64.StdEncoding.EncodeToString([]byte(payloadToSign)), "", testKeyFingerprint)
+	if err != nil {
+		t.Fatalf("Expected encryption to work but got %q", err)
+	}
+
+	dec, err := Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Expected decryption to work but got %q", err)
+	}
+
+	if dec.Base64Data != base64.StdEncoding.EncodeToString([]byte(payloadToSign)) {
+		t.Errorf("Expected decrypted data to be %q but got %q", payloadToSign, dec.Base64Data)
+	}
+
+	_, err = EncryptBase64Data("#$%", "", testKeyFingerprint)
+	if err == nil {
+		t.Error("Expected invalid base64 to fail")
+	}
+}
@@ -0,0 +1,62 @@
+package chevronlib
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/quan-to/chevron/internal/keymagic"
+	"github.com/quan-to/chevron/pkg/fieldcipher"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+var indexerLock sync.Mutex
+var indexer *fieldcipher.BlindIndexer
+
+// SetBlindIndexKey sets the secret key used to compute fieldcipher blind indexes. Should have at least 32 bytes
+// export SetBlindIndexKey
+func SetBlindIndexKey(key []byte) error {
+	bi, err := fieldcipher.MakeBlindIndexer(key)
+	if err != nil {
+		return err
+	}
+
+	indexerLock.Lock()
+	indexer = bi
+	indexerLock.Unlock()
+
+	return nil
+}
+
+func getBlindIndexer() (*fieldcipher.BlindIndexer, error) {
+	indexerLock.Lock()
+	defer indexerLock.Unlock()
+
+	if indexer == nil {
+		return nil, fmt.Errorf("no blind index key. Call SetBlindIndexKey first")
+	}
+
+	return indexer, nil
+}
+
+// ComputeBlindIndex returns the blind index of value for the field selected by the JSONPath pattern
+// export ComputeBlindIndex
+func ComputeBlindIndex(field string, value interface{}) (string, error) {
+	bi, err := getBlindIndexer()
+	if err != nil {
+		return "", err
+	}
+
+	return bi.Compute(field, value)
+}
+
+// FieldCipher encrypts the JSON fields of input to already loaded public keys
+// export FieldCipher
+func FieldCipher(input models.FieldCipherInput) (*fieldcipher.CipherPacket, error) {
+	return keymagic.FieldCipherPacket(ctx, pgpBackend, input, getBlindIndexer)
+}
+
+// FieldDecipher decrypts a fieldcipher packet using a already loaded and unlocked private key
+// export FieldDecipher
+func FieldDecipher(input models.FieldDecipherInput) (*fieldcipher.DecipherPacket, error) {
+	return keymagic.FieldDecipherPacket(ctx, pgpBackend, input)
+}
@@ -0,0 +1,98 @@
+package chevronlib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/quan-to/chevron/pkg/fieldcipher"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+func TestFieldCipher(t *testing.T) {
+	_, _ = LoadKey(testKey)
+	_ = UnlockKey(testKeyFingerprint, testKeyPassword)
+
+	packet, err := FieldCipher(models.FieldCipherInput{
+		JSON: map[string]interface{}{
+			"name":  "huebr",
+			"taxId": "12345678900",
+		},
+		Keys:     []string{testKeyFingerprint},
+		Exclude:  []string{"$.name"},
+		SignWith: testKeyFingerprint,
+	})
+
+	if err != nil {
+		t.Fatalf("Expected field cipher to work but got %q", err)
+	}
+
+	if packet.EncryptedJSON["name"] != "huebr" {
+		t.Errorf("Expected excluded field to be plain text but got %v", packet.EncryptedJSON["name"])
+	}
+
+	if packet.EncryptedJSON["taxId"] == "12345678900" {
+		t.Error("Expected taxId to be encrypted")
+	}
+
+	dec, err := FieldDecipher(models.FieldDecipherInput{
+		Version:        packet.Version,
+		KeyFingerprint: testKeyFingerprint,
+		EncryptedKey:   packet.EncryptedKey,
+		EncryptedJSON:  packet.EncryptedJSON,
+		Signature:      packet.Signature,
+	})
+
+	if err != nil {
+		t.Fatalf("Expected field decipher to work but got %q", err)
+	}
+
+	if dec.DecryptedData["taxId"] != "12345678900" {
+		t.Errorf("Expected taxId to be 12345678900 but got %v", dec.DecryptedData["taxId"])
+	}
+
+	if !dec.SignatureValid || dec.SignerFingerprint != testKeyFingerprint {
+		t.Errorf("Expected a valid signature from %s but got %v from %q", testKeyFingerprint, dec.SignatureValid, dec.SignerFingerprint)
+	}
+
+	_, err = FieldCipher(models.FieldCipherInput{
+		JSON: map[string]interface{}{"name": "huebr"},
+		Keys: []string{"0000000000000000"},
+	})
+
+	if err == nil {
+		t.Error("Expected field cipher to an unknown key to fail")
+	}
+}
+
+func TestFieldCipherBlindIndex(t *testing.T) {
+	_, _ = LoadKey(testKey)
+
+	err := SetBlindIndexKey([]byte("short"))
+	if err == nil {
+		t.Error("Expected a short blind index key to fail")
+	}
+
+	err = SetBlindIndexKey([]byte(strings.Repeat("k", 32)))
+	if err != nil {
+		t.Fatalf("Unexpected error setting blind index key: %q", err)
+	}
+
+	packet, err := FieldCipher(models.FieldCipherInput{
+		JSON:       map[string]interface{}{"taxId": "12345678900"},
+		Keys:       []string{testKeyFingerprint},
+		BlindIndex: []string{"$.taxId"},
+	})
+
+	if err != nil {
+		t.Fatalf("Expected field cipher to work but got %q", err)
+	}
+
+	idx, err := ComputeBlindIndex("$.taxId", "12345678900")
+	if err != nil {
+		t.Fatalf("Unexpected error computing blind index: %q", err)
+	}
+
+	if packet.EncryptedJSON["taxId"+fieldcipher.BlindIndexSuffix] != idx {
+		t.Errorf("Expected blind index to be %s but got %v", idx, packet.EncryptedJSON["taxId"+fieldcipher.BlindIndexSuffix])
+	}
+}
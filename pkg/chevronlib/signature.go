@@ -22,7 +22,12 @@ func UnlockKey(fingerprint, password string) (err error) {
 // VerifySignature verifies a signature using a already loaded public key
 // export VerifySignature
 func VerifySignature(data []byte, signature string) (result bool, err error) {
-	return pgpBackend.VerifySignature(ctx, data, signature)
+	r, err := pgpBackend.VerifySignature(ctx, data, signature)
+	if err != nil {
+		return false, err
+	}
+
+	return r.Valid, nil
 }
 
 // QuantoVerifySignature verifies a signature in Quanto Signature Format using a already loaded public key
@@ -30,7 +35,7 @@ func VerifySignature(data []byte, signature string) (result bool, err error) {
 func QuantoVerifySignature(data []byte, signature string) (result bool, err error) {
 	signature = tools.Quanto2GPG(signature)
 
-	return pgpBackend.VerifySignature(ctx, data, signature)
+	return VerifySignature(data, signature)
 }
 
 // QuantoVerifyBase64DataSignature verifies a signature using a already loaded public key.
@@ -0,0 +1,42 @@
+package chevronlib
+
+import (
+	"github.com/quan-to/chevron/internal/keymagic"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/interfaces"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// SetStorageBackend makes ChevronLib persist the keys saved with SaveKey in storage and loads the keys already stored there
+// The private keys loaded before are discarded, so it should be called before any other ChevronLib call
+func SetStorageBackend(storage interfaces.StorageBackend) {
+	pgpBackend = keymagic.MakePGPManager(nil, storage, keymagic.MakeKeyRingManager(nil, mem))
+	pgpBackend.LoadKeys(ctx)
+}
+
+// SaveKey loads the specified key and stores it in the storage backend. Returns the key fingerprint
+func SaveKey(keyData string) (fingerprint string, err error) {
+	_, err = pgpBackend.LoadKey(ctx, keyData)
+	if err != nil {
+		return
+	}
+
+	fingerprint, err = tools.GetFingerPrintFromKey(keyData)
+	if err != nil {
+		return
+	}
+
+	err = pgpBackend.SaveKey(fingerprint, keyData, nil)
+
+	return
+}
+
+// DeleteKey removes the specified key from memory and from the storage backend
+func DeleteKey(fingerprint string) error {
+	return pgpBackend.DeleteKey(ctx, fingerprint)
+}
+
+// GetLoadedKeys returns the information of every loaded key
+func GetLoadedKeys() []models.KeyInfo {
+	return pgpBackend.GetLoadedKeys()
+}
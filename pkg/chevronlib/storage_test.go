@@ -0,0 +1,59 @@
+package chevronlib
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/quan-to/chevron/internal/keybackend"
+	"github.com/quan-to/chevron/internal/tools"
+)
+
+func TestStorageBackend(t *testing.T) {
+	folder, err := ioutil.TempDir("", "chevronlib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	defer SetStorageBackend(keybackend.MakeVoidBackend())
+
+	SetStorageBackend(keybackend.MakeSaveToDiskBackend(nil, folder, "test_"))
+
+	fp, err := SaveKey(testKey)
+	if err != nil {
+		t.Fatalf("Expected key to be saved. But got %q", err)
+	}
+
+	expectedFp, _ := tools.GetFingerPrintFromKey(testKey)
+	if fp != expectedFp {
+		t.Errorf("Expected fingerprint %s got %s", expectedFp, fp)
+	}
+
+	// A new backend on the same folder should load the saved key
+	SetStorageBackend(keybackend.MakeSaveToDiskBackend(nil, folder, "test_"))
+
+	found := false
+	for _, k := range GetLoadedKeys() {
+		if tools.CompareFingerPrint(k.FingerPrint, fp) {
+			found = k.ContainsPrivateKey
+		}
+	}
+
+	if !found {
+		t.Errorf("Expected private key %s to be loaded from the storage backend", fp)
+	}
+
+	err = DeleteKey(fp)
+	if err != nil {
+		t.Errorf("Expected key to be deleted. But got %q", err)
+	}
+
+	if _, err := os.Stat(folder + "/test_" + fp); !os.IsNotExist(err) {
+		t.Errorf("Expected key %s to be removed from the storage backend", fp)
+	}
+
+	_, err = SaveKey("huebr")
+	if err == nil {
+		t.Error("Expected \"huebr\" to trigger a key save error but got none")
+	}
+}
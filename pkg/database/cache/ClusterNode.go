@@ -0,0 +1,19 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// RegisterClusterNode adds or refreshes a cluster node in the registry
+func (h *Driver) RegisterClusterNode(node models.ClusterNode) error {
+	h.log.Debug("RegisterClusterNode(%s, %s)", node.ID, node.Address)
+	return h.proxy.RegisterClusterNode(node)
+}
+
+// FetchClusterNodes returns all cluster nodes that had been seen after the specified time
+func (h *Driver) FetchClusterNodes(seenAfter time.Time) ([]models.ClusterNode, error) {
+	h.log.Debug("FetchClusterNodes(%s)", seenAfter)
+	return h.proxy.FetchClusterNodes(seenAfter)
+}
@@ -18,6 +18,7 @@ const (
 	gpgKeysByFingerprintCriteria = "gpgKeysByFingerprint-"
 	gpgKeysByValueCriteria       = "gpgKeysByValue-"
 	gpgKeysByNameCriteria        = "gpgKeysByName-"
+	gpgKeyEntryListGeneration    = "gpgKeyEntryListGeneration"
 
 	gpgKeyExpiration        = time.Hour * 24 * 7 // One week expiration for keys
 	gpgKeyEntriesExpiration = time.Minute * 15   // 15 minutes for key entry list
@@ -34,8 +35,28 @@ func (h *Driver) cacheKeyList(keys []models.GPGKey, keyString string) error {
 
 type keyListFallbackFunc = func(string, int, int) ([]models.GPGKey, error)
 
+// keyListGeneration returns the current generation of the key entry lists. It is part of the list cache keys,
+// so changing it invalidates all cached lists in every node
+func (h *Driver) keyListGeneration() string {
+	generation := ""
+	// Errors are cache misses of the first generation
+	_ = h.cache.GetSkippingLocalCache(context.TODO(), gpgKeyEntryListGeneration, &generation)
+	return generation
+}
+
+// invalidateKeyLists invalidates all cached key entry lists by starting a new generation
+func (h *Driver) invalidateKeyLists() error {
+	return h.cache.Set(&cache.Item{
+		Ctx:            context.TODO(),
+		Key:            gpgKeyEntryListGeneration,
+		Value:          fmt.Sprintf("%d-", time.Now().UnixNano()),
+		TTL:            gpgKeyEntriesExpiration,
+		SkipLocalCache: true,
+	})
+}
+
 func (h *Driver) getKeyListCache(value, criteria string, pageStart, pageEnd int, fallback keyListFallbackFunc) (keys []models.GPGKey, err error) {
-	keyString := fmt.Sprintf("%s%s%s%d%d", gpgKeyEntryList, criteria, value, pageStart, pageEnd)
+	keyString := fmt.Sprintf("%s%s%s%s%d%d", gpgKeyEntryList, h.keyListGeneration(), criteria, value, pageStart, pageEnd)
 	err = h.cache.Get(context.TODO(), keyString, &keys)
 	if err == nil { // Cache hit
 		return keys, nil
@@ -113,7 +134,11 @@ func (h *Driver) invalidateCachedKey(key models.GPGKey) error {
 	if err != nil {
 		return err
 	}
-	return h.cache.Delete(context.TODO(), gpgKeyByFingerprintPrefix+key.FullFingerprint)
+	err = h.cache.Delete(context.TODO(), gpgKeyByFingerprintPrefix+tools.FPto16(key.FullFingerprint))
+	if err != nil {
+		return err
+	}
+	return h.invalidateKeyLists()
 }
 
 // UpdateGPGKey updates the specified GPG key by using it's ID
@@ -124,6 +149,9 @@ func (h *Driver) UpdateGPGKey(key models.GPGKey) (err error) {
 		// The cacheKey will log the error
 		// and we don't want to break the flow
 		_ = h.cacheKey(key)
+		if err := h.invalidateKeyLists(); err != nil {
+			h.log.Error("error invalidating key entry lists: %s", err)
+		}
 	}
 	return err
 }
@@ -161,6 +189,9 @@ func (h *Driver) AddGPGKey(key models.GPGKey) (string, bool, error) {
 	// The cacheKey will log the error
 	// and we don't want to break the flow
 	_ = h.cacheKey(key)
+	if err := h.invalidateKeyLists(); err != nil {
+		h.log.Error("error invalidating key entry lists: %s", err)
+	}
 
 	return id, added, err
 }
@@ -212,3 +243,9 @@ func (h *Driver) FindGPGKeyByName(name string, pageStart, pageEnd int) ([]models
 	h.log.Debug("FindGPGKeyByName(%s, %d, %d)", name, pageStart, pageEnd)
 	return h.getKeyListCache(name, gpgKeysByNameCriteria, pageStart, pageEnd, h.proxy.FindGPGKeyByName)
 }
+
+// SearchGPGKeys finds the keys that match the query ranked by relevance. The results are not cached
+func (h *Driver) SearchGPGKeys(query models.GPGKeySearchQuery) (*models.GPGKeySearchResult, error) {
+	h.log.Debug("SearchGPGKeys(%q, %q, %d)", query.Value, query.Cursor, query.Limit)
+	return h.proxy.SearchGPGKeys(query)
+}
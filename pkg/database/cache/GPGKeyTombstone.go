@@ -0,0 +1,17 @@
+package cache
+
+import (
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// AddGPGKeyTombstone adds or replaces the tombstone of a key
+func (h *Driver) AddGPGKeyTombstone(tombstone models.GPGKeyTombstone) error {
+	h.log.Debug("AddGPGKeyTombstone(%s)", tombstone.FingerPrint)
+	return h.proxy.AddGPGKeyTombstone(tombstone)
+}
+
+// FetchGPGKeyTombstone fetches the tombstone of a key by its fingerprint
+func (h *Driver) FetchGPGKeyTombstone(fingerPrint string) (*models.GPGKeyTombstone, error) {
+	h.log.Debug("FetchGPGKeyTombstone(%s)", fingerPrint)
+	return h.proxy.FetchGPGKeyTombstone(fingerPrint)
+}
@@ -41,6 +41,7 @@ func prepareAddGPGKey(t *testing.T) (*Driver, *memory.DbDriver, redismock.Client
 		SetVal("")
 	mock.ExpectSet(gpgKeyByFingerprintPrefix+testKeyToAdd.GetShortFingerPrint(), data, gpgKeyExpiration).
 		SetVal("")
+	mock.Regexp().ExpectSet(gpgKeyEntryListGeneration, `^\[[0-9 ]+\]$`, gpgKeyEntriesExpiration).SetVal("")
 	return h, mem, mock
 }
 
@@ -87,7 +88,8 @@ func TestDriver_DeleteGPGKey(t *testing.T) {
 	_, _, _ = mem.AddGPGKey(testmodels.GpgKey)
 
 	mock.ExpectDel(gpgKeyByIDPrefix + testKeyToRemove.ID).SetVal(0)
-	mock.ExpectDel(gpgKeyByFingerprintPrefix + testKeyToRemove.FullFingerprint).SetVal(0)
+	mock.ExpectDel(gpgKeyByFingerprintPrefix + testKeyToRemove.GetShortFingerPrint()).SetVal(0)
+	mock.Regexp().ExpectSet(gpgKeyEntryListGeneration, `^\[[0-9 ]+\]$`, gpgKeyEntriesExpiration).SetVal("")
 
 	err := h.DeleteGPGKey(testKeyToRemove)
 	if err != nil {
@@ -127,6 +129,7 @@ func TestDriver_UpdateGPGKey(t *testing.T) {
 		SetVal("")
 	mock.ExpectSet(gpgKeyByFingerprintPrefix+testKeyToUpdate.GetShortFingerPrint(), data, gpgKeyExpiration).
 		SetVal("")
+	mock.Regexp().ExpectSet(gpgKeyEntryListGeneration, `^\[[0-9 ]+\]$`, gpgKeyEntriesExpiration).SetVal("")
 
 	err = h.UpdateGPGKey(testKeyToUpdate)
 	if err != nil {
@@ -313,3 +316,38 @@ func TestDriver_FindGPGKeyByValue(t *testing.T) {
 		testFindFunction(v, gpgKeysByValueCriteria, h, h.FindGPGKeyByValue, t)
 	}
 }
+
+func TestDriver_KeyListGeneration(t *testing.T) {
+	mem := memory.MakeMemoryDBDriver(nil)
+	db, mock := redismock.NewClientMock()
+	h := MakeRedisDriver(mem, nil)
+	h.cache = cache.New(&cache.Options{
+		Redis: db,
+	})
+
+	testKey := testmodels.GpgKey
+	id, _, _ := mem.AddGPGKey(testKey)
+	testKey.ID = id
+
+	keyList := []models.GPGKey{testKey}
+
+	data, err := h.cache.Marshal(&keyList)
+	if err != nil {
+		t.Fatalf(unexpectedError, err)
+	}
+
+	value := testmodels.GpgKey.Emails[0]
+
+	// Lists cached after an invalidation are stored with the new generation
+	mock.ExpectGet(gpgKeyEntryListGeneration).SetVal("1234-")
+	mock.ExpectGet(gpgKeyEntryList + "1234-" + gpgKeysByEmailCriteria + value + "010").SetErr(fmt.Errorf("not found"))
+	mock.ExpectSet(gpgKeyEntryList+"1234-"+gpgKeysByEmailCriteria+value+"010", data, gpgKeyEntriesExpiration).SetVal("")
+
+	if _, err := h.FindGPGKeyByEmail(value, 0, 10); err != nil {
+		t.Fatalf(unexpectedError, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf(expectationsWereNotMet, err)
+	}
+}
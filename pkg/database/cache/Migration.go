@@ -20,6 +20,11 @@ func (h *Driver) NextUser(user *models.User) bool {
 	return h.proxy.NextUser(user)
 }
 
+// NextUserToken iterates over the tokens of the proxied handler. Tokens stored only in REDIS are not iterated
+func (h *Driver) NextUserToken(token *models.UserToken) bool {
+	return h.proxy.NextUserToken(token)
+}
+
 func (h *Driver) NumGPGKeys() (int, error) {
 	return h.proxy.NumGPGKeys()
 }
@@ -36,6 +41,9 @@ func (h *Driver) AddGPGKeys(keys []models.GPGKey) ([]string, []bool, error) {
 		// and we don't want to break the flow
 		_ = h.cacheKey(v)
 	}
+	if err := h.invalidateKeyLists(); err != nil {
+		h.log.Error("error invalidating key entry lists: %s", err)
+	}
 
 	return id, added, err
 }
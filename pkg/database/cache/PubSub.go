@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+)
+
+// Publish sends a message to all subscribers of the specified REDIS channel
+func (h *Driver) Publish(ctx context.Context, channel, message string) error {
+	return h.redis.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe returns a go channel that receives the messages of the specified REDIS channel until ctx is done
+func (h *Driver) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	pubSub := h.redis.Subscribe(ctx, channel)
+
+	// Wait for the subscription confirmation
+	if _, err := pubSub.Receive(ctx); err != nil {
+		_ = pubSub.Close()
+		return nil, err
+	}
+
+	messages := make(chan string)
+
+	go func() {
+		defer close(messages)
+		defer pubSub.Close()
+
+		redisMessages := pubSub.Channel()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-redisMessages:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, nil
+}
+
+type notifier interface {
+	Notify(ctx context.Context, channel, message string) error
+	Listen(ctx context.Context, channel string) (<-chan string, error)
+}
+
+// Notify passes the notification to the proxied handler if it supports notifications
+func (h *Driver) Notify(ctx context.Context, channel, message string) error {
+	n, ok := h.proxy.(notifier)
+	if !ok {
+		return fmt.Errorf("the proxied database handler does not support notifications")
+	}
+
+	return n.Notify(ctx, channel, message)
+}
+
+// Listen passes the listen to the proxied handler if it supports notifications
+func (h *Driver) Listen(ctx context.Context, channel string) (<-chan string, error) {
+	n, ok := h.proxy.(notifier)
+	if !ok {
+		return nil, fmt.Errorf("the proxied database handler does not support notifications")
+	}
+
+	return n.Listen(ctx, channel)
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-redis/redismock/v8"
+	"github.com/quan-to/chevron/pkg/database/memory"
+)
+
+func TestDriver_Publish(t *testing.T) {
+	db, mock := redismock.NewClientMock()
+	h := MakeRedisDriver(memory.MakeMemoryDBDriver(nil), nil)
+	h.redis = db
+
+	mock.ExpectPublish("channel", "message").SetVal(1)
+
+	err := h.Publish(context.Background(), "channel", "message")
+	if err != nil {
+		t.Fatalf(unexpectedError, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("expectations were not met: %s", err)
+	}
+}
+
+func TestDriver_NotifyNotSupported(t *testing.T) {
+	h := MakeRedisDriver(memory.MakeMemoryDBDriver(nil), nil)
+
+	if err := h.Notify(context.Background(), "channel", "message"); err == nil {
+		t.Fatalf("expected error when proxied handler does not support notifications")
+	}
+}
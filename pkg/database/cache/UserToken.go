@@ -11,6 +11,11 @@ import (
 
 const userTokenPrefix = "userToken-"
 
+// SupportsUserTokens returns true since tokens are stored on REDIS
+func (h *Driver) SupportsUserTokens() bool {
+	return true
+}
+
 // AddUserToken adds a new user token to be valid and returns its token ID
 func (h *Driver) AddUserToken(ut models.UserToken) (string, error) {
 	h.log.Debug("AddUserToken(%s, %s)", ut.Username, ut.Fingerprint)
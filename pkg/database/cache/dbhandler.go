@@ -1,6 +1,10 @@
 package cache
 
-import "github.com/quan-to/chevron/pkg/models"
+import (
+	"time"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
 
 // ProxiedMigrationHandler
 type ProxiedMigrationHandler interface {
@@ -8,6 +12,7 @@ type ProxiedMigrationHandler interface {
 	FinishCursor() error
 	NextGPGKey(key *models.GPGKey) bool
 	NextUser(user *models.User) bool
+	NextUserToken(token *models.UserToken) bool
 	NumGPGKeys() (int, error)
 }
 
@@ -42,6 +47,8 @@ type ProxiedGPGRepository interface {
 	FindGPGKeyByValue(value string, pageStart, pageEnd int) ([]models.GPGKey, error)
 	// FindGPGKeyByName find all keys that has a underlying UID that contains that name
 	FindGPGKeyByName(name string, pageStart, pageEnd int) ([]models.GPGKey, error)
+	// SearchGPGKeys finds the keys that match the query ranked by relevance
+	SearchGPGKeys(query models.GPGKeySearchQuery) (*models.GPGKeySearchResult, error)
 	// FetchGPGKeyByFingerprint fetch a GPG Key by its fingerprint
 	FetchGPGKeyByFingerprint(fingerprint string) (*models.GPGKey, error)
 	// FetchGPGKeysWithoutSubKeys fetch all keys that does not have a subkey
@@ -51,6 +58,18 @@ type ProxiedGPGRepository interface {
 	DeleteGPGKey(key models.GPGKey) error
 	// UpdateGPGKey updates the specified GPG key by using it's ID
 	UpdateGPGKey(key models.GPGKey) (err error)
+	// AddGPGKeyTombstone adds or replaces the tombstone of a key
+	AddGPGKeyTombstone(tombstone models.GPGKeyTombstone) error
+	// FetchGPGKeyTombstone fetches the tombstone of a key by its fingerprint
+	FetchGPGKeyTombstone(fingerPrint string) (*models.GPGKeyTombstone, error)
+}
+
+// ProxiedClusterRepository a proxy to a Cluster Node Repository
+type ProxiedClusterRepository interface {
+	// RegisterClusterNode adds or refreshes a cluster node in the registry
+	RegisterClusterNode(node models.ClusterNode) error
+	// FetchClusterNodes returns all cluster nodes that had been seen after the specified time
+	FetchClusterNodes(seenAfter time.Time) ([]models.ClusterNode, error)
 }
 
 // ProxiedUserRepository a proxy to a Health Checker
@@ -63,6 +82,7 @@ type ProxiedHealthChecker interface {
 type ProxiedHandler interface {
 	ProxiedUserRepository
 	ProxiedGPGRepository
+	ProxiedClusterRepository
 	ProxiedHealthChecker
 	ProxiedMigrationHandler
 }
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// RegisterClusterNode adds or refreshes a cluster node in the registry
+func (h *DbDriver) RegisterClusterNode(node models.ClusterNode) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	node.LastSeen = time.Now()
+
+	for i, v := range h.clusterNodes {
+		if v.ID == node.ID {
+			h.clusterNodes[i] = node
+			return nil
+		}
+	}
+
+	h.clusterNodes = append(h.clusterNodes, node)
+
+	return nil
+}
+
+// FetchClusterNodes returns all cluster nodes that had been seen after the specified time
+func (h *DbDriver) FetchClusterNodes(seenAfter time.Time) ([]models.ClusterNode, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	nodes := make([]models.ClusterNode, 0)
+
+	for _, v := range h.clusterNodes {
+		if v.LastSeen.After(seenAfter) {
+			nodes = append(nodes, v)
+		}
+	}
+
+	return nodes, nil
+}
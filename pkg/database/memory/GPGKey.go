@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/quan-to/chevron/pkg/database/search"
 	"github.com/quan-to/chevron/pkg/models"
 	"github.com/quan-to/chevron/pkg/uuid"
 )
@@ -269,3 +270,12 @@ func (h *DbDriver) FindGPGKeyByName(name string, pageStart, pageEnd int) ([]mode
 
 	return items, nil
 }
+
+// SearchGPGKeys finds the keys that match the query ranked by relevance
+func (h *DbDriver) SearchGPGKeys(query models.GPGKeySearchQuery) (*models.GPGKeySearchResult, error) {
+	h.log.Debug("SearchGPGKeys(%q, %q, %d)", query.Value, query.Cursor, query.Limit)
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return search.Rank(h.keys, query)
+}
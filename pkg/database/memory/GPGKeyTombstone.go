@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"fmt"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// AddGPGKeyTombstone adds or replaces the tombstone of a key
+func (h *DbDriver) AddGPGKeyTombstone(tombstone models.GPGKeyTombstone) error {
+	h.log.Debug("AddGPGKeyTombstone(%s)", tombstone.FingerPrint)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	tombstone.FingerPrint = tools.FPto16(tombstone.FingerPrint)
+
+	for i, v := range h.tombstones {
+		if v.FingerPrint == tombstone.FingerPrint {
+			h.tombstones[i] = tombstone
+			return nil
+		}
+	}
+
+	h.tombstones = append(h.tombstones, tombstone)
+
+	return nil
+}
+
+// FetchGPGKeyTombstone fetches the tombstone of a key by its fingerprint
+func (h *DbDriver) FetchGPGKeyTombstone(fingerPrint string) (*models.GPGKeyTombstone, error) {
+	h.log.Debug("FetchGPGKeyTombstone(%s)", fingerPrint)
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	fingerPrint = tools.FPto16(fingerPrint)
+
+	for _, v := range h.tombstones {
+		if v.FingerPrint == fingerPrint {
+			t := v
+			return &t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("not found")
+}
@@ -9,11 +9,18 @@ import (
 
 // DbDriver is a database driver for in-memory database for testing
 type DbDriver struct {
-	log    slog.Instance
-	users  []models.User
-	tokens []models.UserToken
-	keys   []models.GPGKey
-	lock   sync.RWMutex
+	log          slog.Instance
+	users        []models.User
+	tokens       []models.UserToken
+	keys         []models.GPGKey
+	clusterNodes []models.ClusterNode
+	tombstones   []models.GPGKeyTombstone
+	lock         sync.RWMutex
+
+	// Migrate
+	keysCursor   []models.GPGKey
+	usersCursor  []models.User
+	tokensCursor []models.UserToken
 }
 
 // MakeMemoryDBDriver creates a new database driver for rethinkdb
@@ -1,29 +1,73 @@
 package memory
 
 import (
-	"fmt"
+	"sort"
 
 	"github.com/quan-to/chevron/pkg/models"
 )
 
+// InitCursor takes a snapshot of the keys, users and tokens to be iterated ordered by ID
 func (h *DbDriver) InitCursor() error {
-	return fmt.Errorf("not implemented")
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	h.keysCursor = append([]models.GPGKey{}, h.keys...)
+	h.usersCursor = append([]models.User{}, h.users...)
+	h.tokensCursor = append([]models.UserToken{}, h.tokens...)
+
+	sort.Slice(h.keysCursor, func(i, j int) bool { return h.keysCursor[i].ID < h.keysCursor[j].ID })
+	sort.Slice(h.usersCursor, func(i, j int) bool { return h.usersCursor[i].ID < h.usersCursor[j].ID })
+	sort.Slice(h.tokensCursor, func(i, j int) bool { return h.tokensCursor[i].ID < h.tokensCursor[j].ID })
+
+	return nil
 }
 
 func (h *DbDriver) FinishCursor() error {
-	return fmt.Errorf("not implemented")
+	h.keysCursor = nil
+	h.usersCursor = nil
+	h.tokensCursor = nil
+
+	return nil
 }
 
 func (h *DbDriver) NextGPGKey(key *models.GPGKey) bool {
-	return false
+	if len(h.keysCursor) == 0 {
+		return false
+	}
+
+	*key = h.keysCursor[0]
+	h.keysCursor = h.keysCursor[1:]
+
+	return true
 }
 
 func (h *DbDriver) NextUser(user *models.User) bool {
-	return false
+	if len(h.usersCursor) == 0 {
+		return false
+	}
+
+	*user = h.usersCursor[0]
+	h.usersCursor = h.usersCursor[1:]
+
+	return true
+}
+
+func (h *DbDriver) NextUserToken(token *models.UserToken) bool {
+	if len(h.tokensCursor) == 0 {
+		return false
+	}
+
+	*token = h.tokensCursor[0]
+	h.tokensCursor = h.tokensCursor[1:]
+
+	return true
 }
 
 func (h *DbDriver) NumGPGKeys() (int, error) {
-	return 0, fmt.Errorf("not implemented")
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	return len(h.keys), nil
 }
 
 // AddGPGKey adds a list GPG Key to the database or update an existing one by fingerprint
@@ -0,0 +1,54 @@
+package pg
+
+import (
+	"time"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+type pgClusterNode struct {
+	ID       string    `db:"cluster_node_id"`
+	Address  string    `db:"cluster_node_address"`
+	LastSeen time.Time `db:"cluster_node_last_seen"`
+}
+
+func (n *pgClusterNode) toClusterNode() models.ClusterNode {
+	return models.ClusterNode{
+		ID:       n.ID,
+		Address:  n.Address,
+		LastSeen: n.LastSeen,
+	}
+}
+
+// RegisterClusterNode adds or refreshes a cluster node in the registry
+func (h *PostgreSQLDBDriver) RegisterClusterNode(node models.ClusterNode) error {
+	h.log.Debug("RegisterClusterNode(%s, %s)", node.ID, node.Address)
+	_, err := h.conn.NamedExec(`INSERT INTO
+            chevron_cluster_node(cluster_node_id, cluster_node_address, cluster_node_last_seen)
+            VALUES (:cluster_node_id, :cluster_node_address, now())
+            ON CONFLICT (cluster_node_id) DO UPDATE SET
+                cluster_node_address = :cluster_node_address,
+                cluster_node_last_seen = now()`, &pgClusterNode{
+		ID:      node.ID,
+		Address: node.Address,
+	})
+
+	return err
+}
+
+// FetchClusterNodes returns all cluster nodes that had been seen after the specified time
+func (h *PostgreSQLDBDriver) FetchClusterNodes(seenAfter time.Time) ([]models.ClusterNode, error) {
+	h.log.Debug("FetchClusterNodes(%s)", seenAfter)
+	var pgNodes []pgClusterNode
+	err := h.conn.Select(&pgNodes, "SELECT * FROM chevron_cluster_node WHERE cluster_node_last_seen > $1", seenAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]models.ClusterNode, len(pgNodes))
+	for i, v := range pgNodes {
+		nodes[i] = v.toClusterNode()
+	}
+
+	return nodes, nil
+}
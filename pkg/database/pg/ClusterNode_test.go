@@ -0,0 +1,69 @@
+package pg
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+func TestPostgreSQLDBDriver_RegisterClusterNode(t *testing.T) {
+	h := MakePostgreSQLDBDriver(nil)
+	converter := sqlmock.ValueConverterOption(customConverter{})
+
+	mockDB, mock, _ := sqlmock.New(converter)
+	h.conn = sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO chevron_cluster_node(cluster_node_id, cluster_node_address, cluster_node_last_seen) VALUES (?, ?, now()) ON CONFLICT (cluster_node_id) DO UPDATE SET cluster_node_address = ?, cluster_node_last_seen = now()`)).
+		WithArgs("node-a", "http://10.0.0.1:5100", "http://10.0.0.1:5100").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := h.RegisterClusterNode(models.ClusterNode{
+		ID:      "node-a",
+		Address: "http://10.0.0.1:5100",
+	})
+
+	if err != nil {
+		t.Fatalf(unexpectedError, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf(expectationsDidNotMet, err)
+	}
+}
+
+func TestPostgreSQLDBDriver_FetchClusterNodes(t *testing.T) {
+	h := MakePostgreSQLDBDriver(nil)
+	converter := sqlmock.ValueConverterOption(customConverter{})
+
+	mockDB, mock, _ := sqlmock.New(converter)
+	h.conn = sqlx.NewDb(mockDB, "sqlmock")
+
+	lastSeen := time.Now()
+	seenAfter := lastSeen.Add(-time.Minute)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM chevron_cluster_node WHERE cluster_node_last_seen > $1`)).
+		WithArgs(seenAfter).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"cluster_node_id",
+			"cluster_node_address",
+			"cluster_node_last_seen",
+		}).AddRow("node-a", "http://10.0.0.1:5100", lastSeen))
+
+	nodes, err := h.FetchClusterNodes(seenAfter)
+
+	if err != nil {
+		t.Fatalf(unexpectedError, err)
+	}
+
+	if len(nodes) != 1 || nodes[0].ID != "node-a" || nodes[0].Address != "http://10.0.0.1:5100" {
+		t.Fatalf("unexpected nodes: %+v", nodes)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf(expectationsDidNotMet, err)
+	}
+}
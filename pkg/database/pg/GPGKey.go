@@ -2,6 +2,7 @@ package pg
 
 import (
 	"github.com/jmoiron/sqlx"
+	"github.com/quan-to/chevron/pkg/database/search"
 	"github.com/quan-to/chevron/pkg/models"
 )
 
@@ -147,6 +148,47 @@ func (h *PostgreSQLDBDriver) FindGPGKeyByName(name string, pageStart, pageEnd in
 	return convertArray(keys, tx)
 }
 
+// SearchGPGKeys finds the keys that match the query ranked by relevance
+func (h *PostgreSQLDBDriver) SearchGPGKeys(query models.GPGKeySearchQuery) (*models.GPGKeySearchResult, error) {
+	h.log.Debug("SearchGPGKeys(%q, %q, %d)", query.Value, query.Cursor, query.Limit)
+	tx, err := h.conn.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { h.rollbackIfErrorCommitIfNot(err, tx) }()
+
+	hits, err := h.searchGPGKeys(tx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.GPGKeySearchResult{
+		Hits: make([]models.GPGKeySearchHit, 0),
+	}
+
+	if len(hits) > 0 {
+		result.Total = hits[0].Total
+	}
+
+	limit := query.GetLimit()
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+		last := hits[limit-1]
+		result.NextCursor = search.EncodeCursor(last.Score, last.ID)
+	}
+
+	for _, v := range hits {
+		key, err := v.toGPGKey(tx)
+		if err != nil {
+			return nil, err
+		}
+		result.Hits = append(result.Hits, models.GPGKeySearchHit{Key: *key, Score: v.Score})
+	}
+
+	return result, nil
+}
+
 func convertArray(keys []pgGPGKey, tx *sqlx.Tx) (res []models.GPGKey, err error) {
 	for _, v := range keys {
 		k, err := v.toGPGKey(tx)
@@ -103,6 +103,7 @@ func pgGPGKeyFromGPGKey(key models.GPGKey) *pgGPGKey {
 			Name:        uid.Name,
 			Description: uid.Description,
 			Email:       uid.Email,
+			Verified:    uid.Verified,
 		})
 	}
 
@@ -153,6 +154,7 @@ func (k *pgGPGKey) toGPGKey(tx *sqlx.Tx) (*models.GPGKey, error) {
 			Name:        v.Name,
 			Email:       v.Email,
 			Description: v.Description,
+			Verified:    v.Verified,
 		})
 	}
 
@@ -212,6 +214,7 @@ func (k *pgGPGKey) updateUIDs(tx *sqlx.Tx, newUIDs []models.GPGKeyUid) error {
 					oldUID.Name = newUID.Name
 					oldUID.Email = newUID.Email
 					oldUID.Description = newUID.Description
+					oldUID.Verified = newUID.Verified
 					err = oldUID.save(tx)
 					if err != nil {
 						return err
@@ -226,6 +229,7 @@ func (k *pgGPGKey) updateUIDs(tx *sqlx.Tx, newUIDs []models.GPGKeyUid) error {
 				Name:        newUID.Name,
 				Description: newUID.Description,
 				Email:       newUID.Email,
+				Verified:    newUID.Verified,
 			}
 			err = newUid.save(tx)
 			if err != nil {
@@ -270,13 +274,14 @@ type pgGPGKeyUID struct {
 	Name        string     `db:"gpg_key_uid_name"`
 	Email       string     `db:"gpg_key_uid_email"`
 	Description string     `db:"gpg_key_uid_description"`
+	Verified    bool       `db:"gpg_key_uid_verified"`
 	CreatedAt   time.Time  `db:"gpg_key_uid_created_at"`
 	UpdatedAt   time.Time  `db:"gpg_key_uid_updated_at"`
 	DeletedAt   *time.Time `db:"gpg_key_uid_deleted_at"`
 }
 
 func (k *pgGPGKeyUID) fieldsChanged(m models.GPGKeyUid) bool {
-	return k.Name != m.Name || k.Email != m.Email || k.Description != m.Description
+	return k.Name != m.Name || k.Email != m.Email || k.Description != m.Description || k.Verified != m.Verified
 }
 
 func (k *pgGPGKeyUID) compareWithKeyUID(m models.GPGKeyUid) bool {
@@ -287,8 +292,8 @@ func (k *pgGPGKeyUID) save(tx *sqlx.Tx) error {
 	if k.ID == "" { // Insert
 		k.ID = uuid.EnsureUUID(nil)
 		_, err := tx.NamedExec(`INSERT INTO
-                               chevron_gpg_key_uid(gpg_key_uid_id, gpg_key_uid_name, gpg_key_uid_email, gpg_key_uid_description, gpg_key_uid_parent)
-                               VALUES (:gpg_key_uid_id, :gpg_key_uid_name, :gpg_key_uid_email, :gpg_key_uid_description, :gpg_key_uid_parent)`, k)
+                               chevron_gpg_key_uid(gpg_key_uid_id, gpg_key_uid_name, gpg_key_uid_email, gpg_key_uid_description, gpg_key_uid_verified, gpg_key_uid_parent)
+                               VALUES (:gpg_key_uid_id, :gpg_key_uid_name, :gpg_key_uid_email, :gpg_key_uid_description, :gpg_key_uid_verified, :gpg_key_uid_parent)`, k)
 		return err
 	}
 	// Update
@@ -296,6 +301,7 @@ func (k *pgGPGKeyUID) save(tx *sqlx.Tx) error {
                            gpg_key_uid_name = :gpg_key_uid_name,
                            gpg_key_uid_email = :gpg_key_uid_email,
                            gpg_key_uid_description = :gpg_key_uid_description,
+                           gpg_key_uid_verified = :gpg_key_uid_verified,
                            gpg_key_uid_updated_at = now()
                            WHERE gpg_key_uid_id = :gpg_key_uid_id`, k)
 	return err
@@ -0,0 +1,82 @@
+package pg
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+type pgGPGKeyTombstone struct {
+	FingerPrint   string         `db:"gpg_key_tombstone_fingerprint16"`
+	Emails        pq.StringArray `db:"gpg_key_tombstone_emails"`
+	Reason        string         `db:"gpg_key_tombstone_reason"`
+	RemovedBy     string         `db:"gpg_key_tombstone_removed_by"`
+	CreatedAt     time.Time      `db:"gpg_key_tombstone_created_at"`
+	OwnerRequests pq.StringArray `db:"gpg_key_tombstone_owner_requests"`
+}
+
+func (t *pgGPGKeyTombstone) toGPGKeyTombstone() *models.GPGKeyTombstone {
+	return &models.GPGKeyTombstone{
+		FingerPrint:   t.FingerPrint,
+		Emails:        t.Emails,
+		Reason:        t.Reason,
+		RemovedBy:     t.RemovedBy,
+		CreatedAt:     t.CreatedAt,
+		OwnerRequests: t.OwnerRequests,
+	}
+}
+
+// AddGPGKeyTombstone adds or replaces the tombstone of a key
+func (h *PostgreSQLDBDriver) AddGPGKeyTombstone(tombstone models.GPGKeyTombstone) error {
+	h.log.Debug("AddGPGKeyTombstone(%s)", tombstone.FingerPrint)
+
+	emails := tombstone.Emails
+	if emails == nil {
+		emails = make([]string, 0)
+	}
+
+	ownerRequests := tombstone.OwnerRequests
+	if ownerRequests == nil {
+		ownerRequests = make([]string, 0)
+	}
+
+	_, err := h.conn.NamedExec(`INSERT INTO
+            chevron_gpg_key_tombstone(gpg_key_tombstone_fingerprint16, gpg_key_tombstone_emails, gpg_key_tombstone_reason, gpg_key_tombstone_removed_by, gpg_key_tombstone_created_at, gpg_key_tombstone_owner_requests)
+            VALUES (:gpg_key_tombstone_fingerprint16, :gpg_key_tombstone_emails, :gpg_key_tombstone_reason, :gpg_key_tombstone_removed_by, :gpg_key_tombstone_created_at, :gpg_key_tombstone_owner_requests)
+            ON CONFLICT (gpg_key_tombstone_fingerprint16) DO UPDATE SET
+                gpg_key_tombstone_emails = :gpg_key_tombstone_emails,
+                gpg_key_tombstone_reason = :gpg_key_tombstone_reason,
+                gpg_key_tombstone_removed_by = :gpg_key_tombstone_removed_by,
+                gpg_key_tombstone_created_at = :gpg_key_tombstone_created_at,
+                gpg_key_tombstone_owner_requests = :gpg_key_tombstone_owner_requests`, &pgGPGKeyTombstone{
+		FingerPrint:   tools.FPto16(tombstone.FingerPrint),
+		Emails:        emails,
+		Reason:        tombstone.Reason,
+		RemovedBy:     tombstone.RemovedBy,
+		CreatedAt:     tombstone.CreatedAt,
+		OwnerRequests: ownerRequests,
+	})
+
+	return err
+}
+
+// FetchGPGKeyTombstone fetches the tombstone of a key by its fingerprint
+func (h *PostgreSQLDBDriver) FetchGPGKeyTombstone(fingerPrint string) (*models.GPGKeyTombstone, error) {
+	h.log.Debug("FetchGPGKeyTombstone(%s)", fingerPrint)
+	var t pgGPGKeyTombstone
+
+	err := h.conn.Get(&t, "SELECT * FROM chevron_gpg_key_tombstone WHERE gpg_key_tombstone_fingerprint16 = $1", tools.FPto16(fingerPrint))
+	if err != nil && !strings.EqualFold("sql: no rows in result set", err.Error()) {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("not found")
+	}
+
+	return t.toGPGKeyTombstone(), nil
+}
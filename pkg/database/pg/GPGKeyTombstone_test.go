@@ -0,0 +1,99 @@
+package pg
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+func TestPostgreSQLDBDriver_AddGPGKeyTombstone(t *testing.T) {
+	h := MakePostgreSQLDBDriver(nil)
+	converter := sqlmock.ValueConverterOption(customConverter{})
+
+	mockDB, mock, _ := sqlmock.New(converter)
+	h.conn = sqlx.NewDb(mockDB, "sqlmock")
+
+	createdAt := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO chevron_gpg_key_tombstone(gpg_key_tombstone_fingerprint16, gpg_key_tombstone_emails, gpg_key_tombstone_reason, gpg_key_tombstone_removed_by, gpg_key_tombstone_created_at, gpg_key_tombstone_owner_requests) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (gpg_key_tombstone_fingerprint16) DO UPDATE SET gpg_key_tombstone_emails = ?, gpg_key_tombstone_reason = ?, gpg_key_tombstone_removed_by = ?, gpg_key_tombstone_created_at = ?, gpg_key_tombstone_owner_requests = ?`)).
+		WithArgs(
+			"0551F452ABE463A4",
+			pq.StringArray{"john@huebr.com"},
+			"GDPR",
+			"admin",
+			createdAt,
+			pq.StringArray{"1603108800:00"},
+			pq.StringArray{"john@huebr.com"},
+			"GDPR",
+			"admin",
+			createdAt,
+			pq.StringArray{"1603108800:00"},
+		).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := h.AddGPGKeyTombstone(models.GPGKeyTombstone{
+		FingerPrint:   "985F68DFBE4B8C20582972300551F452ABE463A4",
+		Emails:        []string{"john@huebr.com"},
+		Reason:        "GDPR",
+		RemovedBy:     "admin",
+		CreatedAt:     createdAt,
+		OwnerRequests: []string{"1603108800:00"},
+	})
+
+	if err != nil {
+		t.Fatalf(unexpectedError, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf(expectationsDidNotMet, err)
+	}
+}
+
+func TestPostgreSQLDBDriver_FetchGPGKeyTombstone(t *testing.T) {
+	h := MakePostgreSQLDBDriver(nil)
+	converter := sqlmock.ValueConverterOption(customConverter{})
+
+	mockDB, mock, _ := sqlmock.New(converter)
+	h.conn = sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM chevron_gpg_key_tombstone WHERE gpg_key_tombstone_fingerprint16 = $1`)).
+		WithArgs("0551F452ABE463A4").
+		WillReturnRows(sqlmock.NewRows([]string{
+			"gpg_key_tombstone_fingerprint16",
+			"gpg_key_tombstone_emails",
+			"gpg_key_tombstone_reason",
+			"gpg_key_tombstone_removed_by",
+			"gpg_key_tombstone_created_at",
+			"gpg_key_tombstone_owner_requests",
+		}).AddRow("0551F452ABE463A4", "{}", "GDPR", "owner", time.Now(), "{}"))
+
+	tombstone, err := h.FetchGPGKeyTombstone("0551F452ABE463A4")
+
+	if err != nil {
+		t.Fatalf(unexpectedError, err)
+	}
+
+	if tombstone.FingerPrint != "0551F452ABE463A4" || !tombstone.IsWholeKey() || tombstone.RemovedBy != "owner" {
+		t.Fatalf("unexpected tombstone: %+v", tombstone)
+	}
+
+	// Test not found
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM chevron_gpg_key_tombstone WHERE gpg_key_tombstone_fingerprint16 = $1`)).
+		WithArgs("0551F452ABE463A4").
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	_, err = h.FetchGPGKeyTombstone("0551F452ABE463A4")
+	if err == nil || !strings.EqualFold(err.Error(), "not found") {
+		t.Fatalf("expected error %q got %v", "not found", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf(expectationsDidNotMet, err)
+	}
+}
@@ -5,9 +5,43 @@ import (
 	"strings"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/quan-to/chevron/pkg/database/search"
 	"github.com/quan-to/chevron/pkg/models"
 )
 
+// uidSearchVector is the full-text document of a key uid. It should be the same expression of chevron_gpg_key_uid_search_idx
+const uidSearchVector = `(setweight(to_tsvector('simple', regexp_replace(lower(coalesce(gpg_key_uid_email, '')), '[^[:alnum:]]+', ' ', 'g')), 'A') ||
+     setweight(to_tsvector('simple', regexp_replace(lower(coalesce(gpg_key_uid_name, '')), '[^[:alnum:]]+', ' ', 'g')), 'B'))`
+
+// searchGPGKeysQuery ranks the keys by the full-text rank and trigram word similarity of its uids, or by fingerprint suffix.
+// The parameters are the lower case value, the prefix tsquery, verified only, fingerprint LIKE pattern, cursor score, cursor id and limit
+var searchGPGKeysQuery = fmt.Sprintf(`SELECT k.*, r.search_score, r.search_total FROM (
+    SELECT m.id, MAX(m.score) AS search_score, COUNT(*) OVER () AS search_total FROM (
+        SELECT gpg_key_uid_parent AS id,
+            ts_rank(%[1]s, to_tsquery('simple', $2))::float8 +
+            GREATEST(word_similarity($1, lower(coalesce(gpg_key_uid_email, ''))), word_similarity($1, lower(coalesce(gpg_key_uid_name, ''))))::float8 * %[2]v AS score
+        FROM chevron_gpg_key_uid
+        WHERE ($3 = false OR gpg_key_uid_verified) AND (
+            %[1]s @@ to_tsquery('simple', $2) OR
+            $1 <%% lower(coalesce(gpg_key_uid_email, '')) OR
+            $1 <%% lower(coalesce(gpg_key_uid_name, '')))
+        UNION ALL
+        SELECT gpg_key_id AS id, %[3]v::float8 AS score
+        FROM chevron_gpg_key
+        WHERE $4 <> '' AND gpg_key_parent IS NULL AND gpg_key_fingerprint16 LIKE $4 AND
+            ($3 = false OR EXISTS (SELECT 1 FROM chevron_gpg_key_uid WHERE gpg_key_uid_parent = gpg_key_id AND gpg_key_uid_verified))
+    ) m GROUP BY m.id
+) r JOIN chevron_gpg_key k ON k.gpg_key_id = r.id
+WHERE $5::float8 IS NULL OR r.search_score < $5 OR (r.search_score = $5 AND k.gpg_key_id > $6::uuid)
+ORDER BY r.search_score DESC, k.gpg_key_id ASC
+LIMIT $7`, uidSearchVector, search.FuzzyScore, search.FingerPrintScore)
+
+type pgGPGKeySearchHit struct {
+	pgGPGKey
+	Score float64 `db:"search_score"`
+	Total int     `db:"search_total"`
+}
+
 func (h *PostgreSQLDBDriver) updateGPGKey(tx *sqlx.Tx, key models.GPGKey) error {
 	gpgKey, err := h.fetchGPGKeyByFingerprint(tx, key.FullFingerprint)
 	if errorIsNotNilAndNotNotFound(err) {
@@ -138,3 +172,55 @@ func (h *PostgreSQLDBDriver) findGPGKeyByValue(tx *sqlx.Tx, value string, pageSt
 func (h *PostgreSQLDBDriver) findGPGKeyByName(tx *sqlx.Tx, name string, pageStart, pageEnd int) (res []pgGPGKey, err error) {
 	return nil, fmt.Errorf("not supported") // Slow query
 }
+
+// searchTSQuery converts a search value to a tsquery that matches words starting with each of its words
+func searchTSQuery(value string) string {
+	words := make([]string, 0)
+
+	for _, term := range search.Terms(value) {
+		for _, w := range search.Words(term) {
+			words = append(words, w+":*")
+		}
+	}
+
+	return strings.Join(words, " & ")
+}
+
+func (h *PostgreSQLDBDriver) searchGPGKeys(tx *sqlx.Tx, query models.GPGKeySearchQuery) (hits []pgGPGKeySearchHit, err error) {
+	value := strings.ToLower(strings.TrimSpace(query.Value))
+	tsQuery := searchTSQuery(value)
+	fingerPrint := ""
+
+	if search.IsFingerPrint(value) {
+		fingerPrint = strings.ToUpper(strings.TrimPrefix(value, "0x"))
+		if len(fingerPrint) > 16 {
+			fingerPrint = fingerPrint[len(fingerPrint)-16:]
+		}
+		fingerPrint = "%" + fingerPrint
+	}
+
+	if tsQuery == "" && fingerPrint == "" {
+		return nil, nil
+	}
+
+	var cursorScore *float64
+	var cursorId *string
+
+	if query.Cursor != "" {
+		score, id, err := search.DecodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		cursorScore = &score
+		cursorId = &id
+	}
+
+	// One more hit to know if there is a next page
+	err = tx.Select(&hits, searchGPGKeysQuery, value, tsQuery, query.VerifiedOnly, fingerPrint, cursorScore, cursorId, query.GetLimit()+1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hits, nil
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/database/search"
+	"github.com/quan-to/chevron/pkg/models"
 	"github.com/quan-to/chevron/pkg/uuid"
 	"github.com/quan-to/slog"
 )
@@ -89,8 +91,8 @@ func expectToUpdate(mock sqlmock.Sqlmock, withSubkeys bool) {
 
 	// Insert UIDs
 	for _, uid := range testmodels.GpgKey.KeyUids {
-		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO chevron_gpg_key_uid(gpg_key_uid_id, gpg_key_uid_name, gpg_key_uid_email, gpg_key_uid_description, gpg_key_uid_parent) VALUES (?, ?, ?, ?, ?)`)).
-			WithArgs(sqlmock.AnyArg(), uid.Name, uid.Email, uid.Description, sqlmock.AnyArg()).
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO chevron_gpg_key_uid(gpg_key_uid_id, gpg_key_uid_name, gpg_key_uid_email, gpg_key_uid_description, gpg_key_uid_verified, gpg_key_uid_parent) VALUES (?, ?, ?, ?, ?, ?)`)).
+			WithArgs(sqlmock.AnyArg(), uid.Name, uid.Email, uid.Description, uid.Verified, sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 	}
 	// Update Key
@@ -124,8 +126,8 @@ func prepareAddGPGKey(t *testing.T) (*PostgreSQLDBDriver, sqlmock.Sqlmock) {
 
 	// Insert UIDs
 	for _, uid := range testmodels.GpgKey.KeyUids {
-		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO chevron_gpg_key_uid(gpg_key_uid_id, gpg_key_uid_name, gpg_key_uid_email, gpg_key_uid_description, gpg_key_uid_parent) VALUES (?, ?, ?, ?, ?)`)).
-			WithArgs(sqlmock.AnyArg(), uid.Name, uid.Email, uid.Description, sqlmock.AnyArg()).
+		mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO chevron_gpg_key_uid(gpg_key_uid_id, gpg_key_uid_name, gpg_key_uid_email, gpg_key_uid_description, gpg_key_uid_verified, gpg_key_uid_parent) VALUES (?, ?, ?, ?, ?, ?)`)).
+			WithArgs(sqlmock.AnyArg(), uid.Name, uid.Email, uid.Description, uid.Verified, sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 	}
 	mock.ExpectCommit()
@@ -263,6 +265,7 @@ func TestPostgreSQLDBDriver_FetchGPGKeyByFingerprint(t *testing.T) {
 		"gpg_key_uid_name",
 		"gpg_key_uid_email",
 		"gpg_key_uid_description",
+		"gpg_key_uid_verified",
 		"gpg_key_uid_created_at",
 		"gpg_key_uid_updated_at",
 		"gpg_key_uid_deleted_at",
@@ -275,6 +278,7 @@ func TestPostgreSQLDBDriver_FetchGPGKeyByFingerprint(t *testing.T) {
 			v.Name,
 			v.Email,
 			v.Description,
+			v.Verified,
 			time.Now(),
 			time.Now(),
 			time.Time{},
@@ -531,3 +535,102 @@ func TestPostgreSQLDBDriver_UpdateGPGKey(t *testing.T) {
 		t.Fatalf(expectationsDidNotMet, err)
 	}
 }
+
+func TestPostgreSQLDBDriver_SearchGPGKeys(t *testing.T) {
+	h := MakePostgreSQLDBDriver(nil)
+	converter := sqlmock.ValueConverterOption(customConverter{})
+
+	mockDB, mock, _ := sqlmock.New(converter)
+	h.conn = sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectBegin()
+
+	expectedRows := sqlmock.NewRows([]string{
+		"gpg_key_id",
+		"gpg_key_full_fingerprint",
+		"gpg_key_fingerprint16",
+		"gpg_key_keybits",
+		"gpg_key_public_key",
+		"gpg_key_private_key",
+		"gpg_key_created_at",
+		"gpg_key_updated_at",
+		"gpg_key_deleted_at",
+		"gpg_key_parent",
+		"search_score",
+		"search_total",
+	}).AddRow(
+		testmodels.GpgKey.ID,
+		testmodels.GpgKey.FullFingerprint,
+		tools.FPto16(testmodels.GpgKey.FullFingerprint),
+		testmodels.GpgKey.KeyBits,
+		testmodels.GpgKey.AsciiArmoredPublicKey,
+		testmodels.GpgKey.AsciiArmoredPrivateKey,
+		time.Now(),
+		time.Now(),
+		time.Time{},
+		(*string)(nil),
+		0.9,
+		2,
+	).AddRow(
+		testmodels.GpgKey.ID+"1234",
+		testmodels.GpgKey.FullFingerprint,
+		tools.FPto16(testmodels.GpgKey.FullFingerprint),
+		testmodels.GpgKey.KeyBits,
+		testmodels.GpgKey.AsciiArmoredPublicKey,
+		testmodels.GpgKey.AsciiArmoredPrivateKey,
+		time.Now(),
+		time.Now(),
+		time.Time{},
+		(*string)(nil),
+		0.5,
+		2,
+	)
+
+	mock.ExpectQuery(regexp.QuoteMeta(searchGPGKeysQuery)).
+		WithArgs(
+			"john hue",
+			"john:* & hue:*",
+			true,
+			"",
+			sqlmock.AnyArg(),
+			sqlmock.AnyArg(),
+			2,
+		).
+		WillReturnRows(expectedRows)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM chevron_gpg_key_uid WHERE gpg_key_uid_parent = $1`)).
+		WithArgs(testmodels.GpgKey.ID).
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM chevron_gpg_key WHERE gpg_key_parent = $1`)).
+		WithArgs(testmodels.GpgKey.ID).
+		WillReturnRows(sqlmock.NewRows(nil))
+
+	mock.ExpectCommit()
+
+	result, err := h.SearchGPGKeys(models.GPGKeySearchQuery{
+		Value:        "John HUE",
+		Limit:        1,
+		VerifiedOnly: true,
+	})
+
+	if err != nil {
+		t.Fatalf(unexpectedError, err)
+	}
+
+	if result.Total != 2 || len(result.Hits) != 1 {
+		t.Fatalf("expected 1 of 2 hits but got %d of %d", len(result.Hits), result.Total)
+	}
+
+	if result.Hits[0].Key.ID != testmodels.GpgKey.ID || result.Hits[0].Score != 0.9 {
+		t.Fatalf("expected hit %q with score 0.9 but got %q with score %f", testmodels.GpgKey.ID, result.Hits[0].Key.ID, result.Hits[0].Score)
+	}
+
+	if result.NextCursor != search.EncodeCursor(0.9, testmodels.GpgKey.ID) {
+		t.Fatalf("expected next cursor after the first hit but got %q", result.NextCursor)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf(expectationsDidNotMet, err)
+	}
+}
@@ -18,8 +18,9 @@ import (
 
 // PostgreSQLDBDriver is a database driver for PostgreSQL
 type PostgreSQLDBDriver struct {
-	log  slog.Instance
-	conn *sqlx.DB
+	log              slog.Instance
+	conn             *sqlx.DB
+	connectionString string
 
 	// Migrate
 	gpgKeysRows *sqlx.Rows
@@ -67,6 +68,7 @@ func (h *PostgreSQLDBDriver) Connect(connectionString string) error {
 		return err
 	}
 	h.conn = db
+	h.connectionString = connectionString
 	h.log.Info("Connected!")
 	return nil
 }
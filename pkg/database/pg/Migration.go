@@ -5,14 +5,14 @@ import (
 )
 
 func (h *PostgreSQLDBDriver) InitCursor() error {
-	gpgRows, err := h.conn.Queryx("SELECT * FROM chevron_gpg_key")
+	gpgRows, err := h.conn.Queryx("SELECT * FROM chevron_gpg_key ORDER BY gpg_key_id")
 	if err != nil {
 		return err
 	}
 
 	h.gpgKeysRows = gpgRows
 
-	userRows, err := h.conn.Queryx("SELECT * FROM chevron_user")
+	userRows, err := h.conn.Queryx("SELECT * FROM chevron_user ORDER BY user_id")
 	if err != nil {
 		_ = h.gpgKeysRows.Close()
 		h.gpgKeysRows = nil
@@ -108,6 +108,11 @@ func (h *PostgreSQLDBDriver) NextUser(user *models.User) bool {
 	return false
 }
 
+// NextUserToken always returns false since tokens are not stored on postgres
+func (h *PostgreSQLDBDriver) NextUserToken(token *models.UserToken) bool {
+	return false
+}
+
 func (h *PostgreSQLDBDriver) NumGPGKeys() (int, error) {
 	count := -1
 	err := h.conn.Get(&count, "SELECT COUNT(*) FROM chevron_gpg_key")
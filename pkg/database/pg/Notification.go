@@ -0,0 +1,66 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	listenerMinReconnectInterval = 10 * time.Second
+	listenerMaxReconnectInterval = time.Minute
+)
+
+// Notify sends a message to all listeners of the specified channel using NOTIFY
+func (h *PostgreSQLDBDriver) Notify(ctx context.Context, channel, message string) error {
+	_, err := h.conn.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, message)
+	return err
+}
+
+// Listen returns a go channel that receives the messages sent to the specified channel until ctx is done.
+// It uses a dedicated connection since LISTEN is bound to the session.
+func (h *PostgreSQLDBDriver) Listen(ctx context.Context, channel string) (<-chan string, error) {
+	if h.connectionString == "" {
+		return nil, fmt.Errorf("not connected")
+	}
+
+	listener := pq.NewListener(h.connectionString, listenerMinReconnectInterval, listenerMaxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			h.log.Error("Listener error on channel %s: %s", channel, err)
+		}
+	})
+
+	if err := listener.Listen(channel); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	messages := make(chan string)
+
+	go func() {
+		defer close(messages)
+		defer listener.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-listener.Notify:
+				if n == nil {
+					// Connection was re-established, notifications sent meanwhile are lost
+					h.log.Warn("Listener on channel %s reconnected", channel)
+					continue
+				}
+				select {
+				case messages <- n.Extra:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return messages, nil
+}
@@ -0,0 +1,39 @@
+package pg
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestPostgreSQLDBDriver_Notify(t *testing.T) {
+	h := MakePostgreSQLDBDriver(nil)
+	converter := sqlmock.ValueConverterOption(customConverter{})
+
+	mockDB, mock, _ := sqlmock.New(converter)
+	h.conn = sqlx.NewDb(mockDB, "sqlmock")
+
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_notify($1, $2)`)).
+		WithArgs("channel", "message").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := h.Notify(context.Background(), "channel", "message")
+	if err != nil {
+		t.Fatalf(unexpectedError, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf(expectationsDidNotMet, err)
+	}
+}
+
+func TestPostgreSQLDBDriver_ListenNotConnected(t *testing.T) {
+	h := MakePostgreSQLDBDriver(nil)
+
+	if _, err := h.Listen(context.Background(), "channel"); err == nil {
+		t.Fatalf("expected error when listening without connection")
+	}
+}
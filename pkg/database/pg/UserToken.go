@@ -6,6 +6,11 @@ import (
 	"github.com/quan-to/chevron/pkg/models"
 )
 
+// SupportsUserTokens returns false since tokens are not stored on postgres
+func (h *PostgreSQLDBDriver) SupportsUserTokens() bool {
+	return false
+}
+
 func (h *PostgreSQLDBDriver) AddUserToken(ut models.UserToken) (string, error) {
 	return "", fmt.Errorf("token is not supported on postgres. please use redis wrapper around it")
 }
@@ -0,0 +1,2 @@
+--changeset racerxdl:create_cluster_node_table
+DROP TABLE chevron_cluster_node;
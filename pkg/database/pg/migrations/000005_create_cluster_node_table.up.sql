@@ -0,0 +1,9 @@
+--changeset racerxdl:create_cluster_node_table
+CREATE TABLE chevron_cluster_node
+(
+    cluster_node_id        varchar   NOT NULL PRIMARY KEY,
+    cluster_node_address   varchar   NOT NULL,
+    cluster_node_last_seen timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX chevron_cluster_node_last_seen_idx ON chevron_cluster_node (cluster_node_last_seen);
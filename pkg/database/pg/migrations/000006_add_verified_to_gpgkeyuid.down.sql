@@ -0,0 +1,4 @@
+--changeset racerxdl:add_verified_to_gpgkeyuid
+
+ALTER TABLE chevron_gpg_key_uid
+    DROP COLUMN gpg_key_uid_verified;
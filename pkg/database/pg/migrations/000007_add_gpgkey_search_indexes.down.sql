@@ -0,0 +1,6 @@
+--changeset racerxdl:add_gpgkey_search_indexes
+
+DROP INDEX chevron_gpg_key_fingerprint16_trgm_idx;
+DROP INDEX chevron_gpg_key_uid_name_trgm_idx;
+DROP INDEX chevron_gpg_key_uid_email_trgm_idx;
+DROP INDEX chevron_gpg_key_uid_search_idx;
@@ -0,0 +1,12 @@
+--changeset racerxdl:add_gpgkey_search_indexes
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX chevron_gpg_key_uid_search_idx ON chevron_gpg_key_uid USING gin (
+    (setweight(to_tsvector('simple', regexp_replace(lower(coalesce(gpg_key_uid_email, '')), '[^[:alnum:]]+', ' ', 'g')), 'A') ||
+     setweight(to_tsvector('simple', regexp_replace(lower(coalesce(gpg_key_uid_name, '')), '[^[:alnum:]]+', ' ', 'g')), 'B'))
+);
+
+CREATE INDEX chevron_gpg_key_uid_email_trgm_idx ON chevron_gpg_key_uid USING gin (lower(coalesce(gpg_key_uid_email, '')) gin_trgm_ops);
+CREATE INDEX chevron_gpg_key_uid_name_trgm_idx ON chevron_gpg_key_uid USING gin (lower(coalesce(gpg_key_uid_name, '')) gin_trgm_ops);
+CREATE INDEX chevron_gpg_key_fingerprint16_trgm_idx ON chevron_gpg_key USING gin (gpg_key_fingerprint16 gin_trgm_ops);
@@ -0,0 +1,2 @@
+--changeset racerxdl:create_gpgkey_tombstone_table
+DROP TABLE chevron_gpg_key_tombstone;
@@ -0,0 +1,9 @@
+--changeset racerxdl:create_gpgkey_tombstone_table
+CREATE TABLE chevron_gpg_key_tombstone
+(
+    gpg_key_tombstone_fingerprint16 varchar   NOT NULL PRIMARY KEY,
+    gpg_key_tombstone_emails        text[]    NOT NULL DEFAULT '{}',
+    gpg_key_tombstone_reason        varchar   NOT NULL DEFAULT '',
+    gpg_key_tombstone_removed_by    varchar   NOT NULL DEFAULT '',
+    gpg_key_tombstone_created_at    timestamp NOT NULL DEFAULT now()
+);
@@ -0,0 +1,4 @@
+--changeset racerxdl:add_owner_requests_to_gpgkey_tombstone
+
+ALTER TABLE chevron_gpg_key_tombstone
+    ADD COLUMN gpg_key_tombstone_owner_requests text[] NOT NULL DEFAULT '{}';
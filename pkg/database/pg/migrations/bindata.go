@@ -8,6 +8,16 @@
 // migrations/000003_create_gpgkeyuid_table.up.sql
 // migrations/000004_add_username_to_user.down.sql
 // migrations/000004_add_username_to_user.up.sql
+// migrations/000005_create_cluster_node_table.down.sql
+// migrations/000005_create_cluster_node_table.up.sql
+// migrations/000006_add_verified_to_gpgkeyuid.down.sql
+// migrations/000006_add_verified_to_gpgkeyuid.up.sql
+// migrations/000007_add_gpgkey_search_indexes.down.sql
+// migrations/000007_add_gpgkey_search_indexes.up.sql
+// migrations/000008_create_gpgkey_tombstone_table.down.sql
+// migrations/000008_create_gpgkey_tombstone_table.up.sql
+// migrations/000009_add_owner_requests_to_gpgkey_tombstone.down.sql
+// migrations/000009_add_owner_requests_to_gpgkey_tombstone.up.sql
 package migrations
 
 import (
@@ -244,6 +254,206 @@ func _000004_add_username_to_userUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __000005_create_cluster_node_tableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x50\x00\xaf\xff\x2d\x2d\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x20\x72\x61\x63\x65\x72\x78\x64\x6c\x3a\x63\x72\x65\x61\x74\x65\x5f\x63\x6c\x75\x73\x74\x65\x72\x5f\x6e\x6f\x64\x65\x5f\x74\x61\x62\x6c\x65\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x65\x76\x72\x6f\x6e\x5f\x63\x6c\x75\x73\x74\x65\x72\x5f\x6e\x6f\x64\x65\x3b\x0a\x03\x00\xe7\x4c\x6f\xe3\x50\x00\x00\x00")
+
+func _000005_create_cluster_node_tableDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000005_create_cluster_node_tableDownSql,
+		"000005_create_cluster_node_table.down.sql",
+	)
+}
+
+func _000005_create_cluster_node_tableDownSql() (*asset, error) {
+	bytes, err := _000005_create_cluster_node_tableDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000005_create_cluster_node_table.down.sql", size: 80, mode: os.FileMode(420), modTime: time.Unix(1792426338, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __000005_create_cluster_node_tableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x6c\x8f\xc1\x4a\x03\x31\x10\x86\xef\x79\x8a\xff\xb8\x0b\xf6\x05\xec\x69\xb5\x11\x8a\x31\x95\x25\x05\x7b\x0a\x63\x32\xb8\x0b\x69\x56\x26\xb1\xf6\xf1\xbd\x28\x65\x31\x73\xfe\xbe\xf9\xf9\x36\x9b\x30\x51\xfe\xe0\xc2\x15\x42\x81\xe5\x1a\xd3\x7d\x10\xa6\xca\x3e\xa4\xaf\x52\x59\x7c\x5e\x22\xfb\x4a\xef\x89\xd5\xe3\xa8\x07\xa7\xe1\x86\x07\xa3\x11\x26\xbe\xc8\x92\x57\x9c\xea\x14\x00\xac\xd4\x39\xe2\xf7\x2e\x24\x61\x22\x01\x60\x0f\x0e\xf6\x68\x0c\x5e\xc7\xfd\xcb\x30\x9e\xf0\xac\x4f\x77\xff\x55\x8a\x51\xb8\x94\xa6\xda\xc0\x13\x95\xea\x0b\x73\x46\x9d\xcf\x5c\x2a\x9d\x3f\x6f\x4b\x3b\xfd\x34\x1c\x8d\x43\x5e\xbe\xbb\x5e\xf5\x5b\xf5\x57\xb3\xb7\x3b\xfd\xd6\xac\xb9\x3d\xf4\x73\xbc\xe2\x60\x9b\x14\xba\xb6\xd3\x6f\xd5\xcf\x00\x78\x83\x06\x2a\x5f\x01\x00\x00")
+
+func _000005_create_cluster_node_tableUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000005_create_cluster_node_tableUpSql,
+		"000005_create_cluster_node_table.up.sql",
+	)
+}
+
+func _000005_create_cluster_node_tableUpSql() (*asset, error) {
+	bytes, err := _000005_create_cluster_node_tableUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000005_create_cluster_node_table.up.sql", size: 351, mode: os.FileMode(420), modTime: time.Unix(1792426338, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __000006_add_verified_to_gpgkeyuidDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x76\x00\x89\xff\x2d\x2d\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x20\x72\x61\x63\x65\x72\x78\x64\x6c\x3a\x61\x64\x64\x5f\x76\x65\x72\x69\x66\x69\x65\x64\x5f\x74\x6f\x5f\x67\x70\x67\x6b\x65\x79\x75\x69\x64\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x65\x76\x72\x6f\x6e\x5f\x67\x70\x67\x5f\x6b\x65\x79\x5f\x75\x69\x64\x0a\x20\x20\x20\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x67\x70\x67\x5f\x6b\x65\x79\x5f\x75\x69\x64\x5f\x76\x65\x72\x69\x66\x69\x65\x64\x3b\x0a\x03\x00\x7c\x61\x19\x96\x76\x00\x00\x00")
+
+func _000006_add_verified_to_gpgkeyuidDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000006_add_verified_to_gpgkeyuidDownSql,
+		"000006_add_verified_to_gpgkeyuid.down.sql",
+	)
+}
+
+func _000006_add_verified_to_gpgkeyuidDownSql() (*asset, error) {
+	bytes, err := _000006_add_verified_to_gpgkeyuidDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000006_add_verified_to_gpgkeyuid.down.sql", size: 118, mode: os.FileMode(420), modTime: time.Unix(1792430755, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __000006_add_verified_to_gpgkeyuidUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4c\xcc\x31\x0e\x83\x20\x14\x87\xf1\xdd\x53\xfc\x2f\xe0\x05\xda\x09\x0b\x9d\x5e\x21\x69\x60\x26\x04\x9e\x48\x34\xda\xa0\x35\xf5\xf6\x8d\x4b\xd3\xf9\xf7\xe5\x6b\xdb\x38\x84\x39\xf3\xca\x1b\x6a\x88\x5c\x3f\x69\xba\x84\x94\xfc\xce\xb5\xf4\x85\x93\xdf\x16\x9f\x5f\x79\xe4\xe3\x5d\x52\xd3\x08\xb2\xea\x09\x2b\x3a\x52\x88\x03\xef\x75\x99\x4f\xf6\x23\x1f\xfe\x0c\x00\x40\x48\x89\x9b\x21\xf7\xd0\xf8\xa3\xdf\x11\x9d\x31\xa4\x84\x86\x36\x16\xda\x11\x41\xaa\xbb\x70\x64\xd1\x87\x69\xe5\x6b\xf3\x1d\x00\xbb\x39\x88\x7a\x94\x00\x00\x00")
+
+func _000006_add_verified_to_gpgkeyuidUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000006_add_verified_to_gpgkeyuidUpSql,
+		"000006_add_verified_to_gpgkeyuid.up.sql",
+	)
+}
+
+func _000006_add_verified_to_gpgkeyuidUpSql() (*asset, error) {
+	bytes, err := _000006_add_verified_to_gpgkeyuidUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000006_add_verified_to_gpgkeyuid.up.sql", size: 148, mode: os.FileMode(420), modTime: time.Unix(1792430755, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __000007_add_gpgkey_search_indexesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\xce\xb1\xae\xc2\x30\x0c\x46\xe1\xbd\x4f\xd1\x17\xe8\x70\x97\x3b\xd0\xb5\x0c\x2c\x80\x98\xd8\x2c\x2b\xfe\x49\x2c\x1a\x53\x39\x01\x85\xb7\x47\x1d\x58\x51\xf7\xef\x48\x67\x18\x42\x62\x8b\x28\xa8\xbd\x73\x80\x37\x99\x77\x2c\x42\x71\x89\x77\xbc\xa9\x80\x3d\x24\x52\x13\x34\x94\xae\x9b\x2e\xa7\x73\x7f\x38\x4e\xfb\x6b\x1f\x12\x5e\xfe\xb0\x55\xd2\x4a\x6f\x6a\x11\xbe\xb8\x5a\xfd\xfb\xa7\xea\x31\x93\x4a\x1b\x7f\x25\x4f\x15\x32\xce\xd8\xae\x91\x59\xe7\xed\xfc\xbb\x2f\x6d\xec\x3e\x03\x00\x80\xb3\xb2\xa2\xeb\x00\x00\x00")
+
+func _000007_add_gpgkey_search_indexesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000007_add_gpgkey_search_indexesDownSql,
+		"000007_add_gpgkey_search_indexes.down.sql",
+	)
+}
+
+func _000007_add_gpgkey_search_indexesDownSql() (*asset, error) {
+	bytes, err := _000007_add_gpgkey_search_indexesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000007_add_gpgkey_search_indexes.down.sql", size: 235, mode: os.FileMode(420), modTime: time.Unix(1792431013, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __000007_add_gpgkey_search_indexesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x91\x41\x4b\xc3\x30\x14\xc7\xef\xfd\x14\xef\x96\x04\xbb\x83\x17\x0f\xdb\x69\x6a\x95\x5e\x3a\xb0\x15\x06\x63\x86\x90\x3e\xd3\x60\x9a\x86\x24\xdb\x2a\xec\xc3\x4b\x37\x98\x43\x86\x56\xd8\x25\x97\xbc\xfc\xff\xbf\xf7\xcb\x64\x22\x1b\x61\x15\x06\x8c\xe0\x85\x44\xdf\xd7\x66\x2a\xea\x9a\x2b\xa7\x3e\xf0\x93\x07\x14\x5e\x36\x5c\xdb\x1a\x7b\x0c\x49\xf2\xf0\x92\xcd\xab\x0c\xb2\x65\x95\x15\x65\xbe\x28\x20\x7f\x82\x62\x51\x41\xb6\xcc\xcb\xaa\x04\xa7\x78\xf4\xaa\x9d\x9d\x06\xf3\xe2\x31\x5b\x82\x6c\x70\xeb\x3b\x3b\x84\xf2\x21\x75\xa3\xeb\x53\x72\xdd\xc3\xa2\xb8\x34\x01\xaf\x65\x5e\x3c\x83\xd2\x16\x68\x02\x00\x40\x03\xc6\x1d\x6a\xd5\x44\x1a\x3b\x1e\xc3\x16\x65\xec\x3c\x25\x41\xb7\xce\x20\x49\xc1\xa3\xc2\xde\x71\x8f\xce\x08\x89\xd4\x74\x3b\xf4\x54\x76\xc2\x60\x90\x48\xcf\xcb\xb1\x15\xda\xa4\x40\x08\x63\x29\x90\xd5\xdb\x6a\x2a\x8c\xdd\xb4\xd3\xf5\xfa\x86\xa4\x40\x60\x38\xd4\xf1\x72\x4e\x18\xec\xf7\x07\x00\xb8\x1e\x80\x15\x2d\x8e\xeb\xbf\x27\x8c\x25\x6c\x8c\xd1\xc3\x52\x07\xff\x23\xad\x8e\x13\x34\xfc\xc0\x31\xb5\x73\x81\xcd\xfe\xe6\x18\x76\xbb\x12\xc6\xb7\xa6\x7f\x51\xbc\x6b\xab\xd0\x3b\xaf\x6d\xbc\xbd\xfb\x0d\xe5\x1c\xe3\xe2\xe3\x9f\xb5\x5f\x03\x00\x58\x26\x78\x11\x30\x03\x00\x00")
+
+func _000007_add_gpgkey_search_indexesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000007_add_gpgkey_search_indexesUpSql,
+		"000007_add_gpgkey_search_indexes.up.sql",
+	)
+}
+
+func _000007_add_gpgkey_search_indexesUpSql() (*asset, error) {
+	bytes, err := _000007_add_gpgkey_search_indexesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000007_add_gpgkey_search_indexes.up.sql", size: 816, mode: os.FileMode(420), modTime: time.Unix(1792431013, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __000008_create_gpgkey_tombstone_tableDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x59\x00\xa6\xff\x2d\x2d\x63\x68\x61\x6e\x67\x65\x73\x65\x74\x20\x72\x61\x63\x65\x72\x78\x64\x6c\x3a\x63\x72\x65\x61\x74\x65\x5f\x67\x70\x67\x6b\x65\x79\x5f\x74\x6f\x6d\x62\x73\x74\x6f\x6e\x65\x5f\x74\x61\x62\x6c\x65\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x63\x68\x65\x76\x72\x6f\x6e\x5f\x67\x70\x67\x5f\x6b\x65\x79\x5f\x74\x6f\x6d\x62\x73\x74\x6f\x6e\x65\x3b\x0a\x03\x00\xde\x87\x7f\xc5\x59\x00\x00\x00")
+
+func _000008_create_gpgkey_tombstone_tableDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000008_create_gpgkey_tombstone_tableDownSql,
+		"000008_create_gpgkey_tombstone_table.down.sql",
+	)
+}
+
+func _000008_create_gpgkey_tombstone_tableDownSql() (*asset, error) {
+	bytes, err := _000008_create_gpgkey_tombstone_tableDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000008_create_gpgkey_tombstone_table.down.sql", size: 89, mode: os.FileMode(420), modTime: time.Unix(1792431493, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __000008_create_gpgkey_tombstone_tableUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x9c\xd0\xcf\x4a\xc3\x40\x10\xc7\xf1\xfb\x3e\xc5\xef\xd6\x16\xec\xc1\x8b\x07\x3d\x45\x5d\x41\x8c\x55\x42\x7a\x28\x22\xcb\x64\x33\x26\xc1\xec\x6e\xd8\x1d\x62\x8b\xf8\xee\x52\xfc\x83\x52\xeb\xa1\x73\x1c\xbe\x7c\x18\x66\x3e\xb7\x2d\xf9\x86\x13\x0b\x22\x59\x8e\xeb\xba\x3f\xb5\x91\x49\xd8\x34\x43\xf3\xcc\x1b\x23\xc1\x55\x49\x82\x67\x23\x54\xf5\xac\x2e\x0a\x9d\x95\x1a\x65\x76\x9e\x6b\xd8\x96\xc7\x18\xfc\xb6\x35\xbf\x62\x35\x55\x00\xb0\xb3\x37\x4f\x9d\x6f\x38\x0e\xb1\xf3\x72\x7c\x82\x91\xa2\x6d\x29\x02\x58\xdc\x95\x58\x2c\xf3\x1c\xf7\xc5\xf5\x6d\x56\xac\x70\xa3\x57\x47\x7b\x0c\x76\xd4\xf5\x09\x9f\x23\xbc\x96\x87\x47\xfc\x34\x2e\xf5\x55\xb6\xcc\x4b\x4c\x5e\xdf\x26\xfb\x90\xc8\x94\x82\xff\x42\xfe\x38\xe4\x1b\xf9\x87\x70\x61\xe4\xda\x54\x9b\x83\x89\x8f\x5f\xd7\x86\x64\x4b\x48\xe7\x38\x09\xb9\x61\x97\xf0\xe1\x65\x3a\x53\xb3\x33\xf5\x3e\x00\xf5\x41\x72\xa7\xb3\x01\x00\x00")
+
+func _000008_create_gpgkey_tombstone_tableUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000008_create_gpgkey_tombstone_tableUpSql,
+		"000008_create_gpgkey_tombstone_table.up.sql",
+	)
+}
+
+func _000008_create_gpgkey_tombstone_tableUpSql() (*asset, error) {
+	bytes, err := _000008_create_gpgkey_tombstone_tableUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000008_create_gpgkey_tombstone_table.up.sql", size: 435, mode: os.FileMode(420), modTime: time.Unix(1792431493, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __000009_add_owner_requests_to_gpgkey_tombstoneDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\xcd\x3d\x0e\xc2\x30\x0c\x06\xd0\x3d\xa7\xf0\x05\x7a\x01\x98\x0a\x74\x0b\x14\x55\x65\xb6\x42\xf2\x29\x95\x80\x18\x1c\xf3\x77\x7b\xc4\x08\xdd\x9f\xf4\x9a\x26\x4e\xa1\x64\x54\x18\x69\x88\xd0\x57\x3a\x2f\x42\x4a\x2c\xcf\x02\x65\xc5\xed\x8e\x6a\x95\x4d\x38\x5f\xf3\x09\x6f\x36\xb9\x1c\xab\x49\x81\x73\xad\x1f\xbb\x81\xc6\x76\xe5\x3b\x8a\x13\x1e\x2a\xe5\xab\xf8\x97\x11\x11\x6d\x86\x7e\x4f\xeb\xde\x1f\xb6\x3b\x9a\x89\xbf\x6b\xe9\x3e\x03\x00\xf8\x59\xf9\xe7\x95\x00\x00\x00")
+
+func _000009_add_owner_requests_to_gpgkey_tombstoneDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000009_add_owner_requests_to_gpgkey_tombstoneDownSql,
+		"000009_add_owner_requests_to_gpgkey_tombstone.down.sql",
+	)
+}
+
+func _000009_add_owner_requests_to_gpgkey_tombstoneDownSql() (*asset, error) {
+	bytes, err := _000009_add_owner_requests_to_gpgkey_tombstoneDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000009_add_owner_requests_to_gpgkey_tombstone.down.sql", size: 149, mode: os.FileMode(420), modTime: time.Unix(1792436286, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var __000009_add_owner_requests_to_gpgkey_tombstoneUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x64\xcd\xb1\x0a\xc2\x30\x10\x06\xe0\xbd\x4f\xf1\x6f\x9d\xfa\x02\x3a\x45\x5b\xa7\xb3\x05\x49\x27\x91\xa3\xb6\x47\x0a\x6a\xa2\xc9\xa9\x15\xf1\xdd\xc5\x51\xdd\x3f\xf8\x8a\xa2\x1f\x3b\xef\x24\x89\x22\x76\xbd\xc4\x69\x38\xce\xba\x61\xe0\x70\xf7\x12\x39\xca\xe5\x2a\x49\x13\x6b\x60\x77\x76\x07\x79\xb0\x86\xd3\x3e\x69\xf0\x92\x65\x86\x6c\xb5\x81\x35\x0b\xaa\xd0\x8f\x72\x8b\xc1\x7f\x14\x7f\x33\x00\x30\x65\x89\x65\x43\xed\xba\xc6\x1f\xf8\xa9\xa0\x32\xe9\x76\x87\xba\xb1\xa8\x5b\x22\x94\xd5\xca\xb4\x64\x91\x3f\x5f\xf9\x3c\x7b\x0f\x00\xe3\x57\x93\x32\xb1\x00\x00\x00")
+
+func _000009_add_owner_requests_to_gpgkey_tombstoneUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__000009_add_owner_requests_to_gpgkey_tombstoneUpSql,
+		"000009_add_owner_requests_to_gpgkey_tombstone.up.sql",
+	)
+}
+
+func _000009_add_owner_requests_to_gpgkey_tombstoneUpSql() (*asset, error) {
+	bytes, err := _000009_add_owner_requests_to_gpgkey_tombstoneUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "000009_add_owner_requests_to_gpgkey_tombstone.up.sql", size: 177, mode: os.FileMode(420), modTime: time.Unix(1792436286, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -296,25 +506,37 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"000001_create_users_table.down.sql":     _000001_create_users_tableDownSql,
-	"000001_create_users_table.up.sql":       _000001_create_users_tableUpSql,
-	"000002_create_gpgkey_table.down.sql":    _000002_create_gpgkey_tableDownSql,
-	"000002_create_gpgkey_table.up.sql":      _000002_create_gpgkey_tableUpSql,
-	"000003_create_gpgkeyuid_table.down.sql": _000003_create_gpgkeyuid_tableDownSql,
-	"000003_create_gpgkeyuid_table.up.sql":   _000003_create_gpgkeyuid_tableUpSql,
-	"000004_add_username_to_user.down.sql":   _000004_add_username_to_userDownSql,
-	"000004_add_username_to_user.up.sql":     _000004_add_username_to_userUpSql,
+	"000001_create_users_table.down.sql":                     _000001_create_users_tableDownSql,
+	"000001_create_users_table.up.sql":                       _000001_create_users_tableUpSql,
+	"000002_create_gpgkey_table.down.sql":                    _000002_create_gpgkey_tableDownSql,
+	"000002_create_gpgkey_table.up.sql":                      _000002_create_gpgkey_tableUpSql,
+	"000003_create_gpgkeyuid_table.down.sql":                 _000003_create_gpgkeyuid_tableDownSql,
+	"000003_create_gpgkeyuid_table.up.sql":                   _000003_create_gpgkeyuid_tableUpSql,
+	"000004_add_username_to_user.down.sql":                   _000004_add_username_to_userDownSql,
+	"000004_add_username_to_user.up.sql":                     _000004_add_username_to_userUpSql,
+	"000005_create_cluster_node_table.down.sql":              _000005_create_cluster_node_tableDownSql,
+	"000005_create_cluster_node_table.up.sql":                _000005_create_cluster_node_tableUpSql,
+	"000006_add_verified_to_gpgkeyuid.down.sql":              _000006_add_verified_to_gpgkeyuidDownSql,
+	"000006_add_verified_to_gpgkeyuid.up.sql":                _000006_add_verified_to_gpgkeyuidUpSql,
+	"000007_add_gpgkey_search_indexes.down.sql":              _000007_add_gpgkey_search_indexesDownSql,
+	"000007_add_gpgkey_search_indexes.up.sql":                _000007_add_gpgkey_search_indexesUpSql,
+	"000008_create_gpgkey_tombstone_table.down.sql":          _000008_create_gpgkey_tombstone_tableDownSql,
+	"000008_create_gpgkey_tombstone_table.up.sql":            _000008_create_gpgkey_tombstone_tableUpSql,
+	"000009_add_owner_requests_to_gpgkey_tombstone.down.sql": _000009_add_owner_requests_to_gpgkey_tombstoneDownSql,
+	"000009_add_owner_requests_to_gpgkey_tombstone.up.sql":   _000009_add_owner_requests_to_gpgkey_tombstoneUpSql,
 }
 
 // AssetDir returns the file names below a certain
 // directory embedded in the file by go-bindata.
 // For example if you run go-bindata on data/... and data contains the
 // following hierarchy:
-//     data/
-//       foo.txt
-//       img/
-//         a.png
-//         b.png
+//
+//	data/
+//	  foo.txt
+//	  img/
+//	    a.png
+//	    b.png
+//
 // then AssetDir("data") would return []string{"foo.txt", "img"}
 // AssetDir("data/img") would return []string{"a.png", "b.png"}
 // AssetDir("foo.txt") and AssetDir("notexist") would return an error
@@ -347,14 +569,24 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"000001_create_users_table.down.sql":     &bintree{_000001_create_users_tableDownSql, map[string]*bintree{}},
-	"000001_create_users_table.up.sql":       &bintree{_000001_create_users_tableUpSql, map[string]*bintree{}},
-	"000002_create_gpgkey_table.down.sql":    &bintree{_000002_create_gpgkey_tableDownSql, map[string]*bintree{}},
-	"000002_create_gpgkey_table.up.sql":      &bintree{_000002_create_gpgkey_tableUpSql, map[string]*bintree{}},
-	"000003_create_gpgkeyuid_table.down.sql": &bintree{_000003_create_gpgkeyuid_tableDownSql, map[string]*bintree{}},
-	"000003_create_gpgkeyuid_table.up.sql":   &bintree{_000003_create_gpgkeyuid_tableUpSql, map[string]*bintree{}},
-	"000004_add_username_to_user.down.sql":   &bintree{_000004_add_username_to_userDownSql, map[string]*bintree{}},
-	"000004_add_username_to_user.up.sql":     &bintree{_000004_add_username_to_userUpSql, map[string]*bintree{}},
+	"000001_create_users_table.down.sql":                     &bintree{_000001_create_users_tableDownSql, map[string]*bintree{}},
+	"000001_create_users_table.up.sql":                       &bintree{_000001_create_users_tableUpSql, map[string]*bintree{}},
+	"000002_create_gpgkey_table.down.sql":                    &bintree{_000002_create_gpgkey_tableDownSql, map[string]*bintree{}},
+	"000002_create_gpgkey_table.up.sql":                      &bintree{_000002_create_gpgkey_tableUpSql, map[string]*bintree{}},
+	"000003_create_gpgkeyuid_table.down.sql":                 &bintree{_000003_create_gpgkeyuid_tableDownSql, map[string]*bintree{}},
+	"000003_create_gpgkeyuid_table.up.sql":                   &bintree{_000003_create_gpgkeyuid_tableUpSql, map[string]*bintree{}},
+	"000004_add_username_to_user.down.sql":                   &bintree{_000004_add_username_to_userDownSql, map[string]*bintree{}},
+	"000004_add_username_to_user.up.sql":                     &bintree{_000004_add_username_to_userUpSql, map[string]*bintree{}},
+	"000005_create_cluster_node_table.down.sql":              &bintree{_000005_create_cluster_node_tableDownSql, map[string]*bintree{}},
+	"000005_create_cluster_node_table.up.sql":                &bintree{_000005_create_cluster_node_tableUpSql, map[string]*bintree{}},
+	"000006_add_verified_to_gpgkeyuid.down.sql":              &bintree{_000006_add_verified_to_gpgkeyuidDownSql, map[string]*bintree{}},
+	"000006_add_verified_to_gpgkeyuid.up.sql":                &bintree{_000006_add_verified_to_gpgkeyuidUpSql, map[string]*bintree{}},
+	"000007_add_gpgkey_search_indexes.down.sql":              &bintree{_000007_add_gpgkey_search_indexesDownSql, map[string]*bintree{}},
+	"000007_add_gpgkey_search_indexes.up.sql":                &bintree{_000007_add_gpgkey_search_indexesUpSql, map[string]*bintree{}},
+	"000008_create_gpgkey_tombstone_table.down.sql":          &bintree{_000008_create_gpgkey_tombstone_tableDownSql, map[string]*bintree{}},
+	"000008_create_gpgkey_tombstone_table.up.sql":            &bintree{_000008_create_gpgkey_tombstone_tableUpSql, map[string]*bintree{}},
+	"000009_add_owner_requests_to_gpgkey_tombstone.down.sql": &bintree{_000009_add_owner_requests_to_gpgkey_tombstoneDownSql, map[string]*bintree{}},
+	"000009_add_owner_requests_to_gpgkey_tombstone.up.sql":   &bintree{_000009_add_owner_requests_to_gpgkey_tombstoneUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory
@@ -0,0 +1,57 @@
+package rql
+
+import (
+	"time"
+
+	"github.com/quan-to/chevron/pkg/models"
+	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
+)
+
+var clusterNodeTableInit = tableInitStruct{
+	TableName:    "cluster_nodes",
+	TableIndexes: []string{"LastSeen"},
+}
+
+func (h *RethinkDBDriver) initClusterNodeTable() error {
+	return h.initFromStruct(clusterNodeTableInit)
+}
+
+// RegisterClusterNode adds or refreshes a cluster node in the registry
+func (h *RethinkDBDriver) RegisterClusterNode(node models.ClusterNode) error {
+	_, err := r.Table(clusterNodeTableInit.TableName).
+		Insert(map[string]interface{}{
+			"id":       node.ID,
+			"Address":  node.Address,
+			"LastSeen": r.Now(),
+		}, r.InsertOpts{Conflict: "update"}).
+		RunWrite(h.conn)
+
+	return err
+}
+
+// FetchClusterNodes returns all cluster nodes that had been seen after the specified time
+func (h *RethinkDBDriver) FetchClusterNodes(seenAfter time.Time) ([]models.ClusterNode, error) {
+	res, err := r.Table(clusterNodeTableInit.TableName).
+		Filter(r.Row.Field("LastSeen").Gt(seenAfter)).
+		Run(h.conn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	nodes := make([]models.ClusterNode, 0)
+	var rdata map[string]interface{}
+
+	for res.Next(&rdata) {
+		var node models.ClusterNode
+		err = convertFromRethinkDB(rdata, &node)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
@@ -2,7 +2,10 @@ package rql
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
+	"github.com/quan-to/chevron/pkg/database/search"
 	"github.com/quan-to/chevron/pkg/models"
 	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
 )
@@ -287,3 +290,52 @@ func (h *RethinkDBDriver) FindGPGKeyByName(name string, pageStart, pageEnd int)
 
 	return h.resultsAsArray(res)
 }
+
+// SearchGPGKeys finds the keys that match the query ranked by relevance.
+// Only the keys with a name or email containing one of the terms are ranked, so misspelled terms are not found
+func (h *RethinkDBDriver) SearchGPGKeys(query models.GPGKeySearchQuery) (*models.GPGKeySearchResult, error) {
+	terms := search.Terms(query.Value)
+	if len(terms) == 0 {
+		return search.Rank(nil, query)
+	}
+
+	for i, v := range terms {
+		terms[i] = regexp.QuoteMeta(v)
+	}
+
+	pattern := "(?i)" + strings.Join(terms, "|")
+
+	var filterTerms = func(r r.Term) interface{} {
+		return r.Match(pattern)
+	}
+
+	var filterSub = func(r r.Term) interface{} {
+		filter := r.Field("Emails").Filter(filterTerms).Count().Gt(0).
+			Or(r.Field("Names").Filter(filterTerms).Count().Gt(0))
+
+		if search.IsFingerPrint(query.Value) {
+			fp := strings.TrimPrefix(strings.TrimSpace(query.Value), "0x")
+			filter = filter.Or(r.Field("FullFingerprint").Match(fmt.Sprintf("(?i)%s$", fp)))
+		}
+
+		return filter
+	}
+
+	res, err := r.Table(gpgKeyTableInit.TableName).
+		Filter(filterSub).
+		CoerceTo("array").
+		Run(h.conn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	keys, err := h.resultsAsArray(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return search.Rank(keys, query)
+}
@@ -0,0 +1,73 @@
+package rql
+
+import (
+	"fmt"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/models"
+	r "gopkg.in/rethinkdb/rethinkdb-go.v6"
+)
+
+var gpgKeyTombstoneTableInit = tableInitStruct{
+	TableName:    "gpgKeyTombstone",
+	TableIndexes: []string{},
+}
+
+func (h *RethinkDBDriver) initGPGKeyTombstoneTable() error {
+	return h.initFromStruct(gpgKeyTombstoneTableInit)
+}
+
+// AddGPGKeyTombstone adds or replaces the tombstone of a key
+func (h *RethinkDBDriver) AddGPGKeyTombstone(tombstone models.GPGKeyTombstone) error {
+	emails := tombstone.Emails
+	if emails == nil {
+		emails = make([]string, 0)
+	}
+
+	ownerRequests := tombstone.OwnerRequests
+	if ownerRequests == nil {
+		ownerRequests = make([]string, 0)
+	}
+
+	_, err := r.Table(gpgKeyTombstoneTableInit.TableName).
+		Insert(map[string]interface{}{
+			"id":            tools.FPto16(tombstone.FingerPrint),
+			"Emails":        emails,
+			"Reason":        tombstone.Reason,
+			"RemovedBy":     tombstone.RemovedBy,
+			"CreatedAt":     tombstone.CreatedAt,
+			"OwnerRequests": ownerRequests,
+		}, r.InsertOpts{Conflict: "replace"}).
+		RunWrite(h.conn)
+
+	return err
+}
+
+// FetchGPGKeyTombstone fetches the tombstone of a key by its fingerprint
+func (h *RethinkDBDriver) FetchGPGKeyTombstone(fingerPrint string) (*models.GPGKeyTombstone, error) {
+	res, err := r.Table(gpgKeyTombstoneTableInit.TableName).
+		Get(tools.FPto16(fingerPrint)).
+		Run(h.conn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Close()
+
+	var rdata map[string]interface{}
+
+	if res.IsNil() || !res.Next(&rdata) {
+		return nil, fmt.Errorf("not found")
+	}
+
+	var t models.GPGKeyTombstone
+	err = convertFromRethinkDB(rdata, &t)
+	if err != nil {
+		return nil, err
+	}
+
+	t.FingerPrint = tools.FPto16(fingerPrint)
+
+	return &t, nil
+}
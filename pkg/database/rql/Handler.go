@@ -23,8 +23,9 @@ type RethinkDBDriver struct {
 	database string
 
 	// Migration tools
-	gpgKeysMigrationCursor *r.Cursor
-	userMigrationCursor    *r.Cursor
+	gpgKeysMigrationCursor   *r.Cursor
+	userMigrationCursor      *r.Cursor
+	userTokenMigrationCursor *r.Cursor
 }
 
 // MakeRethinkDBDriver creates a new database driver for rethinkdb
@@ -54,6 +55,8 @@ func (h *RethinkDBDriver) InitDatabase() error {
 		h.initUserTable,
 		h.initUserTokenTable,
 		h.initGPGKeyTable,
+		h.initClusterNodeTable,
+		h.initGPGKeyTombstoneTable,
 
 		// Migrations
 		h.migrateUserTable,
@@ -8,14 +8,15 @@ import (
 )
 
 func (h *RethinkDBDriver) InitCursor() error {
-	res, err := r.Table(gpgKeyTableInit.TableName).Run(h.conn)
+	// Ordered by primary key so an interrupted migration can be resumed
+	res, err := r.Table(gpgKeyTableInit.TableName).OrderBy(r.OrderByOpts{Index: "id"}).Run(h.conn)
 	if err != nil {
 		return err
 	}
 
 	h.gpgKeysMigrationCursor = res
 
-	res, err = r.Table(userModelTableInit.TableName).Run(h.conn)
+	res, err = r.Table(userModelTableInit.TableName).OrderBy(r.OrderByOpts{Index: "id"}).Run(h.conn)
 	if err != nil {
 		_ = h.gpgKeysMigrationCursor.Close()
 		h.gpgKeysMigrationCursor = nil
@@ -23,12 +24,21 @@ func (h *RethinkDBDriver) InitCursor() error {
 	}
 
 	h.userMigrationCursor = res
+
+	res, err = r.Table(userTokenTableInit.TableName).OrderBy(r.OrderByOpts{Index: "id"}).Run(h.conn)
+	if err != nil {
+		_ = h.FinishCursor()
+		return err
+	}
+
+	h.userTokenMigrationCursor = res
 	return nil
 }
 
 func (h *RethinkDBDriver) FinishCursor() error {
 	var gpgKeysError error
 	var userError error
+	var userTokenError error
 	if h.gpgKeysMigrationCursor != nil {
 		gpgKeysError = h.gpgKeysMigrationCursor.Close()
 		h.gpgKeysMigrationCursor = nil
@@ -39,11 +49,20 @@ func (h *RethinkDBDriver) FinishCursor() error {
 		h.userMigrationCursor = nil
 	}
 
+	if h.userTokenMigrationCursor != nil {
+		userTokenError = h.userTokenMigrationCursor.Close()
+		h.userTokenMigrationCursor = nil
+	}
+
 	if gpgKeysError != nil {
 		return gpgKeysError
 	}
 
-	return userError
+	if userError != nil {
+		return userError
+	}
+
+	return userTokenError
 }
 
 func (h *RethinkDBDriver) NextGPGKey(key *models.GPGKey) bool {
@@ -77,6 +96,21 @@ func (h *RethinkDBDriver) NextUser(user *models.User) bool {
 	return false
 }
 
+func (h *RethinkDBDriver) NextUserToken(token *models.UserToken) bool {
+	rdata := map[string]interface{}{}
+
+	if h.userTokenMigrationCursor.Next(&rdata) {
+		err := convertFromRethinkDB(rdata, token)
+		if err != nil {
+			h.log.Error("Error fetching next User Token: %s", err)
+			return false
+		}
+		return true
+	}
+
+	return false
+}
+
 func (h *RethinkDBDriver) NumGPGKeys() (int, error) {
 	res, err := r.Table(gpgKeyTableInit.TableName).Count().Run(h.conn)
 	if err != nil {
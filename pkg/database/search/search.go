@@ -0,0 +1,272 @@
+// Package search ranks GPG keys for the database drivers that do not have a full-text search engine
+package search
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// Scores of a term match. The score of a key is the average of its best match for each term
+const (
+	ExactScore       = 1.0
+	PrefixScore      = 0.8
+	SubstringScore   = 0.5
+	FuzzyScore       = 0.4 // Multiplied by the similarity
+	FingerPrintScore = 2.0
+)
+
+// Field weights of a term match
+const (
+	EmailWeight = 1.0
+	NameWeight  = 0.9
+)
+
+// MinSimilarity is the minimum trigram similarity of a fuzzy match, the same default of pg_trgm
+const MinSimilarity = 0.3
+
+// MinFingerPrintLength is the minimum length of a search value to be matched against the fingerprints
+const MinFingerPrintLength = 8
+
+// Terms splits a search value in lower case terms
+func Terms(value string) []string {
+	return strings.Fields(strings.ToLower(value))
+}
+
+// Words splits a lower case value in its alphanumeric words
+func Words(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// IsFingerPrint checks if the search value can be a fingerprint or key id
+func IsFingerPrint(value string) bool {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "0x")
+	if len(value) < MinFingerPrintLength {
+		return false
+	}
+
+	for _, c := range value {
+		if !unicode.Is(unicode.ASCII_Hex_Digit, c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func trigrams(word string) map[string]bool {
+	padded := []rune("  " + word + " ")
+	t := make(map[string]bool)
+
+	for i := 0; i+3 <= len(padded); i++ {
+		t[string(padded[i:i+3])] = true
+	}
+
+	return t
+}
+
+// Similarity returns the trigram similarity of two words, like pg_trgm similarity
+func Similarity(a, b string) float64 {
+	ta := trigrams(a)
+	tb := trigrams(b)
+
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+
+	union := len(ta) + len(tb) - common
+	if union == 0 {
+		return 0
+	}
+
+	return float64(common) / float64(union)
+}
+
+// termScore returns how well term matches a lower case value
+func termScore(term, value string) float64 {
+	if value == "" {
+		return 0
+	}
+
+	if value == term {
+		return ExactScore
+	}
+
+	words := Words(value)
+
+	if strings.HasPrefix(value, term) {
+		return PrefixScore
+	}
+
+	for _, w := range words {
+		if strings.HasPrefix(w, term) {
+			return PrefixScore
+		}
+	}
+
+	if strings.Contains(value, term) {
+		return SubstringScore
+	}
+
+	best := 0.0
+	for _, w := range words {
+		if s := Similarity(term, w); s >= MinSimilarity && s > best {
+			best = s
+		}
+	}
+
+	return best * FuzzyScore
+}
+
+// Score returns the relevance of key to the search value. Zero means no match.
+// If verifiedOnly is true only the verified user ids are matched, and keys without a verified user id never match
+func Score(key models.GPGKey, value string, verifiedOnly bool) float64 {
+	names := make([]string, 0)
+	emails := make([]string, 0)
+
+	for _, v := range key.KeyUids {
+		if verifiedOnly && !v.Verified {
+			continue
+		}
+		names = append(names, strings.ToLower(v.Name))
+		emails = append(emails, strings.ToLower(v.Email))
+	}
+
+	if len(key.KeyUids) == 0 && !verifiedOnly {
+		for _, v := range key.Names {
+			names = append(names, strings.ToLower(v))
+		}
+		for _, v := range key.Emails {
+			emails = append(emails, strings.ToLower(v))
+		}
+	}
+
+	if verifiedOnly && len(emails) == 0 {
+		return 0
+	}
+
+	if IsFingerPrint(value) {
+		fp := strings.ToUpper(strings.TrimPrefix(strings.TrimSpace(value), "0x"))
+		if strings.HasSuffix(strings.ToUpper(key.FullFingerprint), fp) {
+			return FingerPrintScore
+		}
+	}
+
+	terms := Terms(value)
+	if len(terms) == 0 {
+		return 0
+	}
+
+	total := 0.0
+
+	for _, term := range terms {
+		best := 0.0
+
+		for _, v := range emails {
+			if s := termScore(term, v) * EmailWeight; s > best {
+				best = s
+			}
+		}
+
+		for _, v := range names {
+			if s := termScore(term, v) * NameWeight; s > best {
+				best = s
+			}
+		}
+
+		if best == 0 {
+			// All terms should match
+			return 0
+		}
+
+		total += best
+	}
+
+	return total / float64(len(terms))
+}
+
+// EncodeCursor creates the cursor of the page that starts after the hit with the specified score and key id
+func EncodeCursor(score float64, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatFloat(score, 'g', -1, 64) + "|" + id))
+}
+
+// DecodeCursor returns the score and key id of a cursor created by EncodeCursor
+func DecodeCursor(cursor string) (float64, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid cursor")
+	}
+
+	parts := strings.SplitN(string(data), "|", 2)
+	if len(parts) != 2 {
+		return 0, "", fmt.Errorf("invalid cursor")
+	}
+
+	score, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid cursor")
+	}
+
+	return score, parts[1], nil
+}
+
+// After checks if a hit comes after the cursor position in the ranking order (score descending, id ascending)
+func After(score float64, id string, cursorScore float64, cursorId string) bool {
+	return score < cursorScore || (score == cursorScore && id > cursorId)
+}
+
+// Rank scores keys with query and returns the page of the query cursor
+func Rank(keys []models.GPGKey, query models.GPGKeySearchQuery) (*models.GPGKeySearchResult, error) {
+	hits := make([]models.GPGKeySearchHit, 0)
+
+	for _, v := range keys {
+		if s := Score(v, query.Value, query.VerifiedOnly); s > 0 {
+			hits = append(hits, models.GPGKeySearchHit{Key: v, Score: s})
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Key.ID < hits[j].Key.ID
+	})
+
+	result := &models.GPGKeySearchResult{
+		Hits:  make([]models.GPGKeySearchHit, 0),
+		Total: len(hits),
+	}
+
+	if query.Cursor != "" {
+		cursorScore, cursorId, err := DecodeCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		for len(hits) > 0 && !After(hits[0].Score, hits[0].Key.ID, cursorScore, cursorId) {
+			hits = hits[1:]
+		}
+	}
+
+	limit := query.GetLimit()
+
+	if len(hits) > limit {
+		last := hits[limit-1]
+		result.NextCursor = EncodeCursor(last.Score, last.Key.ID)
+		hits = hits[:limit]
+	}
+
+	result.Hits = append(result.Hits, hits...)
+
+	return result, nil
+}
@@ -0,0 +1,129 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+func testKey(id, fingerPrint, name, email string, verified bool) models.GPGKey {
+	return models.GPGKey{
+		ID:              id,
+		FullFingerprint: fingerPrint,
+		Names:           []string{name},
+		Emails:          []string{email},
+		KeyUids: []models.GPGKeyUid{
+			{Name: name, Email: email, Verified: verified},
+		},
+	}
+}
+
+var testKeys = []models.GPGKey{
+	testKey("1", "0551F452ABE463A4", "John HUEBR", "john@huebr.com", true),
+	testKey("2", "7A7A7A7A1234ABCD", "Johnny Bravo", "johnny@bravo.com", false),
+	testKey("3", "BCBCBCBC99990000", "Maria HUEBR", "maria@huebr.com", true),
+	testKey("4", "DEDEDEDE55556666", "Jonathan Doe", "jdoe@example.com", true),
+}
+
+func TestScore(t *testing.T) {
+	john := testKeys[0]
+
+	cases := []struct {
+		value    string
+		expected float64
+	}{
+		{"john@huebr.com", ExactScore},
+		{"joh", PrefixScore},
+		{"huebr", PrefixScore},
+		{"ebr", SubstringScore},
+		{"xyz", 0},
+		{"john maria", 0},
+		{"0551F452ABE463A4", FingerPrintScore},
+		{"0xabe463a4", FingerPrintScore},
+	}
+
+	for _, c := range cases {
+		if s := Score(john, c.value, false); s != c.expected {
+			t.Errorf("Expected score %f for %q, got %f", c.expected, c.value, s)
+		}
+	}
+
+	if s := Score(john, "huebt", false); s <= 0 || s >= SubstringScore {
+		t.Errorf("Expected a fuzzy score for a misspelled name, got %f", s)
+	}
+
+	if s := Score(testKeys[1], "johnny", true); s != 0 {
+		t.Errorf("Expected no score for a key without verified user ids, got %f", s)
+	}
+}
+
+func TestRank(t *testing.T) {
+	result, err := Rank(testKeys, models.GPGKeySearchQuery{Value: "huebr"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Total != 2 || len(result.Hits) != 2 || result.NextCursor != "" {
+		t.Fatalf("Expected 2 hits in a single page, got %d of %d", len(result.Hits), result.Total)
+	}
+
+	result, err = Rank(testKeys, models.GPGKeySearchQuery{Value: "john"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Total != 2 || result.Hits[0].Key.ID != "1" || result.Hits[1].Key.ID != "2" {
+		t.Fatalf("Expected keys 1 and 2 ranked for john, got %v", result.Hits)
+	}
+
+	result, err = Rank(testKeys, models.GPGKeySearchQuery{Value: "john", VerifiedOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Total != 1 || result.Hits[0].Key.ID != "1" {
+		t.Fatalf("Expected only the verified key 1, got %v", result.Hits)
+	}
+}
+
+func TestRankPagination(t *testing.T) {
+	keys := make([]models.GPGKey, 0)
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		keys = append(keys, testKey(id, "", "John HUEBR", id+"@huebr.com", true))
+	}
+
+	query := models.GPGKeySearchQuery{Value: "huebr", Limit: 2}
+	ids := ""
+	pages := 0
+
+	for {
+		result, err := Rank(keys, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Total != 5 {
+			t.Fatalf("Expected total of 5 in every page, got %d", result.Total)
+		}
+
+		for _, v := range result.Hits {
+			ids += v.Key.ID
+		}
+
+		pages++
+
+		if result.NextCursor == "" {
+			break
+		}
+
+		query.Cursor = result.NextCursor
+	}
+
+	if ids != "abcde" || pages != 3 {
+		t.Errorf("Expected abcde in 3 pages, got %s in %d pages", ids, pages)
+	}
+
+	if _, err := Rank(keys, models.GPGKeySearchQuery{Value: "huebr", Cursor: "!!"}); err == nil {
+		t.Errorf("Expected error with invalid cursor")
+	}
+}
@@ -0,0 +1,102 @@
+package fieldcipher
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BlindIndexPrefix is the prefix of blind index values
+const BlindIndexPrefix = "FCBI"
+
+// BlindIndexSuffix is appended to the field name to store its blind index in the same object
+const BlindIndexSuffix = "_bidx"
+
+const blindIndexKeySize = 32
+
+// BlindIndexer computes keyed blind indexes of field values, so encrypted fields can be searched by equality
+type BlindIndexer struct {
+	key []byte
+}
+
+// MakeBlindIndexer creates a BlindIndexer from a secret key of at least 32 bytes
+func MakeBlindIndexer(key []byte) (*BlindIndexer, error) {
+	if len(key) < blindIndexKeySize {
+		return nil, fmt.Errorf("blind index key should have at least %d bytes", blindIndexKeySize)
+	}
+
+	k := make([]byte, len(key))
+	copy(k, key)
+
+	return &BlindIndexer{
+		key: k,
+	}, nil
+}
+
+// Compute returns the blind index of value for the field selected by the JSONPath pattern.
+// Each pattern gets its own key, so equal values in different fields have different indexes
+func (b *BlindIndexer) Compute(field string, value interface{}) (string, error) {
+	p, err := ParseJSONPath(field)
+	if err != nil {
+		return "", err
+	}
+
+	return b.compute(p, value)
+}
+
+func (b *BlindIndexer) compute(field *JSONPath, value interface{}) (string, error) {
+	dataType, data, err := blindIndexPayload(value)
+	if err != nil {
+		return "", err
+	}
+
+	fieldKey := hmac.New(sha256.New, b.key)
+	_, _ = fieldKey.Write([]byte("chevron-fieldcipher-blind-index|" + field.canonical()))
+
+	mac := hmac.New(sha256.New, fieldKey.Sum(nil))
+	_, _ = mac.Write([]byte(fmt.Sprintf("(%s)%s", dataType, data)))
+
+	return BlindIndexPrefix + hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// blindIndexPayload returns the same type and value representation used to encrypt a field
+func blindIndexPayload(value interface{}) (string, string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", "null", nil
+	case bool:
+		return "bool", strconv.FormatBool(v), nil
+	case string:
+		return "string", v, nil
+	case float64:
+		return "float", strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return "float", strconv.FormatFloat(float64(v), 'f', -1, 64), nil
+	case int64:
+		return "float", strconv.FormatFloat(float64(v), 'f', -1, 64), nil
+	}
+
+	return "", "", fmt.Errorf("blind index is not supported for %T values", value)
+}
+
+// canonical returns a representation of the pattern that does not depend on the notation used
+func (p *JSONPath) canonical() string {
+	var sb strings.Builder
+	sb.WriteString("$")
+
+	for _, v := range p.segments {
+		sb.WriteString("[")
+		sb.WriteString(strconv.Quote(v))
+		sb.WriteString("]")
+	}
+
+	return sb.String()
+}
+
+// MatchExact returns true if the pattern selects exactly the node at path
+func (p *JSONPath) MatchExact(path []string) bool {
+	return len(p.segments) == len(path) && p.Match(path)
+}
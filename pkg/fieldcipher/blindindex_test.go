@@ -0,0 +1,92 @@
+package fieldcipher
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/quan-to/chevron/test"
+)
+
+func TestBlindIndexer_Compute(t *testing.T) {
+	if _, err := MakeBlindIndexer([]byte("short")); err == nil {
+		t.Fatalf("expected short key to be rejected")
+	}
+
+	indexer, _ := MakeBlindIndexer(bytes.Repeat([]byte{1}, 32))
+	other, _ := MakeBlindIndexer(bytes.Repeat([]byte{2}, 32))
+
+	a, err := indexer.Compute("$.customer.taxId", "12345")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	b, _ := indexer.Compute("$['customer']['taxId']", "12345")
+	if a != b {
+		t.Errorf("expected equivalent patterns to have the same index")
+	}
+
+	c, _ := indexer.Compute("$.customer.document", "12345")
+	d, _ := indexer.Compute("$.customer.taxId", 12345.0)
+	e, _ := other.Compute("$.customer.taxId", "12345")
+
+	if a == c || a == d || a == e {
+		t.Errorf("expected different fields, types and keys to have different indexes")
+	}
+
+	if _, err := indexer.Compute("$.customer", map[string]interface{}{}); err == nil {
+		t.Errorf("expected objects to be rejected")
+	}
+}
+
+func TestCipher_BlindIndex(t *testing.T) {
+	cipher := MakeCipherFromASCIIArmoredKeys([]string{test.TestPublicKey})
+	decipher := makeTestDecipher(t)
+	indexer, _ := MakeBlindIndexer(bytes.Repeat([]byte{1}, 32))
+
+	data := map[string]interface{}{
+		"customer": map[string]interface{}{"taxId": "12345"},
+		"docs": []interface{}{
+			map[string]interface{}{"number": "a1"},
+			map[string]interface{}{"number": "b2"},
+		},
+	}
+
+	policy := FieldPolicy{
+		BlindIndex: []string{"$.customer.taxId", "$.docs[*].number"},
+	}
+
+	if _, err := cipher.GenerateEncryptedPacketWithPolicy(data, policy); err == nil {
+		t.Fatalf("expected blind index without indexer to be rejected")
+	}
+
+	cipher.SetBlindIndexer(indexer)
+
+	packet, err := cipher.GenerateEncryptedPacketWithPolicy(data, policy)
+	if err != nil {
+		t.Fatalf("Error encrypting packet: %s", err)
+	}
+
+	expected, _ := indexer.Compute("$.customer.taxId", "12345")
+	customer := packet.EncryptedJSON["customer"].(map[string]interface{})
+
+	if customer["taxId"+BlindIndexSuffix] != expected {
+		t.Errorf("expected blind index %s got %v", expected, customer["taxId"+BlindIndexSuffix])
+	}
+
+	expected, _ = indexer.Compute("$.docs[*].number", "b2")
+	doc := packet.EncryptedJSON["docs"].([]interface{})[1].(map[string]interface{})
+
+	if doc["number"+BlindIndexSuffix] != expected {
+		t.Errorf("expected blind index %s got %v", expected, doc["number"+BlindIndexSuffix])
+	}
+
+	dec, err := decipher.DecipherPacket(*packet)
+	if err != nil {
+		t.Fatalf("Error decrypting packet: %s", err)
+	}
+
+	decCustomer := dec.DecryptedData["customer"].(map[string]interface{})
+	if len(decCustomer) != 1 || decCustomer["taxId"] != "12345" {
+		t.Errorf("expected blind indexes to be removed from decrypted data got %v", decCustomer)
+	}
+}
@@ -19,10 +19,19 @@ import (
 	"github.com/quan-to/slog"
 )
 
+// MAGIC is the prefix of FormatV1 encrypted fields
 const MAGIC = "FCMN"
 
+// MAGICV2 is the prefix of FormatV2 encrypted fields. It is not a prefix of MAGIC, so each field format can be detected
+const MAGICV2 = "FCM2"
+
+const gcmNonceSize = 12
+
 type Cipher struct {
 	publicKeys []*openpgp.Entity
+	version    int
+	signer     *openpgp.Entity
+	indexer    *BlindIndexer
 }
 
 func MakeCipherFromASCIIArmoredKeys(publicKeys []string) *Cipher {
@@ -46,53 +55,148 @@ func MakeCipherFromASCIIArmoredKeys(publicKeys []string) *Cipher {
 func MakeCipher(publicKeys []*openpgp.Entity) *Cipher {
 	return &Cipher{
 		publicKeys: publicKeys,
+		version:    CurrentFormat,
 	}
 }
 
+// SetFormatVersion changes the format of the generated packets. Use FormatV1 only for deciphers that does not support FormatV2
+func (c *Cipher) SetFormatVersion(version int) error {
+	if version != FormatV1 && version != FormatV2 {
+		return fmt.Errorf("unsupported format version %d", version)
+	}
+
+	c.version = version
+
+	return nil
+}
+
+// SetSigner makes the cipher sign the generated packets with an unlocked private key. Nil disables signing
+func (c *Cipher) SetSigner(signer *openpgp.Entity) error {
+	if signer != nil && (signer.PrivateKey == nil || signer.PrivateKey.Encrypted) {
+		return fmt.Errorf("signer private key is not unlocked")
+	}
+
+	c.signer = signer
+
+	return nil
+}
+
+// SetBlindIndexer sets the indexer used to compute the blind indexes requested by FieldPolicy.BlindIndex
+func (c *Cipher) SetBlindIndexer(indexer *BlindIndexer) {
+	c.indexer = indexer
+}
+
 func (c *Cipher) GenerateEncryptedPacket(data map[string]interface{}, skipFields []string) (*CipherPacket, error) {
-	key, err := GenerateKey()
+	return c.generateEncryptedPacket(data, skipFields, FieldPolicy{})
+}
+
+// GenerateEncryptedPacketWithPolicy encrypts the fields selected by policy. Each recipient group gets its own key
+func (c *Cipher) GenerateEncryptedPacketWithPolicy(data map[string]interface{}, policy FieldPolicy) (*CipherPacket, error) {
+	if len(policy.Groups) > 0 && c.version == FormatV1 {
+		return nil, fmt.Errorf("recipient groups require format version %d", FormatV2)
+	}
+
+	if len(policy.BlindIndex) > 0 && c.indexer == nil {
+		return nil, fmt.Errorf("blind index requested but no blind indexer was set")
+	}
+
+	return c.generateEncryptedPacket(data, nil, policy)
+}
+
+func (c *Cipher) generateEncryptedPacket(data map[string]interface{}, skipFields []string, policy FieldPolicy) (*CipherPacket, error) {
+	selector, err := makeFieldSelector(skipFields, policy)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ec := &encryptionContext{
+		selector:    selector,
+		keys:        map[string][]byte{},
+		groupFields: map[string][]string{},
+	}
+
+	// Clear the memory to let GC run whenever it can and we dont keep the keys in the ram memory
+	defer ec.clearKeys()
+
+	ec.keys[""], err = GenerateKey()
 
 	if err != nil {
 		return nil, fmt.Errorf("error generating key: %s", err)
 	}
 
+	for _, g := range policy.Groups {
+		if _, ok := ec.keys[g.Name]; ok {
+			return nil, fmt.Errorf("duplicated recipient group %s", g.Name)
+		}
+
+		ec.keys[g.Name], err = GenerateKey()
+
+		if err != nil {
+			return nil, fmt.Errorf("error generating key: %s", err)
+		}
+	}
+
 	jsonBytes, err := json.Marshal(data)
 
 	if err != nil {
 		return nil, fmt.Errorf("error serializing data: %s", err)
 	}
 
-	jsonData := string(jsonBytes)
-
-	encJson, err := c.EncryptJSONFields(jsonData, key, skipFields)
+	var realData map[string]interface{}
 
+	err = json.Unmarshal(jsonBytes, &realData)
 	if err != nil {
-		return nil, fmt.Errorf("error ciphering packet: %s", err)
+		return nil, fmt.Errorf("error serializing data: %s", err)
 	}
 
-	encKey, err := c.PGPEncryptToBase64(key, "field-cipher-key.gpg")
+	encJson, err := c.encryptJsonField(realData, ec, "/", nil)
 
 	if err != nil {
 		return nil, fmt.Errorf("error ciphering packet: %s", err)
 	}
 
-	// Clear the memory to let GC run whenever it can and we dont keep the key in the ram memory
-	for i := 0; i < len(key); i++ {
-		key[i] = 0x00
+	encKey, err := c.pgpEncryptToBase64(c.publicKeys, ec.keys[""], "field-cipher-key.gpg")
+
+	if err != nil {
+		return nil, fmt.Errorf("error ciphering packet: %s", err)
 	}
 
-	return &CipherPacket{
+	packet := &CipherPacket{
+		Version:       c.version,
 		EncryptedJSON: encJson,
 		EncryptedKey:  encKey,
-	}, nil
+	}
+
+	for _, g := range policy.Groups {
+		if packet.Groups == nil {
+			packet.Groups = map[string]CipherGroup{}
+		}
+
+		groupKey, err := c.pgpEncryptToBase64(g.PublicKeys, ec.keys[g.Name], "field-cipher-key.gpg")
+
+		if err != nil {
+			return nil, fmt.Errorf("error ciphering key for group %s: %s", g.Name, err)
+		}
+
+		packet.Groups[g.Name] = CipherGroup{
+			EncryptedKey: groupKey,
+			Fields:       ec.groupFields[g.Name],
+		}
+	}
+
+	if c.signer != nil {
+		err = SignPacket(packet, c.signer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return packet, nil
 }
 
 func (c *Cipher) EncryptJSONFields(jsonData string, key []byte, skipFields []string) (map[string]interface{}, error) {
 	// We want to receive a json string so we can constrain the types for the cipher
-	if skipFields == nil {
-		skipFields = make([]string, 0)
-	}
-
 	var realData map[string]interface{}
 
 	err := json.Unmarshal([]byte(jsonData), &realData)
@@ -100,7 +204,18 @@ func (c *Cipher) EncryptJSONFields(jsonData string, key []byte, skipFields []str
 		return nil, err
 	}
 
-	encData, err := c.encryptJsonField(realData, key, "/", skipFields)
+	selector, err := makeFieldSelector(skipFields, FieldPolicy{})
+	if err != nil {
+		return nil, err
+	}
+
+	ec := &encryptionContext{
+		selector:    selector,
+		keys:        map[string][]byte{"": key},
+		groupFields: map[string][]string{},
+	}
+
+	encData, err := c.encryptJsonField(realData, ec, "/", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -108,7 +223,31 @@ func (c *Cipher) EncryptJSONFields(jsonData string, key []byte, skipFields []str
 	return encData, nil
 }
 
-func (c *Cipher) encryptJsonField(data map[string]interface{}, baseKey []byte, currentLevel string, skipFields []string) (map[string]interface{}, error) {
+// encryptionContext holds the keys and field selection of a packet being encrypted
+type encryptionContext struct {
+	selector *fieldSelector
+	// keys are the AES keys of each recipient group. The default recipients are in the empty group
+	keys        map[string][]byte
+	groupFields map[string][]string
+}
+
+func (ec *encryptionContext) clearKeys() {
+	for _, key := range ec.keys {
+		for i := 0; i < len(key); i++ {
+			key[i] = 0x00
+		}
+	}
+}
+
+func childPath(path []string, segment string) []string {
+	p := make([]string, len(path)+1)
+	copy(p, path)
+	p[len(path)] = segment
+
+	return p
+}
+
+func (c *Cipher) encryptJsonField(data map[string]interface{}, ec *encryptionContext, currentLevel string, path []string) (map[string]interface{}, error) {
 	var err error
 	encData := map[string]interface{}{}
 
@@ -116,32 +255,53 @@ func (c *Cipher) encryptJsonField(data map[string]interface{}, baseKey []byte, c
 		nodePath := currentLevel + base64.StdEncoding.EncodeToString([]byte(k)) + "/"
 
 		// Check if its in skip
-		if tools.StringIndexOf(nodePath, skipFields) > -1 {
+		if ec.selector.skip(nodePath) {
 			encData[k] = v
 			continue
 		}
 
-		// Check if its an object
-		v2, ok := v.(map[string]interface{})
-		if ok {
-			encData[k], err = c.encryptJsonField(v2, baseKey, nodePath, skipFields)
-			if err != nil {
-				return nil, fmt.Errorf("error serializing field %s: %s", nodePath, err)
-			}
-			continue
-		}
+		fieldPath := childPath(path, k)
 
-		// Otherwise, its an node
-		encData[k], err = c.encryptNode(v, baseKey, nodePath, skipFields)
+		encData[k], err = c.encryptNode(v, ec, nodePath, fieldPath)
 		if err != nil {
 			return nil, fmt.Errorf("error serializing field %s: %s", nodePath, err)
 		}
+
+		err = c.addBlindIndex(data, encData, k, v, ec, fieldPath)
+		if err != nil {
+			return nil, fmt.Errorf("error indexing field %s: %s", nodePath, err)
+		}
 	}
 
 	return encData, nil
 }
 
-func (c *Cipher) encryptNode(obj interface{}, baseKey []byte, currentLevel string, skipFields []string) (interface{}, error) {
+func (c *Cipher) addBlindIndex(data, encData map[string]interface{}, k string, v interface{}, ec *encryptionContext, path []string) error {
+	field := ec.selector.blindIndexField(path)
+	if field == nil {
+		return nil
+	}
+
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return fmt.Errorf("blind index is only supported for values")
+	}
+
+	if _, ok := data[k+BlindIndexSuffix]; ok {
+		return fmt.Errorf("field %s already exists", k+BlindIndexSuffix)
+	}
+
+	idx, err := c.indexer.compute(field, v)
+	if err != nil {
+		return err
+	}
+
+	encData[k+BlindIndexSuffix] = idx
+
+	return nil
+}
+
+func (c *Cipher) encryptNode(obj interface{}, ec *encryptionContext, currentLevel string, path []string) (interface{}, error) {
 	// The Golang Unmarshal have these output types:
 	// bool, for JSON booleans
 	// float64, for JSON numbers
@@ -150,48 +310,51 @@ func (c *Cipher) encryptNode(obj interface{}, baseKey []byte, currentLevel strin
 	// map[string]interface{}, for JSON objects
 	// nil for JSON null
 
+	switch v := obj.(type) {
+	case []interface{}:
+		return c.encryptArray(v, ec, currentLevel, path)
+	case map[string]interface{}:
+		return c.encryptJsonField(v, ec, currentLevel, path)
+	}
+
+	encrypt, group := ec.selector.classify(path)
+	if !encrypt {
+		return obj, nil
+	}
+
+	baseKey := ec.keys[group]
+	if group != "" {
+		ec.groupFields[group] = append(ec.groupFields[group], currentLevel)
+	}
+
 	if obj == nil {
 		return c.encryptNull(baseKey, currentLevel)
 	}
 
 	switch v := obj.(type) {
-	case []interface{}:
-		return c.encryptArray(v, baseKey, currentLevel, skipFields)
 	case bool:
 		return c.encryptBool(v, baseKey, currentLevel)
 	case float64:
 		return c.encryptFloat64(v, baseKey, currentLevel)
 	case string:
 		return c.encryptString(v, baseKey, currentLevel)
-	case map[string]interface{}:
-		return c.encryptJsonField(v, baseKey, currentLevel, skipFields)
 	}
 
 	return nil, fmt.Errorf("unknown type %s", reflect.TypeOf(obj))
 }
 
-func (c *Cipher) encryptArray(obj []interface{}, baseKey []byte, currentLevel string, skipFields []string) ([]interface{}, error) {
+func (c *Cipher) encryptArray(obj []interface{}, ec *encryptionContext, currentLevel string, path []string) ([]interface{}, error) {
 	var err error
 	out := make([]interface{}, len(obj))
 	for i, v := range obj {
 		nodePath := currentLevel + base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%d", i))) + "/"
 
-		if tools.StringIndexOf(nodePath, skipFields) > -1 {
+		if ec.selector.skip(nodePath) {
 			out[i] = v
 			continue
 		}
-		switch v2 := v.(type) {
-		case bool:
-			out[i], err = c.encryptBool(v2, baseKey, nodePath)
-		case string:
-			out[i], err = c.encryptString(v2, baseKey, nodePath)
-		case float64:
-			out[i], err = c.encryptFloat64(v2, baseKey, nodePath)
-		case map[string]interface{}:
-			out[i], err = c.encryptJsonField(v2, baseKey, nodePath, skipFields)
-		default:
-			return nil, fmt.Errorf("unknown type %s", reflect.TypeOf(v))
-		}
+
+		out[i], err = c.encryptNode(v, ec, nodePath, childPath(path, strconv.Itoa(i)))
 		if err != nil {
 			return nil, err
 		}
@@ -201,23 +364,27 @@ func (c *Cipher) encryptArray(obj []interface{}, baseKey []byte, currentLevel st
 }
 
 func (c *Cipher) encryptBool(v bool, baseKey []byte, currentLevel string) (string, error) {
-	payload := c.genDataPayload("bool", strconv.FormatBool(v), currentLevel)
-	return AESEncrypt(payload, baseKey)
+	return c.encryptPayload("bool", strconv.FormatBool(v), baseKey, currentLevel)
 }
 
 func (c *Cipher) encryptString(v string, baseKey []byte, currentLevel string) (string, error) {
-	payload := c.genDataPayload("string", v, currentLevel)
-	return AESEncrypt(payload, baseKey)
+	return c.encryptPayload("string", v, baseKey, currentLevel)
 }
 
 func (c *Cipher) encryptNull(baseKey []byte, currentLevel string) (string, error) {
-	payload := c.genDataPayload("null", "null", currentLevel)
-	return AESEncrypt(payload, baseKey)
+	return c.encryptPayload("null", "null", baseKey, currentLevel)
 }
 
 func (c *Cipher) encryptFloat64(v float64, baseKey []byte, currentLevel string) (string, error) {
-	payload := c.genDataPayload("float", strconv.FormatFloat(v, 'f', -1, 64), currentLevel)
-	return AESEncrypt(payload, baseKey)
+	return c.encryptPayload("float", strconv.FormatFloat(v, 'f', -1, 64), baseKey, currentLevel)
+}
+
+func (c *Cipher) encryptPayload(dataType, data string, baseKey []byte, currentLevel string) (string, error) {
+	if c.version == FormatV1 {
+		return AESEncrypt(c.genDataPayload(dataType, data, currentLevel), baseKey)
+	}
+
+	return AESGCMEncrypt([]byte(fmt.Sprintf("(%s)%s", dataType, data)), baseKey, []byte(currentLevel))
 }
 
 func (c *Cipher) genDataPayload(dataType, data, currentLevel string) []byte {
@@ -225,6 +392,10 @@ func (c *Cipher) genDataPayload(dataType, data, currentLevel string) []byte {
 }
 
 func (c *Cipher) PGPEncryptToBase64(data []byte, filename string) (string, error) {
+	return c.pgpEncryptToBase64(c.publicKeys, data, filename)
+}
+
+func (c *Cipher) pgpEncryptToBase64(publicKeys []*openpgp.Entity, data []byte, filename string) (string, error) {
 	hints := &openpgp.FileHints{
 		FileName: filename,
 		IsBinary: true,
@@ -240,7 +411,7 @@ func (c *Cipher) PGPEncryptToBase64(data []byte, filename string) (string, error
 
 	buf := bytes.NewBuffer(nil)
 
-	closer, err := openpgp.Encrypt(buf, c.publicKeys, nil, hints, config)
+	closer, err := openpgp.Encrypt(buf, publicKeys, nil, hints, config)
 	if err != nil {
 		return "", err
 	}
@@ -306,3 +477,26 @@ func AESEncrypt(data, baseKey []byte) (string, error) {
 
 	return MAGIC + base64.StdEncoding.EncodeToString(output), nil
 }
+
+// AESGCMEncrypt encrypts data with AES-GCM authenticating additionalData and returns it in FormatV2
+func AESGCMEncrypt(data, baseKey, additionalData []byte) (string, error) {
+	block, err := aes.NewCipher(baseKey)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	output := gcm.Seal(nonce, nonce, data, additionalData)
+
+	return MAGICV2 + base64.StdEncoding.EncodeToString(output), nil
+}
@@ -1,6 +1,7 @@
 package fieldcipher
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/quan-to/chevron/test"
@@ -40,3 +41,55 @@ func TestCipher_GenerateEncryptedPacket(t *testing.T) {
 		t.Errorf("expected /oe to be %v got %v", dataToCipher["oe"], cipheredData["oe"])
 	}
 }
+
+func TestCipher_GenerateEncryptedPacketWithPolicy(t *testing.T) {
+	cipher := MakeCipherFromASCIIArmoredKeys([]string{test.TestPublicKey})
+	_ = cipher.SetFormatVersion(FormatV2)
+
+	dataToCipher := map[string]interface{}{
+		"id": "abcd",
+		"customer": map[string]interface{}{
+			"name":  "HUEBR",
+			"taxId": "12345",
+		},
+		"items": []interface{}{
+			map[string]interface{}{"name": "a", "price": 10.0},
+			map[string]interface{}{"name": "b", "price": 20.0},
+		},
+	}
+
+	packet, err := cipher.GenerateEncryptedPacketWithPolicy(dataToCipher, FieldPolicy{
+		Include: []string{"$.customer", "$.items[*].price"},
+		Exclude: []string{"$.customer.name"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	enc := packet.EncryptedJSON
+	customer := enc["customer"].(map[string]interface{})
+	items := enc["items"].([]interface{})
+
+	if enc["id"] != "abcd" || customer["name"] != "HUEBR" {
+		t.Errorf("expected not included and excluded fields to be kept in plain text")
+	}
+
+	if !strings.HasPrefix(customer["taxId"].(string), MAGICV2) {
+		t.Errorf("expected /customer/taxId/ to be encrypted")
+	}
+
+	for i, v := range items {
+		item := v.(map[string]interface{})
+		if _, ok := item["name"].(string); !ok || strings.HasPrefix(item["name"].(string), MAGICV2) {
+			t.Errorf("expected /items/%d/name/ to be kept in plain text", i)
+		}
+		if _, ok := item["price"].(string); !ok {
+			t.Errorf("expected /items/%d/price/ to be encrypted", i)
+		}
+	}
+
+	_, err = cipher.GenerateEncryptedPacketWithPolicy(dataToCipher, FieldPolicy{Include: []string{"customer"}})
+	if err == nil {
+		t.Errorf("expected invalid jsonpath to be rejected")
+	}
+}
@@ -18,9 +18,11 @@ import (
 )
 
 var fieldMatchRegex = regexp.MustCompile(`\(([a-zA-Z0-9]*)\)\[([\-A-Za-z0-9+/\\=]*)\](.*)`)
+var fieldV2MatchRegex = regexp.MustCompile(`(?s)^\(([a-zA-Z0-9]*)\)(.*)$`)
 
 type Decipher struct {
-	privateKey openpgp.EntityList
+	privateKey       openpgp.EntityList
+	verificationKeys openpgp.KeyRing
 }
 
 func MakeDecipherWithASCIIPrivateKey(privateKey string) (*Decipher, error) {
@@ -38,6 +40,11 @@ func MakeDecipher(privateKey openpgp.EntityList) (*Decipher, error) {
 	}, nil
 }
 
+// SetVerificationKeys sets the public keys used to verify signed packets
+func (d *Decipher) SetVerificationKeys(keyring openpgp.KeyRing) {
+	d.verificationKeys = keyring
+}
+
 func (d *Decipher) Unlock(password string) bool {
 	for _, v := range d.privateKey.DecryptionKeys() {
 		err := v.PrivateKey.Decrypt([]byte(password))
@@ -49,20 +56,56 @@ func (d *Decipher) Unlock(password string) bool {
 	return true
 }
 
+// DecipherPacket decrypts all fields of the packet. Fails if any field cannot be decrypted with our keys
 func (d *Decipher) DecipherPacket(packet CipherPacket) (*DecipherPacket, error) {
+	return d.decipherPacket(packet, false)
+}
+
+// DecipherPacketPartial decrypts every field it can instead of failing on the first bad one.
+// The result has the decrypted subset of the data and the status of each encrypted field in Fields
+func (d *Decipher) DecipherPacketPartial(packet CipherPacket) (*DecipherPacket, error) {
+	return d.decipherPacket(packet, true)
+}
+
+func (d *Decipher) decipherPacket(packet CipherPacket, partial bool) (*DecipherPacket, error) {
 	_, err := base64.StdEncoding.DecodeString(packet.EncryptedKey)
 
 	if err != nil {
 		return nil, fmt.Errorf("invalid encrypted key: %s", err)
 	}
 
-	decryptedKey, err := d.pgpDecrypt(packet.EncryptedKey)
+	// Packets without version, like the ones generated before the versioning, have each field format detected by its magic
+	version := packet.Version
+	if version != 0 && version != FormatV1 && version != FormatV2 {
+		return nil, fmt.Errorf("unsupported format version %d", version)
+	}
 
-	if err != nil {
-		return nil, fmt.Errorf("error decrypting key: %s", err)
+	dc := &decryptionContext{
+		version:   version,
+		fieldKeys: map[string][]byte{},
+		partial:   partial,
+	}
+
+	decryptedKey, keyErr := d.pgpDecrypt(packet.EncryptedKey)
+	dc.defaultKey = decryptedKey
+	readableGroups := 0
+
+	for _, group := range packet.Groups {
+		groupKey, err := d.pgpDecrypt(group.EncryptedKey)
+		if err == nil {
+			readableGroups++
+		}
+
+		for _, field := range group.Fields {
+			dc.fieldKeys[field] = groupKey
+		}
+	}
+
+	if keyErr != nil && readableGroups == 0 && !partial {
+		return nil, fmt.Errorf("error decrypting key: %s", keyErr)
 	}
 
-	unmatchedFields, data, err := d.DecryptJsonFields(packet.EncryptedJSON, decryptedKey)
+	unmatchedFields, data, err := d.decryptJsonObject(packet.EncryptedJSON, dc, "/", nil)
 
 	if err != nil {
 		return nil, err
@@ -73,18 +116,114 @@ func (d *Decipher) DecipherPacket(packet CipherPacket) (*DecipherPacket, error)
 		unmatchedFields[i].Got = CipherPathUnmangle(unmatchedFields[i].Got)
 	}
 
-	return &DecipherPacket{
+	result := &DecipherPacket{
 		UnmatchedFields: unmatchedFields,
 		DecryptedData:   data,
 		JSONChanged:     len(unmatchedFields) > 0,
-	}, nil
+		Fields:          dc.results,
+		Signed:          packet.Signature != "",
+	}
+
+	if result.Signed {
+		result.SignerFingerprint, err = VerifyPacket(packet, d.verificationKeys)
+		result.SignatureValid = err == nil
+		if err != nil {
+			result.SignatureError = err.Error()
+		}
+	}
+
+	return result, nil
 }
 
+// DecryptJsonFields decrypts FormatV1 fields
 func (d *Decipher) DecryptJsonFields(data map[string]interface{}, baseKey []byte) ([]UnmatchedField, map[string]interface{}, error) {
-	return d.decryptJsonObject(data, baseKey, "/", nil)
+	return d.DecryptJsonFieldsWithVersion(data, baseKey, FormatV1)
+}
+
+// DecryptJsonFieldsWithVersion decrypts the fields encrypted in the specified format version
+func (d *Decipher) DecryptJsonFieldsWithVersion(data map[string]interface{}, baseKey []byte, version int) ([]UnmatchedField, map[string]interface{}, error) {
+	if version != FormatV1 && version != FormatV2 {
+		return nil, nil, fmt.Errorf("unsupported format version %d", version)
+	}
+
+	return d.decryptJsonObject(data, &decryptionContext{version: version, defaultKey: baseKey}, "/", nil)
+}
+
+// decryptionContext holds the keys of a packet being decrypted
+type decryptionContext struct {
+	version    int
+	defaultKey []byte
+	// fieldKeys are the recipient group keys by field cipher path. Nil for groups that could not be decrypted
+	fieldKeys map[string][]byte
+	// partial keeps decrypting after a field fails, recording the status of each field in results
+	partial bool
+	results []FieldResult
+}
+
+// fieldError is an error decrypting a single field
+type fieldError struct {
+	status string
+	err    error
 }
 
-func (d *Decipher) decryptJsonObject(data map[string]interface{}, baseKey []byte, currentLevel string, unmatchedFields []UnmatchedField) ([]UnmatchedField, map[string]interface{}, error) {
+func (e *fieldError) Error() string {
+	return e.err.Error()
+}
+
+func makeFieldError(status, format string, args ...interface{}) *fieldError {
+	return &fieldError{
+		status: status,
+		err:    fmt.Errorf(format, args...),
+	}
+}
+
+// fieldFormat returns the format of an encrypted field by its magic, or zero if the field is not encrypted
+func fieldFormat(stringVal string) int {
+	if strings.HasPrefix(stringVal, MAGICV2) {
+		return FormatV2
+	}
+
+	if strings.HasPrefix(stringVal, MAGIC) {
+		return FormatV1
+	}
+
+	return 0
+}
+
+// handleFieldResult records the result of an encrypted field. Returns false if the field should be omitted
+func (dc *decryptionContext) handleFieldResult(currentLevel string, err error) (bool, error) {
+	if !dc.partial {
+		return err == nil, err
+	}
+
+	result := FieldResult{
+		Path:   CipherPathUnmangle(currentLevel),
+		Status: FieldDecrypted,
+	}
+
+	if err != nil {
+		fe, ok := err.(*fieldError)
+		if !ok {
+			return false, err
+		}
+		result.Status = fe.status
+		result.Error = fe.Error()
+	}
+
+	dc.results = append(dc.results, result)
+
+	return err == nil, nil
+}
+
+func (dc *decryptionContext) keyFor(currentLevel string) []byte {
+	if key, ok := dc.fieldKeys[currentLevel]; ok {
+		return key
+	}
+
+	return dc.defaultKey
+}
+
+func (d *Decipher) decryptJsonObject(data map[string]interface{}, dc *decryptionContext, currentLevel string, unmatchedFields []UnmatchedField) ([]UnmatchedField, map[string]interface{}, error) {
 	if unmatchedFields == nil {
 		unmatchedFields = make([]UnmatchedField, 0)
 	}
@@ -92,15 +231,24 @@ func (d *Decipher) decryptJsonObject(data map[string]interface{}, baseKey []byte
 	decData := make(map[string]interface{})
 
 	for k, v := range data {
+		if isBlindIndex(data, k, v) {
+			continue
+		}
+
 		nodePath := currentLevel + base64.StdEncoding.EncodeToString([]byte(k)) + "/"
 
 		switch v2 := v.(type) {
 		case map[string]interface{}:
-			unmatchedFields, decData[k], err = d.decryptJsonObject(v2, baseKey, nodePath, unmatchedFields)
+			unmatchedFields, decData[k], err = d.decryptJsonObject(v2, dc, nodePath, unmatchedFields)
 		case []interface{}:
-			unmatchedFields, decData[k], err = d.decryptArray(v2, baseKey, nodePath, unmatchedFields)
+			unmatchedFields, decData[k], err = d.decryptArray(v2, dc, nodePath, unmatchedFields)
 		default:
-			unmatchedFields, decData[k], err = d.decryptNode(v, baseKey, nodePath, unmatchedFields)
+			var value interface{}
+			var ok bool
+			unmatchedFields, value, ok, err = d.decryptField(v, dc, nodePath, unmatchedFields)
+			if ok {
+				decData[k] = value
+			}
 		}
 
 		if err != nil {
@@ -111,7 +259,22 @@ func (d *Decipher) decryptJsonObject(data map[string]interface{}, baseKey []byte
 	return unmatchedFields, decData, nil
 }
 
-func (d *Decipher) decryptArray(data []interface{}, baseKey []byte, currentLevel string, unmatchedFields []UnmatchedField) ([]UnmatchedField, interface{}, error) {
+// isBlindIndex returns true if the field k is the blind index of another field in data
+func isBlindIndex(data map[string]interface{}, k string, v interface{}) bool {
+	if !strings.HasSuffix(k, BlindIndexSuffix) {
+		return false
+	}
+
+	if _, ok := data[strings.TrimSuffix(k, BlindIndexSuffix)]; !ok {
+		return false
+	}
+
+	idx, ok := v.(string)
+
+	return ok && strings.HasPrefix(idx, BlindIndexPrefix)
+}
+
+func (d *Decipher) decryptArray(data []interface{}, dc *decryptionContext, currentLevel string, unmatchedFields []UnmatchedField) ([]UnmatchedField, interface{}, error) {
 	var err error
 	outArray := make([]interface{}, len(data))
 
@@ -120,11 +283,12 @@ func (d *Decipher) decryptArray(data []interface{}, baseKey []byte, currentLevel
 
 		switch v2 := v.(type) {
 		case map[string]interface{}:
-			unmatchedFields, outArray[i], err = d.decryptJsonObject(v2, baseKey, nodePath, unmatchedFields)
+			unmatchedFields, outArray[i], err = d.decryptJsonObject(v2, dc, nodePath, unmatchedFields)
 		case []interface{}:
-			unmatchedFields, outArray[i], err = d.decryptArray(v2, baseKey, nodePath, unmatchedFields)
+			unmatchedFields, outArray[i], err = d.decryptArray(v2, dc, nodePath, unmatchedFields)
 		default:
-			unmatchedFields, outArray[i], err = d.decryptNode(v, baseKey, nodePath, unmatchedFields)
+			// Omitted array items are kept as null to not change the other indexes
+			unmatchedFields, outArray[i], _, err = d.decryptField(v, dc, nodePath, unmatchedFields)
 		}
 
 		if err != nil {
@@ -135,38 +299,69 @@ func (d *Decipher) decryptArray(data []interface{}, baseKey []byte, currentLevel
 	return unmatchedFields, outArray, nil
 }
 
-func (d *Decipher) decryptNode(data interface{}, baseKey []byte, currentLevel string, unmatchedFields []UnmatchedField) ([]UnmatchedField, interface{}, error) {
+// decryptField decrypts a leaf node. Returns false if the field could not be decrypted in partial mode
+func (d *Decipher) decryptField(data interface{}, dc *decryptionContext, currentLevel string, unmatchedFields []UnmatchedField) ([]UnmatchedField, interface{}, bool, error) {
 	stringVal, ok := data.(string)
+	format := fieldFormat(stringVal)
+
+	if !ok || format == 0 { // Not Encrypted
+		return unmatchedFields, data, true, nil
+	}
+
+	if dc.version == FormatV2 && format == FormatV1 { // Unauthenticated field in an authenticated packet
+		_, err := dc.handleFieldResult(currentLevel, makeFieldError(FieldTampered, "field %s is not in format version %d", CipherPathUnmangle(currentLevel), FormatV2))
+		return unmatchedFields, nil, false, err
+	}
+
+	baseKey := dc.keyFor(currentLevel)
+	if baseKey == nil { // Not readable with our keys. Only partial decipher returns the readable subset
+		_, err := dc.handleFieldResult(currentLevel, makeFieldError(FieldNotAuthorized, "no key available for field %s", CipherPathUnmangle(currentLevel)))
+		return unmatchedFields, nil, false, err
+	}
+
+	var value interface{}
+	var err error
+
+	if format == FormatV2 {
+		value, err = d.decryptNodeV2(stringVal, baseKey, currentLevel)
+	} else {
+		unmatchedFields, value, err = d.decryptNode(stringVal, baseKey, currentLevel, unmatchedFields, dc.partial)
+	}
 
-	if !ok { // If not string, not encrypted
-		return unmatchedFields, data, nil
+	ok, err = dc.handleFieldResult(currentLevel, err)
+	if err != nil {
+		return nil, nil, false, err
 	}
 
-	if len(stringVal) < len(MAGIC) || stringVal[:len(MAGIC)] != MAGIC { // Not Encrypted
-		return unmatchedFields, data, nil
+	if !ok {
+		return unmatchedFields, nil, false, nil
 	}
 
+	return unmatchedFields, value, true, nil
+}
+
+func (d *Decipher) decryptNode(stringVal string, baseKey []byte, currentLevel string, unmatchedFields []UnmatchedField, rejectMoved bool) ([]UnmatchedField, interface{}, error) {
 	stringVal = stringVal[len(MAGIC):]
 	encryptedData, err := base64.StdEncoding.DecodeString(stringVal)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("error decrypting field %s: %s", CipherPathUnmangle(currentLevel), err)
+		return unmatchedFields, nil, makeFieldError(FieldTampered, "error decrypting field %s: %s", CipherPathUnmangle(currentLevel), err)
 	}
 
 	decryptedData, err := AESDecrypt(encryptedData, baseKey)
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("error decrypting field %s: %s", CipherPathUnmangle(currentLevel), err)
+		return unmatchedFields, nil, makeFieldError(FieldTampered, "error decrypting field %s: %s", CipherPathUnmangle(currentLevel), err)
 	}
 
 	if !fieldMatchRegex.MatchString(decryptedData) {
-		return nil, nil, fmt.Errorf("invalid decrypted data: %s", decryptedData)
+		return unmatchedFields, nil, makeFieldError(FieldTampered, "invalid decrypted data: %s", decryptedData)
 	}
 
 	fields := fieldMatchRegex.FindStringSubmatch(decryptedData)
 
 	if len(fields) != 4 {
-		return nil, nil, fmt.Errorf("invalid decrypted data: %s", decryptedData)
+		return unmatchedFields, nil, makeFieldError(FieldTampered, "invalid decrypted data: %s", decryptedData)
 	}
 
 	dataType := fields[1]
@@ -181,9 +376,45 @@ func (d *Decipher) decryptNode(data interface{}, baseKey []byte, currentLevel st
 			Expected: nodePath,
 			Got:      currentLevel,
 		})
+
+		if rejectMoved {
+			return unmatchedFields, nil, makeFieldError(FieldTampered, "field %s was moved from %s", CipherPathUnmangle(currentLevel), CipherPathUnmangle(nodePath))
+		}
+	}
+
+	objData, err := parseFieldData(dataType, dataData, currentLevel)
+	if err != nil {
+		return unmatchedFields, nil, err
+	}
+
+	return unmatchedFields, objData, nil
+}
+
+func (d *Decipher) decryptNodeV2(stringVal string, baseKey []byte, currentLevel string) (interface{}, error) {
+	encryptedData, err := base64.StdEncoding.DecodeString(stringVal[len(MAGICV2):])
+
+	if err != nil {
+		return nil, makeFieldError(FieldTampered, "error decrypting field %s: %s", CipherPathUnmangle(currentLevel), err)
 	}
 
+	decryptedData, err := AESGCMDecrypt(encryptedData, baseKey, []byte(currentLevel))
+
+	if err != nil {
+		return nil, makeFieldError(FieldTampered, "error decrypting field %s: %s", CipherPathUnmangle(currentLevel), err)
+	}
+
+	fields := fieldV2MatchRegex.FindStringSubmatch(decryptedData)
+
+	if len(fields) != 3 {
+		return nil, makeFieldError(FieldTypeError, "invalid decrypted data at %s", CipherPathUnmangle(currentLevel))
+	}
+
+	return parseFieldData(fields[1], fields[2], currentLevel)
+}
+
+func parseFieldData(dataType, dataData, currentLevel string) (interface{}, error) {
 	var objData interface{}
+	var err error
 
 	switch dataType {
 	case "string":
@@ -201,14 +432,14 @@ func (d *Decipher) decryptNode(data interface{}, baseKey []byte, currentLevel st
 		objData = nil
 		err = nil
 	default:
-		return nil, nil, fmt.Errorf("unknown type %s at %s", dataType, CipherPathUnmangle(currentLevel))
+		return nil, makeFieldError(FieldTypeError, "unknown type %s at %s", dataType, CipherPathUnmangle(currentLevel))
 	}
 
 	if err != nil {
-		return nil, nil, fmt.Errorf("error parsing data at %s: %s", CipherPathUnmangle(currentLevel), err)
+		return nil, makeFieldError(FieldTypeError, "error parsing data at %s: %s", CipherPathUnmangle(currentLevel), err)
 	}
 
-	return unmatchedFields, objData, nil
+	return objData, nil
 }
 
 func (d *Decipher) pgpDecrypt(data string) ([]byte, error) {
@@ -254,6 +485,10 @@ func AESDecrypt(data, baseKey []byte) (string, error) {
 		return "", err
 	}
 
+	if len(data) < 16 || len(data)%aes.BlockSize != 0 {
+		return "", fmt.Errorf("invalid encrypted data size")
+	}
+
 	iv := data[:16]
 	data = data[16:]
 
@@ -265,3 +500,27 @@ func AESDecrypt(data, baseKey []byte) (string, error) {
 
 	return string(output), nil
 }
+
+// AESGCMDecrypt decrypts a FormatV2 field checking its authenticity against additionalData
+func AESGCMDecrypt(data, baseKey, additionalData []byte) (string, error) {
+	block, err := aes.NewCipher(baseKey)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	if len(data) < gcmNonceSize {
+		return "", fmt.Errorf("encrypted data too short")
+	}
+
+	output, err := gcm.Open(nil, data[:gcmNonceSize], data[gcmNonceSize:], additionalData)
+	if err != nil {
+		return "", fmt.Errorf("field has been tampered or moved: %s", err)
+	}
+
+	return string(output), nil
+}
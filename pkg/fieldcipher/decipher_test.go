@@ -2,8 +2,10 @@ package fieldcipher
 
 import (
 	"io/ioutil"
+	"strings"
 	"testing"
 
+	"github.com/quan-to/chevron/internal/tools"
 	"github.com/quan-to/chevron/test"
 )
 
@@ -77,3 +79,308 @@ func TestDecipher_DecipherPacket(t *testing.T) {
 		}
 	}
 }
+
+func makeTestDecipher(t *testing.T) *Decipher {
+	keyData, err := ioutil.ReadFile("../../test/data/testkey_privateTestKey.gpg")
+
+	if err != nil {
+		t.Fatalf("Error reading private key: %s", err)
+	}
+
+	keyPass, err := ioutil.ReadFile("../../test/data/testprivatekeyPassword.txt")
+
+	if err != nil {
+		t.Fatalf("Error reading private key password: %s", err)
+	}
+
+	decipher, err := MakeDecipherWithASCIIPrivateKey(string(keyData))
+
+	if err != nil {
+		t.Fatalf("Error loading private key: %s", err)
+	}
+
+	if !decipher.Unlock(string(keyPass)) {
+		t.Fatalf("Error decrypting private key")
+	}
+
+	return decipher
+}
+
+func TestDecipher_DecipherPacketV2(t *testing.T) {
+	cipher := MakeCipherFromASCIIArmoredKeys([]string{test.TestPublicKey})
+	_ = cipher.SetFormatVersion(FormatV2)
+	decipher := makeTestDecipher(t)
+
+	dataToCipher := map[string]interface{}{
+		"a": "b",
+		"c": "d\x00\nline",
+		"e": map[string]interface{}{
+			"v": []interface{}{1.0, "2", true},
+			"t": nil,
+		},
+		"bb": true,
+		"oe": 1234.5,
+	}
+
+	packet, err := cipher.GenerateEncryptedPacket(dataToCipher, []string{CipherPathCombine("a")})
+	if err != nil {
+		t.Fatalf("Error encrypting packet: %s", err)
+	}
+
+	if packet.Version != FormatV2 {
+		t.Errorf("expected packet version to be %d got %d", FormatV2, packet.Version)
+	}
+
+	if !strings.HasPrefix(packet.EncryptedJSON["c"].(string), MAGICV2) {
+		t.Errorf("expected /c/ to be encrypted with %s", MAGICV2)
+	}
+
+	decPacket, err := decipher.DecipherPacket(*packet)
+	if err != nil {
+		t.Fatalf("Error decrypting packet: %s", err)
+	}
+
+	if decPacket.DecryptedData["c"] != dataToCipher["c"] {
+		t.Errorf("expected /c/ to be %q got %q", dataToCipher["c"], decPacket.DecryptedData["c"])
+	}
+
+	if decPacket.DecryptedData["oe"] != dataToCipher["oe"] || decPacket.DecryptedData["bb"] != true {
+		t.Errorf("expected /oe/ and /bb/ to be preserved got %v and %v", decPacket.DecryptedData["oe"], decPacket.DecryptedData["bb"])
+	}
+
+	v := decPacket.DecryptedData["e"].(map[string]interface{})["v"].([]interface{})
+	if v[0] != 1.0 || v[1] != "2" || v[2] != true {
+		t.Errorf("expected /e/v/ to be preserved got %v", v)
+	}
+
+	// Clients that do not send the version back still get each field format detected
+	packet.Version = 0
+	if decPacket, err := decipher.DecipherPacket(*packet); err != nil || decPacket.DecryptedData["c"] != dataToCipher["c"] {
+		t.Errorf("expected packet without version to be decrypted, got %v", err)
+	}
+	packet.Version = FormatV2
+
+	// Swapped fields must fail authentication
+	bb := packet.EncryptedJSON["bb"]
+	packet.EncryptedJSON["bb"] = packet.EncryptedJSON["oe"]
+	packet.EncryptedJSON["oe"] = bb
+
+	if _, err := decipher.DecipherPacket(*packet); err == nil {
+		t.Errorf("expected swapped fields to be rejected")
+	}
+
+	packet.EncryptedJSON["oe"] = packet.EncryptedJSON["bb"]
+	packet.EncryptedJSON["bb"] = bb
+
+	// Tampered ciphertext must fail authentication
+	c := packet.EncryptedJSON["c"].(string)
+	tampered := []byte(c)
+	pos := len(MAGICV2) + 20
+	if tampered[pos] == 'A' {
+		tampered[pos] = 'B'
+	} else {
+		tampered[pos] = 'A'
+	}
+	packet.EncryptedJSON["c"] = string(tampered)
+
+	if _, err := decipher.DecipherPacket(*packet); err == nil {
+		t.Errorf("expected tampered field to be rejected")
+	}
+}
+
+func TestDecipher_DecipherLegacyPacket(t *testing.T) {
+	cipher := MakeCipherFromASCIIArmoredKeys([]string{test.TestPublicKey})
+	_ = cipher.SetFormatVersion(FormatV1)
+	decipher := makeTestDecipher(t)
+
+	packet, err := cipher.GenerateEncryptedPacket(map[string]interface{}{"a": "b"}, nil)
+	if err != nil {
+		t.Fatalf("Error encrypting packet: %s", err)
+	}
+
+	packet.Version = 0 // Packets without version are legacy
+
+	decPacket, err := decipher.DecipherPacket(*packet)
+	if err != nil {
+		t.Fatalf("Error decrypting packet: %s", err)
+	}
+
+	if decPacket.DecryptedData["a"] != "b" {
+		t.Errorf("expected /a/ to be b got %v", decPacket.DecryptedData["a"])
+	}
+
+	packet.Version = FormatV2
+	if _, err := decipher.DecipherPacket(*packet); err == nil {
+		t.Errorf("expected legacy fields to be rejected in a version 2 packet")
+	}
+
+	if err := cipher.SetFormatVersion(3); err == nil {
+		t.Errorf("expected unsupported version to be rejected")
+	}
+}
+
+func TestDecipher_DecipherPacketWithGroups(t *testing.T) {
+	// Default recipients are a key we cannot read, compliance group is the test key
+	cipher := MakeCipherFromASCIIArmoredKeys([]string{test.TestPublicKeyManySubkeys})
+	_ = cipher.SetFormatVersion(FormatV2)
+	decipher := makeTestDecipher(t)
+	complianceKeys, _ := tools.ReadKey(test.TestPublicKey)
+
+	dataToCipher := map[string]interface{}{
+		"amount": 1234.5,
+		"customer": map[string]interface{}{
+			"taxId": "12345",
+		},
+	}
+
+	packet, err := cipher.GenerateEncryptedPacketWithPolicy(dataToCipher, FieldPolicy{
+		Groups: []RecipientGroup{
+			{
+				Name:       "compliance",
+				Fields:     []string{"$.customer.taxId"},
+				PublicKeys: complianceKeys,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encrypting packet: %s", err)
+	}
+
+	group, ok := packet.Groups["compliance"]
+	if !ok || len(group.Fields) != 1 || group.Fields[0] != CipherPathCombine("customer", "taxId") {
+		t.Fatalf("expected compliance group with /customer/taxId/ got %v", packet.Groups)
+	}
+
+	// Full decipher fails on fields we cannot read
+	if _, err := decipher.DecipherPacket(*packet); err == nil {
+		t.Errorf("expected packet with unreadable fields to be rejected")
+	}
+
+	decPacket, err := decipher.DecipherPacketPartial(*packet)
+	if err != nil {
+		t.Fatalf("Error decrypting packet: %s", err)
+	}
+
+	if decPacket.DecryptedData["customer"].(map[string]interface{})["taxId"] != "12345" {
+		t.Errorf("expected /customer/taxId/ to be decrypted")
+	}
+
+	if _, ok := decPacket.DecryptedData["amount"]; ok {
+		t.Errorf("expected /amount/ to be omitted")
+	}
+
+	// Removing a field from the group must not make it readable
+	packet.Groups["compliance"] = CipherGroup{EncryptedKey: group.EncryptedKey}
+	decPacket, err = decipher.DecipherPacketPartial(*packet)
+	if err != nil {
+		t.Fatalf("Error decrypting packet: %s", err)
+	}
+
+	if decPacket.DecryptedData["customer"].(map[string]interface{})["taxId"] == "12345" {
+		t.Errorf("expected /customer/taxId/ to be kept encrypted")
+	}
+
+	delete(packet.Groups, "compliance")
+	if _, err := decipher.DecipherPacket(*packet); err == nil {
+		t.Errorf("expected packet without readable key to be rejected")
+	}
+
+	v1 := MakeCipherFromASCIIArmoredKeys([]string{test.TestPublicKey})
+	_ = v1.SetFormatVersion(FormatV1)
+	_, err = v1.GenerateEncryptedPacketWithPolicy(dataToCipher, FieldPolicy{Groups: []RecipientGroup{{Name: "a", PublicKeys: complianceKeys}}})
+	if err == nil {
+		t.Errorf("expected groups to be rejected in format version 1")
+	}
+}
+
+func TestDecipher_DecipherPacketPartial(t *testing.T) {
+	cipher := MakeCipherFromASCIIArmoredKeys([]string{test.TestPublicKey})
+	_ = cipher.SetFormatVersion(FormatV2)
+	decipher := makeTestDecipher(t)
+	otherKeys, _ := tools.ReadKey(test.TestPublicKeyManySubkeys)
+
+	dataToCipher := map[string]interface{}{
+		"a":        "b",
+		"amount":   1234.5,
+		"tampered": "c",
+		"moved":    true,
+		"customer": map[string]interface{}{
+			"taxId": "12345",
+		},
+		"items": []interface{}{"x", "y"},
+	}
+
+	packet, err := cipher.GenerateEncryptedPacketWithPolicy(dataToCipher, FieldPolicy{
+		Exclude: []string{"$.a"},
+		Groups: []RecipientGroup{
+			{
+				Name:       "compliance",
+				Fields:     []string{"$.customer.taxId"},
+				PublicKeys: otherKeys,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Error encrypting packet: %s", err)
+	}
+
+	packet.EncryptedJSON["tampered"] = packet.EncryptedJSON["tampered"].(string)[:len(MAGICV2)+4] + "AAAA" + packet.EncryptedJSON["tampered"].(string)[len(MAGICV2)+8:]
+	packet.EncryptedJSON["moved"] = packet.EncryptedJSON["amount"]
+	typeError, _ := AESGCMEncrypt([]byte("(int)abc"), mustDecryptKey(t, decipher, packet.EncryptedKey), []byte(CipherPathCombine("items", "1")))
+	packet.EncryptedJSON["items"].([]interface{})[1] = typeError
+
+	if _, err := decipher.DecipherPacket(*packet); err == nil {
+		t.Fatalf("expected full decipher to fail")
+	}
+
+	decPacket, err := decipher.DecipherPacketPartial(*packet)
+	if err != nil {
+		t.Fatalf("Error decrypting packet: %s", err)
+	}
+
+	expected := map[string]string{
+		"/amount/":         FieldDecrypted,
+		"/tampered/":       FieldTampered,
+		"/moved/":          FieldTampered,
+		"/customer/taxId/": FieldNotAuthorized,
+		"/items/0/":        FieldDecrypted,
+		"/items/1/":        FieldTypeError,
+	}
+
+	if len(decPacket.Fields) != len(expected) {
+		t.Errorf("expected %d field results got %d", len(expected), len(decPacket.Fields))
+	}
+
+	for _, v := range decPacket.Fields {
+		if expected[v.Path] != v.Status {
+			t.Errorf("expected %s to be %q got %q (%s)", v.Path, expected[v.Path], v.Status, v.Error)
+		}
+	}
+
+	data := decPacket.DecryptedData
+	if data["a"] != "b" || data["amount"] != 1234.5 {
+		t.Errorf("expected readable fields to be in decrypted data")
+	}
+
+	if _, ok := data["tampered"]; ok {
+		t.Errorf("expected tampered field to be omitted")
+	}
+
+	if _, ok := data["customer"].(map[string]interface{})["taxId"]; ok {
+		t.Errorf("expected not authorized field to be omitted")
+	}
+
+	items := data["items"].([]interface{})
+	if items[0] != "x" || items[1] != nil {
+		t.Errorf("expected failed array item to be null got %v", items)
+	}
+}
+
+func mustDecryptKey(t *testing.T, decipher *Decipher, encryptedKey string) []byte {
+	key, err := decipher.pgpDecrypt(encryptedKey)
+	if err != nil {
+		t.Fatalf("Error decrypting key: %s", err)
+	}
+
+	return key
+}
@@ -0,0 +1,119 @@
+package fieldcipher
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const jsonPathWildcard = "*"
+
+// JSONPath is a compiled JSONPath pattern like $.customer.taxId or $.items[*].price
+// Only child (.name, ['name']), index ([0]) and wildcard (.*, [*]) selectors are supported
+type JSONPath struct {
+	pattern  string
+	segments []string
+}
+
+// ParseJSONPath compiles a JSONPath pattern
+func ParseJSONPath(pattern string) (*JSONPath, error) {
+	p := strings.TrimSpace(pattern)
+	if !strings.HasPrefix(p, "$") {
+		return nil, fmt.Errorf("invalid jsonpath %q: should start with $", pattern)
+	}
+
+	p = p[1:]
+	segments := make([]string, 0)
+
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			if strings.HasPrefix(p, ".") {
+				return nil, fmt.Errorf("invalid jsonpath %q: recursive descent is not supported", pattern)
+			}
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: empty field name", pattern)
+			}
+			segments = append(segments, p[:end])
+			p = p[end:]
+		case '[':
+			end := strings.Index(p, "]")
+			if end == -1 {
+				return nil, fmt.Errorf("invalid jsonpath %q: unclosed bracket", pattern)
+			}
+			selector := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+
+			if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				segments = append(segments, selector[1:len(selector)-1])
+				continue
+			}
+
+			if selector != jsonPathWildcard {
+				if _, err := strconv.ParseUint(selector, 10, 64); err != nil {
+					return nil, fmt.Errorf("invalid jsonpath %q: invalid selector [%s]", pattern, selector)
+				}
+			}
+
+			segments = append(segments, selector)
+		default:
+			return nil, fmt.Errorf("invalid jsonpath %q: unexpected %q", pattern, p[0])
+		}
+	}
+
+	return &JSONPath{
+		pattern:  pattern,
+		segments: segments,
+	}, nil
+}
+
+// ParseJSONPaths compiles a list of JSONPath patterns
+func ParseJSONPaths(patterns []string) ([]*JSONPath, error) {
+	paths := make([]*JSONPath, 0, len(patterns))
+
+	for _, v := range patterns {
+		p, err := ParseJSONPath(v)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+
+	return paths, nil
+}
+
+// String returns the original pattern
+func (p *JSONPath) String() string {
+	return p.pattern
+}
+
+// Match returns true if the pattern selects the node at path or one of its parents.
+// Array indexes should be in path as decimal strings
+func (p *JSONPath) Match(path []string) bool {
+	if len(p.segments) > len(path) {
+		return false
+	}
+
+	for i, v := range p.segments {
+		if v != jsonPathWildcard && v != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchAnyJSONPath(paths []*JSONPath, path []string) bool {
+	for _, v := range paths {
+		if v.Match(path) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package fieldcipher
+
+import (
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	valid := map[string][]string{
+		"$":                    {},
+		"$.customer.taxId":     {"customer", "taxId"},
+		"$.items[*].price":     {"items", "*", "price"},
+		"$['first name'][0].*": {"first name", "0", "*"},
+	}
+
+	for pattern, segments := range valid {
+		p, err := ParseJSONPath(pattern)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %s", pattern, err)
+		}
+
+		if len(p.segments) != len(segments) {
+			t.Fatalf("expected %q to have segments %v got %v", pattern, segments, p.segments)
+		}
+
+		for i := range segments {
+			if p.segments[i] != segments[i] {
+				t.Errorf("expected %q to have segments %v got %v", pattern, segments, p.segments)
+			}
+		}
+	}
+
+	invalid := []string{"customer", "$..name", "$.items[", "$.items[abc]", "$.a.", "$a"}
+
+	for _, pattern := range invalid {
+		if _, err := ParseJSONPath(pattern); err == nil {
+			t.Errorf("expected %q to be invalid", pattern)
+		}
+	}
+}
+
+func TestJSONPath_Match(t *testing.T) {
+	p, _ := ParseJSONPath("$.items[*].price")
+
+	if !p.Match([]string{"items", "3", "price"}) {
+		t.Errorf("expected wildcard to match any index")
+	}
+
+	if !p.Match([]string{"items", "0", "price", "currency"}) {
+		t.Errorf("expected pattern to match children")
+	}
+
+	if p.Match([]string{"items", "0", "name"}) || p.Match([]string{"items"}) {
+		t.Errorf("expected pattern to not match other fields")
+	}
+}
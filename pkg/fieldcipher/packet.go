@@ -1,8 +1,30 @@
 package fieldcipher
 
+const (
+	// FormatV1 is the legacy format: AES-CBC with zero padding and the field path embedded in the encrypted payload
+	FormatV1 = 1
+	// FormatV2 is the authenticated format: AES-GCM with the field path as associated data
+	FormatV2 = 2
+	// CurrentFormat is the format used for new packets. Clients opt in FormatV2 with SetFormatVersion
+	CurrentFormat = FormatV1
+)
+
 type CipherPacket struct {
-	EncryptedKey  string `example:"wcDMA8HPMfuMKotZAQwAo62NR4snfqbT3S3EBd3xKAJjJuRx42hJU/f+p0eiQvXNuitRuLe0rF0U8YB3ArAhMX1OZ27t/QE7LKDd1T1oY28kUnHzkKzaIBoted7YXveXLRgr5WI1L6impgxlv+88C81Q6h7RqVWG2Vo6+rXdtg7GdK/VEOtJezIlRJ9Od/gBxmGFjtbSzeoQUTXyzN+xPY60PjpX1FXx+gmM1wHGvZjNLUSsMoKE01JtJJQj1kD4MX9nusp0CONzY4oCNptxgFgcSI/AFj7MZJAW9nH4yR+lQrjw+2KeAhWsWebGK4WiZFdxbEkVJ26GSawCTUqvqJJVt3R7N8vEmgNmM5u+QugM9inFQVa8SUTfqdHmpxq/QO+HtOqbsEiBZWHfNIC1muqjEshwpGhvqfajinSkyR2PbzwUgxPneTrGHiV/cG2LdriAy2zUjNSyoXsYqB9sp3gs9KdKg6nh+f0YE4fAwnb91+2B7xJz0wJFm25iAT4VkJCZjWOULVOzAEJv/38C0uAB5JjPc2wU364MKjwj+/iNutXhTHLgbeD44dmp4GfkCmlO9Hh8TM9JZXOLSgd7uODg4nkTH3ngvOIoe2BD4NTlA/sAp+tXZWRSPinNVIvt1MQgi4QcnHl3SNajPDCqZT7gSOTi7B1gegeF7uHuCeA7IaxK4qmlohvhOFAA"`
+	// Version is the field format version. Packets without it have the format of each field detected by its magic
+	Version      int    `example:"2"`
+	EncryptedKey string `example:"wcDMA8HPMfuMKotZAQwAo62NR4snfqbT3S3EBd3xKAJjJuRx42hJU/f+p0eiQvXNuitRuLe0rF0U8YB3ArAhMX1OZ27t/QE7LKDd1T1oY28kUnHzkKzaIBoted7YXveXLRgr5WI1L6impgxlv+88C81Q6h7RqVWG2Vo6+rXdtg7GdK/VEOtJezIlRJ9Od/gBxmGFjtbSzeoQUTXyzN+xPY60PjpX1FXx+gmM1wHGvZjNLUSsMoKE01JtJJQj1kD4MX9nusp0CONzY4oCNptxgFgcSI/AFj7MZJAW9nH4yR+lQrjw+2KeAhWsWebGK4WiZFdxbEkVJ26GSawCTUqvqJJVt3R7N8vEmgNmM5u+QugM9inFQVa8SUTfqdHmpxq/QO+HtOqbsEiBZWHfNIC1muqjEshwpGhvqfajinSkyR2PbzwUgxPneTrGHiV/cG2LdriAy2zUjNSyoXsYqB9sp3gs9KdKg6nh+f0YE4fAwnb91+2B7xJz0wJFm25iAT4VkJCZjWOULVOzAEJv/38C0uAB5JjPc2wU364MKjwj+/iNutXhTHLgbeD44dmp4GfkCmlO9Hh8TM9JZXOLSgd7uODg4nkTH3ngvOIoe2BD4NTlA/sAp+tXZWRSPinNVIvt1MQgi4QcnHl3SNajPDCqZT7gSOTi7B1gegeF7uHuCeA7IaxK4qmlohvhOFAA"`
+	// Groups are the recipient groups of the packet, each one with its own key. Fields not in any group are readable with EncryptedKey
+	Groups        map[string]CipherGroup `json:",omitempty"`
 	EncryptedJSON map[string]interface{}
+	// Signature is the base64 detached signature of the packet by its producer. Empty for unsigned packets
+	Signature string `json:",omitempty"`
+}
+
+// CipherGroup is the key of a recipient group and the fields encrypted with it
+type CipherGroup struct {
+	EncryptedKey string
+	// Fields are the cipher paths of the fields encrypted with the group key
+	Fields []string `example:"/Y3VzdG9tZXI=/dGF4SWQ=/"`
 }
 
 type UnmatchedField struct {
@@ -10,8 +32,36 @@ type UnmatchedField struct {
 	Got      string `example:"/data/Test/0/name/"`
 }
 
+const (
+	// FieldDecrypted is the status of a field that was successfully decrypted
+	FieldDecrypted = "decrypted"
+	// FieldNotAuthorized is the status of a field encrypted to a recipient group we are not part of
+	FieldNotAuthorized = "not-authorized"
+	// FieldTampered is the status of a field that was changed or moved to another path
+	FieldTampered = "tampered"
+	// FieldTypeError is the status of a field that was decrypted but its value could not be parsed
+	FieldTypeError = "type-error"
+)
+
+// FieldResult is the decryption status of a single field in partial decipher
+type FieldResult struct {
+	Path   string `example:"/customer/taxId/"`
+	Status string `example:"decrypted"`
+	Error  string `json:",omitempty" example:""`
+}
+
 type DecipherPacket struct {
 	DecryptedData   map[string]interface{}
 	JSONChanged     bool `example:"false"`
 	UnmatchedFields []UnmatchedField
+	// Fields are the status of each encrypted field. Only filled in partial decipher
+	Fields []FieldResult `json:",omitempty"`
+	// Signed is true if the packet has a producer signature
+	Signed bool `example:"true"`
+	// SignatureValid is true if the signature was verified against a known public key
+	SignatureValid bool `example:"true"`
+	// SignerFingerprint is the fingerprint of the key that signed the packet
+	SignerFingerprint string `json:",omitempty" example:"0016A9CA870AFA59"`
+	// SignatureError is the reason why the signature is not valid
+	SignatureError string `json:",omitempty" example:""`
 }
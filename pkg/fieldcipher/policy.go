@@ -0,0 +1,133 @@
+package fieldcipher
+
+import (
+	"fmt"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/openpgp"
+)
+
+// RecipientGroup is a set of recipients that are the only ones able to read the fields selected by Fields
+type RecipientGroup struct {
+	Name string
+	// Fields are JSONPath patterns of the fields readable by this group
+	Fields     []string
+	PublicKeys []*openpgp.Entity
+}
+
+// FieldPolicy selects which fields of a packet are encrypted and to whom
+type FieldPolicy struct {
+	// Include are JSONPath patterns of the fields to encrypt. Every field is encrypted if empty
+	Include []string
+	// Exclude are JSONPath patterns of the fields to keep in plain text. Takes precedence over Include and Groups
+	Exclude []string
+	// Groups are the recipient groups. Fields not selected by any group are readable by the cipher keys
+	Groups []RecipientGroup
+	// BlindIndex are JSONPath patterns of object fields that get a blind index stored in a sibling field named with BlindIndexSuffix
+	BlindIndex []string
+}
+
+// Validate checks the JSONPath patterns and recipient groups of the policy
+func (p FieldPolicy) Validate() error {
+	names := map[string]bool{}
+
+	for _, g := range p.Groups {
+		if names[g.Name] {
+			return fmt.Errorf("duplicated recipient group %s", g.Name)
+		}
+		names[g.Name] = true
+	}
+
+	_, err := makeFieldSelector(nil, p)
+
+	return err
+}
+
+type fieldGroupSelector struct {
+	name   string
+	fields []*JSONPath
+}
+
+type fieldSelector struct {
+	skipFields []string
+	include    []*JSONPath
+	exclude    []*JSONPath
+	groups     []fieldGroupSelector
+	blindIndex []*JSONPath
+}
+
+func makeFieldSelector(skipFields []string, policy FieldPolicy) (*fieldSelector, error) {
+	var err error
+
+	s := &fieldSelector{
+		skipFields: skipFields,
+	}
+
+	s.include, err = ParseJSONPaths(policy.Include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include: %s", err)
+	}
+
+	s.exclude, err = ParseJSONPaths(policy.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude: %s", err)
+	}
+
+	s.blindIndex, err = ParseJSONPaths(policy.BlindIndex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid blind index: %s", err)
+	}
+
+	for _, g := range policy.Groups {
+		if g.Name == "" {
+			return nil, fmt.Errorf("recipient group without name")
+		}
+
+		fields, err := ParseJSONPaths(g.Fields)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fields for group %s: %s", g.Name, err)
+		}
+
+		s.groups = append(s.groups, fieldGroupSelector{
+			name:   g.Name,
+			fields: fields,
+		})
+	}
+
+	return s, nil
+}
+
+// skip returns true if the subtree at nodePath should be kept as is
+func (s *fieldSelector) skip(nodePath string) bool {
+	return tools.StringIndexOf(nodePath, s.skipFields) > -1
+}
+
+// classify returns if the leaf at path should be encrypted and the recipient group of it. Empty group means the default recipients
+func (s *fieldSelector) classify(path []string) (bool, string) {
+	if matchAnyJSONPath(s.exclude, path) {
+		return false, ""
+	}
+
+	for _, g := range s.groups {
+		if matchAnyJSONPath(g.fields, path) {
+			return true, g.name
+		}
+	}
+
+	if len(s.include) == 0 {
+		return true, ""
+	}
+
+	return matchAnyJSONPath(s.include, path), ""
+}
+
+// blindIndexField returns the blind index pattern that selects the field at path. Nil if the field has no blind index
+func (s *fieldSelector) blindIndexField(path []string) *JSONPath {
+	for _, v := range s.blindIndex {
+		if v.MatchExact(path) {
+			return v
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package fieldcipher
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/pkg/openpgp"
+	"github.com/quan-to/chevron/pkg/openpgp/packet"
+)
+
+const packetSignaturePrefix = "CHEVRON_FIELDCIPHER|"
+
+// signedPacket is the part of the CipherPacket covered by the signature
+type signedPacket struct {
+	Version       int
+	EncryptedKey  string
+	Groups        map[string]CipherGroup `json:",omitempty"`
+	EncryptedJSON map[string]interface{}
+}
+
+// packetSignedData returns the canonical serialization of the packet that is signed
+func packetSignedData(p CipherPacket) ([]byte, error) {
+	// json.Marshal sorts the map keys, so the output does not depend on the field order
+	data, err := json.Marshal(signedPacket{
+		Version:       p.Version,
+		EncryptedKey:  p.EncryptedKey,
+		Groups:        p.Groups,
+		EncryptedJSON: p.EncryptedJSON,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error serializing packet: %s", err)
+	}
+
+	return append([]byte(packetSignaturePrefix), data...), nil
+}
+
+// SignPacket signs the packet with an unlocked private key, filling its Signature field
+func SignPacket(p *CipherPacket, signer *openpgp.Entity) error {
+	if signer == nil || signer.PrivateKey == nil {
+		return fmt.Errorf("signer does not have a private key")
+	}
+
+	if signer.PrivateKey.Encrypted {
+		return fmt.Errorf("signer private key is not unlocked")
+	}
+
+	p.Signature = ""
+	data, err := packetSignedData(*p)
+	if err != nil {
+		return err
+	}
+
+	buf := bytes.NewBuffer(nil)
+
+	err = openpgp.DetachSign(buf, signer, bytes.NewReader(data), &packet.Config{
+		DefaultHash: crypto.SHA512,
+	})
+
+	if err != nil {
+		return fmt.Errorf("error signing packet: %s", err)
+	}
+
+	p.Signature = base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	return nil
+}
+
+// GetPacketSigner returns the fingerprint of the key that signed the packet, without verifying the signature
+func GetPacketSigner(p CipherPacket) (string, error) {
+	if p.Signature == "" {
+		return "", fmt.Errorf("packet is not signed")
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(p.Signature)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature: %s", err)
+	}
+
+	pkt, err := packet.Read(bytes.NewReader(sig))
+	if err != nil {
+		return "", fmt.Errorf("invalid signature: %s", err)
+	}
+
+	switch s := pkt.(type) {
+	case *packet.Signature:
+		if s.IssuerKeyId == nil {
+			return "", fmt.Errorf("signature does not have an issuer")
+		}
+		return tools.IssuerKeyIdToFP16(*s.IssuerKeyId), nil
+	case *packet.SignatureV3:
+		return tools.IssuerKeyIdToFP16(s.IssuerKeyId), nil
+	}
+
+	return "", fmt.Errorf("invalid signature: not a signature packet")
+}
+
+// VerifyPacket checks the packet signature against keyring and returns the fingerprint of the signer
+func VerifyPacket(p CipherPacket, keyring openpgp.KeyRing) (string, error) {
+	issuer, err := GetPacketSigner(p)
+	if err != nil {
+		return "", err
+	}
+
+	if keyring == nil {
+		return issuer, fmt.Errorf("no public key for signer %s", issuer)
+	}
+
+	sig, _ := base64.StdEncoding.DecodeString(p.Signature)
+
+	data, err := packetSignedData(p)
+	if err != nil {
+		return issuer, err
+	}
+
+	signer, err := openpgp.CheckDetachedSignature(keyring, bytes.NewReader(data), bytes.NewReader(sig))
+	if err != nil {
+		return issuer, fmt.Errorf("invalid signature from %s: %s", issuer, err)
+	}
+
+	return tools.ByteFingerPrint2FP16(signer.PrimaryKey.Fingerprint[:]), nil
+}
@@ -0,0 +1,94 @@
+package fieldcipher
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/quan-to/chevron/internal/tools"
+	"github.com/quan-to/chevron/test"
+)
+
+func TestSignPacket(t *testing.T) {
+	decipher := makeTestDecipher(t)
+	publicKeys, _ := tools.ReadKey(test.TestPublicKey)
+
+	cipher := MakeCipherFromASCIIArmoredKeys([]string{test.TestPublicKey})
+
+	if err := cipher.SetSigner(publicKeys[0]); err == nil {
+		t.Fatalf("expected public key to be rejected as signer")
+	}
+
+	signer := decipher.privateKey[0]
+	if err := signer.PrivateKey.Decrypt([]byte(test.TestKeyPassword)); err != nil {
+		t.Fatalf("Error unlocking signer: %s", err)
+	}
+
+	if err := cipher.SetSigner(signer); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	packet, err := cipher.GenerateEncryptedPacket(map[string]interface{}{
+		"a": "b",
+		"c": map[string]interface{}{"d": 10.5, "e": []interface{}{1, "2"}},
+	}, []string{CipherPathCombine("a")})
+
+	if err != nil {
+		t.Fatalf("Error encrypting packet: %s", err)
+	}
+
+	if packet.Signature == "" {
+		t.Fatalf("expected packet to be signed")
+	}
+
+	// Packets are usually transported as JSON
+	raw, _ := json.Marshal(packet)
+	var received CipherPacket
+	_ = json.Unmarshal(raw, &received)
+
+	signerFingerprint, err := GetPacketSigner(received)
+	if err != nil || signerFingerprint != test.TestKeyFingerprint {
+		t.Fatalf("expected signer to be %s got %s (%v)", test.TestKeyFingerprint, signerFingerprint, err)
+	}
+
+	// Without verification keys the signer is reported but not trusted
+	dec, err := decipher.DecipherPacket(received)
+	if err != nil {
+		t.Fatalf("Error decrypting packet: %s", err)
+	}
+
+	if !dec.Signed || dec.SignatureValid || dec.SignerFingerprint != test.TestKeyFingerprint || dec.SignatureError == "" {
+		t.Errorf("expected signature to be reported as not verified got %+v", dec)
+	}
+
+	decipher.SetVerificationKeys(publicKeys)
+
+	dec, err = decipher.DecipherPacket(received)
+	if err != nil {
+		t.Fatalf("Error decrypting packet: %s", err)
+	}
+
+	if !dec.Signed || !dec.SignatureValid || dec.SignerFingerprint != test.TestKeyFingerprint {
+		t.Errorf("expected signature to be valid got %+v", dec)
+	}
+
+	// Forged plain field
+	received.EncryptedJSON["a"] = "x"
+
+	dec, err = decipher.DecipherPacket(received)
+	if err != nil {
+		t.Fatalf("Error decrypting packet: %s", err)
+	}
+
+	if dec.SignatureValid {
+		t.Errorf("expected changed packet signature to be invalid")
+	}
+
+	// Unsigned packets
+	_ = cipher.SetSigner(nil)
+	packet, _ = cipher.GenerateEncryptedPacket(map[string]interface{}{"a": "b"}, nil)
+	dec, _ = decipher.DecipherPacket(*packet)
+
+	if dec.Signed || dec.SignatureValid || dec.SignerFingerprint != "" {
+		t.Errorf("expected unsigned packet to be reported as unsigned got %+v", dec)
+	}
+}
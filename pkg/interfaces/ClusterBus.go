@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// ClusterBus is a interface for pushing key password events between the nodes of a chevron cluster
+type ClusterBus interface {
+	// Name returns the name of the bus
+	Name() string
+	// Publish sends the event to the other cluster nodes
+	Publish(ctx context.Context, event models.ClusterPasswordEvent) error
+	// Subscribe returns a channel that receives the events published in the bus until ctx is done
+	Subscribe(ctx context.Context) (<-chan models.ClusterPasswordEvent, error)
+}
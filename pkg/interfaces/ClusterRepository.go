@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"time"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// ClusterRepository is the database access needed by the database peer discovery
+type ClusterRepository interface {
+	// RegisterClusterNode adds or updates the node and the time it was last seen
+	RegisterClusterNode(node models.ClusterNode) error
+	// FetchClusterNodes returns the nodes seen after seenAfter
+	FetchClusterNodes(seenAfter time.Time) ([]models.ClusterNode, error)
+}
@@ -21,4 +21,8 @@ type KeyRingManager interface {
 	GetFingerPrints(ctx context.Context) []string
 	// DeleteKey erases the specified key from the key ring
 	DeleteKey(ctx context.Context, fingerprint string) error
+	// GetMasterKey returns the key of the specified key or subkey fingerprint. Returns nil if it is not cached
+	GetMasterKey(ctx context.Context, fingerprint string) *openpgp.Entity
+	// GetKeySource returns where the cached key was loaded from. Returns empty if it is not cached
+	GetKeySource(ctx context.Context, fingerprint string) models.KeySource
 }
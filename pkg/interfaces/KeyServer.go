@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+)
+
+// KeyServer is a interface for fetching public keys from a upstream keyserver
+type KeyServer interface {
+	// Name returns the name of the keyserver
+	Name() string
+	// GetKeyByFingerPrint returns the ASCII Armored public keys found for the specified fingerprint or key id
+	GetKeyByFingerPrint(ctx context.Context, fingerPrint string) (string, error)
+	// GetKeyByEmail returns the ASCII Armored public keys found for the specified email
+	GetKeyByEmail(ctx context.Context, email string) (string, error)
+}
@@ -0,0 +1,7 @@
+package interfaces
+
+// Mailer is a interface for sending emails
+type Mailer interface {
+	// SendMail sends a plain text email to the specified address
+	SendMail(to, subject, body string) error
+}
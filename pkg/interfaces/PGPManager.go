@@ -33,6 +33,10 @@ type PGPManager interface {
 	DeleteKey(ctx context.Context, fingerprint string) error
 	// SignData signs the specified data with a unlocked private key
 	SignData(ctx context.Context, fingerprint string, data []byte, hashAlgorithm crypto.Hash) (string, error)
+	// SignDataWithOptions signs the specified data with a unlocked private key adding the notations, signer user id, policy URI and expiration of the options
+	SignDataWithOptions(ctx context.Context, fingerprint string, data []byte, hashAlgorithm crypto.Hash, options models.SignatureOptions) (string, error)
+	// TimestampResponse returns the RFC 3161 response of the built-in timestamp authority to a DER encoded timestamp request
+	TimestampResponse(ctx context.Context, request []byte) ([]byte, error)
 	// GetPublicKeyEntity returns the public key entity
 	GetPublicKeyEntity(ctx context.Context, fingerprint string) *openpgp.Entity
 	// GetPublicKey returns the public key
@@ -44,9 +48,9 @@ type PGPManager interface {
 	// GetPublicKeyASCII returns the encrypted private key in ASCII Armored format changing it's password
 	GetPrivateKeyASCIIReencrypt(ctx context.Context, fingerprint, currentPassword, newPassword string) (string, error)
 	// VerifySignatureStringData verifies signature of specified data in string format
-	VerifySignatureStringData(ctx context.Context, data string, signature string) (bool, error)
-	// VerifySignatureStringData verifies signature of specified data
-	VerifySignature(ctx context.Context, data []byte, signature string) (bool, error)
+	VerifySignatureStringData(ctx context.Context, data string, signature string) (*models.GPGVerifySignatureResult, error)
+	// VerifySignature verifies signature of specified data and returns the signature details with the state and trust of the signer key
+	VerifySignature(ctx context.Context, data []byte, signature string) (*models.GPGVerifySignatureResult, error)
 	// GeneratePGPKey generates a new PGP Key with the specified information
 	GeneratePGPKey(ctx context.Context, identifier, password string, numBits int) (string, error)
 	// Encrypt encrypts data using the specified public key.
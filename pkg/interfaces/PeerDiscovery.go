@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/quan-to/chevron/pkg/models"
+)
+
+// PeerDiscovery is a interface for finding the other nodes of a chevron cluster
+type PeerDiscovery interface {
+	// Name returns the name of the discovery method
+	Name() string
+	// Peers returns the other nodes of the cluster. The current node is not included
+	Peers(ctx context.Context) ([]models.ClusterNode, error)
+}
@@ -16,4 +16,12 @@ type SecretsManager interface {
 	UnlockLocalKeys(ctx context.Context, gpg PGPManager)
 	// GetMasterKeyFingerPrint returns the fingerprint of the master key
 	GetMasterKeyFingerPrint(ctx context.Context) string
+	// SignWithMasterKey creates an armored detached signature of data using the master key
+	SignWithMasterKey(ctx context.Context, data []byte) (string, error)
+	// VerifyMasterKeySignature checks if signature is a valid detached signature of data made by the master key
+	VerifyMasterKeySignature(ctx context.Context, data []byte, signature string) error
+	// DeriveMasterKeySecret returns a secret for purpose derived from the master key. Every node with the same master key derives the same secret
+	DeriveMasterKeySecret(ctx context.Context, purpose string) ([]byte, error)
+	// OnPasswordStored registers a callback that is called with the master key encrypted password every time PutKeyPassword stores a password
+	OnPasswordStored(cb func(ctx context.Context, fingerPrint, encryptedPassword string))
 }
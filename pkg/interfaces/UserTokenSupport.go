@@ -0,0 +1,8 @@
+package interfaces
+
+// UserTokenSupport is implemented by database handlers that may not store user tokens.
+// Handlers that do not implement it store user tokens
+type UserTokenSupport interface {
+	// SupportsUserTokens returns true if user tokens can be stored and read
+	SupportsUserTokens() bool
+}
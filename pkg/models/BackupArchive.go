@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// BackupArchive is a full Chevron state backup.
+// Payload is a ASCII Armored gzipped JSON BackupData encrypted to the recovery key
+// and Signature is a master key detached signature of the archive header and payload
+type BackupArchive struct {
+	Version                int       `json:"version"`
+	CreatedAt              time.Time `json:"createdAt"`
+	MasterKeyFingerprint   string    `json:"masterKeyFingerprint"`
+	RecoveryKeyFingerprint string    `json:"recoveryKeyFingerprint"`
+	Payload                string    `json:"payload"`
+	Signature              string    `json:"signature"`
+}
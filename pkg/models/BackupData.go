@@ -0,0 +1,9 @@
+package models
+
+// BackupData is the content of a BackupArchive
+type BackupData struct {
+	PublicKeys  []GPGKey           `json:"publicKeys"`
+	PrivateKeys []BackupPrivateKey `json:"privateKeys"`
+	Users       []User             `json:"users"`
+	UserTokens  []UserToken        `json:"userTokens"`
+}
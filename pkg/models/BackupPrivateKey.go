@@ -0,0 +1,8 @@
+package models
+
+// BackupPrivateKey is a encrypted private key as stored in a StorageBackend
+type BackupPrivateKey struct {
+	Name     string `json:"name"`
+	Data     string `json:"data"`
+	Metadata string `json:"metadata"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// ClusterNode represents a chevron node that takes part in cluster password sharing
+type ClusterNode struct {
+	ID       string    `json:"ID"`
+	Address  string    `json:"Address" example:"http://10.0.0.10:5100"`
+	LastSeen time.Time `json:"LastSeen"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// ClusterPasswordEvent is pushed by a cluster node to the others when it stores a new key password.
+// Payload is a JSON serialized ClusterPasswordPayload and Signature is a master key detached signature of it.
+type ClusterPasswordEvent struct {
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
@@ -0,0 +1,10 @@
+package models
+
+// ClusterPasswordPayload contains the master key encrypted passwords of a node bound to the requester nonce
+type ClusterPasswordPayload struct {
+	Nonce     string            `json:"nonce"`
+	Timestamp int64             `json:"timestamp"`
+	Passwords map[string]string `json:"passwords"`
+	// Origin is the ID of the node that published the payload. Only set on ClusterPasswordEvent
+	Origin string `json:"origin,omitempty"`
+}
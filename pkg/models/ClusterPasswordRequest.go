@@ -0,0 +1,9 @@
+package models
+
+// ClusterPasswordRequest is sent by a cluster node to a peer asking for its master key encrypted passwords, or to unlock its keys.
+// The signature is made by the master key over the nonce and timestamp so the peer can check the requester membership.
+type ClusterPasswordRequest struct {
+	Nonce     string `json:"nonce" example:"6a8f4c0e2b1d4e7f9a3c5b7d9e1f3a5c"`
+	Timestamp int64  `json:"timestamp" example:"1610000000"`
+	Signature string `json:"signature"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// ClusterPasswordResponse is the answer of a cluster node to a ClusterPasswordRequest.
+// Payload is a JSON serialized ClusterPasswordPayload and Signature is a master key detached signature of it.
+type ClusterPasswordResponse struct {
+	Payload   string `json:"payload"`
+	Signature string `json:"signature"`
+}
@@ -0,0 +1,7 @@
+package models
+
+type FieldBlindIndexInput struct {
+	// Field is the JSONPath pattern used in the BlindIndex of the cipher request
+	Field string      `example:"$.customer.taxId"`
+	Value interface{} `swaggertype:"string" example:"12345"`
+}
@@ -0,0 +1,6 @@
+package models
+
+type FieldBlindIndexOutput struct {
+	Field      string `example:"$.customer.taxId"`
+	BlindIndex string `example:"FCBI5f0c3c0f1a5e1b3e8b2d6f7a9c4e1d2b3a4f5e6d7c8b9a0f1e2d3c4b5a6f7e8d9"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// FieldCipherGroup is a fieldcipher recipient group. Only Keys can read the fields selected by Fields
+type FieldCipherGroup struct {
+	Name   string   `example:"compliance"`
+	Keys   []string `example:"0551F452ABE463A4"`
+	Fields []string `example:"$.customer.taxId"`
+}
@@ -4,4 +4,16 @@ type FieldCipherInput struct {
 	JSON       map[string]interface{}
 	Keys       []string `example:"0551F452ABE463A4"`
 	SkipFields []string `example:""`
+	// Include are JSONPath patterns of the fields to encrypt. Every field is encrypted if empty
+	Include []string `example:"$.customer"`
+	// Exclude are JSONPath patterns of the fields to keep in plain text
+	Exclude []string `example:"$.customer.name"`
+	// Groups are recipient groups that are the only ones able to read their fields
+	Groups []FieldCipherGroup
+	// BlindIndex are JSONPath patterns of fields that get a blind index in a sibling field with the _bidx suffix
+	BlindIndex []string `example:"$.customer.taxId"`
+	// SignWith is the fingerprint of an unlocked private key to sign the packet. Empty for unsigned packets
+	SignWith string `example:"0016A9CA870AFA59"`
+	// Version is the fieldcipher format version to generate. Empty for version 1, or version 2 if Groups are set
+	Version int `example:"2"`
 }
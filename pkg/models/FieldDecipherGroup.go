@@ -0,0 +1,7 @@
+package models
+
+// FieldDecipherGroup is the key of a fieldcipher recipient group and the fields encrypted with it
+type FieldDecipherGroup struct {
+	EncryptedKey string
+	Fields       []string `example:"/Y3VzdG9tZXI=/dGF4SWQ=/"`
+}
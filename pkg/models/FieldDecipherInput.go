@@ -1,7 +1,14 @@
 package models
 
 type FieldDecipherInput struct {
+	// Version is the fieldcipher format version of the packet. Empty to detect the format of each field
+	Version        int `example:"2"`
 	KeyFingerprint string
 	EncryptedKey   string
+	Groups         map[string]FieldDecipherGroup
 	EncryptedJSON  map[string]interface{}
+	// Signature is the producer signature of the packet. Empty for unsigned packets
+	Signature string
+	// Partial returns the fields that could be decrypted and the status of each field instead of failing on the first bad field
+	Partial bool `example:"false"`
 }
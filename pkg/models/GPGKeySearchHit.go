@@ -0,0 +1,7 @@
+package models
+
+// GPGKeySearchHit is a key found by a ranked search with its relevance score. Higher scores are better matches
+type GPGKeySearchHit struct {
+	Key   GPGKey
+	Score float64 `example:"0.8"`
+}
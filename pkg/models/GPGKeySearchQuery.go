@@ -0,0 +1,30 @@
+package models
+
+// Limits of a ranked key search page
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// GPGKeySearchQuery is a ranked full-text search in the key store.
+// Value is matched by word prefix, substring and similarity against the names and emails of the keys, and by suffix against their fingerprints.
+// Cursor is the NextCursor of the previous page, or empty for the first page
+type GPGKeySearchQuery struct {
+	Value        string `example:"john huebr"`
+	Cursor       string `example:""`
+	Limit        int    `example:"20"`
+	VerifiedOnly bool   `example:"false"`
+}
+
+// GetLimit returns the page size of the query, between 1 and MaxSearchLimit
+func (q GPGKeySearchQuery) GetLimit() int {
+	if q.Limit <= 0 {
+		return DefaultSearchLimit
+	}
+
+	if q.Limit > MaxSearchLimit {
+		return MaxSearchLimit
+	}
+
+	return q.Limit
+}
@@ -0,0 +1,9 @@
+package models
+
+// GPGKeySearchResult is a page of a ranked key search.
+// Total is the number of keys that match the query in all pages. NextCursor is empty in the last page
+type GPGKeySearchResult struct {
+	Hits       []GPGKeySearchHit
+	Total      int    `example:"1"`
+	NextCursor string `example:""`
+}
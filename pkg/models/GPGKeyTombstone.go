@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// GPGKeyTombstone records a public key removed from the key store, so it is not added again by uploads or upstream keyservers.
+// If Emails is empty the whole key was removed, otherwise only its user ids with these emails.
+// OwnerRequests identifies the deletion requests signed by the key owner that were already used, so they cannot be replayed
+type GPGKeyTombstone struct {
+	FingerPrint   string    `example:"0551F452ABE463A4"`
+	Emails        []string  `example:"john@huebr.com"`
+	Reason        string    `example:"GDPR erasure request"`
+	RemovedBy     string    `example:"admin"`
+	CreatedAt     time.Time `example:"2020-10-19T12:00:00Z"`
+	OwnerRequests []string  `example:"1603108800:9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// IsWholeKey returns true if the whole key was removed
+func (t GPGKeyTombstone) IsWholeKey() bool {
+	return len(t.Emails) == 0
+}
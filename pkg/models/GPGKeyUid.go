@@ -4,4 +4,5 @@ type GPGKeyUid struct {
 	Name        string `example:"Remote Signer Test"`
 	Email       string `example:"test@quan.to"`
 	Description string `example:""`
+	Verified    bool   `example:"true"`
 }
@@ -3,4 +3,5 @@ package models
 type GPGSignData struct {
 	FingerPrint string `example:"0551F452ABE463A4"`
 	Base64Data  string `example:"SGVsbG8gd29ybGQK"`
+	SignatureOptions
 }
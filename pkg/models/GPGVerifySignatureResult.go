@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// GPGVerifySignatureResult is the result of a valid signature verification with the signature details and the signer key state
+type GPGVerifySignatureResult struct {
+	Valid bool `example:"true"`
+	// FingerPrint is the short fingerprint of the signer primary key
+	FingerPrint           string `example:"0551F452ABE463A4"`
+	PrimaryKeyFingerPrint string `example:"9E83D1D5A8E9E2B1C9A0AB280551F452ABE463A4"`
+	// SubKeyFingerPrint is the fingerprint of the subkey that made the signature. Empty when it was made by the primary key
+	SubKeyFingerPrint string              `example:""`
+	CreationTime      time.Time           `example:"2021-01-08T19:44:39Z"`
+	HashAlgorithm     string              `example:"SHA512"`
+	SignatureType     string              `example:"binary"`
+	Notations         []SignatureNotation `json:",omitempty"`
+	SignerUserId      string              `json:",omitempty" example:"John HUEBR <john@huebr.com>"`
+	PolicyURI         string              `json:",omitempty" example:"https://quanto.app/signature-policy"`
+	// SignatureExpiration is when the signature expires. Nil if it does not expire
+	SignatureExpiration *time.Time `json:",omitempty"`
+	SignatureExpired    bool       `example:"false"`
+	// Timestamp is the time of the trusted RFC 3161 timestamp of the signature. Nil if it does not have a trusted timestamp
+	Timestamp          *time.Time `json:",omitempty"`
+	TimestampAuthority string     `json:",omitempty" example:"Chevron TSA 0551F452ABE463A4"`
+	// TimestampError is why the timestamp of the signature is not valid or not trusted
+	TimestampError string `json:",omitempty" example:""`
+	// KeyExpiration is when the signer key expires. Nil if it does not expire
+	KeyExpiration *time.Time `json:",omitempty"`
+	// KeyExpiredAtSigning is if the signer key was expired when the signature was made, which makes the signature invalid
+	KeyExpiredAtSigning bool       `example:"false"`
+	KeyRevoked          bool       `example:"false"`
+	KeyRevokedAtSigning bool       `example:"false"`
+	KeyRevocationTime   *time.Time `json:",omitempty"`
+	KeyRevocationReason string     `example:""`
+	KeySource           KeySource  `example:"pks"`
+	Trusted             bool       `example:"true"`
+	TrustedVia          string     `example:"CA 2B6F1D8E5C1A7F30"`
+}
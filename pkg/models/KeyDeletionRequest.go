@@ -0,0 +1,22 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyDeletionRequest asks the key store to remove a public key, or only its user ids with the specified emails.
+// Signature is a detached signature made by the key itself of the SignedData result. Timestamp is in unix seconds
+type KeyDeletionRequest struct {
+	FingerPrint string   `example:"0551F452ABE463A4"`
+	Emails      []string `example:"john@huebr.com"`
+	Reason      string   `example:"GDPR erasure request"`
+	Timestamp   int64    `example:"1603108800"`
+	Signature   string   `example:"-----BEGIN PGP SIGNATURE-----\n\nwsDcBAABCgAQBQJf+LriCRAFUfRSq+RjpAAAuL0MAGGrSJfK/tnMkwZ2Rkh3JcvF\n-----END PGP SIGNATURE-----"`
+}
+
+// SignedData returns the text that should be signed by the key owner
+func (r KeyDeletionRequest) SignedData() string {
+	return fmt.Sprintf("Delete key %s\nEmails: %s\nReason: %s\nTimestamp: %d\n",
+		strings.ToUpper(r.FingerPrint), strings.Join(r.Emails, ", "), r.Reason, r.Timestamp)
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyDeletionResult describes what was removed from the key store
+type KeyDeletionResult struct {
+	FingerPrint    string   `example:"0551F452ABE463A4"`
+	KeyDeleted     bool     `example:"false"`
+	RemovedUserIds []string `example:"John HUEBR <john@huebr.com>"`
+}
+
+// String returns a human readable summary of the deletion
+func (r KeyDeletionResult) String() string {
+	if r.KeyDeleted {
+		return fmt.Sprintf("Key %s deleted", r.FingerPrint)
+	}
+
+	if len(r.RemovedUserIds) > 0 {
+		return fmt.Sprintf("Removed user ids %s of key %s", strings.Join(r.RemovedUserIds, ", "), r.FingerPrint)
+	}
+
+	return fmt.Sprintf("Key %s blocked", r.FingerPrint)
+}
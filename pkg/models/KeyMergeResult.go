@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyMergeResult describes what changed in the Public Key Store when a key was uploaded.
+// VerificationSent has the emails that received a verification link when email verification is enabled
+type KeyMergeResult struct {
+	FingerPrint      string   `example:"0551F452ABE463A4"`
+	Added            bool     `example:"false"`
+	NewUserIds       []string `example:"John HUEBR <john@huebr.com>"`
+	NewSubkeys       []string `example:"1D5D8B4F5C4B8E8A"`
+	NewSignatures    int      `example:"2"`
+	NewRevocations   int      `example:"0"`
+	VerificationSent []string `example:"john@huebr.com"`
+}
+
+// Changed returns true if the key was added or any packet was merged in the stored key
+func (r KeyMergeResult) Changed() bool {
+	return r.Added || len(r.NewUserIds) > 0 || len(r.NewSubkeys) > 0 || r.NewSignatures > 0 || r.NewRevocations > 0
+}
+
+// String returns a human readable summary of the merge
+func (r KeyMergeResult) String() string {
+	verification := ""
+
+	if len(r.VerificationSent) > 0 {
+		verification = fmt.Sprintf(". Verification sent to %s", strings.Join(r.VerificationSent, ", "))
+	}
+
+	if r.Added {
+		return fmt.Sprintf("Key %s added%s", r.FingerPrint, verification)
+	}
+
+	if !r.Changed() {
+		return fmt.Sprintf("Key %s unchanged%s", r.FingerPrint, verification)
+	}
+
+	changes := make([]string, 0)
+
+	if len(r.NewUserIds) > 0 {
+		changes = append(changes, fmt.Sprintf("%d new user id(s)", len(r.NewUserIds)))
+	}
+
+	if len(r.NewSubkeys) > 0 {
+		changes = append(changes, fmt.Sprintf("%d new subkey(s)", len(r.NewSubkeys)))
+	}
+
+	if r.NewSignatures > 0 {
+		changes = append(changes, fmt.Sprintf("%d new signature(s)", r.NewSignatures))
+	}
+
+	if r.NewRevocations > 0 {
+		changes = append(changes, fmt.Sprintf("%d new revocation(s)", r.NewRevocations))
+	}
+
+	return fmt.Sprintf("Key %s updated: %s%s", r.FingerPrint, strings.Join(changes, ", "), verification)
+}
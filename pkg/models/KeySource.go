@@ -0,0 +1,13 @@
+package models
+
+// KeySource is where a public key used by the server was loaded from
+type KeySource string
+
+const (
+	// KeySourceLocal is a key loaded in the local keyring
+	KeySourceLocal KeySource = "local"
+	// KeySourcePKS is a key fetched from the Public Key Store
+	KeySourcePKS KeySource = "pks"
+	// KeySourceSKS is a key fetched from the upstream keyservers
+	KeySourceSKS KeySource = "sks"
+)
@@ -0,0 +1,19 @@
+package models
+
+import "fmt"
+
+// KeyTrust describes if a key is trusted to sign data and how it is trusted
+type KeyTrust struct {
+	FingerPrint string `example:"0551F452ABE463A4"`
+	Trusted     bool   `example:"true"`
+	Via         string `example:"CA 2B6F1D8E5C1A7F30"`
+}
+
+// String returns a human readable description of the trust
+func (t KeyTrust) String() string {
+	if !t.Trusted {
+		return fmt.Sprintf("Key %s is not trusted", t.FingerPrint)
+	}
+
+	return fmt.Sprintf("Key %s is trusted via %s", t.FingerPrint, t.Via)
+}
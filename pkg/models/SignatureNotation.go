@@ -0,0 +1,10 @@
+package models
+
+// SignatureNotation is a name and value pair added to a signature by the signer.
+// Values of notations that are not human readable are base64 encoded
+type SignatureNotation struct {
+	Name          string `example:"invoice@quanto.app"`
+	Value         string `example:"INV-1234"`
+	HumanReadable bool   `example:"true"`
+	Critical      bool   `example:"false"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// SignatureOptions are the optional details added by the signer to a data signature
+type SignatureOptions struct {
+	Notations []SignatureNotation `json:",omitempty"`
+	// SignerUserId is the user id of the signer key the signature is made on behalf of. It can be the whole user id or its email
+	SignerUserId string `json:",omitempty" example:"John HUEBR <john@huebr.com>"`
+	PolicyURI    string `json:",omitempty" example:"https://quanto.app/signature-policy"`
+	// ExpirationSeconds is how long the signature is valid after it is made. Zero if it does not expire
+	ExpirationSeconds uint32 `json:",omitempty" example:"86400"`
+	// Timestamp adds to the signature a RFC 3161 timestamp of the configured timestamp authority
+	Timestamp bool `json:",omitempty" example:"false"`
+}
@@ -46,6 +46,20 @@ type Config struct {
 	// RSABits is the number of bits in new RSA keys made with NewEntity.
 	// If zero, then 2048 bit keys are created.
 	RSABits int
+	// SigLifetimeSecs is the number of seconds the data signatures are
+	// valid after they are made. If zero, they do not expire.
+	SigLifetimeSecs uint32
+	// SigNotations are the notations added to the data signatures.
+	SigNotations []Notation
+	// SigPolicyURI is the policy URI added to the data signatures.
+	SigPolicyURI string
+	// SignerUserId is the signer user id added to the data signatures.
+	// If empty, it is not added.
+	SignerUserId string
+	// KnownNotations are the names of the critical notations understood
+	// when verifying signatures. Signatures with other critical notations
+	// are rejected.
+	KnownNotations map[string]bool
 }
 
 func (c *Config) Random() io.Reader {
@@ -89,3 +103,10 @@ func (c *Config) PasswordHashIterations() int {
 	}
 	return c.S2KCount
 }
+
+func (c *Config) KnownNotation(name string) bool {
+	if c == nil {
+		return false
+	}
+	return c.KnownNotations[name]
+}
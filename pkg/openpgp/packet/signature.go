@@ -57,6 +57,28 @@ type Signature struct {
 	IssuerKeyId                                             *uint64
 	IsPrimaryId                                             *bool
 
+	// TrustLevel and TrustAmount are set by trust signatures, where the
+	// signer delegates trust to the certified key. See RFC 4880, section
+	// 5.2.3.13 for details.
+	TrustLevel, TrustAmount uint8
+
+	// Notations are the name and value pairs set by the signer. See RFC
+	// 4880, section 5.2.3.16 for details.
+	Notations []Notation
+
+	// UnhashedNotations are the notations of the unhashed area, that are
+	// not covered by the signature. They can be added after the signature
+	// is made, like timestamps of the signature value.
+	UnhashedNotations []Notation
+
+	// PolicyURI is the URI of the policy the signature was made under. See
+	// RFC 4880, section 5.2.3.20 for details.
+	PolicyURI string
+
+	// SignerUserId is the user id the signer is signing as. See RFC 4880,
+	// section 5.2.3.22 for details.
+	SignerUserId *string
+
 	// FlagsValid is set if any flags were given. See RFC 4880, section
 	// 5.2.3.21 for details.
 	FlagsValid                                                           bool
@@ -79,6 +101,28 @@ type Signature struct {
 	outSubpackets []outputSubpacket
 }
 
+// Notation is a signature notation data. Human readable notations have
+// UTF-8 text values. See RFC 4880, section 5.2.3.16.
+type Notation struct {
+	Name          string
+	Value         []byte
+	HumanReadable bool
+	IsCritical    bool
+}
+
+// contents returns the notation data subpacket contents
+func (n Notation) contents() []byte {
+	contents := make([]byte, 8, 8+len(n.Name)+len(n.Value))
+	if n.HumanReadable {
+		contents[0] = 0x80
+	}
+	binary.BigEndian.PutUint16(contents[4:], uint16(len(n.Name)))
+	binary.BigEndian.PutUint16(contents[6:], uint16(len(n.Value)))
+	contents = append(contents, n.Name...)
+	contents = append(contents, n.Value...)
+	return contents
+}
+
 func (sig *Signature) parse(r io.Reader) (err error) {
 	// RFC 4880, section 5.2.3
 	var buf [5]byte
@@ -195,13 +239,17 @@ type signatureSubpacketType uint8
 const (
 	creationTimeSubpacket        signatureSubpacketType = 2
 	signatureExpirationSubpacket signatureSubpacketType = 3
+	trustSubpacket               signatureSubpacketType = 5
 	keyExpirationSubpacket       signatureSubpacketType = 9
 	prefSymmetricAlgosSubpacket  signatureSubpacketType = 11
 	issuerSubpacket              signatureSubpacketType = 16
 	prefHashAlgosSubpacket       signatureSubpacketType = 21
 	prefCompressionSubpacket     signatureSubpacketType = 22
+	notationDataSubpacket        signatureSubpacketType = 20
 	primaryUserIdSubpacket       signatureSubpacketType = 25
+	policyURISubpacket           signatureSubpacketType = 26
 	keyFlagsSubpacket            signatureSubpacketType = 27
+	signerUserIdSubpacket        signatureSubpacketType = 28
 	reasonForRevocationSubpacket signatureSubpacketType = 29
 	featuresSubpacket            signatureSubpacketType = 30
 	embeddedSignatureSubpacket   signatureSubpacketType = 32
@@ -271,6 +319,17 @@ func parseSignatureSubpacket(sig *Signature, subpacket []byte, isHashed bool) (r
 		}
 		sig.SigLifetimeSecs = new(uint32)
 		*sig.SigLifetimeSecs = binary.BigEndian.Uint32(subpacket)
+	case trustSubpacket:
+		// Trust signature, section 5.2.3.13
+		if !isHashed {
+			return
+		}
+		if len(subpacket) != 2 {
+			err = errors.StructuralError("trust subpacket with bad length")
+			return
+		}
+		sig.TrustLevel = subpacket[0]
+		sig.TrustAmount = subpacket[1]
 	case keyExpirationSubpacket:
 		// Key expiration time, section 5.2.3.6
 		if !isHashed {
@@ -297,6 +356,29 @@ func parseSignatureSubpacket(sig *Signature, subpacket []byte, isHashed bool) (r
 		}
 		sig.IssuerKeyId = new(uint64)
 		*sig.IssuerKeyId = binary.BigEndian.Uint64(subpacket)
+	case notationDataSubpacket:
+		// Notation data, section 5.2.3.16
+		if len(subpacket) < 8 {
+			err = errors.StructuralError("notation data subpacket with bad length")
+			return
+		}
+		nameLength := int(subpacket[4])<<8 | int(subpacket[5])
+		valueLength := int(subpacket[6])<<8 | int(subpacket[7])
+		if len(subpacket) != 8+nameLength+valueLength {
+			err = errors.StructuralError("notation data subpacket with bad length")
+			return
+		}
+		notation := Notation{
+			Name:          string(subpacket[8 : 8+nameLength]),
+			Value:         append([]byte(nil), subpacket[8+nameLength:]...),
+			HumanReadable: subpacket[0]&0x80 == 0x80,
+			IsCritical:    isCritical,
+		}
+		if isHashed {
+			sig.Notations = append(sig.Notations, notation)
+		} else {
+			sig.UnhashedNotations = append(sig.UnhashedNotations, notation)
+		}
 	case prefHashAlgosSubpacket:
 		// Preferred hash algorithms, section 5.2.3.8
 		if !isHashed {
@@ -324,6 +406,19 @@ func parseSignatureSubpacket(sig *Signature, subpacket []byte, isHashed bool) (r
 		if subpacket[0] > 0 {
 			*sig.IsPrimaryId = true
 		}
+	case policyURISubpacket:
+		// Policy URI, section 5.2.3.20
+		if !isHashed {
+			return
+		}
+		sig.PolicyURI = string(subpacket)
+	case signerUserIdSubpacket:
+		// Signer's User ID, section 5.2.3.22
+		if !isHashed {
+			return
+		}
+		userId := string(subpacket)
+		sig.SignerUserId = &userId
 	case keyFlagsSubpacket:
 		// Key flags, section 5.2.3.21
 		if !isHashed {
@@ -446,6 +541,9 @@ func serializeSubpackets(to []byte, subpackets []outputSubpacket, hashed bool) {
 		if subpacket.hashed == hashed {
 			n := serializeSubpacketLength(to, len(subpacket.contents)+1)
 			to[n] = byte(subpacket.subpacketType)
+			if subpacket.isCritical {
+				to[n] |= 0x80
+			}
 			to = to[1+n:]
 			n = copy(to, subpacket.contents)
 			to = to[n:]
@@ -658,6 +756,45 @@ func (sig *Signature) Serialize(w io.Writer) (err error) {
 	return
 }
 
+// CheckNotations returns an error if the hashed area of the signature has a
+// critical notation that is not known by config. See RFC 4880, section
+// 5.2.3.16.
+func (sig *Signature) CheckNotations(config *Config) error {
+	for _, notation := range sig.Notations {
+		if notation.IsCritical && !config.KnownNotation(notation.Name) {
+			return errors.SignatureError("unknown critical notation: " + notation.Name)
+		}
+	}
+	return nil
+}
+
+// AddUnhashedNotation adds a notation to the unhashed area of a signature
+// that was already made or parsed.
+func (sig *Signature) AddUnhashedNotation(notation Notation) {
+	if len(sig.outSubpackets) == 0 {
+		sig.outSubpackets = sig.rawSubpackets
+	}
+	sig.outSubpackets = append(sig.outSubpackets[:len(sig.outSubpackets):len(sig.outSubpackets)], outputSubpacket{false, notationDataSubpacket, notation.IsCritical, notation.contents()})
+	sig.UnhashedNotations = append(sig.UnhashedNotations, notation)
+}
+
+// SignatureValue returns the serialized signature MPIs. Timestamps of the
+// signature value prove that the signature existed at their time.
+func (sig *Signature) SignatureValue() []byte {
+	var b bytes.Buffer
+
+	switch sig.PubKeyAlgo {
+	case PubKeyAlgoRSA, PubKeyAlgoRSASignOnly:
+		_ = writeMPIs(&b, sig.RSASignature)
+	case PubKeyAlgoDSA:
+		_ = writeMPIs(&b, sig.DSASigR, sig.DSASigS)
+	case PubKeyAlgoECDSA:
+		_ = writeMPIs(&b, sig.ECDSASigR, sig.ECDSASigS)
+	}
+
+	return b.Bytes()
+}
+
 // outputSubpacket represents a subpacket to be marshaled.
 type outputSubpacket struct {
 	hashed        bool // true if this subpacket is in the hashed area.
@@ -683,6 +820,31 @@ func (sig *Signature) buildSubpackets() (subpackets []outputSubpacket) {
 		subpackets = append(subpackets, outputSubpacket{true, signatureExpirationSubpacket, true, sigLifetime})
 	}
 
+	if sig.TrustLevel != 0 || sig.TrustAmount != 0 {
+		subpackets = append(subpackets, outputSubpacket{true, trustSubpacket, false, []byte{sig.TrustLevel, sig.TrustAmount}})
+	}
+
+	if sig.RevocationReason != nil {
+		reason := append([]byte{*sig.RevocationReason}, sig.RevocationReasonText...)
+		subpackets = append(subpackets, outputSubpacket{true, reasonForRevocationSubpacket, false, reason})
+	}
+
+	for _, notation := range sig.Notations {
+		subpackets = append(subpackets, outputSubpacket{true, notationDataSubpacket, notation.IsCritical, notation.contents()})
+	}
+
+	for _, notation := range sig.UnhashedNotations {
+		subpackets = append(subpackets, outputSubpacket{false, notationDataSubpacket, notation.IsCritical, notation.contents()})
+	}
+
+	if sig.PolicyURI != "" {
+		subpackets = append(subpackets, outputSubpacket{true, policyURISubpacket, false, []byte(sig.PolicyURI)})
+	}
+
+	if sig.SignerUserId != nil {
+		subpackets = append(subpackets, outputSubpacket{true, signerUserIdSubpacket, false, []byte(*sig.SignerUserId)})
+	}
+
 	// Key flags may only appear in self-signatures or certification signatures.
 
 	if sig.FlagsValid {
@@ -75,4 +75,143 @@ func TestSignUserId(t *testing.T) {
 	}
 }
 
+func TestSubpacketsReserialize(t *testing.T) {
+	packet, err := Read(readerFromHex(rsaPkDataHex))
+	if err != nil {
+		t.Fatalf("failed to deserialize public key: %v", err)
+	}
+	pubKey := packet.(*PublicKey)
+
+	packet, err = Read(readerFromHex(privKeyRSAHex))
+	if err != nil {
+		t.Fatalf("failed to deserialize private key: %v", err)
+	}
+	privKey := packet.(*PrivateKey)
+
+	if err = privKey.Decrypt([]byte("testing")); err != nil {
+		t.Fatalf("failed to decrypt private key: %v", err)
+	}
+
+	signerUserId := "John HUEBR <john@huebr.com>"
+	sig := &Signature{
+		SigType:     SigTypeGenericCert,
+		PubKeyAlgo:  PubKeyAlgoRSA,
+		Hash:        crypto.SHA256,
+		TrustLevel:  1,
+		TrustAmount: 120,
+		Notations: []Notation{
+			{Name: "invoice@huebr.com", Value: []byte("1234"), HumanReadable: true},
+			{Name: "hash@huebr.com", Value: []byte{0, 1, 2}, IsCritical: true},
+		},
+		PolicyURI:    "https://huebr.com/policy",
+		SignerUserId: &signerUserId,
+	}
+
+	if err = sig.SignUserId("", pubKey, privKey, nil); err != nil {
+		t.Fatalf("failed to sign user id: %v", err)
+	}
+
+	out := new(bytes.Buffer)
+	if err = sig.Serialize(out); err != nil {
+		t.Fatalf("error serializing: %s", err)
+	}
+
+	packet, err = Read(out)
+	if err != nil {
+		t.Fatalf("failed to deserialize signature: %v", err)
+	}
+
+	parsed := packet.(*Signature)
+	if parsed.TrustLevel != 1 || parsed.TrustAmount != 120 {
+		t.Errorf("expected trust level 1 and amount 120, got %d and %d", parsed.TrustLevel, parsed.TrustAmount)
+	}
+
+	if len(parsed.Notations) != 2 {
+		t.Fatalf("expected 2 notations, got %d", len(parsed.Notations))
+	}
+
+	for i, notation := range sig.Notations {
+		got := parsed.Notations[i]
+		if got.Name != notation.Name || !bytes.Equal(got.Value, notation.Value) || got.HumanReadable != notation.HumanReadable || got.IsCritical != notation.IsCritical {
+			t.Errorf("expected notation %+v, got %+v", notation, got)
+		}
+	}
+
+	if parsed.PolicyURI != sig.PolicyURI || parsed.SignerUserId == nil || *parsed.SignerUserId != signerUserId {
+		t.Errorf("expected policy URI %q and signer user id %q, got %q and %v", sig.PolicyURI, signerUserId, parsed.PolicyURI, parsed.SignerUserId)
+	}
+
+	if err = privKey.PublicKey.VerifyUserIdSignature("", pubKey, parsed); err != nil {
+		t.Errorf("failed to verify trust signature: %v", err)
+	}
+}
+
+func TestAddUnhashedNotation(t *testing.T) {
+	packet, err := Read(readerFromHex(privKeyRSAHex))
+	if err != nil {
+		t.Fatalf("failed to deserialize private key: %v", err)
+	}
+	privKey := packet.(*PrivateKey)
+
+	if err = privKey.Decrypt([]byte("testing")); err != nil {
+		t.Fatalf("failed to decrypt private key: %v", err)
+	}
+
+	sig := &Signature{
+		SigType:    SigTypeBinary,
+		PubKeyAlgo: PubKeyAlgoRSA,
+		Hash:       crypto.SHA256,
+	}
+
+	h := crypto.SHA256.New()
+	_, _ = h.Write([]byte("data"))
+	if err = sig.Sign(h, privKey, nil); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	notation := Notation{Name: "timestamp@huebr.com", Value: sig.SignatureValue()}
+	sig.AddUnhashedNotation(notation)
+
+	out := new(bytes.Buffer)
+	if err = sig.Serialize(out); err != nil {
+		t.Fatalf("error serializing: %s", err)
+	}
+
+	packet, err = Read(out)
+	if err != nil {
+		t.Fatalf("failed to deserialize signature: %v", err)
+	}
+
+	parsed := packet.(*Signature)
+	if len(parsed.Notations) != 0 || len(parsed.UnhashedNotations) != 1 || !bytes.Equal(parsed.UnhashedNotations[0].Value, notation.Value) {
+		t.Errorf("expected unhashed notation %+v, got %+v and %+v", notation, parsed.Notations, parsed.UnhashedNotations)
+	}
+
+	h = crypto.SHA256.New()
+	_, _ = h.Write([]byte("data"))
+	if err = privKey.PublicKey.VerifySignature(h, parsed); err != nil {
+		t.Errorf("failed to verify signature with unhashed notation: %v", err)
+	}
+}
+
+func TestCheckNotations(t *testing.T) {
+	sig := &Signature{
+		Notations: []Notation{
+			{Name: "invoice@huebr.com", Value: []byte("1234"), HumanReadable: true},
+			{Name: "hash@huebr.com", Value: []byte{0, 1, 2}, IsCritical: true},
+		},
+		UnhashedNotations: []Notation{
+			{Name: "unhashed@huebr.com", IsCritical: true},
+		},
+	}
+
+	if err := sig.CheckNotations(nil); err == nil {
+		t.Error("expected unknown critical notation to be rejected")
+	}
+
+	if err := sig.CheckNotations(&Config{KnownNotations: map[string]bool{"hash@huebr.com": true}}); err != nil {
+		t.Errorf("expected known critical notation to be accepted, got %v", err)
+	}
+}
+
 const signatureDataHex = "c2c05c04000102000605024cb45112000a0910ab105c91af38fb158f8d07ff5596ea368c5efe015bed6e78348c0f033c931d5f2ce5db54ce7f2a7e4b4ad64db758d65a7a71773edeab7ba2a9e0908e6a94a1175edd86c1d843279f045b021a6971a72702fcbd650efc393c5474d5b59a15f96d2eaad4c4c426797e0dcca2803ef41c6ff234d403eec38f31d610c344c06f2401c262f0993b2e66cad8a81ebc4322c723e0d4ba09fe917e8777658307ad8329adacba821420741009dfe87f007759f0982275d028a392c6ed983a0d846f890b36148c7358bdb8a516007fac760261ecd06076813831a36d0459075d1befa245ae7f7fb103d92ca759e9498fe60ef8078a39a3beda510deea251ea9f0a7f0df6ef42060f20780360686f3e400e"
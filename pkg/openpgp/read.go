@@ -136,7 +136,7 @@ ParsePackets:
 				return nil, errors.StructuralError("key material not followed by encrypted message")
 			}
 			packets.Unread(p)
-			return readSignedMessage(packets, nil, keyring)
+			return readSignedMessage(packets, nil, keyring, config)
 		}
 	}
 
@@ -216,13 +216,13 @@ FindKey:
 	if err := packets.Push(decrypted); err != nil {
 		return nil, err
 	}
-	return readSignedMessage(packets, md, keyring)
+	return readSignedMessage(packets, md, keyring, config)
 }
 
 // readSignedMessage reads a possibly signed message if mdin is non-zero then
 // that structure is updated and returned. Otherwise a fresh MessageDetails is
 // used.
-func readSignedMessage(packets *packet.Reader, mdin *MessageDetails, keyring KeyRing) (md *MessageDetails, err error) {
+func readSignedMessage(packets *packet.Reader, mdin *MessageDetails, keyring KeyRing, config *packet.Config) (md *MessageDetails, err error) {
 	if mdin == nil {
 		mdin = new(MessageDetails)
 	}
@@ -266,7 +266,7 @@ FindLiteralData:
 	}
 
 	if md.SignedBy != nil {
-		md.UnverifiedBody = &signatureCheckReader{packets, h, wrappedHash, md}
+		md.UnverifiedBody = &signatureCheckReader{packets, h, wrappedHash, md, config}
 	} else if md.decrypted != nil {
 		md.UnverifiedBody = checkReader{md}
 	} else {
@@ -322,6 +322,7 @@ type signatureCheckReader struct {
 	packets        *packet.Reader
 	h, wrappedHash hash.Hash
 	md             *MessageDetails
+	config         *packet.Config
 }
 
 func (scr *signatureCheckReader) Read(buf []byte) (n int, err error) {
@@ -337,6 +338,9 @@ func (scr *signatureCheckReader) Read(buf []byte) (n int, err error) {
 		var ok bool
 		if scr.md.Signature, ok = p.(*packet.Signature); ok {
 			scr.md.SignatureError = scr.md.SignedBy.PublicKey.VerifySignature(scr.h, scr.md.Signature)
+			if scr.md.SignatureError == nil {
+				scr.md.SignatureError = scr.md.Signature.CheckNotations(scr.config)
+			}
 		} else if scr.md.SignatureV3, ok = p.(*packet.SignatureV3); ok {
 			scr.md.SignatureError = scr.md.SignedBy.PublicKey.VerifySignatureV3(scr.h, scr.md.SignatureV3)
 		} else {
@@ -359,28 +363,58 @@ func (scr *signatureCheckReader) Read(buf []byte) (n int, err error) {
 
 // CheckDetachedSignature takes a signed file and a detached signature and
 // returns the signer if the signature is valid. If the signer isn't known,
-// ErrUnknownIssuer is returned.
+// ErrUnknownIssuer is returned. Signatures with critical notations are
+// rejected.
 func CheckDetachedSignature(keyring KeyRing, signed, signature io.Reader) (signer *Entity, err error) {
+	key, _, err := checkDetachedSignature(signed, signature, nil, func(id uint64) []Key {
+		return keyring.KeysByIdUsage(id, packet.KeyFlagSign)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key.Entity, nil
+}
+
+// VerifyDetachedSignature takes a signed file and a detached signature and
+// returns the key that made the signature and the signature packet if the
+// signature is valid. Unlike CheckDetachedSignature, revoked and expired keys
+// are accepted, so the caller can check the state of the key when the
+// signature was made. If the signer isn't known, ErrUnknownIssuer is returned.
+// Signatures with critical notations not known by config are rejected.
+func VerifyDetachedSignature(keyring KeyRing, signed, signature io.Reader, config *packet.Config) (key *Key, sig packet.Packet, err error) {
+	return checkDetachedSignature(signed, signature, config, func(id uint64) (keys []Key) {
+		for _, key := range keyring.KeysById(id) {
+			if key.SelfSignature != nil && key.SelfSignature.FlagsValid && !key.SelfSignature.FlagSign {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		return
+	})
+}
+
+func checkDetachedSignature(signed, signature io.Reader, config *packet.Config, keysById func(id uint64) []Key) (*Key, packet.Packet, error) {
 	var issuerKeyId uint64
 	var hashFunc crypto.Hash
 	var sigType packet.SignatureType
 	var keys []Key
 	var p packet.Packet
+	var err error
 
 	packets := packet.NewReader(signature)
 	for {
 		p, err = packets.Next()
 		if err == io.EOF {
-			return nil, errors.ErrUnknownIssuer
+			return nil, nil, errors.ErrUnknownIssuer
 		}
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		switch sig := p.(type) {
 		case *packet.Signature:
 			if sig.IssuerKeyId == nil {
-				return nil, errors.StructuralError("signature doesn't have an issuer")
+				return nil, nil, errors.StructuralError("signature doesn't have an issuer")
 			}
 			issuerKeyId = *sig.IssuerKeyId
 			hashFunc = sig.Hash
@@ -390,10 +424,10 @@ func CheckDetachedSignature(keyring KeyRing, signed, signature io.Reader) (signe
 			hashFunc = sig.Hash
 			sigType = sig.SigType
 		default:
-			return nil, errors.StructuralError("non signature packet found")
+			return nil, nil, errors.StructuralError("non signature packet found")
 		}
 
-		keys = keyring.KeysByIdUsage(issuerKeyId, packet.KeyFlagSign)
+		keys = keysById(issuerKeyId)
 		if len(keys) > 0 {
 			break
 		}
@@ -405,17 +439,20 @@ func CheckDetachedSignature(keyring KeyRing, signed, signature io.Reader) (signe
 
 	h, wrappedHash, err := hashForSignature(hashFunc, sigType)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if _, err := io.Copy(wrappedHash, signed); err != nil && err != io.EOF {
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, key := range keys {
 		switch sig := p.(type) {
 		case *packet.Signature:
 			err = key.PublicKey.VerifySignature(h, sig)
+			if err == nil {
+				err = sig.CheckNotations(config)
+			}
 		case *packet.SignatureV3:
 			err = key.PublicKey.VerifySignatureV3(h, sig)
 		default:
@@ -423,11 +460,11 @@ func CheckDetachedSignature(keyring KeyRing, signed, signature io.Reader) (signe
 		}
 
 		if err == nil {
-			return key.Entity, nil
+			return &key, p, nil
 		}
 	}
 
-	return nil, err
+	return nil, nil, err
 }
 
 // CheckArmoredDetachedSignature performs the same actions as
@@ -440,3 +477,14 @@ func CheckArmoredDetachedSignature(keyring KeyRing, signed, signature io.Reader)
 
 	return CheckDetachedSignature(keyring, signed, body)
 }
+
+// VerifyArmoredDetachedSignature performs the same actions as
+// VerifyDetachedSignature but expects the signature to be armored.
+func VerifyArmoredDetachedSignature(keyring KeyRing, signed, signature io.Reader, config *packet.Config) (key *Key, sig packet.Packet, err error) {
+	body, err := readArmored(signature, SignatureType)
+	if err != nil {
+		return
+	}
+
+	return VerifyDetachedSignature(keyring, signed, body, config)
+}
@@ -73,6 +73,18 @@ func detachSign(w io.Writer, signer *Entity, message io.Reader, sigType packet.S
 	sig.Hash = config.Hash()
 	sig.CreationTime = config.Now()
 	sig.IssuerKeyId = &signer.PrivateKey.KeyId
+	if config != nil {
+		if config.SigLifetimeSecs != 0 {
+			lifetime := config.SigLifetimeSecs
+			sig.SigLifetimeSecs = &lifetime
+		}
+		sig.Notations = config.SigNotations
+		sig.PolicyURI = config.SigPolicyURI
+		if config.SignerUserId != "" {
+			userId := config.SignerUserId
+			sig.SignerUserId = &userId
+		}
+	}
 
 	h, wrappedHash, err := hashForSignature(sig.Hash, sig.SigType)
 	if err != nil {
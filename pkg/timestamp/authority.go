@@ -0,0 +1,294 @@
+package timestamp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// DefaultPolicy is the policy of the tokens issued by a Authority. It is a placeholder, since the tokens are not issued
+// under a registered policy
+var DefaultPolicy = asn1.ObjectIdentifier{1, 2, 3, 4, 1}
+
+// Authority is a minimal timestamp authority that issues tokens signed by a single key
+type Authority struct {
+	signer      crypto.Signer
+	certificate *x509.Certificate
+	// Policy is the policy of the issued tokens. Requests of other policies are rejected
+	Policy asn1.ObjectIdentifier
+	// Now returns the time of the issued tokens. If nil, time.Now is used
+	Now func() time.Time
+}
+
+// MakeAuthority creates a Authority that signs tokens with signer. The certificate should be of the signer public key
+func MakeAuthority(signer crypto.Signer, certificate *x509.Certificate) *Authority {
+	return &Authority{
+		signer:      signer,
+		certificate: certificate,
+		Policy:      DefaultPolicy,
+	}
+}
+
+// MakeAuthorityCertificate creates a self signed timestamp authority certificate of the signer key. The certificate is
+// valid for 100 years from notBefore, and RSA keys always create the same certificate for the same parameters
+func MakeAuthorityCertificate(signer crypto.Signer, commonName string, serialNumber *big.Int, notBefore time.Time) (*x509.Certificate, error) {
+	eku, err := asn1.Marshal([]asn1.ObjectIdentifier{oidExtKeyUsageTimestamp})
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore = notBefore.UTC().Truncate(time.Second)
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.AddDate(100, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		BasicConstraintsValid: true,
+		// RFC 3161 requires the extended key usage to be critical
+		ExtraExtensions: []pkix.Extension{{Id: oidExtKeyUsage, Critical: true, Value: eku}},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(der)
+}
+
+// Certificate returns the certificate of the authority
+func (a *Authority) Certificate() *x509.Certificate {
+	return a.certificate
+}
+
+// Timestamp returns a DER encoded token of the digest with the authority certificate
+func (a *Authority) Timestamp(h crypto.Hash, digest []byte, nonce *big.Int) ([]byte, error) {
+	return a.token(h, digest, nonce, true)
+}
+
+// Respond returns the DER encoded response of a DER encoded timestamp request. Invalid requests get a rejection response
+func (a *Authority) Respond(request []byte) ([]byte, error) {
+	req, err := parseRequest(request)
+	if err != nil {
+		return rejection(failureBadRequest, "invalid timestamp request")
+	}
+
+	h, ok := hashAlgorithm(req.MessageImprint.HashAlgorithm)
+	if !ok {
+		return rejection(failureBadAlg, "unsupported hash algorithm")
+	}
+
+	if len(req.MessageImprint.HashedMessage) != h.Size() {
+		return rejection(failureBadDataFormat, "invalid hashed message length")
+	}
+
+	if len(req.ReqPolicy) > 0 && !req.ReqPolicy.Equal(a.Policy) {
+		return rejection(failureUnacceptedPolicy, "unaccepted policy")
+	}
+
+	token, err := a.token(h, req.MessageImprint.HashedMessage, req.Nonce, req.CertReq)
+	if err != nil {
+		return rejection(failureSystemFailure, "error creating the timestamp token")
+	}
+
+	return asn1.Marshal(timeStampResp{
+		Status:         pkiStatusInfo{Status: statusGranted},
+		TimeStampToken: asn1.RawValue{FullBytes: token},
+	})
+}
+
+// rejection returns a DER encoded rejection response
+func rejection(failure int, text string) ([]byte, error) {
+	failInfo := asn1.BitString{
+		Bytes:     make([]byte, failure/8+1),
+		BitLength: failure + 1,
+	}
+	failInfo.Bytes[failure/8] = 0x80 >> uint(failure%8)
+
+	statusString, err := makeFreeText(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(timeStampResp{
+		Status: pkiStatusInfo{
+			Status:       statusRejection,
+			StatusString: statusString,
+			FailInfo:     failInfo,
+		},
+	})
+}
+
+// token returns a DER encoded token of the digest
+func (a *Authority) token(h crypto.Hash, digest []byte, nonce *big.Int, includeCertificate bool) ([]byte, error) {
+	hashAlgorithm, ok := hashAlgorithmIdentifier(h)
+	if !ok || len(digest) != h.Size() {
+		return nil, fmt.Errorf("unsupported hash %d for a digest of %d bytes", h, len(digest))
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now
+	if a.Now != nil {
+		now = a.Now
+	}
+
+	content, err := asn1.Marshal(tstInfo{
+		Version: 1,
+		Policy:  a.Policy,
+		MessageImprint: messageImprint{
+			HashAlgorithm: hashAlgorithm,
+			HashedMessage: digest,
+		},
+		SerialNumber: serialNumber,
+		GenTime:      now().UTC().Truncate(time.Second),
+		Accuracy:     accuracy{Seconds: 1},
+		Nonce:        nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	si, err := a.signerInfo(content)
+	if err != nil {
+		return nil, err
+	}
+
+	sha256Algorithm, _ := hashAlgorithmIdentifier(crypto.SHA256)
+
+	sd := signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algorithm},
+		EncapContentInfo: encapsulatedContentInfo{
+			EContentType: oidTSTInfo,
+			EContent:     content,
+		},
+		SignerInfos: []signerInfo{si},
+	}
+
+	if includeCertificate {
+		sd.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: a.certificate.Raw}
+	}
+
+	sdBytes, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sdBytes},
+	})
+}
+
+// signerInfo returns the signer info with the signature of the content by the authority key
+func (a *Authority) signerInfo(content []byte) (signerInfo, error) {
+	var signatureAlgorithm pkix.AlgorithmIdentifier
+
+	switch a.signer.Public().(type) {
+	case *rsa.PublicKey:
+		signatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidSHA256WithRSA, Parameters: asn1.NullRawValue}
+	case *ecdsa.PublicKey:
+		signatureAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}
+	default:
+		return signerInfo{}, fmt.Errorf("unsupported timestamp authority key %T", a.signer.Public())
+	}
+
+	contentDigest := sha256.Sum256(content)
+	certificateDigest := sha256.Sum256(a.certificate.Raw)
+
+	contentType, err := asn1.Marshal(oidTSTInfo)
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	messageDigest, err := asn1.Marshal(contentDigest[:])
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	signingCertificate, err := asn1.Marshal(signingCertificateV2{Certs: []essCertIDv2{{CertHash: certificateDigest[:]}}})
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	attrs, err := marshalAttributes(
+		attribute{Type: oidContentType, Values: setOf(contentType)},
+		attribute{Type: oidMessageDigest, Values: setOf(messageDigest)},
+		attribute{Type: oidSigningCertificateV2, Values: setOf(signingCertificate)},
+	)
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	// The signature is made over the DER encoded SET OF the signed attributes
+	signed, err := asn1.Marshal(setOf(attrs))
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	signedDigest := sha256.Sum256(signed)
+	signature, err := a.signer.Sign(rand.Reader, signedDigest[:], crypto.SHA256)
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	sid, err := asn1.Marshal(issuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: a.certificate.RawIssuer},
+		SerialNumber: a.certificate.SerialNumber,
+	})
+	if err != nil {
+		return signerInfo{}, err
+	}
+
+	sha256Algorithm, _ := hashAlgorithmIdentifier(crypto.SHA256)
+
+	return signerInfo{
+		Version:            1,
+		SID:                asn1.RawValue{FullBytes: sid},
+		DigestAlgorithm:    sha256Algorithm,
+		SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs},
+		SignatureAlgorithm: signatureAlgorithm,
+		Signature:          signature,
+	}, nil
+}
+
+// setOf returns a SET with the DER encoded content
+func setOf(content []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: content}
+}
+
+// marshalAttributes returns the DER encoded attributes sorted as required by a DER SET OF
+func marshalAttributes(attrs ...attribute) ([]byte, error) {
+	encoded := make([][]byte, 0, len(attrs))
+
+	for _, attr := range attrs {
+		b, err := asn1.Marshal(attr)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, b)
+	}
+
+	sort.Slice(encoded, func(i, j int) bool {
+		return bytes.Compare(encoded[i], encoded[j]) < 0
+	})
+
+	return bytes.Join(encoded, nil), nil
+}
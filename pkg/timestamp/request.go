@@ -0,0 +1,139 @@
+package timestamp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"encoding/asn1"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// MimeRequest is the content type of timestamp requests
+const MimeRequest = "application/timestamp-query"
+
+// MimeResponse is the content type of timestamp responses
+const MimeResponse = "application/timestamp-reply"
+
+// DefaultTimeout is the timeout of the requests to a timestamp authority
+const DefaultTimeout = 30 * time.Second
+
+// maxResponseSize is the maximum size of a timestamp response
+const maxResponseSize = 1 << 20
+
+// MakeRequest returns a DER encoded request of a timestamp of the digest, that asks for the timestamp authority certificate
+func MakeRequest(h crypto.Hash, digest []byte, nonce *big.Int) ([]byte, error) {
+	algorithm, ok := hashAlgorithmIdentifier(h)
+	if !ok || len(digest) != h.Size() {
+		return nil, fmt.Errorf("unsupported hash %d for a digest of %d bytes", h, len(digest))
+	}
+
+	return asn1.Marshal(timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: algorithm,
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+}
+
+// CheckRequest returns an error if der is not a DER encoded timestamp request
+func CheckRequest(der []byte) error {
+	_, err := parseRequest(der)
+	return err
+}
+
+// parseRequest parses a DER encoded timestamp request
+func parseRequest(der []byte) (timeStampReq, error) {
+	var req timeStampReq
+
+	rest, err := asn1.Unmarshal(der, &req)
+	if err != nil {
+		return req, err
+	}
+
+	if len(rest) > 0 {
+		return req, fmt.Errorf("trailing data after the timestamp request")
+	}
+
+	if req.Version != 1 {
+		return req, fmt.Errorf("unsupported timestamp request version %d", req.Version)
+	}
+
+	return req, nil
+}
+
+// ParseResponse parses a DER encoded timestamp response and returns its token
+func ParseResponse(der []byte) (*Token, error) {
+	var resp timeStampResp
+
+	if _, err := asn1.Unmarshal(der, &resp); err != nil {
+		return nil, fmt.Errorf("invalid timestamp response: %s", err)
+	}
+
+	if resp.Status.Status != statusGranted && resp.Status.Status != statusGrantedWithMods {
+		return nil, fmt.Errorf("timestamp request rejected with status %d: %s", resp.Status.Status, freeText(resp.Status.StatusString))
+	}
+
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, fmt.Errorf("the timestamp response does not have a token")
+	}
+
+	return ParseToken(resp.TimeStampToken.FullBytes)
+}
+
+// Request requests a timestamp of the digest to the timestamp authority at url. Returns the verified token
+func Request(url string, h crypto.Hash, digest []byte) (*Token, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := MakeRequest(h, digest, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: DefaultTimeout}
+
+	res, err := client.Post(url, MimeRequest, bytes.NewReader(req))
+	if err != nil {
+		return nil, fmt.Errorf("error requesting timestamp: %s", err)
+	}
+
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading timestamp response: %s", err)
+	}
+
+	if len(body) > maxResponseSize {
+		return nil, fmt.Errorf("the timestamp response is too big")
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the timestamp authority returned status %d", res.StatusCode)
+	}
+
+	token, err := ParseResponse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Nonce == nil || token.Nonce.Cmp(nonce) != 0 {
+		return nil, fmt.Errorf("the timestamp response nonce does not match the request")
+	}
+
+	if err := token.verifyDigest(h, digest); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
@@ -0,0 +1,173 @@
+package timestamp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func makeTestAuthority(t *testing.T, signer crypto.Signer) *Authority {
+	t.Helper()
+
+	certificate, err := MakeAuthorityCertificate(signer, "Test TSA", big.NewInt(1234), time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return MakeAuthority(signer, certificate)
+}
+
+func TestAuthorityTimestamp(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("huebr for the win!")
+	digest := sha512.Sum512(data)
+
+	for _, signer := range []crypto.Signer{rsaKey, ecdsaKey} {
+		authority := makeTestAuthority(t, signer)
+		now := time.Now().UTC().Truncate(time.Second)
+		authority.Now = func() time.Time {
+			return now
+		}
+
+		der, err := authority.Timestamp(crypto.SHA512, digest[:], big.NewInt(42))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := ParseToken(der)
+		if err != nil {
+			t.Fatalf("%T: %s", signer, err)
+		}
+
+		if !token.GenTime.Equal(now) || token.Nonce.Int64() != 42 || !token.Policy.Equal(DefaultPolicy) {
+			t.Errorf("%T: unexpected token %+v", signer, token)
+		}
+
+		if err := token.VerifyData(data); err != nil {
+			t.Errorf("%T: %s", signer, err)
+		}
+
+		if err := token.VerifyData([]byte("other data")); err == nil {
+			t.Errorf("%T: expected token to not be a timestamp of other data", signer)
+		}
+
+		roots := x509.NewCertPool()
+		roots.AddCert(authority.Certificate())
+		if err := token.VerifyCertificate(roots); err != nil {
+			t.Errorf("%T: %s", signer, err)
+		}
+
+		if err := token.VerifyCertificate(x509.NewCertPool()); err == nil {
+			t.Errorf("%T: expected certificate to not be trusted without roots", signer)
+		}
+
+		// Changing the timestamp info invalidates the token signature
+		tampered := bytes.Replace(der, digest[:8], make([]byte, 8), 1)
+		if _, err := ParseToken(tampered); err == nil {
+			t.Errorf("%T: expected error parsing a tampered token", signer)
+		}
+	}
+}
+
+func TestMakeAuthorityCertificate(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notBefore := time.Now()
+
+	a, err := MakeAuthorityCertificate(key, "Test TSA", big.NewInt(1234), notBefore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := MakeAuthorityCertificate(key, "Test TSA", big.NewInt(1234), notBefore)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(a.Raw, b.Raw) {
+		t.Errorf("Expected the same certificate for the same RSA key and parameters")
+	}
+
+	if len(a.ExtKeyUsage) != 1 || a.ExtKeyUsage[0] != x509.ExtKeyUsageTimeStamping {
+		t.Errorf("Expected timestamping extended key usage, got %v", a.ExtKeyUsage)
+	}
+}
+
+func TestRequest(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authority := makeTestAuthority(t, key)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != MimeRequest {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		req, _ := ioutil.ReadAll(r.Body)
+		res, err := authority.Respond(req)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", MimeResponse)
+		_, _ = w.Write(res)
+	}))
+	defer server.Close()
+
+	digest := sha512.Sum512([]byte("huebr for the win!"))
+
+	token, err := Request(server.URL, crypto.SHA512, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.Signer == nil || !bytes.Equal(token.Signer.Raw, authority.Certificate().Raw) {
+		t.Errorf("Expected token signed by the authority certificate")
+	}
+
+	if _, err := Request(server.URL, crypto.SHA512, digest[:10]); err == nil {
+		t.Errorf("Expected error requesting a timestamp of a invalid digest")
+	}
+
+	// Invalid requests are rejected
+	if err := CheckRequest([]byte("invalid request")); err == nil {
+		t.Errorf("Expected error checking a invalid request")
+	}
+
+	res, err := authority.Respond([]byte("invalid request"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseResponse(res); err == nil {
+		t.Errorf("Expected error parsing a rejection response")
+	}
+}
@@ -0,0 +1,284 @@
+package timestamp
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Token is a RFC 3161 timestamp token with a valid signature of the timestamp authority
+type Token struct {
+	// GenTime is the time the timestamp authority attests the hashed message existed
+	GenTime       time.Time
+	SerialNumber  *big.Int
+	Policy        asn1.ObjectIdentifier
+	HashAlgorithm crypto.Hash
+	HashedMessage []byte
+	Nonce         *big.Int
+	// Signer is the certificate of the timestamp authority that signed the token
+	Signer       *x509.Certificate
+	Certificates []*x509.Certificate
+	// Raw is the DER encoded token
+	Raw []byte
+}
+
+// ParseToken parses a DER encoded timestamp token and verifies its signature. The trust of the timestamp authority
+// certificate is not verified
+func ParseToken(der []byte) (*Token, error) {
+	var ci contentInfo
+
+	rest, err := asn1.Unmarshal(der, &ci)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp token: %s", err)
+	}
+
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("invalid timestamp token: trailing data")
+	}
+
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("invalid timestamp token: content type %s is not signed data", ci.ContentType)
+	}
+
+	var sd signedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("invalid timestamp token signed data: %s", err)
+	}
+
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) || len(sd.EncapContentInfo.EContent) == 0 {
+		return nil, fmt.Errorf("invalid timestamp token: it does not have timestamp info")
+	}
+
+	var info tstInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, fmt.Errorf("invalid timestamp info: %s", err)
+	}
+
+	h, ok := hashAlgorithm(info.MessageImprint.HashAlgorithm)
+	if !ok {
+		return nil, fmt.Errorf("unsupported timestamp hash algorithm %s", info.MessageImprint.HashAlgorithm.Algorithm)
+	}
+
+	var certificates []*x509.Certificate
+	if len(sd.Certificates.Bytes) > 0 {
+		certificates, err = x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp token certificates: %s", err)
+		}
+	}
+
+	if len(sd.SignerInfos) != 1 {
+		return nil, fmt.Errorf("invalid timestamp token: expected one signer, got %d", len(sd.SignerInfos))
+	}
+
+	si := sd.SignerInfos[0]
+
+	signer := findSigner(si.SID, certificates)
+	if signer == nil {
+		return nil, fmt.Errorf("the timestamp token does not have the timestamp authority certificate")
+	}
+
+	if err := verifySignerInfo(si, signer, sd.EncapContentInfo.EContent); err != nil {
+		return nil, err
+	}
+
+	return &Token{
+		GenTime:       info.GenTime,
+		SerialNumber:  info.SerialNumber,
+		Policy:        info.Policy,
+		HashAlgorithm: h,
+		HashedMessage: info.MessageImprint.HashedMessage,
+		Nonce:         info.Nonce,
+		Signer:        signer,
+		Certificates:  certificates,
+		Raw:           der,
+	}, nil
+}
+
+// findSigner returns the certificate identified by the signer id
+func findSigner(sid asn1.RawValue, certificates []*x509.Certificate) *x509.Certificate {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, c := range certificates {
+			if bytes.Equal(c.SubjectKeyId, sid.Bytes) {
+				return c
+			}
+		}
+
+		return nil
+	}
+
+	var ias issuerAndSerialNumber
+	if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+		return nil
+	}
+
+	for _, c := range certificates {
+		if bytes.Equal(c.RawIssuer, ias.Issuer.FullBytes) && c.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// verifySignerInfo verifies the signed attributes of the token and their signature by the signer certificate
+func verifySignerInfo(si signerInfo, signer *x509.Certificate, content []byte) error {
+	h, ok := hashAlgorithm(si.DigestAlgorithm)
+	if !ok {
+		return fmt.Errorf("unsupported timestamp token digest algorithm %s", si.DigestAlgorithm.Algorithm)
+	}
+
+	if len(si.SignedAttrs.Bytes) == 0 {
+		return fmt.Errorf("invalid timestamp token: it does not have signed attributes")
+	}
+
+	var contentType asn1.ObjectIdentifier
+	var messageDigest []byte
+
+	for rest := si.SignedAttrs.Bytes; len(rest) > 0; {
+		var attr attribute
+		var err error
+
+		rest, err = asn1.Unmarshal(rest, &attr)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp token signed attributes: %s", err)
+		}
+
+		switch {
+		case attr.Type.Equal(oidContentType):
+			_, err = asn1.Unmarshal(attr.Values.Bytes, &contentType)
+		case attr.Type.Equal(oidMessageDigest):
+			_, err = asn1.Unmarshal(attr.Values.Bytes, &messageDigest)
+		case attr.Type.Equal(oidSigningCertificateV2):
+			err = verifySigningCertificate(attr.Values.Bytes, signer)
+		}
+
+		if err != nil {
+			return fmt.Errorf("invalid timestamp token signed attribute %s: %s", attr.Type, err)
+		}
+	}
+
+	if !contentType.Equal(oidTSTInfo) {
+		return fmt.Errorf("invalid timestamp token: signed content type %s is not timestamp info", contentType)
+	}
+
+	digest := h.New()
+	_, _ = digest.Write(content)
+	if !bytes.Equal(digest.Sum(nil), messageDigest) {
+		return fmt.Errorf("invalid timestamp token: the timestamp info does not match the signed digest")
+	}
+
+	algorithm, err := signatureAlgorithm(h, signer)
+	if err != nil {
+		return err
+	}
+
+	// The signature is made over the DER encoded SET OF the signed attributes
+	signed := append([]byte(nil), si.SignedAttrs.FullBytes...)
+	signed[0] = 0x31
+
+	if err := signer.CheckSignature(algorithm, signed, si.Signature); err != nil {
+		return fmt.Errorf("invalid timestamp token signature: %s", err)
+	}
+
+	return nil
+}
+
+// verifySigningCertificate verifies that the signing certificate attribute identifies the signer certificate (RFC 5816)
+func verifySigningCertificate(value []byte, signer *x509.Certificate) error {
+	var sc signingCertificateV2
+	if _, err := asn1.Unmarshal(value, &sc); err != nil {
+		return err
+	}
+
+	if len(sc.Certs) == 0 {
+		return fmt.Errorf("no certificates")
+	}
+
+	h := crypto.SHA256
+	if len(sc.Certs[0].HashAlgorithm.Algorithm) > 0 {
+		var ok bool
+		if h, ok = hashAlgorithm(sc.Certs[0].HashAlgorithm); !ok {
+			return fmt.Errorf("unsupported hash algorithm %s", sc.Certs[0].HashAlgorithm.Algorithm)
+		}
+	}
+
+	digest := h.New()
+	_, _ = digest.Write(signer.Raw)
+	if !bytes.Equal(digest.Sum(nil), sc.Certs[0].CertHash) {
+		return fmt.Errorf("it does not match the signer certificate")
+	}
+
+	return nil
+}
+
+// signatureAlgorithm returns the signature algorithm of the signer with the hash
+func signatureAlgorithm(h crypto.Hash, signer *x509.Certificate) (x509.SignatureAlgorithm, error) {
+	var algorithms map[crypto.Hash]x509.SignatureAlgorithm
+
+	switch signer.PublicKey.(type) {
+	case *rsa.PublicKey:
+		algorithms = map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA1:   x509.SHA1WithRSA,
+			crypto.SHA256: x509.SHA256WithRSA,
+			crypto.SHA384: x509.SHA384WithRSA,
+			crypto.SHA512: x509.SHA512WithRSA,
+		}
+	case *ecdsa.PublicKey:
+		algorithms = map[crypto.Hash]x509.SignatureAlgorithm{
+			crypto.SHA1:   x509.ECDSAWithSHA1,
+			crypto.SHA256: x509.ECDSAWithSHA256,
+			crypto.SHA384: x509.ECDSAWithSHA384,
+			crypto.SHA512: x509.ECDSAWithSHA512,
+		}
+	}
+
+	if algorithm, ok := algorithms[h]; ok {
+		return algorithm, nil
+	}
+
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported timestamp authority key %T with hash %d", signer.PublicKey, h)
+}
+
+// VerifyData verifies that the token is a timestamp of data
+func (t *Token) VerifyData(data []byte) error {
+	digest := t.HashAlgorithm.New()
+	_, _ = digest.Write(data)
+
+	return t.verifyDigest(t.HashAlgorithm, digest.Sum(nil))
+}
+
+func (t *Token) verifyDigest(h crypto.Hash, digest []byte) error {
+	if h != t.HashAlgorithm || !bytes.Equal(digest, t.HashedMessage) {
+		return fmt.Errorf("the timestamp token is not a timestamp of the data")
+	}
+
+	return nil
+}
+
+// VerifyCertificate verifies that the timestamp authority certificate was valid at the token time and issued by one of the roots
+func (t *Token) VerifyCertificate(roots *x509.CertPool) error {
+	intermediates := x509.NewCertPool()
+	for _, c := range t.Certificates {
+		intermediates.AddCert(c)
+	}
+
+	_, err := t.Signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   t.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	})
+
+	if err != nil {
+		return fmt.Errorf("the timestamp authority certificate is not trusted: %s", err)
+	}
+
+	return nil
+}
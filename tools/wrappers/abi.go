@@ -0,0 +1,323 @@
+package main
+
+// #include <stdlib.h>
+import "C"
+import (
+	"encoding/json"
+	"strings"
+	"unsafe"
+
+	"github.com/quan-to/chevron/pkg/QuantoError"
+	"github.com/quan-to/chevron/pkg/chevronlib"
+	"github.com/quan-to/chevron/pkg/models"
+	"github.com/quan-to/chevron/pkg/openpgp/errors"
+)
+
+// ABIVersion is the version of the Chevron* C ABI.
+// Functions are only added within a version. Any change in an existing function signature or behaviour bumps it
+const ABIVersion = 1
+
+// The Chevron* functions return OK / TRUE / FALSE or a negative error code: ERROR_KEY_NOT_FOUND if a key is not loaded
+// in the memory keyring, or ERROR for any other error.
+// Results and error messages are allocated by the library in the result and err output pointers
+// and must be released by the caller using ChevronFree. Output pointers are only set when the function returns them
+
+func setString(dst **C.char, s string) {
+	if dst != nil {
+		*dst = C.CString(s)
+	}
+}
+
+func setBytes(dst **C.char, dstLen *C.int, data []byte) {
+	if dst != nil {
+		*dst = (*C.char)(C.CBytes(data))
+	}
+	if dstLen != nil {
+		*dstLen = C.int(len(data))
+	}
+}
+
+func setError(errOut **C.char, err error) C.int {
+	setString(errOut, err.Error())
+
+	if qe, ok := err.(*QuantoError.ErrorObject); ok && qe.ErrorCode == QuantoError.NotFound {
+		return ERROR_KEY_NOT_FOUND
+	}
+
+	return ERROR
+}
+
+// verifyResult returns TRUE for valid signatures and FALSE with the reason in errOut for invalid ones
+func verifyResult(valid bool, errOut **C.char, err error) C.int {
+	if _, ok := err.(errors.SignatureError); ok {
+		setString(errOut, err.Error())
+		return FALSE
+	}
+
+	if err != nil {
+		return setError(errOut, err)
+	}
+
+	if valid {
+		return TRUE
+	}
+
+	return FALSE
+}
+
+func goBytes(data *C.char, dataLen C.int) []byte {
+	return C.GoBytes(unsafe.Pointer(data), dataLen)
+}
+
+func setJSON(dst **C.char, errOut **C.char, v interface{}) C.int {
+	d, err := json.Marshal(v)
+	if err != nil {
+		return setError(errOut, err)
+	}
+
+	setString(dst, string(d))
+	return OK
+}
+
+// ChevronVersion returns the ABI version of the library
+//export ChevronVersion
+func ChevronVersion() C.int {
+	return C.int(ABIVersion)
+}
+
+// ChevronFree releases memory returned by the library
+//export ChevronFree
+func ChevronFree(ptr *C.char) {
+	C.free(unsafe.Pointer(ptr))
+}
+
+// ChevronLoadKey loads a private or public key into the memory keyring
+//export ChevronLoadKey
+func ChevronLoadKey(keyData *C.char, loadedPrivateKeys *C.int, err **C.char) C.int {
+	l, e := chevronlib.LoadKey(C.GoString(keyData))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	if loadedPrivateKeys != nil {
+		*loadedPrivateKeys = C.int(l)
+	}
+
+	return OK
+}
+
+// ChevronUnlockKey unlocks a private key to be used
+//export ChevronUnlockKey
+func ChevronUnlockKey(fingerprint, password *C.char, err **C.char) C.int {
+	e := chevronlib.UnlockKey(C.GoString(fingerprint), C.GoString(password))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	return OK
+}
+
+// ChevronVerifySignature verifies a signature using a already loaded public key. Invalid signatures return FALSE with the reason in err
+//export ChevronVerifySignature
+func ChevronVerifySignature(data *C.char, dataLen C.int, signature *C.char, err **C.char) C.int {
+	r, e := chevronlib.VerifySignature(goBytes(data, dataLen), C.GoString(signature))
+
+	return verifyResult(r, err, e)
+}
+
+// ChevronQuantoVerifySignature verifies a signature in Quanto Signature Format using a already loaded public key.
+// Invalid signatures return FALSE with the reason in err
+//export ChevronQuantoVerifySignature
+func ChevronQuantoVerifySignature(data *C.char, dataLen C.int, signature *C.char, err **C.char) C.int {
+	r, e := chevronlib.QuantoVerifySignature(goBytes(data, dataLen), C.GoString(signature))
+
+	return verifyResult(r, err, e)
+}
+
+// ChevronSignData signs data using a already loaded and unlocked private key
+//export ChevronSignData
+func ChevronSignData(data *C.char, dataLen C.int, fingerprint *C.char, result **C.char, err **C.char) C.int {
+	r, e := chevronlib.SignData(goBytes(data, dataLen), C.GoString(fingerprint))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	setString(result, r)
+	return OK
+}
+
+// ChevronQuantoSignData signs data using a already loaded and unlocked private key and returns in Quanto Signature Format
+//export ChevronQuantoSignData
+func ChevronQuantoSignData(data *C.char, dataLen C.int, fingerprint *C.char, result **C.char, err **C.char) C.int {
+	r, e := chevronlib.QuantoSignData(goBytes(data, dataLen), C.GoString(fingerprint))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	setString(result, r)
+	return OK
+}
+
+// ChevronEncrypt encrypts data to a already loaded public key. The result is ASCII Armored
+//export ChevronEncrypt
+func ChevronEncrypt(data *C.char, dataLen C.int, filename, fingerprint *C.char, result **C.char, err **C.char) C.int {
+	r, e := chevronlib.Encrypt(goBytes(data, dataLen), C.GoString(filename), C.GoString(fingerprint))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	setString(result, r)
+	return OK
+}
+
+// ChevronDecrypt decrypts a ASCII Armored message using a already loaded and unlocked private key.
+// The result is binary data with resultLen bytes
+//export ChevronDecrypt
+func ChevronDecrypt(encryptedData *C.char, result **C.char, resultLen *C.int, filename **C.char, err **C.char) C.int {
+	data, fn, e := chevronlib.DecryptData(C.GoString(encryptedData))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	setBytes(result, resultLen, data)
+	setString(filename, fn)
+	return OK
+}
+
+// ChevronGenerateKey generates a new key using specified bits and identifier and encrypts it using the specified password
+//export ChevronGenerateKey
+func ChevronGenerateKey(password, identifier *C.char, bits C.int, result **C.char, err **C.char) C.int {
+	r, e := chevronlib.GenerateKey(C.GoString(password), C.GoString(identifier), int(bits))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	setString(result, r)
+	return OK
+}
+
+// ChevronGetKeyFingerprints returns all fingerprints in a ASCII Armored PGP Keychain as a comma separated list
+//export ChevronGetKeyFingerprints
+func ChevronGetKeyFingerprints(keyData *C.char, result **C.char, err **C.char) C.int {
+	fps, e := chevronlib.GetKeyFingerprints(C.GoString(keyData))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	setString(result, strings.Join(fps, ","))
+	return OK
+}
+
+// ChevronChangeKeyPassword re-encrypts the input key using newPassword
+//export ChevronChangeKeyPassword
+func ChevronChangeKeyPassword(keyData, currentPassword, newPassword *C.char, result **C.char, err **C.char) C.int {
+	r, e := chevronlib.ChangeKeyPassword(C.GoString(keyData), C.GoString(currentPassword), C.GoString(newPassword))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	setString(result, r)
+	return OK
+}
+
+// ChevronGetPublicKey returns the cached public key from the specified fingerprint
+//export ChevronGetPublicKey
+func ChevronGetPublicKey(fingerprint *C.char, result **C.char, err **C.char) C.int {
+	r, e := chevronlib.GetPublicKey(C.GoString(fingerprint))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	setString(result, r)
+	return OK
+}
+
+// ChevronGetFingerprintFromKey returns the main fingerprint of a ASCII Armored key
+//export ChevronGetFingerprintFromKey
+func ChevronGetFingerprintFromKey(keyData *C.char, result **C.char, err **C.char) C.int {
+	r, e := chevronlib.GetFingerprintFromKey(C.GoString(keyData))
+	if e != nil {
+		return setError(err, e)
+	}
+
+	setString(result, r)
+	return OK
+}
+
+// ChevronGPG2Quanto converts a GPG Signature to Quanto Signature Format
+//export ChevronGPG2Quanto
+func ChevronGPG2Quanto(signature, fingerprint, hash *C.char, result **C.char) C.int {
+	setString(result, chevronlib.GPG2Quanto(C.GoString(signature), C.GoString(fingerprint), C.GoString(hash)))
+	return OK
+}
+
+// ChevronQuanto2GPG converts a Quanto Signature to GPG Signature
+//export ChevronQuanto2GPG
+func ChevronQuanto2GPG(signature *C.char, result **C.char) C.int {
+	setString(result, chevronlib.Quanto2GPG(C.GoString(signature)))
+	return OK
+}
+
+// ChevronFieldCipher encrypts JSON fields to already loaded public keys.
+// The input is a JSON serialized FieldCipherInput and the result a JSON serialized CipherPacket
+//export ChevronFieldCipher
+func ChevronFieldCipher(input *C.char, result **C.char, err **C.char) C.int {
+	var data models.FieldCipherInput
+
+	if e := json.Unmarshal([]byte(C.GoString(input)), &data); e != nil {
+		return setError(err, e)
+	}
+
+	packet, e := chevronlib.FieldCipher(data)
+	if e != nil {
+		return setError(err, e)
+	}
+
+	return setJSON(result, err, packet)
+}
+
+// ChevronFieldDecipher decrypts JSON fields using a already loaded and unlocked private key.
+// The input is a JSON serialized FieldDecipherInput and the result a JSON serialized DecipherPacket
+//export ChevronFieldDecipher
+func ChevronFieldDecipher(input *C.char, result **C.char, err **C.char) C.int {
+	var data models.FieldDecipherInput
+
+	if e := json.Unmarshal([]byte(C.GoString(input)), &data); e != nil {
+		return setError(err, e)
+	}
+
+	packet, e := chevronlib.FieldDecipher(data)
+	if e != nil {
+		return setError(err, e)
+	}
+
+	return setJSON(result, err, packet)
+}
+
+// ChevronSetBlindIndexKey sets the secret key used to compute fieldcipher blind indexes
+//export ChevronSetBlindIndexKey
+func ChevronSetBlindIndexKey(key *C.char, keyLen C.int, err **C.char) C.int {
+	if e := chevronlib.SetBlindIndexKey(goBytes(key, keyLen)); e != nil {
+		return setError(err, e)
+	}
+
+	return OK
+}
+
+// ChevronComputeBlindIndex returns the blind index of a JSON serialized value for the field selected by the JSONPath pattern
+//export ChevronComputeBlindIndex
+func ChevronComputeBlindIndex(field, value *C.char, result **C.char, err **C.char) C.int {
+	var v interface{}
+
+	if e := json.Unmarshal([]byte(C.GoString(value)), &v); e != nil {
+		return setError(err, e)
+	}
+
+	r, e := chevronlib.ComputeBlindIndex(C.GoString(field), v)
+	if e != nil {
+		return setError(err, e)
+	}
+
+	setString(result, r)
+	return OK
+}
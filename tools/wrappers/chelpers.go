@@ -16,6 +16,9 @@ const FALSE = C.int(0)
 // ERROR is a C Int with value -1
 const ERROR = C.int(-1)
 
+// ERROR_KEY_NOT_FOUND is a C Int with value -2, returned when a key is not loaded in the memory keyring
+const ERROR_KEY_NOT_FOUND = C.int(-2)
+
 // OK is a C Int with value 1
 const OK = TRUE
 
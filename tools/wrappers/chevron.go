@@ -8,6 +8,8 @@ import (
 )
 
 // LoadKey loads a private or public key into the memory keyring
+//
+// Deprecated: use ChevronLoadKey
 //export LoadKey
 func LoadKey(keyData *C.char, result *C.char, resultLen C.int) (err C.int, loadedPrivateKeys C.int) {
 	goKeyData := C.GoString(keyData)
@@ -24,6 +26,8 @@ func LoadKey(keyData *C.char, result *C.char, resultLen C.int) (err C.int, loade
 }
 
 // UnlockKey unlocks a private key to be used
+//
+// Deprecated: use ChevronUnlockKey
 //export UnlockKey
 func UnlockKey(fingerprint, password *C.char, result *C.char, resultLen C.int) C.int {
 	goFingerprint := C.GoString(fingerprint)
@@ -39,6 +43,8 @@ func UnlockKey(fingerprint, password *C.char, result *C.char, resultLen C.int) C
 }
 
 // VerifySignature verifies a signature using a already loaded public key
+//
+// Deprecated: use ChevronVerifySignature, which also returns why a signature is invalid
 //export VerifySignature
 func VerifySignature(data *C.char, dataLen C.int, signature *C.char, result *C.char, resultLen C.int) C.int {
 	goData := make([]byte, int(dataLen))
@@ -63,6 +69,8 @@ func VerifySignature(data *C.char, dataLen C.int, signature *C.char, result *C.c
 }
 
 // QuantoVerifySignature verifies a signature in Quanto Signature Format using a already loaded public key
+//
+// Deprecated: use ChevronQuantoVerifySignature, which also returns why a signature is invalid
 //export QuantoVerifySignature
 func QuantoVerifySignature(data *C.char, dataLen C.int, signature *C.char, result *C.char, resultLen C.int) C.int {
 	goData := make([]byte, int(dataLen))
@@ -87,6 +95,8 @@ func QuantoVerifySignature(data *C.char, dataLen C.int, signature *C.char, resul
 }
 
 // VerifyBase64DataSignature verifies a signature using a already loaded public key. The b64data is a raw binary data encoded in base64 string
+//
+// Deprecated: use ChevronVerifySignature with the decoded data
 //export VerifyBase64DataSignature
 func VerifyBase64DataSignature(b64data, signature *C.char, result *C.char, resultLen C.int) C.int {
 	goB64Data := C.GoString(b64data)
@@ -109,6 +119,8 @@ func VerifyBase64DataSignature(b64data, signature *C.char, result *C.char, resul
 
 // QuantoVerifyBase64DataSignature verifies a signature in Quanto Signature Format using a already loaded public key.
 // The b64data is a raw binary data encoded in base64 string
+//
+// Deprecated: use ChevronQuantoVerifySignature with the decoded data
 //export QuantoVerifyBase64DataSignature
 func QuantoVerifyBase64DataSignature(b64data, signature *C.char, result *C.char, resultLen C.int) C.int {
 	goB64Data := C.GoString(b64data)
@@ -130,6 +142,8 @@ func QuantoVerifyBase64DataSignature(b64data, signature *C.char, result *C.char,
 }
 
 // SignData signs data using a already loaded and unlocked private key
+//
+// Deprecated: use ChevronSignData. The signature is truncated when it does not fit in resultLen bytes
 //export SignData
 func SignData(data *C.char, dataLen C.int, fingerprint *C.char, result *C.char, resultLen C.int) C.int {
 	goData := make([]byte, int(dataLen))
@@ -150,6 +164,8 @@ func SignData(data *C.char, dataLen C.int, fingerprint *C.char, result *C.char,
 }
 
 // QuantoSignData signs data using a already loaded and unlocked private key and returns in Quanto Signature Format
+//
+// Deprecated: use ChevronQuantoSignData. The Quanto signature is cut at resultLen bytes
 //export QuantoSignData
 func QuantoSignData(data *C.char, dataLen C.int, fingerprint *C.char, result *C.char, resultLen C.int) C.int {
 	goData := make([]byte, int(dataLen))
@@ -171,6 +187,8 @@ func QuantoSignData(data *C.char, dataLen C.int, fingerprint *C.char, result *C.
 
 // SignBase64Data signs data using a already loaded and unlocked private key.
 // The b64data is a raw binary data encoded in base64 string
+//
+// Deprecated: use ChevronSignData with the decoded data. Like SignData, the signature can be truncated
 //export SignBase64Data
 func SignBase64Data(b64data, fingerprint *C.char, result *C.char, resultLen C.int) C.int {
 	goB64Data := C.GoString(b64data)
@@ -191,6 +209,8 @@ func SignBase64Data(b64data, fingerprint *C.char, result *C.char, resultLen C.in
 
 // SignBase64Data signs data using a already loaded and unlocked private key. Returns in Quanto Signature Format
 // The b64data is a raw binary data encoded in base64 string
+//
+// Deprecated: use ChevronQuantoSignData with the decoded data. Like QuantoSignData, the signature can be truncated
 //export QuantoSignBase64Data
 func QuantoSignBase64Data(b64data, fingerprint *C.char, result *C.char, resultLen C.int) C.int {
 	goB64Data := C.GoString(b64data)
@@ -210,6 +230,8 @@ func QuantoSignBase64Data(b64data, fingerprint *C.char, result *C.char, resultLe
 }
 
 // GetKeyFingerprints returns all fingerprints in CSV format from a ASCII Armored PGP Keychain
+//
+// Deprecated: use ChevronGetKeyFingerprints. The fingerprints of keychains with many keys are cut at resultLen bytes
 //export GetKeyFingerprints
 func GetKeyFingerprints(keyData *C.char, result *C.char, resultLen C.int) C.int {
 	goKeyData := C.GoString(keyData)
@@ -230,6 +252,8 @@ func GetKeyFingerprints(keyData *C.char, result *C.char, resultLen C.int) C.int
 }
 
 // ChangeKeyPassword re-encrypts the input key using newPassword
+//
+// Deprecated: use ChevronChangeKeyPassword. The re-encrypted private key is truncated when result is smaller than it
 //export ChangeKeyPassword
 func ChangeKeyPassword(keyData, currentPassword, newPassword *C.char, result *C.char, resultLen C.int) C.int {
 	goKeyData := C.GoString(keyData)
@@ -251,6 +275,8 @@ func ChangeKeyPassword(keyData, currentPassword, newPassword *C.char, result *C.
 }
 
 // GetPublicKey returns the cached public key from the specified fingerprint
+//
+// Deprecated: use ChevronGetPublicKey. Public keys larger than resultLen are returned truncated
 //export GetPublicKey
 func GetPublicKey(fingerprint *C.char, result *C.char, resultLen C.int) C.int {
 	goFingerprint := C.GoString(fingerprint)
@@ -269,6 +295,8 @@ func GetPublicKey(fingerprint *C.char, result *C.char, resultLen C.int) C.int {
 }
 
 // GenerateKey generates a new key using specified bits and identifier and encrypts it using the specified password
+//
+// Deprecated: use ChevronGenerateKey. The generated private key is truncated when resultLen is too small for the key size
 //export GenerateKey
 func GenerateKey(password, identifier *C.char, bits C.int, result *C.char, resultLen C.int) C.int {
 	goPassword := C.GoString(password)
@@ -5,13 +5,15 @@
 
 #line 1 "cgo-builtin-export-prolog"
 
-#include <stddef.h> /* for ptrdiff_t below */
+#include <stddef.h>
 
 #ifndef GO_CGO_EXPORT_PROLOGUE_H
 #define GO_CGO_EXPORT_PROLOGUE_H
 
 #ifndef GO_CGO_GOSTRING_TYPEDEF
 typedef struct { const char *p; ptrdiff_t n; } _GoString_;
+extern size_t _GoStringLen(_GoString_ s);
+extern const char *_GoStringPtr(_GoString_ s);
 #endif
 
 #endif
@@ -19,6 +21,11 @@ typedef struct { const char *p; ptrdiff_t n; } _GoString_;
 /* Start of preamble from import "C" comments.  */
 
 
+#line 3 "abi.go"
+ #include <stdlib.h>
+
+#line 1 "cgo-generated-wrapper"
+
 
 
 /* End of preamble from import "C" comments.  */
@@ -40,11 +47,23 @@ typedef long long GoInt64;
 typedef unsigned long long GoUint64;
 typedef GoInt64 GoInt;
 typedef GoUint64 GoUint;
-typedef __SIZE_TYPE__ GoUintptr;
+typedef size_t GoUintptr;
 typedef float GoFloat32;
 typedef double GoFloat64;
+#ifdef _MSC_VER
+#if !defined(__cplusplus) || _MSVC_LANG <= 201402L
+#include <complex.h>
+typedef _Fcomplex GoComplex64;
+typedef _Dcomplex GoComplex128;
+#else
+#include <complex>
+typedef std::complex<float> GoComplex64;
+typedef std::complex<double> GoComplex128;
+#endif
+#else
 typedef float _Complex GoComplex64;
 typedef double _Complex GoComplex128;
+#endif
 
 /*
   static assertion to make sure the file is being used on architecture
@@ -68,6 +87,72 @@ typedef struct { void *data; GoInt len; GoInt cap; } GoSlice;
 extern "C" {
 #endif
 
+// ChevronVersion returns the ABI version of the library
+extern int ChevronVersion(void);
+
+// ChevronFree releases memory returned by the library
+extern void ChevronFree(char* ptr);
+
+// ChevronLoadKey loads a private or public key into the memory keyring
+extern int ChevronLoadKey(char* keyData, int* loadedPrivateKeys, char** err);
+
+// ChevronUnlockKey unlocks a private key to be used
+extern int ChevronUnlockKey(char* fingerprint, char* password, char** err);
+
+// ChevronVerifySignature verifies a signature using a already loaded public key. Invalid signatures return FALSE with the reason in err
+extern int ChevronVerifySignature(char* data, int dataLen, char* signature, char** err);
+
+// ChevronQuantoVerifySignature verifies a signature in Quanto Signature Format using a already loaded public key.
+// Invalid signatures return FALSE with the reason in err
+extern int ChevronQuantoVerifySignature(char* data, int dataLen, char* signature, char** err);
+
+// ChevronSignData signs data using a already loaded and unlocked private key
+extern int ChevronSignData(char* data, int dataLen, char* fingerprint, char** result, char** err);
+
+// ChevronQuantoSignData signs data using a already loaded and unlocked private key and returns in Quanto Signature Format
+extern int ChevronQuantoSignData(char* data, int dataLen, char* fingerprint, char** result, char** err);
+
+// ChevronEncrypt encrypts data to a already loaded public key. The result is ASCII Armored
+extern int ChevronEncrypt(char* data, int dataLen, char* filename, char* fingerprint, char** result, char** err);
+
+// ChevronDecrypt decrypts a ASCII Armored message using a already loaded and unlocked private key.
+// The result is binary data with resultLen bytes
+extern int ChevronDecrypt(char* encryptedData, char** result, int* resultLen, char** filename, char** err);
+
+// ChevronGenerateKey generates a new key using specified bits and identifier and encrypts it using the specified password
+extern int ChevronGenerateKey(char* password, char* identifier, int bits, char** result, char** err);
+
+// ChevronGetKeyFingerprints returns all fingerprints in a ASCII Armored PGP Keychain as a comma separated list
+extern int ChevronGetKeyFingerprints(char* keyData, char** result, char** err);
+
+// ChevronChangeKeyPassword re-encrypts the input key using newPassword
+extern int ChevronChangeKeyPassword(char* keyData, char* currentPassword, char* newPassword, char** result, char** err);
+
+// ChevronGetPublicKey returns the cached public key from the specified fingerprint
+extern int ChevronGetPublicKey(char* fingerprint, char** result, char** err);
+
+// ChevronGetFingerprintFromKey returns the main fingerprint of a ASCII Armored key
+extern int ChevronGetFingerprintFromKey(char* keyData, char** result, char** err);
+
+// ChevronGPG2Quanto converts a GPG Signature to Quanto Signature Format
+extern int ChevronGPG2Quanto(char* signature, char* fingerprint, char* hash, char** result);
+
+// ChevronQuanto2GPG converts a Quanto Signature to GPG Signature
+extern int ChevronQuanto2GPG(char* signature, char** result);
+
+// ChevronFieldCipher encrypts JSON fields to already loaded public keys.
+// The input is a JSON serialized FieldCipherInput and the result a JSON serialized CipherPacket
+extern int ChevronFieldCipher(char* input, char** result, char** err);
+
+// ChevronFieldDecipher decrypts JSON fields using a already loaded and unlocked private key.
+// The input is a JSON serialized FieldDecipherInput and the result a JSON serialized DecipherPacket
+extern int ChevronFieldDecipher(char* input, char** result, char** err);
+
+// ChevronSetBlindIndexKey sets the secret key used to compute fieldcipher blind indexes
+extern int ChevronSetBlindIndexKey(char* key, int keyLen, char** err);
+
+// ChevronComputeBlindIndex returns the blind index of a JSON serialized value for the field selected by the JSONPath pattern
+extern int ChevronComputeBlindIndex(char* field, char* value, char** result, char** err);
 
 /* Return type for LoadKey */
 struct LoadKey_return {
@@ -76,48 +161,76 @@ struct LoadKey_return {
 };
 
 // LoadKey loads a private or public key into the memory keyring
+//
+// Deprecated: use ChevronLoadKey
 extern struct LoadKey_return LoadKey(char* keyData, char* result, int resultLen);
 
 // UnlockKey unlocks a private key to be used
+//
+// Deprecated: use ChevronUnlockKey
 extern int UnlockKey(char* fingerprint, char* password, char* result, int resultLen);
 
 // VerifySignature verifies a signature using a already loaded public key
+//
+// Deprecated: use ChevronVerifySignature, which also returns why a signature is invalid
 extern int VerifySignature(char* data, int dataLen, char* signature, char* result, int resultLen);
 
 // QuantoVerifySignature verifies a signature in Quanto Signature Format using a already loaded public key
+//
+// Deprecated: use ChevronQuantoVerifySignature, which also returns why a signature is invalid
 extern int QuantoVerifySignature(char* data, int dataLen, char* signature, char* result, int resultLen);
 
 // VerifyBase64DataSignature verifies a signature using a already loaded public key. The b64data is a raw binary data encoded in base64 string
+//
+// Deprecated: use ChevronVerifySignature with the decoded data
 extern int VerifyBase64DataSignature(char* b64data, char* signature, char* result, int resultLen);
 
 // QuantoVerifyBase64DataSignature verifies a signature in Quanto Signature Format using a already loaded public key.
 // The b64data is a raw binary data encoded in base64 string
+//
+// Deprecated: use ChevronQuantoVerifySignature with the decoded data
 extern int QuantoVerifyBase64DataSignature(char* b64data, char* signature, char* result, int resultLen);
 
 // SignData signs data using a already loaded and unlocked private key
+//
+// Deprecated: use ChevronSignData. The signature is truncated when it does not fit in resultLen bytes
 extern int SignData(char* data, int dataLen, char* fingerprint, char* result, int resultLen);
 
 // QuantoSignData signs data using a already loaded and unlocked private key and returns in Quanto Signature Format
+//
+// Deprecated: use ChevronQuantoSignData. The Quanto signature is cut at resultLen bytes
 extern int QuantoSignData(char* data, int dataLen, char* fingerprint, char* result, int resultLen);
 
 // SignBase64Data signs data using a already loaded and unlocked private key.
 // The b64data is a raw binary data encoded in base64 string
+//
+// Deprecated: use ChevronSignData with the decoded data. Like SignData, the signature can be truncated
 extern int SignBase64Data(char* b64data, char* fingerprint, char* result, int resultLen);
 
 // SignBase64Data signs data using a already loaded and unlocked private key. Returns in Quanto Signature Format
 // The b64data is a raw binary data encoded in base64 string
+//
+// Deprecated: use ChevronQuantoSignData with the decoded data. Like QuantoSignData, the signature can be truncated
 extern int QuantoSignBase64Data(char* b64data, char* fingerprint, char* result, int resultLen);
 
 // GetKeyFingerprints returns all fingerprints in CSV format from a ASCII Armored PGP Keychain
+//
+// Deprecated: use ChevronGetKeyFingerprints. The fingerprints of keychains with many keys are cut at resultLen bytes
 extern int GetKeyFingerprints(char* keyData, char* result, int resultLen);
 
 // ChangeKeyPassword re-encrypts the input key using newPassword
+//
+// Deprecated: use ChevronChangeKeyPassword. The re-encrypted private key is truncated when result is smaller than it
 extern int ChangeKeyPassword(char* keyData, char* currentPassword, char* newPassword, char* result, int resultLen);
 
 // GetPublicKey returns the cached public key from the specified fingerprint
+//
+// Deprecated: use ChevronGetPublicKey. Public keys larger than resultLen are returned truncated
 extern int GetPublicKey(char* fingerprint, char* result, int resultLen);
 
 // GenerateKey generates a new key using specified bits and identifier and encrypts it using the specified password
+//
+// Deprecated: use ChevronGenerateKey. The generated private key is truncated when resultLen is too small for the key size
 extern int GenerateKey(char* password, char* identifier, int bits, char* result, int resultLen);
 
 #ifdef __cplusplus
@@ -1,148 +1,43 @@
 #include <napi.h>
+#include <functional>
+#include <vector>
 #include "chevronwrap.h"
 
-
-class GenerateKeyAsyncWorker : public Napi::AsyncWorker {
- public:
-  GenerateKeyAsyncWorker(Napi::Function& callback, const std::string& password, const std::string& identifier, int bits) :
-    Napi::AsyncWorker(callback),
-    password(password),
-    identifier(identifier),
-    bits(bits),
-    resultVal(ERROR) {}
-  ~GenerateKeyAsyncWorker() {}
-
-  // Executed inside the worker-thread.
-  // It is not safe to access JS engine data structure
-  // here, so everything we need for input and output
-  // should go on `this`.
-  void Execute() {
-    char resultArray[_CHEVRON_BUFFER_SIZE];
-    memset(resultArray, 0x00, _CHEVRON_BUFFER_SIZE);
-
-    resultVal = chevronlib_generatekey((char *)password.c_str(), (char *)identifier.c_str(), bits, resultArray, _CHEVRON_BUFFER_SIZE);
-    result = std::string(resultArray);
-  }
-
-  // Executed when the async work is complete
-  // this function will be run inside the main event loop
-  // so it is safe to use JS engine data again
-  void OnOK() {
-    Napi::HandleScope scope(Env());
-    if (resultVal == ERROR) { // Error
-        Callback().Call({Napi::String::New(Env(), result), Env().Undefined()});
-    } else {
-        Callback().Call({Env().Undefined(), Napi::String::New(Env(), result)});
-    }
-  }
-
- private:
-    std::string password;
-    std::string identifier;
-    int bits;
-    int resultVal;
-    std::string result;
-};
-
-class LoadKeyAsyncWorker : public Napi::AsyncWorker {
- public:
-  LoadKeyAsyncWorker(Napi::Function& callback, const std::string& keyData) :
-    Napi::AsyncWorker(callback),
-    keyData(keyData),
-    resultVal(ERROR) {}
-  ~LoadKeyAsyncWorker() {}
-
-  // Executed inside the worker-thread.
-  // It is not safe to access JS engine data structure
-  // here, so everything we need for input and output
-  // should go on `this`.
-  void Execute() {
-    char resultArray[_CHEVRON_BUFFER_SIZE];
-    memset(resultArray, 0x00, _CHEVRON_BUFFER_SIZE);
-
-    LoadKey_return res = chevronlib_loadkey((char *)keyData.c_str(), resultArray, _CHEVRON_BUFFER_SIZE);
-    resultVal = res.err;
-    result = std::string(resultArray);
-  }
-
-  // Executed when the async work is complete
-  // this function will be run inside the main event loop
-  // so it is safe to use JS engine data again
-  void OnOK() {
-    Napi::HandleScope scope(Env());
-    if (resultVal == ERROR) { // Error
-        Callback().Call({Napi::String::New(Env(), result), Env().Undefined()});
-    } else {
-        Callback().Call({Env().Undefined(), Napi::String::New(Env(), result)});
-    }
-  }
-
- private:
-    std::string keyData;
-    int resultVal;
-    std::string result;
+// How the result of a ChevronLib call is returned to javascript
+enum ChevronResultType {
+    RESULT_STRING,
+    RESULT_BOOLEAN,
+    RESULT_NUMBER,
+    RESULT_DECRYPTED,
 };
 
-class UnlockKeyAsyncWorker : public Napi::AsyncWorker {
- public:
-  UnlockKeyAsyncWorker(Napi::Function& callback, const std::string& fingerprint, const std::string& password) :
-    Napi::AsyncWorker(callback),
-    fingerprint(fingerprint),
-    password(password),
-    resultVal(ERROR) {}
-  ~UnlockKeyAsyncWorker() {}
-
-  // Executed inside the worker-thread.
-  // It is not safe to access JS engine data structure
-  // here, so everything we need for input and output
-  // should go on `this`.
-  void Execute() {
-    char resultArray[_CHEVRON_BUFFER_SIZE];
-    memset(resultArray, 0x00, _CHEVRON_BUFFER_SIZE);
-
-    resultVal = chevronlib_unlockkey((char *)fingerprint.c_str(), (char *)password.c_str(), resultArray, _CHEVRON_BUFFER_SIZE);
-    result = std::string(resultArray);
-  }
-
-  // Executed when the async work is complete
-  // this function will be run inside the main event loop
-  // so it is safe to use JS engine data again
-  void OnOK() {
-    Napi::HandleScope scope(Env());
-    if (resultVal == ERROR) { // Error
-        Callback().Call({Napi::String::New(Env(), result), Env().Undefined()});
-    } else {
-        Callback().Call({Env().Undefined(), Napi::String::New(Env(), result)});
-    }
-  }
-
- private:
-    std::string fingerprint;
-    std::string password;
-    int resultVal;
-    std::string result;
+// Output of a ChevronLib call
+struct ChevronResult {
+    std::string data;
+    std::string filename;
+    std::string error;
+    int number;
 };
 
+// A ChevronLib call. Returns OK, TRUE, FALSE or a negative error code
+typedef std::function<int(ChevronResult &result)> ChevronCall;
 
-class VerifySignatureAsyncWorker : public Napi::AsyncWorker {
+class ChevronAsyncWorker : public Napi::AsyncWorker {
  public:
-  VerifySignatureAsyncWorker(Napi::Function& callback, const std::string& b64data, const std::string& signature) :
+  ChevronAsyncWorker(Napi::Function& callback, ChevronResultType resultType, ChevronCall call) :
     Napi::AsyncWorker(callback),
-    b64data(b64data),
-    signature(signature),
+    resultType(resultType),
+    call(call),
     resultVal(ERROR) {}
-  ~VerifySignatureAsyncWorker() {}
+  ~ChevronAsyncWorker() {}
 
   // Executed inside the worker-thread.
   // It is not safe to access JS engine data structure
   // here, so everything we need for input and output
   // should go on `this`.
   void Execute() {
-    char resultArray[_CHEVRON_BUFFER_SIZE];
-    memset(resultArray, 0x00, _CHEVRON_BUFFER_SIZE);
-
-    resultVal = chevronlib_verifybase64datasignature((char *)b64data.c_str(), (char *)signature.c_str(), resultArray, _CHEVRON_BUFFER_SIZE);
-    result = std::string(resultArray);
+    result.number = 0;
+    resultVal = call(result);
   }
 
   // Executed when the async work is complete
@@ -150,406 +45,262 @@ class VerifySignatureAsyncWorker : public Napi::AsyncWorker {
   // so it is safe to use JS engine data again
   void OnOK() {
     Napi::HandleScope scope(Env());
-    if (resultVal == ERROR) { // Error
-        Callback().Call({Napi::String::New(Env(), result), Env().Undefined()});
-    } else {
-        Callback().Call({Env().Undefined(), Napi::Boolean::New(Env(), resultVal == TRUE)});
-      }
-  }
-
- private:
-    std::string b64data;
-    std::string signature;
-    int resultVal;
-    std::string result;
-};
-
-class QuantoVerifySignatureAsyncWorker : public Napi::AsyncWorker {
- public:
-  QuantoVerifySignatureAsyncWorker(Napi::Function& callback, const std::string& b64data, const std::string& signature) :
-    Napi::AsyncWorker(callback),
-    b64data(b64data),
-    signature(signature),
-    resultVal(ERROR) {}
-  ~QuantoVerifySignatureAsyncWorker() {}
-
-  // Executed inside the worker-thread.
-  // It is not safe to access JS engine data structure
-  // here, so everything we need for input and output
-  // should go on `this`.
-  void Execute() {
-    char resultArray[_CHEVRON_BUFFER_SIZE];
-    memset(resultArray, 0x00, _CHEVRON_BUFFER_SIZE);
-
-    resultVal = chevronlib_quantoverifybase64datasignature((char *)b64data.c_str(), (char *)signature.c_str(), resultArray, _CHEVRON_BUFFER_SIZE);
-    result = std::string(resultArray);
-  }
+    if (resultVal < 0) { // Error
+        Napi::Error error = Napi::Error::New(Env(), result.error);
+        error.Set("code", Napi::Number::New(Env(), resultVal));
+        Callback().Call({error.Value(), Env().Undefined()});
+        return;
+    }
 
-  // Executed when the async work is complete
-  // this function will be run inside the main event loop
-  // so it is safe to use JS engine data again
-  void OnOK() {
-    Napi::HandleScope scope(Env());
-    if (resultVal == ERROR) { // Error
-        Callback().Call({Napi::String::New(Env(), result), Env().Undefined()});
-    } else {
-        Callback().Call({Env().Undefined(), Napi::Boolean::New(Env(), resultVal == TRUE)});
-      }
+    switch (resultType) {
+        case RESULT_BOOLEAN:
+            Callback().Call({Env().Undefined(), Napi::Boolean::New(Env(), resultVal == TRUE)});
+            break;
+        case RESULT_NUMBER:
+            Callback().Call({Env().Undefined(), Napi::Number::New(Env(), result.number)});
+            break;
+        case RESULT_DECRYPTED: {
+            Napi::Object obj = Napi::Object::New(Env());
+            obj.Set("data", Napi::Buffer<char>::Copy(Env(), result.data.data(), result.data.size()));
+            obj.Set("filename", Napi::String::New(Env(), result.filename));
+            Callback().Call({Env().Undefined(), obj});
+            break;
+        }
+        default:
+            Callback().Call({Env().Undefined(), Napi::String::New(Env(), result.data)});
+    }
   }
 
  private:
-    std::string b64data;
-    std::string signature;
+    ChevronResultType resultType;
+    ChevronCall call;
     int resultVal;
-    std::string result;
-};
-
-class SignDataAsyncWorker : public Napi::AsyncWorker {
- public:
-  SignDataAsyncWorker(Napi::Function& callback, const std::string& b64data, const std::string& fingerprint) :
-    Napi::AsyncWorker(callback),
-    b64data(b64data),
-    fingerprint(fingerprint),
-    resultVal(ERROR) {}
-  ~SignDataAsyncWorker() {}
-
-  // Executed inside the worker-thread.
-  // It is not safe to access JS engine data structure
-  // here, so everything we need for input and output
-  // should go on `this`.
-  void Execute() {
-    char resultArray[_CHEVRON_BUFFER_SIZE];
-    memset(resultArray, 0x00, _CHEVRON_BUFFER_SIZE);
-
-    resultVal = chevronlib_signbase64data((char *)b64data.c_str(), (char *)fingerprint.c_str(), resultArray, _CHEVRON_BUFFER_SIZE);
-    result = std::string(resultArray);
-  }
-
-  // Executed when the async work is complete
-  // this function will be run inside the main event loop
-  // so it is safe to use JS engine data again
-  void OnOK() {
-    Napi::HandleScope scope(Env());
-    if (resultVal == ERROR) { // Error
-        Callback().Call({Napi::String::New(Env(), result), Env().Undefined()});
-    } else {
-        Callback().Call({Env().Undefined(), Napi::String::New(Env(), result)});
-      }
-  }
-
- private:
-    std::string b64data;
-    std::string fingerprint;
-  int resultVal;
-    std::string result;
+    ChevronResult result;
 };
 
-class QuantoSignDataAsyncWorker : public Napi::AsyncWorker {
- public:
-  QuantoSignDataAsyncWorker(Napi::Function& callback, const std::string& b64data, const std::string& fingerprint) :
-    Napi::AsyncWorker(callback),
-    b64data(b64data),
-    fingerprint(fingerprint),
-    resultVal(ERROR) {}
-  ~QuantoSignDataAsyncWorker() {}
-
-  // Executed inside the worker-thread.
-  // It is not safe to access JS engine data structure
-  // here, so everything we need for input and output
-  // should go on `this`.
-  void Execute() {
-    char resultArray[_CHEVRON_BUFFER_SIZE];
-    memset(resultArray, 0x00, _CHEVRON_BUFFER_SIZE);
+// Checks the number of arguments and the types of the arguments before the callback. Throws a TypeError if they don't match
+// Types are "string", "number" and "buffer"
+bool checkArguments(const Napi::CallbackInfo& info, const std::vector<std::pair<std::string, std::string>> &args) {
+    Napi::Env env = info.Env();
 
-    resultVal = chevronlib_quantosignbase64data((char *)b64data.c_str(), (char *)fingerprint.c_str(), resultArray, _CHEVRON_BUFFER_SIZE);
-    result = std::string(resultArray);
-  }
+    if (info.Length() < args.size() + 1) {
+        Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
+        return false;
+    }
 
-  // Executed when the async work is complete
-  // this function will be run inside the main event loop
-  // so it is safe to use JS engine data again
-  void OnOK() {
-    Napi::HandleScope scope(Env());
-    if (resultVal == ERROR) { // Error
-        Callback().Call({Napi::String::New(Env(), result), Env().Undefined()});
-    } else {
-        Callback().Call({Env().Undefined(), Napi::String::New(Env(), result)});
-      }
-  }
+    for (size_t i = 0; i < args.size(); i++) {
+        const std::string &name = args[i].first;
+        const std::string &type = args[i].second;
 
- private:
-    std::string b64data;
-    std::string fingerprint;
-  int resultVal;
-    std::string result;
-};
+        bool valid = (type == "string" && info[i].IsString()) ||
+                     (type == "number" && info[i].IsNumber()) ||
+                     (type == "buffer" && info[i].IsBuffer());
 
+        if (!valid) {
+            Napi::TypeError::New(env, "Expected argument \"" + name + "\" to be " + type + ".").ThrowAsJavaScriptException();
+            return false;
+        }
+    }
 
-class ChangeKeyPasswordAsyncWorker : public Napi::AsyncWorker {
- public:
-  ChangeKeyPasswordAsyncWorker(Napi::Function& callback, const std::string& keyData, const std::string& currentPassword, const std::string& newPassword) :
-    Napi::AsyncWorker(callback),
-    keyData(keyData),
-    currentPassword(currentPassword),
-    newPassword(newPassword),
-    resultVal(ERROR) {}
-  ~ChangeKeyPasswordAsyncWorker() {}
+    if (!info[args.size()].IsFunction()) {
+        Napi::TypeError::New(env, "Expected last argument to be a callback function.").ThrowAsJavaScriptException();
+        return false;
+    }
 
-  // Executed inside the worker-thread.
-  // It is not safe to access JS engine data structure
-  // here, so everything we need for input and output
-  // should go on `this`.
-  void Execute() {
-    char resultArray[_CHEVRON_BUFFER_SIZE];
-    memset(resultArray, 0x00, _CHEVRON_BUFFER_SIZE);
+    return true;
+}
 
-    resultVal = chevronlib_changekeypassword((char *)keyData.c_str(), (char *)currentPassword.c_str(), (char *)newPassword.c_str(), resultArray, _CHEVRON_BUFFER_SIZE);
-    result = std::string(resultArray);
-  }
+std::string stringArg(const Napi::CallbackInfo& info, int i) {
+    return info[i].As<Napi::String>().Utf8Value();
+}
 
-  // Executed when the async work is complete
-  // this function will be run inside the main event loop
-  // so it is safe to use JS engine data again
-  void OnOK() {
-    Napi::HandleScope scope(Env());
-    if (resultVal == ERROR) { // Error
-        Callback().Call({Napi::String::New(Env(), result), Env().Undefined()});
-    } else {
-        Callback().Call({Env().Undefined(), Napi::String::New(Env(), result)});
-      }
-  }
+std::string bufferArg(const Napi::CallbackInfo& info, int i) {
+    Napi::Buffer<char> buff = info[i].As<Napi::Buffer<char>>();
+    return std::string(buff.Data(), buff.Length());
+}
 
- private:
-    std::string keyData;
-    std::string currentPassword;
-    std::string newPassword;
-    int resultVal;
-    std::string result;
-};
+Napi::Value queueCall(const Napi::CallbackInfo& info, int callbackIndex, ChevronResultType resultType, ChevronCall call) {
+    Napi::Function callback = info[callbackIndex].As<Napi::Function>();
 
+    ChevronAsyncWorker* asyncWorker = new ChevronAsyncWorker(callback, resultType, call);
+    asyncWorker->Queue();
+    return info.Env().Undefined();
+}
 
 ////////////////////
 
 Napi::Value GenerateKeyAsync(const Napi::CallbackInfo& info) {
-    Napi::Env env = info.Env();
-
-    if (info.Length() < 4) {
-        Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
-    }
-
-    if (!info[0].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"password\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+    if (!checkArguments(info, {{"password", "string"}, {"identifier", "string"}, {"bits", "number"}})) {
+        return info.Env().Undefined();
     }
 
-    if (!info[1].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"identifier\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
-    }
-    if (!info[2].IsNumber()) {
-        Napi::TypeError::New(env, "Expected first argument \"bits\" to be a number.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
-    }
-
-    std::string password = info[0].As<Napi::String>().Utf8Value();
-    std::string identifier = info[1].As<Napi::String>().Utf8Value();
+    std::string password = stringArg(info, 0);
+    std::string identifier = stringArg(info, 1);
     int bits = info[2].As<Napi::Number>().Uint32Value();
-    Napi::Function callback = info[3].As<Napi::Function>();
 
-    GenerateKeyAsyncWorker* asyncWorker = new GenerateKeyAsyncWorker(callback, password, identifier, bits);
-    asyncWorker->Queue();
-    return info.Env().Undefined();
+    return queueCall(info, 3, RESULT_STRING, [password, identifier, bits](ChevronResult &r) {
+        char *result = NULL, *err = NULL;
+        int v = chevronlib_generatekey((char *)password.c_str(), (char *)identifier.c_str(), bits, &result, &err);
+        r.data = takeChevronString(result);
+        r.error = takeChevronString(err);
+        return v;
+    });
 }
 
-
 Napi::Value LoadKeyAsync(const Napi::CallbackInfo& info) {
-    Napi::Env env = info.Env();
-
-    if (info.Length() < 2) {
-        Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
-    }
-
-    if (!info[0].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"keyData\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+    if (!checkArguments(info, {{"keyData", "string"}})) {
+        return info.Env().Undefined();
     }
 
-    std::string keyData = info[0].As<Napi::String>().Utf8Value();
-    Napi::Function callback = info[1].As<Napi::Function>();
+    std::string keyData = stringArg(info, 0);
 
-    LoadKeyAsyncWorker* asyncWorker = new LoadKeyAsyncWorker(callback, keyData);
-    asyncWorker->Queue();
-    return info.Env().Undefined();
+    return queueCall(info, 1, RESULT_NUMBER, [keyData](ChevronResult &r) {
+        char *err = NULL;
+        int v = chevronlib_loadkey((char *)keyData.c_str(), &r.number, &err);
+        r.error = takeChevronString(err);
+        return v;
+    });
 }
 
 Napi::Value UnlockKeyAsync(const Napi::CallbackInfo& info) {
-    Napi::Env env = info.Env();
-
-    if (info.Length() < 3) {
-        Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+    if (!checkArguments(info, {{"fingerprint", "string"}, {"password", "string"}})) {
+        return info.Env().Undefined();
     }
 
-    if (!info[0].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"fingerprint\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
-    }
+    std::string fingerprint = stringArg(info, 0);
+    std::string password = stringArg(info, 1);
 
-    if (!info[1].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"password\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+    return queueCall(info, 2, RESULT_STRING, [fingerprint, password](ChevronResult &r) {
+        char *err = NULL;
+        int v = chevronlib_unlockkey((char *)fingerprint.c_str(), (char *)password.c_str(), &err);
+        r.error = takeChevronString(err);
+        return v;
+    });
+}
+
+Napi::Value verifySignature(const Napi::CallbackInfo& info, ChevronVerifySignature_t *fn) {
+    if (!checkArguments(info, {{"data", "buffer"}, {"signature", "string"}})) {
+        return info.Env().Undefined();
     }
 
-    std::string fingerprint = info[0].As<Napi::String>().Utf8Value();
-    std::string password = info[1].As<Napi::String>().Utf8Value();
-    Napi::Function callback = info[2].As<Napi::Function>();
+    std::string data = bufferArg(info, 0);
+    std::string signature = stringArg(info, 1);
 
-    UnlockKeyAsyncWorker* asyncWorker = new UnlockKeyAsyncWorker(callback, fingerprint, password);
-    asyncWorker->Queue();
-    return info.Env().Undefined();
+    return queueCall(info, 2, RESULT_BOOLEAN, [fn, data, signature](ChevronResult &r) {
+        char *err = NULL;
+        int v = fn((char *)data.data(), data.size(), (char *)signature.c_str(), &err);
+        r.error = takeChevronString(err);
+        return v;
+    });
 }
 
 Napi::Value VerifySignatureAsync(const Napi::CallbackInfo& info) {
-     Napi::Env env = info.Env();
-
-     if (info.Length() < 3) {
-         Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
-         return Napi::String::New(env, "");
-     }
-
-     if (!info[0].IsString()) {
-         Napi::TypeError::New(env, "Expected first argument \"b64data\" to be string.").ThrowAsJavaScriptException();
-         return Napi::String::New(env, "");
-     }
-
-     if (!info[1].IsString()) {
-         Napi::TypeError::New(env, "Expected first argument \"signature\" to be string.").ThrowAsJavaScriptException();
-         return Napi::String::New(env, "");
-     }
-
-     std::string b64data = info[0].As<Napi::String>().Utf8Value();
-     std::string signature = info[1].As<Napi::String>().Utf8Value();
-     Napi::Function callback = info[2].As<Napi::Function>();
-
-     VerifySignatureAsyncWorker* asyncWorker = new VerifySignatureAsyncWorker(callback, b64data, signature);
-     asyncWorker->Queue();
-     return info.Env().Undefined();
- }
-
- Napi::Value QuantoVerifySignatureAsync(const Napi::CallbackInfo& info) {
-     Napi::Env env = info.Env();
-
-     if (info.Length() < 3) {
-         Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
-         return Napi::String::New(env, "");
-     }
-
-     if (!info[0].IsString()) {
-         Napi::TypeError::New(env, "Expected first argument \"b64data\" to be string.").ThrowAsJavaScriptException();
-         return Napi::String::New(env, "");
-     }
-
-     if (!info[1].IsString()) {
-         Napi::TypeError::New(env, "Expected first argument \"signature\" to be string.").ThrowAsJavaScriptException();
-         return Napi::String::New(env, "");
-     }
-
-     std::string b64data = info[0].As<Napi::String>().Utf8Value();
-     std::string signature = info[1].As<Napi::String>().Utf8Value();
-     Napi::Function callback = info[2].As<Napi::Function>();
-
-     QuantoVerifySignatureAsyncWorker* asyncWorker = new QuantoVerifySignatureAsyncWorker(callback, b64data, signature);
-     asyncWorker->Queue();
-     return info.Env().Undefined();
- }
-
-Napi::Value SignDataAsync(const Napi::CallbackInfo& info) {
-    Napi::Env env = info.Env();
-
-    if (info.Length() < 3) {
-        Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
-    }
+    return verifySignature(info, chevronlib_verifysignature);
+}
 
-    if (!info[0].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"b64data\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
-    }
+Napi::Value QuantoVerifySignatureAsync(const Napi::CallbackInfo& info) {
+    return verifySignature(info, chevronlib_quantoverifysignature);
+}
 
-    if (!info[1].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"fingerprint\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+Napi::Value signData(const Napi::CallbackInfo& info, ChevronSignData_t *fn) {
+    if (!checkArguments(info, {{"data", "buffer"}, {"fingerprint", "string"}})) {
+        return info.Env().Undefined();
     }
 
+    std::string data = bufferArg(info, 0);
+    std::string fingerprint = stringArg(info, 1);
 
-    std::string b64data = info[0].As<Napi::String>().Utf8Value();
-    std::string fingerprint = info[1].As<Napi::String>().Utf8Value();
-    Napi::Function callback = info[2].As<Napi::Function>();
+    return queueCall(info, 2, RESULT_STRING, [fn, data, fingerprint](ChevronResult &r) {
+        char *result = NULL, *err = NULL;
+        int v = fn((char *)data.data(), data.size(), (char *)fingerprint.c_str(), &result, &err);
+        r.data = takeChevronString(result);
+        r.error = takeChevronString(err);
+        return v;
+    });
+}
 
-    SignDataAsyncWorker* asyncWorker = new SignDataAsyncWorker(callback, b64data, fingerprint);
-    asyncWorker->Queue();
-    return info.Env().Undefined();
+Napi::Value SignDataAsync(const Napi::CallbackInfo& info) {
+    return signData(info, chevronlib_signdata);
 }
 
 Napi::Value QuantoSignDataAsync(const Napi::CallbackInfo& info) {
-    Napi::Env env = info.Env();
+    return signData(info, chevronlib_quantosigndata);
+}
 
-    if (info.Length() < 3) {
-        Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+Napi::Value ChangeKeyPasswordAsync(const Napi::CallbackInfo& info) {
+    if (!checkArguments(info, {{"keyData", "string"}, {"currentPassword", "string"}, {"newPassword", "string"}})) {
+        return info.Env().Undefined();
     }
 
-    if (!info[0].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"b64data\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
-    }
+    std::string keyData = stringArg(info, 0);
+    std::string currentPassword = stringArg(info, 1);
+    std::string newPassword = stringArg(info, 2);
+
+    return queueCall(info, 3, RESULT_STRING, [keyData, currentPassword, newPassword](ChevronResult &r) {
+        char *result = NULL, *err = NULL;
+        int v = chevronlib_changekeypassword((char *)keyData.c_str(), (char *)currentPassword.c_str(), (char *)newPassword.c_str(), &result, &err);
+        r.data = takeChevronString(result);
+        r.error = takeChevronString(err);
+        return v;
+    });
+}
 
-    if (!info[1].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"fingerprint\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+Napi::Value EncryptAsync(const Napi::CallbackInfo& info) {
+    if (!checkArguments(info, {{"data", "buffer"}, {"filename", "string"}, {"fingerprint", "string"}})) {
+        return info.Env().Undefined();
     }
 
-
-    std::string b64data = info[0].As<Napi::String>().Utf8Value();
-    std::string fingerprint = info[1].As<Napi::String>().Utf8Value();
-    Napi::Function callback = info[2].As<Napi::Function>();
-
-    QuantoSignDataAsyncWorker* asyncWorker = new QuantoSignDataAsyncWorker(callback, b64data, fingerprint);
-    asyncWorker->Queue();
-    return info.Env().Undefined();
+    std::string data = bufferArg(info, 0);
+    std::string filename = stringArg(info, 1);
+    std::string fingerprint = stringArg(info, 2);
+
+    return queueCall(info, 3, RESULT_STRING, [data, filename, fingerprint](ChevronResult &r) {
+        char *result = NULL, *err = NULL;
+        int v = chevronlib_encrypt((char *)data.data(), data.size(), (char *)filename.c_str(), (char *)fingerprint.c_str(), &result, &err);
+        r.data = takeChevronString(result);
+        r.error = takeChevronString(err);
+        return v;
+    });
 }
 
-Napi::Value ChangeKeyPasswordAsync(const Napi::CallbackInfo& info) {
-    Napi::Env env = info.Env();
-
-    if (info.Length() < 4) {
-        Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+Napi::Value DecryptAsync(const Napi::CallbackInfo& info) {
+    if (!checkArguments(info, {{"encryptedData", "string"}})) {
+        return info.Env().Undefined();
     }
 
-    if (!info[0].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"keyData\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
-    }
+    std::string encryptedData = stringArg(info, 0);
+
+    return queueCall(info, 1, RESULT_DECRYPTED, [encryptedData](ChevronResult &r) {
+        char *result = NULL, *filename = NULL, *err = NULL;
+        int resultLen = 0;
+        int v = chevronlib_decrypt((char *)encryptedData.c_str(), &result, &resultLen, &filename, &err);
+        r.data = takeChevronBuffer(result, resultLen);
+        r.filename = takeChevronString(filename);
+        r.error = takeChevronString(err);
+        return v;
+    });
+}
 
-    if (!info[1].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"currentPassword\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+Napi::Value stringCall(const Napi::CallbackInfo& info, const std::string &name, ChevronStringCall_t *fn) {
+    if (!checkArguments(info, {{name, "string"}})) {
+        return info.Env().Undefined();
     }
 
-    if (!info[2].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"newPassword\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
-    }
+    std::string input = stringArg(info, 0);
 
-    std::string keyData = info[0].As<Napi::String>().Utf8Value();
-    std::string currentPassword = info[1].As<Napi::String>().Utf8Value();
-    std::string newPassword = info[2].As<Napi::String>().Utf8Value();
-    Napi::Function callback = info[3].As<Napi::Function>();
+    return queueCall(info, 1, RESULT_STRING, [fn, input](ChevronResult &r) {
+        char *result = NULL, *err = NULL;
+        int v = fn((char *)input.c_str(), &result, &err);
+        r.data = takeChevronString(result);
+        r.error = takeChevronString(err);
+        return v;
+    });
+}
 
-    ChangeKeyPasswordAsyncWorker* asyncWorker = new ChangeKeyPasswordAsyncWorker(callback, keyData, currentPassword, newPassword);
-    asyncWorker->Queue();
-    return info.Env().Undefined();
+Napi::Value FieldCipherAsync(const Napi::CallbackInfo& info) {
+    return stringCall(info, "input", chevronlib_fieldcipher);
+}
+
+Napi::Value FieldDecipherAsync(const Napi::CallbackInfo& info) {
+    return stringCall(info, "input", chevronlib_fielddecipher);
 }
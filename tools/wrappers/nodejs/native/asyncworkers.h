@@ -14,4 +14,9 @@ Napi::Value ChangeKeyPasswordAsync(const Napi::CallbackInfo& info);
 Napi::Value QuantoVerifySignatureAsync(const Napi::CallbackInfo& info);
 Napi::Value QuantoSignDataAsync(const Napi::CallbackInfo& info);
 
+Napi::Value EncryptAsync(const Napi::CallbackInfo& info);
+Napi::Value DecryptAsync(const Napi::CallbackInfo& info);
+Napi::Value FieldCipherAsync(const Napi::CallbackInfo& info);
+Napi::Value FieldDecipherAsync(const Napi::CallbackInfo& info);
+
 #endif  // CHEVRON_ASYNC_H_
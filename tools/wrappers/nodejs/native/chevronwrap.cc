@@ -2,18 +2,27 @@
 #include <dlfcn.h>
 #include "chevronwrap.h"
 
-UnlockKey_t                             *chevronlib_unlockkey;
-LoadKey_t                               *chevronlib_loadkey;
-VerifySignature_t                       *chevronlib_verifysignature;
-VerifyBase64DataSignature_t             *chevronlib_verifybase64datasignature;
-SignData_t                              *chevronlib_signdata;
-SignBase64Data_t                        *chevronlib_signbase64data;
-GetKeyFingerprints_t                    *chevronlib_getkeyfingerprints;
-ChangeKeyPassword_t                     *chevronlib_changekeypassword;
-GetPublicKey_t                          *chevronlib_getpublickey;
-GenerateKey_t                           *chevronlib_generatekey;
-QuantoVerifyBase64DataSignature_t       *chevronlib_quantoverifybase64datasignature;
-QuantoSignBase64Data_t                  *chevronlib_quantosignbase64data;
+ChevronVersion_t                        *chevronlib_version;
+ChevronFree_t                           *chevronlib_free;
+ChevronLoadKey_t                        *chevronlib_loadkey;
+ChevronUnlockKey_t                      *chevronlib_unlockkey;
+ChevronVerifySignature_t                *chevronlib_verifysignature;
+ChevronVerifySignature_t                *chevronlib_quantoverifysignature;
+ChevronSignData_t                       *chevronlib_signdata;
+ChevronSignData_t                       *chevronlib_quantosigndata;
+ChevronEncrypt_t                        *chevronlib_encrypt;
+ChevronDecrypt_t                        *chevronlib_decrypt;
+ChevronGenerateKey_t                    *chevronlib_generatekey;
+ChevronStringCall_t                     *chevronlib_getkeyfingerprints;
+ChevronStringCall_t                     *chevronlib_getpublickey;
+ChevronStringCall_t                     *chevronlib_getfingerprintfromkey;
+ChevronChangeKeyPassword_t              *chevronlib_changekeypassword;
+ChevronGPG2Quanto_t                     *chevronlib_gpg2quanto;
+ChevronQuanto2GPG_t                     *chevronlib_quanto2gpg;
+ChevronStringCall_t                     *chevronlib_fieldcipher;
+ChevronStringCall_t                     *chevronlib_fielddecipher;
+ChevronSetBlindIndexKey_t               *chevronlib_setblindindexkey;
+ChevronComputeBlindIndex_t              *chevronlib_computeblindindex;
 
 
 void *tryLoad(const char *path, const char *name) {
@@ -60,26 +69,70 @@ void *loadChevronLibDL(const char *path) {
 }
 
 void loadChevronCalls(void *handler) {
-    chevronlib_loadkey                              = (LoadKey_t*)                              dlsym( handler, "LoadKey" );
-    chevronlib_unlockkey                            = (UnlockKey_t*)                            dlsym( handler, "UnlockKey" );
-    chevronlib_verifysignature                      = (VerifySignature_t*)                      dlsym( handler, "VerifySignature" );
-    chevronlib_verifybase64datasignature            = (VerifyBase64DataSignature_t*)            dlsym( handler, "VerifyBase64DataSignature" );
-    chevronlib_signdata                             = (SignData_t*)                             dlsym( handler, "SignData" );
-    chevronlib_signbase64data                       = (SignBase64Data_t*)                       dlsym( handler, "SignBase64Data" );
-    chevronlib_getkeyfingerprints                   = (GetKeyFingerprints_t*)                   dlsym( handler, "GetKeyFingerprints" );
-    chevronlib_changekeypassword                    = (ChangeKeyPassword_t*)                    dlsym( handler, "ChangeKeyPassword" );
-    chevronlib_getpublickey                         = (GetPublicKey_t*)                         dlsym( handler, "GetPublicKey" );
-    chevronlib_generatekey                          = (GenerateKey_t*)                          dlsym( handler, "GenerateKey" );
-    chevronlib_quantoverifybase64datasignature      = (QuantoVerifyBase64DataSignature_t*)      dlsym( handler, "QuantoVerifyBase64DataSignature" );
-    chevronlib_quantosignbase64data                 = (QuantoSignBase64Data_t*)                 dlsym( handler, "QuantoSignBase64Data" );
+    chevronlib_free                     = (ChevronFree_t*)                  dlsym( handler, "ChevronFree" );
+    chevronlib_loadkey                  = (ChevronLoadKey_t*)               dlsym( handler, "ChevronLoadKey" );
+    chevronlib_unlockkey                = (ChevronUnlockKey_t*)             dlsym( handler, "ChevronUnlockKey" );
+    chevronlib_verifysignature          = (ChevronVerifySignature_t*)       dlsym( handler, "ChevronVerifySignature" );
+    chevronlib_quantoverifysignature    = (ChevronVerifySignature_t*)       dlsym( handler, "ChevronQuantoVerifySignature" );
+    chevronlib_signdata                 = (ChevronSignData_t*)              dlsym( handler, "ChevronSignData" );
+    chevronlib_quantosigndata           = (ChevronSignData_t*)              dlsym( handler, "ChevronQuantoSignData" );
+    chevronlib_encrypt                  = (ChevronEncrypt_t*)               dlsym( handler, "ChevronEncrypt" );
+    chevronlib_decrypt                  = (ChevronDecrypt_t*)               dlsym( handler, "ChevronDecrypt" );
+    chevronlib_generatekey              = (ChevronGenerateKey_t*)           dlsym( handler, "ChevronGenerateKey" );
+    chevronlib_getkeyfingerprints       = (ChevronStringCall_t*)            dlsym( handler, "ChevronGetKeyFingerprints" );
+    chevronlib_getpublickey             = (ChevronStringCall_t*)            dlsym( handler, "ChevronGetPublicKey" );
+    chevronlib_getfingerprintfromkey    = (ChevronStringCall_t*)            dlsym( handler, "ChevronGetFingerprintFromKey" );
+    chevronlib_changekeypassword        = (ChevronChangeKeyPassword_t*)     dlsym( handler, "ChevronChangeKeyPassword" );
+    chevronlib_gpg2quanto               = (ChevronGPG2Quanto_t*)            dlsym( handler, "ChevronGPG2Quanto" );
+    chevronlib_quanto2gpg               = (ChevronQuanto2GPG_t*)            dlsym( handler, "ChevronQuanto2GPG" );
+    chevronlib_fieldcipher              = (ChevronStringCall_t*)            dlsym( handler, "ChevronFieldCipher" );
+    chevronlib_fielddecipher            = (ChevronStringCall_t*)            dlsym( handler, "ChevronFieldDecipher" );
+    chevronlib_setblindindexkey         = (ChevronSetBlindIndexKey_t*)      dlsym( handler, "ChevronSetBlindIndexKey" );
+    chevronlib_computeblindindex        = (ChevronComputeBlindIndex_t*)     dlsym( handler, "ChevronComputeBlindIndex" );
 }
 
-int loadChevron(const char *path) {
+int loadChevron(const char *path, std::string &error) {
     void *handler = loadChevronLibDL(path);
     if (handler == NULL) {
+        error = "Error loading ChevronLib!";
         return FALSE;
     }
+
+    chevronlib_version = (ChevronVersion_t*) dlsym( handler, "ChevronVersion" );
+    if (chevronlib_version == NULL) {
+        error = "ChevronLib does not export the Chevron C ABI";
+        return FALSE;
+    }
+
+    int version = chevronlib_version();
+    if (version != CHEVRON_ABI_VERSION) {
+        error = "ChevronLib has ABI version " + std::to_string(version) + ", expected " + std::to_string(CHEVRON_ABI_VERSION);
+        return FALSE;
+    }
+
     loadChevronCalls(handler);
 
     return TRUE;
 }
+
+std::string takeChevronString(char *ptr) {
+    if (ptr == NULL) {
+        return std::string();
+    }
+
+    std::string result(ptr);
+    chevronlib_free(ptr);
+
+    return result;
+}
+
+std::string takeChevronBuffer(char *ptr, int len) {
+    if (ptr == NULL) {
+        return std::string();
+    }
+
+    std::string result(ptr, len);
+    chevronlib_free(ptr);
+
+    return result;
+}
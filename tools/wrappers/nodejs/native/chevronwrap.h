@@ -1,11 +1,16 @@
 #ifndef __CHEVRON_WRAP__
 #define __CHEVRON_WRAP__
 
+#include <string>
+
 #define TRUE 1
 #define FALSE 0
 #define OK TRUE
 #define ERROR -1
-#define _CHEVRON_BUFFER_SIZE 16384
+#define ERROR_KEY_NOT_FOUND -2
+
+// ABI version of ChevronLib this wrapper was written for
+#define CHEVRON_ABI_VERSION 1
 
 // Check windows
 #if _WIN32 || _WIN64
@@ -25,37 +30,50 @@
 #   endif
 #endif
 
-struct LoadKey_return {
-    int err;
-    int loadedPrivateKeys;
-};
-
-typedef int                     UnlockKey_t(char* fingerprint, char* password, char* result, int resultLen);
-typedef struct LoadKey_return   LoadKey_t(char* keyData, char* result, int resultLen);
-typedef int                     VerifySignature_t(char* data, int dataLen, char* signature, char* result, int resultLen);
-typedef int                     VerifyBase64DataSignature_t(char* b64data, char* signature, char* result, int resultLen);
-typedef int                     SignData_t(char* data, int dataLen, char* fingerprint, char* result, int resultLen);
-typedef int                     SignBase64Data_t(char* b64data, char* fingerprint, char* result, int resultLen);
-typedef int                     GetKeyFingerprints_t(char* keyData, char* result, int resultLen);
-typedef int                     ChangeKeyPassword_t(char* keyData, char* currentPassword, char* newPassword, char* result, int resultLen);
-typedef int                     GetPublicKey_t(char* fingerprint, char* result, int resultLen);
-typedef int                     GenerateKey_t(char* password, char* identifier, int bits, char* result, int resultLen);
-typedef int                     QuantoVerifyBase64DataSignature_t(char* b64data, char* signature, char* result, int resultLen);
-typedef int                     QuantoSignBase64Data_t(char* b64data, char* fingerprint, char* result, int resultLen);
-
-extern UnlockKey_t                          *chevronlib_unlockkey;
-extern LoadKey_t                        	*chevronlib_loadkey;
-extern VerifySignature_t                	*chevronlib_verifysignature;
-extern VerifyBase64DataSignature_t      	*chevronlib_verifybase64datasignature;
-extern SignData_t                       	*chevronlib_signdata;
-extern SignBase64Data_t                 	*chevronlib_signbase64data;
-extern GetKeyFingerprints_t             	*chevronlib_getkeyfingerprints;
-extern ChangeKeyPassword_t              	*chevronlib_changekeypassword;
-extern GetPublicKey_t                   	*chevronlib_getpublickey;
-extern GenerateKey_t                    	*chevronlib_generatekey;
-extern QuantoVerifyBase64DataSignature_t    *chevronlib_quantoverifybase64datasignature;
-extern QuantoSignBase64Data_t               *chevronlib_quantosignbase64data;
-
-int loadChevron(const char *path);
+typedef int     ChevronVersion_t();
+typedef void    ChevronFree_t(char* ptr);
+typedef int     ChevronLoadKey_t(char* keyData, int* loadedPrivateKeys, char** err);
+typedef int     ChevronUnlockKey_t(char* fingerprint, char* password, char** err);
+typedef int     ChevronVerifySignature_t(char* data, int dataLen, char* signature, char** err);
+typedef int     ChevronSignData_t(char* data, int dataLen, char* fingerprint, char** result, char** err);
+typedef int     ChevronEncrypt_t(char* data, int dataLen, char* filename, char* fingerprint, char** result, char** err);
+typedef int     ChevronDecrypt_t(char* encryptedData, char** result, int* resultLen, char** filename, char** err);
+typedef int     ChevronGenerateKey_t(char* password, char* identifier, int bits, char** result, char** err);
+typedef int     ChevronStringCall_t(char* input, char** result, char** err);
+typedef int     ChevronChangeKeyPassword_t(char* keyData, char* currentPassword, char* newPassword, char** result, char** err);
+typedef int     ChevronGPG2Quanto_t(char* signature, char* fingerprint, char* hash, char** result);
+typedef int     ChevronQuanto2GPG_t(char* signature, char** result);
+typedef int     ChevronSetBlindIndexKey_t(char* key, int keyLen, char** err);
+typedef int     ChevronComputeBlindIndex_t(char* field, char* value, char** result, char** err);
+
+extern ChevronVersion_t                 *chevronlib_version;
+extern ChevronFree_t                    *chevronlib_free;
+extern ChevronLoadKey_t                 *chevronlib_loadkey;
+extern ChevronUnlockKey_t               *chevronlib_unlockkey;
+extern ChevronVerifySignature_t         *chevronlib_verifysignature;
+extern ChevronVerifySignature_t         *chevronlib_quantoverifysignature;
+extern ChevronSignData_t                *chevronlib_signdata;
+extern ChevronSignData_t                *chevronlib_quantosigndata;
+extern ChevronEncrypt_t                 *chevronlib_encrypt;
+extern ChevronDecrypt_t                 *chevronlib_decrypt;
+extern ChevronGenerateKey_t             *chevronlib_generatekey;
+extern ChevronStringCall_t              *chevronlib_getkeyfingerprints;
+extern ChevronStringCall_t              *chevronlib_getpublickey;
+extern ChevronStringCall_t              *chevronlib_getfingerprintfromkey;
+extern ChevronChangeKeyPassword_t       *chevronlib_changekeypassword;
+extern ChevronGPG2Quanto_t              *chevronlib_gpg2quanto;
+extern ChevronQuanto2GPG_t              *chevronlib_quanto2gpg;
+extern ChevronStringCall_t              *chevronlib_fieldcipher;
+extern ChevronStringCall_t              *chevronlib_fielddecipher;
+extern ChevronSetBlindIndexKey_t        *chevronlib_setblindindexkey;
+extern ChevronComputeBlindIndex_t       *chevronlib_computeblindindex;
+
+int loadChevron(const char *path, std::string &error);
+
+// Copies a string allocated by ChevronLib and releases it
+std::string takeChevronString(char *ptr);
+
+// Copies a buffer allocated by ChevronLib and releases it
+std::string takeChevronBuffer(char *ptr, int len);
 
 #endif
@@ -19,8 +19,10 @@ Napi::Value LoadNative(const Napi::CallbackInfo& info) {
 
     std::string path = info[0].As<Napi::String>().Utf8Value();
 
-   if (!loadChevron(path.c_str())) {
-        Napi::TypeError::New(env, "Error loading ChevronLib!") .ThrowAsJavaScriptException();
+    std::string error;
+
+    if (!loadChevron(path.c_str(), error)) {
+        Napi::Error::New(env, error).ThrowAsJavaScriptException();
         return Napi::Boolean::New(env, FALSE);
     }
 
@@ -40,6 +42,16 @@ Napi::Object init(Napi::Env env, Napi::Object exports) {
     exports.Set(Napi::String::New(env, "changeKeyPassword"),        Napi::Function::New(env, ChangeKeyPasswordAsync));
     exports.Set(Napi::String::New(env, "quantoSignData"),           Napi::Function::New(env, QuantoSignDataAsync));
     exports.Set(Napi::String::New(env, "quantoVerifySignature"),    Napi::Function::New(env, QuantoVerifySignatureAsync));
+    exports.Set(Napi::String::New(env, "encrypt"),                  Napi::Function::New(env, EncryptAsync));
+    exports.Set(Napi::String::New(env, "decrypt"),                  Napi::Function::New(env, DecryptAsync));
+    exports.Set(Napi::String::New(env, "fieldCipher"),              Napi::Function::New(env, FieldCipherAsync));
+    exports.Set(Napi::String::New(env, "fieldDecipher"),            Napi::Function::New(env, FieldDecipherAsync));
+    exports.Set(Napi::String::New(env, "getFingerprintFromKey"),    Napi::Function::New(env, GetFingerprintFromKeySync));
+    exports.Set(Napi::String::New(env, "gpg2quanto"),               Napi::Function::New(env, GPG2QuantoSync));
+    exports.Set(Napi::String::New(env, "quanto2gpg"),               Napi::Function::New(env, Quanto2GPGSync));
+    exports.Set(Napi::String::New(env, "setBlindIndexKey"),         Napi::Function::New(env, SetBlindIndexKeySync));
+    exports.Set(Napi::String::New(env, "computeBlindIndex"),        Napi::Function::New(env, ComputeBlindIndexSync));
+    exports.Set(Napi::String::New(env, "version"),                  Napi::Function::New(env, VersionSync));
 
     return exports;
 };
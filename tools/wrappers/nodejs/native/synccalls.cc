@@ -1,54 +1,136 @@
 #include <napi.h>
+#include <vector>
 #include "chevronwrap.h"
 
 
-
-Napi::Value GetKeyFingerprintsSync(const Napi::CallbackInfo& info) {
+// Checks that the first n arguments are strings. Throws a TypeError if they are not
+bool checkStringArguments(const Napi::CallbackInfo& info, const std::vector<std::string> &names) {
     Napi::Env env = info.Env();
 
-    if (info.Length() < 1) {
+    if (info.Length() < names.size()) {
         Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+        return false;
     }
 
-    if (!info[0].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"keyData\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+    for (size_t i = 0; i < names.size(); i++) {
+        if (!info[i].IsString()) {
+            Napi::TypeError::New(env, "Expected argument \"" + names[i] + "\" to be string.").ThrowAsJavaScriptException();
+            return false;
+        }
     }
 
-    std::string keyData = info[0].As<Napi::String>().Utf8Value();
-    char resultArray[_CHEVRON_BUFFER_SIZE];
-    memset(resultArray, 0x00, _CHEVRON_BUFFER_SIZE);
+    return true;
+}
 
-    if (chevronlib_getkeyfingerprints((char *)keyData.c_str(), resultArray, _CHEVRON_BUFFER_SIZE) == ERROR) {
-        Napi::TypeError::New(env, resultArray).ThrowAsJavaScriptException();
+// Throws the error of a ChevronLib call with its error code
+void throwChevronError(Napi::Env env, int resultVal, const std::string &message) {
+    Napi::Error error = Napi::Error::New(env, message);
+    error.Set("code", Napi::Number::New(env, resultVal));
+    error.ThrowAsJavaScriptException();
+}
+
+// Returns the result of a ChevronLib call or throws its error
+Napi::Value chevronResult(Napi::Env env, int resultVal, char *result, char *err) {
+    std::string data = takeChevronString(result);
+    std::string error = takeChevronString(err);
+
+    if (resultVal < 0) {
+        throwChevronError(env, resultVal, error);
         return Napi::String::New(env, "");
     }
 
-    return Napi::String::New(env, resultArray);
+    return Napi::String::New(env, data);
+}
+
+Napi::Value stringSyncCall(const Napi::CallbackInfo& info, const std::string &name, ChevronStringCall_t *fn) {
+    if (!checkStringArguments(info, {name})) {
+        return Napi::String::New(info.Env(), "");
+    }
+
+    std::string input = info[0].As<Napi::String>().Utf8Value();
+    char *result = NULL, *err = NULL;
+
+    int resultVal = fn((char *)input.c_str(), &result, &err);
+
+    return chevronResult(info.Env(), resultVal, result, err);
+}
+
+Napi::Value GetKeyFingerprintsSync(const Napi::CallbackInfo& info) {
+    return stringSyncCall(info, "keyData", chevronlib_getkeyfingerprints);
 }
 
 Napi::Value GetPublicKeySync(const Napi::CallbackInfo& info) {
+    return stringSyncCall(info, "fingerPrint", chevronlib_getpublickey);
+}
+
+Napi::Value GetFingerprintFromKeySync(const Napi::CallbackInfo& info) {
+    return stringSyncCall(info, "keyData", chevronlib_getfingerprintfromkey);
+}
+
+Napi::Value GPG2QuantoSync(const Napi::CallbackInfo& info) {
+    if (!checkStringArguments(info, {"signature", "fingerprint", "hash"})) {
+        return Napi::String::New(info.Env(), "");
+    }
+
+    std::string signature = info[0].As<Napi::String>().Utf8Value();
+    std::string fingerprint = info[1].As<Napi::String>().Utf8Value();
+    std::string hash = info[2].As<Napi::String>().Utf8Value();
+    char *result = NULL;
+
+    int resultVal = chevronlib_gpg2quanto((char *)signature.c_str(), (char *)fingerprint.c_str(), (char *)hash.c_str(), &result);
+
+    return chevronResult(info.Env(), resultVal, result, NULL);
+}
+
+Napi::Value Quanto2GPGSync(const Napi::CallbackInfo& info) {
+    if (!checkStringArguments(info, {"signature"})) {
+        return Napi::String::New(info.Env(), "");
+    }
+
+    std::string signature = info[0].As<Napi::String>().Utf8Value();
+    char *result = NULL;
+
+    int resultVal = chevronlib_quanto2gpg((char *)signature.c_str(), &result);
+
+    return chevronResult(info.Env(), resultVal, result, NULL);
+}
+
+Napi::Value SetBlindIndexKeySync(const Napi::CallbackInfo& info) {
     Napi::Env env = info.Env();
 
-    if (info.Length() < 1) {
-        Napi::TypeError::New(env, "Wrong number of arguments").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+    if (info.Length() < 1 || !info[0].IsBuffer()) {
+        Napi::TypeError::New(env, "Expected argument \"key\" to be buffer.").ThrowAsJavaScriptException();
+        return Napi::Boolean::New(env, FALSE);
     }
 
-    if (!info[0].IsString()) {
-        Napi::TypeError::New(env, "Expected first argument \"fingerPrint\" to be string.").ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+    Napi::Buffer<char> key = info[0].As<Napi::Buffer<char>>();
+    char *err = NULL;
+
+    int resultVal = chevronlib_setblindindexkey(key.Data(), key.Length(), &err);
+    std::string error = takeChevronString(err);
+
+    if (resultVal < 0) {
+        throwChevronError(env, resultVal, error);
+        return Napi::Boolean::New(env, FALSE);
     }
 
-    std::string fingerprint = info[0].As<Napi::String>().Utf8Value();
-    char resultArray[_CHEVRON_BUFFER_SIZE];
-    memset(resultArray, 0x00, _CHEVRON_BUFFER_SIZE);
+    return Napi::Boolean::New(env, TRUE);
+}
 
-    if (chevronlib_getpublickey((char *)fingerprint.c_str(), resultArray, _CHEVRON_BUFFER_SIZE) == ERROR) {
-        Napi::TypeError::New(env, resultArray).ThrowAsJavaScriptException();
-        return Napi::String::New(env, "");
+Napi::Value ComputeBlindIndexSync(const Napi::CallbackInfo& info) {
+    if (!checkStringArguments(info, {"field", "value"})) {
+        return Napi::String::New(info.Env(), "");
     }
 
-    return Napi::String::New(env, resultArray);
+    std::string field = info[0].As<Napi::String>().Utf8Value();
+    std::string value = info[1].As<Napi::String>().Utf8Value();
+    char *result = NULL, *err = NULL;
+
+    int resultVal = chevronlib_computeblindindex((char *)field.c_str(), (char *)value.c_str(), &result, &err);
+
+    return chevronResult(info.Env(), resultVal, result, err);
+}
+
+Napi::Value VersionSync(const Napi::CallbackInfo& info) {
+    return Napi::Number::New(info.Env(), chevronlib_version());
 }
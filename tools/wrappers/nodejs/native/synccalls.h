@@ -5,5 +5,11 @@
 
 Napi::Value GetKeyFingerprintsSync(const Napi::CallbackInfo& info);
 Napi::Value GetPublicKeySync(const Napi::CallbackInfo& info);
+Napi::Value GetFingerprintFromKeySync(const Napi::CallbackInfo& info);
+Napi::Value GPG2QuantoSync(const Napi::CallbackInfo& info);
+Napi::Value Quanto2GPGSync(const Napi::CallbackInfo& info);
+Napi::Value SetBlindIndexKeySync(const Napi::CallbackInfo& info);
+Napi::Value ComputeBlindIndexSync(const Napi::CallbackInfo& info);
+Napi::Value VersionSync(const Napi::CallbackInfo& info);
 
-#endif  // CHEVRON_ASYNC_H_
\ No newline at end of file
+#endif  // CHEVRON_SYNC_H_
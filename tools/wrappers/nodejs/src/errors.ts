@@ -0,0 +1,107 @@
+/**
+ * Base class of the errors returned by chevronlib
+ */
+class ChevronError extends Error {
+    constructor(message: string) {
+        super(message);
+        this.name = new.target.name;
+        Object.setPrototypeOf(this, new.target.prototype);
+    }
+}
+
+/**
+ * The shared library cannot be loaded or is incompatible with this wrapper
+ */
+class LibraryError extends ChevronError {}
+
+/**
+ * An argument has an invalid format
+ */
+class InvalidArgumentError extends ChevronError {}
+
+/**
+ * A key cannot be loaded or parsed
+ */
+class KeyLoadError extends ChevronError {}
+
+/**
+ * A private key cannot be unlocked
+ */
+class KeyUnlockError extends ChevronError {}
+
+/**
+ * A key is not loaded in the memory keyring
+ */
+class KeyNotFoundError extends ChevronError {}
+
+/**
+ * A key cannot be generated
+ */
+class KeyGenerationError extends ChevronError {}
+
+/**
+ * The password of a key cannot be changed
+ */
+class KeyPasswordError extends ChevronError {}
+
+/**
+ * Data cannot be signed
+ */
+class SignatureError extends ChevronError {}
+
+/**
+ * Data cannot be encrypted
+ */
+class EncryptionError extends ChevronError {}
+
+/**
+ * Data cannot be decrypted
+ */
+class DecryptionError extends ChevronError {}
+
+/**
+ * A fieldcipher operation failed
+ */
+class FieldCipherError extends ChevronError {}
+
+type ChevronErrorClass = new (message: string) => ChevronError;
+
+// Error code returned by chevronlib when a key is not in the memory keyring
+const ERROR_KEY_NOT_FOUND = -2;
+
+/**
+ * An error of the native library with the chevronlib error code
+ */
+interface NativeError {
+    message: string;
+    code?: number;
+}
+
+/**
+ * Returns a KeyNotFoundError for the ERROR_KEY_NOT_FOUND code, or a errorClass error otherwise
+ */
+const chevronError = (error: NativeError, errorClass: ChevronErrorClass) : ChevronError => {
+    if (error.code === ERROR_KEY_NOT_FOUND) {
+        return new KeyNotFoundError(error.message);
+    }
+
+    return new errorClass(error.message);
+};
+
+export {
+    ChevronError,
+    ChevronErrorClass,
+    LibraryError,
+    InvalidArgumentError,
+    KeyLoadError,
+    KeyUnlockError,
+    KeyNotFoundError,
+    KeyGenerationError,
+    KeyPasswordError,
+    SignatureError,
+    EncryptionError,
+    DecryptionError,
+    FieldCipherError,
+    NativeError,
+    chevronError,
+};
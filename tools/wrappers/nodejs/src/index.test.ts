@@ -87,7 +87,7 @@ function toBase64(data: string) : string {
 
 test('generate key 1024', () => {
     expect.assertions(1);
-    return chevron.generateKey('abcd', 'abcd', 1024).catch((e: any|void) => expect(typeof e).toBe('string'));
+    return chevron.generateKey('abcd', 'abcd', 1024).catch((e: any|void) => expect(e).toBeInstanceOf(chevron.KeyGenerationError));
 });
 
 test('generate key 2048', () => {
@@ -102,7 +102,7 @@ test('load key', () => {
 
 test('load key invalid', () => {
     expect.assertions(1);
-    return chevron.loadKey('HUEBR').catch((v: any|void) => expect(typeof v).toBe('string'));
+    return chevron.loadKey('HUEBR').catch((v: any|void) => expect(v).toBeInstanceOf(chevron.KeyLoadError));
 });
 
 test('verify signature', async () => {
@@ -112,9 +112,12 @@ test('verify signature', async () => {
 });
 
 test('verify signature invalid base64 payload', async () => {
-  expect.assertions(1);
+  expect.assertions(2);
   await chevron.loadKey(testKey); // Load test key to have public key
-  return chevron.verifySignature(payloadToSign, testSignature).catch((res: string) => expect(res).toBe('Expected a base64 encoded data'));
+  return chevron.verifySignature(payloadToSign, testSignature).catch((res: any) => {
+    expect(res).toBeInstanceOf(chevron.InvalidArgumentError);
+    expect(res.message).toBe('Expected a base64 encoded data');
+  });
 });
 
 test('verify invalid signature payload', async () => {
@@ -126,7 +129,7 @@ test('verify invalid signature payload', async () => {
 test('verify invalid signature', async () => {
     expect.assertions(1);
     await chevron.loadKey(testKey); // Load test key to have public key
-    return chevron.verifySignature(toBase64(payloadToSign), testSignature.replace("B", "u")).catch((e: string|void) => expect(typeof e).toBe('string'));
+    return chevron.verifySignature(toBase64(payloadToSign), testSignature.replace("B", "u")).catch((e: any|void) => expect(e).toBeInstanceOf(chevron.ChevronError));
 });
 
 test('test unlock key', async() => {
@@ -138,7 +141,7 @@ test('test unlock key', async() => {
 test('test unlock key invalid password', async() => {
     expect.assertions(1);
     await chevron.loadKey(testKey); // Load test key to have public key
-    return chevron.unlockKey(testKeyFingerprint, testKeyPassword + "balblablalbalb").catch((v: string|void) => expect(typeof v).toBe('string'));
+    return chevron.unlockKey(testKeyFingerprint, testKeyPassword + "balblablalbalb").catch((v: any|void) => expect(v).toBeInstanceOf(chevron.KeyUnlockError));
 });
 
 test('generate signature', async () => {
@@ -156,10 +159,13 @@ test('generate signature', async () => {
 
 
 test('generate signature invalid base64 payload', async () => {
-  expect.assertions(1);
+  expect.assertions(2);
   await chevron.loadKey(testKey); // Load test key to have public key
   await chevron.unlockKey(testKeyFingerprint, testKeyPassword); // Unlock key
-  chevron.signData(payloadToSign, testKeyFingerprint).catch((res: string) => expect(res).toBe('Expected a base64 encoded data'));
+  return chevron.signData(payloadToSign, testKeyFingerprint).catch((res: any) => {
+    expect(res).toBeInstanceOf(chevron.InvalidArgumentError);
+    expect(res.message).toBe('Expected a base64 encoded data');
+  });
 });
 
 
@@ -167,7 +173,7 @@ test('test generate signature locked key', async() => {
     expect.assertions(1);
     const key = await chevron.generateKey('abcd', 'abcd', 2048);
     const fp = await chevron.loadKey(key);
-    return chevron.signData(toBase64(payloadToSign), fp).catch((e: string|void) => expect(typeof e).toBe('string'));
+    return chevron.signData(toBase64(payloadToSign), fp).catch((e: any|void) => expect(e).toBeInstanceOf(chevron.SignatureError));
 });
 
 test('check base64', () => {
@@ -202,11 +208,14 @@ test('verify signature quanto', async() => {
 });
 
 test('verify signature quanto invalid base64', async() => {
-    expect.assertions(1);
+    expect.assertions(2);
     await chevron.loadKey(testKey); // Load test key to have public key
     await chevron.unlockKey(testKeyFingerprint, testKeyPassword); // Unlock key
 
-    return chevron.quantoVerifySignature(payloadToSign, testQuantoSignature).catch((res: string) => expect(res).toBe('Expected a base64 encoded data'));
+    return chevron.quantoVerifySignature(payloadToSign, testQuantoSignature).catch((res: any) => {
+        expect(res).toBeInstanceOf(chevron.InvalidArgumentError);
+        expect(res.message).toBe('Expected a base64 encoded data');
+    });
 });
 
 test('verify signature quanto invalid payload', async() => {
@@ -222,7 +231,7 @@ test('verify signature quanto invalid signature', async() => {
     await chevron.loadKey(testKey); // Load test key to have public key
     await chevron.unlockKey(testKeyFingerprint, testKeyPassword); // Unlock key
 
-    return chevron.quantoVerifySignature(toBase64(payloadToSign), "ABCD").catch((res: string|void) => expect(typeof res).toBe('string'));
+    return chevron.quantoVerifySignature(toBase64(payloadToSign), "ABCD").catch((res: any|void) => expect(res).toBeInstanceOf(chevron.ChevronError));
 });
 
 test('sign quanto', async() => {
@@ -241,17 +250,20 @@ test('sign quanto', async() => {
 });
 
 test('sign quanto', async() => {
-    expect.assertions(1);
+    expect.assertions(2);
     await chevron.loadKey(testKey); // Load test key to have public key
     await chevron.unlockKey(testKeyFingerprint, testKeyPassword); // Unlock key
 
-    chevron.quantoSignData(payloadToSign, testKeyFingerprint).catch((res: string) => expect(res).toBe('Expected a base64 encoded data'));
+    return chevron.quantoSignData(payloadToSign, testKeyFingerprint).catch((res: any) => {
+        expect(res).toBeInstanceOf(chevron.InvalidArgumentError);
+        expect(res.message).toBe('Expected a base64 encoded data');
+    });
 });
 
 test('sign quanto invalid key', async() => {
     expect.assertions(1);
 
-    return chevron.quantoSignData(toBase64(payloadToSign), "DEADBEEF1234").catch((res: string) => expect(typeof res).toBe('string'));
+    return chevron.quantoSignData(toBase64(payloadToSign), "DEADBEEF1234").catch((res: any) => expect(res).toBeInstanceOf(chevron.ChevronError));
 });
 
 
@@ -271,5 +283,98 @@ test('change key password invalid', async() => {
     const tmpPass = '0912345aseuahse';
     const key = await chevron.generateKey(tmpPass, tmpPass, 2048);
 
-    return chevron.changeKeyPassword(key, testKeyPassword, tmpPass).catch((res: string) => expect(typeof res).toBe("string"));
+    return chevron.changeKeyPassword(key, testKeyPassword, tmpPass).catch((res: any) => expect(res).toBeInstanceOf(chevron.KeyPasswordError));
+});
+
+test('abi version', () => {
+    expect(chevron.abiVersion()).toBe(chevron.ABI_VERSION);
+});
+
+test('get fingerprint from key', () => {
+    expect(chevron.getFingerprintFromKey(testKey)).toBe(testKeyFingerprint);
+    expect(() => chevron.getFingerprintFromKey('HUEBR')).toThrow(chevron.KeyLoadError);
+});
+
+test('get public key not exists typed error', () => {
+    expect(() => chevron.getPublicKey('ABCDDEADBEEF')).toThrow(chevron.KeyNotFoundError);
+});
+
+test('encrypt and decrypt', async() => {
+    expect.assertions(3);
+    await chevron.loadKey(testKey);
+    await chevron.unlockKey(testKeyFingerprint, testKeyPassword);
+
+    const data = Buffer.alloc(256 * 1024);
+    for (let i = 0; i < data.length; i++) {
+        data[i] = i % 256;
+    }
+
+    const encrypted = await chevron.encrypt(data.toString('base64'), testKeyFingerprint, 'test.bin');
+    expect(encrypted.indexOf('PGP MESSAGE')).toBeGreaterThan(-1);
+
+    const decrypted = await chevron.decrypt(encrypted);
+    expect(decrypted.data).toBe(data.toString('base64'));
+    expect(decrypted.filename).toBe('test.bin');
+});
+
+test('encrypt key not found', async() => {
+    expect.assertions(1);
+    return chevron.encrypt(toBase64(payloadToSign), '0000000000000000').catch((e: any) => expect(e).toBeInstanceOf(chevron.KeyNotFoundError));
+});
+
+test('decrypt invalid', async() => {
+    expect.assertions(1);
+    return chevron.decrypt('HUEBR').catch((e: any) => expect(e).toBeInstanceOf(chevron.DecryptionError));
+});
+
+test('sign large data', async() => {
+    expect.assertions(1);
+    await chevron.loadKey(testKey);
+    await chevron.unlockKey(testKeyFingerprint, testKeyPassword);
+
+    const data = toBase64('A'.repeat(1024 * 1024));
+    const signature = await chevron.signData(data, testKeyFingerprint);
+    return chevron.verifySignature(data, signature).then((res: boolean) => expect(res).toBe(true));
+});
+
+test('signature converters', async() => {
+    expect.assertions(3);
+    await chevron.loadKey(testKey);
+
+    const gpgSignature = chevron.quanto2gpg(testQuantoSignature);
+    expect(gpgSignature.indexOf('PGP SIGNATURE')).toBeGreaterThan(-1);
+    expect(await chevron.verifySignature(toBase64(payloadToSign), gpgSignature)).toBe(true);
+    expect(chevron.gpg2quanto(gpgSignature, testKeyFingerprint)).toBe(testQuantoSignature);
+});
+
+test('field cipher', async() => {
+    expect.assertions(4);
+    await chevron.loadKey(testKey);
+    await chevron.unlockKey(testKeyFingerprint, testKeyPassword);
+
+    const data = { name: 'huebr', taxId: '12345678900', age: 30 };
+    const packet = await chevron.fieldCipher(data, [testKeyFingerprint], { Exclude: ['$.name'], SignWith: testKeyFingerprint });
+    expect(packet.EncryptedJSON.name).toBe('huebr');
+    expect(packet.EncryptedJSON.taxId).not.toBe('12345678900');
+
+    const decrypted = await chevron.fieldDecipher(packet, testKeyFingerprint);
+    expect(decrypted.DecryptedData).toEqual(data);
+    expect(decrypted.SignatureValid).toBe(true);
+});
+
+test('field cipher invalid key', async() => {
+    expect.assertions(1);
+    return chevron.fieldCipher({ name: 'huebr' }, ['0000000000000000']).catch((e: any) => expect(e).toBeInstanceOf(chevron.FieldCipherError));
+});
+
+test('blind index', async() => {
+    expect.assertions(3);
+    await chevron.loadKey(testKey);
+    chevron.setBlindIndexKey(Buffer.alloc(32, 1));
+
+    const packet = await chevron.fieldCipher({ taxId: '12345678900' }, [testKeyFingerprint], { BlindIndex: ['$.taxId'] });
+    const index = chevron.computeBlindIndex('$.taxId', '12345678900');
+    expect(packet.EncryptedJSON.taxId_bidx).toBe(index);
+    expect(chevron.computeBlindIndex('$.taxId', '00000000000')).not.toBe(index);
+    expect(() => chevron.setBlindIndexKey(Buffer.alloc(4))).toThrow(chevron.FieldCipherError);
 });
@@ -1,7 +1,127 @@
+import {
+    ChevronError,
+    ChevronErrorClass,
+    LibraryError,
+    InvalidArgumentError,
+    KeyLoadError,
+    KeyUnlockError,
+    KeyNotFoundError,
+    KeyGenerationError,
+    KeyPasswordError,
+    SignatureError,
+    EncryptionError,
+    DecryptionError,
+    FieldCipherError,
+    NativeError,
+    chevronError,
+} from './errors';
+
 const lib = require('bindings')('chevron');
 
 // Ensure that the library has been loaded
-lib.__loadnative(__dirname );
+try {
+    lib.__loadnative(__dirname);
+} catch (e) {
+    throw new LibraryError(e.message);
+}
+
+/**
+ * ABI version of ChevronLib this wrapper was written for
+ */
+const ABI_VERSION = 1;
+
+/**
+ * A fieldcipher recipient group. Only Keys can read the fields selected by Fields
+ */
+interface FieldCipherGroup {
+    Name: string;
+    Keys: string[];
+    Fields: string[];
+}
+
+/**
+ * Options of fieldCipher
+ */
+interface FieldCipherOptions {
+    /** Fields to keep in plain text. Cannot be used with Include, Exclude, Groups or BlindIndex */
+    SkipFields?: string[];
+    /** JSONPath patterns of the fields to encrypt. Every field is encrypted if empty */
+    Include?: string[];
+    /** JSONPath patterns of the fields to keep in plain text */
+    Exclude?: string[];
+    /** Recipient groups that are the only ones able to read their fields */
+    Groups?: FieldCipherGroup[];
+    /** JSONPath patterns of the fields that get a blind index. Requires setBlindIndexKey */
+    BlindIndex?: string[];
+    /** Fingerprint of a pre-unlocked private key to sign the packet */
+    SignWith?: string;
+    /** The fieldcipher format version. Empty for the current one */
+    Version?: number;
+}
+
+/**
+ * A packet generated by fieldCipher
+ */
+interface CipherPacket {
+    Version: number;
+    EncryptedKey: string;
+    Groups?: { [name: string]: { EncryptedKey: string; Fields: string[] } };
+    EncryptedJSON: { [key: string]: any };
+    Signature?: string;
+}
+
+/**
+ * The result of fieldDecipher
+ */
+interface DecipherPacket {
+    DecryptedData: { [key: string]: any };
+    JSONChanged: boolean;
+    UnmatchedFields: { Expected: string; Got: string }[] | null;
+    Fields?: { Path: string; Status: string; Error?: string }[];
+    Signed?: boolean;
+    SignatureValid?: boolean;
+    SignerFingerprint?: string;
+    SignatureError?: string;
+}
+
+/**
+ * The result of decrypt
+ */
+interface DecryptedData {
+    /** Base64 Encoded decrypted data */
+    data: string;
+    /** Filename stored in the message */
+    filename: string;
+}
+
+/**
+ * Calls an async function of the native library, converting its errors to errorClass
+ */
+const nativeCall = function<T>(errorClass: ChevronErrorClass, fn: (...args: any[]) => void, ...args: any[]) : Promise<T> {
+    return new Promise((resolve, reject) => {
+        fn(...args, (error: NativeError|void, result: T) => {
+            if (error) {
+                return reject(chevronError(error, errorClass));
+            }
+
+            return resolve(result);
+        });
+    });
+};
+
+/**
+ * Calls a sync function of the native library, converting its errors to errorClass
+ */
+const nativeSyncCall = function<T>(errorClass: ChevronErrorClass, fn: (...args: any[]) => T, ...args: any[]) : T {
+    try {
+        return fn(...args);
+    } catch (e) {
+        if (e instanceof TypeError) {
+            throw new InvalidArgumentError(e.message);
+        }
+        throw chevronError(e, errorClass);
+    }
+};
 
 /**
  * Checks if the data string is a base64 encoded payload
@@ -14,12 +134,36 @@ const isBase64 = (data: string) : boolean => {
   return base64regex.test(data);
 };
 
+/**
+ * Decodes a base64 data argument
+ */
+const base64Arg = (data: string) : Buffer => {
+    if (!isBase64(data)) {
+        throw new InvalidArgumentError('Expected a base64 encoded data');
+    }
+    return Buffer.from(data, 'base64');
+};
+
+/**
+ * Returns the C ABI version of the loaded shared library
+ *
+ * @returns {number}
+ */
+const abiVersion = () : number => lib.version();
+
 /**
  * Returns all fingerprints contained in the specified key
  * @param {string} asciiArmoredKey - The private / public key you want the fingerprints in ASCII Armored Format
  * @returns {string[]} the fingerprints in the specified keys
  */
-const getKeyFingerprints = (asciiArmoredKey: string) => lib.getKeyFingerprints(asciiArmoredKey).split(",");
+const getKeyFingerprints = (asciiArmoredKey: string) : string[] => nativeSyncCall<string>(KeyLoadError, lib.getKeyFingerprints, asciiArmoredKey).split(",");
+
+/**
+ * Returns the main fingerprint of the specified key
+ * @param {string} asciiArmoredKey - The private / public key in ASCII Armored Format
+ * @returns {string} the main fingerprint of the key
+ */
+const getFingerprintFromKey = (asciiArmoredKey: string) : string => nativeSyncCall<string>(KeyLoadError, lib.getFingerprintFromKey, asciiArmoredKey);
 
 /**
  * Loads the specified key into memory store for later use
@@ -28,17 +172,10 @@ const getKeyFingerprints = (asciiArmoredKey: string) => lib.getKeyFingerprints(a
  * @param {string} asciiArmoredKey - The private / public key you want the fingerprints in ASCII Armored Format
  * @returns {Promise<string>} the first fingerprint of the loaded key
  */
-const loadKey = async function(asciiArmoredKey: string) {
-    return new Promise((resolve, reject) => {
-        lib.loadKey(asciiArmoredKey, (error: string|void) => {
-            if (error) {
-                reject(error);
-            } else {
-                const fps = getKeyFingerprints(asciiArmoredKey);
-                resolve(fps[0]);
-            }
-        });
-    });
+const loadKey = async function(asciiArmoredKey: string) : Promise<string> {
+    await nativeCall<number>(KeyLoadError, lib.loadKey, asciiArmoredKey);
+    const fps = getKeyFingerprints(asciiArmoredKey);
+    return fps[0];
 };
 
 /**
@@ -52,18 +189,14 @@ const loadKey = async function(asciiArmoredKey: string) {
  * @returns {Promise<string>} - The ASCII Armored PGP Signature
  */
 const signData = async function(data: string, fingerprint: string) : Promise<string> {
-    return new Promise((resolve, reject) => {
-        if (!isBase64(data)) {
-            return reject('Expected a base64 encoded data');
-        }
-        lib.signData(data, fingerprint, (error: string|void, result: any|void) => {
-            if (error) {
-                return reject(error);
-            }
+    return nativeCall<string>(SignatureError, lib.signData, base64Arg(data), fingerprint);
+};
 
-            return resolve(result);
-        });
-    });
+/**
+ * Verifies a signature calling the native function fn
+ */
+const verify = async function(fn: (...args: any[]) => void, data: string, signature: string) : Promise<boolean> {
+    return nativeCall<boolean>(SignatureError, fn, base64Arg(data), signature);
 };
 
 /**
@@ -76,22 +209,8 @@ const signData = async function(data: string, fingerprint: string) : Promise<str
  * @param {string} signature - A ASCII Armored Format or Base64 Encoded Binary PGP Signature
  * @returns {Promise<boolean>}
  */
-const verifySignature = async function(data: string, signature: string) : Promise<boolean|void> {
-    return new Promise((resolve, reject) => {
-        if (!isBase64(data)) {
-            return reject('Expected a base64 encoded data');
-        }
-        lib.verifySignature(data, signature, (error: string|void, result: boolean|void) => {
-            if (error) {
-                if (error.indexOf('invalid signature') > -1) {
-                    return resolve(false);
-                }
-                return reject(error);
-            }
-
-            return resolve(result);
-        });
-    });
+const verifySignature = async function(data: string, signature: string) : Promise<boolean> {
+    return verify(lib.verifySignature, data, signature);
 };
 
 /**
@@ -103,16 +222,8 @@ const verifySignature = async function(data: string, signature: string) : Promis
  * @param {string} password - Password of the private key
  * @returns {Promise}
  */
-const unlockKey = async function(fingerprint: string, password: string) {
-    return new Promise((resolve, reject) => {
-        lib.unlockKey(fingerprint, password, (error: string|void, result: any|void) => {
-            if (error) {
-                reject(error)
-            } else {
-                resolve(result);
-            }
-        });
-    });
+const unlockKey = async function(fingerprint: string, password: string) : Promise<string> {
+    return nativeCall<string>(KeyUnlockError, lib.unlockKey, fingerprint, password);
 };
 
 /**
@@ -129,16 +240,8 @@ const unlockKey = async function(fingerprint: string, password: string) {
  * @param {number} bits - Number of bits of the RSA Key (recommended 3072)
  * @returns {Promise<string>} - The generated private key
  */
-const generateKey = async function(password: string, identifier: string, bits: number) : Promise<string|void> {
-    return new Promise((resolve, reject) => {
-        lib.generateKey(password, identifier, bits, (error: string|void, result: string|void) => {
-            if (error) {
-                reject(error);
-            } else {
-                resolve(result);
-            }
-        });
-    });
+const generateKey = async function(password: string, identifier: string, bits: number) : Promise<string> {
+    return nativeCall<string>(KeyGenerationError, lib.generateKey, password, identifier, bits);
 };
 
 /**
@@ -149,7 +252,7 @@ const generateKey = async function(password: string, identifier: string, bits: n
  * @param {string} fingerprint - Fingerprint to fetch the public key
  * @returns {string} - The public key
  */
-const getPublicKey = (fingerprint: string) : string => lib.getPublicKey(fingerprint);
+const getPublicKey = (fingerprint: string) : string => nativeSyncCall<string>(KeyNotFoundError, lib.getPublicKey, fingerprint);
 
 
 /**
@@ -164,18 +267,7 @@ const getPublicKey = (fingerprint: string) : string => lib.getPublicKey(fingerpr
  * @returns {Promise<string>} - The ASCII Armored PGP Signature
  */
 const quantoSignData = async function(data: string, fingerprint: string) : Promise<string> {
-    return new Promise((resolve, reject) => {
-        if (!isBase64(data)) {
-            return reject('Expected a base64 encoded data');
-        }
-        lib.quantoSignData(data, fingerprint, (error: string|void, result: any|void) => {
-            if (error) {
-                return reject(error);
-            }
-
-            return resolve(result);
-        });
-    });
+    return nativeCall<string>(SignatureError, lib.quantoSignData, base64Arg(data), fingerprint);
 };
 
 /**
@@ -188,22 +280,8 @@ const quantoSignData = async function(data: string, fingerprint: string) : Promi
  * @param {string} signature - A ASCII Armored Format or Base64 Encoded Binary PGP Signature
  * @returns {Promise<boolean>}
  */
-const quantoVerifySignature = async function(data: string, signature: string) : Promise<boolean|void> {
-    return new Promise((resolve, reject) => {
-        if (!isBase64(data)) {
-            return reject('Expected a base64 encoded data');
-        }
-        lib.quantoVerifySignature(data, signature, (error: string|void, result: boolean|void) => {
-            if (error) {
-                if (error.indexOf('invalid signature') > -1) {
-                    return resolve(false);
-                }
-                return reject(error);
-            }
-
-            return resolve(result);
-        });
-    });
+const quantoVerifySignature = async function(data: string, signature: string) : Promise<boolean> {
+    return verify(lib.quantoVerifySignature, data, signature);
 };
 
 /**
@@ -214,22 +292,110 @@ const quantoVerifySignature = async function(data: string, signature: string) :
  * @param {string} newPassword - The new password for the key
  * @returns {Promise<string>} the same private key encrypted with the newPassword
  */
-const changeKeyPassword = async function(keyData: string, currentPassword: string, newPassword: string): Promise<string|void> {
-    return new Promise((resolve, reject) => {
-        lib.changeKeyPassword(keyData, currentPassword, newPassword, (error: string|void, result: string|void) => {
-            if (error) {
-                return reject(error);
-            }
+const changeKeyPassword = async function(keyData: string, currentPassword: string, newPassword: string): Promise<string> {
+    return nativeCall<string>(KeyPasswordError, lib.changeKeyPassword, keyData, currentPassword, newPassword);
+};
 
-            return resolve(result);
-        });
-    });
+/**
+ * Encrypts the specified data to a pre-loaded public key specified by fingerprint
+ *
+ * The data should be always encoded as base64
+ *
+ * @param {string} data - Base64 Encoded Data to be encrypted
+ * @param {string} fingerprint - Fingerprint of the key to encrypt to
+ * @param {string} filename - Filename stored in the encrypted message
+ * @returns {Promise<string>} - The ASCII Armored PGP Message
+ */
+const encrypt = async function(data: string, fingerprint: string, filename = '') : Promise<string> {
+    return nativeCall<string>(EncryptionError, lib.encrypt, base64Arg(data), filename, fingerprint);
+};
+
+/**
+ * Decrypts a ASCII Armored PGP Message using any pre-loaded and pre-unlocked private key
+ *
+ * @param {string} encryptedData - The ASCII Armored PGP Message
+ * @returns {Promise<DecryptedData>} - The Base64 Encoded decrypted data and the filename stored in the message
+ */
+const decrypt = async function(encryptedData: string) : Promise<DecryptedData> {
+    const result = await nativeCall<{ data: Buffer; filename: string }>(DecryptionError, lib.decrypt, encryptedData);
+    return {
+        data: result.data.toString('base64'),
+        filename: result.filename,
+    };
+};
+
+/**
+ * Converts a GPG Signature to Quanto Signature Format
+ *
+ * @param {string} signature - The ASCII Armored PGP Signature
+ * @param {string} fingerprint - Fingerprint of the key that made the signature
+ * @param {string} hash - Hash algorithm of the signature
+ * @returns {string} - The Quanto Signature
+ */
+const gpg2quanto = (signature: string, fingerprint: string, hash = 'SHA512') : string => nativeSyncCall<string>(SignatureError, lib.gpg2quanto, signature, fingerprint, hash);
+
+/**
+ * Converts a Quanto Signature to a ASCII Armored GPG Signature
+ *
+ * @param {string} signature - The Quanto Signature
+ * @returns {string} - The ASCII Armored PGP Signature
+ */
+const quanto2gpg = (signature: string) : string => nativeSyncCall<string>(SignatureError, lib.quanto2gpg, signature);
+
+/**
+ * Encrypts the fields of a JSON object to pre-loaded public keys
+ *
+ * Field selectors are JSONPath patterns like $.customer.taxId
+ *
+ * @param {object} data - The JSON object to encrypt
+ * @param {string[]} keys - Fingerprints of the keys that can read the fields not selected by any group
+ * @param {FieldCipherOptions} options - Field selection, recipient groups, blind indexes and signature options
+ * @returns {Promise<CipherPacket>} - The encrypted packet
+ */
+const fieldCipher = async function(data: { [key: string]: any }, keys: string[], options: FieldCipherOptions = {}) : Promise<CipherPacket> {
+    const input = JSON.stringify({ ...options, JSON: data, Keys: keys });
+    const result = await nativeCall<string>(FieldCipherError, lib.fieldCipher, input);
+    return JSON.parse(result);
+};
+
+/**
+ * Decrypts a packet generated by fieldCipher using a pre-loaded and pre-unlocked private key
+ *
+ * @param {CipherPacket} packet - The encrypted packet
+ * @param {string} fingerprint - Fingerprint of the private key used to decrypt
+ * @param {boolean} partial - Return the readable fields and the status of each field instead of failing on the first bad field
+ * @returns {Promise<DecipherPacket>} - The decrypted packet
+ */
+const fieldDecipher = async function(packet: CipherPacket, fingerprint: string, partial = false) : Promise<DecipherPacket> {
+    const input = JSON.stringify({ ...packet, KeyFingerprint: fingerprint, Partial: partial });
+    const result = await nativeCall<string>(FieldCipherError, lib.fieldDecipher, input);
+    return JSON.parse(result);
 };
 
+/**
+ * Sets the secret key used to compute fieldcipher blind indexes
+ *
+ * @param {Buffer} key - The secret key. Should have at least 32 bytes
+ * @returns {boolean}
+ */
+const setBlindIndexKey = (key: Buffer) : boolean => nativeSyncCall<boolean>(FieldCipherError, lib.setBlindIndexKey, key);
+
+/**
+ * Computes the blind index of a value, to search fields encrypted with BlindIndex by equality
+ *
+ * @param {string} field - The JSONPath pattern used in BlindIndex
+ * @param {string|number|boolean|null} value - The plain text value
+ * @returns {string} - The blind index
+ */
+const computeBlindIndex = (field: string, value: string|number|boolean|null) : string => nativeSyncCall<string>(FieldCipherError, lib.computeBlindIndex, field, JSON.stringify(value));
+
 export {
+    ABI_VERSION,
+    abiVersion,
     verifySignature,
     signData,
     getKeyFingerprints,
+    getFingerprintFromKey,
     loadKey,
     unlockKey,
     generateKey,
@@ -238,4 +404,29 @@ export {
     quantoSignData,
     quantoVerifySignature,
     changeKeyPassword,
+    encrypt,
+    decrypt,
+    gpg2quanto,
+    quanto2gpg,
+    fieldCipher,
+    fieldDecipher,
+    setBlindIndexKey,
+    computeBlindIndex,
+    FieldCipherGroup,
+    FieldCipherOptions,
+    CipherPacket,
+    DecipherPacket,
+    DecryptedData,
+    ChevronError,
+    LibraryError,
+    InvalidArgumentError,
+    KeyLoadError,
+    KeyUnlockError,
+    KeyNotFoundError,
+    KeyGenerationError,
+    KeyPasswordError,
+    SignatureError,
+    EncryptionError,
+    DecryptionError,
+    FieldCipherError,
 };
@@ -1,6 +1,9 @@
 #!/usr/bin/env python3
 
+from base64 import b64decode
 from . import native
+from .native import ChevronError, LibraryError, KeyLoadError, KeyUnlockError, KeyNotFoundError, KeyGenerationError, \
+  KeyPasswordError, SignatureError, EncryptionError, DecryptionError, FieldCipherError
 
 native.init_native()
 
@@ -70,7 +73,7 @@ def verify_base64_data_signature(b64data, signature):
     @param {string} signature - A ASCII Armored Format or Base64 Encoded Binary PGP Signature
     @returns {boolean}
   '''
-  return native.verify_base64_data_signature(b64data, signature)
+  return native.verify_signature(b64decode(b64data), signature)
 
 def quanto_verify_base64_data_signature(b64data, signature):
   '''
@@ -82,7 +85,7 @@ def quanto_verify_base64_data_signature(b64data, signature):
     @param {string} signature - A ASCII Armored Format or Base64 Encoded Binary PGP Signature
     @returns {boolean}
   '''
-  return native.quanto_verify_base64_data_signature(b64data, signature)
+  return native.quanto_verify_signature(b64decode(b64data), signature)
 
 def sign_data(data, fingerprint):
   '''
@@ -116,7 +119,7 @@ def sign_base64_data(data, fingerprint):
     @param {string} fingerprint - Fingerprint of the key used to sign data
     @returns {string} - The ASCII Armored PGP Signature
   '''
-  return native.sign_base64_data(data, fingerprint)
+  return native.sign_data(b64decode(data), fingerprint)
 
 def quanto_sign_base64_data(data, fingerprint):
   '''
@@ -128,7 +131,7 @@ def quanto_sign_base64_data(data, fingerprint):
     @param {string} fingerprint - Fingerprint of the key used to sign data
     @returns {string} - The ASCII Armored PGP Signature
   '''
-  return native.quanto_sign_base64_data(data, fingerprint)
+  return native.quanto_sign_data(b64decode(data), fingerprint)
 
 def change_key_password(key_data, current_password, new_password):
   '''
@@ -168,3 +171,114 @@ def get_public_key(fingerprint):
     @returns {string} - The public key
   '''
   return native.get_public_key(fingerprint)
+
+def get_fingerprint_from_key(ascii_armored_key):
+  '''
+    Returns the main fingerprint of the specified key
+
+    @param {string} ascii_armored_key - The private / public key in ASCII Armored Format
+    @returns {string} the main fingerprint of the key
+  '''
+  return native.get_fingerprint_from_key(ascii_armored_key)
+
+def encrypt(data, fingerprint, filename=""):
+  '''
+    Encrypts data to a pre-loaded public key specified by fingerprint
+
+    @param {bytes|string} data - Data to be encrypted
+    @param {string} fingerprint - Fingerprint of the key to encrypt to
+    @param {string} filename - Filename stored in the encrypted message
+    @returns {string} - The ASCII Armored PGP Message
+  '''
+  return native.encrypt(data, fingerprint, filename)
+
+def decrypt(encrypted_data):
+  '''
+    Decrypts a ASCII Armored PGP Message using any pre-loaded and pre-unlocked private key
+
+    @param {string} encrypted_data - The ASCII Armored PGP Message
+    @returns {(bytes, string)} - The decrypted data and the filename stored in the message
+  '''
+  return native.decrypt(encrypted_data)
+
+def gpg_to_quanto(signature, fingerprint, hash_name="SHA512"):
+  '''
+    Converts a GPG Signature to Quanto Signature Format
+
+    @param {string} signature - The ASCII Armored PGP Signature
+    @param {string} fingerprint - Fingerprint of the key that made the signature
+    @param {string} hash_name - Hash algorithm of the signature
+    @returns {string} - The Quanto Signature
+  '''
+  return native.gpg_to_quanto(signature, fingerprint, hash_name)
+
+def quanto_to_gpg(signature):
+  '''
+    Converts a Quanto Signature to a ASCII Armored GPG Signature
+
+    @param {string} signature - The Quanto Signature
+    @returns {string} - The ASCII Armored PGP Signature
+  '''
+  return native.quanto_to_gpg(signature)
+
+def field_cipher(data, keys, skip_fields=None, include=None, exclude=None, groups=None, blind_index=None, sign_with="", version=0):
+  '''
+    Encrypts the fields of a JSON object to pre-loaded public keys
+
+    Groups are dicts with Name, Keys and Fields. Fields selectors are JSONPath patterns like $.customer.taxId
+
+    @param {dict} data - The JSON object to encrypt
+    @param {string[]} keys - Fingerprints of the keys that can read the fields not selected by any group
+    @param {string[]} skip_fields - Fields to keep in plain text. Cannot be used with include, exclude or groups
+    @param {string[]} include - JSONPath patterns of the fields to encrypt. Every field is encrypted if empty
+    @param {string[]} exclude - JSONPath patterns of the fields to keep in plain text
+    @param {dict[]} groups - Recipient groups that are the only ones able to read their fields
+    @param {string[]} blind_index - JSONPath patterns of the fields that get a blind index. Requires set_blind_index_key
+    @param {string} sign_with - Fingerprint of a pre-unlocked private key to sign the packet
+    @param {number} version - The fieldcipher format version. 0 for the current one
+    @returns {dict} - The encrypted packet
+  '''
+  return native.field_cipher({
+    "JSON": data,
+    "Keys": keys,
+    "SkipFields": skip_fields or [],
+    "Include": include or [],
+    "Exclude": exclude or [],
+    "Groups": groups or [],
+    "BlindIndex": blind_index or [],
+    "SignWith": sign_with,
+    "Version": version,
+  })
+
+def field_decipher(packet, fingerprint, partial=False):
+  '''
+    Decrypts a packet generated by field_cipher using a pre-loaded and pre-unlocked private key
+
+    @param {dict} packet - The encrypted packet
+    @param {string} fingerprint - Fingerprint of the private key used to decrypt
+    @param {boolean} partial - Return the readable fields and the status of each field instead of failing on the first bad field
+    @returns {dict} - The decrypted packet
+  '''
+  decipher_input = dict(packet)
+  decipher_input["KeyFingerprint"] = fingerprint
+  decipher_input["Partial"] = partial
+  return native.field_decipher(decipher_input)
+
+def set_blind_index_key(key):
+  '''
+    Sets the secret key used to compute fieldcipher blind indexes
+
+    @param {bytes} key - The secret key. Should have at least 32 bytes
+    @returns True
+  '''
+  return native.set_blind_index_key(key)
+
+def compute_blind_index(field, value):
+  '''
+    Computes the blind index of a value, to search fields encrypted with blind_index by equality
+
+    @param {string} field - The JSONPath pattern used in blind_index
+    @param {string|number|boolean|None} value - The plain text value
+    @returns {string} - The blind index
+  '''
+  return native.compute_blind_index(field, value)
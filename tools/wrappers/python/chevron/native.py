@@ -1,17 +1,23 @@
 #!/usr/bin/env python3
 #
 import os
+import json
 from ctypes import *
 from sys import platform
 
 TRUE = 1
 FALSE = 0
 OK = TRUE
-BUFFER_SIZE = 4096
+ERROR = -1
+# ERROR_KEY_NOT_FOUND is returned when a key is not loaded in the memory keyring
+ERROR_KEY_NOT_FOUND = -2
+
+# ABI_VERSION is the ChevronLib C ABI version this wrapper was written for
+ABI_VERSION = 1
 
 native_handler = None
 
-# region Structures
+# region Errors
 
 class ChevronError(Exception):
   '''
@@ -21,11 +27,55 @@ class ChevronError(Exception):
     self.message = message
     super().__init__(self.message)
 
-class loadkey_return(Structure):
+class LibraryError(ChevronError):
+  '''
+  Exception raised when the shared library cannot be loaded or is incompatible
+  '''
+
+class KeyLoadError(ChevronError):
+  '''
+  Exception raised when a key cannot be loaded or parsed
+  '''
+
+class KeyUnlockError(ChevronError):
+  '''
+  Exception raised when a private key cannot be unlocked
+  '''
+
+class KeyNotFoundError(ChevronError):
+  '''
+  Exception raised when a key is not loaded in the memory keyring
+  '''
+
+class KeyGenerationError(ChevronError):
+  '''
+  Exception raised when a key cannot be generated
+  '''
+
+class KeyPasswordError(ChevronError):
+  '''
+  Exception raised when the password of a key cannot be changed
+  '''
+
+class SignatureError(ChevronError):
   '''
-  LoadKey_return struct
+  Exception raised when data cannot be signed
+  '''
+
+class EncryptionError(ChevronError):
+  '''
+  Exception raised when data cannot be encrypted
+  '''
+
+class DecryptionError(ChevronError):
+  '''
+  Exception raised when data cannot be decrypted
+  '''
+
+class FieldCipherError(ChevronError):
+  '''
+  Exception raised when a fieldcipher operation fails
   '''
-  _fields_ = [('err', c_int), ('loadedPrivateKeys', c_int)]
 
 # endregion
 
@@ -41,7 +91,7 @@ def init_native():
   if platform.startswith('win32'):
     # Windows has two different DLL, one for 32 bit and one for 64 bit
     # No one should be using that in 32 bit windows, but who knows
-    if platform.architecture()[0] == "32bit":
+    if sizeof(c_void_p) == 4:
       shared_library_path = "./chevron32.dll"
     else:
       shared_library_path = "./chevron.dll"
@@ -55,305 +105,341 @@ def init_native():
     libbasepath = os.path.dirname(__file__)
     native_handler = CDLL(os.path.join(libbasepath, shared_library_path))
   except Exception as e:
-    raise ChevronError("cannot load shared library %s: %s" % (shared_library_path, e))
+    raise LibraryError("cannot load shared library %s: %s" % (shared_library_path, e))
 
-  native_handler.GetKeyFingerprints.restype=c_int
-  native_handler.GetKeyFingerprints.argtypes=[c_char_p,c_char_p,c_int]
+  try:
+    native_handler.ChevronVersion.restype=c_int
+    native_handler.ChevronVersion.argtypes=[]
+  except AttributeError:
+    raise LibraryError("shared library %s does not export the Chevron C ABI" % shared_library_path)
 
-  native_handler.LoadKey.restype=loadkey_return
-  native_handler.LoadKey.argtypes=[c_char_p,c_char_p,c_int]
+  version = native_handler.ChevronVersion()
+  if version != ABI_VERSION:
+    raise LibraryError("shared library %s has ABI version %d, expected %d" % (shared_library_path, version, ABI_VERSION))
 
-  native_handler.UnlockKey.restype=c_int
-  native_handler.UnlockKey.argtypes=[c_char_p,c_char_p,c_char_p,c_int]
+  result_p = POINTER(c_void_p)
 
-  native_handler.VerifySignature.restype=c_int
-  native_handler.VerifySignature.argtypes=[c_char_p,c_int,c_char_p,c_char_p,c_int]
+  native_handler.ChevronFree.restype=None
+  native_handler.ChevronFree.argtypes=[c_void_p]
 
-  native_handler.QuantoVerifySignature.restype=c_int
-  native_handler.QuantoVerifySignature.argtypes=[c_char_p,c_int,c_char_p,c_char_p,c_int]
+  native_handler.ChevronLoadKey.restype=c_int
+  native_handler.ChevronLoadKey.argtypes=[c_char_p,POINTER(c_int),result_p]
 
-  native_handler.VerifyBase64DataSignature.restype=c_int
-  native_handler.VerifyBase64DataSignature.argtypes=[c_char_p,c_char_p,c_char_p,c_int]
+  native_handler.ChevronUnlockKey.restype=c_int
+  native_handler.ChevronUnlockKey.argtypes=[c_char_p,c_char_p,result_p]
 
-  native_handler.QuantoVerifyBase64DataSignature.restype=c_int
-  native_handler.QuantoVerifyBase64DataSignature.argtypes=[c_char_p,c_char_p,c_char_p,c_int]
+  native_handler.ChevronVerifySignature.restype=c_int
+  native_handler.ChevronVerifySignature.argtypes=[c_char_p,c_int,c_char_p,result_p]
 
-  native_handler.SignData.restype=c_int
-  native_handler.SignData.argtypes=[c_char_p,c_int,c_char_p,c_char_p,c_int]
+  native_handler.ChevronQuantoVerifySignature.restype=c_int
+  native_handler.ChevronQuantoVerifySignature.argtypes=[c_char_p,c_int,c_char_p,result_p]
 
-  native_handler.QuantoSignData.restype=c_int
-  native_handler.QuantoSignData.argtypes=[c_char_p,c_int,c_char_p,c_char_p,c_int]
+  native_handler.ChevronSignData.restype=c_int
+  native_handler.ChevronSignData.argtypes=[c_char_p,c_int,c_char_p,result_p,result_p]
 
-  native_handler.SignBase64Data.restype=c_int
-  native_handler.SignBase64Data.argtypes=[c_char_p,c_char_p,c_char_p,c_int]
+  native_handler.ChevronQuantoSignData.restype=c_int
+  native_handler.ChevronQuantoSignData.argtypes=[c_char_p,c_int,c_char_p,result_p,result_p]
 
-  native_handler.QuantoSignBase64Data.restype=c_int
-  native_handler.QuantoSignBase64Data.argtypes=[c_char_p,c_char_p,c_char_p,c_int]
+  native_handler.ChevronEncrypt.restype=c_int
+  native_handler.ChevronEncrypt.argtypes=[c_char_p,c_int,c_char_p,c_char_p,result_p,result_p]
 
-  native_handler.ChangeKeyPassword.restype=c_int
-  native_handler.ChangeKeyPassword.argtypes=[c_char_p,c_char_p,c_char_p,c_char_p,c_int]
+  native_handler.ChevronDecrypt.restype=c_int
+  native_handler.ChevronDecrypt.argtypes=[c_char_p,result_p,POINTER(c_int),result_p,result_p]
 
-  native_handler.GetPublicKey.restype=c_int
-  native_handler.GetPublicKey.argtypes=[c_char_p,c_char_p,c_int]
+  native_handler.ChevronGenerateKey.restype=c_int
+  native_handler.ChevronGenerateKey.argtypes=[c_char_p,c_char_p,c_int,result_p,result_p]
 
-  native_handler.GenerateKey.restype=c_int
-  native_handler.GenerateKey.argtypes=[c_char_p,c_char_p,c_int,c_char_p,c_int]
+  native_handler.ChevronGetKeyFingerprints.restype=c_int
+  native_handler.ChevronGetKeyFingerprints.argtypes=[c_char_p,result_p,result_p]
 
-# endregion
+  native_handler.ChevronChangeKeyPassword.restype=c_int
+  native_handler.ChevronChangeKeyPassword.argtypes=[c_char_p,c_char_p,c_char_p,result_p,result_p]
 
-# region Calls
+  native_handler.ChevronGetPublicKey.restype=c_int
+  native_handler.ChevronGetPublicKey.argtypes=[c_char_p,result_p,result_p]
 
-def get_key_fingerprints(ascii_armored_key):
-  '''
-  get_key_fingerprints returns all fingerprints in CSV format from a ASCII Armored PGP Keychain
+  native_handler.ChevronGetFingerprintFromKey.restype=c_int
+  native_handler.ChevronGetFingerprintFromKey.argtypes=[c_char_p,result_p,result_p]
 
-  extern int GetKeyFingerprints(char* key_data, char* result, int resultLen);
-  '''
-  global native_handler
-  buff = create_string_buffer(BUFFER_SIZE)
+  native_handler.ChevronGPG2Quanto.restype=c_int
+  native_handler.ChevronGPG2Quanto.argtypes=[c_char_p,c_char_p,c_char_p,result_p]
 
-  result = int(native_handler.GetKeyFingerprints(c_char_p(ascii_armored_key.encode('utf-8')), buff, len(buff)))
-  buff = str(buff.value.decode("utf-8"))
-  if result != OK:
-    raise ChevronError(buff)
+  native_handler.ChevronQuanto2GPG.restype=c_int
+  native_handler.ChevronQuanto2GPG.argtypes=[c_char_p,result_p]
 
-  return buff.split(",")
+  native_handler.ChevronFieldCipher.restype=c_int
+  native_handler.ChevronFieldCipher.argtypes=[c_char_p,result_p,result_p]
 
+  native_handler.ChevronFieldDecipher.restype=c_int
+  native_handler.ChevronFieldDecipher.argtypes=[c_char_p,result_p,result_p]
 
-def load_key(key_data):
-  '''
-  load_key loads a private or public key into the memory keyring
+  native_handler.ChevronSetBlindIndexKey.restype=c_int
+  native_handler.ChevronSetBlindIndexKey.argtypes=[c_char_p,c_int,result_p]
 
-  extern struct loadkey_return LoadKey(char* key_data, char* result, int resultLen);
-  '''
-  global native_handler
+  native_handler.ChevronComputeBlindIndex.restype=c_int
+  native_handler.ChevronComputeBlindIndex.argtypes=[c_char_p,c_char_p,result_p,result_p]
 
-  buff = create_string_buffer(BUFFER_SIZE)
+# endregion
 
-  result = native_handler.LoadKey(c_char_p(key_data.encode('utf-8')), buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  if result.err != OK:
-    raise ChevronError(buff)
+# region Helpers
 
-  return result.loadedPrivateKeys
+def _to_bytes(data):
+  if isinstance(data, (bytes, bytearray)):
+    return bytes(data)
+  return data.encode('utf-8')
 
-def unlock_key(fingerprint, password):
+def _take_bytes(ptr, length=None):
   '''
-  unlock_key unlocks a private key to be used
-
-  extern int UnlockKey(char* fingerprint, char* password, char* result, int resultLen);
+  _take_bytes copies a library allocated buffer and releases it
   '''
-  global native_handler
+  if not ptr.value:
+    return b''
+  try:
+    if length is None:
+      return string_at(ptr.value)
+    return string_at(ptr.value, length)
+  finally:
+    native_handler.ChevronFree(ptr)
 
-  buff = create_string_buffer(BUFFER_SIZE)
+def _take_string(ptr):
+  return _take_bytes(ptr).decode('utf-8')
 
-  result = native_handler.UnlockKey(c_char_p(fingerprint.encode('utf-8')), c_char_p(password.encode('utf-8')), buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  if result != OK:
-    raise ChevronError(buff)
+def _call(error_class, fn, *args):
+  '''
+  _call calls fn with args plus a result and an error output pointer and returns the result as string
+  '''
+  result = c_void_p()
+  err = c_void_p()
 
-  return True
+  r = fn(*args, byref(result), byref(err))
+  if r < 0:
+    raise _error(r, _take_string(err), error_class)
 
-def verify_signature(data, signature):
+  return _take_string(result)
+
+def _error(code, message, default_class):
   '''
-  VerifySignature verifies a signature using a already loaded public key
+  _error returns the exception of a failed call by its error code
+  '''
+  if code == ERROR_KEY_NOT_FOUND:
+    return KeyNotFoundError(message)
+  return default_class(message)
 
-  extern int VerifySignature(char* data, int dataLen, char* signature, char* result, int resultLen);
+# endregion
+
+# region Calls
+
+def abi_version():
   '''
-  global native_handler
+  abi_version returns the C ABI version of the loaded shared library
 
+  extern int ChevronVersion();
+  '''
+  return native_handler.ChevronVersion()
 
-  buff = create_string_buffer(BUFFER_SIZE)
+def get_key_fingerprints(ascii_armored_key):
+  '''
+  get_key_fingerprints returns all fingerprints from a ASCII Armored PGP Keychain
 
-  data_p = c_char_p(data.encode('utf-8'))
-  signature = c_char_p(signature.encode('utf-8'))
+  extern int ChevronGetKeyFingerprints(char* keyData, char** result, char** err);
+  '''
+  result = _call(KeyLoadError, native_handler.ChevronGetKeyFingerprints, _to_bytes(ascii_armored_key))
+  return result.split(",")
 
-  result = native_handler.VerifySignature(data_p, len(data), signature, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  return result == 1, buff
+def get_fingerprint_from_key(ascii_armored_key):
+  '''
+  get_fingerprint_from_key returns the main fingerprint of a ASCII Armored PGP Key
 
-def quanto_verify_signature(data, signature):
+  extern int ChevronGetFingerprintFromKey(char* keyData, char** result, char** err);
   '''
-  QuantoVerifySignature verifies a signature in Quanto Signature Format using a already loaded public key
+  return _call(KeyLoadError, native_handler.ChevronGetFingerprintFromKey, _to_bytes(ascii_armored_key))
 
-  extern int QuantoVerifySignature(char* data, int dataLen, char* signature, char* result, int resultLen);
+def load_key(key_data):
   '''
-  global native_handler
+  load_key loads a private or public key into the memory keyring
 
-  buff = create_string_buffer(BUFFER_SIZE)
+  extern int ChevronLoadKey(char* keyData, int* loadedPrivateKeys, char** err);
+  '''
+  loaded = c_int(0)
+  err = c_void_p()
 
-  data_p = c_char_p(data.encode('utf-8'))
-  signature = c_char_p(signature.encode('utf-8'))
+  result = native_handler.ChevronLoadKey(_to_bytes(key_data), byref(loaded), byref(err))
+  if result < 0:
+    raise _error(result, _take_string(err), KeyLoadError)
 
-  result = native_handler.QuantoVerifySignature(data_p, len(data), signature, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  return result == 1, buff
+  return loaded.value
 
-def verify_base64_data_signature(b64data, signature):
+def unlock_key(fingerprint, password):
   '''
-  verify_base64_data_signature verifies a signature using a already loaded public key. The b64data is a raw binary data encoded in base64 string
+  unlock_key unlocks a private key to be used
 
-  extern int VerifyBase64DataSignature(char* b64data, char* signature, char* result, int resultLen);
+  extern int ChevronUnlockKey(char* fingerprint, char* password, char** err);
   '''
-  global native_handler
-  buff = create_string_buffer(BUFFER_SIZE)
+  err = c_void_p()
 
-  data_p = c_char_p(b64data)
-  signature = c_char_p(signature.encode('utf-8'))
+  result = native_handler.ChevronUnlockKey(_to_bytes(fingerprint), _to_bytes(password), byref(err))
+  if result < 0:
+    raise _error(result, _take_string(err), KeyUnlockError)
+
+  return True
 
-  result = native_handler.VerifyBase64DataSignature(data_p, signature, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  return result == 1, buff
+def _verify(fn, data, signature):
+  err = c_void_p()
+  data = _to_bytes(data)
 
-def quanto_verify_base64_data_signature(b64data, signature):
+  result = fn(data, len(data), _to_bytes(signature), byref(err))
+  if result == ERROR_KEY_NOT_FOUND:
+    raise KeyNotFoundError(_take_string(err))
+
+  # Invalid signatures return FALSE with the reason in err
+  return result == TRUE, _take_string(err)
+
+def verify_signature(data, signature):
   '''
-  quanto_verify_base64_data_signature verifies a signature in Quanto Signature Format using a already loaded public key.
-  The b64data is a raw binary data encoded in base64 string
+  verify_signature verifies a signature using a already loaded public key.
+  Returns if the signature is valid and the reason when it is not
 
-  extern int QuantoVerifyBase64DataSignature(char* b64data, char* signature, char* result, int resultLen);
+  extern int ChevronVerifySignature(char* data, int dataLen, char* signature, char** err);
   '''
-  global native_handler
-  buff = create_string_buffer(BUFFER_SIZE)
+  return _verify(native_handler.ChevronVerifySignature, data, signature)
 
-  data_p = c_char_p(b64data)
-  signature = c_char_p(signature.encode('utf-8'))
+def quanto_verify_signature(data, signature):
+  '''
+  quanto_verify_signature verifies a signature in Quanto Signature Format using a already loaded public key.
+  Returns if the signature is valid and the reason when it is not
 
-  result = native_handler.QuantoVerifyBase64DataSignature(data_p, signature, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  return result == 1, buff
+  extern int ChevronQuantoVerifySignature(char* data, int dataLen, char* signature, char** err);
+  '''
+  return _verify(native_handler.ChevronQuantoVerifySignature, data, signature)
 
 def sign_data(data, fingerprint):
   '''
   sign_data signs data using a already loaded and unlocked private key
 
-  extern int SignData(char* data, int dataLen, char* fingerprint, char* result, int resultLen);
+  extern int ChevronSignData(char* data, int dataLen, char* fingerprint, char** result, char** err);
   '''
-  global native_handler
-  buff = create_string_buffer(BUFFER_SIZE)
-
-  data_p = c_char_p(data.encode('utf-8'))
-  fingerprint = c_char_p(fingerprint.encode('utf-8'))
-
-  result = native_handler.SignData(data_p, len(data), fingerprint, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  if result != OK:
-    raise ChevronError(buff)
-
-  return buff
+  data = _to_bytes(data)
+  return _call(SignatureError, native_handler.ChevronSignData, data, len(data), _to_bytes(fingerprint))
 
 def quanto_sign_data(data, fingerprint):
   '''
   quanto_sign_data signs data using a already loaded and unlocked private key and returns in Quanto Signature Format
 
-  extern int QuantoSignData(char* data, int dataLen, char* fingerprint, char* result, int resultLen);
+  extern int ChevronQuantoSignData(char* data, int dataLen, char* fingerprint, char** result, char** err);
   '''
-  global native_handler
-  buff = create_string_buffer(BUFFER_SIZE)
-
-  data_p = c_char_p(data.encode('utf-8'))
-  fingerprint = c_char_p(fingerprint.encode('utf-8'))
+  data = _to_bytes(data)
+  return _call(SignatureError, native_handler.ChevronQuantoSignData, data, len(data), _to_bytes(fingerprint))
 
-  result = native_handler.QuantoSignData(data_p, len(data), fingerprint, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  if result != OK:
-    raise ChevronError(buff)
-
-  return buff
+def encrypt(data, fingerprint, filename=""):
+  '''
+  encrypt encrypts data to a already loaded public key and returns it ASCII Armored
 
-def sign_base64_data(b64data, fingerprint):
+  extern int ChevronEncrypt(char* data, int dataLen, char* filename, char* fingerprint, char** result, char** err);
   '''
-  SignBase64Data signs data using a already loaded and unlocked private key.
-  The b64data is a raw binary data encoded in base64 string
+  data = _to_bytes(data)
+  return _call(EncryptionError, native_handler.ChevronEncrypt, data, len(data), _to_bytes(filename), _to_bytes(fingerprint))
 
-  extern int SignBase64Data(char* b64data, char* fingerprint, char* result, int resultLen);
+def decrypt(encrypted_data):
   '''
-  global native_handler
-  buff = create_string_buffer(BUFFER_SIZE)
+  decrypt decrypts a ASCII Armored message using a already loaded and unlocked private key.
+  Returns the decrypted bytes and the filename stored in the message
 
-  data_p = c_char_p(b64data)
-  fingerprint = c_char_p(fingerprint.encode('utf-8'))
+  extern int ChevronDecrypt(char* encryptedData, char** result, int* resultLen, char** filename, char** err);
+  '''
+  result = c_void_p()
+  result_len = c_int(0)
+  filename = c_void_p()
+  err = c_void_p()
 
-  result = native_handler.SignBase64Data(data_p, fingerprint, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  if result != OK:
-    raise ChevronError(buff)
+  r = native_handler.ChevronDecrypt(_to_bytes(encrypted_data), byref(result), byref(result_len), byref(filename), byref(err))
+  if r < 0:
+    raise _error(r, _take_string(err), DecryptionError)
 
-  return buff
+  return _take_bytes(result, result_len.value), _take_string(filename)
 
-def quanto_sign_base64_data(b64data, fingerprint):
+def change_key_password(key_data, current_password, new_password):
   '''
-  quanto_sign_base64_data signs data using a already loaded and unlocked private key. Returns in Quanto Signature Format
-  The b64data is a raw binary data encoded in base64 string
+  change_key_password re-encrypts the input key using new_password
 
-  extern int QuantoSignBase64Data(char* b64data, char* fingerprint, char* result, int resultLen);
+  extern int ChevronChangeKeyPassword(char* keyData, char* currentPassword, char* newPassword, char** result, char** err);
   '''
-  global native_handler
-  buff = create_string_buffer(BUFFER_SIZE)
+  return _call(KeyPasswordError, native_handler.ChevronChangeKeyPassword, _to_bytes(key_data), _to_bytes(current_password), _to_bytes(new_password))
 
-  data_p = c_char_p(b64data)
-  fingerprint = c_char_p(fingerprint.encode('utf-8'))
-
-  result = native_handler.QuantoSignBase64Data(data_p, fingerprint, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  if result != OK:
-    raise ChevronError(buff)
+def get_public_key(fingerprint):
+  '''
+  get_public_key returns the cached public key from the specified fingerprint
 
-  return buff
+  extern int ChevronGetPublicKey(char* fingerprint, char** result, char** err);
+  '''
+  return _call(KeyNotFoundError, native_handler.ChevronGetPublicKey, _to_bytes(fingerprint))
 
-def change_key_password(key_data, current_password, new_password):
+def generate_key(password, identifier, bits):
   '''
-  change_key_password re-encrypts the input key using new_password
+  generate_key generates a new key using specified bits and identifier and encrypts it using the specified password
 
-  extern int ChangeKeyPassword(char* key_data, char* current_password, char* new_password, char* result, int resultLen);
+  extern int ChevronGenerateKey(char* password, char* identifier, int bits, char** result, char** err);
   '''
-  global native_handler
-  buff = create_string_buffer(BUFFER_SIZE)
+  return _call(KeyGenerationError, native_handler.ChevronGenerateKey, _to_bytes(password), _to_bytes(identifier), bits)
 
-  key_data = c_char_p(key_data.encode('utf-8'))
-  current_password = c_char_p(current_password.encode('utf-8'))
-  new_password = c_char_p(new_password.encode('utf-8'))
+def gpg_to_quanto(signature, fingerprint, hash_name):
+  '''
+  gpg_to_quanto converts a GPG Signature to Quanto Signature Format
 
-  result = native_handler.ChangeKeyPassword(key_data, current_password, new_password, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  if result != OK:
-    raise ChevronError(buff)
+  extern int ChevronGPG2Quanto(char* signature, char* fingerprint, char* hash, char** result);
+  '''
+  result = c_void_p()
+  native_handler.ChevronGPG2Quanto(_to_bytes(signature), _to_bytes(fingerprint), _to_bytes(hash_name), byref(result))
+  return _take_string(result)
 
-  return buff
+def quanto_to_gpg(signature):
+  '''
+  quanto_to_gpg converts a Quanto Signature to GPG Signature
 
-def get_public_key(fingerprint):
+  extern int ChevronQuanto2GPG(char* signature, char** result);
   '''
-  get_public_key returns the cached public key from the specified fingerprint
+  result = c_void_p()
+  native_handler.ChevronQuanto2GPG(_to_bytes(signature), byref(result))
+  return _take_string(result)
 
-  extern int GetPublicKey(char* fingerprint, char* result, int resultLen);
+def field_cipher(cipher_input):
   '''
-  global native_handler
-  buff = create_string_buffer(BUFFER_SIZE)
+  field_cipher encrypts JSON fields. cipher_input is a dict in the FieldCipherInput format and the result a CipherPacket dict
 
-  fingerprint = c_char_p(fingerprint.encode('utf-8'))
+  extern int ChevronFieldCipher(char* input, char** result, char** err);
+  '''
+  result = _call(FieldCipherError, native_handler.ChevronFieldCipher, _to_bytes(json.dumps(cipher_input)))
+  return json.loads(result)
 
-  result = native_handler.GetPublicKey(fingerprint, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  if result != OK:
-    raise ChevronError(buff)
+def field_decipher(decipher_input):
+  '''
+  field_decipher decrypts JSON fields. decipher_input is a dict in the FieldDecipherInput format and the result a DecipherPacket dict
 
-  return buff
+  extern int ChevronFieldDecipher(char* input, char** result, char** err);
+  '''
+  result = _call(FieldCipherError, native_handler.ChevronFieldDecipher, _to_bytes(json.dumps(decipher_input)))
+  return json.loads(result)
 
-def generate_key(password, identifier, bits):
+def set_blind_index_key(key):
   '''
-  generate_key generates a new key using specified bits and identifier and encrypts it using the specified password
+  set_blind_index_key sets the secret key used to compute fieldcipher blind indexes
 
-  extern int GenerateKey(char* password, char* identifier, int bits, char* result, int resultLen);
+  extern int ChevronSetBlindIndexKey(char* key, int keyLen, char** err);
   '''
-  global native_handler
-  buff = create_string_buffer(BUFFER_SIZE)
+  err = c_void_p()
+  key = _to_bytes(key)
+
+  result = native_handler.ChevronSetBlindIndexKey(key, len(key), byref(err))
+  if result < 0:
+    raise _error(result, _take_string(err), FieldCipherError)
 
-  password = c_char_p(password.encode('utf-8'))
-  identifier = c_char_p(identifier.encode('utf-8'))
+  return True
 
-  result = native_handler.GenerateKey(password, identifier, bits, buff, len(buff))
-  buff = str(buff.value.decode("utf-8"))
-  if result != OK:
-    raise ChevronError(buff)
+def compute_blind_index(field, value):
+  '''
+  compute_blind_index returns the blind index of value for the field selected by the JSONPath pattern
 
-  return buff
-# endregion
\ No newline at end of file
+  extern int ChevronComputeBlindIndex(char* field, char* value, char** result, char** err);
+  '''
+  return _call(FieldCipherError, native_handler.ChevronComputeBlindIndex, _to_bytes(field), _to_bytes(json.dumps(value)))
+
+# endregion
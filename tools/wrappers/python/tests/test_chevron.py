@@ -93,3 +93,84 @@ def test_get_public_key():
   assert len(public_key) > 0
   assert len(fps) > 0
   assert fps[0] == TestKeyFingerprint
+
+def test_abi_version():
+  assert native.abi_version() == native.ABI_VERSION
+
+def test_get_fingerprint_from_key():
+  assert get_fingerprint_from_key(TestKey) == TestKeyFingerprint
+
+def test_typed_errors():
+  try:
+    load_key("HUEBR")
+    assert False, "expected KeyLoadError"
+  except KeyLoadError:
+    pass
+
+  load_key(TestKey)
+  try:
+    unlock_key(TestKeyFingerprint, TestKeyPassword + "HUEBR")
+    assert False, "expected KeyUnlockError"
+  except KeyUnlockError as e:
+    assert isinstance(e, ChevronError)
+
+  try:
+    encrypt(PayloadToSign, "0000000000000000")
+    assert False, "expected KeyNotFoundError"
+  except KeyNotFoundError:
+    pass
+
+  try:
+    decrypt("HUEBR")
+    assert False, "expected DecryptionError"
+  except DecryptionError:
+    pass
+
+def test_encrypt_decrypt():
+  load_key(TestKey)
+  unlock_key(TestKeyFingerprint, TestKeyPassword)
+  data = bytes(range(256)) * 1024
+  encrypted = encrypt(data, TestKeyFingerprint, "test.bin")
+  assert "PGP MESSAGE" in encrypted
+  decrypted, filename = decrypt(encrypted)
+  assert decrypted == data
+  assert filename == "test.bin"
+
+def test_sign_large_data():
+  load_key(TestKey)
+  unlock_key(TestKeyFingerprint, TestKeyPassword)
+  data = "A" * 1024 * 1024
+  signature = sign_data(data, TestKeyFingerprint)
+  assert verify_signature(data, signature)[0] == True
+
+def test_signature_converters():
+  load_key(TestKey)
+  gpg_signature = quanto_to_gpg(TestQuantoSignature)
+  assert "PGP SIGNATURE" in gpg_signature
+  assert verify_signature(PayloadToSign, gpg_signature)[0] == True
+  assert gpg_to_quanto(gpg_signature, TestKeyFingerprint, "SHA512") == TestQuantoSignature
+
+def test_field_cipher():
+  load_key(TestKey)
+  unlock_key(TestKeyFingerprint, TestKeyPassword)
+  data = {"name": "huebr", "taxId": "12345678900", "age": 30}
+  packet = field_cipher(data, [TestKeyFingerprint], exclude=["$.name"], sign_with=TestKeyFingerprint)
+  assert packet["EncryptedJSON"]["name"] == "huebr"
+  assert packet["EncryptedJSON"]["taxId"] != "12345678900"
+
+  decrypted = field_decipher(packet, TestKeyFingerprint)
+  assert decrypted["DecryptedData"] == data
+  assert decrypted["SignatureValid"] == True
+  assert decrypted["SignerFingerprint"] == TestKeyFingerprint
+
+  try:
+    field_cipher(data, ["0000000000000000"])
+    assert False, "expected FieldCipherError"
+  except FieldCipherError:
+    pass
+
+def test_blind_index():
+  load_key(TestKey)
+  set_blind_index_key(b"k" * 32)
+  packet = field_cipher({"taxId": "12345678900"}, [TestKeyFingerprint], blind_index=["$.taxId"])
+  assert packet["EncryptedJSON"]["taxId_bidx"] == compute_blind_index("$.taxId", "12345678900")